@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/developer-mesh/developer-mesh/apps/edge-mcp/internal/config"
+)
+
+// Validation check statuses reported by --dry-run
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// validationCheck is the outcome of a single startup step
+type validationCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// validationReport collects the outcome of each startup step in --dry-run mode
+type validationReport struct {
+	Version string            `json:"version"`
+	Valid   bool              `json:"valid"`
+	Checks  []validationCheck `json:"checks"`
+}
+
+func newValidationReport() *validationReport {
+	return &validationReport{Version: version, Valid: true}
+}
+
+func (r *validationReport) add(name, status, message string) {
+	if status == checkFail {
+		r.Valid = false
+	}
+	r.Checks = append(r.Checks, validationCheck{Name: name, Status: status, Message: message})
+}
+
+func (r *validationReport) pass(name, message string) { r.add(name, checkPass, message) }
+func (r *validationReport) warn(name, message string) { r.add(name, checkWarn, message) }
+func (r *validationReport) fail(name string, err error) {
+	r.add(name, checkFail, err.Error())
+}
+func (r *validationReport) skip(name, message string) { r.add(name, checkSkip, message) }
+
+// write prints the report as JSON and returns the process exit code
+func (r *validationReport) write(w io.Writer) int {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil || !r.Valid {
+		return 1
+	}
+	return 0
+}
+
+// validateTLS checks that the configured server certificate can be loaded and that
+// mutual TLS has a certificate to serve with
+func validateTLS(cfg config.ServerConfig, clientCertAuth bool, report *validationReport) {
+	switch {
+	case cfg.TLSCertFile == "" && cfg.TLSKeyFile == "":
+		if clientCertAuth {
+			report.fail("tls", errors.New("client certificate authentication requires EDGE_MCP_TLS_CERT_FILE and EDGE_MCP_TLS_KEY_FILE"))
+			return
+		}
+		report.skip("tls", "TLS not configured")
+	case cfg.TLSCertFile == "" || cfg.TLSKeyFile == "":
+		report.fail("tls", errors.New("both EDGE_MCP_TLS_CERT_FILE and EDGE_MCP_TLS_KEY_FILE must be set"))
+	default:
+		if _, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			report.fail("tls", err)
+			return
+		}
+		report.pass("tls", cfg.TLSCertFile)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/developer-mesh/developer-mesh/apps/edge-mcp/internal/platform"
 	"github.com/developer-mesh/developer-mesh/apps/edge-mcp/internal/tools"
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	securitytls "github.com/developer-mesh/developer-mesh/pkg/security/tls"
 	"github.com/gin-gonic/gin"
 )
 
@@ -36,6 +37,7 @@ func main() {
 		showVersion = flag.Bool("version", false, "Show version information")
 		logLevel    = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
 		stdioMode   = flag.Bool("stdio", false, "Run in stdio mode for Claude Code")
+		dryRun      = flag.Bool("dry-run", false, "Validate configuration and exit without starting the server")
 	)
 	flag.Parse()
 
@@ -83,6 +85,9 @@ func main() {
 		})
 	}
 
+	// In dry-run mode every startup step is recorded instead of aborting startup
+	report := newValidationReport()
+
 	// Load configuration
 	cfg, err := config.Load(*configFile)
 	if err != nil {
@@ -90,6 +95,9 @@ func main() {
 			"error": err.Error(),
 		})
 		cfg = config.Default()
+		report.warn("config", "could not load config file, using defaults: "+err.Error())
+	} else {
+		report.pass("config", "")
 	}
 
 	// Override with command line flags
@@ -107,6 +115,12 @@ func main() {
 		cfg.Server.Port = 8082
 	}
 
+	if cfg.Auth.APIKey == "" {
+		report.warn("api_key", "no API key configured, all requests will be accepted")
+	} else {
+		report.pass("api_key", "")
+	}
+
 	// Initialize in-memory cache (no Redis/DB dependencies)
 	memCache := cache.NewMemoryCache(1000, 5*time.Minute)
 
@@ -126,11 +140,35 @@ func main() {
 				"error": err.Error(),
 			})
 			coreClient = nil
+			report.fail("core_platform", err)
+		} else {
+			report.pass("core_platform", cfg.Core.URL)
 		}
+	} else {
+		report.skip("core_platform", "no Core Platform URL configured")
 	}
 
-	// Initialize authentication
+	// Initialize authentication, with optional mutual TLS client certificates
 	authenticator := auth.NewEdgeAuthenticator(cfg.Auth.APIKey)
+	var certAuth *securitytls.ClientCertAuthenticator
+	if cfg.Auth.ClientCAFile != "" {
+		certAuth, err = securitytls.NewClientCertAuthenticator(securitytls.ClientCertConfig{
+			Enabled:      true,
+			ClientCAFile: cfg.Auth.ClientCAFile,
+			Mode:         cfg.Auth.ClientCertMode,
+		})
+		if err != nil {
+			if !*dryRun {
+				logger.Fatal("Failed to initialize client certificate authentication", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			report.fail("client_cert_auth", err)
+		} else {
+			authenticator = auth.NewEdgeAuthenticatorWithClientCert(cfg.Auth.APIKey, certAuth)
+			report.pass("client_cert_auth", cfg.Auth.ClientCAFile)
+		}
+	}
 
 	// Initialize tool registry
 	toolRegistry := tools.NewRegistry()
@@ -149,6 +187,7 @@ func main() {
 			logger.Warn("Could not fetch remote tools", map[string]interface{}{
 				"error": err.Error(),
 			})
+			report.fail("tool_registration", err)
 		} else {
 			for _, tool := range remoteTools {
 				toolRegistry.RegisterRemote(tool)
@@ -156,7 +195,15 @@ func main() {
 			logger.Info("Registered remote tools", map[string]interface{}{
 				"count": len(remoteTools),
 			})
+			report.pass("tool_registration", fmt.Sprintf("registered %d remote tools", toolRegistry.Count()))
 		}
+	} else {
+		report.skip("tool_registration", "not connected to Core Platform")
+	}
+
+	if *dryRun {
+		validateTLS(cfg.Server, cfg.Auth.ClientCAFile != "", report)
+		os.Exit(report.write(os.Stdout))
 	}
 
 	// Initialize MCP handler
@@ -236,6 +283,21 @@ func main() {
 		Handler: router,
 	}
 
+	useTLS := cfg.Server.TLSCertFile != "" && cfg.Server.TLSKeyFile != ""
+	if certAuth != nil {
+		if !useTLS {
+			logger.Fatal("Client certificate authentication requires EDGE_MCP_TLS_CERT_FILE and EDGE_MCP_TLS_KEY_FILE", nil)
+		}
+		tlsConfig, err := securitytls.DefaultConfig().BuildTLSConfig()
+		if err != nil {
+			logger.Fatal("Failed to build TLS config", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		certAuth.ConfigureTLS(tlsConfig)
+		srv.TLSConfig = tlsConfig
+	}
+
 	// Graceful shutdown
 	go func() {
 		sigChan := make(chan os.Signal, 1)
@@ -257,6 +319,8 @@ func main() {
 	logger.Info("Edge MCP starting", map[string]interface{}{
 		"version": version,
 		"port":    cfg.Server.Port,
+		"tls":     useTLS,
+		"mtls":    certAuth != nil,
 	})
 	if coreClient != nil {
 		logger.Info("Connected to Core Platform", map[string]interface{}{
@@ -266,7 +330,12 @@ func main() {
 		logger.Info("Running in standalone mode (no Core Platform connection)", nil)
 	}
 
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	if useTLS {
+		err = srv.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		logger.Fatal("Server failed to start", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+
+	securitytls "github.com/developer-mesh/developer-mesh/pkg/security/tls"
 )
 
 // Authenticator handles authentication
@@ -10,9 +13,11 @@ type Authenticator interface {
 	AuthenticateRequest(r *http.Request) bool
 }
 
-// EdgeAuthenticator implements simple API key authentication for Edge MCP
+// EdgeAuthenticator implements simple API key authentication for Edge MCP,
+// optionally combined with mutual TLS client certificate authentication
 type EdgeAuthenticator struct {
-	apiKey string
+	apiKey   string
+	certAuth *securitytls.ClientCertAuthenticator
 }
 
 // NewEdgeAuthenticator creates a new Edge authenticator
@@ -22,8 +27,36 @@ func NewEdgeAuthenticator(apiKey string) Authenticator {
 	}
 }
 
+// NewEdgeAuthenticatorWithClientCert creates an Edge authenticator that also accepts
+// client certificates, either instead of or in addition to the API key depending on
+// the certificate authenticator's mode
+func NewEdgeAuthenticatorWithClientCert(apiKey string, certAuth *securitytls.ClientCertAuthenticator) Authenticator {
+	return &EdgeAuthenticator{
+		apiKey:   apiKey,
+		certAuth: certAuth,
+	}
+}
+
 // AuthenticateRequest authenticates an HTTP request
 func (a *EdgeAuthenticator) AuthenticateRequest(r *http.Request) bool {
+	if a.certAuth == nil {
+		return a.authenticateAPIKey(r)
+	}
+
+	_, err := a.certAuth.Authenticate(r.TLS)
+	if err != nil && !errors.Is(err, securitytls.ErrNoClientCert) {
+		return false
+	}
+	hasCert := err == nil
+
+	if a.certAuth.Mode() == securitytls.ClientCertModeAlternative {
+		return hasCert || a.authenticateAPIKey(r)
+	}
+	return hasCert && a.authenticateAPIKey(r)
+}
+
+// authenticateAPIKey checks the request's API key
+func (a *EdgeAuthenticator) authenticateAPIKey(r *http.Request) bool {
 	// If no API key is configured, allow all requests (for local development)
 	if a.apiKey == "" {
 		return true
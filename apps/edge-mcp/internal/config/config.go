@@ -14,12 +14,17 @@ type Config struct {
 
 // ServerConfig represents server configuration
 type ServerConfig struct {
-	Port int `yaml:"port"`
+	Port        int    `yaml:"port"`
+	TLSCertFile string `yaml:"tls_cert_file"`
+	TLSKeyFile  string `yaml:"tls_key_file"`
 }
 
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
 	APIKey string `yaml:"api_key"`
+	// ClientCAFile enables mutual TLS client certificate authentication when set
+	ClientCAFile   string `yaml:"client_ca_file"`
+	ClientCertMode string `yaml:"client_cert_mode"` // alternative (default) or additional
 }
 
 // CoreConfig represents Core Platform configuration
@@ -41,10 +46,14 @@ func Load(configFile string) (*Config, error) {
 func Default() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: 8082,
+			Port:        8082,
+			TLSCertFile: getEnv("EDGE_MCP_TLS_CERT_FILE", ""),
+			TLSKeyFile:  getEnv("EDGE_MCP_TLS_KEY_FILE", ""),
 		},
 		Auth: AuthConfig{
-			APIKey: getEnv("EDGE_MCP_API_KEY", ""),
+			APIKey:         getEnv("EDGE_MCP_API_KEY", ""),
+			ClientCAFile:   getEnv("EDGE_MCP_CLIENT_CA_FILE", ""),
+			ClientCertMode: getEnv("EDGE_MCP_CLIENT_CERT_MODE", ""),
 		},
 		Core: CoreConfig{
 			URL:       getEnv("CORE_PLATFORM_URL", ""),
@@ -39,6 +39,7 @@ import (
 
 	// Import auth package for production authorizer
 	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/auth/oauth2"
 
 	// Import rules package for rule engine and policy manager
 	"github.com/developer-mesh/developer-mesh/pkg/rules"
@@ -1077,6 +1078,21 @@ func buildAPIConfig(cfg *commonconfig.Config, logger observability.Logger) api.C
 	}
 	apiConfig.TLSCertFile = cfg.API.TLSCertFile
 	apiConfig.TLSKeyFile = cfg.API.TLSKeyFile
+	if cfg.API.ClientCertAuth != nil {
+		apiConfig.ClientCertAuth = securitytls.ClientCertConfig{
+			Enabled:      cfg.API.ClientCertAuth.Enabled,
+			ClientCAFile: cfg.API.ClientCertAuth.ClientCAFile,
+			Mode:         cfg.API.ClientCertAuth.Mode,
+			URIScheme:    cfg.API.ClientCertAuth.URIScheme,
+		}
+	}
+	if cfg.API.MCPTelemetry != nil {
+		apiConfig.MCPTelemetry = api.MCPTelemetryConfig{
+			RotationInterval: cfg.API.MCPTelemetry.RotationInterval,
+			Retention:        cfg.API.MCPTelemetry.Retention,
+			Compression:      cfg.API.MCPTelemetry.Compression,
+		}
+	}
 
 	// Set timeouts from environment if available
 	if timeout := getEnvDuration("API_READ_TIMEOUT", 0); timeout > 0 {
@@ -1116,6 +1132,38 @@ func buildAPIConfig(cfg *commonconfig.Config, logger observability.Logger) api.C
 				})
 			}
 		}
+
+		if autoProvision, ok := cfg.API.Auth["auto_provision_tenants"].(bool); ok {
+			apiConfig.Auth.AutoProvisionTenants = autoProvision
+		}
+
+		if algorithm, ok := cfg.API.Auth["api_key_hash_algorithm"].(string); ok {
+			switch auth.KeyHashAlgorithm(algorithm) {
+			case auth.KeyHashSHA256, auth.KeyHashBcrypt:
+				apiConfig.Auth.APIKeyHashAlgorithm = algorithm
+			default:
+				logger.Warn("Unknown API key hash algorithm, using sha256", map[string]interface{}{
+					"algorithm": algorithm,
+				})
+			}
+		}
+
+		if stream, ok := cfg.API.Auth["audit_stream"].(string); ok {
+			apiConfig.Auth.AuditStream = stream
+		}
+		if maxLen, ok := cfg.API.Auth["audit_stream_max_len"].(int); ok {
+			apiConfig.Auth.AuditStreamMaxLen = int64(maxLen)
+		}
+
+		// OAuth2 identity provider configuration
+		if oauth2Config, ok := cfg.API.Auth["oauth2"].(map[string]interface{}); ok {
+			apiConfig.Auth.OAuth2 = parseOAuth2Config(oauth2Config)
+		}
+	}
+
+	// Override the OAuth2 client secret from environment if set
+	if clientSecret := os.Getenv("OAUTH2_CLIENT_SECRET"); clientSecret != "" && apiConfig.Auth.OAuth2 != nil {
+		apiConfig.Auth.OAuth2.ClientSecret = clientSecret
 	}
 
 	// Override JWT secret from environment if set
@@ -1197,6 +1245,55 @@ func parseRateLimitConfig(input interface{}) api.RateLimitConfig {
 	}
 }
 
+// parseOAuth2Config parses OAuth2 identity provider configuration. It returns
+// nil, disabling OAuth2 sign-in, unless enabled is true.
+func parseOAuth2Config(input map[string]interface{}) *oauth2.ProviderConfig {
+	if enabled, ok := input["enabled"].(bool); !ok || !enabled {
+		return nil
+	}
+
+	str := func(key string) string {
+		value, _ := input[key].(string)
+		return value
+	}
+	strs := func(key string) []string {
+		var values []string
+		if items, ok := input[key].([]interface{}); ok {
+			for _, item := range items {
+				if value, ok := item.(string); ok {
+					values = append(values, value)
+				}
+			}
+		}
+		return values
+	}
+	duration := func(key string) time.Duration {
+		if value, ok := input[key].(string); ok {
+			if d, err := time.ParseDuration(value); err == nil {
+				return d
+			}
+		}
+		return 0
+	}
+
+	return &oauth2.ProviderConfig{
+		Issuer:                str("issuer"),
+		AuthorizationEndpoint: str("authorization_endpoint"),
+		TokenEndpoint:         str("token_endpoint"),
+		JWKSURL:               str("jwks_url"),
+		ClientID:              str("client_id"),
+		ClientSecret:          str("client_secret"),
+		RedirectURI:           str("redirect_uri"),
+		Scopes:                strs("scopes"),
+		TenantClaim:           str("tenant_claim"),
+		DefaultTenantID:       str("default_tenant_id"),
+		ScopesClaim:           str("scopes_claim"),
+		DefaultScopes:         strs("default_scopes"),
+		JWKSCacheTTL:          duration("jwks_cache_ttl"),
+		HTTPTimeout:           duration("http_timeout"),
+	}
+}
+
 // parseWebSocketConfig parses WebSocket configuration
 func parseWebSocketConfig(wsConfig *commonconfig.WebSocketConfig) api.WebSocketConfig {
 	config := api.WebSocketConfig{
@@ -1228,6 +1325,150 @@ func parseWebSocketConfig(wsConfig *commonconfig.WebSocketConfig) api.WebSocketC
 		}
 	}
 
+	// Parse context checkpoint config
+	if wsConfig.ContextCheckpoint != nil {
+		config.ContextCheckpoint = websocket.ContextCheckpointConfig{
+			Enabled:        wsConfig.ContextCheckpoint.Enabled,
+			AppendInterval: wsConfig.ContextCheckpoint.AppendInterval,
+			TimeInterval:   wsConfig.ContextCheckpoint.TimeInterval,
+			TTL:            wsConfig.ContextCheckpoint.TTL,
+		}
+	}
+
+	// Parse workspace broadcast rate limit config
+	if wsConfig.BroadcastRateLimit != nil {
+		config.BroadcastRateLimit = websocket.BroadcastRateLimitConfig{
+			MaxBroadcastsPerMinute: wsConfig.BroadcastRateLimit.MaxBroadcastsPerMinute,
+			PerWorkspaceType:       wsConfig.BroadcastRateLimit.PerWorkspaceType,
+		}
+	}
+
+	// Parse tool execution quota config
+	if wsConfig.ToolQuota != nil {
+		config.ToolQuota = websocket.ToolQuotaConfig{
+			MaxExecutions: wsConfig.ToolQuota.MaxExecutions,
+			Window:        wsConfig.ToolQuota.Window,
+		}
+	}
+
+	// Parse tool retry config
+	if wsConfig.ToolRetry != nil {
+		config.ToolRetry = websocket.ToolRetryConfig{
+			MaxRetries:     wsConfig.ToolRetry.MaxRetries,
+			InitialBackoff: wsConfig.ToolRetry.InitialBackoff,
+			MaxBackoff:     wsConfig.ToolRetry.MaxBackoff,
+		}
+	}
+
+	// Parse tool output limit config
+	if wsConfig.ToolOutputLimit != nil {
+		config.ToolOutputLimit = websocket.ToolOutputLimitConfig{
+			MaxBytes:  wsConfig.ToolOutputLimit.MaxBytes,
+			PerTool:   wsConfig.ToolOutputLimit.PerTool,
+			Policy:    wsConfig.ToolOutputLimit.Policy,
+			ResultTTL: wsConfig.ToolOutputLimit.ResultTTL,
+		}
+	}
+
+	// Parse context token budget config
+	if wsConfig.ContextTokenBudget != nil {
+		config.ContextTokenBudget = websocket.ContextTokenBudgetConfig{
+			Strategy:  wsConfig.ContextTokenBudget.Strategy,
+			MaxTokens: wsConfig.ContextTokenBudget.MaxTokens,
+		}
+	}
+
+	// Parse tool metadata forwarding config
+	if wsConfig.ToolMetadata != nil {
+		config.ToolMetadata = websocket.ToolMetadataConfig{
+			ForwardKeys: wsConfig.ToolMetadata.ForwardKeys,
+		}
+	}
+
+	// Parse workflow portability config
+	if wsConfig.WorkflowPortability != nil {
+		config.WorkflowPortability = websocket.WorkflowPortabilityConfig{
+			SigningKey: wsConfig.WorkflowPortability.SigningKey,
+		}
+	}
+
+	// Parse compression dictionary config
+	if wsConfig.CompressionDictionary != nil {
+		config.CompressionDictionary = websocket.CompressionDictionaryConfig{
+			Disabled: wsConfig.CompressionDictionary.Disabled,
+			Version:  wsConfig.CompressionDictionary.Version,
+			Path:     wsConfig.CompressionDictionary.Path,
+		}
+	}
+
+	// Parse request deduplication config
+	if wsConfig.RequestDedup != nil {
+		config.RequestDedup = websocket.RequestDedupConfig{
+			Disabled: wsConfig.RequestDedup.Disabled,
+			Window:   wsConfig.RequestDedup.Window,
+		}
+	}
+
+	// Parse keepalive config
+	if wsConfig.Keepalive != nil {
+		config.Keepalive = websocket.KeepaliveConfig{
+			MaxMissedPongs: wsConfig.Keepalive.MaxMissedPongs,
+			StaleAfter:     wsConfig.Keepalive.StaleAfter,
+		}
+	}
+
+	// Parse shared tool catalog config
+	if wsConfig.SharedToolCatalog != nil {
+		config.SharedToolCatalog = websocket.SharedToolCatalogConfig{
+			TenantID: wsConfig.SharedToolCatalog.TenantID,
+		}
+	}
+
+	// Parse subscription cleanup config
+	if wsConfig.SubscriptionCleanup != nil {
+		config.SubscriptionCleanup = websocket.SubscriptionCleanupConfig{
+			Disabled:        wsConfig.SubscriptionCleanup.Disabled,
+			Interval:        wsConfig.SubscriptionCleanup.Interval,
+			ReplayRetention: wsConfig.SubscriptionCleanup.ReplayRetention,
+		}
+	}
+
+	// Parse connection warm-up config
+	if wsConfig.ConnectionWarmup != nil {
+		config.ConnectionWarmup = websocket.ConnectionWarmupConfig{
+			Disabled: wsConfig.ConnectionWarmup.Disabled,
+			TTL:      wsConfig.ConnectionWarmup.TTL,
+		}
+	}
+
+	// Parse workflow limits config
+	if wsConfig.WorkflowLimits != nil {
+		config.WorkflowLimits = websocket.WorkflowLimitsConfig{
+			MaxSteps: wsConfig.WorkflowLimits.MaxSteps,
+			MaxDepth: wsConfig.WorkflowLimits.MaxDepth,
+		}
+	}
+
+	// Parse workflow resume re-subscription config
+	if wsConfig.WorkflowResubscribe != nil {
+		config.WorkflowResubscribe = websocket.WorkflowResubscribeConfig{
+			Disabled: wsConfig.WorkflowResubscribe.Disabled,
+		}
+	}
+
+	// Parse scope elevation config
+	if wsConfig.ScopeElevation != nil {
+		config.ScopeElevation = websocket.ScopeElevationConfig{
+			AllowedScopes:     wsConfig.ScopeElevation.AllowedScopes,
+			AutoApproveScopes: wsConfig.ScopeElevation.AutoApproveScopes,
+			MaxDuration:       wsConfig.ScopeElevation.MaxDuration,
+			RequestTTL:        wsConfig.ScopeElevation.RequestTTL,
+		}
+	}
+
+	config.ContextMetadataSchemas = wsConfig.ContextMetadataSchemas
+	config.MethodSchemas = wsConfig.MethodSchemas
+
 	return config
 }
 
@@ -162,3 +162,17 @@ func (a *EventBusAdapter) UnsubscribeEvents(connectionID string, eventTypes []st
 func (a *EventBusAdapter) Publish(event string, data interface{}) error {
 	return a.bus.Publish(context.Background(), event, "websocket", data)
 }
+
+// SubscribeHandler registers an in-process handler for an event
+func (a *EventBusAdapter) SubscribeHandler(event string, handler func(data interface{})) (string, error) {
+	subID := a.bus.Subscribe(event, func(ctx context.Context, e *events.Event) error {
+		handler(e.Data)
+		return nil
+	})
+	return subID, nil
+}
+
+// UnsubscribeHandler removes a handler registered with SubscribeHandler
+func (a *EventBusAdapter) UnsubscribeHandler(subscriptionID string) error {
+	return a.bus.Unsubscribe(subscriptionID)
+}
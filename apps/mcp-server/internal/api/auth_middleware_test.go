@@ -36,7 +36,7 @@ func TestAuthMiddlewareRateLimiting(t *testing.T) {
 	})
 
 	// Setup enhanced auth with rate limiting
-	rateLimiter := auth.NewRateLimiter(mockCache, observability.NewNoopLogger(), &auth.RateLimiterConfig{
+	rateLimiter := auth.NewAttemptRateLimiter(mockCache, observability.NewNoopLogger(), &auth.RateLimiterConfig{
 		Enabled:       true,
 		MaxAttempts:   3,
 		WindowSize:    1 * time.Minute,
@@ -159,7 +159,7 @@ func TestAuthMiddlewareConcurrency(t *testing.T) {
 	})
 
 	// Create auth middleware with proper setup
-	rateLimiter := auth.NewRateLimiter(mockCache, observability.NewNoopLogger(), nil)
+	rateLimiter := auth.NewAttemptRateLimiter(mockCache, observability.NewNoopLogger(), nil)
 	metricsCollector := auth.NewMetricsCollector(observability.NewNoOpMetricsClient())
 	auditLogger := auth.NewAuditLogger(observability.NewNoopLogger())
 	authMiddleware := auth.NewAuthMiddleware(authService, rateLimiter, metricsCollector, auditLogger)
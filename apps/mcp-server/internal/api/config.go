@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/developer-mesh/developer-mesh/apps/mcp-server/internal/api/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/auth/oauth2"
+	securitytls "github.com/developer-mesh/developer-mesh/pkg/security/tls"
 )
 
 // Config holds configuration for the API server
@@ -22,6 +24,12 @@ type Config struct {
 	Performance   PerformanceConfig `mapstructure:"performance"`
 	RestAPI       RestAPIConfig     `mapstructure:"rest_api"`
 	WebSocket     WebSocketConfig   `mapstructure:"websocket"`
+
+	// Mutual TLS client certificate authentication
+	ClientCertAuth securitytls.ClientCertConfig `mapstructure:"client_cert_auth"`
+
+	// MCP method latency retention
+	MCPTelemetry MCPTelemetryConfig `mapstructure:"mcp_telemetry"`
 }
 
 // VersioningConfig holds API versioning configuration
@@ -60,10 +68,17 @@ type PerformanceConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	JWTSecret        string      `mapstructure:"jwt_secret"`
-	APIKeys          interface{} `mapstructure:"api_keys"`
-	ServiceSecret    string      `mapstructure:"service_secret"`
-	DefaultRateLimit int         `mapstructure:"default_rate_limit"`
+	JWTSecret            string      `mapstructure:"jwt_secret"`
+	APIKeys              interface{} `mapstructure:"api_keys"`
+	ServiceSecret        string      `mapstructure:"service_secret"`
+	DefaultRateLimit     int         `mapstructure:"default_rate_limit"`
+	AutoProvisionTenants bool        `mapstructure:"auto_provision_tenants"` // Create default tenant resources on first auth
+	APIKeyHashAlgorithm  string      `mapstructure:"api_key_hash_algorithm"` // "sha256" (default) or "bcrypt"
+	AuditStream          string      `mapstructure:"audit_stream"`           // Redis stream auth decisions are audited to; empty disables auditing
+	AuditStreamMaxLen    int64       `mapstructure:"audit_stream_max_len"`   // Approximate cap on audit stream entries; 0 keeps them all
+
+	// OAuth2 signs users in through a corporate identity provider; nil disables it
+	OAuth2 *oauth2.ProviderConfig `mapstructure:"oauth2"`
 }
 
 // RateLimitConfig holds rate limiting configuration
@@ -94,6 +109,28 @@ type WebSocketConfig struct {
 	MaxMessageSize  int64                       `mapstructure:"max_message_size"`
 	Security        websocket.SecurityConfig    `mapstructure:"security"`
 	RateLimit       websocket.RateLimiterConfig `mapstructure:"rate_limit"`
+
+	ContextCheckpoint  websocket.ContextCheckpointConfig  `mapstructure:"context_checkpoint"`
+	BroadcastRateLimit websocket.BroadcastRateLimitConfig `mapstructure:"broadcast_rate_limit"`
+	ToolQuota          websocket.ToolQuotaConfig          `mapstructure:"tool_quota"`
+	ToolOutputLimit    websocket.ToolOutputLimitConfig    `mapstructure:"tool_output_limit"`
+	ToolRetry          websocket.ToolRetryConfig          `mapstructure:"tool_retry"`
+	ContextTokenBudget websocket.ContextTokenBudgetConfig `mapstructure:"context_token_budget"`
+	ToolMetadata       websocket.ToolMetadataConfig       `mapstructure:"tool_metadata"`
+
+	WorkflowPortability   websocket.WorkflowPortabilityConfig   `mapstructure:"workflow_portability"`
+	CompressionDictionary websocket.CompressionDictionaryConfig `mapstructure:"compression_dictionary"`
+	RequestDedup          websocket.RequestDedupConfig          `mapstructure:"request_dedup"`
+	Keepalive             websocket.KeepaliveConfig             `mapstructure:"keepalive"`
+	SharedToolCatalog     websocket.SharedToolCatalogConfig     `mapstructure:"shared_tool_catalog"`
+	SubscriptionCleanup   websocket.SubscriptionCleanupConfig   `mapstructure:"subscription_cleanup"`
+	ConnectionWarmup      websocket.ConnectionWarmupConfig      `mapstructure:"connection_warmup"`
+	WorkflowLimits        websocket.WorkflowLimitsConfig        `mapstructure:"workflow_limits"`
+	WorkflowResubscribe   websocket.WorkflowResubscribeConfig   `mapstructure:"workflow_resubscribe"`
+	ScopeElevation        websocket.ScopeElevationConfig        `mapstructure:"scope_elevation"`
+
+	ContextMetadataSchemas map[string]interface{} `mapstructure:"context_metadata_schemas"`
+	MethodSchemas          map[string]interface{} `mapstructure:"method_schemas"`
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultLatencyDigestCompression trades digest size for accuracy. At 100 the
+// tail percentiles are typically within a fraction of a percent.
+const DefaultLatencyDigestCompression = 100
+
+// centroid is a cluster of samples summarized by their mean and count
+type centroid struct {
+	mean  float64
+	count float64
+}
+
+// latencyDigest is a merging t-digest: a streaming quantile estimator that
+// keeps clusters small near the tails, where p95 and p99 need precision, and
+// large near the median. Its size depends on the compression, not on how
+// many samples were added. It is not safe for concurrent use.
+type latencyDigest struct {
+	compression float64
+	centroids   []centroid // Sorted by mean once compressed
+	buffer      []centroid // Samples added since the last compression
+	count       float64
+	sum         float64
+	min         float64
+	max         float64
+}
+
+func newLatencyDigest(compression float64) *latencyDigest {
+	if compression <= 0 {
+		compression = DefaultLatencyDigestCompression
+	}
+	return &latencyDigest{
+		compression: compression,
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add records a sample
+func (d *latencyDigest) Add(value float64) {
+	d.addCentroid(centroid{mean: value, count: 1})
+	d.sum += value
+}
+
+// Merge adds every sample summarized by another digest
+func (d *latencyDigest) Merge(other *latencyDigest) {
+	other.compress()
+	for _, c := range other.centroids {
+		d.addCentroid(c)
+	}
+	d.sum += other.sum
+}
+
+func (d *latencyDigest) addCentroid(c centroid) {
+	d.buffer = append(d.buffer, c)
+	d.count += c.count
+	d.min = math.Min(d.min, c.mean)
+	d.max = math.Max(d.max, c.mean)
+
+	if len(d.buffer) >= int(d.compression)*5 {
+		d.compress()
+	}
+}
+
+// compress merges buffered samples into the centroids, combining neighbours
+// while the combined cluster stays within the size allowed at its quantile
+func (d *latencyDigest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := append(d.centroids, d.buffer...)
+	d.buffer = d.buffer[:0]
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(all))
+	merged = append(merged, all[0])
+	before := 0.0 // Samples in the centroids before the last merged one
+	for _, c := range all[1:] {
+		last := &merged[len(merged)-1]
+		proposed := last.count + c.count
+		q := (before + proposed/2) / d.count
+		if proposed <= 4*d.count*q*(1-q)/d.compression {
+			last.mean += (c.mean - last.mean) * c.count / proposed
+			last.count = proposed
+			continue
+		}
+		before += last.count
+		merged = append(merged, c)
+	}
+	d.centroids = merged
+}
+
+// Count returns the number of samples added
+func (d *latencyDigest) Count() float64 {
+	return d.count
+}
+
+// Mean returns the mean of the samples added
+func (d *latencyDigest) Mean() float64 {
+	if d.count == 0 {
+		return 0
+	}
+	return d.sum / d.count
+}
+
+// Quantile estimates the value below which the fraction q of samples fall,
+// interpolating between the centres of neighbouring centroids
+func (d *latencyDigest) Quantile(q float64) float64 {
+	d.compress()
+	if d.count == 0 {
+		return 0
+	}
+	if len(d.centroids) == 1 {
+		return d.centroids[0].mean
+	}
+
+	target := math.Max(0, math.Min(1, q)) * d.count
+
+	// Below the first centre, interpolate from the smallest sample
+	first := d.centroids[0]
+	if target < first.count/2 {
+		return d.min + (first.mean-d.min)*target/(first.count/2)
+	}
+
+	cumulative := 0.0
+	for i := 0; i < len(d.centroids)-1; i++ {
+		current, next := d.centroids[i], d.centroids[i+1]
+		centre := cumulative + current.count/2
+		nextCentre := cumulative + current.count + next.count/2
+		if target < nextCentre {
+			return current.mean + (next.mean-current.mean)*(target-centre)/(nextCentre-centre)
+		}
+		cumulative += current.count
+	}
+
+	// Above the last centre, interpolate towards the largest sample
+	last := d.centroids[len(d.centroids)-1]
+	lastCentre := d.count - last.count/2
+	return last.mean + (d.max-last.mean)*(target-lastCentre)/(last.count/2)
+}
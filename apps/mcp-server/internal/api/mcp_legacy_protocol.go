@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// MCPLegacyProtocolCutoff is the first date-versioned MCP protocol revision.
+// Clients declaring an earlier version are served through a LegacyProtocolAdapter.
+const MCPLegacyProtocolCutoff = "2024-11-05"
+
+// Legacy MCP error codes, used in place of JSON-RPC 2.0 codes for pre-2024-11-05 clients
+const (
+	MCPLegacyErrorBadRequest = 400
+	MCPLegacyErrorNotFound   = 404
+	MCPLegacyErrorInternal   = 500
+)
+
+var mcpDateVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// IsLegacyProtocolVersion reports whether a client-declared protocol version predates
+// MCPLegacyProtocolCutoff. Pre-release versions such as "0.1" are legacy; an empty
+// version is treated as current.
+func IsLegacyProtocolVersion(version string) bool {
+	if version == "" {
+		return false
+	}
+	if mcpDateVersionPattern.MatchString(version) {
+		// ISO dates compare correctly as strings
+		return version < MCPLegacyProtocolCutoff
+	}
+	return strings.HasPrefix(version, "0.")
+}
+
+// LegacyProtocolAdapter translates between the current MCP message format and the
+// envelope used by clients predating MCPLegacyProtocolCutoff. Legacy responses carry
+// result fields at the top level instead of in a "result" wrapper, and errors use
+// HTTP-style codes.
+type LegacyProtocolAdapter struct {
+	version string
+}
+
+// NewLegacyProtocolAdapter creates an adapter for a client's declared protocol version
+func NewLegacyProtocolAdapter(version string) *LegacyProtocolAdapter {
+	return &LegacyProtocolAdapter{version: version}
+}
+
+// Version returns the protocol version declared by the client
+func (a *LegacyProtocolAdapter) Version() string {
+	return a.version
+}
+
+// TranslateRequest normalizes a legacy request into a JSON-RPC 2.0 message
+func (a *LegacyProtocolAdapter) TranslateRequest(msg *MCPMessage) {
+	if msg.JSONRPC == "" {
+		msg.JSONRPC = "2.0"
+	}
+}
+
+// EncodeResult encodes a successful response in the legacy envelope.
+// Object results are flattened into the envelope; other results are sent as "data".
+func (a *LegacyProtocolAdapter) EncodeResult(id interface{}, result interface{}) ([]byte, error) {
+	envelope := make(map[string]interface{})
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err == nil && fields != nil {
+		for k, v := range fields {
+			envelope[k] = v
+		}
+	} else {
+		envelope["data"] = result
+	}
+
+	if id != nil {
+		envelope["id"] = id
+	}
+	return json.Marshal(envelope)
+}
+
+// EncodeError encodes an error response in the legacy envelope
+func (a *LegacyProtocolAdapter) EncodeError(id interface{}, code int, message string) ([]byte, error) {
+	envelope := map[string]interface{}{
+		"error": map[string]interface{}{
+			"code":    LegacyErrorCode(code),
+			"message": message,
+		},
+	}
+	if id != nil {
+		envelope["id"] = id
+	}
+	return json.Marshal(envelope)
+}
+
+// LegacyErrorCode maps a JSON-RPC 2.0 error code to its legacy equivalent
+func LegacyErrorCode(code int) int {
+	switch code {
+	case MCPErrorParseError, MCPErrorInvalidRequest, MCPErrorInvalidParams:
+		return MCPLegacyErrorBadRequest
+	case MCPErrorMethodNotFound:
+		return MCPLegacyErrorNotFound
+	default:
+		return MCPLegacyErrorInternal
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsLegacyProtocolVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		legacy  bool
+	}{
+		{"", false},
+		{"0.1", true},
+		{"0.1.0", true},
+		{"2024-10-07", true},
+		{"2024-11-05", false},
+		{"2025-06-18", false},
+		{"1.0", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			assert.Equal(t, tt.legacy, IsLegacyProtocolVersion(tt.version))
+		})
+	}
+}
+
+func TestLegacyProtocolAdapterEncodeResult(t *testing.T) {
+	adapter := NewLegacyProtocolAdapter("0.1")
+
+	data, err := adapter.EncodeResult(1, map[string]interface{}{"tools": []string{"github"}})
+	require.NoError(t, err)
+
+	var envelope map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.NotContains(t, envelope, "result")
+	assert.NotContains(t, envelope, "jsonrpc")
+	assert.Equal(t, float64(1), envelope["id"])
+	assert.Equal(t, []interface{}{"github"}, envelope["tools"])
+
+	data, err = adapter.EncodeResult("req-2", []string{"a", "b"})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, []interface{}{"a", "b"}, envelope["data"])
+}
+
+func TestLegacyProtocolAdapterEncodeError(t *testing.T) {
+	adapter := NewLegacyProtocolAdapter("0.1")
+
+	data, err := adapter.EncodeError("req-1", MCPErrorMethodNotFound, "Method not found: foo")
+	require.NoError(t, err)
+
+	var envelope struct {
+		ID    string `json:"id"`
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, "req-1", envelope.ID)
+	assert.Equal(t, MCPLegacyErrorNotFound, envelope.Error.Code)
+	assert.Equal(t, "Method not found: foo", envelope.Error.Message)
+
+	assert.Equal(t, MCPLegacyErrorBadRequest, LegacyErrorCode(MCPErrorInvalidParams))
+	assert.Equal(t, MCPLegacyErrorInternal, LegacyErrorCode(MCPErrorInternalError))
+}
+
+func TestLegacyProtocolAdapterTranslateRequest(t *testing.T) {
+	msg := MCPMessage{Method: "tools/list", ID: 1}
+	NewLegacyProtocolAdapter("0.1").TranslateRequest(&msg)
+	assert.Equal(t, "2.0", msg.JSONRPC)
+}
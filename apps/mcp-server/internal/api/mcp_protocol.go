@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/coder/websocket"
 
 	"github.com/developer-mesh/developer-mesh/pkg/adapters/mcp"
 	"github.com/developer-mesh/developer-mesh/pkg/adapters/mcp/resources"
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
 	"github.com/developer-mesh/developer-mesh/pkg/clients"
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
 )
@@ -62,10 +64,25 @@ type MCPProtocolHandler struct {
 	toolsCache      *ToolsCache
 	toolNameCache   map[string]map[string]string // tenant_id -> tool_name -> tool_id
 	toolNameCacheMu sync.RWMutex
-	metrics         observability.MetricsClient
-	telemetry       *MCPTelemetry
+	// Output templates of tools that define one, guarded by toolNameCacheMu
+	toolOutputTemplates map[string]*template.Template // tool_id -> template
+	metrics             observability.MetricsClient
+	telemetry           *MCPTelemetry
 	// Resilience
 	circuitBreakers *ToolCircuitBreakerManager
+	// Compliance
+	auditStore auth.ToolAuditStore
+	// Results larger than this (estimated bytes) are streamed; <= 0 disables streaming
+	streamThreshold int
+	// Connections that initialized with a pre-2024-11-05 protocol version
+	legacyConns   map[*websocket.Conn]legacyConn
+	legacyConnsMu sync.RWMutex
+}
+
+// legacyConn tracks the protocol adapter for a legacy client connection
+type legacyConn struct {
+	connID  string
+	adapter *LegacyProtocolAdapter
 }
 
 // NewMCPProtocolHandler creates a new MCP protocol handler
@@ -74,15 +91,18 @@ func NewMCPProtocolHandler(
 	logger observability.Logger,
 ) *MCPProtocolHandler {
 	return &MCPProtocolHandler{
-		restAPIClient:    restClient,
-		sessions:         make(map[string]*MCPSession),
-		logger:           logger,
-		protocolAdapter:  mcp.NewProtocolAdapter(logger),
-		resourceProvider: resources.NewResourceProvider(logger),
-		toolsCache:       NewToolsCache(5 * time.Minute), // 5 minute TTL
-		toolNameCache:    make(map[string]map[string]string),
-		telemetry:        NewMCPTelemetry(logger),
-		circuitBreakers:  NewToolCircuitBreakerManager(logger),
+		restAPIClient:       restClient,
+		sessions:            make(map[string]*MCPSession),
+		logger:              logger,
+		protocolAdapter:     mcp.NewProtocolAdapter(logger),
+		resourceProvider:    resources.NewResourceProvider(logger),
+		toolsCache:          NewToolsCache(5 * time.Minute), // 5 minute TTL
+		toolNameCache:       make(map[string]map[string]string),
+		toolOutputTemplates: make(map[string]*template.Template),
+		telemetry:           NewMCPTelemetry(logger),
+		circuitBreakers:     NewToolCircuitBreakerManager(logger),
+		streamThreshold:     DefaultStreamingResultThreshold,
+		legacyConns:         make(map[*websocket.Conn]legacyConn),
 	}
 }
 
@@ -94,6 +114,51 @@ func (h *MCPProtocolHandler) SetMetricsClient(metrics observability.MetricsClien
 	}
 }
 
+// SetTelemetryConfig sets how long method latencies are retained
+func (h *MCPProtocolHandler) SetTelemetryConfig(config MCPTelemetryConfig) {
+	if h.telemetry != nil {
+		h.telemetry.SetConfig(config)
+	}
+}
+
+// SetToolAuditStore sets the store used to audit tool executions
+func (h *MCPProtocolHandler) SetToolAuditStore(store auth.ToolAuditStore) {
+	h.auditStore = store
+}
+
+// recordToolAudit writes a tool execution audit record. Arguments are redacted by the store.
+func (h *MCPProtocolHandler) recordToolAudit(ctx context.Context, session *MCPSession, tenantID, toolID, action string, args map[string]interface{}, start time.Time, resultSummary string, execErr error) {
+	if h.auditStore == nil {
+		return
+	}
+
+	record := &auth.ToolExecutionAuditRecord{
+		Timestamp:     start,
+		TenantID:      tenantID,
+		ToolID:        toolID,
+		Action:        action,
+		Source:        "mcp",
+		Arguments:     args,
+		Status:        auth.ToolAuditStatusSuccess,
+		DurationMS:    time.Since(start).Milliseconds(),
+		ResultSummary: resultSummary,
+	}
+	if session != nil {
+		record.AgentID = session.AgentID
+	}
+	if execErr != nil {
+		record.Status = auth.ToolAuditStatusFailed
+		record.Error = execErr.Error()
+	}
+
+	if err := h.auditStore.Record(ctx, record); err != nil {
+		h.logger.Warn("Failed to record tool execution audit", map[string]interface{}{
+			"tool_id": toolID,
+			"error":   err.Error(),
+		})
+	}
+}
+
 // resolveToolNameToID resolves a tool display name to its UUID for a specific tenant
 // This ensures tenant isolation - a tenant can only access their own tools
 func (h *MCPProtocolHandler) resolveToolNameToID(ctx context.Context, tenantID, toolName string) (string, error) {
@@ -133,6 +198,19 @@ func (h *MCPProtocolHandler) resolveToolNameToID(ctx context.Context, tenantID,
 		}
 		h.toolNameCache[tenantID][name] = tool.ID
 
+		// Templates are opt-in per tool; a broken template leaves results raw
+		if tmpl, err := parseToolOutputTemplate(tool); err != nil {
+			h.logger.Warn("Ignoring tool output template", map[string]interface{}{
+				"tool_id": tool.ID,
+				"error":   err.Error(),
+			})
+			delete(h.toolOutputTemplates, tool.ID)
+		} else if tmpl != nil {
+			h.toolOutputTemplates[tool.ID] = tmpl
+		} else {
+			delete(h.toolOutputTemplates, tool.ID)
+		}
+
 		// Check if this is the tool we're looking for
 		if name == toolName {
 			foundID = tool.ID
@@ -168,6 +246,10 @@ func (h *MCPProtocolHandler) HandleMessage(conn *websocket.Conn, connID string,
 		return h.sendError(conn, nil, MCPErrorParseError, "Parse error")
 	}
 
+	if adapter := h.legacyAdapter(conn); adapter != nil {
+		adapter.TranslateRequest(&msg)
+	}
+
 	h.logger.Debug("Handling MCP method", map[string]interface{}{
 		"method":        msg.Method,
 		"id":            msg.ID,
@@ -284,9 +366,29 @@ func (h *MCPProtocolHandler) handleInitialize(conn *websocket.Conn, connID, tena
 		"protocol_version": params.ProtocolVersion,
 	})
 
+	// Clients predating 2024-11-05 get the legacy envelope for the rest of the connection
+	protocolVersion := "2025-06-18"
+	legacy := IsLegacyProtocolVersion(params.ProtocolVersion)
+	h.legacyConnsMu.Lock()
+	if legacy {
+		h.legacyConns[conn] = legacyConn{connID: connID, adapter: NewLegacyProtocolAdapter(params.ProtocolVersion)}
+		protocolVersion = params.ProtocolVersion
+	} else {
+		delete(h.legacyConns, conn)
+	}
+	h.legacyConnsMu.Unlock()
+
+	if legacy {
+		h.logger.Warn("Client is using a deprecated MCP protocol version", map[string]interface{}{
+			"connection_id":    connID,
+			"protocol_version": params.ProtocolVersion,
+			"minimum_version":  MCPLegacyProtocolCutoff,
+		})
+	}
+
 	// Return capabilities
 	return h.sendResult(conn, msg.ID, map[string]interface{}{
-		"protocolVersion": "2025-06-18",
+		"protocolVersion": protocolVersion,
 		"serverInfo": map[string]interface{}{
 			"name":    "developer-mesh-mcp",
 			"version": "1.0.0",
@@ -599,6 +701,9 @@ func (h *MCPProtocolHandler) handleToolCall(conn *websocket.Conn, connID, tenant
 	var params struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
+		Meta      struct {
+			RawOutput bool `json:"raw_output"` // Include the raw result alongside a templated summary
+		} `json:"_meta"`
 	}
 	if err := json.Unmarshal(msg.Params, &params); err != nil {
 		h.recordTelemetry("tools_call", time.Since(startTime), false)
@@ -673,39 +778,35 @@ func (h *MCPProtocolHandler) handleToolCall(conn *websocket.Conn, connID, tenant
 			"error":     err.Error(),
 			"tenant_id": tenantID,
 		})
+		h.recordToolAudit(ctx, session, tenantID, toolID, action, params.Arguments, startTime, "", err)
 		h.recordTelemetry(fmt.Sprintf("tools_call.%s", params.Name), time.Since(startTime), false)
 		return h.sendError(conn, msg.ID, MCPErrorInternalError, fmt.Sprintf("Tool execution failed: %v", err))
 	}
 
 	result := resultInterface.(*clients.ToolExecutionResult)
 
-	// Return in MCP format
-	// Format the response based on what's available
-	var responseText string
-	if result.Result != nil && result.Result.Body != nil {
-		// Convert body to string representation
-		if bodyStr, ok := result.Result.Body.(string); ok {
-			responseText = bodyStr
-		} else {
-			// Marshal body to JSON string
-			bodyBytes, _ := json.Marshal(result.Result.Body)
-			responseText = string(bodyBytes)
+	// Stream large structured bodies instead of marshaling them whole; legacy
+	// clients do not understand streamed chunks, and templated results are summarized
+	if result.Result != nil && result.Result.Body != nil && h.legacyAdapter(conn) == nil && h.toolOutputTemplate(toolID) == nil {
+		if stream, size := h.shouldStreamResult(result.Result.Body); stream {
+			h.recordToolAudit(ctx, session, tenantID, toolID, action, params.Arguments, startTime,
+				fmt.Sprintf("streamed result (~%d bytes)", size), result.Error)
+			return h.sendStreamedToolResult(conn, msg.ID, result.Result.Body)
 		}
-	} else if result.Error != nil {
-		responseText = fmt.Sprintf("Error: %s", result.Error.Error())
-	} else if result.Result != nil {
-		responseText = fmt.Sprintf("Tool executed successfully (status: %d)", result.Result.StatusCode)
-	} else {
-		responseText = "Tool execution completed"
 	}
 
+	// Return in MCP format, typed by the tool response's content type
+	content, auditText := toolResultContent(params.Name, result)
+
+	// Tools with an output template return a summary instead of the raw result
+	if templated, ok := h.templatedToolContent(toolID, result, content, params.Meta.RawOutput); ok {
+		content = templated
+	}
+
+	h.recordToolAudit(ctx, session, tenantID, toolID, action, params.Arguments, startTime, auditText, result.Error)
+
 	return h.sendResult(conn, msg.ID, map[string]interface{}{
-		"content": []interface{}{
-			map[string]interface{}{
-				"type": "text",
-				"text": responseText,
-			},
-		},
+		"content": content,
 	})
 }
 
@@ -1474,12 +1575,36 @@ func (h *MCPProtocolHandler) getSession(connID string) *MCPSession {
 // removeSession removes a session when connection closes
 func (h *MCPProtocolHandler) RemoveSession(connID string) {
 	h.sessionsMu.Lock()
-	defer h.sessionsMu.Unlock()
 	delete(h.sessions, connID)
+	h.sessionsMu.Unlock()
+
+	h.legacyConnsMu.Lock()
+	defer h.legacyConnsMu.Unlock()
+	for conn, legacy := range h.legacyConns {
+		if legacy.connID == connID {
+			delete(h.legacyConns, conn)
+		}
+	}
+}
+
+// legacyAdapter returns the legacy protocol adapter for a connection, or nil if the
+// client uses a current protocol version
+func (h *MCPProtocolHandler) legacyAdapter(conn *websocket.Conn) *LegacyProtocolAdapter {
+	h.legacyConnsMu.RLock()
+	defer h.legacyConnsMu.RUnlock()
+	return h.legacyConns[conn].adapter
 }
 
 // sendResult sends a successful result response
 func (h *MCPProtocolHandler) sendResult(conn *websocket.Conn, id interface{}, result interface{}) error {
+	if adapter := h.legacyAdapter(conn); adapter != nil {
+		data, err := adapter.EncodeResult(id, result)
+		if err != nil {
+			return err
+		}
+		return conn.Write(context.Background(), websocket.MessageText, data)
+	}
+
 	msg := MCPMessage{
 		JSONRPC: "2.0",
 		ID:      id,
@@ -1499,6 +1624,14 @@ func (h *MCPProtocolHandler) sendResponse(conn *websocket.Conn, id interface{},
 
 // sendError sends an error response
 func (h *MCPProtocolHandler) sendError(conn *websocket.Conn, id interface{}, code int, message string) error {
+	if adapter := h.legacyAdapter(conn); adapter != nil {
+		data, err := adapter.EncodeError(id, code, message)
+		if err != nil {
+			return err
+		}
+		return conn.Write(context.Background(), websocket.MessageText, data)
+	}
+
 	msg := MCPMessage{
 		JSONRPC: "2.0",
 		ID:      id,
@@ -1570,15 +1703,39 @@ func (tc *ToolsCache) Set(tools []interface{}) {
 	tc.lastUpdate = time.Now()
 }
 
+// MCPTelemetryConfig configures how method latencies are retained
+type MCPTelemetryConfig struct {
+	RotationInterval time.Duration `mapstructure:"rotation_interval"` // Length of each latency window
+	Retention        time.Duration `mapstructure:"retention"`         // How far back latency stats look
+	Compression      float64       `mapstructure:"compression"`       // Latency digest accuracy; higher is more precise and larger
+}
+
+// DefaultMCPTelemetryConfig returns default telemetry configuration
+func DefaultMCPTelemetryConfig() MCPTelemetryConfig {
+	return MCPTelemetryConfig{
+		RotationInterval: time.Minute,
+		Retention:        15 * time.Minute,
+		Compression:      DefaultLatencyDigestCompression,
+	}
+}
+
+// latencyWindow holds the latencies recorded during one rotation interval
+type latencyWindow struct {
+	start  time.Time
+	digest *latencyDigest
+}
+
 // MCPTelemetry tracks MCP protocol metrics
 type MCPTelemetry struct {
 	mu      sync.RWMutex
 	logger  observability.Logger
 	metrics observability.MetricsClient
+	config  MCPTelemetryConfig
+	now     func() time.Time
 
 	// Tracking data
 	methodCounts  map[string]uint64
-	methodLatency map[string][]time.Duration
+	methodLatency map[string][]latencyWindow // Oldest first, covering the retention period
 	errorCounts   map[string]uint64
 	totalMessages uint64
 	totalErrors   uint64
@@ -1588,12 +1745,35 @@ type MCPTelemetry struct {
 func NewMCPTelemetry(logger observability.Logger) *MCPTelemetry {
 	return &MCPTelemetry{
 		logger:        logger,
+		config:        DefaultMCPTelemetryConfig(),
+		now:           time.Now,
 		methodCounts:  make(map[string]uint64),
-		methodLatency: make(map[string][]time.Duration),
+		methodLatency: make(map[string][]latencyWindow),
 		errorCounts:   make(map[string]uint64),
 	}
 }
 
+// SetConfig sets the latency retention configuration. Unset fields keep their defaults.
+func (mt *MCPTelemetry) SetConfig(config MCPTelemetryConfig) {
+	defaults := DefaultMCPTelemetryConfig()
+	if config.RotationInterval <= 0 {
+		config.RotationInterval = defaults.RotationInterval
+	}
+	if config.Retention <= 0 {
+		config.Retention = defaults.Retention
+	}
+	if config.Retention < config.RotationInterval {
+		config.Retention = config.RotationInterval
+	}
+	if config.Compression <= 0 {
+		config.Compression = defaults.Compression
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.config = config
+}
+
 // SetMetricsClient sets the metrics client
 func (mt *MCPTelemetry) SetMetricsClient(metrics observability.MetricsClient) {
 	mt.mu.Lock()
@@ -1609,16 +1789,14 @@ func (mt *MCPTelemetry) Record(method string, duration time.Duration, success bo
 	mt.methodCounts[method]++
 	mt.totalMessages++
 
-	// Track latency
-	if _, exists := mt.methodLatency[method]; !exists {
-		mt.methodLatency[method] = make([]time.Duration, 0, 100)
-	}
-	mt.methodLatency[method] = append(mt.methodLatency[method], duration)
-
-	// Keep bounded
-	if len(mt.methodLatency[method]) > 100 {
-		mt.methodLatency[method] = mt.methodLatency[method][1:]
+	// Track latency in the current window, starting a new one each rotation interval
+	now := mt.now()
+	windows := mt.retainedWindows(method, now)
+	if len(windows) == 0 || now.Sub(windows[len(windows)-1].start) >= mt.config.RotationInterval {
+		windows = append(windows, latencyWindow{start: now, digest: newLatencyDigest(mt.config.Compression)})
 	}
+	windows[len(windows)-1].digest.Add(float64(duration) / float64(time.Millisecond))
+	mt.methodLatency[method] = windows
 
 	if !success {
 		mt.errorCounts[method]++
@@ -1628,8 +1806,9 @@ func (mt *MCPTelemetry) Record(method string, duration time.Duration, success bo
 
 // GetStats returns current telemetry statistics
 func (mt *MCPTelemetry) GetStats() map[string]interface{} {
-	mt.mu.RLock()
-	defer mt.mu.RUnlock()
+	// Reading a latency digest compresses it, so stats need the write lock
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
 
 	stats := map[string]interface{}{
 		"total_messages": mt.totalMessages,
@@ -1638,22 +1817,53 @@ func (mt *MCPTelemetry) GetStats() map[string]interface{} {
 		"error_counts":   mt.errorCounts,
 	}
 
-	// Calculate average latencies
+	// Summarize latencies over the retention period, dropping expired windows
+	// so methods no longer called don't hold on to them
+	now := mt.now()
 	avgLatencies := make(map[string]float64)
-	for method, latencies := range mt.methodLatency {
-		if len(latencies) > 0 {
-			var total time.Duration
-			for _, l := range latencies {
-				total += l
-			}
-			avgLatencies[method] = float64(total.Milliseconds()) / float64(len(latencies))
+	percentiles := make(map[string]map[string]float64)
+	for method := range mt.methodLatency {
+		windows := mt.retainedWindows(method, now)
+		if len(windows) == 0 {
+			delete(mt.methodLatency, method)
+			continue
+		}
+		mt.methodLatency[method] = windows
+
+		digest := newLatencyDigest(mt.config.Compression)
+		for _, window := range windows {
+			digest.Merge(window.digest)
+		}
+		if digest.Count() == 0 {
+			continue
+		}
+
+		avgLatencies[method] = digest.Mean()
+		percentiles[method] = map[string]float64{
+			"p50": digest.Quantile(0.50),
+			"p95": digest.Quantile(0.95),
+			"p99": digest.Quantile(0.99),
 		}
 	}
 	stats["avg_latency_ms"] = avgLatencies
+	stats["latency_percentiles_ms"] = percentiles
+	stats["latency_retention_seconds"] = mt.config.Retention.Seconds()
 
 	return stats
 }
 
+// retainedWindows returns a method's latency windows, dropping those older
+// than the retention period. The caller must hold the write lock.
+func (mt *MCPTelemetry) retainedWindows(method string, now time.Time) []latencyWindow {
+	windows := mt.methodLatency[method]
+	expired := 0
+	for expired < len(windows) && now.Sub(windows[expired].start) >= mt.config.Retention {
+		expired++
+	}
+	clear(windows[:expired]) // Release the expired digests
+	return windows[expired:]
+}
+
 // GetMetrics returns comprehensive MCP handler metrics
 func (h *MCPProtocolHandler) GetMetrics() map[string]interface{} {
 	metrics := map[string]interface{}{
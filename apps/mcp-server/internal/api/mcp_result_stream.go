@@ -0,0 +1,214 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/coder/websocket"
+)
+
+// DefaultStreamingResultThreshold is the estimated result size above which tool
+// results are streamed to the client instead of marshaled in one piece
+const DefaultStreamingResultThreshold = 1 << 20 // 1MB
+
+// SetStreamingResultThreshold sets the estimated size in bytes above which tool
+// results are streamed. A value <= 0 disables streaming.
+func (h *MCPProtocolHandler) SetStreamingResultThreshold(threshold int) {
+	h.streamThreshold = threshold
+}
+
+// shouldStreamResult reports whether a tool result body is large enough to stream
+func (h *MCPProtocolHandler) shouldStreamResult(body interface{}) (bool, int) {
+	if h.streamThreshold <= 0 {
+		return false, 0
+	}
+	if _, ok := body.(string); ok {
+		return false, 0
+	}
+	size := estimateJSONSize(body)
+	return size > h.streamThreshold, size
+}
+
+// sendStreamedToolResult writes a tool result to the connection as a single
+// WebSocket message, encoding the body incrementally across frames
+func (h *MCPProtocolHandler) sendStreamedToolResult(conn *websocket.Conn, id interface{}, body interface{}) error {
+	w, err := conn.Writer(context.Background(), websocket.MessageText)
+	if err != nil {
+		return err
+	}
+	if err := writeToolResultStream(w, id, body); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// writeToolResultStream writes the JSON-RPC envelope for a text tool result whose
+// text is the JSON encoding of body. The output is byte-for-byte what sendResult
+// produces for the same content, without holding either encoding in memory.
+func writeToolResultStream(w io.Writer, id interface{}, body interface{}) error {
+	idJSON, err := json.Marshal(id)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriterSize(w, 32*1024)
+	if _, err := fmt.Fprintf(bw, `{"jsonrpc":"2.0","id":%s,"result":{"content":[{"text":"`, idJSON); err != nil {
+		return err
+	}
+	if err := encodeJSONStream(&jsonStringWriter{w: bw}, body); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(bw, `","type":"text"}]}}`); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeJSONStream encodes v to w, walking maps and slices so that only leaf
+// values are marshaled at a time. Map keys are sorted to match encoding/json.
+func encodeJSONStream(w io.Writer, v interface{}) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		if _, err := io.WriteString(w, "{"); err != nil {
+			return err
+		}
+		for i, k := range keys {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := encodeJSONLeaf(w, k); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, ":"); err != nil {
+				return err
+			}
+			if err := encodeJSONStream(w, val[k]); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+	case []interface{}:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		for i, item := range val {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			if err := encodeJSONStream(w, item); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "]")
+		return err
+	default:
+		return encodeJSONLeaf(w, v)
+	}
+}
+
+func encodeJSONLeaf(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// estimateJSONSize approximates the encoded size of v without marshaling containers
+func estimateJSONSize(v interface{}) int {
+	switch val := v.(type) {
+	case nil:
+		return 4
+	case string:
+		return len(val) + 2
+	case bool:
+		return 5
+	case float64, float32, int, int64, int32, uint, uint64, uint32, json.Number:
+		return 8
+	case map[string]interface{}:
+		size := 2
+		for k, item := range val {
+			size += len(k) + 4 + estimateJSONSize(item)
+		}
+		return size
+	case []interface{}:
+		size := 2
+		for _, item := range val {
+			size += 1 + estimateJSONSize(item)
+		}
+		return size
+	default:
+		// Other types are uncommon in decoded tool bodies; fall back to the reflected length
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.String:
+			return rv.Len() * 8
+		}
+		return 8
+	}
+}
+
+// jsonStringWriter escapes everything written to it as JSON string content
+type jsonStringWriter struct {
+	w io.Writer
+}
+
+const hexDigits = "0123456789abcdef"
+
+func (s *jsonStringWriter) Write(p []byte) (int, error) {
+	start := 0
+	for i, b := range p {
+		var escaped []byte
+		switch {
+		case b == '"':
+			escaped = []byte(`\"`)
+		case b == '\\':
+			escaped = []byte(`\\`)
+		case b == '\n':
+			escaped = []byte(`\n`)
+		case b == '\r':
+			escaped = []byte(`\r`)
+		case b == '\t':
+			escaped = []byte(`\t`)
+		case b < 0x20:
+			escaped = []byte{'\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF]}
+		default:
+			continue
+		}
+
+		if start < i {
+			if _, err := s.w.Write(p[start:i]); err != nil {
+				return start, err
+			}
+		}
+		if _, err := s.w.Write(escaped); err != nil {
+			return i, err
+		}
+		start = i + 1
+	}
+
+	if start < len(p) {
+		if _, err := s.w.Write(p[start:]); err != nil {
+			return start, err
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func largeToolBody(items int) map[string]interface{} {
+	records := make([]interface{}, 0, items)
+	for i := 0; i < items; i++ {
+		records = append(records, map[string]interface{}{
+			"id":      float64(i),
+			"name":    fmt.Sprintf("record-%d", i),
+			"notes":   "line one\nline \"two\"\t<tab> & \\ backslash  ",
+			"active":  i%2 == 0,
+			"tags":    []interface{}{"a", "b", nil},
+			"nested":  map[string]interface{}{"score": 0.5 + float64(i)},
+			"unicode": "héllo 世界",
+		})
+	}
+	return map[string]interface{}{
+		"total":   float64(items),
+		"records": records,
+	}
+}
+
+func TestWriteToolResultStreamMatchesMarshal(t *testing.T) {
+	body := largeToolBody(5000)
+
+	var streamed bytes.Buffer
+	require.NoError(t, writeToolResultStream(&streamed, "req-1", body))
+
+	bodyJSON, err := json.Marshal(body)
+	require.NoError(t, err)
+	expected, err := json.Marshal(MCPMessage{
+		JSONRPC: "2.0",
+		ID:      "req-1",
+		Result: map[string]interface{}{
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": string(bodyJSON)},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), streamed.String())
+
+	// Reassembled text decodes back to the original body
+	var msg struct {
+		Result struct {
+			Content []struct {
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(streamed.Bytes(), &msg))
+	require.Len(t, msg.Result.Content, 1)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(msg.Result.Content[0].Text), &decoded))
+	assert.Equal(t, body, decoded)
+}
+
+func TestShouldStreamResult(t *testing.T) {
+	h := &MCPProtocolHandler{streamThreshold: 1024}
+
+	stream, _ := h.shouldStreamResult(map[string]interface{}{"ok": true})
+	assert.False(t, stream)
+
+	stream, size := h.shouldStreamResult(largeToolBody(100))
+	assert.True(t, stream)
+	assert.Greater(t, size, 1024)
+
+	stream, _ = h.shouldStreamResult(strings.Repeat("x", 4096))
+	assert.False(t, stream, "string bodies are sent as-is")
+
+	h.SetStreamingResultThreshold(0)
+	stream, _ = h.shouldStreamResult(largeToolBody(100))
+	assert.False(t, stream)
+}
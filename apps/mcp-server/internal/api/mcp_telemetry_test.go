@@ -0,0 +1,131 @@
+package api
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// exactPercentile returns the nearest-rank percentile of sorted values
+func exactPercentile(sorted []float64, q float64) float64 {
+	rank := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func TestLatencyDigestPercentiles(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+
+	distributions := map[string]func() float64{
+		"uniform": func() float64 { return 1 + rng.Float64()*999 },
+		// Latencies are usually long-tailed
+		"exponential": func() float64 { return 5 + rng.ExpFloat64()*40 },
+		"lognormal":   func() float64 { return math.Exp(3 + rng.NormFloat64()*0.8) },
+	}
+
+	for name, sample := range distributions {
+		t.Run(name, func(t *testing.T) {
+			digest := newLatencyDigest(DefaultLatencyDigestCompression)
+			values := make([]float64, 20000)
+			for i := range values {
+				values[i] = sample()
+				digest.Add(values[i])
+			}
+			sort.Float64s(values)
+
+			assert.Equal(t, float64(len(values)), digest.Count())
+			for _, q := range []float64{0.50, 0.95, 0.99} {
+				expected := exactPercentile(values, q)
+				assert.InEpsilon(t, expected, digest.Quantile(q), 0.02, "p%.0f", q*100)
+			}
+			assert.Equal(t, values[0], digest.Quantile(0))
+			assert.Equal(t, values[len(values)-1], digest.Quantile(1))
+		})
+	}
+}
+
+func TestLatencyDigestMerge(t *testing.T) {
+	merged := newLatencyDigest(DefaultLatencyDigestCompression)
+	for part := 0; part < 4; part++ {
+		digest := newLatencyDigest(DefaultLatencyDigestCompression)
+		for i := 1; i <= 2500; i++ {
+			digest.Add(float64(part*2500 + i))
+		}
+		merged.Merge(digest)
+	}
+
+	assert.Equal(t, float64(10000), merged.Count())
+	assert.InDelta(t, 5000.5, merged.Mean(), 0.001)
+	assert.InEpsilon(t, 5000, merged.Quantile(0.50), 0.01)
+	assert.InEpsilon(t, 9500, merged.Quantile(0.95), 0.01)
+	assert.InEpsilon(t, 9900, merged.Quantile(0.99), 0.01)
+}
+
+func TestMCPTelemetryLatencyPercentiles(t *testing.T) {
+	telemetry := NewMCPTelemetry(observability.NewNoopLogger())
+
+	// 1ms to 1000ms, so each percentile is known
+	for ms := 1; ms <= 1000; ms++ {
+		telemetry.Record("tools/call", time.Duration(ms)*time.Millisecond, true)
+	}
+
+	stats := telemetry.GetStats()
+	percentiles := stats["latency_percentiles_ms"].(map[string]map[string]float64)["tools/call"]
+	require.NotNil(t, percentiles)
+	assert.InEpsilon(t, 500, percentiles["p50"], 0.02)
+	assert.InEpsilon(t, 950, percentiles["p95"], 0.02)
+	assert.InEpsilon(t, 990, percentiles["p99"], 0.02)
+	assert.InDelta(t, 500.5, stats["avg_latency_ms"].(map[string]float64)["tools/call"], 0.001)
+}
+
+func TestMCPTelemetryLatencyRetention(t *testing.T) {
+	telemetry := NewMCPTelemetry(observability.NewNoopLogger())
+	telemetry.SetConfig(MCPTelemetryConfig{RotationInterval: time.Minute, Retention: 5 * time.Minute})
+
+	now := time.Now()
+	telemetry.now = func() time.Time { return now }
+
+	// A slow period followed, after the retention period, by a fast one
+	for i := 0; i < 100; i++ {
+		telemetry.Record("tools/call", 900*time.Millisecond, true)
+	}
+	now = now.Add(3 * time.Minute)
+	for i := 0; i < 100; i++ {
+		telemetry.Record("tools/call", 10*time.Millisecond, true)
+	}
+
+	// Both periods are within the retention period
+	stats := telemetry.GetStats()
+	assert.InDelta(t, 455, stats["avg_latency_ms"].(map[string]float64)["tools/call"], 0.001)
+	assert.Len(t, telemetry.methodLatency["tools/call"], 2)
+
+	// The slow window ages out
+	now = now.Add(3 * time.Minute)
+	stats = telemetry.GetStats()
+	assert.InDelta(t, 10, stats["avg_latency_ms"].(map[string]float64)["tools/call"], 0.001)
+	assert.InDelta(t, 10, stats["latency_percentiles_ms"].(map[string]map[string]float64)["tools/call"]["p99"], 0.001)
+	assert.Len(t, telemetry.methodLatency["tools/call"], 1)
+
+	// Records start a new window after the rotation interval
+	telemetry.Record("tools/call", 10*time.Millisecond, true)
+	assert.Len(t, telemetry.methodLatency["tools/call"], 2)
+
+	// Methods with nothing retained are left out, and their windows dropped
+	// even though they aren't recorded again
+	telemetry.Record("resources/list", 5*time.Millisecond, true)
+	now = now.Add(10 * time.Minute)
+	stats = telemetry.GetStats()
+	assert.NotContains(t, stats["avg_latency_ms"], "tools/call")
+	assert.NotContains(t, stats["avg_latency_ms"], "resources/list")
+	assert.Empty(t, telemetry.methodLatency)
+	assert.Equal(t, uint64(202), stats["total_messages"])
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// toolResultContent converts a tool execution result into MCP content blocks.
+// Images become image blocks and other binary content becomes embedded
+// resources, based on the tool response's content type; everything else is sent
+// as text. It also returns a text summary of the result for auditing.
+func toolResultContent(toolName string, result *clients.ToolExecutionResult) ([]interface{}, string) {
+	if result.Result != nil && result.Result.Body != nil {
+		contentType := toolResponseContentType(result.Result)
+		if body, ok := result.Result.Body.(string); ok && isBinaryContentType(contentType) {
+			return binaryToolContent(toolName, contentType, body)
+		}
+	}
+
+	text := toolResultText(result)
+	return []interface{}{
+		map[string]interface{}{
+			"type": "text",
+			"text": text,
+		},
+	}, text
+}
+
+// toolResultText formats a tool execution result as text
+func toolResultText(result *clients.ToolExecutionResult) string {
+	switch {
+	case result.Result != nil && result.Result.Body != nil:
+		if bodyStr, ok := result.Result.Body.(string); ok {
+			return bodyStr
+		}
+		bodyBytes, _ := json.Marshal(result.Result.Body)
+		return string(bodyBytes)
+	case result.Error != nil:
+		return fmt.Sprintf("Error: %s", result.Error.Error())
+	case result.Result != nil:
+		return fmt.Sprintf("Tool executed successfully (status: %d)", result.Result.StatusCode)
+	default:
+		return "Tool execution completed"
+	}
+}
+
+// binaryToolContent builds the content block for a binary tool result. The body
+// is either base64 encoded data, a URL to the content, or the raw bytes.
+func binaryToolContent(toolName, contentType, body string) ([]interface{}, string) {
+	if isContentURL(body) {
+		block := map[string]interface{}{
+			"type": "resource",
+			"resource": map[string]interface{}{
+				"uri":      body,
+				"mimeType": contentType,
+			},
+		}
+		return []interface{}{block}, fmt.Sprintf("%s content at %s", contentType, body)
+	}
+
+	data := body
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		decoded = []byte(body)
+		data = base64.StdEncoding.EncodeToString(decoded)
+	}
+	summary := fmt.Sprintf("%s content (%d bytes)", contentType, len(decoded))
+
+	if strings.HasPrefix(contentType, "image/") {
+		return []interface{}{
+			map[string]interface{}{
+				"type":     "image",
+				"data":     data,
+				"mimeType": contentType,
+			},
+		}, summary
+	}
+
+	return []interface{}{
+		map[string]interface{}{
+			"type": "resource",
+			"resource": map[string]interface{}{
+				"uri":      fmt.Sprintf("devmesh://tools/%s/result", toolName),
+				"mimeType": contentType,
+				"blob":     data,
+			},
+		},
+	}, summary
+}
+
+// toolResponseContentType returns the media type of a tool response, without parameters
+func toolResponseContentType(resp *models.ToolExecutionResponse) string {
+	for name, values := range resp.Headers {
+		if !strings.EqualFold(name, "Content-Type") || len(values) == 0 {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(values[0])
+		if err != nil {
+			return ""
+		}
+		return mediaType
+	}
+	return ""
+}
+
+// isBinaryContentType reports whether a media type can't be sent as a text block
+func isBinaryContentType(mediaType string) bool {
+	switch {
+	case mediaType == "":
+		return false
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		strings.HasSuffix(mediaType, "+json"),
+		mediaType == "application/xml",
+		strings.HasSuffix(mediaType, "+xml"),
+		mediaType == "application/javascript",
+		mediaType == "application/x-www-form-urlencoded":
+		return false
+	default:
+		return true
+	}
+}
+
+// isContentURL reports whether a tool result body is a link to the content
+func isContentURL(body string) bool {
+	if !strings.HasPrefix(body, "http://") && !strings.HasPrefix(body, "https://") {
+		return false
+	}
+	u, err := url.Parse(body)
+	return err == nil && u.Host != ""
+}
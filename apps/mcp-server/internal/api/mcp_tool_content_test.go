@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+func toolResultWithBody(contentType string, body interface{}) *clients.ToolExecutionResult {
+	return &clients.ToolExecutionResult{
+		Result: &models.ToolExecutionResponse{
+			Success:    true,
+			StatusCode: 200,
+			Headers:    map[string][]string{"content-type": {contentType}},
+			Body:       body,
+		},
+	}
+}
+
+func TestToolResultContent(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+	encoded := base64.StdEncoding.EncodeToString(png)
+
+	t.Run("image produces an image block", func(t *testing.T) {
+		content, audit := toolResultContent("render_chart", toolResultWithBody("image/png", encoded))
+		require.Len(t, content, 1)
+
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "image", block["type"])
+		assert.Equal(t, encoded, block["data"])
+		assert.Equal(t, "image/png", block["mimeType"])
+		assert.NotContains(t, block, "text")
+		assert.Equal(t, "image/png content (8 bytes)", audit)
+	})
+
+	t.Run("raw image bytes are base64 encoded", func(t *testing.T) {
+		content, _ := toolResultContent("render_chart", toolResultWithBody("image/png; charset=binary", string(png)))
+
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "image", block["type"])
+		assert.Equal(t, encoded, block["data"])
+		assert.Equal(t, "image/png", block["mimeType"])
+	})
+
+	t.Run("image URL produces a resource link", func(t *testing.T) {
+		content, _ := toolResultContent("render_chart", toolResultWithBody("image/jpeg", "https://cdn.example.com/chart.jpg"))
+
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "resource", block["type"])
+		assert.Equal(t, map[string]interface{}{
+			"uri":      "https://cdn.example.com/chart.jpg",
+			"mimeType": "image/jpeg",
+		}, block["resource"])
+	})
+
+	t.Run("binary file produces an embedded resource", func(t *testing.T) {
+		content, _ := toolResultContent("export_report", toolResultWithBody("application/pdf", encoded))
+
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "resource", block["type"])
+		resource := block["resource"].(map[string]interface{})
+		assert.Equal(t, "devmesh://tools/export_report/result", resource["uri"])
+		assert.Equal(t, "application/pdf", resource["mimeType"])
+		assert.Equal(t, encoded, resource["blob"])
+	})
+
+	t.Run("JSON stays text", func(t *testing.T) {
+		content, audit := toolResultContent("list_repos", toolResultWithBody("application/json", map[string]interface{}{"name": "mesh"}))
+
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "text", block["type"])
+		assert.Equal(t, `{"name":"mesh"}`, block["text"])
+		assert.Equal(t, `{"name":"mesh"}`, audit)
+	})
+
+	t.Run("missing content type stays text", func(t *testing.T) {
+		result := toolResultWithBody("", "plain output")
+		result.Result.Headers = nil
+
+		content, _ := toolResultContent("echo", result)
+		block := content[0].(map[string]interface{})
+		assert.Equal(t, "text", block["type"])
+		assert.Equal(t, "plain output", block["text"])
+	})
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// ToolOutputTemplateConfigKey is the tool config key holding a Go template that
+// turns the tool's raw result into an agent-friendly summary
+const ToolOutputTemplateConfigKey = "output_template"
+
+// toolOutputTemplateFuncs are available to output templates
+var toolOutputTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(items []interface{}, sep string) string {
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, sep)
+	},
+}
+
+// parseToolOutputTemplate parses a tool's output template. Tools without one
+// return nil, so their results are sent as is.
+func parseToolOutputTemplate(tool *models.DynamicTool) (*template.Template, error) {
+	source, _ := tool.Config[ToolOutputTemplateConfigKey].(string)
+	if strings.TrimSpace(source) == "" {
+		return nil, nil
+	}
+
+	tmpl, err := template.New(tool.ToolName).Funcs(toolOutputTemplateFuncs).Option("missingkey=zero").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template for tool %s: %w", tool.ToolName, err)
+	}
+	return tmpl, nil
+}
+
+// renderToolOutput applies an output template to a tool result body. JSON string
+// bodies are decoded first so templates can address their fields.
+func renderToolOutput(tmpl *template.Template, result *clients.ToolExecutionResult) (string, error) {
+	if result.Result == nil || result.Result.Body == nil {
+		return "", fmt.Errorf("tool result has no body to template")
+	}
+
+	data := result.Result.Body
+	if body, ok := data.(string); ok {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(body), &decoded); err == nil {
+			data = decoded
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render output template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// toolOutputTemplate returns the cached output template for a tool, if any
+func (h *MCPProtocolHandler) toolOutputTemplate(toolID string) *template.Template {
+	h.toolNameCacheMu.RLock()
+	defer h.toolNameCacheMu.RUnlock()
+	return h.toolOutputTemplates[toolID]
+}
+
+// templatedToolContent renders a tool's result through its output template. The
+// raw content follows the summary when requested. ok is false when the tool has
+// no template or rendering fails, in which case the raw content should be sent.
+func (h *MCPProtocolHandler) templatedToolContent(toolID string, result *clients.ToolExecutionResult, raw []interface{}, includeRaw bool) ([]interface{}, bool) {
+	tmpl := h.toolOutputTemplate(toolID)
+	if tmpl == nil || result.Error != nil {
+		return nil, false
+	}
+
+	summary, err := renderToolOutput(tmpl, result)
+	if err != nil {
+		h.logger.Warn("Sending raw tool result", map[string]interface{}{
+			"tool_id": toolID,
+			"error":   err.Error(),
+		})
+		return nil, false
+	}
+
+	content := []interface{}{
+		map[string]interface{}{
+			"type": "text",
+			"text": summary,
+		},
+	}
+	if includeRaw {
+		content = append(content, raw...)
+	}
+	return content, true
+}
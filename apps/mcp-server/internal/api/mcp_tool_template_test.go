@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+const pullRequestsTemplate = `{{len .}} open pull requests:
+{{range .}}- #{{.number}} {{.title}} by {{.user.login}} [{{join .labels ", "}}]
+{{end}}`
+
+const pullRequestsBody = `[
+	{"number": 12, "title": "Fix login redirect", "user": {"login": "ana"}, "labels": ["bug", "auth"], "url": "https://example.com/12"},
+	{"number": 15, "title": "Add dark mode", "user": {"login": "kai"}, "labels": ["ui"], "url": "https://example.com/15"}
+]`
+
+func newTemplatedToolHandler(t *testing.T) *MCPProtocolHandler {
+	mockClient := new(MockRESTAPIClient)
+	mockClient.On("ListTools", mock.Anything, "tenant-1").Return([]*models.DynamicTool{
+		{ID: "tool-prs", ToolName: "list_prs", Config: map[string]interface{}{ToolOutputTemplateConfigKey: pullRequestsTemplate}},
+		{ID: "tool-raw", ToolName: "get_repo", Config: map[string]interface{}{}},
+		{ID: "tool-broken", ToolName: "broken", Config: map[string]interface{}{ToolOutputTemplateConfigKey: "{{.unclosed"}},
+	}, nil)
+
+	handler := NewMCPProtocolHandler(mockClient, observability.NewNoopLogger())
+	_, err := handler.resolveToolNameToID(context.Background(), "tenant-1", "list_prs")
+	require.NoError(t, err)
+	return handler
+}
+
+func TestTemplatedToolContent(t *testing.T) {
+	handler := newTemplatedToolHandler(t)
+	result := toolResultWithBody("application/json", pullRequestsBody)
+	raw, _ := toolResultContent("list_prs", result)
+
+	t.Run("template summarizes the raw result", func(t *testing.T) {
+		content, ok := handler.templatedToolContent("tool-prs", result, raw, false)
+		require.True(t, ok)
+		require.Len(t, content, 1)
+		assert.Equal(t, map[string]interface{}{
+			"type": "text",
+			"text": "2 open pull requests:\n- #12 Fix login redirect by ana [bug, auth]\n- #15 Add dark mode by kai [ui]\n",
+		}, content[0])
+	})
+
+	t.Run("raw output follows the summary on request", func(t *testing.T) {
+		content, ok := handler.templatedToolContent("tool-prs", result, raw, true)
+		require.True(t, ok)
+		require.Len(t, content, 2)
+		assert.Equal(t, raw[0], content[1])
+		assert.Contains(t, content[1].(map[string]interface{})["text"], `"url": "https://example.com/12"`)
+	})
+
+	t.Run("structured bodies are templated directly", func(t *testing.T) {
+		structured := toolResultWithBody("application/json", []interface{}{
+			map[string]interface{}{"number": 7, "title": "Bump deps", "user": map[string]interface{}{"login": "lee"}, "labels": []interface{}{}},
+		})
+		content, ok := handler.templatedToolContent("tool-prs", structured, nil, false)
+		require.True(t, ok)
+		assert.Equal(t, "1 open pull requests:\n- #7 Bump deps by lee []\n", content[0].(map[string]interface{})["text"])
+	})
+
+	t.Run("tools without a template return raw output", func(t *testing.T) {
+		_, ok := handler.templatedToolContent("tool-raw", result, raw, false)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid templates are ignored", func(t *testing.T) {
+		_, ok := handler.templatedToolContent("tool-broken", result, raw, false)
+		assert.False(t, ok)
+	})
+
+	t.Run("results the template can't render fall back to raw output", func(t *testing.T) {
+		_, ok := handler.templatedToolContent("tool-prs", toolResultWithBody("application/json", `{"message": "rate limited"}`), raw, false)
+		assert.False(t, ok)
+	})
+}
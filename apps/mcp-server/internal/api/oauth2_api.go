@@ -0,0 +1,133 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/auth/oauth2"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// oauth2LoginCookie carries a sign-in's state and PKCE code verifier from the
+// authorize redirect to the callback, so any instance can complete the sign-in
+const oauth2LoginCookie = "mesh_oauth2_login"
+
+// oauth2LoginMaxAge is how long a user has to sign in at the provider, in seconds
+const oauth2LoginMaxAge = 600
+
+// OAuth2API signs users in through a corporate identity provider and issues
+// them a JWT for the MCP API
+type OAuth2API struct {
+	handler     *oauth2.OAuth2Handler
+	authService *auth.Service
+	logger      observability.Logger
+}
+
+// NewOAuth2API creates a new OAuth2 API handler
+func NewOAuth2API(handler *oauth2.OAuth2Handler, authService *auth.Service, logger observability.Logger) *OAuth2API {
+	return &OAuth2API{
+		handler:     handler,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the OAuth2 sign-in routes. They are public, as
+// they are how users without credentials get them.
+func (api *OAuth2API) RegisterRoutes(router gin.IRouter) {
+	oauth2Routes := router.Group("/oauth2")
+	{
+		oauth2Routes.GET("/authorize", api.authorize)
+		oauth2Routes.GET("/callback", api.callback)
+	}
+}
+
+// authorize starts a sign-in by redirecting to the provider
+func (api *OAuth2API) authorize(c *gin.Context) {
+	state, err := oauth2.NewState()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sign-in"})
+		return
+	}
+	verifier, err := oauth2.NewCodeVerifier()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start sign-in"})
+		return
+	}
+
+	config := api.handler.Config()
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauth2LoginCookie, state+"."+verifier, oauth2LoginMaxAge, "/oauth2", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusFound, api.handler.AuthorizeURL(config.ClientID, config.RedirectURI, state, oauth2.CodeChallenge(verifier)))
+}
+
+// callback completes a sign-in, exchanging the provider's authorization code
+// for tokens and the validated ID token for an MCP JWT
+func (api *OAuth2API) callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":       providerErr,
+			"description": c.Query("error_description"),
+		})
+		return
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code and state are required"})
+		return
+	}
+
+	login, err := c.Cookie(oauth2LoginCookie)
+	expectedState, verifier, ok := strings.Cut(login, ".")
+	if err != nil || !ok || subtle.ConstantTimeCompare([]byte(state), []byte(expectedState)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sign-in expired or was started elsewhere"})
+		return
+	}
+	// The code verifier is single use
+	c.SetCookie(oauth2LoginCookie, "", -1, "/oauth2", "", c.Request.TLS != nil, true)
+
+	tokens, err := api.handler.ExchangeCode(c.Request.Context(), code, verifier)
+	if err != nil {
+		api.logger.Warn("OAuth2 code exchange failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "sign-in failed"})
+		return
+	}
+
+	user, err := api.handler.User(tokens.Claims)
+	if err != nil {
+		api.logger.Warn("OAuth2 claims don't map to a user", map[string]interface{}{
+			"subject": tokens.Claims.Subject,
+			"error":   err.Error(),
+		})
+		c.JSON(http.StatusForbidden, gin.H{"error": "signed-in user has no access"})
+		return
+	}
+
+	token, err := api.authService.GenerateJWT(c.Request.Context(), user)
+	if err != nil {
+		api.logger.Error("Failed to issue JWT for OAuth2 sign-in", map[string]interface{}{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+
+	api.logger.Info("User signed in through OAuth2", map[string]interface{}{
+		"user_id":   user.ID,
+		"tenant_id": user.TenantID,
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"user":         user,
+	})
+}
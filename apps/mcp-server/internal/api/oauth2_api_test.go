@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/auth/oauth2"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+const testOAuth2Tenant = "00000000-0000-0000-0000-000000000001"
+
+// newTestIdentityProvider serves a JWKS and a token endpoint that accepts the
+// code "good-code" when it comes with the verifier whose challenge was registered
+func newTestIdentityProvider(t *testing.T, challenge *string) *httptest.Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "idp-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != "good-code" || oauth2.CodeChallenge(r.PostForm.Get("code_verifier")) != *challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   server.URL,
+			"sub":   "okta-user-1",
+			"aud":   "mesh-client",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"email": "dev@example.com",
+			"scp":   []string{"read", "write"},
+		})
+		idToken.Header["kid"] = "idp-key"
+		signed, err := idToken.SignedString(key)
+		require.NoError(t, err)
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "idp-access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     signed,
+		})
+	})
+	server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOAuth2API(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var challenge string
+	idp := newTestIdentityProvider(t, &challenge)
+	handler, err := oauth2.NewOAuth2Handler(oauth2.ProviderConfig{
+		Issuer:                idp.URL,
+		AuthorizationEndpoint: idp.URL + "/authorize",
+		TokenEndpoint:         idp.URL + "/token",
+		JWKSURL:               idp.URL + "/jwks",
+		ClientID:              "mesh-client",
+		RedirectURI:           "https://mesh.example.com/oauth2/callback",
+		DefaultTenantID:       testOAuth2Tenant,
+	}, nil)
+	require.NoError(t, err)
+
+	authConfig := auth.DefaultConfig()
+	authConfig.JWTSecret = "oauth2-test-secret-at-least-32-characters"
+	authService := auth.NewService(authConfig, nil, nil, observability.NewNoopLogger())
+
+	router := gin.New()
+	NewOAuth2API(handler, authService, observability.NewNoopLogger()).RegisterRoutes(router)
+
+	get := func(target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Start a sign-in
+	w := get("/oauth2/authorize")
+	require.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	state := location.Query().Get("state")
+	challenge = location.Query().Get("code_challenge")
+	require.NotEmpty(t, state)
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	loginCookie := cookies[0]
+	assert.True(t, loginCookie.HttpOnly)
+
+	t.Run("state mismatch", func(t *testing.T) {
+		w := get("/oauth2/callback?code=good-code&state=other", loginCookie)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("missing login cookie", func(t *testing.T) {
+		w := get("/oauth2/callback?code=good-code&state=" + url.QueryEscape(state))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("provider error", func(t *testing.T) {
+		w := get("/oauth2/callback?error=access_denied", loginCookie)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("rejected code", func(t *testing.T) {
+		w := get("/oauth2/callback?code=bad-code&state="+url.QueryEscape(state), loginCookie)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("signed in", func(t *testing.T) {
+		w := get("/oauth2/callback?code=good-code&state="+url.QueryEscape(state), loginCookie)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response struct {
+			AccessToken string `json:"access_token"`
+			TokenType   string `json:"token_type"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Bearer", response.TokenType)
+
+		user, err := authService.ValidateJWT(context.Background(), response.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "dev@example.com", user.Email)
+		assert.Equal(t, testOAuth2Tenant, user.TenantID.String())
+		assert.Equal(t, []string{"read", "write"}, user.Scopes)
+
+		// The login cookie is cleared once used
+		cleared := w.Result().Cookies()
+		require.Len(t, cleared, 1)
+		assert.Equal(t, "", cleared[0].Value)
+		assert.Negative(t, cleared[0].MaxAge)
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/developer-mesh/developer-mesh/apps/mcp-server/internal/core"
 
 	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/auth/oauth2"
 	"github.com/developer-mesh/developer-mesh/pkg/client/rest"
 	"github.com/developer-mesh/developer-mesh/pkg/clients"
 	"github.com/developer-mesh/developer-mesh/pkg/common/cache"
@@ -25,11 +26,13 @@ import (
 	"github.com/developer-mesh/developer-mesh/pkg/repository"
 	"github.com/developer-mesh/developer-mesh/pkg/repository/agent"
 	"github.com/developer-mesh/developer-mesh/pkg/security"
+	securitytls "github.com/developer-mesh/developer-mesh/pkg/security/tls"
 	pgservices "github.com/developer-mesh/developer-mesh/pkg/services"
 	pkgtools "github.com/developer-mesh/developer-mesh/pkg/tools"
 	"github.com/developer-mesh/developer-mesh/pkg/tools/adapters"
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -76,6 +79,8 @@ type Server struct {
 	dynamicToolsV2       *DynamicToolsV2Wrapper // New implementation
 	healthCheckScheduler *pkgtools.HealthCheckScheduler
 	encryptionService    *security.EncryptionService
+	// OAuth2 sign-in through a corporate identity provider
+	oauth2API *OAuth2API
 	// MCP Protocol handler
 	mcpProtocolHandler *MCPProtocolHandler
 	// Adaptive Protocol Intelligence Layer
@@ -140,8 +145,30 @@ func NewServer(engine *core.Engine, cfg Config, db *sqlx.DB, cacheClient cache.C
 	authConfig.EnableAPIKeys = true
 	authConfig.EnableJWT = true
 
+	authConfig.AutoProvisionTenants = cfg.Auth.AutoProvisionTenants
+	authConfig.HashAlgorithm = auth.KeyHashAlgorithm(cfg.Auth.APIKeyHashAlgorithm)
+
 	// Create auth service with cache
 	authService := auth.NewService(authConfig, db, cacheClient, observability.DefaultLogger)
+	if cfg.Auth.AutoProvisionTenants && db != nil {
+		authService.AddTenantProvisioningHook(auth.DefaultTenantResourcesHook(db))
+	}
+
+	// Enforce per-key request limits and audit auth decisions when the shared
+	// cache is Redis-backed
+	var redisClient redis.UniversalClient
+	switch rc := cacheClient.(type) {
+	case *cache.RedisCache:
+		redisClient = rc.GetClient()
+	case *cache.RedisClusterCache:
+		redisClient = rc.GetClient()
+	}
+	if redisClient != nil {
+		authService.SetAPIKeyRateLimiter(auth.NewRedisRateLimiter(redisClient))
+		if cfg.Auth.AuditStream != "" {
+			authService.SetAuditSink(auth.NewRedisStreamAuditSink(redisClient, cfg.Auth.AuditStream, cfg.Auth.AuditStreamMaxLen, observability.DefaultLogger))
+		}
+	}
 
 	// Setup enhanced authentication with rate limiting, metrics, and audit logging
 	authMiddleware, err := auth.SetupAuthentication(db, cacheClient, observability.DefaultLogger, metrics)
@@ -258,6 +285,18 @@ func NewServer(engine *core.Engine, cfg Config, db *sqlx.DB, cacheClient cache.C
 		modelAPIProxy:  modelProxy,
 	}
 
+	// Enable OAuth2 sign-in if an identity provider is configured
+	if cfg.Auth.OAuth2 != nil {
+		oauth2Handler, err := oauth2.NewOAuth2Handler(*cfg.Auth.OAuth2, nil)
+		if err != nil {
+			observability.DefaultLogger.Error("Failed to configure OAuth2 sign-in", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			s.oauth2API = NewOAuth2API(oauth2Handler, authService, observability.DefaultLogger)
+		}
+	}
+
 	// Initialize MCP protocol handler if REST API is enabled
 	if restAPIEnabled && restClientFactory != nil {
 		restAPIClient := clients.NewRESTAPIClient(clients.RESTClientConfig{
@@ -266,6 +305,7 @@ func NewServer(engine *core.Engine, cfg Config, db *sqlx.DB, cacheClient cache.C
 			Logger:  observability.DefaultLogger,
 		})
 		s.mcpProtocolHandler = NewMCPProtocolHandler(restAPIClient, observability.DefaultLogger)
+		s.mcpProtocolHandler.SetTelemetryConfig(cfg.MCPTelemetry)
 		observability.DefaultLogger.Info("MCP protocol handler initialized", nil)
 	}
 
@@ -280,12 +320,48 @@ func NewServer(engine *core.Engine, cfg Config, db *sqlx.DB, cacheClient cache.C
 			MaxMessageSize:  cfg.WebSocket.MaxMessageSize,
 			Security:        cfg.WebSocket.Security,
 			RateLimit:       cfg.WebSocket.RateLimit,
+
+			ContextCheckpoint:  cfg.WebSocket.ContextCheckpoint,
+			BroadcastRateLimit: cfg.WebSocket.BroadcastRateLimit,
+			ToolQuota:          cfg.WebSocket.ToolQuota,
+			ToolOutputLimit:    cfg.WebSocket.ToolOutputLimit,
+			ToolRetry:          cfg.WebSocket.ToolRetry,
+			ContextTokenBudget: cfg.WebSocket.ContextTokenBudget,
+			ToolMetadata:       cfg.WebSocket.ToolMetadata,
+
+			WorkflowPortability:   cfg.WebSocket.WorkflowPortability,
+			CompressionDictionary: cfg.WebSocket.CompressionDictionary,
+			RequestDedup:          cfg.WebSocket.RequestDedup,
+			Keepalive:             cfg.WebSocket.Keepalive,
+			SharedToolCatalog:     cfg.WebSocket.SharedToolCatalog,
+			SubscriptionCleanup:   cfg.WebSocket.SubscriptionCleanup,
+			ConnectionWarmup:      cfg.WebSocket.ConnectionWarmup,
+			WorkflowLimits:        cfg.WebSocket.WorkflowLimits,
+			WorkflowResubscribe:   cfg.WebSocket.WorkflowResubscribe,
+			ScopeElevation:        cfg.WebSocket.ScopeElevation,
+
+			ContextMetadataSchemas: cfg.WebSocket.ContextMetadataSchemas,
+			MethodSchemas:          cfg.WebSocket.MethodSchemas,
 		}
 
 		s.wsServer = websocket.NewServer(authService, metrics, observability.DefaultLogger, wsConfig)
 
+		// Share a single tool execution audit trail between WebSocket and MCP tool calls
+		toolAuditStore := auth.NewInMemoryToolAuditStore(0, nil)
+		s.wsServer.SetToolAuditStore(toolAuditStore)
+
+		// Verify client certificates during the TLS handshake when mTLS is enabled
+		if cfg.ClientCertAuth.Enabled {
+			if err := s.configureClientCertAuth(cfg.ClientCertAuth); err != nil {
+				observability.DefaultLogger.Error("Failed to enable client certificate authentication", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+
 		// Set MCP handler if available
 		if s.mcpProtocolHandler != nil {
+			s.mcpProtocolHandler.SetToolAuditStore(toolAuditStore)
 			s.wsServer.SetMCPHandler(s.mcpProtocolHandler)
 
 			// Initialize APIL if enabled
@@ -482,6 +558,9 @@ func (s *Server) Initialize(ctx context.Context) error {
 		}
 	}
 
+	// Deactivate rotated API keys once their grace period ends
+	go s.authService.RunAPIKeyRotationSweeper(ctx, time.Minute)
+
 	// Initialize routes
 	s.setupRoutes()
 
@@ -537,6 +616,12 @@ func (s *Server) setupRoutes() {
 		})
 	}
 
+	// OAuth2 sign-in routes are public
+	if s.oauth2API != nil {
+		s.oauth2API.RegisterRoutes(s.router)
+		s.logger.Info("OAuth2 sign-in enabled at /oauth2/authorize", nil)
+	}
+
 	// Setup API documentation
 	// Create API versioned routes
 	baseURL := ""
@@ -587,6 +672,9 @@ func (s *Server) setupRoutes() {
 		wsMonitoring := websocket.NewMonitoringEndpoints(s.wsServer)
 		wsMonitoring.RegisterRoutes(v1)
 		s.logger.Info("WebSocket monitoring routes registered", nil)
+
+		// Full results of tool output truncated by size limits
+		websocket.NewToolResultEndpoints(s.wsServer).RegisterRoutes(v1)
 	}
 
 	// Register APIL monitoring routes
@@ -673,6 +761,29 @@ func (s *Server) GetListenAddress() string {
 	return s.server.Addr
 }
 
+// configureClientCertAuth enables mutual TLS on the HTTP server and lets WebSocket
+// connections authenticate with the tenant/agent identity in their client certificate
+func (s *Server) configureClientCertAuth(certConfig securitytls.ClientCertConfig) error {
+	certAuth, err := securitytls.NewClientCertAuthenticator(certConfig)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := securitytls.DefaultConfig().BuildTLSConfig()
+	if err != nil {
+		return fmt.Errorf("failed to build TLS config: %w", err)
+	}
+	certAuth.ConfigureTLS(tlsConfig)
+
+	s.server.TLSConfig = tlsConfig
+	s.wsServer.SetClientCertAuthenticator(certAuth)
+
+	s.logger.Info("Client certificate authentication enabled", map[string]interface{}{
+		"mode": certAuth.Mode(),
+	})
+	return nil
+}
+
 // Start starts the API server without TLS
 func (s *Server) Start() error {
 	// Start without TLS
@@ -82,6 +82,11 @@ func (a *contextManagerAdapter) TruncateContext(ctx context.Context, contextID s
 
 // CreateContext implements websocket.ContextManager
 func (a *contextManagerAdapter) CreateContext(ctx context.Context, agentID, tenantID, name, content, modelID string) (*models.Context, error) {
+	return a.CreateContextWithMetadata(ctx, agentID, tenantID, name, content, modelID, nil)
+}
+
+// CreateContextWithMetadata implements websocket.ContextMetadataManager
+func (a *contextManagerAdapter) CreateContextWithMetadata(ctx context.Context, agentID, tenantID, name, content, modelID string, metadata map[string]interface{}) (*models.Context, error) {
 	// Create a new context
 	newContext := &models.Context{
 		Name:     name,
@@ -94,11 +99,21 @@ func (a *contextManagerAdapter) CreateContext(ctx context.Context, agentID, tena
 				Role:    "system",
 			},
 		},
+		Metadata: metadata,
 	}
 
 	return a.coreManager.CreateContext(ctx, newContext)
 }
 
+// UpdateContextMetadata implements websocket.ContextMetadataManager
+func (a *contextManagerAdapter) UpdateContextMetadata(ctx context.Context, contextID string, metadata map[string]interface{}) (*models.Context, error) {
+	updateData := &models.Context{
+		Metadata: metadata,
+	}
+
+	return a.coreManager.UpdateContext(ctx, contextID, updateData, &models.ContextUpdateOptions{})
+}
+
 // AppendToContext appends content to an existing context
 func (a *contextManagerAdapter) AppendToContext(ctx context.Context, contextID string, content string) (*models.Context, error) {
 	// Get current context
@@ -121,6 +136,38 @@ func (a *contextManagerAdapter) AppendToContext(ctx context.Context, contextID s
 	return a.coreManager.UpdateContext(ctx, contextID, currentContext, options)
 }
 
+// AppendItemToContext implements websocket.ContextItemAppender. Only the new
+// item is sent, so the context's token count grows by the item's tokens.
+func (a *contextManagerAdapter) AppendItemToContext(ctx context.Context, contextID string, item models.ContextItem) (*models.Context, error) {
+	updateData := &models.Context{
+		Content: []models.ContextItem{item},
+	}
+
+	return a.coreManager.UpdateContext(ctx, contextID, updateData, &models.ContextUpdateOptions{})
+}
+
+// AppendManyToContext implements websocket.ContextBatchAppender, appending
+// every content in a single context update
+func (a *contextManagerAdapter) AppendManyToContext(ctx context.Context, contextID string, contents []string) (*models.Context, error) {
+	currentContext, err := a.coreManager.GetContext(ctx, contextID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, content := range contents {
+		currentContext.Content = append(currentContext.Content, models.ContextItem{
+			Content: content,
+			Role:    "user",
+		})
+	}
+
+	options := &models.ContextUpdateOptions{
+		Truncate: false,
+	}
+
+	return a.coreManager.UpdateContext(ctx, contextID, currentContext, options)
+}
+
 // GetContextStats returns statistics for a context
 func (a *contextManagerAdapter) GetContextStats(ctx context.Context, contextID string) (*ContextStats, error) {
 	// Get context to calculate stats
@@ -92,8 +92,9 @@ func (sm *SessionManager) RemoveSessionKey(connectionID string) {
 	delete(sm.keys, connectionID)
 }
 
-// ValidateConnection performs initial authentication
-func (s *Server) ValidateConnection(token string) (*auth.Claims, error) {
+// ValidateConnection performs initial authentication. API keys bound to an
+// allowlist are checked against the address carried in ctx.
+func (s *Server) ValidateConnection(ctx context.Context, token string) (*auth.Claims, error) {
 	// Check if auth service is available
 	if s.auth == nil {
 		// Fall back to local JWT validation if no auth service
@@ -124,7 +125,7 @@ func (s *Server) ValidateConnection(token string) (*auth.Claims, error) {
 	// Use auth service for validation
 	// Try to validate as JWT first
 	if strings.Count(token, ".") == 2 { // Looks like a JWT
-		user, err := s.auth.ValidateJWT(context.Background(), token)
+		user, err := s.auth.ValidateJWT(ctx, token)
 		if err == nil {
 			// Convert User to Claims
 			return &auth.Claims{
@@ -139,16 +140,17 @@ func (s *Server) ValidateConnection(token string) (*auth.Claims, error) {
 	}
 
 	// Try as API key
-	user, err := s.auth.ValidateAPIKey(context.Background(), token)
+	user, err := s.auth.ValidateAPIKey(ctx, token)
 	if err == nil {
 		// Convert User to Claims
 		return &auth.Claims{
 			RegisteredClaims: jwt.RegisteredClaims{
 				Subject: user.ID.String(),
 			},
-			TenantID: user.TenantID.String(),
-			UserID:   user.ID.String(),
-			Scopes:   user.Scopes,
+			TenantID:  user.TenantID.String(),
+			UserID:    user.ID.String(),
+			Scopes:    user.Scopes,
+			KeyPrefix: apiKeyPrefix(user),
 		}, nil
 	}
 
@@ -212,6 +214,12 @@ func (s *Server) ValidateAPIKey(key string) bool {
 	return false
 }
 
+// apiKeyPrefix returns the prefix of the API key a user was validated with
+func apiKeyPrefix(user *auth.User) string {
+	prefix, _ := user.Metadata["key_prefix"].(string)
+	return prefix
+}
+
 // CheckIPWhitelist validates IP address against whitelist
 func (s *Server) CheckIPWhitelist(ip string) bool {
 	// If no whitelist configured, allow all
@@ -248,7 +256,7 @@ func (s *Server) EnhancedAuth(ctx context.Context, token string, clientIP string
 	}
 
 	// Validate authentication token
-	claims, err := s.ValidateConnection(token)
+	claims, err := s.ValidateConnection(context.WithValue(ctx, auth.ContextKeyIPAddress, clientIP), token)
 	if err != nil {
 		s.metricsCollector.RecordError("auth_failed")
 		return nil, err
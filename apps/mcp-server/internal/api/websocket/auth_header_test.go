@@ -4,8 +4,11 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -201,3 +204,42 @@ func TestAuthConsistencyBetweenRESTAndWebSocket(t *testing.T) {
 		assert.Equal(t, user.TenantID.String(), claims.TenantID, "Tenant ID mismatch for header set %d", i)
 	}
 }
+
+// exhaustedRateLimiter rejects every request until resetAt
+type exhaustedRateLimiter struct {
+	resetAt time.Time
+}
+
+func (l exhaustedRateLimiter) Check(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	return false, 0, l.resetAt, nil
+}
+
+// TestHandleWebSocketAPIKeyRateLimited verifies a key over its request limit is
+// refused with 429 and a Retry-After header rather than 401
+func TestHandleWebSocketAPIKeyRateLimited(t *testing.T) {
+	logger := observability.NewNoopLogger()
+	authService := auth.NewService(auth.DefaultConfig(), nil, nil, logger)
+	authService.SetAPIKeyRateLimiter(exhaustedRateLimiter{resetAt: time.Now().Add(30 * time.Second)})
+
+	rateLimit := 10
+	key, err := authService.CreateAPIKeyWithType(context.Background(), auth.CreateAPIKeyRequest{
+		Name:      "rate-limited",
+		TenantID:  uuid.New().String(),
+		KeyType:   auth.KeyTypeUser,
+		Scopes:    []string{"read"},
+		RateLimit: &rateLimit,
+	})
+	require.NoError(t, err)
+
+	server := NewServer(authService, observability.NewNoOpMetricsClient(), logger, Config{MaxConnections: 10})
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Authorization", "Bearer "+key.Key)
+	rec := httptest.NewRecorder()
+	server.HandleWebSocket(rec, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 30, retryAfter, 1)
+}
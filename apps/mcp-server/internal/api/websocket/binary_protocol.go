@@ -22,11 +22,13 @@ const (
 const (
 	FlagCompressed = 1 << 0
 	FlagEncrypted  = 1 << 1
+	FlagDictionary = 1 << 2 // Compressed with the dictionary whose version is in the reserved header bytes
 )
 
 // BinaryEncoder handles binary message encoding
 type BinaryEncoder struct {
 	compressionThreshold int
+	dictionary           *CompressionDictionary
 }
 
 // NewBinaryEncoder creates a new binary encoder
@@ -36,6 +38,15 @@ func NewBinaryEncoder(compressionThreshold int) *BinaryEncoder {
 	}
 }
 
+// NewBinaryEncoderWithDictionary creates a binary encoder that compresses with a
+// shared dictionary. A nil dictionary falls back to plain compression.
+func NewBinaryEncoderWithDictionary(compressionThreshold int, dictionary *CompressionDictionary) *BinaryEncoder {
+	return &BinaryEncoder{
+		compressionThreshold: compressionThreshold,
+		dictionary:           dictionary,
+	}
+}
+
 // Encode encodes a message to binary format
 func (be *BinaryEncoder) Encode(msg *ws.Message) ([]byte, error) {
 	// Marshal message to JSON
@@ -46,11 +57,21 @@ func (be *BinaryEncoder) Encode(msg *ws.Message) ([]byte, error) {
 
 	// Check if compression is needed
 	var flags byte
+	var dictionaryVersion uint32
 	if len(payload) > be.compressionThreshold {
-		compressed, err := compressPayload(payload)
-		if err == nil && len(compressed) < len(payload) {
-			payload = compressed
-			flags |= FlagCompressed
+		if be.dictionary != nil {
+			compressed, err := be.dictionary.Compress(payload)
+			if err == nil && len(compressed) < len(payload) {
+				payload = compressed
+				flags |= FlagCompressed | FlagDictionary
+				dictionaryVersion = be.dictionary.Version
+			}
+		} else {
+			compressed, err := compressPayload(payload)
+			if err == nil && len(compressed) < len(payload) {
+				payload = compressed
+				flags |= FlagCompressed
+			}
 		}
 	}
 
@@ -66,7 +87,8 @@ func (be *BinaryEncoder) Encode(msg *ws.Message) ([]byte, error) {
 		return nil, fmt.Errorf("payload length %d exceeds uint32 range", payloadLen)
 	}
 	binary.BigEndian.PutUint32(header[4:8], uint32(payloadLen))
-	// header[8:12] reserved for future use
+	// header[8:12] holds the dictionary version for dictionary compressed payloads
+	binary.BigEndian.PutUint32(header[8:12], dictionaryVersion)
 
 	// Combine header and payload
 	result := append(header, payload...)
@@ -99,7 +121,17 @@ func (be *BinaryEncoder) Decode(data []byte) (*ws.Message, error) {
 	payload := data[HeaderSize : HeaderSize+payloadSize]
 
 	// Decompress if needed
-	if flags&FlagCompressed != 0 {
+	if flags&FlagDictionary != 0 {
+		dictionaryVersion := binary.BigEndian.Uint32(data[8:12])
+		if be.dictionary == nil || be.dictionary.Version != dictionaryVersion {
+			return nil, fmt.Errorf("unknown compression dictionary version: %d", dictionaryVersion)
+		}
+		decompressed, err := be.dictionary.Decompress(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress payload: %w", err)
+		}
+		payload = decompressed
+	} else if flags&FlagCompressed != 0 {
 		decompressed, err := decompressPayload(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decompress payload: %w", err)
@@ -158,7 +190,6 @@ func decompressPayload(data []byte) ([]byte, error) {
 	defer PutBuffer(buf)
 
 	// Limit decompressed size to prevent decompression bombs
-	const maxDecompressedSize = 10 * 1024 * 1024 // 10MB
 	limitedReader := io.LimitReader(reader, maxDecompressedSize)
 
 	n, err := io.Copy(buf, limitedReader)
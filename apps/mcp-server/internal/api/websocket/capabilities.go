@@ -0,0 +1,22 @@
+package websocket
+
+// serverCapabilities returns the capabilities advertised on initialize. Each
+// capability is only advertised when the component serving it is wired, so
+// clients don't call methods that would fail on this instance.
+func (s *Server) serverCapabilities() map[string]interface{} {
+	return map[string]interface{}{
+		"tools":            s.restAPIClient != nil || s.toolRegistry != nil,
+		"context":          s.contextManager != nil,
+		"events":           s.eventBus != nil,
+		"binary":           true,
+		"sessions":         s.conversationManager != nil,
+		"workflows":        s.workflowService != nil && s.workflowEngine != nil,
+		"agents":           s.agentRegistry != nil,
+		"tasks":            s.taskService != nil,
+		"workspaces":       s.workspaceManager != nil,
+		"subscriptions":    s.subscriptionManager != nil,
+		"token_management": s.contextManager != nil,
+		"warmup":           s.warmupEnabled(),
+		"scope_elevation":  s.scopeElevation.Enabled(),
+	}
+}
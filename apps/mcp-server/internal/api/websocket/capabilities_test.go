@@ -0,0 +1,45 @@
+package websocket
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestInitializeAdvertisesServableCapabilities(t *testing.T) {
+	newServer := func() (*Server, *Connection) {
+		server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+		conn := NewConnection("conn-1", nil, server)
+		conn.TenantID = "tenant-1"
+		conn.AgentID = "agent-1"
+		return server, conn
+	}
+
+	t.Run("server without a workflow service excludes workflows", func(t *testing.T) {
+		server, conn := newServer()
+
+		capabilities := initializeWithCapabilities(t, server, conn)["capabilities"].(map[string]interface{})
+		assert.Equal(t, false, capabilities["workflows"])
+		assert.Equal(t, false, capabilities["tasks"])
+		assert.Equal(t, false, capabilities["tools"])
+		assert.Equal(t, false, capabilities["context"])
+
+		// Components the server always wires are still advertised
+		assert.Equal(t, true, capabilities["agents"])
+		assert.Equal(t, true, capabilities["subscriptions"])
+		assert.Equal(t, true, capabilities["binary"])
+	})
+
+	t.Run("wired services are advertised", func(t *testing.T) {
+		server, conn := newServer()
+		server.SetWorkflowService(&stubWorkflowService{})
+		server.SetRESTClient(&stubToolCatalog{})
+
+		capabilities := initializeWithCapabilities(t, server, conn)["capabilities"].(map[string]interface{})
+		assert.Equal(t, true, capabilities["workflows"])
+		assert.Equal(t, true, capabilities["tools"])
+	})
+}
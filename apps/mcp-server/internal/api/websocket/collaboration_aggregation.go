@@ -0,0 +1,162 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Consensus aggregation functions
+const (
+	AggregationMajorityVote    = "majority_vote"
+	AggregationWeightedAverage = "weighted_average"
+	AggregationFirstSuccess    = "first_success"
+)
+
+// CollaborationAgentResult is one agent's result in a collaboration
+type CollaborationAgentResult struct {
+	AgentID string      `json:"agent_id"`
+	Result  interface{} `json:"result"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// ConsensusResult is the single output aggregated from agent results
+type ConsensusResult struct {
+	Function     string      `json:"function"`
+	Value        interface{} `json:"value"`
+	Agreement    float64     `json:"agreement"`    // Share of successful results (by weight) supporting the value
+	Contributors []string    `json:"contributors"` // Agents whose results support the value
+	Failed       []string    `json:"failed,omitempty"`
+}
+
+// ConsensusAggregator combines successful agent results into a consensus. Weights
+// are keyed by agent ID; agents without a weight count as 1.
+type ConsensusAggregator func(results []CollaborationAgentResult, weights map[string]float64) (*ConsensusResult, error)
+
+var consensusAggregators = map[string]ConsensusAggregator{
+	AggregationMajorityVote:    majorityVote,
+	AggregationWeightedAverage: weightedAverage,
+	AggregationFirstSuccess:    firstSuccess,
+}
+
+// aggregateConsensus applies the named aggregation function, majority vote by
+// default, to the successful agent results
+func aggregateConsensus(function string, results []CollaborationAgentResult, weights map[string]float64) (*ConsensusResult, error) {
+	if function == "" {
+		function = AggregationMajorityVote
+	}
+	aggregate, ok := consensusAggregators[function]
+	if !ok {
+		return nil, fmt.Errorf("unknown aggregation function: %s", function)
+	}
+
+	var succeeded []CollaborationAgentResult
+	var failed []string
+	for _, result := range results {
+		if result.Error != "" {
+			failed = append(failed, result.AgentID)
+			continue
+		}
+		succeeded = append(succeeded, result)
+	}
+	if len(succeeded) == 0 {
+		return nil, fmt.Errorf("no successful agent results to aggregate")
+	}
+
+	consensus, err := aggregate(succeeded, weights)
+	if err != nil {
+		return nil, err
+	}
+	consensus.Function = function
+	consensus.Failed = failed
+	return consensus, nil
+}
+
+func agentWeight(weights map[string]float64, agentID string) float64 {
+	if weight, ok := weights[agentID]; ok {
+		return weight
+	}
+	return 1
+}
+
+// majorityVote picks the result with the most weighted votes. Results are equal
+// when their JSON encodings match; ties go to the result seen first.
+func majorityVote(results []CollaborationAgentResult, weights map[string]float64) (*ConsensusResult, error) {
+	type candidate struct {
+		value  interface{}
+		weight float64
+		agents []string
+	}
+
+	var candidates []*candidate
+	byKey := make(map[string]*candidate)
+	var total float64
+	for _, result := range results {
+		encoded, err := json.Marshal(result.Result)
+		if err != nil {
+			return nil, fmt.Errorf("invalid result from agent %s: %w", result.AgentID, err)
+		}
+		key := string(encoded)
+
+		c, ok := byKey[key]
+		if !ok {
+			c = &candidate{value: result.Result}
+			byKey[key] = c
+			candidates = append(candidates, c)
+		}
+		weight := agentWeight(weights, result.AgentID)
+		c.weight += weight
+		c.agents = append(c.agents, result.AgentID)
+		total += weight
+	}
+
+	winner := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.weight > winner.weight {
+			winner = c
+		}
+	}
+
+	agreement := 0.0
+	if total > 0 {
+		agreement = winner.weight / total
+	}
+	return &ConsensusResult{
+		Value:        winner.value,
+		Agreement:    agreement,
+		Contributors: winner.agents,
+	}, nil
+}
+
+// weightedAverage averages numeric results by agent weight
+func weightedAverage(results []CollaborationAgentResult, weights map[string]float64) (*ConsensusResult, error) {
+	var sum, total float64
+	contributors := make([]string, 0, len(results))
+	for _, result := range results {
+		value, ok := result.Result.(float64)
+		if !ok {
+			return nil, fmt.Errorf("weighted_average requires numeric results, agent %s returned %T", result.AgentID, result.Result)
+		}
+		weight := agentWeight(weights, result.AgentID)
+		sum += value * weight
+		total += weight
+		contributors = append(contributors, result.AgentID)
+	}
+	if total <= 0 {
+		return nil, fmt.Errorf("weighted_average requires a positive total weight")
+	}
+
+	return &ConsensusResult{
+		Value:        sum / total,
+		Agreement:    1,
+		Contributors: contributors,
+	}, nil
+}
+
+// firstSuccess takes the first successful result
+func firstSuccess(results []CollaborationAgentResult, weights map[string]float64) (*ConsensusResult, error) {
+	return &ConsensusResult{
+		Value:        results[0].Result,
+		Agreement:    1,
+		Contributors: []string{results[0].AgentID},
+	}, nil
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestAggregateConsensus(t *testing.T) {
+	results := []CollaborationAgentResult{
+		{AgentID: "agent-a", Result: "approve"},
+		{AgentID: "agent-b", Result: "reject"},
+		{AgentID: "agent-c", Result: "approve"},
+		{AgentID: "agent-d", Error: "timed out"},
+	}
+
+	t.Run("majority vote", func(t *testing.T) {
+		consensus, err := aggregateConsensus("", results, nil)
+		require.NoError(t, err)
+		assert.Equal(t, AggregationMajorityVote, consensus.Function)
+		assert.Equal(t, "approve", consensus.Value)
+		assert.Equal(t, []string{"agent-a", "agent-c"}, consensus.Contributors)
+		assert.InDelta(t, 2.0/3.0, consensus.Agreement, 0.0001)
+		assert.Equal(t, []string{"agent-d"}, consensus.Failed)
+	})
+
+	t.Run("weighted majority vote", func(t *testing.T) {
+		consensus, err := aggregateConsensus(AggregationMajorityVote, results, map[string]float64{"agent-b": 3})
+		require.NoError(t, err)
+		assert.Equal(t, "reject", consensus.Value)
+		assert.InDelta(t, 0.6, consensus.Agreement, 0.0001)
+	})
+
+	t.Run("majority vote compares structured results", func(t *testing.T) {
+		consensus, err := aggregateConsensus(AggregationMajorityVote, []CollaborationAgentResult{
+			{AgentID: "agent-a", Result: map[string]interface{}{"severity": "high", "line": 12.0}},
+			{AgentID: "agent-b", Result: map[string]interface{}{"line": 12.0, "severity": "high"}},
+			{AgentID: "agent-c", Result: map[string]interface{}{"severity": "low", "line": 12.0}},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"severity": "high", "line": 12.0}, consensus.Value)
+	})
+
+	t.Run("weighted average", func(t *testing.T) {
+		consensus, err := aggregateConsensus(AggregationWeightedAverage, []CollaborationAgentResult{
+			{AgentID: "agent-a", Result: 0.9},
+			{AgentID: "agent-b", Result: 0.3},
+			{AgentID: "agent-c", Error: "failed"},
+		}, map[string]float64{"agent-a": 3})
+		require.NoError(t, err)
+		assert.InDelta(t, 0.75, consensus.Value, 0.0001)
+		assert.Equal(t, []string{"agent-a", "agent-b"}, consensus.Contributors)
+
+		_, err = aggregateConsensus(AggregationWeightedAverage, results, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("first success", func(t *testing.T) {
+		consensus, err := aggregateConsensus(AggregationFirstSuccess, []CollaborationAgentResult{
+			{AgentID: "agent-a", Error: "failed"},
+			{AgentID: "agent-b", Result: "patch-2"},
+			{AgentID: "agent-c", Result: "patch-3"},
+		}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "patch-2", consensus.Value)
+		assert.Equal(t, []string{"agent-b"}, consensus.Contributors)
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		_, err := aggregateConsensus("median", results, nil)
+		assert.Error(t, err)
+
+		_, err = aggregateConsensus(AggregationMajorityVote, []CollaborationAgentResult{{AgentID: "agent-a", Error: "failed"}}, nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestHandleAgentCollaborateConsensus(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	for _, id := range []string{"agent-a", "agent-b", "agent-c"} {
+		_, err := server.agentRegistry.RegisterAgent(context.Background(), &AgentRegistration{ID: id, Name: id, TenantID: "tenant-1"})
+		require.NoError(t, err)
+	}
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.AgentID = "initiator"
+	conn.TenantID = "tenant-1"
+
+	result, err := server.handleAgentCollaborate(context.Background(), conn, json.RawMessage(`{
+		"agent_ids": ["agent-a", "agent-b", "agent-c"],
+		"task": {"type": "review"},
+		"strategy": "consensus",
+		"results": [
+			{"agent_id": "agent-a", "result": 4},
+			{"agent_id": "agent-b", "result": 8},
+			{"agent_id": "agent-c", "result": 8}
+		],
+		"aggregation": {"function": "weighted_average", "weights": {"agent-a": 2}}
+	}`))
+	require.NoError(t, err)
+
+	consensus := result.(map[string]interface{})["consensus"].(*ConsensusResult)
+	assert.Equal(t, AggregationWeightedAverage, consensus.Function)
+	assert.InDelta(t, 6.0, consensus.Value, 0.0001)
+
+	result, err = server.handleAgentCollaborate(context.Background(), conn, json.RawMessage(`{
+		"agent_ids": ["agent-a", "agent-b", "agent-c"],
+		"strategy": "consensus",
+		"results": [
+			{"agent_id": "agent-a", "result": 4},
+			{"agent_id": "agent-b", "result": 8},
+			{"agent_id": "agent-c", "result": 8}
+		]
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, 8.0, result.(map[string]interface{})["consensus"].(*ConsensusResult).Value)
+
+	// Collaborations without results are only initiated
+	result, err = server.handleAgentCollaborate(context.Background(), conn, json.RawMessage(`{
+		"agent_ids": ["agent-a"],
+		"strategy": "consensus"
+	}`))
+	require.NoError(t, err)
+	assert.NotContains(t, result.(map[string]interface{}), "consensus")
+}
@@ -296,6 +296,18 @@ func (s *Server) handleTaskCreateDistributed(ctx context.Context, conn *Connecti
 			return nil, fmt.Errorf("failed to create distributed task: %w", err)
 		}
 
+		// Subtasks are spread over the target agents by spare capacity
+		var distributor *WeightedRoundRobin
+		capacities := make(map[string]int, len(createParams.TargetAgents))
+		if len(createParams.TargetAgents) > 0 {
+			agents := s.distributionAgents(ctx, createParams.TargetAgents)
+			for _, agent := range agents {
+				capacities[agent.ID] = agent.Capacity
+			}
+			distributor = NewWeightedRoundRobin(agents)
+		}
+		assignments := make(map[string]string)
+
 		// Create subtasks
 		subtaskIDs := []string{}
 		for _, st := range createParams.Subtasks {
@@ -324,6 +336,12 @@ func (s *Server) handleTaskCreateDistributed(ctx context.Context, conn *Connecti
 			}
 			subtaskIDs = append(subtaskIDs, subtask.ID.String())
 
+			if distributor != nil {
+				if agentID := s.assignDistributedSubtask(ctx, distributor, capacities, subtask.ID); agentID != "" {
+					assignments[subtask.ID.String()] = agentID
+				}
+			}
+
 			// Subscribe to subtask notifications
 			if s.notificationManager != nil {
 				s.notificationManager.Subscribe(conn.ID, fmt.Sprintf("task:%s", subtask.ID))
@@ -344,6 +362,7 @@ func (s *Server) handleTaskCreateDistributed(ctx context.Context, conn *Connecti
 			"subtask_ids":   subtaskIDs,
 			"strategy":      createParams.Strategy,
 			"target_agents": createParams.TargetAgents,
+			"assignments":   assignments,
 			"created_at":    task.CreatedAt.Format(time.RFC3339),
 		}, nil
 	}
@@ -352,6 +371,30 @@ func (s *Server) handleTaskCreateDistributed(ctx context.Context, conn *Connecti
 	return nil, fmt.Errorf("task service not initialized")
 }
 
+// assignDistributedSubtask assigns a subtask to the next agent from the
+// distributor and returns the agent, or "" when no agent accepted it. Agents
+// that fail an assignment are treated as full for the rest of the distribution.
+func (s *Server) assignDistributedSubtask(ctx context.Context, distributor *WeightedRoundRobin, capacities map[string]int, subtaskID uuid.UUID) string {
+	for attempt := 0; attempt < len(capacities); attempt++ {
+		agentID, ok := distributor.Next()
+		if !ok {
+			return ""
+		}
+
+		if err := s.taskService.AssignTask(ctx, subtaskID, agentID); err != nil {
+			s.logger.Warn("Failed to assign distributed subtask", map[string]interface{}{
+				"subtask_id": subtaskID,
+				"agent_id":   agentID,
+				"error":      err.Error(),
+			})
+			distributor.UpdateLoad(agentID, capacities[agentID])
+			continue
+		}
+		return agentID
+	}
+	return ""
+}
+
 // handleTaskDelegate delegates a task to another agent
 func (s *Server) handleTaskDelegate(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	startTime := time.Now()
@@ -484,6 +527,7 @@ func (s *Server) handleTaskAccept(ctx context.Context, conn *Connection, params
 		if err != nil {
 			return nil, fmt.Errorf("failed to get task: %w", err)
 		}
+		s.publishTaskTransition(task, "")
 
 		// Notify original creator
 		if s.notificationManager != nil {
@@ -539,15 +583,27 @@ func (s *Server) handleTaskComplete(ctx context.Context, conn *Connection, param
 	}
 
 	if s.taskService != nil {
+		task, err := s.taskService.Get(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		previousStatus := string(task.Status)
+
+		// Reject results that don't satisfy the task's declared output schema
+		if err := validateTaskOutput(task, completeParams.Result); err != nil {
+			return nil, err
+		}
+
 		if err := s.taskService.CompleteTask(ctx, taskID, conn.AgentID, completeParams.Result); err != nil {
 			return nil, fmt.Errorf("failed to complete task: %w", err)
 		}
 
-		// Get task details
-		task, err := s.taskService.Get(ctx, taskID)
+		// Get updated task details
+		task, err = s.taskService.Get(ctx, taskID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get task: %w", err)
 		}
+		s.publishTaskTransition(task, previousStatus)
 
 		// Record task completion metrics
 		if s.metricsCollector != nil && task.StartedAt != nil {
@@ -617,6 +673,7 @@ func (s *Server) handleTaskFail(ctx context.Context, conn *Connection, params js
 		if err != nil {
 			return nil, fmt.Errorf("failed to get task: %w", err)
 		}
+		s.publishTaskTransition(task, "")
 
 		// Notify task creator
 		if s.notificationManager != nil {
@@ -1082,6 +1139,10 @@ func (s *Server) handleWorkflowResume(ctx context.Context, conn *Connection, par
 		ExecutionID string                 `json:"execution_id"`
 		Input       map[string]interface{} `json:"input"`
 		Force       bool                   `json:"force"`
+		// Subscriptions held before reconnecting and the last event ID seen on
+		// them, to replay the events missed in between
+		SubscriptionIDs []string `json:"subscription_ids"`
+		Cursor          uint64   `json:"cursor"`
 	}
 
 	if err := json.Unmarshal(params, &resumeParams); err != nil {
@@ -1099,6 +1160,18 @@ func (s *Server) handleWorkflowResume(ctx context.Context, conn *Connection, par
 	}
 
 	{
+		// Subscribe before resuming so no step notification is missed
+		var resubscription *workflowResubscription
+		if !s.config.WorkflowResubscribe.Disabled {
+			execution, err := s.workflowService.GetExecution(ctx, executionID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resume workflow: %w", err)
+			}
+			resubscription = s.resubscribeWorkflow(conn,
+				workflowExecutionTopics(execution.WorkflowID.String(), execution.ID.String()),
+				resumeParams.SubscriptionIDs, resumeParams.Cursor)
+		}
+
 		if err := s.workflowService.ResumeExecution(ctx, executionID); err != nil {
 			return nil, fmt.Errorf("failed to resume workflow: %w", err)
 		}
@@ -1109,13 +1182,19 @@ func (s *Server) handleWorkflowResume(ctx context.Context, conn *Connection, par
 			return nil, fmt.Errorf("failed to resume workflow: %w", err)
 		}
 
-		return map[string]interface{}{
+		response := map[string]interface{}{
 			"execution_id": execution.ID.String(),
 			"workflow_id":  execution.WorkflowID.String(),
 			"status":       execution.Status,
 			"resumed_at":   time.Now().Format(time.RFC3339),
 			"resumed_by":   conn.AgentID,
-		}, nil
+		}
+		if resubscription != nil {
+			response["subscriptions"] = resubscription.Subscriptions
+			response["replayed"] = resubscription.Replayed
+			response["events_lost"] = resubscription.EventsLost
+		}
+		return response, nil
 	}
 }
 
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultCompressionDictionaryVersion is the version of the built-in compression dictionary
+const DefaultCompressionDictionaryVersion = 1
+
+// maxDecompressedSize limits decompressed payloads to prevent decompression bombs
+const maxDecompressedSize = 10 * 1024 * 1024 // 10MB
+
+// defaultCompressionDictionary holds structures repeated across responses.
+// Deflate favors matches near the end of the dictionary, so the most common
+// strings come last.
+const defaultCompressionDictionary = `{"type":"object","properties":{},"required":[],"additionalProperties":false}` +
+	`"description":"","type":"string","type":"integer","type":"boolean","type":"array","items":{"type":"object"}` +
+	`"inputSchema":{"type":"object","properties":{"tools":[{"name":"","description":"","input_schema":` +
+	`"created_at":"","updated_at":"","tenant_id":"","agent_id":"","context_id":"","session_id":"","workspace_id":"` +
+	`"content":[{"type":"text","text":""}],"status":"completed","status":"active","error":null,"metadata":{}` +
+	`{"id":"","type":1,"method":"","params":{},"result":{"success":true,"data":{"message":""}}}`
+
+// CompressionDictionaryConfig configures the shared compression dictionary
+// offered to clients that enable binary protocol compression
+type CompressionDictionaryConfig struct {
+	Disabled bool   `mapstructure:"disabled"` // Don't offer a dictionary
+	Version  uint32 `mapstructure:"version"`  // Version clients acknowledge; required with Path
+	Path     string `mapstructure:"path"`     // Dictionary file; the built-in dictionary is used when empty
+}
+
+// CompressionDictionary is a preset deflate dictionary identified by a version
+// the client acknowledges before it is used on a connection
+type CompressionDictionary struct {
+	Version uint32
+	Data    []byte
+}
+
+// NewCompressionDictionary loads the configured compression dictionary. It returns
+// nil when dictionaries are disabled.
+func NewCompressionDictionary(config CompressionDictionaryConfig) (*CompressionDictionary, error) {
+	if config.Disabled {
+		return nil, nil
+	}
+
+	if config.Path == "" {
+		return &CompressionDictionary{
+			Version: DefaultCompressionDictionaryVersion,
+			Data:    []byte(defaultCompressionDictionary),
+		}, nil
+	}
+
+	if config.Version == 0 {
+		return nil, fmt.Errorf("compression dictionary version is required with a dictionary path")
+	}
+	if config.Version == DefaultCompressionDictionaryVersion {
+		return nil, fmt.Errorf("compression dictionary version %d is reserved for the built-in dictionary", DefaultCompressionDictionaryVersion)
+	}
+
+	data, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compression dictionary: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("compression dictionary %s is empty", config.Path)
+	}
+
+	return &CompressionDictionary{Version: config.Version, Data: data}, nil
+}
+
+// Compress deflates data using the dictionary
+func (d *CompressionDictionary) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriterDict(&buf, flate.BestCompression, d.Data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress inflates data compressed with the dictionary
+func (d *CompressionDictionary) Decompress(data []byte) ([]byte, error) {
+	reader := flate.NewReaderDict(bytes.NewReader(data), d.Data)
+	defer func() { _ = reader.Close() }()
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if n > maxDecompressedSize {
+		return nil, fmt.Errorf("decompressed data exceeds maximum size of %d bytes", maxDecompressedSize)
+	}
+	return buf.Bytes(), nil
+}
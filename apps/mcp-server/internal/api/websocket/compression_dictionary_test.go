@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// repetitiveToolListMessage builds a tool list response, which repeats schema boilerplate
+func repetitiveToolListMessage() *ws.Message {
+	tools := make([]interface{}, 0, 8)
+	for i := 0; i < 8; i++ {
+		tools = append(tools, map[string]interface{}{
+			"name":        fmt.Sprintf("tool_%d", i),
+			"description": fmt.Sprintf("Runs operation %d", i),
+			"input_schema": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]interface{}{
+					"workspace_id": map[string]interface{}{"type": "string", "description": ""},
+					"limit":        map[string]interface{}{"type": "integer", "description": ""},
+				},
+				"required": []string{"workspace_id"},
+			},
+		})
+	}
+	return &ws.Message{
+		ID:     "msg-1",
+		Type:   ws.MessageTypeResponse,
+		Result: map[string]interface{}{"tools": tools},
+	}
+}
+
+func plainDeflate(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.BestCompression)
+	require.NoError(t, err)
+	_, err = writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func TestCompressionDictionaryEncoding(t *testing.T) {
+	dictionary, err := NewCompressionDictionary(CompressionDictionaryConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, dictionary)
+	assert.Equal(t, uint32(DefaultCompressionDictionaryVersion), dictionary.Version)
+
+	msg := repetitiveToolListMessage()
+	payload, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	t.Run("dictionary frames are smaller than plain deflate", func(t *testing.T) {
+		withDictionary, err := dictionary.Compress(payload)
+		require.NoError(t, err)
+		assert.Less(t, len(withDictionary), len(plainDeflate(t, payload)))
+
+		dictionaryFrame, err := NewBinaryEncoderWithDictionary(0, dictionary).Encode(msg)
+		require.NoError(t, err)
+		plainFrame, err := NewBinaryEncoder(0).Encode(msg)
+		require.NoError(t, err)
+		assert.Less(t, len(dictionaryFrame), len(plainFrame))
+		assert.NotZero(t, dictionaryFrame[1]&FlagDictionary)
+	})
+
+	t.Run("dictionary frames decompress correctly", func(t *testing.T) {
+		encoder := NewBinaryEncoderWithDictionary(0, dictionary)
+		frame, err := encoder.Encode(msg)
+		require.NoError(t, err)
+
+		decoded, err := encoder.Decode(frame)
+		require.NoError(t, err)
+		decodedPayload, err := json.Marshal(decoded)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(payload), string(decodedPayload))
+	})
+
+	t.Run("unknown dictionary versions are rejected", func(t *testing.T) {
+		frame, err := NewBinaryEncoderWithDictionary(0, dictionary).Encode(msg)
+		require.NoError(t, err)
+
+		_, err = NewBinaryEncoder(0).Decode(frame)
+		assert.ErrorContains(t, err, "unknown compression dictionary version")
+
+		other := &CompressionDictionary{Version: 2, Data: dictionary.Data}
+		_, err = NewBinaryEncoderWithDictionary(0, other).Decode(frame)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewCompressionDictionary(t *testing.T) {
+	dictionary, err := NewCompressionDictionary(CompressionDictionaryConfig{Disabled: true})
+	require.NoError(t, err)
+	assert.Nil(t, dictionary)
+
+	path := filepath.Join(t.TempDir(), "dictionary")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type":"object"}`), 0o600))
+
+	dictionary, err = NewCompressionDictionary(CompressionDictionaryConfig{Version: 7, Path: path})
+	require.NoError(t, err)
+	assert.Equal(t, uint32(7), dictionary.Version)
+	assert.Equal(t, []byte(`{"type":"object"}`), dictionary.Data)
+
+	_, err = NewCompressionDictionary(CompressionDictionaryConfig{Path: path})
+	assert.Error(t, err)
+	_, err = NewCompressionDictionary(CompressionDictionaryConfig{Version: DefaultCompressionDictionaryVersion, Path: path})
+	assert.Error(t, err)
+}
+
+func TestSetBinaryProtocolDictionaryNegotiation(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	conn := NewConnection("conn-1", nil, server)
+
+	// The server ships its dictionary until the client acknowledges it
+	result, postAction, err := server.handleSetBinaryProtocolWithPostAction(context.Background(), conn,
+		json.RawMessage(`{"enabled": true, "compression": {"enabled": true, "threshold": 512}}`))
+	require.NoError(t, err)
+	postAction.Action()
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, false, response["dictionary_enabled"])
+	shipped := response["dictionary"].(map[string]interface{})
+	assert.Equal(t, uint32(DefaultCompressionDictionaryVersion), shipped["version"])
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(defaultCompressionDictionary)), shipped["data"])
+	assert.Nil(t, conn.GetCompressionDictionary())
+
+	result, postAction, err = server.handleSetBinaryProtocolWithPostAction(context.Background(), conn,
+		json.RawMessage(`{"enabled": true, "compression": {"enabled": true, "dictionary_version": 1}}`))
+	require.NoError(t, err)
+	postAction.Action()
+
+	response = result.(map[string]interface{})
+	assert.Equal(t, true, response["dictionary_enabled"])
+	assert.NotContains(t, response, "dictionary")
+	require.NotNil(t, conn.GetCompressionDictionary())
+	assert.Equal(t, uint32(DefaultCompressionDictionaryVersion), conn.GetCompressionDictionary().Version)
+
+	// Disabling compression drops the dictionary
+	_, postAction, err = server.handleSetBinaryProtocolWithPostAction(context.Background(), conn,
+		json.RawMessage(`{"enabled": true}`))
+	require.NoError(t, err)
+	postAction.Action()
+	assert.Nil(t, conn.GetCompressionDictionary())
+}
@@ -41,17 +41,19 @@ func (m ConnectionMode) String() string {
 
 // ConnectionState tracks additional connection state
 type ConnectionState struct {
-	BinaryMode           bool
-	CompressionThreshold int
-	MaxTokens            int
-	CurrentTokenUsage    int
-	ActiveSessionID      string
-	PreviousSessionID    string
-	SystemPromptTokens   int
-	ConversationTokens   int
-	ToolTokens           int
-	Claims               *auth.Claims   // Authentication claims
-	ConnectionMode       ConnectionMode // Type of connection
+	BinaryMode            bool
+	CompressionThreshold  int
+	CompressionDictionary *CompressionDictionary // Shared dictionary the client acknowledged
+	MaxTokens             int
+	CurrentTokenUsage     int
+	ActiveSessionID       string
+	PreviousSessionID     string
+	SystemPromptTokens    int
+	ConversationTokens    int
+	ToolTokens            int
+	Claims                *auth.Claims      // Authentication claims
+	ConnectionMode        ConnectionMode    // Type of connection
+	ToolMetadata          map[string]string // Allowlisted headers forwarded with tool executions
 }
 
 // RateLimiter implements token bucket algorithm
@@ -185,6 +187,7 @@ func (c *Connection) readPump() {
 
 		// Update last activity
 		c.LastPing = time.Now()
+		c.recordActivity()
 
 		// Rate limiting is handled within the MCP handler
 		if !rateLimiter.Allow() {
@@ -255,7 +258,7 @@ func (c *Connection) writePump() {
 				// For binary mode, we need to parse the JSON message first
 				var msg ws.Message
 				if jsonErr := json.Unmarshal(message, &msg); jsonErr == nil {
-					encoder := NewBinaryEncoder(1024)
+					encoder := NewBinaryEncoderWithDictionary(1024, c.GetCompressionDictionary())
 					if binaryData, encodeErr := encoder.Encode(&msg); encodeErr == nil {
 						err = conn.Write(writeCtx, websocket.MessageBinary, binaryData)
 					} else {
@@ -342,19 +345,8 @@ func (c *Connection) writePump() {
 			conn := c.conn
 			c.mu.RUnlock()
 
-			if conn != nil {
-				pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
-				if err := conn.Ping(pingCtx); err != nil {
-					cancel()
-					if c.hub != nil && c.hub.logger != nil && c.Connection != nil {
-						c.hub.logger.Error("Ping error", map[string]interface{}{
-							"error":         err.Error(),
-							"connection_id": c.ID,
-						})
-					}
-					return
-				}
-				cancel()
+			if conn != nil && !c.keepalivePing(ctx, conn.Ping) {
+				return
 			}
 		}
 	}
@@ -486,6 +478,10 @@ var (
 		Code:    ws.ErrCodeServerError,
 		Message: "Message channel full",
 	}
+	ErrAgentNotConnected = &ws.Error{
+		Code:    ws.ErrCodeServerError,
+		Message: "Agent not connected",
+	}
 )
 
 // Extended Connection methods for new features
@@ -530,6 +526,27 @@ func (c *Connection) GetCompressionThreshold() int {
 	return c.state.CompressionThreshold
 }
 
+// SetCompressionDictionary sets the shared dictionary used to compress binary messages;
+// nil uses plain compression
+func (c *Connection) SetCompressionDictionary(dictionary *CompressionDictionary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		c.state = &ConnectionState{}
+	}
+	c.state.CompressionDictionary = dictionary
+}
+
+// GetCompressionDictionary returns the connection's compression dictionary
+func (c *Connection) GetCompressionDictionary() *CompressionDictionary {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.state == nil {
+		return nil
+	}
+	return c.state.CompressionDictionary
+}
+
 // Token management methods
 
 // SetMaxTokens sets the maximum token window for the connection
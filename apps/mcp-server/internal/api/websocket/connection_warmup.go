@@ -0,0 +1,122 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// WarmupCapability is the initialize capability a client sends to have the
+// connection's common resources prefetched
+const WarmupCapability = "warmup"
+
+const (
+	// DefaultConnectionWarmupTTL is how long prefetched resources serve requests
+	DefaultConnectionWarmupTTL = 30 * time.Second
+	// connectionWarmupTimeout bounds the prefetch
+	connectionWarmupTimeout = 10 * time.Second
+)
+
+// ConnectionWarmupConfig configures the prefetch of a connection's tool list
+// after initialize, for clients that ask for it
+type ConnectionWarmupConfig struct {
+	Disabled bool          `mapstructure:"disabled"` // Ignore the warmup capability
+	TTL      time.Duration `mapstructure:"ttl"`      // How long prefetched resources are served; defaults to 30 seconds
+}
+
+// connectionWarmup holds the resources prefetched for a connection. done is
+// closed once the prefetch finishes; the other fields are read only after that.
+type connectionWarmup struct {
+	done      chan struct{}
+	tools     []*models.DynamicTool
+	shared    map[string]bool
+	err       error
+	fetchedAt time.Time
+}
+
+// warmupRequested reports whether the client asked for warm-up, and returns
+// its capabilities without the warm-up flag
+func warmupRequested(capabilities []string) (bool, []string) {
+	requested := false
+	remaining := make([]string, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if capability == WarmupCapability {
+			requested = true
+			continue
+		}
+		remaining = append(remaining, capability)
+	}
+	return requested, remaining
+}
+
+// warmupEnabled reports whether clients can ask for warm-up
+func (s *Server) warmupEnabled() bool {
+	return !s.config.ConnectionWarmup.Disabled && s.restAPIClient != nil
+}
+
+// startConnectionWarmup prefetches the connection tenant's tool list in the
+// background so the first tool.list doesn't pay the REST API round trip
+func (s *Server) startConnectionWarmup(conn *Connection) bool {
+	if !s.warmupEnabled() {
+		return false
+	}
+
+	warmup := &connectionWarmup{done: make(chan struct{})}
+	conn.mu.Lock()
+	conn.warmup = warmup
+	tenantID := conn.TenantID
+	conn.mu.Unlock()
+
+	go func() {
+		defer close(warmup.done)
+
+		ctx, cancel := context.WithTimeout(context.Background(), connectionWarmupTimeout)
+		defer cancel()
+
+		start := time.Now()
+		warmup.tools, warmup.shared, warmup.err = s.listToolsWithSharedCatalog(ctx, tenantID)
+		warmup.fetchedAt = time.Now()
+
+		fields := map[string]interface{}{
+			"connection_id": conn.ID,
+			"tenant_id":     tenantID,
+			"duration_ms":   time.Since(start).Milliseconds(),
+		}
+		if warmup.err != nil {
+			fields["error"] = warmup.err.Error()
+			s.logger.Warn("Connection warm-up failed", fields)
+			return
+		}
+		fields["tool_count"] = len(warmup.tools)
+		s.logger.Debug("Connection warm-up completed", fields)
+	}()
+
+	return true
+}
+
+// warmToolList returns the connection's prefetched tool list while it's fresh.
+// A prefetch still in flight is waited for rather than duplicated.
+func (s *Server) warmToolList(ctx context.Context, conn *Connection) ([]*models.DynamicTool, map[string]bool, bool) {
+	conn.mu.RLock()
+	warmup := conn.warmup
+	conn.mu.RUnlock()
+	if warmup == nil {
+		return nil, nil, false
+	}
+
+	select {
+	case <-warmup.done:
+	case <-ctx.Done():
+		return nil, nil, false
+	}
+
+	ttl := s.config.ConnectionWarmup.TTL
+	if ttl <= 0 {
+		ttl = DefaultConnectionWarmupTTL
+	}
+	if warmup.err != nil || time.Since(warmup.fetchedAt) > ttl {
+		return nil, nil, false
+	}
+	return warmup.tools, warmup.shared, true
+}
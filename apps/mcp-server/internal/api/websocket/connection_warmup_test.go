@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// countingToolCatalog counts the tool list requests that reach the REST API
+type countingToolCatalog struct {
+	stubToolCatalog
+	lists atomic.Int32
+}
+
+func (c *countingToolCatalog) ListTools(ctx context.Context, tenantID string) ([]*models.DynamicTool, error) {
+	c.lists.Add(1)
+	return c.stubToolCatalog.ListTools(ctx, tenantID)
+}
+
+func newWarmupTestServer(t *testing.T, config ConnectionWarmupConfig) (*Server, *countingToolCatalog, *Connection) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{ConnectionWarmup: config})
+	catalog := &countingToolCatalog{stubToolCatalog: stubToolCatalog{tools: map[string][]*models.DynamicTool{
+		"tenant-1": {{ID: "tool-1", ToolName: "github"}},
+	}}}
+	server.SetRESTClient(catalog)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	return server, catalog, conn
+}
+
+func initializeWithCapabilities(t *testing.T, server *Server, conn *Connection, capabilities ...string) map[string]interface{} {
+	t.Helper()
+
+	params, err := json.Marshal(map[string]interface{}{"name": "test-agent", "capabilities": capabilities})
+	require.NoError(t, err)
+	result, err := server.handleInitialize(context.Background(), conn, params)
+	require.NoError(t, err)
+	return result.(map[string]interface{})
+}
+
+func listToolsForWarmupTest(t *testing.T, server *Server, conn *Connection) map[string]interface{} {
+	t.Helper()
+
+	result, err := server.handleToolList(context.Background(), conn, nil)
+	require.NoError(t, err)
+	response := result.(map[string]interface{})
+	require.Len(t, response["tools"], 1)
+	return response
+}
+
+func TestConnectionWarmup(t *testing.T) {
+	t.Run("first tool.list is served from the warm-up", func(t *testing.T) {
+		server, catalog, conn := newWarmupTestServer(t, ConnectionWarmupConfig{})
+
+		result := initializeWithCapabilities(t, server, conn, "code_review", WarmupCapability)
+		assert.Equal(t, true, result["warmup_started"])
+		assert.Equal(t, true, result["capabilities"].(map[string]interface{})["warmup"])
+
+		// The prefetch is the only request to reach the REST API
+		assert.Equal(t, true, listToolsForWarmupTest(t, server, conn)["from_cache"])
+		assert.Equal(t, true, listToolsForWarmupTest(t, server, conn)["from_cache"])
+		assert.Equal(t, int32(1), catalog.lists.Load())
+
+		// The warm-up flag isn't registered as an agent capability
+		agent, err := server.agentRegistry.GetAgentStatus(context.Background(), "agent-1")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"code_review"}, agent.Capabilities)
+	})
+
+	t.Run("without warm-up the first tool.list fetches", func(t *testing.T) {
+		server, catalog, conn := newWarmupTestServer(t, ConnectionWarmupConfig{})
+
+		result := initializeWithCapabilities(t, server, conn)
+		assert.Equal(t, false, result["warmup_started"])
+		assert.Equal(t, int32(0), catalog.lists.Load())
+
+		assert.NotContains(t, listToolsForWarmupTest(t, server, conn), "from_cache")
+		assert.Equal(t, int32(1), catalog.lists.Load())
+	})
+
+	t.Run("disabled warm-up ignores the capability", func(t *testing.T) {
+		server, catalog, conn := newWarmupTestServer(t, ConnectionWarmupConfig{Disabled: true})
+
+		result := initializeWithCapabilities(t, server, conn, WarmupCapability)
+		assert.Equal(t, false, result["warmup_started"])
+		assert.NotContains(t, listToolsForWarmupTest(t, server, conn), "from_cache")
+		assert.Equal(t, int32(1), catalog.lists.Load())
+	})
+
+	t.Run("expired warm-up is refetched", func(t *testing.T) {
+		server, catalog, conn := newWarmupTestServer(t, ConnectionWarmupConfig{TTL: time.Millisecond})
+
+		initializeWithCapabilities(t, server, conn, WarmupCapability)
+		<-conn.warmup.done
+		time.Sleep(5 * time.Millisecond)
+
+		assert.NotContains(t, listToolsForWarmupTest(t, server, conn), "from_cache")
+		assert.Equal(t, int32(2), catalog.lists.Load())
+	})
+}
@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// countingContextManager is a minimal ContextManager that tracks append calls
+type countingContextManager struct {
+	tokens  int
+	appends int
+}
+
+func (m *countingContextManager) GetContext(ctx context.Context, contextID string) (*models.Context, error) {
+	return &models.Context{ID: contextID, CurrentTokens: m.tokens, UpdatedAt: time.Now()}, nil
+}
+
+func (m *countingContextManager) UpdateContext(ctx context.Context, contextID string, content string) (*models.Context, error) {
+	return m.GetContext(ctx, contextID)
+}
+
+func (m *countingContextManager) TruncateContext(ctx context.Context, contextID string, maxTokens int, preserveRecent bool) (*TruncatedContext, int, error) {
+	return &TruncatedContext{ID: contextID, TokenCount: maxTokens}, 0, nil
+}
+
+func (m *countingContextManager) CreateContext(ctx context.Context, agentID, tenantID, name, content, modelID string) (*models.Context, error) {
+	return &models.Context{ID: "ctx-new", AgentID: agentID, TenantID: tenantID}, nil
+}
+
+func (m *countingContextManager) AppendToContext(ctx context.Context, contextID string, content string) (*models.Context, error) {
+	m.appends++
+	m.tokens += len(content)
+	return m.GetContext(ctx, contextID)
+}
+
+func (m *countingContextManager) GetContextStats(ctx context.Context, contextID string) (*ContextStats, error) {
+	return &ContextStats{TotalTokens: m.tokens}, nil
+}
+
+func TestHandleContextAppendIdempotency(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	manager := &countingContextManager{tokens: 10}
+	server.SetContextManager(manager)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+
+	params, err := json.Marshal(map[string]interface{}{
+		"context_id":      "ctx-1",
+		"content":         "hello",
+		"idempotency_key": "retry-key",
+	})
+	require.NoError(t, err)
+
+	first, err := server.handleContextAppend(context.Background(), conn, params)
+	require.NoError(t, err)
+	firstResult := first.(map[string]interface{})
+	assert.Equal(t, 15, firstResult["current_tokens"])
+	assert.Equal(t, 5, firstResult["token_delta"])
+
+	// Retried request with the same key returns the stored result without appending
+	second, err := server.handleContextAppend(context.Background(), conn, params)
+	require.NoError(t, err)
+	secondResult := second.(map[string]interface{})
+	assert.Equal(t, 1, manager.appends)
+	assert.Equal(t, float64(15), secondResult["current_tokens"])
+	assert.Equal(t, float64(5), secondResult["token_delta"])
+	assert.Equal(t, true, secondResult["duplicate"])
+
+	// A different key appends again
+	params, err = json.Marshal(map[string]interface{}{
+		"context_id":      "ctx-1",
+		"content":         "hello",
+		"idempotency_key": "another-key",
+	})
+	require.NoError(t, err)
+	_, err = server.handleContextAppend(context.Background(), conn, params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, manager.appends)
+}
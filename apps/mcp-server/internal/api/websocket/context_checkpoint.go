@@ -0,0 +1,264 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/common/cache"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// ErrCheckpointNotFound is returned when no checkpoint exists for a context
+var ErrCheckpointNotFound = errors.New("context checkpoint not found")
+
+// ContextCheckpointConfig configures automatic context checkpointing
+type ContextCheckpointConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	AppendInterval int           `mapstructure:"append_interval"` // Checkpoint every N appends
+	TimeInterval   time.Duration `mapstructure:"time_interval"`   // Checkpoint when the last one is older than this
+	TTL            time.Duration `mapstructure:"ttl"`             // How long checkpoints are retained
+}
+
+// DefaultContextCheckpointConfig returns default checkpoint configuration
+func DefaultContextCheckpointConfig() ContextCheckpointConfig {
+	return ContextCheckpointConfig{
+		Enabled:        false,
+		AppendInterval: 20,
+		TimeInterval:   5 * time.Minute,
+		TTL:            24 * time.Hour,
+	}
+}
+
+// ContextCheckpoint is a durable snapshot of a context
+type ContextCheckpoint struct {
+	ContextID string          `json:"context_id"`
+	Sequence  int64           `json:"sequence"`
+	Context   *models.Context `json:"context"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// contextOpLog holds the appends made since the latest checkpoint
+type contextOpLog struct {
+	Items []models.ContextItem `json:"items"`
+}
+
+// checkpointTracker tracks in-memory progress toward the next checkpoint
+type checkpointTracker struct {
+	appendsSince   int
+	lastCheckpoint time.Time
+}
+
+// ContextCheckpointer periodically snapshots contexts to a durable store and
+// keeps an operation log of appends made since the latest snapshot
+type ContextCheckpointer struct {
+	store    cache.Cache
+	config   ContextCheckpointConfig
+	logger   observability.Logger
+	mu       sync.Mutex
+	trackers map[string]*checkpointTracker
+}
+
+// NewContextCheckpointer creates a new context checkpointer
+func NewContextCheckpointer(store cache.Cache, config ContextCheckpointConfig, logger observability.Logger) *ContextCheckpointer {
+	defaults := DefaultContextCheckpointConfig()
+	if config.AppendInterval <= 0 {
+		config.AppendInterval = defaults.AppendInterval
+	}
+	if config.TimeInterval <= 0 {
+		config.TimeInterval = defaults.TimeInterval
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+
+	return &ContextCheckpointer{
+		store:    store,
+		config:   config,
+		logger:   logger,
+		trackers: make(map[string]*checkpointTracker),
+	}
+}
+
+// SetStore replaces the durable store used for checkpoints
+func (c *ContextCheckpointer) SetStore(store cache.Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+}
+
+// RecordAppend logs an append and checkpoints the context once the append or
+// time interval has elapsed. snapshot is the context state after the append.
+func (c *ContextCheckpointer) RecordAppend(ctx context.Context, contextID, content string, snapshot *models.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tracker, ok := c.trackers[contextID]
+	if !ok {
+		tracker = &checkpointTracker{lastCheckpoint: time.Now()}
+		c.trackers[contextID] = tracker
+	}
+	tracker.appendsSince++
+
+	if tracker.appendsSince >= c.config.AppendInterval || time.Since(tracker.lastCheckpoint) >= c.config.TimeInterval {
+		return c.checkpointLocked(ctx, contextID, snapshot, tracker)
+	}
+
+	opLog, err := c.loadOpLog(ctx, contextID)
+	if err != nil {
+		return err
+	}
+	opLog.Items = append(opLog.Items, models.ContextItem{
+		ContextID: contextID,
+		Role:      "user",
+		Content:   content,
+		Timestamp: time.Now(),
+	})
+
+	return c.save(ctx, c.opLogKey(contextID), opLog)
+}
+
+// Checkpoint immediately snapshots a context and clears its operation log
+func (c *ContextCheckpointer) Checkpoint(ctx context.Context, snapshot *models.Context) error {
+	if snapshot == nil {
+		return fmt.Errorf("cannot checkpoint nil context")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tracker, ok := c.trackers[snapshot.ID]
+	if !ok {
+		tracker = &checkpointTracker{}
+		c.trackers[snapshot.ID] = tracker
+	}
+
+	return c.checkpointLocked(ctx, snapshot.ID, snapshot, tracker)
+}
+
+// Restore rebuilds a context from its latest checkpoint plus a replay of the
+// appends recorded since. It returns the restored context, the checkpoint it
+// was built from and the number of replayed operations.
+func (c *ContextCheckpointer) Restore(ctx context.Context, contextID string) (*models.Context, *ContextCheckpoint, int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	checkpoint, err := c.loadCheckpoint(ctx, contextID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	opLog, err := c.loadOpLog(ctx, contextID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	restored := *checkpoint.Context
+	restored.Content = append([]models.ContextItem{}, checkpoint.Context.Content...)
+	for _, item := range opLog.Items {
+		restored.Content = append(restored.Content, item)
+		restored.CurrentTokens += item.Tokens
+		restored.UpdatedAt = item.Timestamp
+	}
+
+	return &restored, checkpoint, len(opLog.Items), nil
+}
+
+// checkpointLocked writes a checkpoint and resets the operation log. Caller must hold c.mu.
+func (c *ContextCheckpointer) checkpointLocked(ctx context.Context, contextID string, snapshot *models.Context, tracker *checkpointTracker) error {
+	if snapshot == nil {
+		return fmt.Errorf("cannot checkpoint nil context")
+	}
+
+	var sequence int64 = 1
+	if previous, err := c.loadCheckpoint(ctx, contextID); err == nil {
+		sequence = previous.Sequence + 1
+	}
+
+	checkpoint := &ContextCheckpoint{
+		ContextID: contextID,
+		Sequence:  sequence,
+		Context:   snapshot,
+		CreatedAt: time.Now(),
+	}
+
+	if err := c.save(ctx, c.checkpointKey(contextID), checkpoint); err != nil {
+		return err
+	}
+	if err := c.store.Delete(ctx, c.opLogKey(contextID)); err != nil {
+		return fmt.Errorf("failed to reset context operation log: %w", err)
+	}
+
+	tracker.appendsSince = 0
+	tracker.lastCheckpoint = checkpoint.CreatedAt
+
+	if c.logger != nil {
+		c.logger.Debug("Context checkpoint written", map[string]interface{}{
+			"context_id": contextID,
+			"sequence":   sequence,
+		})
+	}
+
+	return nil
+}
+
+func (c *ContextCheckpointer) loadCheckpoint(ctx context.Context, contextID string) (*ContextCheckpoint, error) {
+	var checkpoint ContextCheckpoint
+	found, err := c.load(ctx, c.checkpointKey(contextID), &checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if !found || checkpoint.Context == nil {
+		return nil, ErrCheckpointNotFound
+	}
+	return &checkpoint, nil
+}
+
+func (c *ContextCheckpointer) loadOpLog(ctx context.Context, contextID string) (*contextOpLog, error) {
+	opLog := &contextOpLog{}
+	if _, err := c.load(ctx, c.opLogKey(contextID), opLog); err != nil {
+		return nil, err
+	}
+	return opLog, nil
+}
+
+// save stores a value as JSON bytes so it round-trips through any cache implementation
+func (c *ContextCheckpointer) save(ctx context.Context, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint data: %w", err)
+	}
+	if err := c.store.Set(ctx, key, data, c.config.TTL); err != nil {
+		return fmt.Errorf("failed to store checkpoint data: %w", err)
+	}
+	return nil
+}
+
+func (c *ContextCheckpointer) load(ctx context.Context, key string, value interface{}) (bool, error) {
+	var data []byte
+	if err := c.store.Get(ctx, key, &data); err != nil {
+		if errors.Is(err, cache.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load checkpoint data: %w", err)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, fmt.Errorf("failed to unmarshal checkpoint data: %w", err)
+	}
+	return true, nil
+}
+
+func (c *ContextCheckpointer) checkpointKey(contextID string) string {
+	return "context_checkpoint:" + contextID
+}
+
+func (c *ContextCheckpointer) opLogKey(contextID string) string {
+	return "context_checkpoint_oplog:" + contextID
+}
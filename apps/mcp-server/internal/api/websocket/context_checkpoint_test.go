@@ -0,0 +1,77 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+func TestContextCheckpointerRestore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryCache()
+	config := ContextCheckpointConfig{Enabled: true, AppendInterval: 3, TimeInterval: time.Hour}
+
+	checkpointer := NewContextCheckpointer(store, config, NewTestLogger())
+
+	snapshot := &models.Context{ID: "ctx-1", Name: "test"}
+	for _, content := range []string{"one", "two", "three", "four"} {
+		snapshot.Content = append(snapshot.Content, models.ContextItem{Role: "user", Content: content})
+		require.NoError(t, checkpointer.RecordAppend(ctx, "ctx-1", content, copyContext(snapshot)))
+	}
+
+	// Simulate losing all in-memory state by restoring through a fresh checkpointer
+	recovered := NewContextCheckpointer(store, config, NewTestLogger())
+	restored, checkpoint, replayed, err := recovered.Restore(ctx, "ctx-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), checkpoint.Sequence)
+	assert.Len(t, checkpoint.Context.Content, 3, "checkpoint taken on the third append")
+	assert.Equal(t, 1, replayed, "fourth append replayed from the operation log")
+
+	require.Len(t, restored.Content, 4)
+	for i, content := range []string{"one", "two", "three", "four"} {
+		assert.Equal(t, content, restored.Content[i].Content)
+	}
+}
+
+func TestContextCheckpointerRestoreMissing(t *testing.T) {
+	checkpointer := NewContextCheckpointer(NewInMemoryCache(), ContextCheckpointConfig{Enabled: true}, NewTestLogger())
+
+	_, _, _, err := checkpointer.Restore(context.Background(), "unknown")
+	assert.ErrorIs(t, err, ErrCheckpointNotFound)
+}
+
+func TestHandleContextRestoreCheckpoint(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextCheckpoint: ContextCheckpointConfig{Enabled: true, AppendInterval: 2, TimeInterval: time.Hour},
+	})
+	server.SetContextManager(&countingContextManager{})
+	conn := NewConnection("conn-1", nil, server)
+
+	for _, content := range []string{"a", "b", "c"} {
+		params, err := json.Marshal(map[string]interface{}{"context_id": "ctx-1", "content": content})
+		require.NoError(t, err)
+		_, err = server.handleContextAppend(context.Background(), conn, params)
+		require.NoError(t, err)
+	}
+
+	result, err := server.handleContextRestoreCheckpoint(context.Background(), conn, json.RawMessage(`{"context_id":"ctx-1"}`))
+	require.NoError(t, err)
+
+	restored := result.(map[string]interface{})
+	assert.Equal(t, int64(1), restored["checkpoint_sequence"])
+	assert.Equal(t, 1, restored["replayed_operations"])
+}
+
+func copyContext(c *models.Context) *models.Context {
+	clone := *c
+	clone.Content = append([]models.ContextItem{}, c.Content...)
+	return &clone
+}
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// maxHydratedMessages caps the messages a single context.get_messages call can return
+const maxHydratedMessages = 100
+
+// contextMessageID returns the ID clients use to request a context message.
+// Items without a stored ID are addressed by their position in the context.
+func contextMessageID(item models.ContextItem, index int) string {
+	if item.ID != "" {
+		return item.ID
+	}
+	return strconv.Itoa(index)
+}
+
+// formatLazyContextResponse converts a context into the wire format used by
+// context.get, with message headers in place of message content
+func formatLazyContextResponse(context *models.Context) map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(context.Content))
+	for i, item := range context.Content {
+		messages = append(messages, map[string]interface{}{
+			"id":             contextMessageID(item, i),
+			"index":          i,
+			"role":           item.Role,
+			"timestamp":      item.Timestamp.Format(time.RFC3339),
+			"tokens":         item.Tokens,
+			"content_length": len(item.Content),
+			"metadata":       item.Metadata,
+		})
+	}
+
+	return map[string]interface{}{
+		"id":             context.ID,
+		"name":           context.Name,
+		"agent_id":       context.AgentID,
+		"messages":       messages,
+		"message_count":  len(messages),
+		"lazy":           true,
+		"current_tokens": context.CurrentTokens,
+		"max_tokens":     context.MaxTokens,
+		"created_at":     context.CreatedAt.Format(time.RFC3339),
+		"updated_at":     context.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+// handleContextGetMessages hydrates the full content of selected context messages,
+// typically after a lazy context.get
+func (s *Server) handleContextGetMessages(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var getParams struct {
+		ContextID  string   `json:"context_id"`
+		MessageIDs []string `json:"message_ids"`
+	}
+
+	if err := json.Unmarshal(params, &getParams); err != nil {
+		return nil, err
+	}
+
+	if getParams.ContextID == "" {
+		return nil, fmt.Errorf("context_id is required")
+	}
+	if len(getParams.MessageIDs) == 0 {
+		return nil, fmt.Errorf("message_ids is required")
+	}
+	if len(getParams.MessageIDs) > maxHydratedMessages {
+		return nil, fmt.Errorf("at most %d messages can be requested at once", maxHydratedMessages)
+	}
+
+	if s.contextManager == nil {
+		return nil, fmt.Errorf("context manager not available")
+	}
+
+	context, err := s.contextManager.GetContext(ctx, getParams.ContextID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]int, len(context.Content))
+	for i, item := range context.Content {
+		byID[contextMessageID(item, i)] = i
+	}
+
+	// Messages are returned in the order requested
+	messages := make([]map[string]interface{}, 0, len(getParams.MessageIDs))
+	missing := []string{}
+	for _, id := range getParams.MessageIDs {
+		i, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		item := context.Content[i]
+		messages = append(messages, map[string]interface{}{
+			"id":        id,
+			"index":     i,
+			"role":      item.Role,
+			"content":   item.Content,
+			"timestamp": item.Timestamp.Format(time.RFC3339),
+			"tokens":    item.Tokens,
+			"metadata":  item.Metadata,
+		})
+	}
+
+	return map[string]interface{}{
+		"context_id": context.ID,
+		"messages":   messages,
+		"missing":    missing,
+	}, nil
+}
@@ -0,0 +1,88 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// fixedContextManager serves a single context with content
+type fixedContextManager struct {
+	countingContextManager
+	context *models.Context
+}
+
+func (m *fixedContextManager) GetContext(ctx context.Context, contextID string) (*models.Context, error) {
+	return m.context, nil
+}
+
+func newLazyContextTestServer() (*Server, *Connection) {
+	now := time.Now()
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetContextManager(&fixedContextManager{context: &models.Context{
+		ID:            "ctx-1",
+		CurrentTokens: 60,
+		Content: []models.ContextItem{
+			{ID: "msg-a", Role: "system", Content: "You are a reviewer", Timestamp: now, Tokens: 10},
+			{ID: "msg-b", Role: "user", Content: "Review this very long diff", Timestamp: now, Tokens: 30},
+			{Role: "assistant", Content: "Looks good", Timestamp: now, Tokens: 20},
+		},
+	}})
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	return server, conn
+}
+
+func TestHandleContextGetLazy(t *testing.T) {
+	server, conn := newLazyContextTestServer()
+
+	result, err := server.handleContextGet(context.Background(), conn, json.RawMessage(`{"context_id": "ctx-1", "lazy": true}`))
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, true, response["lazy"])
+	assert.Equal(t, 60, response["current_tokens"])
+	assert.NotContains(t, response, "content")
+
+	messages := response["messages"].([]map[string]interface{})
+	require.Len(t, messages, 3)
+	for _, message := range messages {
+		assert.NotContains(t, message, "content")
+	}
+	assert.Equal(t, "msg-b", messages[1]["id"])
+	assert.Equal(t, 30, messages[1]["tokens"])
+	assert.Equal(t, len("Review this very long diff"), messages[1]["content_length"])
+	// Messages without a stored ID are addressed by position
+	assert.Equal(t, "2", messages[2]["id"])
+
+	// The default get still returns content
+	result, err = server.handleContextGet(context.Background(), conn, json.RawMessage(`{"context_id": "ctx-1"}`))
+	require.NoError(t, err)
+	assert.Len(t, result.(map[string]interface{})["content"], 3)
+}
+
+func TestHandleContextGetMessages(t *testing.T) {
+	server, conn := newLazyContextTestServer()
+
+	result, err := server.handleContextGetMessages(context.Background(), conn,
+		json.RawMessage(`{"context_id": "ctx-1", "message_ids": ["2", "msg-b", "msg-missing"]}`))
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	messages := response["messages"].([]map[string]interface{})
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Looks good", messages[0]["content"])
+	assert.Equal(t, "Review this very long diff", messages[1]["content"])
+	assert.Equal(t, []string{"msg-missing"}, response["missing"])
+
+	_, err = server.handleContextGetMessages(context.Background(), conn, json.RawMessage(`{"context_id": "ctx-1"}`))
+	assert.Error(t, err)
+}
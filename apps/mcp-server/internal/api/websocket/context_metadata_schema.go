@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// ErrMetadataValidationFailed identifies contexts rejected by metadata schema validation
+const ErrMetadataValidationFailed = "metadata_validation_failed"
+
+// ContextMetadataManager is implemented by context managers that store context metadata
+type ContextMetadataManager interface {
+	CreateContextWithMetadata(ctx context.Context, agentID, tenantID, name, content, modelID string, metadata map[string]interface{}) (*models.Context, error)
+	// UpdateContextMetadata merges metadata into the context's existing metadata
+	UpdateContextMetadata(ctx context.Context, contextID string, metadata map[string]interface{}) (*models.Context, error)
+}
+
+// ContextMetadataSchemas holds the JSON Schemas tenants' context metadata must
+// satisfy. Tenants without a schema accept any metadata.
+type ContextMetadataSchemas struct {
+	mu      sync.RWMutex
+	schemas map[string]*gojsonschema.Schema // tenant ID -> schema
+}
+
+// NewContextMetadataSchemas creates an empty metadata schema registry
+func NewContextMetadataSchemas() *ContextMetadataSchemas {
+	return &ContextMetadataSchemas{
+		schemas: make(map[string]*gojsonschema.Schema),
+	}
+}
+
+// Set sets a tenant's metadata schema, given as a JSON string or decoded JSON.
+// A nil schema removes it.
+func (m *ContextMetadataSchemas) Set(tenantID string, schema interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if schema == nil {
+		delete(m.schemas, tenantID)
+		return nil
+	}
+
+	var loader gojsonschema.JSONLoader
+	if raw, ok := schema.(string); ok {
+		loader = gojsonschema.NewStringLoader(raw)
+	} else {
+		loader = gojsonschema.NewGoLoader(schema)
+	}
+
+	compiled, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return fmt.Errorf("invalid metadata schema for tenant %s: %w", tenantID, err)
+	}
+	m.schemas[tenantID] = compiled
+	return nil
+}
+
+// Validate checks metadata against the tenant's schema
+func (m *ContextMetadataSchemas) Validate(tenantID string, metadata map[string]interface{}) error {
+	m.mu.RLock()
+	schema, ok := m.schemas[tenantID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+
+	validation, err := schema.Validate(gojsonschema.NewGoLoader(metadata))
+	if err != nil {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Context metadata could not be validated", map[string]interface{}{
+			"error":   ErrMetadataValidationFailed,
+			"details": err.Error(),
+		})
+	}
+	if validation.Valid() {
+		return nil
+	}
+
+	violations := schemaViolations(validation)
+	return ws.NewError(ws.ErrCodeInvalidParams,
+		fmt.Sprintf("Context metadata does not match the tenant's metadata schema (%d violations)", len(violations)),
+		map[string]interface{}{
+			"error":      ErrMetadataValidationFailed,
+			"violations": violations,
+		})
+}
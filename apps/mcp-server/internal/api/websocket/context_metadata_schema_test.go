@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// metadataContextManager stores context metadata in memory
+type metadataContextManager struct {
+	countingContextManager
+	metadata map[string]interface{}
+}
+
+func (m *metadataContextManager) GetContext(ctx context.Context, contextID string) (*models.Context, error) {
+	return &models.Context{ID: contextID, Metadata: m.metadata}, nil
+}
+
+func (m *metadataContextManager) CreateContextWithMetadata(ctx context.Context, agentID, tenantID, name, content, modelID string, metadata map[string]interface{}) (*models.Context, error) {
+	m.metadata = metadata
+	return &models.Context{ID: "ctx-new", AgentID: agentID, TenantID: tenantID, Metadata: metadata}, nil
+}
+
+func (m *metadataContextManager) UpdateContextMetadata(ctx context.Context, contextID string, metadata map[string]interface{}) (*models.Context, error) {
+	if m.metadata == nil {
+		m.metadata = make(map[string]interface{})
+	}
+	for k, v := range metadata {
+		m.metadata[k] = v
+	}
+	return m.GetContext(ctx, contextID)
+}
+
+var projectMetadataSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"project", "priority"},
+	"properties": map[string]interface{}{
+		"project":  map[string]interface{}{"type": "string"},
+		"priority": map[string]interface{}{"type": "string", "enum": []interface{}{"low", "high"}},
+		"labels":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+	},
+}
+
+func newMetadataSchemaTestServer(t *testing.T) (*Server, *metadataContextManager) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextMetadataSchemas: map[string]interface{}{"tenant-1": projectMetadataSchema},
+	})
+	manager := &metadataContextManager{}
+	server.SetContextManager(manager)
+	return server, manager
+}
+
+func metadataViolations(t *testing.T, err error) []string {
+	t.Helper()
+	var wsErr *ws.Error
+	require.ErrorAs(t, err, &wsErr)
+	assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+
+	data := wsErr.Data.(map[string]interface{})
+	assert.Equal(t, ErrMetadataValidationFailed, data["error"])
+
+	var fields []string
+	for _, violation := range data["violations"].([]map[string]interface{}) {
+		fields = append(fields, violation["field"].(string))
+	}
+	return fields
+}
+
+func TestContextCreateValidatesMetadata(t *testing.T) {
+	server, manager := newMetadataSchemaTestServer(t)
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+
+	create := func(metadata map[string]interface{}) (interface{}, error) {
+		params, err := json.Marshal(map[string]interface{}{
+			"name":     "release",
+			"content":  "notes",
+			"metadata": metadata,
+		})
+		require.NoError(t, err)
+		return server.handleContextCreate(context.Background(), conn, params)
+	}
+
+	t.Run("conforming metadata is accepted", func(t *testing.T) {
+		result, err := create(map[string]interface{}{"project": "mesh", "priority": "high", "labels": []string{"q3"}})
+		require.NoError(t, err)
+		assert.Equal(t, "mesh", result.(map[string]interface{})["metadata"].(map[string]interface{})["project"])
+		assert.Equal(t, "mesh", manager.metadata["project"])
+	})
+
+	t.Run("non-conforming metadata is rejected with field errors", func(t *testing.T) {
+		manager.metadata = nil
+		_, err := create(map[string]interface{}{"priority": "urgent", "labels": []interface{}{"q3", 7}})
+		fields := metadataViolations(t, err)
+		assert.ElementsMatch(t, []string{"project", "priority", "labels.1"}, fields)
+		assert.Nil(t, manager.metadata)
+	})
+
+	t.Run("missing required metadata is rejected", func(t *testing.T) {
+		params, err := json.Marshal(map[string]interface{}{"name": "release"})
+		require.NoError(t, err)
+		_, err = server.handleContextCreate(context.Background(), conn, params)
+		assert.ElementsMatch(t, []string{"project", "priority"}, metadataViolations(t, err))
+	})
+
+	t.Run("tenants without a schema accept any metadata", func(t *testing.T) {
+		other := NewConnection("conn-2", nil, server)
+		other.TenantID = "tenant-2"
+		params, err := json.Marshal(map[string]interface{}{"name": "scratch", "metadata": map[string]interface{}{"anything": 1}})
+		require.NoError(t, err)
+		_, err = server.handleContextCreate(context.Background(), other, params)
+		assert.NoError(t, err)
+	})
+}
+
+func TestContextUpdateValidatesMergedMetadata(t *testing.T) {
+	server, manager := newMetadataSchemaTestServer(t)
+	manager.metadata = map[string]interface{}{"project": "mesh", "priority": "low"}
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+
+	update := func(metadata map[string]interface{}) (interface{}, error) {
+		params, err := json.Marshal(map[string]interface{}{"context_id": "ctx-1", "metadata": metadata})
+		require.NoError(t, err)
+		return server.handleContextUpdate(context.Background(), conn, params)
+	}
+
+	// A partial update is valid once merged with the existing metadata
+	result, err := update(map[string]interface{}{"priority": "high"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"project": "mesh", "priority": "high"}, result.(map[string]interface{})["metadata"])
+
+	_, err = update(map[string]interface{}{"project": 42})
+	assert.Equal(t, []string{"project"}, metadataViolations(t, err))
+	assert.Equal(t, "mesh", manager.metadata["project"])
+}
+
+func TestContextMetadataSchemasSet(t *testing.T) {
+	schemas := NewContextMetadataSchemas()
+
+	assert.Error(t, schemas.Set("tenant-1", `{"type": "not-a-type"}`))
+
+	require.NoError(t, schemas.Set("tenant-1", `{"type": "object", "required": ["project"]}`))
+	assert.Error(t, schemas.Validate("tenant-1", map[string]interface{}{}))
+
+	// Removing the schema accepts any metadata again
+	require.NoError(t, schemas.Set("tenant-1", nil))
+	assert.NoError(t, schemas.Validate("tenant-1", map[string]interface{}{}))
+}
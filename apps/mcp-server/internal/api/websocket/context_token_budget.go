@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// Context token budget strategies
+const (
+	// ContextBudgetReject rejects appends that would exceed the context's max tokens
+	ContextBudgetReject = "reject"
+	// ContextBudgetTruncate drops the oldest content until the context fits
+	ContextBudgetTruncate = "truncate"
+	// ContextBudgetSummarize replaces the oldest content with a summary until the
+	// context fits, truncating when the context manager can't summarize
+	ContextBudgetSummarize = "summarize"
+)
+
+// ContextTokenBudgetConfig configures how context.append enforces contexts' max tokens
+type ContextTokenBudgetConfig struct {
+	Strategy string `mapstructure:"strategy"` // reject, truncate or summarize; empty disables enforcement
+	// MaxTokens applies to contexts that don't set their own max tokens
+	MaxTokens int `mapstructure:"max_tokens"`
+}
+
+// Enabled reports whether appends are checked against the token budget
+func (c ContextTokenBudgetConfig) Enabled() bool {
+	return c.Strategy != ""
+}
+
+// Validate checks the strategy is known
+func (c ContextTokenBudgetConfig) Validate() error {
+	switch c.Strategy {
+	case "", ContextBudgetReject, ContextBudgetTruncate, ContextBudgetSummarize:
+		return nil
+	}
+	return fmt.Errorf("unknown context token budget strategy: %s", c.Strategy)
+}
+
+// ContextSummarizer is implemented by context managers that can condense a
+// context's older content into a summary
+type ContextSummarizer interface {
+	// SummarizeContext replaces the oldest content with a summary so the context
+	// fits within maxTokens, returning the updated context and the tokens removed
+	SummarizeContext(ctx context.Context, contextID string, maxTokens int) (*models.Context, int, error)
+}
+
+// estimateTokens approximates the tokens in content at four characters a token
+func estimateTokens(content string) int {
+	return (len(content) + 3) / 4
+}
+
+// contextTokenLimit returns the max tokens a context may hold, or zero when unlimited
+func (s *Server) contextTokenLimit(existing *models.Context) int {
+	if existing != nil && existing.MaxTokens > 0 {
+		return existing.MaxTokens
+	}
+	return s.config.ContextTokenBudget.MaxTokens
+}
+
+// checkContextAppendBudget rejects an append that would take the context over
+// its token limit when the reject strategy is configured
+func (s *Server) checkContextAppendBudget(existing *models.Context, content string) error {
+	budget := s.config.ContextTokenBudget
+	if budget.Strategy != ContextBudgetReject || existing == nil {
+		return nil
+	}
+
+	limit := s.contextTokenLimit(existing)
+	appendTokens := estimateTokens(content)
+	if limit <= 0 || existing.CurrentTokens+appendTokens <= limit {
+		return nil
+	}
+
+	return ws.NewError(ws.ErrCodeContextTooLarge, "Append would exceed the context's token limit", map[string]interface{}{
+		"context_id":     existing.ID,
+		"max_tokens":     limit,
+		"current_tokens": existing.CurrentTokens,
+		"append_tokens":  appendTokens,
+		"strategy":       budget.Strategy,
+	})
+}
+
+// enforceContextBudget brings a context that an append took over its token
+// limit back within it, by truncating or summarizing per the configured
+// strategy. It returns the resulting context and what was done, or nil when
+// the context is within its limit.
+func (s *Server) enforceContextBudget(ctx context.Context, contextID string, existing, appended *models.Context) (*models.Context, map[string]interface{}, error) {
+	budget := s.config.ContextTokenBudget
+	if budget.Strategy != ContextBudgetTruncate && budget.Strategy != ContextBudgetSummarize {
+		return appended, nil, nil
+	}
+
+	limit := s.contextTokenLimit(existing)
+	if limit <= 0 || appended.CurrentTokens <= limit {
+		return appended, nil, nil
+	}
+
+	enforcement := map[string]interface{}{
+		"max_tokens": limit,
+		"strategy":   budget.Strategy,
+	}
+
+	if budget.Strategy == ContextBudgetSummarize {
+		if summarizer, ok := s.contextManager.(ContextSummarizer); ok {
+			summarized, removed, err := summarizer.SummarizeContext(ctx, contextID, limit)
+			if err == nil {
+				enforcement["action"] = "summarized"
+				enforcement["removed_tokens"] = removed
+				return summarized, enforcement, nil
+			}
+			s.logger.Warn("Failed to summarize context, truncating instead", map[string]interface{}{
+				"context_id": contextID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	truncated, removed, err := s.contextManager.TruncateContext(ctx, contextID, limit, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to truncate context to its token limit: %w", err)
+	}
+
+	enforcement["action"] = "truncated"
+	enforcement["removed_tokens"] = removed
+	result := *appended
+	result.CurrentTokens = truncated.TokenCount
+	return &result, enforcement, nil
+}
@@ -0,0 +1,213 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// budgetContextManager is a ContextManager holding a single context with a token limit
+type budgetContextManager struct {
+	countingContextManager
+	maxTokens int
+	truncates int
+}
+
+func (m *budgetContextManager) GetContext(ctx context.Context, contextID string) (*models.Context, error) {
+	return &models.Context{ID: contextID, CurrentTokens: m.tokens, MaxTokens: m.maxTokens, UpdatedAt: time.Now()}, nil
+}
+
+func (m *budgetContextManager) AppendToContext(ctx context.Context, contextID string, content string) (*models.Context, error) {
+	m.appends++
+	m.tokens += estimateTokens(content)
+	return m.GetContext(ctx, contextID)
+}
+
+func (m *budgetContextManager) TruncateContext(ctx context.Context, contextID string, maxTokens int, preserveRecent bool) (*TruncatedContext, int, error) {
+	m.truncates++
+	removed := m.tokens - maxTokens
+	m.tokens = maxTokens
+	return &TruncatedContext{ID: contextID, TokenCount: m.tokens}, removed, nil
+}
+
+// summarizingContextManager can also summarize, optionally failing to
+type summarizingContextManager struct {
+	budgetContextManager
+	summarizes int
+	err        error
+}
+
+func (m *summarizingContextManager) SummarizeContext(ctx context.Context, contextID string, maxTokens int) (*models.Context, int, error) {
+	m.summarizes++
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+	removed := m.tokens - maxTokens/2
+	m.tokens = maxTokens / 2
+	summarized, _ := m.GetContext(ctx, contextID)
+	return summarized, removed, nil
+}
+
+func appendForBudget(t *testing.T, server *Server, content string) (map[string]interface{}, error) {
+	t.Helper()
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+
+	params, err := json.Marshal(map[string]interface{}{
+		"context_id": "ctx-1",
+		"content":    content,
+	})
+	require.NoError(t, err)
+
+	result, err := server.handleContextAppend(context.Background(), conn, params)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func TestContextTokenBudgetReject(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetReject},
+	})
+	manager := &budgetContextManager{maxTokens: 100}
+	manager.tokens = 90
+	server.SetContextManager(manager)
+
+	// 40 characters is 10 tokens, which still fits
+	result, err := appendForBudget(t, server, string(make([]byte, 40)))
+	require.NoError(t, err)
+	assert.Equal(t, 100, result["current_tokens"])
+
+	_, err = appendForBudget(t, server, "one more")
+	require.Error(t, err)
+
+	var wsErr *ws.Error
+	require.True(t, errors.As(err, &wsErr))
+	assert.Equal(t, ws.ErrCodeContextTooLarge, wsErr.Code)
+	data := wsErr.Data.(map[string]interface{})
+	assert.Equal(t, 100, data["max_tokens"])
+	assert.Equal(t, 100, data["current_tokens"])
+	assert.Equal(t, 2, data["append_tokens"])
+
+	// The rejected content was never appended
+	assert.Equal(t, 1, manager.appends)
+	assert.Equal(t, 100, manager.tokens)
+}
+
+func TestContextTokenBudgetTruncate(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetTruncate},
+	})
+	manager := &budgetContextManager{maxTokens: 100}
+	manager.tokens = 90
+	server.SetContextManager(manager)
+
+	result, err := appendForBudget(t, server, string(make([]byte, 80)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, manager.appends)
+	assert.Equal(t, 1, manager.truncates)
+	assert.Equal(t, 100, result["current_tokens"])
+	assert.Equal(t, 10, result["token_delta"])
+
+	enforcement := result["token_budget"].(map[string]interface{})
+	assert.Equal(t, "truncated", enforcement["action"])
+	assert.Equal(t, 10, enforcement["removed_tokens"])
+	assert.Equal(t, 100, enforcement["max_tokens"])
+
+	// Appends within the limit aren't truncated
+	manager.tokens = 50
+	result, err = appendForBudget(t, server, "fits")
+	require.NoError(t, err)
+	assert.Equal(t, 1, manager.truncates)
+	assert.NotContains(t, result, "token_budget")
+}
+
+func TestContextTokenBudgetSummarize(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetSummarize},
+	})
+	manager := &summarizingContextManager{}
+	manager.maxTokens = 100
+	manager.tokens = 90
+	server.SetContextManager(manager)
+
+	result, err := appendForBudget(t, server, string(make([]byte, 80)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, manager.summarizes)
+	assert.Equal(t, 0, manager.truncates)
+	assert.Equal(t, 50, result["current_tokens"])
+
+	enforcement := result["token_budget"].(map[string]interface{})
+	assert.Equal(t, "summarized", enforcement["action"])
+	assert.Equal(t, 60, enforcement["removed_tokens"])
+}
+
+func TestContextTokenBudgetSummarizeFallsBackToTruncate(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetSummarize},
+	})
+
+	// A failed summary truncates instead
+	manager := &summarizingContextManager{err: errors.New("summarizer unavailable")}
+	manager.maxTokens = 100
+	manager.tokens = 90
+	server.SetContextManager(manager)
+
+	result, err := appendForBudget(t, server, string(make([]byte, 80)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, manager.summarizes)
+	assert.Equal(t, 1, manager.truncates)
+	assert.Equal(t, "truncated", result["token_budget"].(map[string]interface{})["action"])
+
+	// So does a context manager that can't summarize
+	plain := &budgetContextManager{maxTokens: 100}
+	plain.tokens = 90
+	server.SetContextManager(plain)
+
+	result, err = appendForBudget(t, server, string(make([]byte, 80)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, plain.truncates)
+	assert.Equal(t, 100, result["current_tokens"])
+}
+
+func TestContextTokenBudgetDefaultMaxTokens(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetReject, MaxTokens: 20},
+	})
+
+	// The context sets no limit of its own
+	manager := &budgetContextManager{}
+	manager.tokens = 18
+	server.SetContextManager(manager)
+
+	_, err := appendForBudget(t, server, "more than two tokens")
+	require.Error(t, err)
+	assert.Equal(t, 0, manager.appends)
+}
+
+func TestContextTokenBudgetDisabled(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ContextTokenBudget: ContextTokenBudgetConfig{Strategy: "compress"},
+	})
+	assert.False(t, server.config.ContextTokenBudget.Enabled())
+
+	manager := &budgetContextManager{maxTokens: 100}
+	manager.tokens = 90
+	server.SetContextManager(manager)
+
+	result, err := appendForBudget(t, server, string(make([]byte, 80)))
+	require.NoError(t, err)
+	assert.Equal(t, 110, result["current_tokens"])
+	assert.Equal(t, 0, manager.truncates)
+}
@@ -0,0 +1,273 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+const (
+	// MaxOpenContextTxs caps the context transactions a connection may have open
+	MaxOpenContextTxs = 10
+	// MaxContextTxOperations caps the operations buffered in one context transaction
+	MaxContextTxOperations = 100
+	// ContextTxTimeout is how long a context transaction may stay open
+	ContextTxTimeout = 5 * time.Minute
+)
+
+// ContextBatchAppender is implemented by context managers that can append
+// several items to a context in a single update, which context transactions
+// need to commit atomically
+type ContextBatchAppender interface {
+	AppendManyToContext(ctx context.Context, contextID string, contents []string) (*models.Context, error)
+}
+
+// contextTransaction buffers a connection's mutations to one context until
+// they are committed or rolled back. Transactions live on the connection, so
+// a dropped connection discards its uncommitted work.
+type contextTransaction struct {
+	id        string
+	contextID string
+	appends   []string
+	startedAt time.Time
+}
+
+func (tx *contextTransaction) expired() bool {
+	return time.Since(tx.startedAt) > ContextTxTimeout
+}
+
+// beginContextTx opens a transaction on the context, or returns false when
+// the connection already has too many open
+func (c *Connection) beginContextTx(contextID string) (*contextTransaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.contextTxs == nil {
+		c.contextTxs = make(map[string]*contextTransaction)
+	}
+	for id, tx := range c.contextTxs {
+		if tx.expired() {
+			delete(c.contextTxs, id)
+		}
+	}
+	if len(c.contextTxs) >= MaxOpenContextTxs {
+		return nil, false
+	}
+
+	tx := &contextTransaction{
+		id:        uuid.New().String(),
+		contextID: contextID,
+		startedAt: time.Now(),
+	}
+	c.contextTxs[tx.id] = tx
+	return tx, true
+}
+
+// bufferContextAppend adds an append to an open transaction on the context
+// and returns the number of buffered operations
+func (c *Connection) bufferContextAppend(txID, contextID, content string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.openContextTxLocked(txID)
+	if err != nil {
+		return 0, err
+	}
+	if contextID != "" && contextID != tx.contextID {
+		return 0, ws.NewError(ws.ErrCodeInvalidParams, "Transaction belongs to a different context", map[string]interface{}{
+			"tx_id":      txID,
+			"context_id": tx.contextID,
+		})
+	}
+	if len(tx.appends) >= MaxContextTxOperations {
+		return 0, ws.NewError(ws.ErrCodeInvalidParams, "Transaction has too many operations", map[string]interface{}{
+			"tx_id":          txID,
+			"max_operations": MaxContextTxOperations,
+		})
+	}
+
+	tx.appends = append(tx.appends, content)
+	return len(tx.appends), nil
+}
+
+// endContextTx removes an open transaction from the connection and returns it
+func (c *Connection) endContextTx(txID string) (*contextTransaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, err := c.openContextTxLocked(txID)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.contextTxs, txID)
+	return tx, nil
+}
+
+// openContextTxLocked returns an open transaction. Expired transactions are
+// discarded. The caller must hold c.mu.
+func (c *Connection) openContextTxLocked(txID string) (*contextTransaction, error) {
+	tx, ok := c.contextTxs[txID]
+	if ok && tx.expired() {
+		delete(c.contextTxs, txID)
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Transaction expired and was rolled back", map[string]interface{}{
+			"tx_id":      txID,
+			"timeout_ms": ContextTxTimeout.Milliseconds(),
+		})
+	}
+	if !ok {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Transaction not found", map[string]interface{}{
+			"tx_id": txID,
+		})
+	}
+	return tx, nil
+}
+
+// handleContextBeginTx opens a transaction that buffers context.append calls
+// carrying its tx_id until context.commit or context.rollback
+func (s *Server) handleContextBeginTx(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var beginParams struct {
+		ContextID string `json:"context_id"`
+	}
+
+	if err := json.Unmarshal(params, &beginParams); err != nil {
+		return nil, err
+	}
+	if beginParams.ContextID == "" {
+		return nil, fmt.Errorf("context_id is required")
+	}
+
+	tx, ok := conn.beginContextTx(beginParams.ContextID)
+	if !ok {
+		return nil, ws.NewError(ws.ErrCodeRateLimited, "Too many open transactions", map[string]interface{}{
+			"max_open_transactions": MaxOpenContextTxs,
+		})
+	}
+
+	return map[string]interface{}{
+		"tx_id":      tx.id,
+		"context_id": tx.contextID,
+		"expires_at": tx.startedAt.Add(ContextTxTimeout).Format(time.RFC3339),
+	}, nil
+}
+
+// handleContextCommit applies a transaction's buffered appends in a single
+// context update
+func (s *Server) handleContextCommit(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var commitParams struct {
+		TxID string `json:"tx_id"`
+	}
+
+	if err := json.Unmarshal(params, &commitParams); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.endContextTx(commitParams.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"tx_id":      tx.id,
+		"context_id": tx.contextID,
+		"applied":    0,
+	}
+	if len(tx.appends) == 0 || s.contextManager == nil {
+		return result, nil
+	}
+
+	existing, err := s.contextManager.GetContext(ctx, tx.contextID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get context: %w", err)
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("context not found: %s", tx.contextID)
+	}
+	if err := s.checkContextAppendBudget(existing, strings.Join(tx.appends, "")); err != nil {
+		return nil, err
+	}
+
+	committed, err := s.applyContextTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	committed, enforcement, err := s.enforceContextBudget(ctx, tx.contextID, existing, committed)
+	if err != nil {
+		return nil, err
+	}
+
+	// Checkpoint the committed state rather than logging each append against it
+	if s.contextCheckpointer != nil {
+		if err := s.contextCheckpointer.Checkpoint(ctx, committed); err != nil {
+			s.logger.Warn("Failed to checkpoint committed context", map[string]interface{}{
+				"context_id": tx.contextID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	result["applied"] = len(tx.appends)
+	result["current_tokens"] = committed.CurrentTokens
+	result["token_delta"] = committed.CurrentTokens - existing.CurrentTokens
+	result["updated_at"] = committed.UpdatedAt.Format(time.RFC3339)
+	if enforcement != nil {
+		result["token_budget"] = enforcement
+	}
+	return result, nil
+}
+
+// applyContextTx appends a transaction's content to its context. Context
+// managers that can't batch appends get them one at a time, so a failure part
+// way through reports how many were applied.
+func (s *Server) applyContextTx(ctx context.Context, tx *contextTransaction) (*models.Context, error) {
+	if batcher, ok := s.contextManager.(ContextBatchAppender); ok {
+		committed, err := batcher.AppendManyToContext(ctx, tx.contextID, tx.appends)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return committed, nil
+	}
+
+	var committed *models.Context
+	for i, content := range tx.appends {
+		var err error
+		committed, err = s.contextManager.AppendToContext(ctx, tx.contextID, content)
+		if err != nil {
+			return nil, ws.NewError(ws.ErrCodeServerError, "Transaction partially applied", map[string]interface{}{
+				"tx_id":   tx.id,
+				"applied": i,
+				"error":   err.Error(),
+			})
+		}
+	}
+	return committed, nil
+}
+
+// handleContextRollback discards a transaction's buffered operations
+func (s *Server) handleContextRollback(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var rollbackParams struct {
+		TxID string `json:"tx_id"`
+	}
+
+	if err := json.Unmarshal(params, &rollbackParams); err != nil {
+		return nil, err
+	}
+
+	tx, err := conn.endContextTx(rollbackParams.TxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"tx_id":      tx.id,
+		"context_id": tx.contextID,
+		"discarded":  len(tx.appends),
+	}, nil
+}
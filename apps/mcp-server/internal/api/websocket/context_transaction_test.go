@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// batchingContextManager records the batches appended to contexts
+type batchingContextManager struct {
+	countingContextManager
+	batches [][]string
+}
+
+func (m *batchingContextManager) AppendManyToContext(ctx context.Context, contextID string, contents []string) (*models.Context, error) {
+	m.batches = append(m.batches, contents)
+	for _, content := range contents {
+		m.tokens += len(content)
+	}
+	return m.GetContext(ctx, contextID)
+}
+
+func callContextMethod(t *testing.T, handler func(context.Context, *Connection, json.RawMessage) (interface{}, error), conn *Connection, params map[string]interface{}) (map[string]interface{}, error) {
+	t.Helper()
+
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+	result, err := handler(context.Background(), conn, data)
+	if err != nil {
+		return nil, err
+	}
+	return result.(map[string]interface{}), nil
+}
+
+func beginContextTx(t *testing.T, server *Server, conn *Connection, contextID string) string {
+	t.Helper()
+
+	result, err := callContextMethod(t, server.handleContextBeginTx, conn, map[string]interface{}{"context_id": contextID})
+	require.NoError(t, err)
+	return result["tx_id"].(string)
+}
+
+func appendInTx(t *testing.T, server *Server, conn *Connection, txID string, contents ...string) {
+	t.Helper()
+
+	for i, content := range contents {
+		result, err := callContextMethod(t, server.handleContextAppend, conn, map[string]interface{}{
+			"context_id": "ctx-1",
+			"content":    content,
+			"tx_id":      txID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "pending", result["status"])
+		assert.Equal(t, i+1, result["pending"])
+	}
+}
+
+func TestContextTransactionRollback(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	manager := &batchingContextManager{}
+	server.SetContextManager(manager)
+	conn := NewConnection("conn-1", nil, server)
+
+	txID := beginContextTx(t, server, conn, "ctx-1")
+	appendInTx(t, server, conn, txID, "first", "second", "third")
+	assert.Equal(t, 0, manager.appends)
+	assert.Empty(t, manager.batches)
+
+	result, err := callContextMethod(t, server.handleContextRollback, conn, map[string]interface{}{"tx_id": txID})
+	require.NoError(t, err)
+	assert.Equal(t, 3, result["discarded"])
+
+	// Nothing was applied and the transaction is gone
+	assert.Equal(t, 0, manager.appends)
+	assert.Empty(t, manager.batches)
+	assert.Equal(t, 0, manager.tokens)
+
+	_, err = callContextMethod(t, server.handleContextCommit, conn, map[string]interface{}{"tx_id": txID})
+	var wsErr *ws.Error
+	require.True(t, errors.As(err, &wsErr))
+	assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+}
+
+func TestContextTransactionCommit(t *testing.T) {
+	t.Run("batches appends into one update", func(t *testing.T) {
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		manager := &batchingContextManager{countingContextManager: countingContextManager{tokens: 10}}
+		server.SetContextManager(manager)
+		conn := NewConnection("conn-1", nil, server)
+
+		txID := beginContextTx(t, server, conn, "ctx-1")
+		appendInTx(t, server, conn, txID, "first", "second", "third")
+
+		result, err := callContextMethod(t, server.handleContextCommit, conn, map[string]interface{}{"tx_id": txID})
+		require.NoError(t, err)
+		assert.Equal(t, 3, result["applied"])
+		assert.Equal(t, 26, result["current_tokens"])
+		assert.Equal(t, 16, result["token_delta"])
+
+		require.Len(t, manager.batches, 1)
+		assert.Equal(t, []string{"first", "second", "third"}, manager.batches[0])
+		assert.Equal(t, 0, manager.appends)
+	})
+
+	t.Run("applies appends in order without batch support", func(t *testing.T) {
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		manager := &countingContextManager{}
+		server.SetContextManager(manager)
+		conn := NewConnection("conn-1", nil, server)
+
+		txID := beginContextTx(t, server, conn, "ctx-1")
+		appendInTx(t, server, conn, txID, "first", "second")
+
+		result, err := callContextMethod(t, server.handleContextCommit, conn, map[string]interface{}{"tx_id": txID})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result["applied"])
+		assert.Equal(t, 2, manager.appends)
+		assert.Equal(t, 11, manager.tokens)
+	})
+
+	t.Run("rejects a commit over the token budget", func(t *testing.T) {
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+			ContextTokenBudget: ContextTokenBudgetConfig{Strategy: ContextBudgetReject, MaxTokens: 2},
+		})
+		manager := &batchingContextManager{}
+		server.SetContextManager(manager)
+		conn := NewConnection("conn-1", nil, server)
+
+		txID := beginContextTx(t, server, conn, "ctx-1")
+		appendInTx(t, server, conn, txID, "first", "second")
+
+		_, err := callContextMethod(t, server.handleContextCommit, conn, map[string]interface{}{"tx_id": txID})
+		var wsErr *ws.Error
+		require.True(t, errors.As(err, &wsErr))
+		assert.Equal(t, ws.ErrCodeContextTooLarge, wsErr.Code)
+		assert.Empty(t, manager.batches)
+	})
+}
+
+func TestContextTransactionScope(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	manager := &batchingContextManager{}
+	server.SetContextManager(manager)
+	conn := NewConnection("conn-1", nil, server)
+
+	t.Run("appends must target the transaction's context", func(t *testing.T) {
+		txID := beginContextTx(t, server, conn, "ctx-2")
+		_, err := callContextMethod(t, server.handleContextAppend, conn, map[string]interface{}{
+			"context_id": "ctx-1",
+			"content":    "misdirected",
+			"tx_id":      txID,
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("transactions belong to their connection", func(t *testing.T) {
+		txID := beginContextTx(t, server, conn, "ctx-1")
+		appendInTx(t, server, conn, txID, "first")
+
+		// A reconnecting client can't commit work buffered on the dropped connection
+		reconnected := NewConnection("conn-2", nil, server)
+		_, err := callContextMethod(t, server.handleContextCommit, reconnected, map[string]interface{}{"tx_id": txID})
+		require.Error(t, err)
+		assert.Empty(t, manager.batches)
+	})
+
+	t.Run("expired transactions are rolled back", func(t *testing.T) {
+		txID := beginContextTx(t, server, conn, "ctx-1")
+		appendInTx(t, server, conn, txID, "first")
+
+		conn.mu.Lock()
+		conn.contextTxs[txID].startedAt = time.Now().Add(-ContextTxTimeout - time.Second)
+		conn.mu.Unlock()
+
+		_, err := callContextMethod(t, server.handleContextCommit, conn, map[string]interface{}{"tx_id": txID})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expired")
+		assert.Empty(t, manager.batches)
+	})
+
+	t.Run("open transactions are capped", func(t *testing.T) {
+		other := NewConnection("conn-3", nil, server)
+		for i := 0; i < MaxOpenContextTxs; i++ {
+			beginContextTx(t, server, other, "ctx-1")
+		}
+		_, err := callContextMethod(t, server.handleContextBeginTx, other, map[string]interface{}{"context_id": "ctx-1"})
+		require.Error(t, err)
+	})
+}
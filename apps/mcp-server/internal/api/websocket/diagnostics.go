@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/security"
+)
+
+// handleSystemDiagnostics handles the system.diagnostics method. It assembles
+// what support usually asks for in one redacted bundle. Tenant-specific
+// sections only cover the caller's tenant.
+func (s *Server) handleSystemDiagnostics(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	config, err := s.diagnosticsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"generated_at":     time.Now().UTC().Format(time.RFC3339),
+		"server":           s.diagnosticsServer(),
+		"config":           config,
+		"connections":      s.diagnosticsConnections(conn.TenantID),
+		"metrics":          s.diagnosticsMetrics(conn.TenantID),
+		"circuit_breakers": s.diagnosticsCircuitBreakers(),
+		"caches":           s.diagnosticsCaches(),
+	}, nil
+}
+
+// diagnosticsServer describes the running build
+func (s *Server) diagnosticsServer() map[string]interface{} {
+	return map[string]interface{}{
+		"version":        s.config.Version,
+		"build_time":     s.config.BuildTime,
+		"git_commit":     s.config.GitCommit,
+		"go_version":     runtime.Version(),
+		"goroutines":     runtime.NumGoroutine(),
+		"uptime_seconds": time.Since(s.startTime).Seconds(),
+	}
+}
+
+// diagnosticsConfig snapshots the server config with sensitive values redacted,
+// by the field names tool audit records and exchange logs are redacted by.
+// Schemas are summarized by count, since they're large and tenant-specific.
+func (s *Server) diagnosticsConfig() (map[string]interface{}, error) {
+	data, err := json.Marshal(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot config: %w", err)
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to snapshot config: %w", err)
+	}
+
+	delete(snapshot, "ContextMetadataSchemas")
+	delete(snapshot, "MethodSchemas")
+	snapshot["ContextMetadataSchemaCount"] = len(s.config.ContextMetadataSchemas)
+	snapshot["MethodSchemaOverrideCount"] = len(s.config.MethodSchemas)
+
+	return security.RedactMap(snapshot, security.SensitiveFields), nil
+}
+
+// diagnosticsConnections summarizes open connections
+func (s *Server) diagnosticsConnections(tenantID string) map[string]interface{} {
+	s.mu.RLock()
+	total := len(s.connections)
+	tenantConns := make([]*Connection, 0)
+	for _, c := range s.connections {
+		if c.Connection != nil && c.TenantID == tenantID {
+			tenantConns = append(tenantConns, c)
+		}
+	}
+	s.mu.RUnlock()
+
+	byMode := make(map[string]int)
+	binary := 0
+	for _, c := range tenantConns {
+		c.mu.RLock()
+		if c.state != nil {
+			byMode[c.state.ConnectionMode.String()]++
+			if c.state.BinaryMode {
+				binary++
+			}
+		}
+		c.mu.RUnlock()
+	}
+
+	stale := 0
+	for _, stats := range s.KeepaliveReport(tenantID) {
+		if stats.Stale {
+			stale++
+		}
+	}
+
+	return map[string]interface{}{
+		"total":        total,
+		"tenant_total": len(tenantConns),
+		"tenant_stale": stale,
+		"by_mode":      byMode,
+		"binary_mode":  binary,
+	}
+}
+
+// diagnosticsMetrics reports the collected WebSocket metrics
+func (s *Server) diagnosticsMetrics(tenantID string) map[string]interface{} {
+	if s.metricsCollector == nil {
+		return nil
+	}
+	stats := s.metricsCollector.GetStats()
+
+	metrics := map[string]interface{}{
+		"total_connections":        stats.TotalConnections,
+		"active_connections":       stats.ActiveConnections,
+		"failed_connections":       stats.FailedConnections,
+		"avg_connection_seconds":   stats.AvgConnectionDuration.Seconds(),
+		"messages_received":        stats.MessagesReceived,
+		"messages_sent":            stats.MessagesSent,
+		"messages_dropped":         stats.MessagesDropped,
+		"batches_sent":             stats.BatchesSent,
+		"avg_message_latency_ms":   stats.AvgMessageLatency * 1000,
+		"binary_messages":          stats.BinaryMessages,
+		"json_messages":            stats.JSONMessages,
+		"compressed_messages":      stats.CompressedMessages,
+		"auth_errors":              stats.AuthErrors,
+		"rate_limit_errors":        stats.RateLimitErrors,
+		"protocol_errors":          stats.ProtocolErrors,
+		"tenant_connections":       uint64(0),
+		"tenant_messages_received": uint64(0),
+	}
+	if tenant, ok := stats.TenantStats[tenantID]; ok {
+		metrics["tenant_connections"] = tenant.Connections
+		metrics["tenant_messages_received"] = tenant.Messages
+	}
+	return metrics
+}
+
+// diagnosticsCircuitBreakers reports the state of the breakers guarding
+// outbound calls
+func (s *Server) diagnosticsCircuitBreakers() map[string]interface{} {
+	breakers := make(map[string]interface{})
+	if s.restAPIClient != nil {
+		clientMetrics := s.restAPIClient.GetMetrics()
+		breaker := map[string]interface{}{
+			"state":           clientMetrics.CircuitBreakerState,
+			"healthy":         clientMetrics.Healthy,
+			"total_requests":  clientMetrics.TotalRequests,
+			"failed_requests": clientMetrics.FailedRequests,
+		}
+		if !clientMetrics.LastHealthCheck.IsZero() {
+			breaker["last_health_check"] = clientMetrics.LastHealthCheck.UTC().Format(time.RFC3339)
+		}
+		breakers["rest_api"] = breaker
+	}
+	return breakers
+}
+
+// diagnosticsCaches reports cache sizes and hit rates
+func (s *Server) diagnosticsCaches() map[string]interface{} {
+	caches := make(map[string]interface{})
+	if s.restAPIClient != nil {
+		clientMetrics := s.restAPIClient.GetMetrics()
+		caches["tool_list"] = map[string]interface{}{
+			"hits":   clientMetrics.CacheHits,
+			"misses": clientMetrics.CacheMisses,
+		}
+	}
+	if s.idempotencyCache != nil {
+		caches["idempotency_keys"] = map[string]interface{}{"size": s.idempotencyCache.Size()}
+	}
+	if s.toolOutputLimit != nil && s.toolOutputLimit.store != nil {
+		caches["tool_outputs"] = map[string]interface{}{"size": s.toolOutputLimit.store.Size()}
+	}
+	if s.requestDedup != nil {
+		caches["request_dedup"] = map[string]interface{}{"size": s.requestDedup.Size()}
+	}
+	return caches
+}
@@ -0,0 +1,113 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// stubClientMetrics reports fixed REST API client metrics
+type stubClientMetrics struct {
+	clients.RESTAPIClient
+	metrics clients.ClientMetrics
+}
+
+func (c *stubClientMetrics) GetMetrics() clients.ClientMetrics {
+	return c.metrics
+}
+
+func TestHandleSystemDiagnostics(t *testing.T) {
+	const (
+		jwtSecret  = "diagnostics-jwt-secret"
+		apiKey     = "diagnostics-api-key"
+		signingKey = "diagnostics-signing-key"
+	)
+
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		Version: "1.2.3",
+		Security: SecurityConfig{
+			RequireAuth:    true,
+			JWTSecret:      jwtSecret,
+			APIKeys:        []string{apiKey},
+			AllowedOrigins: []string{"https://app.example.com"},
+		},
+		WorkflowPortability: WorkflowPortabilityConfig{SigningKey: signingKey},
+		MethodSchemas:       map[string]interface{}{"tool.list": map[string]interface{}{"type": "object"}},
+	})
+	server.SetRESTClient(&stubClientMetrics{metrics: clients.ClientMetrics{
+		CircuitBreakerState: "open",
+		CacheHits:           7,
+		CacheMisses:         3,
+	}})
+
+	tenantID := uuid.New().String()
+	admin := addScopedConnection(server, "admin", tenantID, "admin-user", "admin")
+	addScopedConnection(server, "other", uuid.New().String(), "other-user", "read")
+
+	response := callMethodAs(t, server, admin, "system.diagnostics", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	bundle := response.Result.(map[string]interface{})
+
+	t.Run("bundle includes the expected sections", func(t *testing.T) {
+		for _, section := range []string{"generated_at", "server", "config", "connections", "metrics", "circuit_breakers", "caches"} {
+			assert.Contains(t, bundle, section)
+		}
+
+		assert.Equal(t, "1.2.3", bundle["server"].(map[string]interface{})["version"])
+
+		connections := bundle["connections"].(map[string]interface{})
+		assert.Equal(t, 2.0, connections["total"])
+		assert.Equal(t, 1.0, connections["tenant_total"])
+
+		breakers := bundle["circuit_breakers"].(map[string]interface{})
+		assert.Equal(t, "open", breakers["rest_api"].(map[string]interface{})["state"])
+
+		caches := bundle["caches"].(map[string]interface{})
+		assert.Equal(t, 7.0, caches["tool_list"].(map[string]interface{})["hits"])
+		assert.Contains(t, caches, "idempotency_keys")
+
+		config := bundle["config"].(map[string]interface{})
+		security := config["Security"].(map[string]interface{})
+		assert.Equal(t, true, security["RequireAuth"])
+		assert.Equal(t, []interface{}{"https://app.example.com"}, security["AllowedOrigins"])
+		assert.NotContains(t, config, "MethodSchemas")
+		assert.Equal(t, 1.0, config["MethodSchemaOverrideCount"])
+	})
+
+	t.Run("sensitive config values are redacted", func(t *testing.T) {
+		config := bundle["config"].(map[string]interface{})
+		security := config["Security"].(map[string]interface{})
+		assert.Equal(t, "[REDACTED]", security["JWTSecret"])
+		assert.Equal(t, "[REDACTED]", security["APIKeys"])
+		assert.Equal(t, "[REDACTED]", config["WorkflowPortability"].(map[string]interface{})["SigningKey"])
+
+		data, err := json.Marshal(bundle)
+		require.NoError(t, err)
+		for _, secret := range []string{jwtSecret, apiKey, signingKey} {
+			assert.NotContains(t, string(data), secret)
+		}
+	})
+
+	t.Run("requires admin", func(t *testing.T) {
+		reader := addScopedConnection(server, "reader", tenantID, "reader-user", "read", "write")
+		response := callMethodAs(t, server, reader, "system.diagnostics", map[string]interface{}{})
+		require.NotNil(t, response.Error)
+		assert.Equal(t, ws.ErrCodeAuthFailed, response.Error.Code)
+	})
+
+	t.Run("works without optional components", func(t *testing.T) {
+		bare := &Server{config: Config{}, connections: make(map[string]*Connection)}
+		result, err := bare.handleSystemDiagnostics(context.Background(), admin, nil)
+		require.NoError(t, err)
+		assert.Empty(t, result.(map[string]interface{})["circuit_breakers"])
+	})
+}
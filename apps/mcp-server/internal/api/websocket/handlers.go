@@ -2,7 +2,9 @@ package websocket
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -82,6 +84,9 @@ func (s *Server) RegisterHandlers() {
 		"protocol.set_binary": s.handleSetBinaryProtocol,
 		"protocol.get_info":   s.handleProtocolGetInfo,
 
+		// Introspection
+		"system.describe_methods": s.handleSystemDescribeMethods,
+
 		// Testing and diagnostics
 		"echo":      s.handleEcho,
 		"ping":      s.handlePing,
@@ -92,17 +97,33 @@ func (s *Server) RegisterHandlers() {
 		"tool.execute": s.handleToolExecute,
 		"tool.cancel":  s.handleToolCancel,
 
+		// Administration
+		"admin.tool_audit.query":   s.handleToolAuditQuery,
+		"admin.connections.report": s.handleConnectionsReport,
+		"system.diagnostics":       s.handleSystemDiagnostics,
+
+		// Temporary scope elevation
+		"scope.elevation.request": s.handleScopeElevationRequest,
+		"scope.elevation.grant":   s.handleScopeElevationGrant,
+		"scope.elevation.deny":    s.handleScopeElevationDeny,
+		"scope.elevation.list":    s.handleScopeElevationList,
+
 		// Embedding operations
 		"embedding.generate": s.handleEmbeddingGenerate,
 
 		// Context management
-		"context.create":     s.handleContextCreate,
-		"context.get":        s.handleContextGet,
-		"context.update":     s.handleContextUpdate,
-		"context.append":     s.handleContextAppend,
-		"context.get_limits": s.handleContextGetLimits,
-		"context.get_stats":  s.handleContextGetStats,
-		"context.truncate":   s.handleContextTruncate,
+		"context.create":             s.handleContextCreate,
+		"context.get":                s.handleContextGet,
+		"context.get_messages":       s.handleContextGetMessages,
+		"context.update":             s.handleContextUpdate,
+		"context.append":             s.handleContextAppend,
+		"context.get_limits":         s.handleContextGetLimits,
+		"context.get_stats":          s.handleContextGetStats,
+		"context.truncate":           s.handleContextTruncate,
+		"context.restore_checkpoint": s.handleContextRestoreCheckpoint,
+		"context.begin_tx":           s.handleContextBeginTx,
+		"context.commit":             s.handleContextCommit,
+		"context.rollback":           s.handleContextRollback,
 
 		// Context window management
 		"window.setTokens":     s.handleWindowSetTokens,
@@ -127,6 +148,7 @@ func (s *Server) RegisterHandlers() {
 		"subscription.list":    s.handleSubscriptionList,
 		"subscription.status":  s.handleSubscriptionStatus,
 		"subscription.restore": s.handleSubscriptionRestore,
+		"subscription.replay":  s.handleSubscriptionReplay,
 		"event.subscribe":      s.handleEventSubscribe,
 		"event.unsubscribe":    s.handleEventUnsubscribe,
 
@@ -141,6 +163,8 @@ func (s *Server) RegisterHandlers() {
 		"workflow.get":                   s.handleWorkflowGet,
 		"workflow.resume":                s.handleWorkflowResume,
 		"workflow.complete_task":         s.handleWorkflowCompleteTask,
+		"workflow.export":                s.handleWorkflowExport,
+		"workflow.import":                s.handleWorkflowImport,
 
 		// Agent management - using new idempotent registration
 		"agent.register":      s.handleAgentRegisterIdempotent,
@@ -157,6 +181,7 @@ func (s *Server) RegisterHandlers() {
 		"task.create_distributed": s.handleTaskCreateDistributed,
 		"task.status":             s.handleTaskStatus,
 		"task.cancel":             s.handleTaskCancel,
+		"task.watch":              s.handleTaskWatch,
 		"task.list":               s.handleTaskList,
 		"task.delegate":           s.handleTaskDelegate,
 		"task.accept":             s.handleTaskAccept,
@@ -182,7 +207,8 @@ func (s *Server) RegisterHandlers() {
 		"stream.binary": s.handleStreamBinary,
 
 		// Metrics
-		"metrics.record": s.handleMetricsRecord,
+		"metrics.record":       s.handleMetricsRecord,
+		"metrics.record_batch": s.handleMetricsRecordBatch,
 
 		// Conflict Resolution
 		"document.sync":       s.handleDocumentSync,
@@ -262,25 +288,25 @@ func (s *Server) processMessage(ctx context.Context, conn *Connection, msg *ws.M
 		params = paramBytes
 	}
 
-	// Check authorization
-	if conn.state != nil && conn.state.Claims != nil {
+	// Check authorization, including any scopes the connection was temporarily elevated to
+	if claims := s.effectiveClaims(conn); claims != nil {
 		// Add claims to context using auth package functions
-		ctx = auth.WithTenantID(ctx, uuid.MustParse(conn.state.Claims.TenantID))
-		ctx = auth.WithUserID(ctx, conn.state.Claims.UserID)
-		ctx = context.WithValue(ctx, contextKeyClaims, conn.state.Claims)
+		ctx = auth.WithTenantID(ctx, uuid.MustParse(claims.TenantID))
+		ctx = auth.WithUserID(ctx, claims.UserID)
+		ctx = context.WithValue(ctx, contextKeyClaims, claims)
 
 		// Debug logging
 		s.logger.Info("Context enriched with auth", map[string]interface{}{
-			"user_id":   conn.state.Claims.UserID,
-			"tenant_id": conn.state.Claims.TenantID,
+			"user_id":   claims.UserID,
+			"tenant_id": claims.TenantID,
 			"method":    msg.Method,
 		})
 
 		// Check method-specific permissions
-		if err := s.checkMethodPermission(conn.state.Claims, msg.Method); err != nil {
+		if err := s.checkMethodPermission(ctx, claims, msg.Method); err != nil {
 			s.logger.Warn("Authorization failed", map[string]interface{}{
 				"method":  msg.Method,
-				"user_id": conn.state.Claims.UserID,
+				"user_id": claims.UserID,
 				"error":   err.Error(),
 			})
 			resp, _ := s.createErrorResponse(msg.ID, ws.ErrCodeAuthFailed, "Unauthorized")
@@ -292,6 +318,17 @@ func (s *Server) processMessage(ctx context.Context, conn *Connection, msg *ws.M
 		return resp, nil, nil
 	}
 
+	// Params must match the method's input schema, if it has one
+	if err := s.methodSchemas.Validate(msg.Method, params); err != nil {
+		var wsErr *ws.Error
+		if errors.As(err, &wsErr) {
+			resp, _ := s.createErrorResponseWithData(msg.ID, wsErr.Code, wsErr.Message, wsErr.Data)
+			return resp, nil, nil
+		}
+		resp, _ := s.createErrorResponse(msg.ID, ws.ErrCodeInvalidParams, err.Error())
+		return resp, nil, nil
+	}
+
 	// Add request metadata to context
 	ctx = context.WithValue(ctx, contextKeyRequestID, msg.ID)
 	ctx = context.WithValue(ctx, contextKeyMethod, msg.Method)
@@ -326,6 +363,11 @@ func (s *Server) processMessage(ctx context.Context, conn *Connection, msg *ws.M
 			result, postAction, err = handlerWithPost(ctx, conn, params)
 		}
 	} else if handler, ok := handlerInterface.(MessageHandler); ok {
+		// Identical concurrent read-only requests share one handler call
+		if key := requestDedupKey(conn, msg.Method, params); key != "" && s.requestDedup != nil {
+			handler = s.deduplicatedHandler(key, msg.Method, handler)
+		}
+
 		// Regular handler without post-action support
 		if s.tracingHandler != nil {
 			// Use tracing handler to wrap individual method execution
@@ -350,6 +392,12 @@ func (s *Server) processMessage(ctx context.Context, conn *Connection, msg *ws.M
 			"error":         err.Error(),
 			"connection_id": conn.ID,
 		})
+		// Preserve structured protocol errors (e.g. rate limiting) returned by handlers
+		var wsErr *ws.Error
+		if errors.As(err, &wsErr) {
+			resp, _ := s.createErrorResponseWithData(msg.ID, wsErr.Code, wsErr.Message, wsErr.Data)
+			return resp, nil, nil
+		}
 		resp, _ := s.createErrorResponse(msg.ID, ws.ErrCodeServerError, err.Error())
 		return resp, nil, nil
 	}
@@ -370,68 +418,89 @@ func (s *Server) processMessage(ctx context.Context, conn *Connection, msg *ws.M
 	return responseBytes, postAction, nil
 }
 
+// readOnlyMethods don't modify state and can be called with read scope
+var readOnlyMethods = auth.NewScopeSet(
+	"echo",
+	"system.describe_methods",
+	"ping",
+	"protocol.get_info",
+	"context.get",
+	"context.get_messages",
+	"context.get_limits",
+	"context.get_stats",
+	"tool.list",
+	"session.get",
+	"session.get_history",
+	"session.list",
+	"subscription.list",
+	"subscription.status",
+	"subscription.replay",
+	"workflow.status",
+	"workflow.list",
+	"workflow.get",
+	"workflow.export",
+	"agent.status",
+	"task.status",
+	"task.list",
+	"task.watch",
+	"workspace.list_members",
+	"workspace.get_state",
+	"window.getTokenUsage",
+	"session.get_metrics",
+	"vector_clock.get",
+)
+
+// adminOnlyMethods can only be called with admin scope
+var adminOnlyMethods = auth.NewScopeSet(
+	"agent.register",
+	"metrics.record",
+	"metrics.record_batch",
+	"admin.tool_audit.query",
+	"admin.connections.report",
+	"scope.elevation.grant",
+	"scope.elevation.deny",
+	"scope.elevation.list",
+	"system.diagnostics",
+)
+
 // checkMethodPermission checks if the user has permission to call a method
-func (s *Server) checkMethodPermission(claims *auth.Claims, method string) error {
-	// Define method permission mappings
-	readOnlyMethods := map[string]bool{
-		"echo":                   true,
-		"ping":                   true,
-		"protocol.get_info":      true,
-		"context.get":            true,
-		"context.get_limits":     true,
-		"context.get_stats":      true,
-		"tool.list":              true,
-		"session.get":            true,
-		"session.get_history":    true,
-		"session.list":           true,
-		"subscription.list":      true,
-		"subscription.status":    true,
-		"workflow.status":        true,
-		"workflow.list":          true,
-		"workflow.get":           true,
-		"agent.status":           true,
-		"task.status":            true,
-		"task.list":              true,
-		"workspace.list_members": true,
-		"workspace.get_state":    true,
-		"window.getTokenUsage":   true,
-		"session.get_metrics":    true,
-		"vector_clock.get":       true,
-	}
-
-	adminOnlyMethods := map[string]bool{
-		"agent.register": true,
-		"metrics.record": true,
+func (s *Server) checkMethodPermission(ctx context.Context, claims *auth.Claims, method string) error {
+	// Agents request elevation because they lack scopes, so any scope may ask
+	if method == "scope.elevation.request" {
+		return nil
 	}
 
 	// Check admin-only methods
-	if adminOnlyMethods[method] {
-		for _, scope := range claims.Scopes {
-			if scope == "admin" {
-				return nil
-			}
+	if adminOnlyMethods.Matches(method) {
+		if !s.claimsGrant(ctx, claims, "admin") {
+			return fmt.Errorf("admin permission required for method: %s", method)
 		}
-		return fmt.Errorf("admin permission required for method: %s", method)
+		return nil
 	}
 
 	// Check if user has write permission for write methods
-	if !readOnlyMethods[method] {
-		// Check for write scope
-		hasWriteScope := false
-		for _, scope := range claims.Scopes {
-			if scope == "write" || scope == "admin" {
-				hasWriteScope = true
-				break
-			}
-		}
-		if !hasWriteScope {
-			return fmt.Errorf("write permission required for method: %s", method)
-		}
+	if !readOnlyMethods.Matches(method) && !s.claimsGrant(ctx, claims, "write") {
+		return fmt.Errorf("write permission required for method: %s", method)
 	}
 
 	return nil
 }
 
+// claimsGrant reports whether the claims grant a scope. The auth service
+// decides, so the scope hierarchy it's configured with, including a tenant's
+// own implications, applies as it does over HTTP.
+func (s *Server) claimsGrant(ctx context.Context, claims *auth.Claims, scope string) bool {
+	if s.auth == nil {
+		// Connections authenticated by local JWT validation get the default implications
+		return auth.NewScopeSet(auth.DefaultScopeHierarchy().Expand(claims.Scopes...)...).Matches(scope)
+	}
+
+	userID, _ := uuid.Parse(claims.UserID)
+	tenantID, _ := uuid.Parse(claims.TenantID)
+	user := &auth.User{ID: userID, TenantID: tenantID, Scopes: claims.Scopes}
+	return s.auth.AuthorizeScopesContext(ctx, user, []string{scope}) == nil
+}
+
 // createErrorResponse creates an error response message
 func (s *Server) createErrorResponse(id string, code int, message string) ([]byte, error) {
 	response := GetMessage()
@@ -447,6 +516,22 @@ func (s *Server) createErrorResponse(id string, code int, message string) ([]byt
 	return json.Marshal(response)
 }
 
+// createErrorResponseWithData creates an error response message carrying additional error data
+func (s *Server) createErrorResponseWithData(id string, code int, message string, data interface{}) ([]byte, error) {
+	response := GetMessage()
+	defer PutMessage(response)
+
+	response.ID = id
+	response.Type = ws.MessageTypeError
+	response.Error = &ws.Error{
+		Code:    code,
+		Message: message,
+		Data:    data,
+	}
+
+	return json.Marshal(response)
+}
+
 // Protocol handlers
 
 // handleProtocolGetInfo returns protocol information
@@ -637,6 +722,7 @@ func (s *Server) handleInitialize(ctx context.Context, conn *Connection, params
 		AgentID      string                 `json:"agentId"`
 		Capabilities []string               `json:"capabilities"`
 		Metadata     map[string]interface{} `json:"metadata"`
+		ToolMetadata map[string]string      `json:"toolMetadata"` // Forwarded with every tool execution
 	}
 
 	if err := json.Unmarshal(params, &initParams); err != nil {
@@ -655,6 +741,15 @@ func (s *Server) handleInitialize(ctx context.Context, conn *Connection, params
 		conn.mu.Unlock()
 	}
 
+	if len(initParams.ToolMetadata) > 0 {
+		s.setConnectionToolMetadata(conn, initParams.ToolMetadata)
+	}
+
+	// Warm-up is a protocol option rather than an agent capability
+	var warmup bool
+	warmup, initParams.Capabilities = warmupRequested(initParams.Capabilities)
+	warmupStarted := warmup && s.startConnectionWarmup(conn)
+
 	// Store agent capabilities if provided
 	if len(initParams.Capabilities) > 0 && s.agentRegistry != nil {
 		s.logger.Debug("Registering agent with capabilities", map[string]interface{}{
@@ -695,21 +790,10 @@ func (s *Server) handleInitialize(ctx context.Context, conn *Connection, params
 
 	// Return server capabilities
 	return map[string]interface{}{
-		"version":    "1.0.0",
-		"session_id": conn.ID, // Return connection ID as session ID for reconnection
-		"capabilities": map[string]interface{}{
-			"tools":            true,
-			"context":          true,
-			"events":           true,
-			"binary":           true,
-			"sessions":         true,
-			"workflows":        true,
-			"agents":           true,
-			"tasks":            true,
-			"workspaces":       true,
-			"subscriptions":    true,
-			"token_management": true,
-		},
+		"version":        "1.0.0",
+		"session_id":     conn.ID, // Return connection ID as session ID for reconnection
+		"capabilities":   s.serverCapabilities(),
+		"warmup_started": warmupStarted,
 		"limits": map[string]interface{}{
 			"max_context_tokens":   200000,
 			"max_message_size":     10 * 1024 * 1024, // 10MB
@@ -740,8 +824,13 @@ func (s *Server) handleToolList(ctx context.Context, conn *Connection, params js
 		s.logger.Debug("Proxying tool.list to REST API", logFields)
 
 		startTime := time.Now()
-		tools, err := s.restAPIClient.ListTools(ctx, conn.TenantID)
+		tools, shared, warm := s.warmToolList(ctx, conn)
+		var err error
+		if !warm {
+			tools, shared, err = s.listToolsWithSharedCatalog(ctx, conn.TenantID)
+		}
 		duration := time.Since(startTime)
+		logFields["from_warmup"] = warm
 
 		logFields["duration_ms"] = duration.Milliseconds()
 
@@ -777,12 +866,22 @@ func (s *Server) handleToolList(ctx context.Context, conn *Connection, params js
 				}
 			}
 
+			// Shared catalog tools can be used but not modified by the tenant
+			if shared[tool.ID] {
+				toolEntry["shared"] = true
+				toolEntry["read_only"] = true
+			}
+
 			toolList = append(toolList, toolEntry)
 		}
 
-		return map[string]interface{}{
+		response := map[string]interface{}{
 			"tools": toolList,
-		}, nil
+		}
+		if warm {
+			response["from_cache"] = true
+		}
+		return s.withToolQuota(conn, response), nil
 	}
 
 	// Fallback: Use tool registry if available (deprecated path)
@@ -806,9 +905,9 @@ func (s *Server) handleToolList(ctx context.Context, conn *Connection, params js
 			})
 		}
 
-		return map[string]interface{}{
+		return s.withToolQuota(conn, map[string]interface{}{
 			"tools": toolList,
-		}, nil
+		}), nil
 	}
 
 	// No tools available
@@ -816,13 +915,13 @@ func (s *Server) handleToolList(ctx context.Context, conn *Connection, params js
 	logFields["has_tool_registry"] = s.toolRegistry != nil
 	s.logger.Warn("No tool sources available", logFields)
 
-	return map[string]interface{}{
+	return s.withToolQuota(conn, map[string]interface{}{
 		"tools": []map[string]interface{}{},
-	}, nil
+	}), nil
 }
 
 // handleToolExecute handles the tool.execute method
-func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params json.RawMessage) (response interface{}, err error) {
 	// Extract correlation ID from context
 	correlationID := ctx.Value(contextKeyRequestID)
 	if correlationID == nil {
@@ -833,6 +932,15 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 		ToolID     string                 `json:"tool_id"`
 		Action     string                 `json:"action"`
 		Parameters map[string]interface{} `json:"parameters"`
+		// SessionID binds the execution to a session; BindSession binds the active one
+		SessionID   string `json:"session_id"`
+		BindSession bool   `json:"bind_session"`
+		// Metadata is forwarded to the tool as headers when allowlisted
+		Metadata map[string]string `json:"metadata"`
+		// MaxRetries lowers the configured retries for retryable failures
+		MaxRetries *int `json:"max_retries"`
+		// ContextID appends the result to this context as a tool message
+		ContextID string `json:"context_id"`
 	}
 
 	if err := json.Unmarshal(params, &execParams); err != nil {
@@ -859,6 +967,57 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 		args = make(map[string]interface{})
 	}
 
+	// Record every execution attempt in the audit trail
+	auditStart := time.Now()
+	defer func() {
+		s.recordToolAudit(ctx, conn, toolID, action, args, auditStart, response, err)
+	}()
+
+	// Session-bound executions receive the session context alongside their
+	// arguments, which are otherwise left as audited
+	execArgs := args
+	var sessionID string
+	if execParams.SessionID != "" || execParams.BindSession {
+		sessionContext, err := s.toolSessionContext(ctx, conn, execParams.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		sessionID = sessionContext["session_id"].(string)
+
+		execArgs = make(map[string]interface{}, len(args)+1)
+		for key, value := range args {
+			execArgs[key] = value
+		}
+		execArgs[ToolSessionContextArg] = sessionContext
+	}
+
+	// Results merged into a context are appended once the tool completes
+	if execParams.ContextID != "" {
+		if err := s.checkToolResultContext(ctx, execParams.ContextID); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if result, ok := response.(map[string]interface{}); ok && err == nil {
+				s.appendToolResult(ctx, execParams.ContextID, toolID, action, result)
+			}
+		}()
+	}
+
+	// Enforce the agent's tool execution quota
+	var quota *ToolQuota
+	if s.toolQuota.Enabled() {
+		q, allowed := s.toolQuota.Consume(conn.TenantID, conn.AgentID)
+		if !allowed {
+			data := q.toMap()
+			data["retry_after_ms"] = data["reset_in_ms"]
+			return nil, ws.NewError(ws.ErrCodeRateLimited, "Tool execution quota exceeded", data)
+		}
+		quota = &q
+	}
+
+	// Only allowlisted connection and request metadata reaches the tool
+	ctx = s.withToolMetadata(ctx, conn, execParams.Metadata)
+
 	logFields := map[string]interface{}{
 		"correlation_id": correlationID,
 		"tenant_id":      conn.TenantID,
@@ -876,9 +1035,10 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 		// Resolve tool name to UUID if needed
 		// Check if toolID is a name (not a UUID format)
 		var actualToolID string
+		executionTenantID := conn.TenantID
 		if !isUUID(toolID) {
 			// Need to look up the tool UUID by name
-			tools, err := s.restAPIClient.ListTools(ctx, conn.TenantID)
+			tools, shared, err := s.listToolsWithSharedCatalog(ctx, conn.TenantID)
 			if err != nil {
 				s.logger.Error("Failed to list tools for name resolution", map[string]interface{}{
 					"error":     err.Error(),
@@ -900,6 +1060,7 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 			if !found {
 				return nil, fmt.Errorf("tool not found: %s", toolID)
 			}
+			executionTenantID = s.toolExecutionTenant(conn.TenantID, actualToolID, shared)
 
 			s.logger.Debug("Resolved tool name to UUID", map[string]interface{}{
 				"tool_name": toolID,
@@ -907,30 +1068,57 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 			})
 		} else {
 			actualToolID = toolID
+			if s.config.SharedToolCatalog.TenantID != "" {
+				// The ID may belong to a shared tool
+				if _, shared, err := s.listToolsWithSharedCatalog(ctx, conn.TenantID); err == nil {
+					executionTenantID = s.toolExecutionTenant(conn.TenantID, actualToolID, shared)
+				}
+			}
 		}
 
 		startTime := time.Now()
-		result, err := s.restAPIClient.ExecuteTool(ctx, conn.TenantID, actualToolID, action, args)
+		var (
+			result *models.ToolExecutionResponse
+			err    error
+		)
+		retries := s.retryToolExecution(ctx, conn, toolID, s.toolRetry.MaxRetries(execParams.MaxRetries), func() string {
+			result, err = s.restAPIClient.ExecuteTool(ctx, executionTenantID, actualToolID, action, execArgs)
+			switch {
+			case err != nil:
+				return classifyToolError(err)
+			case result != nil && !result.Success:
+				return classifyToolResult(result)
+			default:
+				return ""
+			}
+		})
 		duration := time.Since(startTime)
 
 		logFields["duration_ms"] = duration.Milliseconds()
+		if retries > 0 {
+			logFields["retries"] = retries
+		}
 
 		if err != nil {
 			logFields["error"] = err.Error()
 			s.logger.Error("REST API tool.execute failed", logFields)
 
-			// Check if circuit breaker is open
-			if strings.Contains(err.Error(), "circuit breaker") {
-				return nil, fmt.Errorf("service temporarily unavailable: %w", err)
+			category := classifyToolError(err)
+
+			var toolErr *ws.Error
+			switch {
+			case strings.Contains(err.Error(), "circuit breaker"):
+				// Check if circuit breaker is open
+				toolErr = toolExecutionError(toolID, fmt.Sprintf("service temporarily unavailable: %s", err), category)
+			case strings.Contains(err.Error(), "HTTP 404"):
+				// Check for specific HTTP errors
+				toolErr = toolExecutionError(toolID, fmt.Sprintf("tool not found: %s", toolID), category)
+			case strings.Contains(err.Error(), "HTTP 403") && category == ToolErrorCategoryAuth:
+				toolErr = toolExecutionError(toolID, fmt.Sprintf("permission denied for tool: %s", toolID), category)
+			default:
+				toolErr = toolExecutionError(toolID, fmt.Sprintf("failed to execute tool: %s", err), category)
 			}
-			// Check for specific HTTP errors
-			if strings.Contains(err.Error(), "HTTP 404") {
-				return nil, fmt.Errorf("tool not found: %s", toolID)
-			}
-			if strings.Contains(err.Error(), "HTTP 403") {
-				return nil, fmt.Errorf("permission denied for tool: %s", toolID)
-			}
-			return nil, fmt.Errorf("failed to execute tool: %w", err)
+			return nil, withToolRetries(toolErr, retries)
 		}
 
 		logFields["success"] = result != nil && result.Success
@@ -947,7 +1135,9 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 
 		if result != nil {
 			if result.Success {
-				response["result"] = result.Body
+				if err := s.limitToolOutput(ctx, conn, toolID, response, result.Body); err != nil {
+					return nil, err
+				}
 
 				// Pass through cache metadata from the ToolExecutionResponse
 				if result.FromCache || result.CacheHit {
@@ -963,8 +1153,18 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 			} else {
 				response["status"] = "failed"
 				response["error"] = result.Error
+				response["error_category"] = classifyToolResult(result)
 			}
 		}
+		if retries > 0 {
+			response["retries"] = retries
+		}
+		if quota != nil {
+			response["quota"] = quota.toMap()
+		}
+		if sessionID != "" {
+			response["session_id"] = sessionID
+		}
 
 		return response, nil
 	}
@@ -974,24 +1174,59 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 		s.logger.Warn("Using deprecated tool registry for execution", logFields)
 
 		startTime := time.Now()
-		result, err := s.toolRegistry.ExecuteTool(ctx, conn.AgentID, toolID, args)
+		var (
+			result interface{}
+			err    error
+		)
+		retries := s.retryToolExecution(ctx, conn, toolID, s.toolRetry.MaxRetries(execParams.MaxRetries), func() string {
+			result, err = s.toolRegistry.ExecuteTool(ctx, conn.AgentID, toolID, execArgs)
+			var wsErr *ws.Error
+			switch {
+			case err == nil:
+				return ""
+			case errors.As(err, &wsErr):
+				// Protocol errors are final
+				return ToolErrorCategoryTool
+			default:
+				return classifyToolError(err)
+			}
+		})
 		duration := time.Since(startTime)
 
 		logFields["duration_ms"] = duration.Milliseconds()
+		if retries > 0 {
+			logFields["retries"] = retries
+		}
 
 		if err != nil {
 			logFields["error"] = err.Error()
 			s.logger.Error("Tool registry execution failed", logFields)
-			return nil, err
+			var wsErr *ws.Error
+			if errors.As(err, &wsErr) {
+				return nil, err
+			}
+			return nil, withToolRetries(toolExecutionError(toolID, err.Error(), classifyToolError(err)), retries)
 		}
 
 		s.logger.Info("Tool registry execution completed", logFields)
 
-		return map[string]interface{}{
+		response := map[string]interface{}{
 			"tool":   toolID,
 			"status": "completed",
-			"result": result,
-		}, nil
+		}
+		if err := s.limitToolOutput(ctx, conn, toolID, response, result); err != nil {
+			return nil, err
+		}
+		if retries > 0 {
+			response["retries"] = retries
+		}
+		if quota != nil {
+			response["quota"] = quota.toMap()
+		}
+		if sessionID != "" {
+			response["session_id"] = sessionID
+		}
+		return response, nil
 	}
 
 	// No tool execution sources available
@@ -1002,19 +1237,100 @@ func (s *Server) handleToolExecute(ctx context.Context, conn *Connection, params
 	return nil, fmt.Errorf("tool execution not available: tool '%s' cannot be executed without REST API or tool registry", toolID)
 }
 
+// withToolQuota adds the connection's current tool execution quota to a response
+func (s *Server) withToolQuota(conn *Connection, response map[string]interface{}) map[string]interface{} {
+	if s.toolQuota.Enabled() {
+		response["quota"] = s.toolQuota.Status(conn.TenantID, conn.AgentID).toMap()
+	}
+	return response
+}
+
+// recordToolAudit writes a tool execution audit record. Arguments are redacted by the store.
+func (s *Server) recordToolAudit(ctx context.Context, conn *Connection, toolID, action string, args map[string]interface{}, start time.Time, response interface{}, execErr error) {
+	if s.toolAuditStore == nil {
+		return
+	}
+
+	record := &auth.ToolExecutionAuditRecord{
+		Timestamp:  start,
+		TenantID:   conn.TenantID,
+		AgentID:    conn.AgentID,
+		ToolID:     toolID,
+		Action:     action,
+		Source:     "websocket",
+		Arguments:  args,
+		Status:     auth.ToolAuditStatusSuccess,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+
+	if execErr != nil {
+		record.Status = auth.ToolAuditStatusFailed
+		record.Error = execErr.Error()
+	} else if resp, ok := response.(map[string]interface{}); ok {
+		if status, _ := resp["status"].(string); status == "failed" {
+			record.Status = auth.ToolAuditStatusFailed
+			record.Error = fmt.Sprintf("%v", resp["error"])
+		}
+		record.ResultSummary = auth.SummarizeToolResult(resp["result"])
+	}
+
+	if err := s.toolAuditStore.Record(ctx, record); err != nil {
+		s.logger.Warn("Failed to record tool execution audit", map[string]interface{}{
+			"tool_id": toolID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// handleToolAuditQuery returns tool execution audit records matching the given filters
+func (s *Server) handleToolAuditQuery(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var filter auth.ToolAuditFilter
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &filter); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	if s.toolAuditStore == nil {
+		return nil, fmt.Errorf("tool audit trail is not available")
+	}
+
+	// Admins may only query their own tenant's audit trail
+	filter.TenantID = conn.TenantID
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+
+	records, err := s.toolAuditStore.Query(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"records": records,
+		"count":   len(records),
+	}, nil
+}
+
 // handleContextCreate handles the context.create method
 func (s *Server) handleContextCreate(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	var createParams struct {
-		Name        string `json:"name"`
-		Content     string `json:"content"`
-		ModelID     string `json:"model_id"` // Optional model ID for context
-		ReturnStats bool   `json:"return_stats"`
+		Name        string                 `json:"name"`
+		Content     string                 `json:"content"`
+		ModelID     string                 `json:"model_id"` // Optional model ID for context
+		ReturnStats bool                   `json:"return_stats"`
+		Metadata    map[string]interface{} `json:"metadata"`
 	}
 
 	if err := json.Unmarshal(params, &createParams); err != nil {
 		return nil, err
 	}
 
+	// Metadata must match the tenant's schema, if it has one
+	if err := s.contextMetadataSchemas.Validate(conn.TenantID, createParams.Metadata); err != nil {
+		return nil, err
+	}
+
 	// Create context through context manager
 	if s.contextManager == nil {
 		// Mock response when context manager not available
@@ -1028,6 +1344,9 @@ func (s *Server) handleContextCreate(ctx context.Context, conn *Connection, para
 			"created_at": time.Now().Format(time.RFC3339),
 			"updated_at": time.Now().Format(time.RFC3339),
 		}
+		if createParams.Metadata != nil {
+			result["metadata"] = createParams.Metadata
+		}
 
 		if createParams.ReturnStats {
 			tokenCount := len(createParams.Content) / 4
@@ -1043,14 +1362,32 @@ func (s *Server) handleContextCreate(ctx context.Context, conn *Connection, para
 		modelID = "claude-sonnet-4"
 	}
 
-	context, err := s.contextManager.CreateContext(
-		ctx,
-		conn.AgentID,
-		conn.TenantID,
-		createParams.Name,
-		createParams.Content,
-		modelID,
-	)
+	var context *models.Context
+	var err error
+	if createParams.Metadata != nil {
+		metadataManager, ok := s.contextManager.(ContextMetadataManager)
+		if !ok {
+			return nil, ws.NewError(ws.ErrCodeInvalidParams, "Context metadata is not supported", nil)
+		}
+		context, err = metadataManager.CreateContextWithMetadata(
+			ctx,
+			conn.AgentID,
+			conn.TenantID,
+			createParams.Name,
+			createParams.Content,
+			modelID,
+			createParams.Metadata,
+		)
+	} else {
+		context, err = s.contextManager.CreateContext(
+			ctx,
+			conn.AgentID,
+			conn.TenantID,
+			createParams.Name,
+			createParams.Content,
+			modelID,
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1063,6 +1400,9 @@ func (s *Server) handleContextCreate(ctx context.Context, conn *Connection, para
 		"created_at": context.CreatedAt.Format(time.RFC3339),
 		"updated_at": context.UpdatedAt.Format(time.RFC3339),
 	}
+	if context.Metadata != nil {
+		result["metadata"] = context.Metadata
+	}
 
 	// Add token stats if requested
 	if createParams.ReturnStats {
@@ -1078,12 +1418,18 @@ func (s *Server) handleContextCreate(ctx context.Context, conn *Connection, para
 func (s *Server) handleContextGet(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	var getParams struct {
 		ContextID string `json:"context_id"`
+		Lazy      bool   `json:"lazy"` // Return message headers without content
 	}
 
 	if err := json.Unmarshal(params, &getParams); err != nil {
 		return nil, err
 	}
 
+	format := formatContextResponse
+	if getParams.Lazy {
+		format = formatLazyContextResponse
+	}
+
 	// Get context through context manager
 	if s.contextManager == nil {
 		// Mock response when context manager not available
@@ -1097,10 +1443,55 @@ func (s *Server) handleContextGet(ctx context.Context, conn *Connection, params
 	}
 
 	context, err := s.contextManager.GetContext(ctx, getParams.ContextID)
+	if err != nil {
+		// Fall back to the latest checkpoint if the context could not be loaded
+		if s.contextCheckpointer != nil {
+			if restored, _, _, restoreErr := s.contextCheckpointer.Restore(ctx, getParams.ContextID); restoreErr == nil {
+				result := format(restored)
+				result["restored_from_checkpoint"] = true
+				return result, nil
+			}
+		}
+		return nil, err
+	}
+
+	return format(context), nil
+}
+
+// handleContextRestoreCheckpoint rebuilds a context from its latest checkpoint
+// plus a replay of the appends recorded after it
+func (s *Server) handleContextRestoreCheckpoint(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var restoreParams struct {
+		ContextID string `json:"context_id"`
+	}
+
+	if err := json.Unmarshal(params, &restoreParams); err != nil {
+		return nil, err
+	}
+
+	if restoreParams.ContextID == "" {
+		return nil, fmt.Errorf("context_id is required")
+	}
+
+	if s.contextCheckpointer == nil {
+		return nil, fmt.Errorf("context checkpointing is not enabled")
+	}
+
+	restored, checkpoint, replayed, err := s.contextCheckpointer.Restore(ctx, restoreParams.ContextID)
 	if err != nil {
 		return nil, err
 	}
 
+	result := formatContextResponse(restored)
+	result["checkpoint_sequence"] = checkpoint.Sequence
+	result["checkpoint_at"] = checkpoint.CreatedAt.Format(time.RFC3339)
+	result["replayed_operations"] = replayed
+
+	return result, nil
+}
+
+// formatContextResponse converts a context into the wire format used by context methods
+func formatContextResponse(context *models.Context) map[string]interface{} {
 	// Convert context items to simple format
 	var content []map[string]interface{}
 	for _, item := range context.Content {
@@ -1121,14 +1512,15 @@ func (s *Server) handleContextGet(ctx context.Context, conn *Connection, params
 		"max_tokens":     context.MaxTokens,
 		"created_at":     context.CreatedAt.Format(time.RFC3339),
 		"updated_at":     context.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	}
 }
 
 // handleContextUpdate handles the context.update method
 func (s *Server) handleContextUpdate(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	var updateParams struct {
-		ContextID string `json:"context_id"`
-		Content   string `json:"content"`
+		ContextID string                 `json:"context_id"`
+		Content   string                 `json:"content"`
+		Metadata  map[string]interface{} `json:"metadata"` // Merged into existing metadata
 	}
 
 	if err := json.Unmarshal(params, &updateParams); err != nil {
@@ -1137,6 +1529,12 @@ func (s *Server) handleContextUpdate(ctx context.Context, conn *Connection, para
 
 	// Update context through context manager
 	if s.contextManager == nil {
+		if updateParams.Metadata != nil {
+			if err := s.contextMetadataSchemas.Validate(conn.TenantID, updateParams.Metadata); err != nil {
+				return nil, err
+			}
+		}
+
 		// Mock response when context manager not available
 		return map[string]interface{}{
 			"id":         updateParams.ContextID,
@@ -1144,16 +1542,54 @@ func (s *Server) handleContextUpdate(ctx context.Context, conn *Connection, para
 		}, nil
 	}
 
-	context, err := s.contextManager.UpdateContext(ctx, updateParams.ContextID, updateParams.Content)
-	if err != nil {
-		return nil, err
+	var metadataManager ContextMetadataManager
+	if updateParams.Metadata != nil {
+		var ok bool
+		if metadataManager, ok = s.contextManager.(ContextMetadataManager); !ok {
+			return nil, ws.NewError(ws.ErrCodeInvalidParams, "Context metadata is not supported", nil)
+		}
+
+		// Validate the metadata the context will have after the merge
+		existing, err := s.contextManager.GetContext(ctx, updateParams.ContextID)
+		if err != nil {
+			return nil, err
+		}
+		merged := make(map[string]interface{}, len(existing.Metadata)+len(updateParams.Metadata))
+		for k, v := range existing.Metadata {
+			merged[k] = v
+		}
+		for k, v := range updateParams.Metadata {
+			merged[k] = v
+		}
+		if err := s.contextMetadataSchemas.Validate(conn.TenantID, merged); err != nil {
+			return nil, err
+		}
 	}
 
-	return map[string]interface{}{
+	var context *models.Context
+	var err error
+	if updateParams.Content != "" || metadataManager == nil {
+		context, err = s.contextManager.UpdateContext(ctx, updateParams.ContextID, updateParams.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if metadataManager != nil {
+		context, err = metadataManager.UpdateContextMetadata(ctx, updateParams.ContextID, updateParams.Metadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result := map[string]interface{}{
 		"id":             context.ID,
 		"current_tokens": context.CurrentTokens,
 		"updated_at":     context.UpdatedAt.Format(time.RFC3339),
-	}, nil
+	}
+	if updateParams.Metadata != nil {
+		result["metadata"] = context.Metadata
+	}
+	return result, nil
 }
 
 // handleEventSubscribe handles the event.subscribe method
@@ -1184,8 +1620,9 @@ func (s *Server) handleSetBinaryProtocolWithPostAction(ctx context.Context, conn
 	var binaryParams struct {
 		Enabled     bool `json:"enabled"`
 		Compression struct {
-			Enabled   bool `json:"enabled"`
-			Threshold int  `json:"threshold"`
+			Enabled           bool   `json:"enabled"`
+			Threshold         int    `json:"threshold"`
+			DictionaryVersion uint32 `json:"dictionary_version"` // Acknowledges the server's dictionary
 		} `json:"compression"`
 	}
 
@@ -1218,6 +1655,13 @@ func (s *Server) handleSetBinaryProtocolWithPostAction(ctx context.Context, conn
 		return nil, nil, fmt.Errorf("invalid binary protocol params: %w", err)
 	}
 
+	// The shared dictionary is only used once the client acknowledges its version
+	var dictionary *CompressionDictionary
+	if binaryParams.Enabled && binaryParams.Compression.Enabled && s.compressionDictionary != nil &&
+		binaryParams.Compression.DictionaryVersion == s.compressionDictionary.Version {
+		dictionary = s.compressionDictionary
+	}
+
 	// Create post-action to update connection settings after response is sent
 	postAction := &PostActionConfig{
 		Action: func() {
@@ -1225,23 +1669,36 @@ func (s *Server) handleSetBinaryProtocolWithPostAction(ctx context.Context, conn
 			if binaryParams.Compression.Enabled {
 				conn.SetCompressionThreshold(binaryParams.Compression.Threshold)
 			}
+			conn.SetCompressionDictionary(dictionary)
 			if s.logger != nil {
 				s.logger.Info("Binary protocol settings updated (synchronous)", map[string]interface{}{
 					"connection_id":       conn.ID,
 					"binary_enabled":      binaryParams.Enabled,
 					"compression_enabled": binaryParams.Compression.Enabled,
 					"threshold":           binaryParams.Compression.Threshold,
+					"dictionary_enabled":  dictionary != nil,
 				})
 			}
 		},
 		Synchronous: true, // Protocol switching must be synchronous
 	}
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"binary_enabled":      binaryParams.Enabled,
 		"compression_enabled": binaryParams.Compression.Enabled,
+		"dictionary_enabled":  dictionary != nil,
 		"status":              "protocol_updated",
-	}, postAction, nil
+	}
+
+	// Ship the dictionary to clients that haven't acknowledged it yet
+	if binaryParams.Enabled && binaryParams.Compression.Enabled && s.compressionDictionary != nil && dictionary == nil {
+		response["dictionary"] = map[string]interface{}{
+			"version": s.compressionDictionary.Version,
+			"data":    base64.StdEncoding.EncodeToString(s.compressionDictionary.Data),
+		}
+	}
+
+	return response, postAction, nil
 }
 
 // handleSetBinaryProtocol is a wrapper for backward compatibility
@@ -1289,30 +1746,66 @@ func (s *Server) handleToolCancel(ctx context.Context, conn *Connection, params
 	}, nil
 }
 
+// AppendIdempotencyTTL is how long processed context.append idempotency keys are remembered
+const AppendIdempotencyTTL = 10 * time.Minute
+
 // handleContextAppend appends content to an existing context
 func (s *Server) handleContextAppend(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	var appendParams struct {
-		ContextID string `json:"context_id"`
-		Content   string `json:"content"`
+		ContextID      string `json:"context_id"`
+		Content        string `json:"content"`
+		IdempotencyKey string `json:"idempotency_key,omitempty"`
+		TxID           string `json:"tx_id,omitempty"` // Buffer the append in an open transaction
 	}
 
 	if err := json.Unmarshal(params, &appendParams); err != nil {
 		return nil, err
 	}
 
-	if s.contextManager != nil {
-		context, err := s.contextManager.AppendToContext(ctx, appendParams.ContextID, appendParams.Content)
+	// Appends within a transaction are applied when it is committed
+	if appendParams.TxID != "" {
+		pending, err := conn.bufferContextAppend(appendParams.TxID, appendParams.ContextID, appendParams.Content)
 		if err != nil {
 			return nil, err
 		}
-
 		return map[string]interface{}{
-			"id":             context.ID,
-			"current_tokens": context.CurrentTokens,
-			"updated_at":     context.UpdatedAt.Format(time.RFC3339),
+			"id":      appendParams.ContextID,
+			"tx_id":   appendParams.TxID,
+			"status":  "pending",
+			"pending": pending,
 		}, nil
 	}
 
+	// Replay the previous result if this append was already processed
+	var idempotencyKey string
+	if appendParams.IdempotencyKey != "" && s.idempotencyCache != nil {
+		idempotencyKey = fmt.Sprintf("context_append:%s:%s:%s", conn.TenantID, appendParams.ContextID, appendParams.IdempotencyKey)
+
+		var cached []byte
+		if err := s.idempotencyCache.Get(ctx, idempotencyKey, &cached); err == nil && len(cached) > 0 {
+			var result map[string]interface{}
+			if err := json.Unmarshal(cached, &result); err == nil {
+				result["duplicate"] = true
+				return result, nil
+			}
+		}
+	}
+
+	if s.contextManager != nil {
+		result, err := s.appendToContext(ctx, appendParams.ContextID, appendParams.Content, func() (*models.Context, error) {
+			return s.contextManager.AppendToContext(ctx, appendParams.ContextID, appendParams.Content)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if idempotencyKey != "" {
+			s.storeAppendResult(ctx, idempotencyKey, result)
+		}
+
+		return result, nil
+	}
+
 	// Mock response
 	return map[string]interface{}{
 		"id":         appendParams.ContextID,
@@ -1320,6 +1813,68 @@ func (s *Server) handleContextAppend(ctx context.Context, conn *Connection, para
 	}, nil
 }
 
+// appendToContext runs an append to a context within its token budget and
+// records the checkpoint, returning the context's new token count
+func (s *Server) appendToContext(ctx context.Context, contextID, content string, appendContent func() (*models.Context, error)) (map[string]interface{}, error) {
+	previousTokens := 0
+	existing, err := s.contextManager.GetContext(ctx, contextID)
+	if err != nil || existing == nil {
+		existing = nil
+	} else {
+		previousTokens = existing.CurrentTokens
+	}
+
+	if err := s.checkContextAppendBudget(existing, content); err != nil {
+		return nil, err
+	}
+
+	context, err := appendContent()
+	if err != nil {
+		return nil, err
+	}
+
+	context, enforcement, err := s.enforceContextBudget(ctx, contextID, existing, context)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"id":             context.ID,
+		"current_tokens": context.CurrentTokens,
+		"token_delta":    context.CurrentTokens - previousTokens,
+		"updated_at":     context.UpdatedAt.Format(time.RFC3339),
+	}
+	if enforcement != nil {
+		result["token_budget"] = enforcement
+	}
+
+	if s.contextCheckpointer != nil {
+		if err := s.contextCheckpointer.RecordAppend(ctx, contextID, content, context); err != nil {
+			s.logger.Warn("Failed to record context checkpoint", map[string]interface{}{
+				"context_id": contextID,
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// storeAppendResult records a processed context.append result under its idempotency key
+func (s *Server) storeAppendResult(ctx context.Context, key string, result map[string]interface{}) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+
+	if err := s.idempotencyCache.Set(ctx, key, data, AppendIdempotencyTTL); err != nil {
+		s.logger.Warn("Failed to store context append idempotency key", map[string]interface{}{
+			"key":   key,
+			"error": err.Error(),
+		})
+	}
+}
+
 // handleContextGetStats returns statistics for a context
 func (s *Server) handleContextGetStats(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
 	var statsParams struct {
@@ -1980,6 +2535,13 @@ func (s *Server) handleWorkflowExecute(ctx context.Context, conn *Connection, pa
 			return nil, fmt.Errorf("invalid workflow ID: %w", parseErr)
 		}
 
+		// Limits may have been lowered since the workflow was created
+		if workflow, getErr := s.workflowService.GetWorkflow(ctx, workflowID); getErr == nil && workflow != nil {
+			if limitErr := s.config.WorkflowLimits.CheckModelSteps(workflow.Steps); limitErr != nil {
+				return nil, limitErr
+			}
+		}
+
 		// Prepare context for workflow execution
 		executionContext := models.JSONMap(execParams.Input)
 		if executionContext == nil {
@@ -2307,12 +2869,28 @@ func (s *Server) handleAgentCollaborate(ctx context.Context, conn *Connection, p
 		AgentIDs []string               `json:"agent_ids"`
 		Task     map[string]interface{} `json:"task"`
 		Strategy string                 `json:"strategy"` // parallel, sequential, consensus
+		// Agent results collected for a consensus and how to aggregate them
+		Results     []CollaborationAgentResult `json:"results"`
+		Aggregation struct {
+			Function string             `json:"function"` // majority_vote (default), weighted_average, first_success
+			Weights  map[string]float64 `json:"weights"`
+		} `json:"aggregation"`
 	}
 
 	if err := json.Unmarshal(params, &collabParams); err != nil {
 		return nil, err
 	}
 
+	// Validate aggregation before starting the collaboration
+	var consensus *ConsensusResult
+	if collabParams.Strategy == "consensus" && len(collabParams.Results) > 0 {
+		var err error
+		consensus, err = aggregateConsensus(collabParams.Aggregation.Function, collabParams.Results, collabParams.Aggregation.Weights)
+		if err != nil {
+			return nil, ws.NewError(ws.ErrCodeInvalidParams, err.Error(), nil)
+		}
+	}
+
 	collaboration, err := s.agentRegistry.InitiateCollaboration(
 		ctx,
 		conn.AgentID,
@@ -2324,13 +2902,26 @@ func (s *Server) handleAgentCollaborate(ctx context.Context, conn *Connection, p
 		return nil, err
 	}
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"collaboration_id":     collaboration.ID,
 		"participating_agents": collaboration.Agents,
 		"strategy":             collaboration.Strategy,
 		"status":               collaboration.Status,
 		"initiated_at":         collaboration.InitiatedAt.Format(time.RFC3339),
-	}, nil
+	}
+
+	if consensus != nil {
+		if collaboration.Results == nil {
+			collaboration.Results = make(map[string]interface{})
+		}
+		for _, result := range collabParams.Results {
+			collaboration.Results[result.AgentID] = result
+		}
+		collaboration.Results["consensus"] = consensus
+		response["consensus"] = consensus
+	}
+
+	return response, nil
 }
 
 func (s *Server) handleAgentStatus(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
@@ -2507,6 +3098,7 @@ func (s *Server) handleTaskCancel(ctx context.Context, conn *Connection, params
 	}
 
 	// Update task status to cancelled
+	previousStatus := string(task.Status)
 	now := time.Now()
 	task.Status = models.TaskStatusCancelled
 	task.Error = cancelParams.Reason
@@ -2517,6 +3109,7 @@ func (s *Server) handleTaskCancel(ctx context.Context, conn *Connection, params
 	if err != nil {
 		return nil, fmt.Errorf("failed to cancel task: %w", err)
 	}
+	s.publishTaskTransition(task, previousStatus)
 
 	return map[string]interface{}{
 		"task_id":      cancelParams.TaskID,
@@ -2711,8 +3304,24 @@ func (s *Server) handleWorkspaceBroadcast(ctx context.Context, conn *Connection,
 		return nil, fmt.Errorf("not a member of workspace")
 	}
 
+	// Enforce per-agent broadcast rate limit for this workspace
+	if s.broadcastLimiter != nil {
+		workspaceType := s.workspaceManager.GetWorkspaceType(broadcastParams.WorkspaceID)
+		allowed, retryAfter, err := s.broadcastLimiter.Allow(ctx, broadcastParams.WorkspaceID, workspaceType, conn.AgentID)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ws.NewError(ws.ErrCodeRateLimited, "Workspace broadcast rate limit exceeded", map[string]interface{}{
+				"workspace_id":   broadcastParams.WorkspaceID,
+				"limit":          s.broadcastLimiter.LimitFor(workspaceType),
+				"retry_after_ms": retryAfter.Milliseconds(),
+			})
+		}
+	}
+
 	// Broadcast to all workspace members
-	recipients, err := s.workspaceManager.BroadcastToWorkspace(
+	result, err := s.workspaceManager.BroadcastToWorkspace(
 		ctx,
 		broadcastParams.WorkspaceID,
 		conn.AgentID,
@@ -2726,7 +3335,9 @@ func (s *Server) handleWorkspaceBroadcast(ctx context.Context, conn *Connection,
 	return map[string]interface{}{
 		"workspace_id": broadcastParams.WorkspaceID,
 		"event":        broadcastParams.Event,
-		"recipients":   recipients,
+		"recipients":   result.Recipients,
+		"successful":   result.Successful,
+		"failed":       result.Failed,
 		"broadcast_at": time.Now().Format(time.RFC3339),
 	}, nil
 }
@@ -2776,34 +3387,87 @@ func (s *Server) handleStreamBinary(ctx context.Context, conn *Connection, param
 	}, nil
 }
 
+// metricPoint is a single metric recorded by metrics.record and metrics.record_batch
+type metricPoint struct {
+	Metric    string            `json:"metric"`
+	Value     float64           `json:"value"`
+	Tags      map[string]string `json:"tags"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+// recordMetricPoint records a metric tagged with the connection's agent and tenant
+func (s *Server) recordMetricPoint(conn *Connection, point metricPoint) {
+	if s.metrics == nil {
+		return
+	}
+
+	tags := make(map[string]string, len(point.Tags)+2)
+	for k, v := range point.Tags {
+		tags[k] = v
+	}
+	tags["agent_id"] = conn.AgentID
+	tags["tenant_id"] = conn.TenantID
+
+	s.metrics.RecordGauge(point.Metric, point.Value, tags)
+}
+
 // handleMetricsRecord handles metrics recording
 func (s *Server) handleMetricsRecord(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
-	var metricsParams struct {
-		Metric    string            `json:"metric"`
-		Value     float64           `json:"value"`
-		Tags      map[string]string `json:"tags"`
-		Timestamp int64             `json:"timestamp"`
-	}
+	var metricsParams metricPoint
 
 	if err := json.Unmarshal(params, &metricsParams); err != nil {
 		return nil, err
 	}
 
 	// Record metric
-	if s.metrics != nil {
-		tags := metricsParams.Tags
-		if tags == nil {
-			tags = make(map[string]string)
+	s.recordMetricPoint(conn, metricsParams)
+
+	return map[string]interface{}{
+		"metric":    metricsParams.Metric,
+		"recorded":  true,
+		"timestamp": time.Now().Unix(),
+	}, nil
+}
+
+// MaxMetricsBatchSize is the most metric points metrics.record_batch accepts in one request
+const MaxMetricsBatchSize = 1000
+
+// handleMetricsRecordBatch records several metric points in one request. The
+// batch is validated as a whole, so either every point is recorded or none are.
+func (s *Server) handleMetricsRecordBatch(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var batchParams struct {
+		Metrics []metricPoint `json:"metrics"`
+	}
+
+	if err := json.Unmarshal(params, &batchParams); err != nil {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Invalid parameters", err.Error())
+	}
+
+	if len(batchParams.Metrics) == 0 {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "metrics is required", nil)
+	}
+	if len(batchParams.Metrics) > MaxMetricsBatchSize {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams,
+			fmt.Sprintf("Batch of %d metrics exceeds the maximum of %d", len(batchParams.Metrics), MaxMetricsBatchSize),
+			map[string]interface{}{
+				"batch_size":     len(batchParams.Metrics),
+				"max_batch_size": MaxMetricsBatchSize,
+			})
+	}
+	for i, point := range batchParams.Metrics {
+		if point.Metric == "" {
+			return nil, ws.NewError(ws.ErrCodeInvalidParams,
+				fmt.Sprintf("metrics[%d]: metric is required", i),
+				map[string]interface{}{"index": i})
 		}
-		tags["agent_id"] = conn.AgentID
-		tags["tenant_id"] = conn.TenantID
+	}
 
-		s.metrics.RecordGauge(metricsParams.Metric, metricsParams.Value, tags)
+	for _, point := range batchParams.Metrics {
+		s.recordMetricPoint(conn, point)
 	}
 
 	return map[string]interface{}{
-		"metric":    metricsParams.Metric,
-		"recorded":  true,
+		"recorded":  len(batchParams.Metrics),
 		"timestamp": time.Now().Unix(),
 	}, nil
 }
@@ -2830,6 +3494,10 @@ type EventBus interface {
 	Unsubscribe(connectionID string) error
 	UnsubscribeEvents(connectionID string, events []string) error
 	Publish(event string, data interface{}) error
+	// SubscribeHandler registers an in-process handler for an event and returns its subscription ID
+	SubscribeHandler(event string, handler func(data interface{})) (string, error)
+	// UnsubscribeHandler removes a handler registered with SubscribeHandler
+	UnsubscribeHandler(subscriptionID string) error
 }
 
 // Helper method to get agent configuration
@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KeepaliveConfig configures connection health tracking
+type KeepaliveConfig struct {
+	MaxMissedPongs int           `mapstructure:"max_missed_pongs"` // Consecutive unanswered pings before closing; defaults to 1
+	StaleAfter     time.Duration `mapstructure:"stale_after"`      // Silence after which a connection is reported stale; defaults to 3 ping intervals
+}
+
+// connectionKeepalive tracks the liveness of a connection
+type connectionKeepalive struct {
+	mu           sync.Mutex
+	lastRTT      time.Duration
+	lastPongAt   time.Time
+	missedPongs  int
+	lastActivity time.Time
+}
+
+// KeepaliveStats is a snapshot of a connection's health
+type KeepaliveStats struct {
+	ConnectionID  string     `json:"connection_id"`
+	AgentID       string     `json:"agent_id"`
+	TenantID      string     `json:"tenant_id"`
+	ConnectedAt   time.Time  `json:"connected_at"`
+	LastActivity  time.Time  `json:"last_activity"`
+	IdleSeconds   float64    `json:"idle_seconds"`
+	LastPingRTTMs float64    `json:"last_ping_rtt_ms"`
+	LastPongAt    *time.Time `json:"last_pong_at,omitempty"`
+	MissedPongs   int        `json:"missed_pongs"`
+	Stale         bool       `json:"stale"`
+	StaleReason   string     `json:"stale_reason,omitempty"`
+}
+
+// recordActivity notes that a message was received on the connection
+func (c *Connection) recordActivity() {
+	c.keepalive.mu.Lock()
+	defer c.keepalive.mu.Unlock()
+	c.keepalive.lastActivity = time.Now()
+}
+
+// keepalivePing pings the connection and records the round trip. It returns
+// false once the configured number of consecutive pongs have been missed,
+// meaning the connection should be closed.
+func (c *Connection) keepalivePing(ctx context.Context, ping func(context.Context) error) bool {
+	maxMissed := 1
+	if c.hub != nil && c.hub.config.Keepalive.MaxMissedPongs > 0 {
+		maxMissed = c.hub.config.Keepalive.MaxMissedPongs
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err := ping(pingCtx)
+	rtt := time.Since(start)
+
+	c.keepalive.mu.Lock()
+	if err == nil {
+		c.keepalive.lastRTT = rtt
+		c.keepalive.lastPongAt = time.Now()
+		c.keepalive.missedPongs = 0
+	} else {
+		c.keepalive.missedPongs++
+	}
+	missed := c.keepalive.missedPongs
+	c.keepalive.mu.Unlock()
+
+	if c.hub != nil && c.hub.metrics != nil {
+		if err == nil {
+			c.hub.metrics.RecordHistogram("websocket_ping_rtt_seconds", rtt.Seconds(), nil)
+		} else {
+			c.hub.metrics.IncrementCounter("websocket_missed_pongs_total", 1)
+		}
+	}
+	if err == nil {
+		return true
+	}
+
+	if c.hub != nil && c.hub.logger != nil && c.Connection != nil {
+		fields := map[string]interface{}{
+			"error":         err.Error(),
+			"connection_id": c.ID,
+			"missed_pongs":  missed,
+		}
+		if missed >= maxMissed {
+			c.hub.logger.Error("Ping error", fields)
+		} else {
+			c.hub.logger.Warn("Missed pong", fields)
+		}
+	}
+	return missed < maxMissed
+}
+
+// KeepaliveStats returns a snapshot of the connection's health. Connections
+// with unanswered pings, or that have been silent longer than staleAfter, are
+// flagged stale.
+func (c *Connection) KeepaliveStats(staleAfter time.Duration) KeepaliveStats {
+	c.keepalive.mu.Lock()
+	keepalive := KeepaliveStats{
+		LastActivity:  c.keepalive.lastActivity,
+		LastPingRTTMs: float64(c.keepalive.lastRTT) / float64(time.Millisecond),
+		MissedPongs:   c.keepalive.missedPongs,
+	}
+	lastPongAt := c.keepalive.lastPongAt
+	c.keepalive.mu.Unlock()
+
+	keepalive.ConnectionID = c.ID
+	keepalive.AgentID = c.AgentID
+	keepalive.TenantID = c.TenantID
+	keepalive.ConnectedAt = c.CreatedAt
+
+	// Connections that haven't sent anything count as active since connecting
+	if keepalive.LastActivity.IsZero() {
+		keepalive.LastActivity = c.CreatedAt
+	}
+	keepalive.IdleSeconds = time.Since(keepalive.LastActivity).Seconds()
+
+	lastHeard := keepalive.LastActivity
+	if !lastPongAt.IsZero() {
+		keepalive.LastPongAt = &lastPongAt
+		if lastPongAt.After(lastHeard) {
+			lastHeard = lastPongAt
+		}
+	}
+
+	switch {
+	case keepalive.MissedPongs > 0:
+		keepalive.Stale = true
+		keepalive.StaleReason = fmt.Sprintf("%d missed pongs", keepalive.MissedPongs)
+	case staleAfter > 0 && time.Since(lastHeard) > staleAfter:
+		keepalive.Stale = true
+		keepalive.StaleReason = fmt.Sprintf("nothing received for %s", time.Since(lastHeard).Round(time.Second))
+	}
+
+	return keepalive
+}
+
+// staleAfter is how long a connection may be silent before it's reported stale
+func (s *Server) staleAfter() time.Duration {
+	if s.config.Keepalive.StaleAfter > 0 {
+		return s.config.Keepalive.StaleAfter
+	}
+	pingInterval := s.config.PingInterval
+	if pingInterval <= 0 {
+		pingInterval = 30 * time.Second
+	}
+	return 3 * pingInterval
+}
+
+// KeepaliveReport returns the health of a tenant's connections, stale
+// connections first and then by how long they have been idle
+func (s *Server) KeepaliveReport(tenantID string) []KeepaliveStats {
+	staleAfter := s.staleAfter()
+
+	s.mu.RLock()
+	report := make([]KeepaliveStats, 0, len(s.connections))
+	for _, conn := range s.connections {
+		if conn.Connection != nil && conn.TenantID == tenantID {
+			report = append(report, conn.KeepaliveStats(staleAfter))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Stale != report[j].Stale {
+			return report[i].Stale
+		}
+		return report[i].IdleSeconds > report[j].IdleSeconds
+	})
+	return report
+}
+
+// handleConnectionsReport handles the admin.connections.report method
+func (s *Server) handleConnectionsReport(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var reportParams struct {
+		StaleOnly bool `json:"stale_only"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &reportParams); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	// Admins may only see their own tenant's connections
+	report := s.KeepaliveReport(conn.TenantID)
+
+	staleCount := 0
+	connections := make([]KeepaliveStats, 0, len(report))
+	for _, stats := range report {
+		if stats.Stale {
+			staleCount++
+		} else if reportParams.StaleOnly {
+			continue
+		}
+		connections = append(connections, stats)
+	}
+
+	return map[string]interface{}{
+		"connections":         connections,
+		"count":               len(connections),
+		"stale_count":         staleCount,
+		"stale_after_seconds": s.staleAfter().Seconds(),
+	}, nil
+}
@@ -0,0 +1,196 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// recordedGauge is a gauge value sent to recordingMetrics
+type recordedGauge struct {
+	name   string
+	value  float64
+	labels map[string]string
+}
+
+// recordingMetrics records the counters, gauges and histograms it's sent
+type recordingMetrics struct {
+	observability.MetricsClient
+	mu         sync.Mutex
+	counters   map[string]float64
+	gauges     []recordedGauge
+	histograms map[string][]float64
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		MetricsClient: observability.NewNoOpMetricsClient(),
+		counters:      make(map[string]float64),
+		histograms:    make(map[string][]float64),
+	}
+}
+
+func (m *recordingMetrics) IncrementCounter(name string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += value
+}
+
+func (m *recordingMetrics) RecordGauge(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gauges = append(m.gauges, recordedGauge{name: name, value: value, labels: labels})
+}
+
+func (m *recordingMetrics) RecordHistogram(name string, value float64, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.histograms[name] = append(m.histograms[name], value)
+}
+
+func newKeepaliveTestServer(metrics observability.MetricsClient, config KeepaliveConfig) *Server {
+	return NewServer(&auth.Service{}, metrics, NewTestLogger(), Config{Keepalive: config})
+}
+
+func addKeepaliveConnection(server *Server, id, tenantID string) *Connection {
+	conn := NewConnection(id, nil, server)
+	conn.TenantID = tenantID
+	conn.AgentID = "agent-" + id
+	server.mu.Lock()
+	server.connections[id] = conn
+	server.mu.Unlock()
+	return conn
+}
+
+func TestKeepalivePing(t *testing.T) {
+	ctx := context.Background()
+	pong := func(context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+	noPong := func(context.Context) error { return errors.New("context deadline exceeded") }
+
+	t.Run("records round trip time", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server := newKeepaliveTestServer(metrics, KeepaliveConfig{})
+		conn := addKeepaliveConnection(server, "conn-1", "tenant-1")
+
+		assert.True(t, conn.keepalivePing(ctx, pong))
+
+		require.Len(t, metrics.histograms["websocket_ping_rtt_seconds"], 1)
+		assert.GreaterOrEqual(t, metrics.histograms["websocket_ping_rtt_seconds"][0], 0.005)
+
+		stats := conn.KeepaliveStats(time.Minute)
+		assert.GreaterOrEqual(t, stats.LastPingRTTMs, 5.0)
+		assert.NotNil(t, stats.LastPongAt)
+		assert.False(t, stats.Stale)
+	})
+
+	t.Run("closes after the first missed pong by default", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server := newKeepaliveTestServer(metrics, KeepaliveConfig{})
+		conn := addKeepaliveConnection(server, "conn-1", "tenant-1")
+
+		assert.False(t, conn.keepalivePing(ctx, noPong))
+		assert.Equal(t, 1.0, metrics.counters["websocket_missed_pongs_total"])
+	})
+
+	t.Run("tolerates missed pongs up to the limit", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server := newKeepaliveTestServer(metrics, KeepaliveConfig{MaxMissedPongs: 3})
+		conn := addKeepaliveConnection(server, "conn-1", "tenant-1")
+		healthy := addKeepaliveConnection(server, "conn-2", "tenant-1")
+		require.True(t, healthy.keepalivePing(ctx, pong))
+
+		assert.True(t, conn.keepalivePing(ctx, noPong))
+		assert.True(t, conn.keepalivePing(ctx, noPong))
+
+		report := server.KeepaliveReport("tenant-1")
+		require.Len(t, report, 2)
+		assert.Equal(t, "conn-1", report[0].ConnectionID)
+		assert.True(t, report[0].Stale)
+		assert.Equal(t, 2, report[0].MissedPongs)
+		assert.Equal(t, "2 missed pongs", report[0].StaleReason)
+		assert.Equal(t, "conn-2", report[1].ConnectionID)
+		assert.False(t, report[1].Stale)
+
+		assert.False(t, conn.keepalivePing(ctx, noPong))
+		assert.Equal(t, 3.0, metrics.counters["websocket_missed_pongs_total"])
+	})
+
+	t.Run("a pong clears missed pongs", func(t *testing.T) {
+		server := newKeepaliveTestServer(newRecordingMetrics(), KeepaliveConfig{MaxMissedPongs: 3})
+		conn := addKeepaliveConnection(server, "conn-1", "tenant-1")
+
+		require.True(t, conn.keepalivePing(ctx, noPong))
+		require.True(t, conn.keepalivePing(ctx, pong))
+
+		stats := conn.KeepaliveStats(time.Minute)
+		assert.Zero(t, stats.MissedPongs)
+		assert.False(t, stats.Stale)
+	})
+}
+
+func TestKeepaliveStatsFlagsSilentConnections(t *testing.T) {
+	server := newKeepaliveTestServer(nil, KeepaliveConfig{StaleAfter: time.Minute})
+	conn := addKeepaliveConnection(server, "conn-1", "tenant-1")
+	conn.CreatedAt = time.Now().Add(-5 * time.Minute)
+
+	stats := conn.KeepaliveStats(server.staleAfter())
+	assert.True(t, stats.Stale)
+	assert.Contains(t, stats.StaleReason, "nothing received for")
+	assert.InDelta(t, 300, stats.IdleSeconds, 5)
+
+	conn.recordActivity()
+	stats = conn.KeepaliveStats(server.staleAfter())
+	assert.False(t, stats.Stale)
+	assert.Less(t, stats.IdleSeconds, 5.0)
+}
+
+func TestHandleConnectionsReport(t *testing.T) {
+	ctx := context.Background()
+	server := newKeepaliveTestServer(nil, KeepaliveConfig{MaxMissedPongs: 5})
+	admin := addKeepaliveConnection(server, "admin", "tenant-1")
+	stale := addKeepaliveConnection(server, "stale", "tenant-1")
+	addKeepaliveConnection(server, "other-tenant", "tenant-2")
+
+	missPong := func(context.Context) error { return errors.New("no pong") }
+	require.True(t, stale.keepalivePing(ctx, missPong))
+	require.True(t, stale.keepalivePing(ctx, missPong))
+
+	report := func(params string) map[string]interface{} {
+		t.Helper()
+		result, err := server.handleConnectionsReport(ctx, admin, json.RawMessage(params))
+		require.NoError(t, err)
+
+		data, err := json.Marshal(result)
+		require.NoError(t, err)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		return decoded
+	}
+
+	all := report(`{}`)
+	assert.Equal(t, 2.0, all["count"])
+	assert.Equal(t, 1.0, all["stale_count"])
+	connections := all["connections"].([]interface{})
+	first := connections[0].(map[string]interface{})
+	assert.Equal(t, "stale", first["connection_id"])
+	assert.Equal(t, true, first["stale"])
+	assert.Equal(t, 2.0, first["missed_pongs"])
+
+	staleOnly := report(`{"stale_only": true}`)
+	assert.Equal(t, 1.0, staleOnly["count"])
+
+	err := server.checkMethodPermission(context.Background(), &auth.Claims{Scopes: []string{"read"}}, "admin.connections.report")
+	assert.Error(t, err)
+}
@@ -0,0 +1,328 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// ErrParamsValidationFailed identifies requests rejected by method input schema validation
+const ErrParamsValidationFailed = "params_validation_failed"
+
+// defaultMethodSchemas are the built-in input schemas, keyed by method. They only
+// require what the handlers can't work without.
+var defaultMethodSchemas = map[string]string{
+	"context.create": `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"content": {"type": "string"},
+			"model_id": {"type": "string"},
+			"return_stats": {"type": "boolean"},
+			"metadata": {"type": "object"}
+		}
+	}`,
+	"context.get": `{
+		"type": "object",
+		"properties": {
+			"context_id": {"type": "string"},
+			"lazy": {"type": "boolean"}
+		}
+	}`,
+	"context.update": `{
+		"type": "object",
+		"properties": {
+			"context_id": {"type": "string"},
+			"content": {"type": "string"},
+			"metadata": {"type": "object"}
+		}
+	}`,
+	"context.append": `{
+		"type": "object",
+		"properties": {
+			"context_id": {"type": "string"},
+			"content": {"type": "string"},
+			"idempotency_key": {"type": "string"},
+			"tx_id": {"type": "string"}
+		}
+	}`,
+	"context.begin_tx": `{
+		"type": "object",
+		"required": ["context_id"],
+		"properties": {
+			"context_id": {"type": "string", "minLength": 1}
+		}
+	}`,
+	"context.commit": `{
+		"type": "object",
+		"required": ["tx_id"],
+		"properties": {
+			"tx_id": {"type": "string", "minLength": 1}
+		}
+	}`,
+	"context.rollback": `{
+		"type": "object",
+		"required": ["tx_id"],
+		"properties": {
+			"tx_id": {"type": "string", "minLength": 1}
+		}
+	}`,
+	"embedding.generate": `{
+		"type": "object",
+		"properties": {
+			"text": {"type": "string"},
+			"model": {"type": "string"},
+			"task_type": {"type": "string"},
+			"agent_id": {"type": "string"}
+		}
+	}`,
+	"tool.execute": `{
+		"type": "object",
+		"required": ["tool_id", "action"],
+		"properties": {
+			"tool_id": {"type": "string", "minLength": 1},
+			"action": {"type": "string", "minLength": 1},
+			"parameters": {"type": "object"},
+			"session_id": {"type": "string"},
+			"bind_session": {"type": "boolean"},
+			"metadata": {"type": "object", "additionalProperties": {"type": "string"}},
+			"max_retries": {"type": "integer", "minimum": 0},
+			"context_id": {"type": "string", "minLength": 1}
+		}
+	}`,
+	"tool.cancel": `{
+		"type": "object",
+		"properties": {
+			"execution_id": {"type": "string"}
+		}
+	}`,
+	"scope.elevation.request": `{
+		"type": "object",
+		"required": ["scopes"],
+		"properties": {
+			"scopes": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+			"reason": {"type": "string"},
+			"duration_seconds": {"type": "integer", "minimum": 0}
+		}
+	}`,
+	"scope.elevation.grant": `{
+		"type": "object",
+		"required": ["request_id"],
+		"properties": {
+			"request_id": {"type": "string", "minLength": 1},
+			"duration_seconds": {"type": "integer", "minimum": 0}
+		}
+	}`,
+	"scope.elevation.deny": `{
+		"type": "object",
+		"required": ["request_id"],
+		"properties": {
+			"request_id": {"type": "string", "minLength": 1}
+		}
+	}`,
+	"task.create": `{
+		"type": "object",
+		"properties": {
+			"type": {"type": "string"},
+			"parameters": {"type": "object"},
+			"priority": {"type": "string"},
+			"max_retries": {"type": "integer", "minimum": 0},
+			"timeout_seconds": {"type": "integer", "minimum": 0}
+		}
+	}`,
+	"task.status": `{
+		"type": "object",
+		"required": ["task_id"],
+		"properties": {
+			"task_id": {"type": "string"}
+		}
+	}`,
+	"workspace.join": `{
+		"type": "object",
+		"properties": {
+			"workspace_id": {"type": "string"},
+			"role": {"type": "string", "enum": ["", "member", "moderator", "admin"]}
+		}
+	}`,
+	"workspace.broadcast": `{
+		"type": "object",
+		"required": ["workspace_id"],
+		"properties": {
+			"workspace_id": {"type": "string"},
+			"event": {"type": "string"},
+			"data": {"type": "object"}
+		}
+	}`,
+}
+
+// methodSchema is a registered input schema
+type methodSchema struct {
+	raw      interface{} // decoded JSON, as exposed by system.describe_methods
+	compiled *gojsonschema.Schema
+}
+
+// MethodSchemaRegistry holds the JSON Schemas method params are validated
+// against before dispatch. Methods without a schema accept any params.
+type MethodSchemaRegistry struct {
+	mu      sync.RWMutex
+	schemas map[string]*methodSchema
+}
+
+// NewMethodSchemaRegistry creates a registry with the built-in method schemas
+func NewMethodSchemaRegistry() *MethodSchemaRegistry {
+	r := &MethodSchemaRegistry{
+		schemas: make(map[string]*methodSchema),
+	}
+	for method, schema := range defaultMethodSchemas {
+		if err := r.Register(method, schema); err != nil {
+			panic(err) // built-in schemas are static
+		}
+	}
+	return r
+}
+
+// Register sets a method's input schema, given as a JSON string or decoded JSON.
+// A nil schema removes it.
+func (r *MethodSchemaRegistry) Register(method string, schema interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if schema == nil {
+		delete(r.schemas, method)
+		return nil
+	}
+
+	raw := schema
+	if source, ok := schema.(string); ok {
+		if err := json.Unmarshal([]byte(source), &raw); err != nil {
+			return fmt.Errorf("invalid input schema for method %s: %w", method, err)
+		}
+	}
+
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(raw))
+	if err != nil {
+		return fmt.Errorf("invalid input schema for method %s: %w", method, err)
+	}
+	r.schemas[method] = &methodSchema{raw: raw, compiled: compiled}
+	return nil
+}
+
+// Schema returns a method's input schema
+func (r *MethodSchemaRegistry) Schema(method string) (interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[method]
+	if !ok {
+		return nil, false
+	}
+	return schema.raw, true
+}
+
+// Validate checks params against the method's input schema. Missing params are
+// validated as an empty object.
+func (r *MethodSchemaRegistry) Validate(method string, params json.RawMessage) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	schema, ok := r.schemas[method]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("{}")
+	}
+
+	result, err := schema.compiled.Validate(gojsonschema.NewBytesLoader(params))
+	if err != nil {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Invalid parameters", map[string]interface{}{
+			"error":   ErrParamsValidationFailed,
+			"method":  method,
+			"details": err.Error(),
+		})
+	}
+	if result.Valid() {
+		return nil
+	}
+
+	violations := schemaViolations(result)
+	return ws.NewError(ws.ErrCodeInvalidParams,
+		fmt.Sprintf("Invalid parameters for %s (%d violations)", method, len(violations)),
+		map[string]interface{}{
+			"error":      ErrParamsValidationFailed,
+			"method":     method,
+			"violations": violations,
+		})
+}
+
+// schemaViolations lists the field, type and description of each schema violation
+func schemaViolations(result *gojsonschema.Result) []map[string]interface{} {
+	violations := make([]map[string]interface{}, 0, len(result.Errors()))
+	for _, violation := range result.Errors() {
+		violations = append(violations, map[string]interface{}{
+			"field":       violationField(violation),
+			"type":        violation.Type(),
+			"description": violation.Description(),
+		})
+	}
+	return violations
+}
+
+// violationField returns the path of the field a violation applies to. Missing
+// required properties are reported against their parent, so the property name is
+// appended.
+func violationField(violation gojsonschema.ResultError) string {
+	field := violation.Field()
+	if violation.Type() != "required" {
+		return field
+	}
+	property, ok := violation.Details()["property"].(string)
+	if !ok {
+		return field
+	}
+	if field == gojsonschema.STRING_CONTEXT_ROOT {
+		return property
+	}
+	return field + "." + property
+}
+
+// handleSystemDescribeMethods lists the methods the server handles, with their
+// input schemas where registered
+func (s *Server) handleSystemDescribeMethods(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	names := make([]string, 0, len(s.handlers))
+	for method := range s.handlers {
+		names = append(names, method)
+	}
+	sort.Strings(names)
+
+	methods := make([]map[string]interface{}, 0, len(names))
+	for _, method := range names {
+		description := map[string]interface{}{
+			"name":      method,
+			"read_only": readOnlyMethods.Matches(method),
+		}
+		if schema, ok := s.methodSchemas.Schema(method); ok {
+			description["input_schema"] = schema
+		}
+		methods = append(methods, description)
+	}
+
+	return map[string]interface{}{
+		"methods": methods,
+		"count":   len(methods),
+	}, nil
+}
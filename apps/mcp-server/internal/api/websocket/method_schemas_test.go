@@ -0,0 +1,198 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+const reportSchema = `{
+	"type": "object",
+	"required": ["name", "format"],
+	"properties": {
+		"name": {"type": "string"},
+		"format": {"type": "string", "enum": ["csv", "json"]}
+	}
+}`
+
+func newMethodSchemaTestServer(t *testing.T) (*Server, *int) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		MethodSchemas: map[string]interface{}{"report.generate": reportSchema},
+	})
+
+	// Stub handlers so only validation decides the outcome
+	calls := 0
+	stub := MessageHandler(func(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+		calls++
+		return map[string]interface{}{"ok": true}, nil
+	})
+	for method := range defaultMethodSchemas {
+		server.handlers[method] = stub
+	}
+	server.handlers["report.generate"] = stub
+
+	return server, &calls
+}
+
+func callMethod(t *testing.T, server *Server, method string, params interface{}) ws.Message {
+	t.Helper()
+
+	conn := NewConnection("conn", nil, server)
+	conn.AgentID = "agent-1"
+	conn.TenantID = "tenant-1"
+
+	raw, _, err := server.processMessage(context.Background(), conn, &ws.Message{
+		ID:     "msg-1",
+		Type:   ws.MessageTypeRequest,
+		Method: method,
+		Params: params,
+	})
+	require.NoError(t, err)
+
+	var response ws.Message
+	require.NoError(t, json.Unmarshal(raw, &response))
+	return response
+}
+
+func assertValidationError(t *testing.T, response ws.Message, method string) []interface{} {
+	t.Helper()
+
+	require.NotNil(t, response.Error, "%s accepted invalid params", method)
+	assert.Equal(t, ws.ErrCodeInvalidParams, response.Error.Code)
+
+	data, ok := response.Error.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, ErrParamsValidationFailed, data["error"])
+	assert.Equal(t, method, data["method"])
+
+	violations, ok := data["violations"].([]interface{})
+	require.True(t, ok)
+	require.NotEmpty(t, violations)
+	for _, violation := range violations {
+		assert.Contains(t, violation, "field")
+		assert.Contains(t, violation, "type")
+		assert.Contains(t, violation, "description")
+	}
+	return violations
+}
+
+func TestProcessMessageValidatesParams(t *testing.T) {
+	t.Run("every registered method rejects params of the wrong shape", func(t *testing.T) {
+		server, calls := newMethodSchemaTestServer(t)
+
+		methods := []string{"report.generate"}
+		for method := range defaultMethodSchemas {
+			methods = append(methods, method)
+		}
+		for _, method := range methods {
+			assertValidationError(t, callMethod(t, server, method, []string{"not", "an", "object"}), method)
+		}
+		assert.Zero(t, *calls)
+	})
+
+	t.Run("violations name the offending fields", func(t *testing.T) {
+		server, calls := newMethodSchemaTestServer(t)
+
+		violations := assertValidationError(t, callMethod(t, server, "report.generate", map[string]interface{}{
+			"format": "xml",
+		}), "report.generate")
+
+		fields := make([]interface{}, 0, len(violations))
+		for _, violation := range violations {
+			fields = append(fields, violation.(map[string]interface{})["field"])
+		}
+		assert.ElementsMatch(t, []interface{}{"name", "format"}, fields)
+		assert.Zero(t, *calls)
+	})
+
+	t.Run("missing params are validated as an empty object", func(t *testing.T) {
+		server, calls := newMethodSchemaTestServer(t)
+
+		assertValidationError(t, callMethod(t, server, "tool.execute", nil), "tool.execute")
+		assert.Zero(t, *calls)
+	})
+
+	t.Run("valid params are dispatched", func(t *testing.T) {
+		server, calls := newMethodSchemaTestServer(t)
+
+		response := callMethod(t, server, "tool.execute", map[string]interface{}{
+			"tool_id": "github",
+			"action":  "list_issues",
+		})
+		assert.Nil(t, response.Error)
+		response = callMethod(t, server, "report.generate", map[string]interface{}{
+			"name":   "weekly",
+			"format": "csv",
+		})
+		assert.Nil(t, response.Error)
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("methods without a schema accept any params", func(t *testing.T) {
+		server, _ := newMethodSchemaTestServer(t)
+
+		response := callMethod(t, server, "echo", map[string]interface{}{"message": 42})
+		assert.Nil(t, response.Error)
+	})
+}
+
+func TestMethodSchemaRegistryRegister(t *testing.T) {
+	registry := NewMethodSchemaRegistry()
+
+	assert.Error(t, registry.Register("report.generate", `{"type": 12}`))
+	assert.Error(t, registry.Register("report.generate", `not json`))
+
+	require.NoError(t, registry.Register("tool.execute", nil))
+	_, ok := registry.Schema("tool.execute")
+	assert.False(t, ok)
+	assert.NoError(t, registry.Validate("tool.execute", json.RawMessage(`[]`)))
+}
+
+func TestSystemDescribeMethods(t *testing.T) {
+	server, _ := newMethodSchemaTestServer(t)
+
+	response := callMethod(t, server, "system.describe_methods", nil)
+	require.Nil(t, response.Error)
+
+	result, ok := response.Result.(map[string]interface{})
+	require.True(t, ok)
+	methods, ok := result["methods"].([]interface{})
+	require.True(t, ok)
+	assert.Equal(t, float64(len(methods)), result["count"])
+
+	byName := make(map[string]map[string]interface{}, len(methods))
+	for _, method := range methods {
+		description := method.(map[string]interface{})
+		byName[description["name"].(string)] = description
+	}
+
+	// Built-in and configured schemas are both exposed
+	toolExecute := byName["tool.execute"]
+	require.NotNil(t, toolExecute)
+	assert.Equal(t, false, toolExecute["read_only"])
+	schema := toolExecute["input_schema"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"tool_id", "action"}, schema["required"])
+
+	report := byName["report.generate"]
+	require.NotNil(t, report)
+	var expected interface{}
+	require.NoError(t, json.Unmarshal([]byte(reportSchema), &expected))
+	assert.Equal(t, expected, report["input_schema"])
+
+	// Methods without a schema are listed without one
+	echo := byName["echo"]
+	require.NotNil(t, echo)
+	assert.Equal(t, true, echo["read_only"])
+	assert.NotContains(t, echo, "input_schema")
+
+	assert.Contains(t, byName, "system.describe_methods")
+}
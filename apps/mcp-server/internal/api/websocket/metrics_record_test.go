@@ -0,0 +1,92 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+func newMetricsTestConnection(metrics *recordingMetrics) (*Server, *Connection) {
+	server := NewServer(&auth.Service{}, metrics, NewTestLogger(), Config{})
+	conn := NewConnection("conn-1", nil, server)
+	conn.AgentID = "agent-1"
+	conn.TenantID = "tenant-1"
+	return server, conn
+}
+
+func TestHandleMetricsRecordBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records every point with its tags", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server, conn := newMetricsTestConnection(metrics)
+
+		result, err := server.handleMetricsRecordBatch(ctx, conn, json.RawMessage(`{"metrics": [
+			{"metric": "agent.tasks_completed", "value": 3, "tags": {"queue": "builds"}},
+			{"metric": "agent.latency_ms", "value": 125.5},
+			{"metric": "agent.tasks_completed", "value": 1, "tags": {"queue": "deploys", "tenant_id": "spoofed"}}
+		]}`))
+		require.NoError(t, err)
+		assert.Equal(t, 3, result.(map[string]interface{})["recorded"])
+
+		require.Len(t, metrics.gauges, 3)
+		assert.Equal(t, recordedGauge{
+			name:   "agent.tasks_completed",
+			value:  3,
+			labels: map[string]string{"queue": "builds", "agent_id": "agent-1", "tenant_id": "tenant-1"},
+		}, metrics.gauges[0])
+		assert.Equal(t, recordedGauge{
+			name:   "agent.latency_ms",
+			value:  125.5,
+			labels: map[string]string{"agent_id": "agent-1", "tenant_id": "tenant-1"},
+		}, metrics.gauges[1])
+		// Points can't claim another tenant
+		assert.Equal(t, map[string]string{"queue": "deploys", "agent_id": "agent-1", "tenant_id": "tenant-1"}, metrics.gauges[2].labels)
+	})
+
+	t.Run("rejects oversized batches", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server, conn := newMetricsTestConnection(metrics)
+
+		points := make([]string, MaxMetricsBatchSize+1)
+		for i := range points {
+			points[i] = fmt.Sprintf(`{"metric": "agent.events", "value": %d}`, i)
+		}
+		params := json.RawMessage(`{"metrics": [` + strings.Join(points, ",") + `]}`)
+
+		_, err := server.handleMetricsRecordBatch(ctx, conn, params)
+		var wsErr *ws.Error
+		require.ErrorAs(t, err, &wsErr)
+		assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+		assert.Empty(t, metrics.gauges)
+	})
+
+	t.Run("rejects the batch when a point is invalid", func(t *testing.T) {
+		metrics := newRecordingMetrics()
+		server, conn := newMetricsTestConnection(metrics)
+
+		_, err := server.handleMetricsRecordBatch(ctx, conn, json.RawMessage(`{"metrics": [
+			{"metric": "agent.events", "value": 1},
+			{"value": 2}
+		]}`))
+		var wsErr *ws.Error
+		require.ErrorAs(t, err, &wsErr)
+		assert.Contains(t, wsErr.Message, "metrics[1]")
+		assert.Empty(t, metrics.gauges)
+	})
+
+	t.Run("rejects empty batches", func(t *testing.T) {
+		server, conn := newMetricsTestConnection(newRecordingMetrics())
+
+		_, err := server.handleMetricsRecordBatch(ctx, conn, json.RawMessage(`{"metrics": []}`))
+		assert.Error(t, err)
+	})
+}
@@ -121,35 +121,54 @@ func (nm *NotificationManager) SendNotification(ctx context.Context, connID stri
 	return conn.SendMessage(msg)
 }
 
+// sendMessage sends a prepared message to a specific connection
+func (nm *NotificationManager) sendMessage(connID string, msg *ws.Message) error {
+	nm.mu.RLock()
+	conn, ok := nm.connections[connID]
+	nm.mu.RUnlock()
+
+	if !ok {
+		return ErrConnectionNotFound
+	}
+
+	return conn.SendMessage(msg)
+}
+
 // BroadcastNotification sends a notification to all connections subscribed to a topic
 func (nm *NotificationManager) BroadcastNotification(ctx context.Context, topic string, method string, params interface{}) {
 	nm.mu.RLock()
 	// Get subscribers from internal map
 	subs := nm.subscribers[topic]
 
-	// Also get subscribers from subscription manager if available
+	subscriptionManager := nm.subscriptionManager
+	nm.mu.RUnlock()
+
+	// Also get subscribers from subscription manager if available. The event is
+	// recorded so resource subscribers can replay it after reconnecting.
 	var resourceSubs []string
-	if nm.subscriptionManager != nil {
+	var eventMsg *ws.Message
+	if subscriptionManager != nil {
 		// The topic is used as resource name for subscription manager
-		subscriptions := nm.subscriptionManager.GetSubscriptions(topic)
-		for _, sub := range subscriptions {
-			resourceSubs = append(resourceSubs, sub.ConnectionID)
+		var eventID uint64
+		eventID, resourceSubs = subscriptionManager.RecordEvent(topic, method, params)
+		if eventID != 0 {
+			eventMsg = subscriptionEventMessage(SubscriptionEvent{ID: eventID, Method: method, Params: params})
 		}
 	}
-	nm.mu.RUnlock()
 
 	nm.logger.Debug("BroadcastNotification checking subscribers", map[string]interface{}{
 		"topic":                    topic,
 		"method":                   method,
 		"internal_subscribers":     len(subs),
 		"resource_subscribers":     len(resourceSubs),
-		"has_subscription_manager": nm.subscriptionManager != nil,
+		"has_subscription_manager": subscriptionManager != nil,
 	})
 
-	// Combine both subscriber lists and deduplicate
+	// Combine both subscriber lists and deduplicate. Resource subscribers receive
+	// the recorded event ID as the message ID for use as a replay cursor.
 	allSubs := make(map[string]bool)
 	for _, connID := range subs {
-		allSubs[connID] = true
+		allSubs[connID] = false
 	}
 	for _, connID := range resourceSubs {
 		allSubs[connID] = true
@@ -161,18 +180,24 @@ func (nm *NotificationManager) BroadcastNotification(ctx context.Context, topic
 
 	// Send to all subscribers
 	var wg sync.WaitGroup
-	for connID := range allSubs {
+	for connID, recorded := range allSubs {
 		wg.Add(1)
-		go func(id string) {
+		go func(id string, recorded bool) {
 			defer wg.Done()
-			if err := nm.SendNotification(ctx, id, method, params); err != nil {
+			var err error
+			if recorded {
+				err = nm.sendMessage(id, eventMsg)
+			} else {
+				err = nm.SendNotification(ctx, id, method, params)
+			}
+			if err != nil {
 				nm.logger.Debug("Failed to send notification", map[string]interface{}{
 					"connection_id": id,
 					"method":        method,
 					"error":         err.Error(),
 				})
 			}
-		}(connID)
+		}(connID, recorded)
 	}
 
 	// Wait with timeout
@@ -221,15 +246,37 @@ func (nm *NotificationManager) NotifyWorkflowStepStarted(ctx context.Context, wo
 	nm.BroadcastNotification(ctx, "workflow:"+workflowID, "workflow.step_started", params)
 }
 
-// NotifyWorkflowStepCompleted sends a workflow.step_completed notification
-func (nm *NotificationManager) NotifyWorkflowStepCompleted(ctx context.Context, workflowID, executionID, stepID string, result interface{}) {
+// WorkflowStepCompletion describes a workflow step that finished executing
+type WorkflowStepCompletion struct {
+	StepID      string
+	StepIndex   int
+	Status      string
+	Output      interface{}
+	Error       string
+	Result      map[string]interface{}
+	StartedAt   time.Time
+	CompletedAt time.Time
+}
+
+// NotifyWorkflowStepCompleted sends a workflow.step_completed notification as
+// soon as a step finishes, so streaming clients see partial progress
+func (nm *NotificationManager) NotifyWorkflowStepCompleted(ctx context.Context, workflowID, executionID string, step WorkflowStepCompletion) {
 	params := map[string]interface{}{
-		"step_id":      stepID,
+		"step_id":      step.StepID,
+		"step_index":   step.StepIndex,
 		"workflow_id":  workflowID,
 		"execution_id": executionID,
-		"result":       result,
+		"status":       step.Status,
+		"output":       step.Output,
+		"result":       step.Result,
+		"started_at":   step.StartedAt.Format(time.RFC3339Nano),
+		"completed_at": step.CompletedAt.Format(time.RFC3339Nano),
+		"duration_ms":  step.CompletedAt.Sub(step.StartedAt).Milliseconds(),
 		"timestamp":    time.Now().Format(time.RFC3339),
 	}
+	if step.Error != "" {
+		params["error"] = step.Error
+	}
 
 	nm.BroadcastNotification(ctx, "workflow:"+workflowID, "workflow.step_completed", params)
 }
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RequestDedupConfig configures deduplication of identical read-only requests
+type RequestDedupConfig struct {
+	Disabled bool          `mapstructure:"disabled"` // Run every read request separately
+	Window   time.Duration `mapstructure:"window"`   // How long a completed result is reused; 0 only shares in-flight requests
+}
+
+// connectionScopedMethods are read-only methods whose results depend on, or act
+// on, the calling connection, so they can't be shared between connections
+var connectionScopedMethods = map[string]bool{
+	"echo":                 true,
+	"ping":                 true,
+	"protocol.get_info":    true,
+	"context.get_limits":   true,
+	"subscription.list":    true,
+	"subscription.status":  true,
+	"subscription.replay":  true,
+	"task.watch":           true,
+	"window.getTokenUsage": true,
+}
+
+// dedupCall is an in-flight or recently completed request shared by identical requests
+type dedupCall struct {
+	done    chan struct{}
+	result  interface{}
+	err     error
+	expires time.Time
+}
+
+// RequestDeduplicator shares one handler call between concurrent identical
+// read-only requests, so bursts of the same read hit the backend once
+type RequestDeduplicator struct {
+	window time.Duration
+	mu     sync.Mutex
+	calls  map[string]*dedupCall
+}
+
+// NewRequestDeduplicator creates a new request deduplicator. It returns nil when
+// deduplication is disabled.
+func NewRequestDeduplicator(config RequestDedupConfig) *RequestDeduplicator {
+	if config.Disabled {
+		return nil
+	}
+	return &RequestDeduplicator{
+		window: config.Window,
+		calls:  make(map[string]*dedupCall),
+	}
+}
+
+// Do runs fn once for concurrent calls with the same key and returns its result
+// to every caller. shared reports whether the result came from another call.
+// Waiting callers return early if their context is cancelled.
+func (d *RequestDeduplicator) Do(ctx context.Context, key string, fn func() (interface{}, error)) (result interface{}, err error, shared bool) {
+	d.mu.Lock()
+	if call, ok := d.calls[key]; ok {
+		select {
+		case <-call.done:
+			if time.Now().Before(call.expires) {
+				d.mu.Unlock()
+				return call.result, call.err, true
+			}
+			// Expired, so make a fresh call
+		default:
+			d.mu.Unlock()
+			select {
+			case <-call.done:
+				return call.result, call.err, true
+			case <-ctx.Done():
+				return nil, ctx.Err(), false
+			}
+		}
+	}
+
+	call := &dedupCall{done: make(chan struct{})}
+	d.calls[key] = call
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		// Errors are never reused after the call completes
+		if d.window > 0 && call.err == nil {
+			call.expires = time.Now().Add(d.window)
+			time.AfterFunc(d.window, func() { d.forget(key, call) })
+		} else {
+			delete(d.calls, key)
+		}
+		d.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.result, call.err = fn()
+	return call.result, call.err, false
+}
+
+// Size returns the number of calls in flight or kept for reuse
+func (d *RequestDeduplicator) Size() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.calls)
+}
+
+func (d *RequestDeduplicator) forget(key string, call *dedupCall) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.calls[key] == call {
+		delete(d.calls, key)
+	}
+}
+
+// requestDedupKey returns the key identical requests share, or "" when the
+// request can't be deduplicated
+func requestDedupKey(conn *Connection, method string, params json.RawMessage) string {
+	if !readOnlyMethods.Matches(method) || connectionScopedMethods[method] {
+		return ""
+	}
+
+	var userID string
+	if conn.state != nil && conn.state.Claims != nil {
+		userID = conn.state.Claims.UserID
+	}
+
+	return strings.Join([]string{method, conn.TenantID, userID, conn.AgentID, string(params)}, "\x00")
+}
+
+// deduplicatedHandler wraps a read-only handler so identical requests share its call.
+// The shared call isn't cancelled when the request that started it is.
+func (s *Server) deduplicatedHandler(key, method string, handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+		result, err, shared := s.requestDedup.Do(ctx, key, func() (interface{}, error) {
+			return handler(context.WithoutCancel(ctx), conn, params)
+		})
+		if shared && s.metrics != nil {
+			s.metrics.IncrementCounterWithLabels("websocket_requests_deduplicated", 1, map[string]string{
+				"method": method,
+			})
+		}
+		return result, err
+	}
+}
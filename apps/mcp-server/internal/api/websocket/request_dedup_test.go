@@ -0,0 +1,213 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// countingBackend is a slow read handler that counts its calls
+type countingBackend struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func newCountingBackend() *countingBackend {
+	return &countingBackend{release: make(chan struct{})}
+}
+
+func (b *countingBackend) handle(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	b.calls.Add(1)
+	<-b.release
+	return map[string]interface{}{"tools": []string{"github", "jira"}}, nil
+}
+
+// runConcurrently starts n calls and releases the backend once they have all started
+func runConcurrently(n int, backend *countingBackend, call func(i int)) {
+	var started atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			started.Add(1)
+			call(i)
+		}(i)
+	}
+
+	for started.Load() < int32(n) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(backend.release)
+	wg.Wait()
+}
+
+func TestProcessMessageDeduplicatesConcurrentReads(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	backend := newCountingBackend()
+	server.handlers["tool.list"] = MessageHandler(backend.handle)
+
+	const callers = 50
+	responses := make([]ws.Message, callers)
+	runConcurrently(callers, backend, func(i int) {
+		conn := NewConnection("conn", nil, server)
+		conn.AgentID = "agent-1"
+		conn.TenantID = "tenant-1"
+
+		response, _, err := server.processMessage(context.Background(), conn, &ws.Message{
+			ID:     "msg",
+			Type:   ws.MessageTypeRequest,
+			Method: "tool.list",
+		})
+		if assert.NoError(t, err) {
+			assert.NoError(t, json.Unmarshal(response, &responses[i]))
+		}
+	})
+
+	assert.Equal(t, int32(1), backend.calls.Load())
+	for _, response := range responses {
+		assert.Nil(t, response.Error)
+		assert.Equal(t, map[string]interface{}{"tools": []interface{}{"github", "jira"}}, response.Result)
+	}
+}
+
+func TestRequestDeduplicatorDo(t *testing.T) {
+	t.Run("concurrent identical calls share one call", func(t *testing.T) {
+		dedup := NewRequestDeduplicator(RequestDedupConfig{})
+		backend := newCountingBackend()
+
+		const callers = 50
+		results := make([]interface{}, callers)
+		var sharedCount atomic.Int32
+		runConcurrently(callers, backend, func(i int) {
+			result, err, shared := dedup.Do(context.Background(), "key", func() (interface{}, error) {
+				return backend.handle(context.Background(), nil, nil)
+			})
+			assert.NoError(t, err)
+			results[i] = result
+			if shared {
+				sharedCount.Add(1)
+			}
+		})
+
+		assert.Equal(t, int32(1), backend.calls.Load())
+		assert.Equal(t, int32(callers-1), sharedCount.Load())
+		for _, result := range results {
+			assert.Equal(t, results[0], result)
+		}
+	})
+
+	t.Run("completed results are reused within the window", func(t *testing.T) {
+		dedup := NewRequestDeduplicator(RequestDedupConfig{Window: 50 * time.Millisecond})
+		var calls int
+		fn := func() (interface{}, error) {
+			calls++
+			return calls, nil
+		}
+
+		result, _, shared := dedup.Do(context.Background(), "key", fn)
+		assert.Equal(t, 1, result)
+		assert.False(t, shared)
+
+		result, _, shared = dedup.Do(context.Background(), "key", fn)
+		assert.Equal(t, 1, result)
+		assert.True(t, shared)
+
+		time.Sleep(60 * time.Millisecond)
+		result, _, _ = dedup.Do(context.Background(), "key", fn)
+		assert.Equal(t, 2, result)
+	})
+
+	t.Run("without a window completed results aren't reused", func(t *testing.T) {
+		dedup := NewRequestDeduplicator(RequestDedupConfig{})
+		var calls int
+		fn := func() (interface{}, error) {
+			calls++
+			return calls, nil
+		}
+
+		_, _, _ = dedup.Do(context.Background(), "key", fn)
+		result, _, shared := dedup.Do(context.Background(), "key", fn)
+		assert.Equal(t, 2, result)
+		assert.False(t, shared)
+	})
+
+	t.Run("errors aren't reused", func(t *testing.T) {
+		dedup := NewRequestDeduplicator(RequestDedupConfig{Window: time.Minute})
+		var calls int
+		fn := func() (interface{}, error) {
+			calls++
+			return nil, errors.New("backend unavailable")
+		}
+
+		_, err, _ := dedup.Do(context.Background(), "key", fn)
+		assert.Error(t, err)
+		_, err, shared := dedup.Do(context.Background(), "key", fn)
+		assert.Error(t, err)
+		assert.False(t, shared)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("waiters return when their context is cancelled", func(t *testing.T) {
+		dedup := NewRequestDeduplicator(RequestDedupConfig{})
+		release := make(chan struct{})
+		defer close(release)
+
+		go func() {
+			_, _, _ = dedup.Do(context.Background(), "key", func() (interface{}, error) {
+				<-release
+				return "done", nil
+			})
+		}()
+		require.Eventually(t, func() bool {
+			dedup.mu.Lock()
+			defer dedup.mu.Unlock()
+			return dedup.calls["key"] != nil
+		}, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err, _ := dedup.Do(ctx, "key", func() (interface{}, error) { return nil, nil })
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, NewRequestDeduplicator(RequestDedupConfig{Disabled: true}))
+	})
+}
+
+func TestRequestDedupKey(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	newConn := func(tenantID, agentID string) *Connection {
+		conn := NewConnection("conn", nil, server)
+		conn.TenantID = tenantID
+		conn.AgentID = agentID
+		return conn
+	}
+	params := json.RawMessage(`{"context_id":"ctx-1"}`)
+
+	key := requestDedupKey(newConn("tenant-1", "agent-1"), "context.get", params)
+	assert.NotEmpty(t, key)
+	assert.Equal(t, key, requestDedupKey(newConn("tenant-1", "agent-1"), "context.get", params))
+
+	// Requests from other tenants or agents, or with other params, aren't shared
+	assert.NotEqual(t, key, requestDedupKey(newConn("tenant-2", "agent-1"), "context.get", params))
+	assert.NotEqual(t, key, requestDedupKey(newConn("tenant-1", "agent-2"), "context.get", params))
+	assert.NotEqual(t, key, requestDedupKey(newConn("tenant-1", "agent-1"), "context.get", json.RawMessage(`{"context_id":"ctx-2"}`)))
+
+	// Writes and connection-scoped reads aren't deduplicated
+	assert.Empty(t, requestDedupKey(newConn("tenant-1", "agent-1"), "context.update", params))
+	assert.Empty(t, requestDedupKey(newConn("tenant-1", "agent-1"), "subscription.list", nil))
+}
@@ -0,0 +1,466 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+)
+
+const (
+	// DefaultScopeElevationMaxDuration is the longest an elevation can be granted for
+	DefaultScopeElevationMaxDuration = 15 * time.Minute
+	// DefaultScopeElevationRequestTTL is how long a request waits for an approver
+	DefaultScopeElevationRequestTTL = 10 * time.Minute
+)
+
+// Scope elevation request statuses
+const (
+	ScopeElevationPending = "pending"
+	ScopeElevationGranted = "granted"
+	ScopeElevationDenied  = "denied"
+)
+
+var (
+	// ErrScopeElevationDisabled is returned when no scopes can be requested
+	ErrScopeElevationDisabled = errors.New("scope elevation is not enabled")
+	// ErrScopeElevationNotFound is returned for unknown or expired requests
+	ErrScopeElevationNotFound = errors.New("scope elevation request not found")
+)
+
+// ScopeElevationConfig configures temporary scope elevation. Agents request
+// scopes for their connection and an admin of the same tenant grants them for a
+// bounded duration, after which the connection reverts to its own scopes.
+type ScopeElevationConfig struct {
+	AllowedScopes     []string      `mapstructure:"allowed_scopes"`      // Scopes that can be requested; elevation is disabled when empty
+	AutoApproveScopes []string      `mapstructure:"auto_approve_scopes"` // Scopes granted without an approver
+	MaxDuration       time.Duration `mapstructure:"max_duration"`        // Longest grant; defaults to 15 minutes
+	RequestTTL        time.Duration `mapstructure:"request_ttl"`         // How long a request waits for approval; defaults to 10 minutes
+}
+
+// ScopeElevationRequest is an agent's request for temporary scopes
+type ScopeElevationRequest struct {
+	ID           string        `json:"request_id"`
+	ConnectionID string        `json:"connection_id"`
+	TenantID     string        `json:"tenant_id"`
+	UserID       string        `json:"user_id"`
+	AgentID      string        `json:"agent_id,omitempty"`
+	Scopes       []string      `json:"scopes"`
+	Reason       string        `json:"reason,omitempty"`
+	Duration     time.Duration `json:"-"`
+	Status       string        `json:"status"`
+	RequestedAt  time.Time     `json:"requested_at"`
+	DecidedBy    string        `json:"decided_by,omitempty"`
+	DecidedAt    *time.Time    `json:"decided_at,omitempty"`
+	GrantedUntil *time.Time    `json:"granted_until,omitempty"`
+	Requester    string        `json:"-"` // Credential of the requester, as credentialID reports it
+}
+
+// scopeElevation is a grant in effect on a connection
+type scopeElevation struct {
+	scopes []string
+	until  time.Time
+}
+
+// ScopeElevationManager tracks scope elevation requests until they're decided
+type ScopeElevationManager struct {
+	config      ScopeElevationConfig
+	allowed     map[string]bool
+	autoApprove map[string]bool
+	mu          sync.Mutex
+	requests    map[string]*ScopeElevationRequest
+	now         func() time.Time
+}
+
+// NewScopeElevationManager creates a new scope elevation manager
+func NewScopeElevationManager(config ScopeElevationConfig) *ScopeElevationManager {
+	if config.MaxDuration <= 0 {
+		config.MaxDuration = DefaultScopeElevationMaxDuration
+	}
+	if config.RequestTTL <= 0 {
+		config.RequestTTL = DefaultScopeElevationRequestTTL
+	}
+
+	m := &ScopeElevationManager{
+		config:      config,
+		allowed:     make(map[string]bool),
+		autoApprove: make(map[string]bool),
+		requests:    make(map[string]*ScopeElevationRequest),
+		now:         time.Now,
+	}
+	for _, scope := range config.AllowedScopes {
+		m.allowed[scope] = true
+	}
+	for _, scope := range config.AutoApproveScopes {
+		m.autoApprove[scope] = m.allowed[scope]
+	}
+	return m
+}
+
+// Enabled reports whether any scope can be requested
+func (m *ScopeElevationManager) Enabled() bool {
+	return m != nil && len(m.allowed) > 0
+}
+
+// grantDuration bounds a requested duration by the maximum, defaulting to it
+func (m *ScopeElevationManager) grantDuration(requested time.Duration) time.Duration {
+	if requested <= 0 || requested > m.config.MaxDuration {
+		return m.config.MaxDuration
+	}
+	return requested
+}
+
+// Request records a request for scopes the caller doesn't hold. It's granted
+// right away when every scope is auto-approved.
+func (m *ScopeElevationManager) Request(req ScopeElevationRequest, held []string) (*ScopeElevationRequest, error) {
+	if !m.Enabled() {
+		return nil, ErrScopeElevationDisabled
+	}
+	if len(req.Scopes) == 0 {
+		return nil, errors.New("scopes are required")
+	}
+
+	holds := make(map[string]bool, len(held))
+	for _, scope := range held {
+		holds[scope] = true
+	}
+
+	var scopes []string
+	autoApproved := true
+	for _, scope := range req.Scopes {
+		if !m.allowed[scope] {
+			return nil, fmt.Errorf("scope %q can't be requested", scope)
+		}
+		if holds[scope] {
+			continue
+		}
+		holds[scope] = true
+		scopes = append(scopes, scope)
+		autoApproved = autoApproved && m.autoApprove[scope]
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("no scopes to request; the connection already holds them")
+	}
+
+	now := m.now()
+	req.ID = uuid.New().String()
+	req.Scopes = scopes
+	req.Duration = m.grantDuration(req.Duration)
+	req.Status = ScopeElevationPending
+	req.RequestedAt = now
+	if autoApproved {
+		until := now.Add(req.Duration)
+		req.Status = ScopeElevationGranted
+		req.DecidedBy = "auto"
+		req.DecidedAt = &now
+		req.GrantedUntil = &until
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+	if req.Status == ScopeElevationPending {
+		m.requests[req.ID] = &req
+	}
+	result := req
+	return &result, nil
+}
+
+// Decide grants or denies a pending request of the approver's tenant. The
+// approver is identified by credential and can't be the requester. A granted
+// request lasts for duration, or the requested duration when it's zero, bounded
+// by the maximum.
+func (m *ScopeElevationManager) Decide(requestID, tenantID, approver string, grant bool, duration time.Duration) (*ScopeElevationRequest, error) {
+	if !m.Enabled() {
+		return nil, ErrScopeElevationDisabled
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	req, ok := m.requests[requestID]
+	if !ok || req.TenantID != tenantID {
+		return nil, ErrScopeElevationNotFound
+	}
+	if req.Requester == approver {
+		return nil, errors.New("scope elevation can't be approved by its requester")
+	}
+	delete(m.requests, requestID)
+
+	now := m.now()
+	req.DecidedBy = approver
+	req.DecidedAt = &now
+	req.Status = ScopeElevationDenied
+	if grant {
+		if duration <= 0 {
+			duration = req.Duration
+		}
+		until := now.Add(m.grantDuration(duration))
+		req.Status = ScopeElevationGranted
+		req.GrantedUntil = &until
+	}
+
+	result := *req
+	return &result, nil
+}
+
+// Pending returns a tenant's requests awaiting a decision, oldest first
+func (m *ScopeElevationManager) Pending(tenantID string) []ScopeElevationRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneLocked()
+
+	pending := make([]ScopeElevationRequest, 0)
+	for _, req := range m.requests {
+		if req.TenantID == tenantID {
+			pending = append(pending, *req)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].RequestedAt.Before(pending[j].RequestedAt)
+	})
+	return pending
+}
+
+// pruneLocked drops requests nobody decided on in time. m.mu must be held.
+func (m *ScopeElevationManager) pruneLocked() {
+	cutoff := m.now().Add(-m.config.RequestTTL)
+	for id, req := range m.requests {
+		if req.RequestedAt.Before(cutoff) {
+			delete(m.requests, id)
+		}
+	}
+}
+
+// credentialID identifies the credential behind claims: the API key they were
+// derived from, as API key connections can share a user ID, or else the user
+func credentialID(claims *auth.Claims) string {
+	if claims.KeyPrefix != "" {
+		return "api_key:" + claims.KeyPrefix
+	}
+	return "user:" + claims.UserID
+}
+
+// baseClaims returns the connection's own claims, without elevated scopes
+func (c *Connection) baseClaims() *auth.Claims {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		return nil
+	}
+	return c.state.Claims
+}
+
+// elevate applies a granted request to a connection
+func (c *Connection) elevate(req *ScopeElevationRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scopeElevations = append(c.scopeElevations, scopeElevation{
+		scopes: req.Scopes,
+		until:  *req.GrantedUntil,
+	})
+}
+
+// effectiveClaims returns the connection's claims with the scopes of its
+// unexpired elevations added. Expired elevations are dropped, which reverts the
+// connection to its own scopes.
+func (s *Server) effectiveClaims(conn *Connection) *auth.Claims {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	if conn.state == nil || conn.state.Claims == nil {
+		return nil
+	}
+	claims := conn.state.Claims
+	if len(conn.scopeElevations) == 0 {
+		return claims
+	}
+
+	now := s.scopeElevation.now()
+	active := conn.scopeElevations[:0]
+	for _, elevation := range conn.scopeElevations {
+		if now.Before(elevation.until) {
+			active = append(active, elevation)
+		}
+	}
+	conn.scopeElevations = active
+	if len(active) == 0 {
+		return claims
+	}
+
+	elevated := *claims
+	elevated.Scopes = append([]string(nil), claims.Scopes...)
+	for _, elevation := range active {
+		elevated.Scopes = append(elevated.Scopes, elevation.scopes...)
+	}
+	return &elevated
+}
+
+// applyScopeElevation elevates the requesting connection's scopes and tells it
+// about the grant
+func (s *Server) applyScopeElevation(req *ScopeElevationRequest) error {
+	s.mu.RLock()
+	target, ok := s.connections[req.ConnectionID]
+	s.mu.RUnlock()
+	if !ok {
+		return errors.New("the requesting connection has closed")
+	}
+
+	target.elevate(req)
+
+	if err := target.SendNotification("scope.elevation.granted", req); err != nil {
+		s.logger.Warn("Failed to notify connection of scope elevation", map[string]interface{}{
+			"connection_id": req.ConnectionID,
+			"request_id":    req.ID,
+			"error":         err.Error(),
+		})
+	}
+
+	s.logger.Info("Scope elevation granted", map[string]interface{}{
+		"request_id":    req.ID,
+		"connection_id": req.ConnectionID,
+		"tenant_id":     req.TenantID,
+		"user_id":       req.UserID,
+		"scopes":        req.Scopes,
+		"decided_by":    req.DecidedBy,
+		"granted_until": req.GrantedUntil.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// scopeElevationApprover returns the claims of a connection deciding on scope
+// elevation requests. Its own scopes must grant admin: scopes it was elevated to
+// don't count, so an elevation can't be used to approve others.
+func (s *Server) scopeElevationApprover(ctx context.Context, conn *Connection) (*auth.Claims, error) {
+	claims := conn.baseClaims()
+	if claims == nil {
+		return nil, errors.New("scope elevation requires an authenticated connection")
+	}
+	if !s.claimsGrant(ctx, claims, "admin") {
+		return nil, errors.New("admin permission required to decide on scope elevation")
+	}
+	return claims, nil
+}
+
+// handleScopeElevationRequest handles the scope.elevation.request method
+func (s *Server) handleScopeElevationRequest(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var requestParams struct {
+		Scopes          []string `json:"scopes"`
+		Reason          string   `json:"reason"`
+		DurationSeconds int      `json:"duration_seconds"`
+	}
+	if err := json.Unmarshal(params, &requestParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	claims := s.effectiveClaims(conn)
+	if claims == nil {
+		return nil, errors.New("scope elevation requires an authenticated connection")
+	}
+
+	req, err := s.scopeElevation.Request(ScopeElevationRequest{
+		ConnectionID: conn.ID,
+		TenantID:     claims.TenantID,
+		UserID:       claims.UserID,
+		AgentID:      conn.AgentID,
+		Requester:    credentialID(claims),
+		Scopes:       requestParams.Scopes,
+		Reason:       requestParams.Reason,
+		Duration:     time.Duration(requestParams.DurationSeconds) * time.Second,
+	}, claims.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Scope elevation requested", map[string]interface{}{
+		"request_id":    req.ID,
+		"connection_id": conn.ID,
+		"tenant_id":     req.TenantID,
+		"user_id":       req.UserID,
+		"scopes":        req.Scopes,
+		"status":        req.Status,
+	})
+
+	if req.Status == ScopeElevationGranted {
+		conn.elevate(req)
+	}
+	return req, nil
+}
+
+// handleScopeElevationGrant handles the scope.elevation.grant method
+func (s *Server) handleScopeElevationGrant(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var grantParams struct {
+		RequestID       string `json:"request_id"`
+		DurationSeconds int    `json:"duration_seconds"`
+	}
+	if err := json.Unmarshal(params, &grantParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	claims, err := s.scopeElevationApprover(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.scopeElevation.Decide(grantParams.RequestID, claims.TenantID, credentialID(claims), true,
+		time.Duration(grantParams.DurationSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyScopeElevation(req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// handleScopeElevationDeny handles the scope.elevation.deny method
+func (s *Server) handleScopeElevationDeny(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var denyParams struct {
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(params, &denyParams); err != nil {
+		return nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	claims, err := s.scopeElevationApprover(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.scopeElevation.Decide(denyParams.RequestID, claims.TenantID, credentialID(claims), false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	target, ok := s.connections[req.ConnectionID]
+	s.mu.RUnlock()
+	if ok {
+		_ = target.SendNotification("scope.elevation.denied", req)
+	}
+
+	s.logger.Info("Scope elevation denied", map[string]interface{}{
+		"request_id": req.ID,
+		"tenant_id":  req.TenantID,
+		"user_id":    req.UserID,
+		"decided_by": req.DecidedBy,
+	})
+	return req, nil
+}
+
+// handleScopeElevationList handles the scope.elevation.list method
+func (s *Server) handleScopeElevationList(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	pending := s.scopeElevation.Pending(conn.TenantID)
+	return map[string]interface{}{
+		"requests": pending,
+		"count":    len(pending),
+	}, nil
+}
@@ -0,0 +1,249 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func newScopeElevationTestServer(config ScopeElevationConfig) *Server {
+	return NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{ScopeElevation: config})
+}
+
+func addScopedConnection(server *Server, id, tenantID, userID string, scopes ...string) *Connection {
+	conn := NewConnection(id, nil, server)
+	conn.TenantID = tenantID
+	conn.AgentID = "agent-" + id
+	conn.state = &ConnectionState{Claims: &auth.Claims{TenantID: tenantID, UserID: userID, Scopes: scopes}}
+	server.mu.Lock()
+	server.connections[id] = conn
+	server.mu.Unlock()
+	return conn
+}
+
+// callMethodAs sends a request on a connection and returns the decoded response
+func callMethodAs(t *testing.T, server *Server, conn *Connection, method string, params interface{}) ws.Message {
+	t.Helper()
+	data, _, err := server.processMessage(context.Background(), conn, &ws.Message{
+		ID:     uuid.New().String(),
+		Type:   ws.MessageTypeRequest,
+		Method: method,
+		Params: params,
+	})
+	require.NoError(t, err)
+
+	var response ws.Message
+	require.NoError(t, json.Unmarshal(data, &response))
+	return response
+}
+
+func TestScopeElevationWorkflow(t *testing.T) {
+	server := newScopeElevationTestServer(ScopeElevationConfig{
+		AllowedScopes: []string{"admin"},
+		MaxDuration:   time.Hour,
+	})
+	now := time.Now()
+	server.scopeElevation.now = func() time.Time { return now }
+
+	tenantID := uuid.New().String()
+	agent := addScopedConnection(server, "agent", tenantID, "agent-user", "read", "write")
+	approver := addScopedConnection(server, "approver", tenantID, "admin-user", "admin")
+
+	// Without elevation the agent can't call admin methods
+	response := callMethodAs(t, server, agent, "admin.connections.report", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+	assert.Equal(t, ws.ErrCodeAuthFailed, response.Error.Code)
+
+	response = callMethodAs(t, server, agent, "scope.elevation.request", map[string]interface{}{
+		"scopes":           []string{"admin"},
+		"reason":           "rotate the deploy key",
+		"duration_seconds": 600,
+	})
+	require.Nil(t, response.Error)
+	request := response.Result.(map[string]interface{})
+	assert.Equal(t, ScopeElevationPending, request["status"])
+	requestID := request["request_id"].(string)
+
+	// A pending request doesn't elevate anything yet
+	response = callMethodAs(t, server, agent, "admin.connections.report", map[string]interface{}{})
+	require.NotNil(t, response.Error)
+
+	response = callMethodAs(t, server, approver, "scope.elevation.list", map[string]interface{}{})
+	require.Nil(t, response.Error)
+	assert.Equal(t, 1.0, response.Result.(map[string]interface{})["count"])
+
+	response = callMethodAs(t, server, approver, "scope.elevation.grant", map[string]interface{}{"request_id": requestID})
+	require.Nil(t, response.Error)
+	grant := response.Result.(map[string]interface{})
+	assert.Equal(t, ScopeElevationGranted, grant["status"])
+	assert.Equal(t, "user:admin-user", grant["decided_by"])
+
+	// The agent is told about the grant
+	select {
+	case data := <-agent.send:
+		var notification ws.Message
+		require.NoError(t, json.Unmarshal(data, &notification))
+		assert.Equal(t, "scope.elevation.granted", notification.Method)
+	default:
+		t.Fatal("expected a scope.elevation.granted notification")
+	}
+
+	t.Run("elevated scope works", func(t *testing.T) {
+		response := callMethodAs(t, server, agent, "admin.connections.report", map[string]interface{}{})
+		assert.Nil(t, response.Error)
+		assert.Equal(t, []string{"read", "write"}, agent.state.Claims.Scopes, "the connection's own claims are unchanged")
+	})
+
+	t.Run("elevation reverts after expiry", func(t *testing.T) {
+		now = now.Add(10*time.Minute + time.Second)
+
+		response := callMethodAs(t, server, agent, "admin.connections.report", map[string]interface{}{})
+		require.NotNil(t, response.Error)
+		assert.Equal(t, ws.ErrCodeAuthFailed, response.Error.Code)
+		assert.Empty(t, agent.scopeElevations)
+	})
+}
+
+func TestScopeElevationApprovers(t *testing.T) {
+	server := newScopeElevationTestServer(ScopeElevationConfig{
+		AllowedScopes:     []string{"admin", "deploy"},
+		AutoApproveScopes: []string{"admin"},
+	})
+	tenantID := uuid.New().String()
+
+	// API key connections share the key owner's user ID
+	const keyUser = "service-account"
+	agent := addScopedConnection(server, "agent", tenantID, keyUser, "read")
+	agent.state.Claims.KeyPrefix = "agt_1234"
+	sameKey := addScopedConnection(server, "same-key", tenantID, keyUser, "admin")
+	sameKey.state.Claims.KeyPrefix = "agt_1234"
+	otherKey := addScopedConnection(server, "other-key", tenantID, keyUser, "admin")
+	otherKey.state.Claims.KeyPrefix = "adm_5678"
+
+	requestDeploy := func() string {
+		response := callMethodAs(t, server, agent, "scope.elevation.request", map[string]interface{}{"scopes": []string{"deploy"}})
+		require.Nil(t, response.Error)
+		return response.Result.(map[string]interface{})["request_id"].(string)
+	}
+
+	t.Run("elevated admin can't approve", func(t *testing.T) {
+		elevated := addScopedConnection(server, "elevated", tenantID, "other-user", "read")
+		response := callMethodAs(t, server, elevated, "scope.elevation.request", map[string]interface{}{"scopes": []string{"admin"}})
+		require.Nil(t, response.Error)
+		require.Equal(t, ScopeElevationGranted, response.Result.(map[string]interface{})["status"])
+
+		requestID := requestDeploy()
+		for _, method := range []string{"scope.elevation.grant", "scope.elevation.deny"} {
+			response = callMethodAs(t, server, elevated, method, map[string]interface{}{"request_id": requestID})
+			require.NotNil(t, response.Error, method)
+			assert.Contains(t, response.Error.Message, "admin permission required")
+		}
+		assert.Len(t, server.scopeElevation.Pending(tenantID), 1)
+	})
+
+	t.Run("requester's key can't approve", func(t *testing.T) {
+		requestID := server.scopeElevation.Pending(tenantID)[0].ID
+		response := callMethodAs(t, server, sameKey, "scope.elevation.grant", map[string]interface{}{"request_id": requestID})
+		require.NotNil(t, response.Error)
+		assert.Contains(t, response.Error.Message, "requester")
+	})
+
+	t.Run("another key of the same user can approve", func(t *testing.T) {
+		requestID := server.scopeElevation.Pending(tenantID)[0].ID
+		response := callMethodAs(t, server, otherKey, "scope.elevation.grant", map[string]interface{}{"request_id": requestID})
+		require.Nil(t, response.Error)
+		assert.Equal(t, "api_key:adm_5678", response.Result.(map[string]interface{})["decided_by"])
+	})
+}
+
+func TestScopeElevationManager(t *testing.T) {
+	const tenantID = "tenant-1"
+	config := ScopeElevationConfig{
+		AllowedScopes:     []string{"admin", "write", "deploy"},
+		AutoApproveScopes: []string{"deploy"},
+		MaxDuration:       15 * time.Minute,
+		RequestTTL:        time.Minute,
+	}
+	request := func(scopes ...string) ScopeElevationRequest {
+		return ScopeElevationRequest{TenantID: tenantID, UserID: "agent-user", Requester: "user:agent-user", Scopes: scopes}
+	}
+
+	tests := []struct {
+		name    string
+		config  ScopeElevationConfig
+		request ScopeElevationRequest
+		held    []string
+		status  string
+		scopes  []string
+		wantErr string
+	}{
+		{name: "allowed scope waits for approval", config: config, request: request("admin"), status: ScopeElevationPending, scopes: []string{"admin"}},
+		{name: "auto-approved scope is granted", config: config, request: request("deploy"), status: ScopeElevationGranted, scopes: []string{"deploy"}},
+		{name: "mixed scopes wait for approval", config: config, request: request("deploy", "admin"), status: ScopeElevationPending, scopes: []string{"deploy", "admin"}},
+		{name: "held scopes are dropped", config: config, request: request("write", "admin"), held: []string{"write"}, status: ScopeElevationPending, scopes: []string{"admin"}},
+		{name: "only held scopes", config: config, request: request("write"), held: []string{"write"}, wantErr: "already holds"},
+		{name: "scope not allowed", config: config, request: request("superuser"), wantErr: "can't be requested"},
+		{name: "no scopes", config: config, request: request(), wantErr: "scopes are required"},
+		{name: "disabled", config: ScopeElevationConfig{}, request: request("admin"), wantErr: ErrScopeElevationDisabled.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewScopeElevationManager(tt.config)
+			req, err := manager.Request(tt.request, tt.held)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, req.Status)
+			assert.Equal(t, tt.scopes, req.Scopes)
+		})
+	}
+
+	t.Run("decisions", func(t *testing.T) {
+		manager := NewScopeElevationManager(config)
+		now := time.Now()
+		manager.now = func() time.Time { return now }
+
+		req, err := manager.Request(ScopeElevationRequest{TenantID: tenantID, UserID: "agent-user", Requester: "user:agent-user", Scopes: []string{"admin"}, Duration: time.Hour}, nil)
+		require.NoError(t, err)
+
+		_, err = manager.Decide(req.ID, "tenant-2", "user:admin-user", true, 0)
+		assert.ErrorIs(t, err, ErrScopeElevationNotFound, "approvers only see their own tenant")
+		_, err = manager.Decide(req.ID, tenantID, "user:agent-user", true, 0)
+		assert.ErrorContains(t, err, "requester")
+
+		granted, err := manager.Decide(req.ID, tenantID, "user:admin-user", true, 0)
+		require.NoError(t, err)
+		assert.Equal(t, now.Add(config.MaxDuration), *granted.GrantedUntil, "grants are bounded by the maximum duration")
+
+		_, err = manager.Decide(req.ID, tenantID, "user:admin-user", true, 0)
+		assert.ErrorIs(t, err, ErrScopeElevationNotFound, "a request is decided once")
+
+		req, err = manager.Request(request("admin"), nil)
+		require.NoError(t, err)
+		denied, err := manager.Decide(req.ID, tenantID, "user:admin-user", false, 0)
+		require.NoError(t, err)
+		assert.Equal(t, ScopeElevationDenied, denied.Status)
+		assert.Nil(t, denied.GrantedUntil)
+
+		req, err = manager.Request(request("admin"), nil)
+		require.NoError(t, err)
+		assert.Len(t, manager.Pending(tenantID), 1)
+		now = now.Add(config.RequestTTL + time.Second)
+		assert.Empty(t, manager.Pending(tenantID), "undecided requests expire")
+		_, err = manager.Decide(req.ID, tenantID, "user:admin-user", true, 0)
+		assert.ErrorIs(t, err, ErrScopeElevationNotFound)
+	})
+}
@@ -3,7 +3,9 @@ package websocket
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,6 +20,7 @@ import (
 	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
 	agentRepository "github.com/developer-mesh/developer-mesh/pkg/repository/agent"
+	securitytls "github.com/developer-mesh/developer-mesh/pkg/security/tls"
 	"github.com/developer-mesh/developer-mesh/pkg/services"
 	"go.opentelemetry.io/otel/attribute"
 )
@@ -56,6 +59,54 @@ type Server struct {
 	documentService  services.DocumentService
 	conflictService  services.ConflictResolutionService
 
+	// Idempotency key storage (Redis when available, in-memory otherwise)
+	idempotencyCache cache.Cache
+
+	// Automatic context checkpointing (nil when disabled)
+	contextCheckpointer *ContextCheckpointer
+
+	// Tool execution audit trail
+	toolAuditStore auth.ToolAuditStore
+
+	// Per-agent tool execution quotas
+	toolQuota *ToolQuotaLimiter
+
+	// Retries of tool executions that fail with a retryable error
+	toolRetry *ToolRetryPolicy
+
+	// Pending requests for temporary scopes
+	scopeElevation *ScopeElevationManager
+
+	// Tool output size caps
+	toolOutputLimit *ToolOutputLimiter
+
+	// Allowlist of metadata forwarded to tool executions
+	toolMetadata *ToolMetadataFilter
+
+	// Shared compression dictionary offered to binary protocol clients (nil when disabled)
+	compressionDictionary *CompressionDictionary
+
+	// Shares calls between identical in-flight read-only requests (nil when disabled)
+	requestDedup *RequestDeduplicator
+
+	// Per-tenant context metadata schemas
+	contextMetadataSchemas *ContextMetadataSchemas
+
+	// Input schemas method params are validated against before dispatch
+	methodSchemas *MethodSchemaRegistry
+
+	// Periodic orphaned subscription cleanup (nil when disabled)
+	subscriptionSweeper *subscriptionSweeper
+
+	// Active task.watch subscriptions (connection ID:task ID -> event bus subscription ID)
+	taskWatches sync.Map
+
+	// Per-agent workspace broadcast rate limiting
+	broadcastLimiter *BroadcastRateLimiter
+
+	// Mutual TLS client certificate authentication (nil when disabled)
+	clientCertAuth *securitytls.ClientCertAuthenticator
+
 	// Security components
 	sessionManager  *SessionManager
 	ipRateLimiter   *IPRateLimiter
@@ -87,6 +138,63 @@ type Config struct {
 	Security  SecurityConfig    `mapstructure:"security"`
 	RateLimit RateLimiterConfig `mapstructure:"rate_limit"`
 
+	// Context checkpointing
+	ContextCheckpoint ContextCheckpointConfig `mapstructure:"context_checkpoint"`
+
+	// Max token enforcement on context.append
+	ContextTokenBudget ContextTokenBudgetConfig `mapstructure:"context_token_budget"`
+
+	// Workspace broadcast rate limiting
+	BroadcastRateLimit BroadcastRateLimitConfig `mapstructure:"broadcast_rate_limit"`
+
+	// Tool execution quotas
+	ToolQuota ToolQuotaConfig `mapstructure:"tool_quota"`
+
+	// Tool output size caps
+	ToolOutputLimit ToolOutputLimitConfig `mapstructure:"tool_output_limit"`
+
+	// Retries of tool executions that fail with a retryable error
+	ToolRetry ToolRetryConfig `mapstructure:"tool_retry"`
+
+	// Metadata forwarded to tool executions
+	ToolMetadata ToolMetadataConfig `mapstructure:"tool_metadata"`
+
+	// Workflow export/import signing
+	WorkflowPortability WorkflowPortabilityConfig `mapstructure:"workflow_portability"`
+
+	// Shared dictionary for binary protocol compression
+	CompressionDictionary CompressionDictionaryConfig `mapstructure:"compression_dictionary"`
+
+	// Deduplication of identical read-only requests
+	RequestDedup RequestDedupConfig `mapstructure:"request_dedup"`
+
+	// Connection keepalive and stale detection
+	Keepalive KeepaliveConfig `mapstructure:"keepalive"`
+
+	// Tools shared read-only across tenants
+	SharedToolCatalog SharedToolCatalogConfig `mapstructure:"shared_tool_catalog"`
+
+	// Cleanup of subscriptions whose connection is gone
+	SubscriptionCleanup SubscriptionCleanupConfig `mapstructure:"subscription_cleanup"`
+
+	// Prefetch of common resources after initialize
+	ConnectionWarmup ConnectionWarmupConfig `mapstructure:"connection_warmup"`
+
+	// Workflow step count and depth limits
+	WorkflowLimits WorkflowLimitsConfig `mapstructure:"workflow_limits"`
+
+	// Re-subscription to an execution's notifications when it's resumed
+	WorkflowResubscribe WorkflowResubscribeConfig `mapstructure:"workflow_resubscribe"`
+
+	// Temporary scope elevation approved by tenant admins
+	ScopeElevation ScopeElevationConfig `mapstructure:"scope_elevation"`
+
+	// JSON Schemas context metadata must satisfy, by tenant ID
+	ContextMetadataSchemas map[string]interface{} `mapstructure:"context_metadata_schemas"`
+
+	// Input schemas for method params by method, added to or replacing the built-in ones
+	MethodSchemas map[string]interface{} `mapstructure:"method_schemas"`
+
 	// Version information
 	Version   string `mapstructure:"-"`
 	BuildTime string `mapstructure:"-"`
@@ -102,6 +210,16 @@ type Connection struct {
 	hub       *Server
 	mu        sync.RWMutex
 	state     *ConnectionState
+	keepalive connectionKeepalive
+
+	// Open context transactions by ID, guarded by mu
+	contextTxs map[string]*contextTransaction
+
+	// Resources prefetched after initialize, guarded by mu (nil without warm-up)
+	warmup *connectionWarmup
+
+	// Scopes granted temporarily on top of the claims, guarded by mu
+	scopeElevations []scopeElevation
 
 	// Connection lifecycle management
 	closeOnce sync.Once
@@ -109,7 +227,7 @@ type Connection struct {
 	wg        sync.WaitGroup
 }
 
-func NewServer(auth *auth.Service, metrics observability.MetricsClient, logger observability.Logger, config Config) *Server {
+func NewServer(authService *auth.Service, metrics observability.MetricsClient, logger observability.Logger, config Config) *Server {
 	// Create tracer function for tracing handler
 	var tracerFunc observability.StartSpanFunc = func(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, observability.Span) {
 		// This would use the global tracer or one passed in config
@@ -131,7 +249,7 @@ func NewServer(auth *auth.Service, metrics observability.MetricsClient, logger o
 	s := &Server{
 		connections:    make(map[string]*Connection),
 		handlers:       make(map[string]interface{}),
-		auth:           auth,
+		auth:           authService,
 		metrics:        metrics,
 		logger:         logger,
 		tracingHandler: NewTracingHandler(tracerFunc, metrics, logger),
@@ -165,8 +283,9 @@ func NewServer(auth *auth.Service, metrics observability.MetricsClient, logger o
 
 	// Initialize new managers (these would typically be injected as dependencies)
 	s.subscriptionManager = NewSubscriptionManager(logger, metrics)
+	s.subscriptionManager.SetReplayRetention(config.SubscriptionCleanup.ReplayRetention)
 	// Initialize workflow engine with nil services for now - will be set later
-	s.workflowEngine = NewWorkflowEngine(logger, metrics, nil, nil)
+	s.workflowEngine = s.newWorkflowEngine(nil, nil)
 	s.agentRegistry = NewAgentRegistry(logger, metrics)
 	s.taskManager = NewTaskManager(logger, metrics)
 	s.workspaceManager = NewWorkspaceManager(logger, metrics, s)
@@ -174,14 +293,81 @@ func NewServer(auth *auth.Service, metrics observability.MetricsClient, logger o
 	// Connect notification manager with subscription manager
 	s.notificationManager.SetSubscriptionManager(s.subscriptionManager)
 
-	// Set notification manager in workflow engine
-	s.workflowEngine.SetNotificationManager(s.notificationManager)
-
 	// Initialize conversation manager with a simple in-memory cache
 	// In production, this would be injected with a proper cache implementation
 	inMemoryCache := NewInMemoryCache()
 	s.conversationManager = NewConversationSessionManager(inMemoryCache, logger, metrics)
 
+	// Idempotency keys default to in-memory until a shared cache is configured
+	s.idempotencyCache = NewInMemoryCache()
+
+	// Tool executions are audited in memory until a shared store is configured
+	s.toolAuditStore = auth.NewInMemoryToolAuditStore(0, nil)
+
+	// Tool execution quotas are tracked per tenant and agent
+	s.toolQuota = NewToolQuotaLimiter(config.ToolQuota)
+	s.toolRetry = NewToolRetryPolicy(config.ToolRetry)
+
+	// Scope elevation requests wait in memory for an approver
+	s.scopeElevation = NewScopeElevationManager(config.ScopeElevation)
+
+	// Full results of truncated tool output are kept in memory until a shared cache is configured
+	s.toolOutputLimit = NewToolOutputLimiter(config.ToolOutputLimit, NewInMemoryCache())
+	s.toolMetadata = NewToolMetadataFilter(config.ToolMetadata)
+
+	// Clients acknowledge the compression dictionary when enabling binary compression
+	if dictionary, err := NewCompressionDictionary(config.CompressionDictionary); err != nil {
+		logger.Warn("Compression dictionary unavailable, using plain compression", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		s.compressionDictionary = dictionary
+	}
+
+	s.requestDedup = NewRequestDeduplicator(config.RequestDedup)
+
+	// An unknown strategy leaves appends unchecked rather than failing them all
+	if err := config.ContextTokenBudget.Validate(); err != nil {
+		logger.Warn("Context token budget disabled", map[string]interface{}{
+			"error": err.Error(),
+		})
+		s.config.ContextTokenBudget.Strategy = ""
+	}
+
+	// Tenants with an invalid schema accept any metadata rather than none
+	s.contextMetadataSchemas = NewContextMetadataSchemas()
+	for tenantID, schema := range config.ContextMetadataSchemas {
+		if err := s.contextMetadataSchemas.Set(tenantID, schema); err != nil {
+			logger.Warn("Ignoring context metadata schema", map[string]interface{}{
+				"tenant_id": tenantID,
+				"error":     err.Error(),
+			})
+		}
+	}
+
+	s.methodSchemas = NewMethodSchemaRegistry()
+	for method, schema := range config.MethodSchemas {
+		if err := s.methodSchemas.Register(method, schema); err != nil {
+			logger.Warn("Ignoring method input schema", map[string]interface{}{
+				"method": method,
+				"error":  err.Error(),
+			})
+		}
+	}
+
+	// Broadcast limits are tracked in memory until Redis is configured
+	s.broadcastLimiter = NewBroadcastRateLimiter(config.BroadcastRateLimit, logger, metrics)
+
+	// Initialize context checkpointing if enabled
+	if config.ContextCheckpoint.Enabled {
+		s.contextCheckpointer = NewContextCheckpointer(NewInMemoryCache(), config.ContextCheckpoint, logger)
+	}
+
+	// Sweep subscriptions left behind by connections that went away
+	if !config.SubscriptionCleanup.Disabled {
+		s.startSubscriptionCleanup(config.SubscriptionCleanup)
+	}
+
 	// Register handlers
 	s.RegisterHandlers()
 
@@ -206,6 +392,13 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			"remote_addr": r.RemoteAddr,
 			"path":        r.URL.Path,
 		})
+		var rateLimitErr *auth.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			s.metricsCollector.RecordConnectionFailure("rate_limited")
+			w.Header().Set("Retry-After", strconv.Itoa(rateLimitErr.RetryAfter()))
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
 		s.metricsCollector.RecordConnectionFailure("auth_failed")
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
@@ -442,11 +635,14 @@ func (s *Server) removeConnection(conn *Connection) {
 			s.notificationManager.UnregisterConnection(conn.ID)
 		}
 
-		// Unsubscribe from all subscriptions
+		// Keep subscriptions recording events for replay after reconnect
 		if s.subscriptionManager != nil {
-			_ = s.subscriptionManager.UnsubscribeAll(conn.ID)
+			s.subscriptionManager.DetachAll(conn.ID)
 		}
 
+		// Stop any task watches
+		s.stopConnectionTaskWatches(conn.ID)
+
 		// Clean up session key
 		if s.sessionManager != nil {
 			s.sessionManager.RemoveSessionKey(conn.ID)
@@ -496,15 +692,19 @@ func (s *Server) Broadcast(tenantID string, message []byte) {
 	}
 }
 
-// SendToAgent sends a message to all connections for a specific agent
-func (s *Server) SendToAgent(agentID string, message []byte) {
+// SendToAgent sends a message to all connections for a specific agent without
+// blocking. It returns an error if no connection accepted the message.
+func (s *Server) SendToAgent(agentID string, message []byte) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	found, delivered := false, false
 	for _, conn := range s.connections {
 		if conn.AgentID == agentID {
+			found = true
 			select {
 			case conn.send <- message:
+				delivered = true
 			default:
 				// Channel full, skip this connection
 				s.logger.Warn("Skipping message to connection - channel full", map[string]interface{}{
@@ -513,6 +713,15 @@ func (s *Server) SendToAgent(agentID string, message []byte) {
 			}
 		}
 	}
+
+	switch {
+	case delivered:
+		return nil
+	case found:
+		return ErrChannelFull
+	default:
+		return ErrAgentNotConnected
+	}
 }
 
 // SetToolRegistry sets the tool registry for the server
@@ -539,6 +748,16 @@ func (s *Server) SetContextManager(manager ContextManager) {
 	s.contextManager = manager
 }
 
+// SetToolAuditStore sets the store used for the tool execution audit trail
+func (s *Server) SetToolAuditStore(store auth.ToolAuditStore) {
+	s.toolAuditStore = store
+}
+
+// SetClientCertAuthenticator enables client certificate authentication for WebSocket connections
+func (s *Server) SetClientCertAuthenticator(authenticator *securitytls.ClientCertAuthenticator) {
+	s.clientCertAuth = authenticator
+}
+
 // SetEventBus sets the event bus for the server
 func (s *Server) SetEventBus(bus EventBus) {
 	s.eventBus = bus
@@ -549,13 +768,20 @@ func (s *Server) SetConversationSessionManager(manager *ConversationSessionManag
 	s.conversationManager = manager
 }
 
+// newWorkflowEngine creates a workflow engine wired to the server's notifications and limits
+func (s *Server) newWorkflowEngine(workflowService services.WorkflowService, taskService services.TaskService) *WorkflowEngine {
+	engine := NewWorkflowEngine(s.logger, s.metrics, workflowService, taskService)
+	engine.SetNotificationManager(s.notificationManager)
+	engine.SetLimits(s.config.WorkflowLimits)
+	return engine
+}
+
 // SetWorkflowService sets the workflow service for the server
 func (s *Server) SetWorkflowService(service services.WorkflowService) {
 	s.workflowService = service
 	// Update workflow engine if it exists
 	if s.workflowEngine != nil {
-		s.workflowEngine = NewWorkflowEngine(s.logger, s.metrics, service, s.taskService)
-		s.workflowEngine.SetNotificationManager(s.notificationManager)
+		s.workflowEngine = s.newWorkflowEngine(service, s.taskService)
 	}
 }
 
@@ -564,8 +790,7 @@ func (s *Server) SetTaskService(service services.TaskService) {
 	s.taskService = service
 	// Update workflow engine if it exists
 	if s.workflowEngine != nil {
-		s.workflowEngine = NewWorkflowEngine(s.logger, s.metrics, s.workflowService, service)
-		s.workflowEngine.SetNotificationManager(s.notificationManager)
+		s.workflowEngine = s.newWorkflowEngine(s.workflowService, service)
 	}
 }
 
@@ -580,6 +805,18 @@ func (s *Server) SetServices(taskService services.TaskService, workflowService s
 	s.documentService = documentService
 	s.conflictService = conflictService
 
+	// Share idempotency keys across server instances when a distributed cache is available
+	if cache != nil {
+		s.idempotencyCache = cache
+		if s.toolOutputLimit != nil {
+			s.toolOutputLimit.SetStore(cache)
+		}
+		if s.contextCheckpointer != nil {
+			s.contextCheckpointer.SetStore(cache)
+		}
+		s.useSharedBroadcastLimits(cache)
+	}
+
 	// Replace in-memory agent registry with database-backed one if repository is available
 	if agentRepo != nil && cache != nil {
 		s.agentRegistry = NewDBAgentRegistry(agentRepo, cache, s.logger, s.metrics)
@@ -590,8 +827,7 @@ func (s *Server) SetServices(taskService services.TaskService, workflowService s
 
 	// Reinitialize workflow engine with real services
 	if workflowService != nil && taskService != nil {
-		s.workflowEngine = NewWorkflowEngine(s.logger, s.metrics, workflowService, taskService)
-		s.workflowEngine.SetNotificationManager(s.notificationManager)
+		s.workflowEngine = s.newWorkflowEngine(workflowService, taskService)
 	}
 }
 
@@ -620,11 +856,62 @@ func (s *Server) Close() error {
 		s.connectionPool.Stop()
 	}
 
+	// Stop the orphaned subscription sweep
+	if s.subscriptionSweeper != nil {
+		s.subscriptionSweeper.Stop()
+	}
+
 	return nil
 }
 
 // authenticateRequest validates the request and returns auth claims
 func (s *Server) authenticateRequest(r *http.Request) (*auth.Claims, error) {
+	if s.clientCertAuth == nil {
+		return s.authenticateToken(r)
+	}
+
+	identity, err := s.clientCertAuth.Authenticate(r.TLS)
+	if err != nil && !errors.Is(err, securitytls.ErrNoClientCert) {
+		s.logger.Warn("Client certificate validation failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	if s.clientCertAuth.Mode() == securitytls.ClientCertModeAlternative {
+		// A verified certificate is sufficient; otherwise fall back to API key/JWT
+		if identity != nil {
+			return &auth.Claims{
+				RegisteredClaims: jwt.RegisteredClaims{
+					Subject: identity.AgentID,
+				},
+				TenantID: identity.TenantID,
+				UserID:   identity.AgentID,
+			}, nil
+		}
+		return s.authenticateToken(r)
+	}
+
+	// Additional factor: both the certificate and the token must authenticate the same tenant
+	if identity == nil {
+		return nil, securitytls.ErrNoClientCert
+	}
+	claims, err := s.authenticateToken(r)
+	if err != nil {
+		return nil, err
+	}
+	if claims.TenantID != identity.TenantID {
+		s.logger.Warn("Client certificate tenant does not match token tenant", map[string]interface{}{
+			"cert_tenant_id":  identity.TenantID,
+			"token_tenant_id": claims.TenantID,
+		})
+		return nil, fmt.Errorf("%w: tenant does not match credentials", securitytls.ErrInvalidClientCert)
+	}
+	return claims, nil
+}
+
+// authenticateToken validates the request's API key or JWT and returns auth claims
+func (s *Server) authenticateToken(r *http.Request) (*auth.Claims, error) {
 	// Check if auth service is available
 	if s.auth == nil {
 		s.logger.Error("Auth service not initialized", nil)
@@ -693,8 +980,9 @@ func (s *Server) authenticateRequest(r *http.Request) (*auth.Claims, error) {
 		// If JWT validation fails, fall through to try as API key
 	}
 
-	// Try as API key
-	user, err := s.auth.ValidateAPIKey(r.Context(), token)
+	// Try as API key, from the client's address for keys bound to an allowlist
+	ctx := context.WithValue(r.Context(), auth.ContextKeyIPAddress, s.getClientIP(r))
+	user, err := s.auth.ValidateAPIKey(ctx, token)
 	if err != nil {
 		keyPrefix := token
 		if len(keyPrefix) > 8 {
@@ -712,9 +1000,10 @@ func (s *Server) authenticateRequest(r *http.Request) (*auth.Claims, error) {
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject: user.ID.String(),
 		},
-		TenantID: user.TenantID.String(),
-		UserID:   user.ID.String(),
-		Scopes:   user.Scopes,
+		TenantID:  user.TenantID.String(),
+		UserID:    user.ID.String(),
+		Scopes:    user.Scopes,
+		KeyPrefix: apiKeyPrefix(user),
 	}
 
 	// If TenantID is empty, check X-Tenant-ID header (for e2e tests)
@@ -754,3 +1043,21 @@ func (s *Server) getClientIP(r *http.Request) string {
 
 	return r.RemoteAddr
 }
+
+// useSharedBroadcastLimits switches broadcast rate limiting to Redis when the shared cache is Redis-backed
+func (s *Server) useSharedBroadcastLimits(c cache.Cache) {
+	if s.broadcastLimiter == nil {
+		return
+	}
+
+	switch rc := c.(type) {
+	case *cache.RedisCache:
+		s.broadcastLimiter.SetRedisClient(rc.GetClient())
+	case *cache.RedisClusterCache:
+		s.broadcastLimiter.SetRedisClient(rc.GetClient())
+	default:
+		return
+	}
+
+	s.logger.Info("Using Redis sliding window for workspace broadcast rate limits", nil)
+}
@@ -45,7 +45,7 @@ type ExtendedServer struct {
 	manifestRepo repository.AgentManifestRepository
 
 	// Enhanced components
-	rateLimiter    interface{} // Can be *auth.RateLimiter or *AgentRateLimiter
+	rateLimiter    interface{} // Can be *auth.AttemptRateLimiter or *AgentRateLimiter
 	circuitBreaker interface{} // Can be *resilience.CircuitBreaker or *AgentCircuitBreaker
 	messageBroker  *AgentMessageBroker
 
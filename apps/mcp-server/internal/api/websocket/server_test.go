@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/coder/websocket"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -331,3 +332,50 @@ func TestIPRateLimiter(t *testing.T) {
 	time.Sleep(600 * time.Millisecond)
 	assert.True(t, limiter.Allow(ip))
 }
+
+func TestCheckMethodPermission(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+
+	tests := []struct {
+		name    string
+		scopes  []string
+		method  string
+		wantErr bool
+	}{
+		{name: "read scope calls read-only methods", scopes: []string{"read"}, method: "context.get"},
+		{name: "read scope can't call write methods", scopes: []string{"read"}, method: "context.update", wantErr: true},
+		{name: "write scope calls write methods", scopes: []string{"write"}, method: "context.update"},
+		{name: "admin scope calls write methods", scopes: []string{"admin"}, method: "context.update"},
+		{name: "write scope can't call admin methods", scopes: []string{"write"}, method: "system.diagnostics", wantErr: true},
+		{name: "admin scope calls admin methods", scopes: []string{"admin"}, method: "system.diagnostics"},
+		{name: "wildcard scope calls admin methods", scopes: []string{"*"}, method: "agent.register"},
+		{name: "any scope requests elevation", scopes: []string{"read"}, method: "scope.elevation.request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := server.checkMethodPermission(context.Background(), &auth.Claims{Scopes: tt.scopes}, tt.method)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("tenant scope implications apply", func(t *testing.T) {
+		tenantID := uuid.New()
+		config := auth.DefaultConfig()
+		config.TenantScopeHierarchies = map[uuid.UUID]auth.ScopeHierarchy{
+			tenantID: {"deployer": {"write"}},
+		}
+		server := NewServer(auth.NewService(config, nil, nil, NewTestLogger()), nil, NewTestLogger(), Config{})
+
+		claims := &auth.Claims{TenantID: tenantID.String(), Scopes: []string{"deployer"}}
+		assert.NoError(t, server.checkMethodPermission(context.Background(), claims, "context.update"))
+		assert.Error(t, server.checkMethodPermission(context.Background(), claims, "system.diagnostics"))
+
+		otherTenant := &auth.Claims{TenantID: uuid.New().String(), Scopes: []string{"deployer"}}
+		assert.Error(t, server.checkMethodPermission(context.Background(), otherTenant, "context.update"))
+	})
+}
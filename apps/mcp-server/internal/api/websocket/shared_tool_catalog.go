@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"context"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// SharedToolCatalogConfig configures the catalog of tools shared across tenants
+type SharedToolCatalogConfig struct {
+	// TenantID is the namespace whose tools every tenant can list and execute
+	// but not modify. Empty disables the shared catalog.
+	TenantID string `mapstructure:"tenant_id"`
+}
+
+// listToolsWithSharedCatalog returns a tenant's tools merged with the shared
+// catalog, and the IDs of the tools that came from the shared catalog. A
+// tenant's own tools shadow shared tools of the same name.
+func (s *Server) listToolsWithSharedCatalog(ctx context.Context, tenantID string) ([]*models.DynamicTool, map[string]bool, error) {
+	tools, err := s.restAPIClient.ListTools(ctx, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedTenantID := s.config.SharedToolCatalog.TenantID
+	if sharedTenantID == "" || sharedTenantID == tenantID {
+		return tools, nil, nil
+	}
+
+	sharedTools, err := s.restAPIClient.ListTools(ctx, sharedTenantID)
+	if err != nil {
+		// Tenants keep their own tools when the shared catalog is unavailable
+		s.logger.Warn("Failed to list shared tool catalog", map[string]interface{}{
+			"tenant_id":        tenantID,
+			"shared_tenant_id": sharedTenantID,
+			"error":            err.Error(),
+		})
+		return tools, nil, nil
+	}
+
+	names := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		names[tool.ToolName] = true
+	}
+
+	merged := make([]*models.DynamicTool, len(tools), len(tools)+len(sharedTools))
+	copy(merged, tools)
+	shared := make(map[string]bool, len(sharedTools))
+	for _, tool := range sharedTools {
+		if names[tool.ToolName] {
+			continue
+		}
+		merged = append(merged, tool)
+		shared[tool.ID] = true
+	}
+	return merged, shared, nil
+}
+
+// toolExecutionTenant returns the tenant a tool is executed as: shared tools
+// execute in the shared catalog's namespace, everything else in the caller's
+func (s *Server) toolExecutionTenant(tenantID, toolID string, shared map[string]bool) string {
+	if shared[toolID] {
+		return s.config.SharedToolCatalog.TenantID
+	}
+	return tenantID
+}
@@ -0,0 +1,112 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// executingToolCatalog is a tool catalog that records the tenant tools are executed as
+type executingToolCatalog struct {
+	stubToolCatalog
+	executedAs map[string]string // Tenant each tool ID was executed as
+}
+
+func (c *executingToolCatalog) ExecuteTool(ctx context.Context, tenantID, toolID, action string, params map[string]interface{}) (*models.ToolExecutionResponse, error) {
+	c.executedAs[toolID] = tenantID
+	return &models.ToolExecutionResponse{Success: true, StatusCode: 200}, nil
+}
+
+func newSharedCatalogTestServer(t *testing.T) (*Server, *executingToolCatalog, string) {
+	t.Helper()
+
+	sharedTenant := "shared-catalog"
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		SharedToolCatalog: SharedToolCatalogConfig{TenantID: sharedTenant},
+	})
+	catalog := &executingToolCatalog{
+		stubToolCatalog: stubToolCatalog{tools: map[string][]*models.DynamicTool{
+			sharedTenant: {
+				{ID: "11111111-1111-1111-1111-111111111111", ToolName: "github"},
+				{ID: "22222222-2222-2222-2222-222222222222", ToolName: "jira"},
+			},
+			"tenant-a": {
+				{ID: "33333333-3333-3333-3333-333333333333", ToolName: "jira"},
+			},
+		}},
+		executedAs: make(map[string]string),
+	}
+	server.SetRESTClient(catalog)
+	return server, catalog, sharedTenant
+}
+
+func listToolsFor(t *testing.T, server *Server, tenantID string) map[string]map[string]interface{} {
+	t.Helper()
+
+	conn := NewConnection(uuid.New().String(), nil, server)
+	conn.TenantID = tenantID
+	conn.AgentID = "agent-1"
+
+	result, err := server.handleToolList(context.Background(), conn, nil)
+	require.NoError(t, err)
+
+	tools := make(map[string]map[string]interface{})
+	for _, tool := range result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+		tools[tool["name"].(string)] = tool
+	}
+	return tools
+}
+
+func TestSharedToolCatalog(t *testing.T) {
+	t.Run("shared tools are listed for every tenant", func(t *testing.T) {
+		server, _, _ := newSharedCatalogTestServer(t)
+
+		for _, tenantID := range []string{"tenant-a", "tenant-b"} {
+			tools := listToolsFor(t, server, tenantID)
+			require.Contains(t, tools, "github", tenantID)
+			assert.Equal(t, true, tools["github"]["shared"], tenantID)
+			assert.Equal(t, true, tools["github"]["read_only"], tenantID)
+		}
+
+		tools := listToolsFor(t, server, "tenant-b")
+		assert.Len(t, tools, 2)
+		assert.Equal(t, "22222222-2222-2222-2222-222222222222", tools["jira"]["id"])
+	})
+
+	t.Run("tenant tools shadow shared tools of the same name", func(t *testing.T) {
+		server, _, _ := newSharedCatalogTestServer(t)
+
+		tools := listToolsFor(t, server, "tenant-a")
+		assert.Len(t, tools, 2)
+		assert.Equal(t, "33333333-3333-3333-3333-333333333333", tools["jira"]["id"])
+		assert.NotContains(t, tools["jira"], "shared")
+		assert.NotContains(t, tools["jira"], "read_only")
+	})
+
+	t.Run("shared tools execute in the shared namespace", func(t *testing.T) {
+		server, catalog, sharedTenant := newSharedCatalogTestServer(t)
+		conn := NewConnection("conn-1", nil, server)
+		conn.TenantID = "tenant-a"
+		conn.AgentID = "agent-1"
+
+		for _, toolID := range []string{"github", "jira", "22222222-2222-2222-2222-222222222222"} {
+			params, err := json.Marshal(map[string]interface{}{"tool_id": toolID, "action": "list_issues"})
+			require.NoError(t, err)
+			_, err = server.handleToolExecute(context.Background(), conn, params)
+			require.NoError(t, err, toolID)
+		}
+
+		assert.Equal(t, sharedTenant, catalog.executedAs["11111111-1111-1111-1111-111111111111"])
+		// jira resolves to the tenant's own tool
+		assert.Equal(t, "tenant-a", catalog.executedAs["33333333-3333-3333-3333-333333333333"])
+		// The shadowed shared tool stays with the tenant when addressed by ID
+		assert.Equal(t, "tenant-a", catalog.executedAs["22222222-2222-2222-2222-222222222222"])
+	})
+}
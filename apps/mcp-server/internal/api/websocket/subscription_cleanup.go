@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultSubscriptionCleanupInterval is how often orphaned subscriptions are swept
+const DefaultSubscriptionCleanupInterval = time.Minute
+
+// SubscriptionCleanupConfig configures the removal of subscriptions whose connection is gone.
+// Closing a connection detaches its subscriptions; they are removed once the replay
+// retention elapses. The periodic sweep catches connections that went away without
+// being closed cleanly and removes expired subscriptions when nothing else does.
+type SubscriptionCleanupConfig struct {
+	Disabled        bool          `mapstructure:"disabled"`         // Disable the periodic sweep
+	Interval        time.Duration `mapstructure:"interval"`         // How often to sweep; defaults to 1 minute
+	ReplayRetention time.Duration `mapstructure:"replay_retention"` // How long subscriptions of a closed connection are kept for replay; defaults to 5 minutes, negative removes them on close
+}
+
+// subscriptionSweeper runs the periodic orphaned subscription cleanup
+type subscriptionSweeper struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// Stop ends the periodic sweep
+func (sw *subscriptionSweeper) Stop() {
+	sw.stopOnce.Do(func() {
+		close(sw.stop)
+	})
+}
+
+// SetReplayRetention sets how long subscriptions of a closed connection are kept
+// for replay. Zero uses the default and a negative value disables retention.
+func (sm *SubscriptionManager) SetReplayRetention(retention time.Duration) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	switch {
+	case retention == 0:
+		sm.replayRetention = DefaultSubscriptionReplayRetention
+	case retention < 0:
+		sm.replayRetention = 0
+	default:
+		sm.replayRetention = retention
+	}
+}
+
+// ReapOrphaned detaches the subscriptions of connections that are no longer live,
+// so events are no longer dispatched to them, and removes detached subscriptions
+// whose replay retention has elapsed. It returns the number of subscriptions
+// detached and removed.
+func (sm *SubscriptionManager) ReapOrphaned(isLive func(connectionID string) bool) (int, int) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	detached := 0
+	for connectionID, subscriptionIDs := range sm.connections {
+		if isLive(connectionID) {
+			continue
+		}
+		for _, subID := range subscriptionIDs {
+			if sub, ok := sm.subscriptions[subID]; ok {
+				sub.ConnectionID = ""
+				sm.detached[subID] = now
+				detached++
+			}
+		}
+		delete(sm.connections, connectionID)
+	}
+
+	return detached, sm.pruneDetached(now)
+}
+
+// unregisterDeadConnections removes connections that are no longer live and
+// returns how many were removed
+func (nm *NotificationManager) unregisterDeadConnections(isLive func(connectionID string) bool) int {
+	nm.mu.RLock()
+	var dead []string
+	for connID := range nm.connections {
+		if !isLive(connID) {
+			dead = append(dead, connID)
+		}
+	}
+	nm.mu.RUnlock()
+
+	for _, connID := range dead {
+		nm.UnregisterConnection(connID)
+	}
+	return len(dead)
+}
+
+// isConnectionLive reports whether a connection is registered and not closed
+func (s *Server) isConnectionLive(connectionID string) bool {
+	s.mu.RLock()
+	conn, ok := s.connections[connectionID]
+	s.mu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	select {
+	case <-conn.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// reapOrphanedSubscriptions removes subscriptions and notification registrations
+// left behind by connections that are gone
+func (s *Server) reapOrphanedSubscriptions() {
+	detached, removed := s.subscriptionManager.ReapOrphaned(s.isConnectionLive)
+	unregistered := s.notificationManager.unregisterDeadConnections(s.isConnectionLive)
+
+	if detached == 0 && removed == 0 && unregistered == 0 {
+		return
+	}
+
+	s.metrics.IncrementCounter("subscriptions_orphaned", float64(detached))
+	s.logger.Info("Reaped orphaned subscriptions", map[string]interface{}{
+		"detached":                 detached,
+		"removed":                  removed,
+		"unregistered_connections": unregistered,
+	})
+}
+
+// startSubscriptionCleanup starts the periodic orphaned subscription sweep
+func (s *Server) startSubscriptionCleanup(config SubscriptionCleanupConfig) {
+	interval := config.Interval
+	if interval <= 0 {
+		interval = DefaultSubscriptionCleanupInterval
+	}
+
+	sweeper := &subscriptionSweeper{stop: make(chan struct{})}
+	s.subscriptionSweeper = sweeper
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-sweeper.stop:
+				return
+			case <-ticker.C:
+				s.reapOrphanedSubscriptions()
+			}
+		}
+	}()
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func subscribeCleanupTestClient(t *testing.T, server *Server, id string, resources ...string) *Connection {
+	t.Helper()
+
+	conn := NewConnection(id, nil, server)
+	server.addConnection(conn)
+	for _, resource := range resources {
+		params, err := json.Marshal(map[string]interface{}{"resource": resource})
+		require.NoError(t, err)
+		_, err = server.handleSubscribe(context.Background(), conn, params)
+		require.NoError(t, err)
+	}
+	return conn
+}
+
+// dropCleanupTestClient loses a connection without running the close path
+func dropCleanupTestClient(server *Server, conn *Connection) {
+	server.mu.Lock()
+	delete(server.connections, conn.ID)
+	server.mu.Unlock()
+}
+
+func TestReapOrphanedSubscriptionsAfterAbruptDisconnect(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		SubscriptionCleanup: SubscriptionCleanupConfig{Disabled: true},
+	})
+	defer func() { _ = server.Close() }()
+	ctx := context.Background()
+
+	dropped := subscribeCleanupTestClient(t, server, "conn-dropped", "workflow.wf-1", "workspace.ws-1")
+	live := subscribeCleanupTestClient(t, server, "conn-live", "workflow.wf-1")
+	server.notificationManager.Subscribe(dropped.ID, "workflow.wf-1")
+
+	dropCleanupTestClient(server, dropped)
+	server.reapOrphanedSubscriptions()
+
+	// Dispatch no longer targets the dropped connection
+	server.notificationManager.BroadcastNotification(ctx, "workflow.wf-1", "workflow.step_completed", map[string]interface{}{"n": 1})
+	assert.Empty(t, receivedEventIDs(t, dropped))
+	assert.Len(t, receivedEventIDs(t, live), 1)
+
+	subscriptions := server.subscriptionManager.GetSubscriptions("workflow.wf-1")
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, live.ID, subscriptions[0].ConnectionID)
+	assert.Empty(t, server.subscriptionManager.GetConnectionSubscriptions(dropped.ID))
+	assert.Empty(t, server.subscriptionManager.GetSubscriptions("workspace.ws-1"))
+
+	// Once the replay retention elapses they are removed entirely
+	server.subscriptionManager.mu.Lock()
+	for subID := range server.subscriptionManager.detached {
+		server.subscriptionManager.detached[subID] = time.Now().Add(-2 * DefaultSubscriptionReplayRetention)
+	}
+	server.subscriptionManager.mu.Unlock()
+	server.reapOrphanedSubscriptions()
+
+	server.subscriptionManager.mu.RLock()
+	assert.Len(t, server.subscriptionManager.subscriptions, 1)
+	assert.Empty(t, server.subscriptionManager.detached)
+	server.subscriptionManager.mu.RUnlock()
+	assert.Len(t, server.subscriptionManager.GetConnectionSubscriptions(live.ID), 1)
+}
+
+func TestSubscriptionsRemovedOnCloseWithoutReplayRetention(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		SubscriptionCleanup: SubscriptionCleanupConfig{Disabled: true, ReplayRetention: -1},
+	})
+	defer func() { _ = server.Close() }()
+
+	conn := subscribeCleanupTestClient(t, server, "conn-1", "workflow.wf-1", "workspace.ws-1")
+	require.Len(t, server.subscriptionManager.GetConnectionSubscriptions(conn.ID), 2)
+
+	require.NoError(t, conn.Close())
+
+	server.subscriptionManager.mu.RLock()
+	defer server.subscriptionManager.mu.RUnlock()
+	assert.Empty(t, server.subscriptionManager.subscriptions)
+	assert.Empty(t, server.subscriptionManager.resources["workflow.wf-1"])
+	assert.Empty(t, server.subscriptionManager.detached)
+}
+
+func TestPeriodicSubscriptionCleanup(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		SubscriptionCleanup: SubscriptionCleanupConfig{Interval: 10 * time.Millisecond, ReplayRetention: -1},
+	})
+	defer func() { _ = server.Close() }()
+
+	conn := subscribeCleanupTestClient(t, server, "conn-1", "workflow.wf-1")
+	dropCleanupTestClient(server, conn)
+
+	assert.Eventually(t, func() bool {
+		server.subscriptionManager.mu.RLock()
+		defer server.subscriptionManager.mu.RUnlock()
+		return len(server.subscriptionManager.subscriptions) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -17,6 +17,13 @@ type SubscriptionManager struct {
 	mu            sync.RWMutex
 	logger        observability.Logger
 	metrics       observability.MetricsClient
+
+	// Event replay
+	eventLogs       map[string]*subscriptionEventLog // subscription ID -> recent events
+	detached        map[string]time.Time             // subscription ID -> disconnect time
+	lastEventID     uint64
+	replayBuffer    int
+	replayRetention time.Duration
 }
 
 // NewSubscriptionManager creates a new subscription manager
@@ -27,6 +34,11 @@ func NewSubscriptionManager(logger observability.Logger, metrics observability.M
 		resources:     make(map[string][]string),
 		logger:        logger,
 		metrics:       metrics,
+
+		eventLogs:       make(map[string]*subscriptionEventLog),
+		detached:        make(map[string]time.Time),
+		replayBuffer:    DefaultSubscriptionReplayBuffer,
+		replayRetention: DefaultSubscriptionReplayRetention,
 	}
 }
 
@@ -88,6 +100,7 @@ func (sm *SubscriptionManager) Unsubscribe(connectionID, subscriptionID string)
 
 	// Remove from all maps
 	delete(sm.subscriptions, subscriptionID)
+	sm.forgetEvents(subscriptionID)
 
 	// Remove from connection map
 	if subs := sm.connections[connectionID]; subs != nil {
@@ -116,6 +129,7 @@ func (sm *SubscriptionManager) UnsubscribeAll(connectionID string) error {
 	for _, subID := range subscriptionIDs {
 		if subscription, ok := sm.subscriptions[subID]; ok {
 			delete(sm.subscriptions, subID)
+			sm.forgetEvents(subID)
 			if subs := sm.resources[subscription.Resource]; subs != nil {
 				sm.resources[subscription.Resource] = sm.removeFromSlice(subs, subID)
 			}
@@ -140,7 +154,7 @@ func (sm *SubscriptionManager) GetSubscriptions(resource string) []*Subscription
 
 	var subscriptions []*Subscription
 	for _, id := range subscriptionIDs {
-		if sub, ok := sm.subscriptions[id]; ok {
+		if sub, ok := sm.subscriptions[id]; ok && sub.ConnectionID != "" {
 			subscriptions = append(subscriptions, sub)
 		}
 	}
@@ -187,6 +201,7 @@ func (sm *SubscriptionManager) UnsubscribeFromWorkspace(connectionID, workspaceI
 		for _, subID := range subscriptionIDs {
 			if sub, ok := sm.subscriptions[subID]; ok && sub.Resource == resource {
 				delete(sm.subscriptions, subID)
+				sm.forgetEvents(subID)
 				if subs := sm.resources[resource]; subs != nil {
 					sm.resources[resource] = sm.removeFromSlice(subs, subID)
 				}
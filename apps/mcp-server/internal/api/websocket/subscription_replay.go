@@ -0,0 +1,223 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+const (
+	// DefaultSubscriptionReplayBuffer is the number of recent events kept per subscription
+	DefaultSubscriptionReplayBuffer = 1000
+	// DefaultSubscriptionReplayRetention is how long subscriptions of a disconnected
+	// connection keep recording events for replay
+	DefaultSubscriptionReplayRetention = 5 * time.Minute
+)
+
+// SubscriptionEvent is an event recorded for replay. Its ID is the cursor clients
+// pass to subscription.replay and is sent as the notification message ID.
+type SubscriptionEvent struct {
+	ID        uint64      `json:"event_id"`
+	Method    string      `json:"method"`
+	Params    interface{} `json:"params"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// subscriptionEventLog is a bounded, ordered log of a subscription's recent events
+type subscriptionEventLog struct {
+	events      []SubscriptionEvent
+	droppedThru uint64 // ID of the newest event evicted from the log
+}
+
+func (l *subscriptionEventLog) append(event SubscriptionEvent, limit int) {
+	l.events = append(l.events, event)
+	if overflow := len(l.events) - limit; overflow > 0 {
+		l.droppedThru = l.events[overflow-1].ID
+		l.events = append([]SubscriptionEvent(nil), l.events[overflow:]...)
+	}
+}
+
+// since returns the events after the cursor, and whether events after the cursor
+// were evicted before they could be replayed
+func (l *subscriptionEventLog) since(cursor uint64) ([]SubscriptionEvent, bool) {
+	var events []SubscriptionEvent
+	for _, event := range l.events {
+		if event.ID > cursor {
+			events = append(events, event)
+		}
+	}
+	return events, cursor < l.droppedThru
+}
+
+// RecordEvent records an event for every subscription to a resource, including
+// subscriptions of disconnected connections, and returns the event ID and the
+// connections it should be delivered to live
+func (sm *SubscriptionManager) RecordEvent(resource, method string, params interface{}) (uint64, []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	subscriptionIDs := sm.resources[resource]
+	if len(subscriptionIDs) == 0 {
+		return 0, nil
+	}
+
+	sm.lastEventID++
+	event := SubscriptionEvent{
+		ID:        sm.lastEventID,
+		Method:    method,
+		Params:    params,
+		Timestamp: time.Now(),
+	}
+
+	var connectionIDs []string
+	for _, subID := range subscriptionIDs {
+		sub, ok := sm.subscriptions[subID]
+		if !ok {
+			continue
+		}
+
+		log, ok := sm.eventLogs[subID]
+		if !ok {
+			log = &subscriptionEventLog{}
+			sm.eventLogs[subID] = log
+		}
+		log.append(event, sm.replayBuffer)
+
+		if sub.ConnectionID != "" {
+			connectionIDs = append(connectionIDs, sub.ConnectionID)
+		}
+	}
+
+	return event.ID, connectionIDs
+}
+
+// DetachAll keeps a disconnected connection's subscriptions recording events so
+// they can be replayed after reconnecting. Subscriptions not resumed within the
+// retention period are removed.
+func (sm *SubscriptionManager) DetachAll(connectionID string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	for _, subID := range sm.connections[connectionID] {
+		if sub, ok := sm.subscriptions[subID]; ok {
+			sub.ConnectionID = ""
+			sm.detached[subID] = now
+		}
+	}
+	delete(sm.connections, connectionID)
+
+	sm.pruneDetached(now)
+}
+
+// Replay resumes a subscription on a connection, delivering the events recorded
+// after the cursor in order. Live delivery to the connection starts only once the
+// missed events have been delivered. The returned flag reports whether some missed
+// events were evicted from the log and cannot be replayed.
+func (sm *SubscriptionManager) Replay(connectionID, subscriptionID string, cursor uint64, deliver func(SubscriptionEvent) error) (int, bool, error) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sm.pruneDetached(time.Now())
+
+	sub, ok := sm.subscriptions[subscriptionID]
+	if !ok {
+		return 0, false, fmt.Errorf("subscription not found: %s", subscriptionID)
+	}
+	if sub.ConnectionID != "" && sub.ConnectionID != connectionID {
+		return 0, false, fmt.Errorf("subscription not owned by connection")
+	}
+
+	var events []SubscriptionEvent
+	var gap bool
+	if log, ok := sm.eventLogs[subscriptionID]; ok {
+		events, gap = log.since(cursor)
+	}
+
+	for i, event := range events {
+		if err := deliver(event); err != nil {
+			return i, gap, fmt.Errorf("failed to replay event %d: %w", event.ID, err)
+		}
+	}
+
+	if sub.ConnectionID == "" {
+		sub.ConnectionID = connectionID
+		delete(sm.detached, subscriptionID)
+		sm.connections[connectionID] = append(sm.connections[connectionID], subscriptionID)
+	}
+
+	sm.metrics.IncrementCounter("subscription_events_replayed", float64(len(events)))
+	return len(events), gap, nil
+}
+
+// pruneDetached removes detached subscriptions older than the retention period
+// and returns how many were removed
+func (sm *SubscriptionManager) pruneDetached(now time.Time) int {
+	removed := 0
+	for subID, detachedAt := range sm.detached {
+		if now.Sub(detachedAt) < sm.replayRetention {
+			continue
+		}
+		if sub, ok := sm.subscriptions[subID]; ok {
+			delete(sm.subscriptions, subID)
+			if subs := sm.resources[sub.Resource]; subs != nil {
+				sm.resources[sub.Resource] = sm.removeFromSlice(subs, subID)
+			}
+			sm.metrics.IncrementCounter("subscriptions_removed", 1)
+			removed++
+		}
+		sm.forgetEvents(subID)
+	}
+	return removed
+}
+
+// forgetEvents drops a removed subscription's replay state
+func (sm *SubscriptionManager) forgetEvents(subscriptionID string) {
+	delete(sm.eventLogs, subscriptionID)
+	delete(sm.detached, subscriptionID)
+}
+
+// subscriptionEventMessage builds the notification for a recorded event
+func subscriptionEventMessage(event SubscriptionEvent) *ws.Message {
+	return &ws.Message{
+		ID:     strconv.FormatUint(event.ID, 10),
+		Type:   ws.MessageTypeNotification,
+		Method: event.Method,
+		Params: event.Params,
+	}
+}
+
+// handleSubscriptionReplay resumes a subscription after reconnecting, replaying the
+// events missed since the client's cursor before live delivery resumes
+func (s *Server) handleSubscriptionReplay(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var replayParams struct {
+		SubscriptionID string `json:"subscription_id"`
+		Cursor         uint64 `json:"cursor"`
+	}
+
+	if err := json.Unmarshal(params, &replayParams); err != nil {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Invalid params", err.Error())
+	}
+	if replayParams.SubscriptionID == "" {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "subscription_id is required", nil)
+	}
+
+	replayed, gap, err := s.subscriptionManager.Replay(conn.ID, replayParams.SubscriptionID, replayParams.Cursor,
+		func(event SubscriptionEvent) error {
+			return conn.SendMessage(subscriptionEventMessage(event))
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"subscription_id": replayParams.SubscriptionID,
+		"replayed":        replayed,
+		"events_lost":     gap,
+		"status":          "active",
+	}, nil
+}
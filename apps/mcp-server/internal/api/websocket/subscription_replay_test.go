@@ -0,0 +1,116 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func connectReplayTestClient(server *Server, id string) *Connection {
+	conn := NewConnection(id, nil, server)
+	server.notificationManager.RegisterConnection(conn)
+	return conn
+}
+
+func disconnectReplayTestClient(server *Server, conn *Connection) {
+	server.notificationManager.UnregisterConnection(conn.ID)
+	server.subscriptionManager.DetachAll(conn.ID)
+}
+
+// receivedEventIDs drains the notifications queued for a connection
+func receivedEventIDs(t *testing.T, conn *Connection) []string {
+	t.Helper()
+
+	var ids []string
+	for {
+		select {
+		case data := <-conn.send:
+			var msg ws.Message
+			require.NoError(t, json.Unmarshal(data, &msg))
+			require.Equal(t, ws.MessageTypeNotification, msg.Type)
+			ids = append(ids, msg.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestSubscriptionReplayAfterReconnect(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	ctx := context.Background()
+	publish := func(n int) {
+		server.notificationManager.BroadcastNotification(ctx, "workflow.wf-1", "workflow.step_completed", map[string]interface{}{"n": n})
+	}
+
+	first := connectReplayTestClient(server, "conn-1")
+	result, err := server.handleSubscribe(ctx, first, json.RawMessage(`{"resource": "workflow.wf-1"}`))
+	require.NoError(t, err)
+	subscriptionID := result.(map[string]interface{})["subscription_id"].(string)
+
+	publish(1)
+	assert.Equal(t, []string{"1"}, receivedEventIDs(t, first))
+
+	// Events published while disconnected are recorded but not delivered
+	disconnectReplayTestClient(server, first)
+	publish(2)
+	publish(3)
+	assert.Empty(t, receivedEventIDs(t, first))
+
+	second := connectReplayTestClient(server, "conn-2")
+	params, err := json.Marshal(map[string]interface{}{"subscription_id": subscriptionID, "cursor": 1})
+	require.NoError(t, err)
+	result, err = server.handleSubscriptionReplay(ctx, second, params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.(map[string]interface{})["replayed"])
+	assert.Equal(t, false, result.(map[string]interface{})["events_lost"])
+
+	// Live delivery resumes after the missed events, each delivered exactly once
+	publish(4)
+	assert.Equal(t, []string{"2", "3", "4"}, receivedEventIDs(t, second))
+
+	subscriptions := server.subscriptionManager.ListSubscriptions(second.ID)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, subscriptionID, subscriptions[0]["id"])
+
+	// Another connection can't take over an active subscription
+	_, err = server.handleSubscriptionReplay(ctx, connectReplayTestClient(server, "conn-3"), params)
+	assert.Error(t, err)
+}
+
+func TestSubscriptionReplayBounds(t *testing.T) {
+	sm := NewSubscriptionManager(NewTestLogger(), observability.NewNoOpMetricsClient())
+	sm.replayBuffer = 2
+
+	subscriptionID, err := sm.Subscribe("conn-1", "workspace.ws-1", nil)
+	require.NoError(t, err)
+	sm.DetachAll("conn-1")
+
+	for i := 0; i < 4; i++ {
+		sm.RecordEvent("workspace.ws-1", "workspace.updated", i)
+	}
+
+	var replayed []uint64
+	n, lost, err := sm.Replay("conn-2", subscriptionID, 0, func(event SubscriptionEvent) error {
+		replayed = append(replayed, event.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, lost, "events 1 and 2 were evicted")
+	assert.Equal(t, []uint64{3, 4}, replayed)
+
+	// Detached subscriptions are removed after the retention period
+	sm.DetachAll("conn-2")
+	sm.detached[subscriptionID] = time.Now().Add(-2 * DefaultSubscriptionReplayRetention)
+	_, _, err = sm.Replay("conn-3", subscriptionID, 0, func(SubscriptionEvent) error { return nil })
+	assert.Error(t, err)
+	assert.Empty(t, sm.GetSubscriptions("workspace.ws-1"))
+}
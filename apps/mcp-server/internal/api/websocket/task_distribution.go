@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultAgentCapacity is the number of concurrent tasks assumed for agents that
+// don't advertise max_concurrent_tasks in their metadata
+const DefaultAgentCapacity = 10
+
+// DistributionAgent is an agent tasks can be distributed to
+type DistributionAgent struct {
+	ID          string
+	Capacity    int // concurrent tasks the agent can run
+	ActiveTasks int
+}
+
+// weight is the agent's spare capacity. Agents at capacity get no tasks.
+func (a DistributionAgent) weight() int {
+	if a.ActiveTasks >= a.Capacity {
+		return 0
+	}
+	return a.Capacity - a.ActiveTasks
+}
+
+type weightedAgent struct {
+	agent   DistributionAgent
+	current int
+}
+
+// WeightedRoundRobin distributes tasks across agents in proportion to their spare
+// capacity, interleaving agents rather than handing each a run of tasks. When
+// every agent is at capacity, tasks are queued in proportion to capacity instead.
+type WeightedRoundRobin struct {
+	mu     sync.Mutex
+	agents []*weightedAgent
+}
+
+// NewWeightedRoundRobin creates a distributor over the given agents. Agents
+// without a capacity get DefaultAgentCapacity.
+func NewWeightedRoundRobin(agents []DistributionAgent) *WeightedRoundRobin {
+	w := &WeightedRoundRobin{
+		agents: make([]*weightedAgent, 0, len(agents)),
+	}
+	for _, agent := range agents {
+		if agent.Capacity <= 0 {
+			agent.Capacity = DefaultAgentCapacity
+		}
+		w.agents = append(w.agents, &weightedAgent{agent: agent})
+	}
+	return w
+}
+
+// Next returns the agent to give the next task to, or false when there are no agents
+func (w *WeightedRoundRobin) Next() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.agents) == 0 {
+		return "", false
+	}
+
+	weight := func(a *weightedAgent) int { return a.agent.weight() }
+	total := 0
+	for _, a := range w.agents {
+		total += weight(a)
+	}
+	if total == 0 {
+		weight = func(a *weightedAgent) int { return a.agent.Capacity }
+		for _, a := range w.agents {
+			total += weight(a)
+		}
+	}
+
+	// Smooth weighted round-robin: every agent accrues its weight, and the one
+	// with the most accrued is picked and pays back the total
+	var selected *weightedAgent
+	for _, a := range w.agents {
+		a.current += weight(a)
+		if selected == nil || a.current > selected.current {
+			selected = a
+		}
+	}
+	selected.current -= total
+
+	return selected.agent.ID, true
+}
+
+// UpdateLoad rebalances the distribution after an agent's active task count changed
+func (w *WeightedRoundRobin) UpdateLoad(agentID string, activeTasks int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, a := range w.agents {
+		if a.agent.ID == agentID {
+			a.agent.ActiveTasks = activeTasks
+			return
+		}
+	}
+}
+
+// distributionAgents looks up the capacity and load of the target agents.
+// Unknown agents are given the default capacity and no load.
+func (s *Server) distributionAgents(ctx context.Context, agentIDs []string) []DistributionAgent {
+	agents := make([]DistributionAgent, 0, len(agentIDs))
+	for _, agentID := range agentIDs {
+		agent := DistributionAgent{ID: agentID, Capacity: DefaultAgentCapacity}
+		if s.agentRegistry != nil {
+			if info, err := s.agentRegistry.GetAgentStatus(ctx, agentID); err == nil && info != nil {
+				agent.ActiveTasks = info.ActiveTasks
+				switch capacity := info.Metadata["max_concurrent_tasks"].(type) {
+				case int:
+					if capacity > 0 {
+						agent.Capacity = capacity
+					}
+				case float64:
+					if capacity > 0 {
+						agent.Capacity = int(capacity)
+					}
+				}
+			}
+		}
+		agents = append(agents, agent)
+	}
+	return agents
+}
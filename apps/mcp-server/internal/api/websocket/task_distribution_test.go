@@ -0,0 +1,239 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+func distribute(w *WeightedRoundRobin, tasks int) map[string]int {
+	counts := make(map[string]int)
+	for i := 0; i < tasks; i++ {
+		agentID, ok := w.Next()
+		if !ok {
+			break
+		}
+		counts[agentID]++
+	}
+	return counts
+}
+
+// assertProportional checks each agent's share of the tasks is within 2% of its weight
+func assertProportional(t *testing.T, counts map[string]int, weights map[string]int, tasks int) {
+	t.Helper()
+
+	total := 0
+	for _, weight := range weights {
+		total += weight
+	}
+	for agentID, weight := range weights {
+		expected := float64(tasks) * float64(weight) / float64(total)
+		assert.InDelta(t, expected, counts[agentID], float64(tasks)*0.02, "agent %s", agentID)
+	}
+}
+
+func TestWeightedRoundRobinDistributesByCapacity(t *testing.T) {
+	w := NewWeightedRoundRobin([]DistributionAgent{
+		{ID: "large", Capacity: 8},
+		{ID: "medium", Capacity: 4},
+		{ID: "small", Capacity: 2},
+	})
+
+	counts := distribute(w, 1400)
+	assertProportional(t, counts, map[string]int{"large": 8, "medium": 4, "small": 2}, 1400)
+}
+
+func TestWeightedRoundRobinInterleavesAgents(t *testing.T) {
+	w := NewWeightedRoundRobin([]DistributionAgent{
+		{ID: "a", Capacity: 2},
+		{ID: "b", Capacity: 1},
+	})
+
+	var order []string
+	for i := 0; i < 6; i++ {
+		agentID, _ := w.Next()
+		order = append(order, agentID)
+	}
+	assert.Equal(t, []string{"a", "b", "a", "a", "b", "a"}, order)
+}
+
+func TestWeightedRoundRobinWeighsSpareCapacity(t *testing.T) {
+	t.Run("busy agents receive proportionally fewer tasks", func(t *testing.T) {
+		w := NewWeightedRoundRobin([]DistributionAgent{
+			{ID: "busy", Capacity: 10, ActiveTasks: 7},
+			{ID: "idle", Capacity: 6},
+		})
+
+		counts := distribute(w, 900)
+		assertProportional(t, counts, map[string]int{"busy": 3, "idle": 6}, 900)
+	})
+
+	t.Run("full agents receive no tasks", func(t *testing.T) {
+		w := NewWeightedRoundRobin([]DistributionAgent{
+			{ID: "full", Capacity: 4, ActiveTasks: 4},
+			{ID: "free", Capacity: 4, ActiveTasks: 1},
+		})
+
+		counts := distribute(w, 100)
+		assert.Zero(t, counts["full"])
+		assert.Equal(t, 100, counts["free"])
+	})
+
+	t.Run("when every agent is full tasks queue by capacity", func(t *testing.T) {
+		w := NewWeightedRoundRobin([]DistributionAgent{
+			{ID: "large", Capacity: 6, ActiveTasks: 6},
+			{ID: "small", Capacity: 2, ActiveTasks: 3},
+		})
+
+		counts := distribute(w, 800)
+		assertProportional(t, counts, map[string]int{"large": 6, "small": 2}, 800)
+	})
+
+	t.Run("agents without a capacity get the default", func(t *testing.T) {
+		w := NewWeightedRoundRobin([]DistributionAgent{
+			{ID: "default"},
+			{ID: "small", Capacity: DefaultAgentCapacity / 2},
+		})
+
+		counts := distribute(w, 600)
+		assertProportional(t, counts, map[string]int{"default": 2, "small": 1}, 600)
+	})
+}
+
+func TestWeightedRoundRobinRebalancesOnLoadChange(t *testing.T) {
+	w := NewWeightedRoundRobin([]DistributionAgent{
+		{ID: "a", Capacity: 10},
+		{ID: "b", Capacity: 10},
+	})
+
+	counts := distribute(w, 400)
+	assertProportional(t, counts, map[string]int{"a": 1, "b": 1}, 400)
+
+	// a picks up work elsewhere, leaving it 2 spare slots against b's 10
+	w.UpdateLoad("a", 8)
+	counts = distribute(w, 1200)
+	assertProportional(t, counts, map[string]int{"a": 2, "b": 10}, 1200)
+
+	// and once it frees up again the split evens out
+	w.UpdateLoad("a", 0)
+	counts = distribute(w, 400)
+	assertProportional(t, counts, map[string]int{"a": 1, "b": 1}, 400)
+
+	// Unknown agents are ignored
+	w.UpdateLoad("c", 5)
+	assert.NotContains(t, distribute(w, 10), "c")
+}
+
+func TestWeightedRoundRobinWithoutAgents(t *testing.T) {
+	_, ok := NewWeightedRoundRobin(nil).Next()
+	assert.False(t, ok)
+}
+
+// distributionTaskService records created tasks and their assignments
+type distributionTaskService struct {
+	stubTaskService
+	created     int
+	assignments map[uuid.UUID]string
+	failAgents  map[string]bool
+}
+
+func (s *distributionTaskService) Create(ctx context.Context, task *models.Task, idempotencyKey string) error {
+	s.created++
+	return nil
+}
+
+func (s *distributionTaskService) AssignTask(ctx context.Context, taskID uuid.UUID, agentID string) error {
+	if s.failAgents[agentID] {
+		return errors.New("agent unavailable")
+	}
+	s.assignments[taskID] = agentID
+	return nil
+}
+
+func createDistributedTask(t *testing.T, server *Server, subtasks int, agents ...string) map[string]interface{} {
+	t.Helper()
+
+	subtaskParams := make([]map[string]interface{}, subtasks)
+	for i := range subtaskParams {
+		subtaskParams[i] = map[string]interface{}{
+			"type":       "review",
+			"priority":   "normal",
+			"title":      "review chunk",
+			"parameters": map[string]interface{}{"chunk": i},
+		}
+	}
+	params, err := json.Marshal(map[string]interface{}{
+		"title":         "review",
+		"type":          "review",
+		"priority":      "normal",
+		"parameters":    map[string]interface{}{},
+		"subtasks":      subtaskParams,
+		"strategy":      "parallel",
+		"target_agents": agents,
+	})
+	require.NoError(t, err)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.AgentID = "agent-1"
+	conn.TenantID = uuid.New().String()
+
+	result, err := server.handleTaskCreateDistributed(context.Background(), conn, params)
+	require.NoError(t, err)
+	return result.(map[string]interface{})
+}
+
+func TestHandleTaskCreateDistributedAssignsSubtasks(t *testing.T) {
+	t.Run("subtasks are spread across target agents", func(t *testing.T) {
+		tasks := &distributionTaskService{assignments: make(map[uuid.UUID]string)}
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		server.taskService = tasks
+
+		result := createDistributedTask(t, server, 30, "agent-a", "agent-b", "agent-c")
+
+		assert.Equal(t, 31, tasks.created)
+		assignments := result["assignments"].(map[string]string)
+		assert.Len(t, assignments, 30)
+
+		counts := make(map[string]int)
+		for _, agentID := range tasks.assignments {
+			counts[agentID]++
+		}
+		assert.Equal(t, map[string]int{"agent-a": 10, "agent-b": 10, "agent-c": 10}, counts)
+	})
+
+	t.Run("agents that fail an assignment are skipped", func(t *testing.T) {
+		tasks := &distributionTaskService{
+			assignments: make(map[uuid.UUID]string),
+			failAgents:  map[string]bool{"agent-b": true},
+		}
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		server.taskService = tasks
+
+		result := createDistributedTask(t, server, 20, "agent-a", "agent-b")
+
+		assignments := result["assignments"].(map[string]string)
+		assert.Len(t, assignments, 20)
+		for _, agentID := range assignments {
+			assert.Equal(t, "agent-a", agentID)
+		}
+	})
+
+	t.Run("subtasks stay unassigned without target agents", func(t *testing.T) {
+		tasks := &distributionTaskService{assignments: make(map[uuid.UUID]string)}
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		server.taskService = tasks
+
+		result := createDistributedTask(t, server, 5)
+
+		assert.Empty(t, result["assignments"])
+		assert.Empty(t, tasks.assignments)
+	})
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"fmt"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// TaskOutputSchemaParam is the task parameter holding a JSON Schema that completion
+// results must satisfy
+const TaskOutputSchemaParam = "output_schema"
+
+// ErrSchemaValidationFailed identifies task completions rejected by output schema validation
+const ErrSchemaValidationFailed = "schema_validation_failed"
+
+// validateTaskOutput checks a completion result against the task's output schema.
+// Tasks without an output schema accept any result.
+func validateTaskOutput(task *models.Task, result map[string]interface{}) error {
+	schema, ok := task.Parameters[TaskOutputSchemaParam]
+	if !ok || schema == nil {
+		return nil
+	}
+
+	var schemaLoader gojsonschema.JSONLoader
+	if raw, ok := schema.(string); ok {
+		schemaLoader = gojsonschema.NewStringLoader(raw)
+	} else {
+		schemaLoader = gojsonschema.NewGoLoader(schema)
+	}
+
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+
+	validation, err := gojsonschema.Validate(schemaLoader, gojsonschema.NewGoLoader(result))
+	if err != nil {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Task output schema is invalid", map[string]interface{}{
+			"error":   ErrSchemaValidationFailed,
+			"task_id": task.ID.String(),
+			"details": err.Error(),
+		})
+	}
+	if validation.Valid() {
+		return nil
+	}
+
+	violations := make([]map[string]interface{}, 0, len(validation.Errors()))
+	for _, violation := range validation.Errors() {
+		violations = append(violations, map[string]interface{}{
+			"field":       violation.Field(),
+			"type":        violation.Type(),
+			"description": violation.Description(),
+		})
+	}
+
+	return ws.NewError(ws.ErrCodeInvalidParams,
+		fmt.Sprintf("Task result does not match output schema (%d violations)", len(violations)),
+		map[string]interface{}{
+			"error":      ErrSchemaValidationFailed,
+			"task_id":    task.ID.String(),
+			"violations": violations,
+		})
+}
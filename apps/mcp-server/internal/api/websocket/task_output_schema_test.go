@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// completingTaskService completes its fixed task in place
+type completingTaskService struct {
+	stubTaskService
+	completions int
+}
+
+func (s *completingTaskService) CompleteTask(ctx context.Context, taskID uuid.UUID, agentID string, result interface{}) error {
+	s.completions++
+	now := time.Now()
+	s.task.Status = models.TaskStatusCompleted
+	s.task.CompletedAt = &now
+	return nil
+}
+
+func newTaskCompleteTestServer(parameters models.JSONMap) (*Server, *Connection, *completingTaskService, *syncEventBus) {
+	task := &models.Task{
+		ID:         uuid.New(),
+		Status:     models.TaskStatusInProgress,
+		CreatedBy:  "creator-agent",
+		Parameters: parameters,
+	}
+	service := &completingTaskService{stubTaskService: stubTaskService{task: task}}
+	bus := newSyncEventBus()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetEventBus(bus)
+	server.taskService = service
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "worker-agent"
+	return server, conn, service, bus
+}
+
+var reportOutputSchema = map[string]interface{}{
+	"type":     "object",
+	"required": []interface{}{"summary", "score"},
+	"properties": map[string]interface{}{
+		"summary": map[string]interface{}{"type": "string"},
+		"score":   map[string]interface{}{"type": "number", "minimum": 0, "maximum": 1},
+	},
+}
+
+func TestHandleTaskCompleteValidatesOutputSchema(t *testing.T) {
+	server, conn, service, _ := newTaskCompleteTestServer(models.JSONMap{"output_schema": reportOutputSchema})
+	params, err := json.Marshal(map[string]interface{}{
+		"task_id": service.task.ID.String(),
+		"result":  map[string]interface{}{"score": 2},
+	})
+	require.NoError(t, err)
+
+	_, err = server.handleTaskComplete(context.Background(), conn, params)
+	require.Error(t, err)
+
+	wsErr, ok := err.(*ws.Error)
+	require.True(t, ok)
+	assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+
+	data := wsErr.Data.(map[string]interface{})
+	assert.Equal(t, ErrSchemaValidationFailed, data["error"])
+	violations := data["violations"].([]map[string]interface{})
+	assert.Len(t, violations, 2, "missing summary and score above maximum")
+
+	assert.Equal(t, 0, service.completions)
+	assert.Equal(t, models.TaskStatusInProgress, service.task.Status)
+}
+
+func TestHandleTaskCompleteWithValidOutput(t *testing.T) {
+	server, conn, service, bus := newTaskCompleteTestServer(models.JSONMap{"output_schema": reportOutputSchema})
+
+	var transitions []*TaskStatusTransition
+	_, err := bus.SubscribeHandler(TaskStatusChangedEvent(service.task.ID.String()), func(data interface{}) {
+		transitions = append(transitions, data.(*TaskStatusTransition))
+	})
+	require.NoError(t, err)
+
+	params, err := json.Marshal(map[string]interface{}{
+		"task_id": service.task.ID.String(),
+		"result":  map[string]interface{}{"summary": "all good", "score": 0.9},
+	})
+	require.NoError(t, err)
+
+	result, err := server.handleTaskComplete(context.Background(), conn, params)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.TaskStatusCompleted, result.(map[string]interface{})["status"])
+	assert.Equal(t, 1, service.completions)
+	assert.NotNil(t, service.task.CompletedAt)
+
+	require.Len(t, transitions, 1)
+	assert.Equal(t, string(models.TaskStatusCompleted), transitions[0].Status)
+	assert.Equal(t, string(models.TaskStatusInProgress), transitions[0].PreviousStatus)
+}
+
+func TestHandleTaskCompleteWithoutSchema(t *testing.T) {
+	server, conn, service, _ := newTaskCompleteTestServer(nil)
+	params, err := json.Marshal(map[string]interface{}{
+		"task_id": service.task.ID.String(),
+		"result":  map[string]interface{}{"anything": true},
+	})
+	require.NoError(t, err)
+
+	_, err = server.handleTaskComplete(context.Background(), conn, params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, service.completions)
+}
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// TaskStatusTransition is published on the event bus when a task changes state
+// and pushed to watching connections as a task.update notification
+type TaskStatusTransition struct {
+	TaskID         string      `json:"task_id"`
+	Status         string      `json:"status"`
+	PreviousStatus string      `json:"previous_status,omitempty"`
+	Result         interface{} `json:"result,omitempty"`
+	Error          string      `json:"error,omitempty"`
+	TransitionedAt time.Time   `json:"transitioned_at"`
+}
+
+// TaskStatusChangedEvent returns the event bus event name for a task's status transitions
+func TaskStatusChangedEvent(taskID string) string {
+	return fmt.Sprintf("task.%s.status_changed", taskID)
+}
+
+// isTerminalTaskStatus reports whether a task status ends a watch
+func isTerminalTaskStatus(status string) bool {
+	switch models.TaskStatus(status) {
+	case models.TaskStatusCompleted, models.TaskStatusFailed, models.TaskStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+func taskWatchKey(connID, taskID string) string {
+	return connID + ":" + taskID
+}
+
+// handleTaskWatch streams task state transitions to the connection as task.update
+// notifications until the task reaches a terminal state
+func (s *Server) handleTaskWatch(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	var watchParams struct {
+		TaskID string `json:"task_id"`
+	}
+
+	if err := json.Unmarshal(params, &watchParams); err != nil {
+		return nil, err
+	}
+
+	taskUUID, err := uuid.Parse(watchParams.TaskID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID: %w", err)
+	}
+	taskID := taskUUID.String()
+
+	if s.eventBus == nil {
+		return nil, fmt.Errorf("event bus not initialized")
+	}
+
+	// Report the current status and skip the subscription if the task has already finished
+	var status string
+	if s.taskService != nil {
+		task, err := s.taskService.Get(ctx, taskUUID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		status = string(task.Status)
+		if isTerminalTaskStatus(status) {
+			return map[string]interface{}{
+				"task_id":  taskID,
+				"status":   status,
+				"watching": false,
+			}, nil
+		}
+	}
+
+	key := taskWatchKey(conn.ID, taskID)
+	if _, exists := s.taskWatches.Load(key); exists {
+		return map[string]interface{}{
+			"task_id":  taskID,
+			"status":   status,
+			"watching": true,
+		}, nil
+	}
+
+	subscriptionID, err := s.eventBus.SubscribeHandler(TaskStatusChangedEvent(taskID), func(data interface{}) {
+		transition, ok := data.(*TaskStatusTransition)
+		if !ok {
+			return
+		}
+
+		if err := conn.SendNotification("task.update", transition); err != nil {
+			s.logger.Warn("Failed to send task update", map[string]interface{}{
+				"connection_id": conn.ID,
+				"task_id":       taskID,
+				"error":         err.Error(),
+			})
+		}
+
+		if isTerminalTaskStatus(transition.Status) {
+			s.stopTaskWatch(conn.ID, taskID)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch task: %w", err)
+	}
+	s.taskWatches.Store(key, subscriptionID)
+
+	return map[string]interface{}{
+		"task_id":  taskID,
+		"status":   status,
+		"watching": true,
+	}, nil
+}
+
+// publishTaskTransition publishes a task's new state to watchers
+func (s *Server) publishTaskTransition(task *models.Task, previousStatus string) {
+	if s.eventBus == nil || task == nil {
+		return
+	}
+
+	transition := &TaskStatusTransition{
+		TaskID:         task.ID.String(),
+		Status:         string(task.Status),
+		PreviousStatus: previousStatus,
+		Error:          task.Error,
+		TransitionedAt: time.Now(),
+	}
+	if task.Result != nil {
+		transition.Result = task.Result
+	}
+
+	if err := s.eventBus.Publish(TaskStatusChangedEvent(transition.TaskID), transition); err != nil {
+		s.logger.Warn("Failed to publish task status transition", map[string]interface{}{
+			"task_id": transition.TaskID,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// stopTaskWatch removes a connection's watch on a task
+func (s *Server) stopTaskWatch(connID, taskID string) {
+	val, ok := s.taskWatches.LoadAndDelete(taskWatchKey(connID, taskID))
+	if !ok || s.eventBus == nil {
+		return
+	}
+	_ = s.eventBus.UnsubscribeHandler(val.(string))
+}
+
+// stopConnectionTaskWatches removes all task watches held by a connection
+func (s *Server) stopConnectionTaskWatches(connID string) {
+	prefix := connID + ":"
+	s.taskWatches.Range(func(key, _ interface{}) bool {
+		if k := key.(string); strings.HasPrefix(k, prefix) {
+			s.stopTaskWatch(connID, strings.TrimPrefix(k, prefix))
+		}
+		return true
+	})
+}
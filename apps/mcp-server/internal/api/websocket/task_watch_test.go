@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/services"
+)
+
+// syncEventBus delivers events to handlers synchronously
+type syncEventBus struct {
+	mu       sync.Mutex
+	handlers map[string]map[string]func(interface{})
+	next     int
+}
+
+func newSyncEventBus() *syncEventBus {
+	return &syncEventBus{handlers: make(map[string]map[string]func(interface{}))}
+}
+
+func (b *syncEventBus) Subscribe(connectionID string, events []string) error { return nil }
+func (b *syncEventBus) Unsubscribe(connectionID string) error                { return nil }
+func (b *syncEventBus) UnsubscribeEvents(connectionID string, events []string) error {
+	return nil
+}
+
+func (b *syncEventBus) Publish(event string, data interface{}) error {
+	b.mu.Lock()
+	handlers := make([]func(interface{}), 0, len(b.handlers[event]))
+	for _, h := range b.handlers[event] {
+		handlers = append(handlers, h)
+	}
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(data)
+	}
+	return nil
+}
+
+func (b *syncEventBus) SubscribeHandler(event string, handler func(data interface{})) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.next++
+	id := fmt.Sprintf("sub-%d", b.next)
+	if b.handlers[event] == nil {
+		b.handlers[event] = make(map[string]func(interface{}))
+	}
+	b.handlers[event][id] = handler
+	return id, nil
+}
+
+func (b *syncEventBus) UnsubscribeHandler(subscriptionID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subs := range b.handlers {
+		delete(subs, subscriptionID)
+	}
+	return nil
+}
+
+func (b *syncEventBus) count(event string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.handlers[event])
+}
+
+// stubTaskService returns a fixed task from Get
+type stubTaskService struct {
+	services.TaskService
+	task *models.Task
+}
+
+func (s *stubTaskService) Get(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	return s.task, nil
+}
+
+func TestHandleTaskWatch(t *testing.T) {
+	task := &models.Task{ID: uuid.New(), Status: models.TaskStatusInProgress}
+	bus := newSyncEventBus()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetEventBus(bus)
+	server.taskService = &stubTaskService{task: task}
+	conn := NewConnection("conn-1", nil, server)
+
+	params, err := json.Marshal(map[string]string{"task_id": task.ID.String()})
+	require.NoError(t, err)
+
+	result, err := server.handleTaskWatch(context.Background(), conn, params)
+	require.NoError(t, err)
+	assert.Equal(t, true, result.(map[string]interface{})["watching"])
+
+	event := TaskStatusChangedEvent(task.ID.String())
+	require.Equal(t, 1, bus.count(event))
+
+	// Terminal transition is delivered and ends the watch
+	task.Status = models.TaskStatusCompleted
+	task.Result = models.JSONMap{"answer": 42}
+	server.publishTaskTransition(task, string(models.TaskStatusInProgress))
+
+	var msg ws.Message
+	require.NoError(t, json.Unmarshal(<-conn.send, &msg))
+	assert.Equal(t, "task.update", msg.Method)
+
+	update := msg.Params.(map[string]interface{})
+	assert.Equal(t, string(models.TaskStatusCompleted), update["status"])
+	assert.Equal(t, string(models.TaskStatusInProgress), update["previous_status"])
+	assert.NotEmpty(t, update["transitioned_at"])
+	assert.Equal(t, float64(42), update["result"].(map[string]interface{})["answer"])
+
+	assert.Equal(t, 0, bus.count(event), "watch removed after terminal state")
+}
+
+func TestHandleTaskWatchAlreadyTerminal(t *testing.T) {
+	task := &models.Task{ID: uuid.New(), Status: models.TaskStatusFailed}
+	bus := newSyncEventBus()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetEventBus(bus)
+	server.taskService = &stubTaskService{task: task}
+	conn := NewConnection("conn-1", nil, server)
+
+	params, err := json.Marshal(map[string]string{"task_id": task.ID.String()})
+	require.NoError(t, err)
+
+	result, err := server.handleTaskWatch(context.Background(), conn, params)
+	require.NoError(t, err)
+	assert.Equal(t, false, result.(map[string]interface{})["watching"])
+	assert.Equal(t, 0, bus.count(TaskStatusChangedEvent(task.ID.String())))
+}
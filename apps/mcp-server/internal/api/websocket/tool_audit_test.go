@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+)
+
+// stubToolRegistry is a ToolRegistry that returns a fixed result or error
+type stubToolRegistry struct {
+	result interface{}
+	err    error
+}
+
+func (r *stubToolRegistry) GetToolsForAgent(agentID string) ([]Tool, error) {
+	return nil, nil
+}
+
+func (r *stubToolRegistry) ExecuteTool(ctx context.Context, agentID, toolID string, args map[string]interface{}) (interface{}, error) {
+	return r.result, r.err
+}
+
+func (r *stubToolRegistry) CancelExecution(ctx context.Context, executionID string) error {
+	return nil
+}
+
+func (r *stubToolRegistry) GetExecutionStatus(ctx context.Context, executionID string) (*ToolExecutionStatus, error) {
+	return nil, nil
+}
+
+func TestToolExecuteAuditTrail(t *testing.T) {
+	params := json.RawMessage(`{
+		"tool_id": "github",
+		"action": "create_issue",
+		"parameters": {"title": "bug", "api_token": "ghp_secret", "nested": {"password": "hunter2"}}
+	}`)
+
+	t.Run("successful execution records redacted args", func(t *testing.T) {
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		store := auth.NewInMemoryToolAuditStore(0, nil)
+		server.SetToolAuditStore(store)
+		server.SetToolRegistry(&stubToolRegistry{result: map[string]interface{}{"number": 42}})
+
+		conn := NewConnection("conn-1", nil, server)
+		conn.TenantID = "tenant-1"
+		conn.AgentID = "agent-1"
+
+		_, err := server.handleToolExecute(context.Background(), conn, params)
+		require.NoError(t, err)
+
+		records, err := store.Query(context.Background(), auth.ToolAuditFilter{TenantID: "tenant-1"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+
+		record := records[0]
+		assert.Equal(t, "agent-1", record.AgentID)
+		assert.Equal(t, "github", record.ToolID)
+		assert.Equal(t, "create_issue", record.Action)
+		assert.Equal(t, auth.ToolAuditStatusSuccess, record.Status)
+		assert.Equal(t, "bug", record.Arguments["title"])
+		assert.Equal(t, "[REDACTED]", record.Arguments["api_token"])
+		assert.Equal(t, "[REDACTED]", record.Arguments["nested"].(map[string]interface{})["password"])
+		assert.Contains(t, record.ResultSummary, "42")
+	})
+
+	t.Run("failed execution records failed status", func(t *testing.T) {
+		server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+		store := auth.NewInMemoryToolAuditStore(0, nil)
+		server.SetToolAuditStore(store)
+		server.SetToolRegistry(&stubToolRegistry{err: errors.New("upstream unavailable")})
+
+		conn := NewConnection("conn-1", nil, server)
+		conn.TenantID = "tenant-1"
+
+		_, err := server.handleToolExecute(context.Background(), conn, params)
+		require.Error(t, err)
+
+		records, err := store.Query(context.Background(), auth.ToolAuditFilter{Status: auth.ToolAuditStatusFailed})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.Equal(t, "upstream unavailable", records[0].Error)
+	})
+}
+
+func TestHandleToolAuditQueryScopesToTenant(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	store := auth.NewInMemoryToolAuditStore(0, nil)
+	server.SetToolAuditStore(store)
+
+	ctx := context.Background()
+	require.NoError(t, store.Record(ctx, &auth.ToolExecutionAuditRecord{TenantID: "tenant-1", ToolID: "github", Status: auth.ToolAuditStatusSuccess}))
+	require.NoError(t, store.Record(ctx, &auth.ToolExecutionAuditRecord{TenantID: "tenant-2", ToolID: "github", Status: auth.ToolAuditStatusSuccess}))
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+
+	result, err := server.handleToolAuditQuery(ctx, conn, json.RawMessage(`{"tenant_id":"tenant-2","tool_id":"github"}`))
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, 1, response["count"])
+	records := response["records"].([]*auth.ToolExecutionAuditRecord)
+	assert.Equal(t, "tenant-1", records[0].TenantID)
+}
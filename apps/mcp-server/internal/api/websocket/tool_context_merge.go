@@ -0,0 +1,89 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// ToolResultRole is the role of the context message a tool result is appended as
+const ToolResultRole = "tool"
+
+// ContextItemAppender is implemented by context managers that can append a
+// message with its own role and token count, which merging tool results
+// into a context needs
+type ContextItemAppender interface {
+	AppendItemToContext(ctx context.Context, contextID string, item models.ContextItem) (*models.Context, error)
+}
+
+// checkToolResultContext verifies that a tool result can be appended to the
+// context before the tool runs, so a bad context_id doesn't cost an execution
+func (s *Server) checkToolResultContext(ctx context.Context, contextID string) error {
+	if s.contextManager == nil {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Context management is not available", nil)
+	}
+	if _, ok := s.contextManager.(ContextItemAppender); !ok {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Context manager cannot append tool results", nil)
+	}
+	if existing, err := s.contextManager.GetContext(ctx, contextID); err != nil || existing == nil {
+		return ws.NewError(ws.ErrCodeInvalidParams, "Context not found", map[string]interface{}{
+			"context_id": contextID,
+		})
+	}
+	return nil
+}
+
+// appendToolResult appends a completed tool execution's result to a context
+// as a tool message and adds the context's new token count to the response.
+// The tool has already run, so a failed append is reported in the response
+// rather than failing the execution.
+func (s *Server) appendToolResult(ctx context.Context, contextID, toolID, action string, response map[string]interface{}) {
+	if response["status"] != "completed" {
+		return
+	}
+
+	content, err := toolResultContent(response["result"])
+	if err == nil {
+		item := models.ContextItem{
+			Role:    ToolResultRole,
+			Content: content,
+			Tokens:  estimateTokens(content),
+			Metadata: map[string]any{
+				"tool_id": toolID,
+				"action":  action,
+			},
+		}
+		appender := s.contextManager.(ContextItemAppender)
+
+		var appended map[string]interface{}
+		appended, err = s.appendToContext(ctx, contextID, content, func() (*models.Context, error) {
+			return appender.AppendItemToContext(ctx, contextID, item)
+		})
+		if err == nil {
+			response["context"] = appended
+			return
+		}
+	}
+
+	s.logger.Warn("Failed to append tool result to context", map[string]interface{}{
+		"context_id": contextID,
+		"tool_id":    toolID,
+		"error":      err.Error(),
+	})
+	response["context_error"] = err.Error()
+}
+
+// toolResultContent renders a tool result as message content, leaving text as is
+func toolResultContent(result interface{}) (string, error) {
+	if text, ok := result.(string); ok {
+		return text, nil
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode tool result: %w", err)
+	}
+	return string(data), nil
+}
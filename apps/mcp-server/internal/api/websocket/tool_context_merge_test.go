@@ -0,0 +1,110 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/apps/mcp-server/internal/core"
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+func newToolContextTestServer(t *testing.T, registry *stubToolRegistry) (*Server, *Connection, *models.Context) {
+	t.Helper()
+
+	existing := &models.Context{
+		ID:            "ctx-1",
+		CurrentTokens: 12,
+		MaxTokens:     4000,
+		Content:       []models.ContextItem{{Role: "user", Content: "List the open pull requests", Tokens: 12}},
+	}
+	db := &core.MockDB{}
+	db.On("GetContext", mock.Anything, "ctx-1").Return(existing, nil)
+	db.On("GetContext", mock.Anything, mock.Anything).Return(nil, errors.New("context not found"))
+	db.On("UpdateContext", mock.Anything, mock.Anything).Return(nil)
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetToolRegistry(registry)
+	server.SetContextManager(NewContextManagerAdapter(core.NewContextManager(db, nil)))
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	return server, conn, existing
+}
+
+func TestToolExecuteAppendsResultToContext(t *testing.T) {
+	result := map[string]interface{}{"pull_requests": []interface{}{"#41", "#42"}}
+	server, conn, existing := newToolContextTestServer(t, &stubToolRegistry{result: result})
+
+	response, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list_pull_requests", "context_id": "ctx-1"}`))
+	require.NoError(t, err)
+
+	content, err := json.Marshal(result)
+	require.NoError(t, err)
+	tokens := estimateTokens(string(content))
+
+	require.Len(t, existing.Content, 2)
+	appended := existing.Content[1]
+	assert.Equal(t, ToolResultRole, appended.Role)
+	assert.JSONEq(t, string(content), appended.Content)
+	assert.Equal(t, tokens, appended.Tokens)
+	assert.Equal(t, "github", appended.Metadata["tool_id"])
+	assert.Equal(t, "list_pull_requests", appended.Metadata["action"])
+	assert.Equal(t, 12+tokens, existing.CurrentTokens)
+
+	merged := response.(map[string]interface{})["context"].(map[string]interface{})
+	assert.Equal(t, "ctx-1", merged["id"])
+	assert.Equal(t, 12+tokens, merged["current_tokens"])
+	assert.Equal(t, tokens, merged["token_delta"])
+}
+
+func TestToolExecuteContextMerging(t *testing.T) {
+	t.Run("text results are appended as is", func(t *testing.T) {
+		server, conn, existing := newToolContextTestServer(t, &stubToolRegistry{result: "2 open pull requests"})
+
+		_, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "summarize", "context_id": "ctx-1"}`))
+		require.NoError(t, err)
+		require.Len(t, existing.Content, 2)
+		assert.Equal(t, "2 open pull requests", existing.Content[1].Content)
+	})
+
+	t.Run("without a context_id nothing is appended", func(t *testing.T) {
+		server, conn, existing := newToolContextTestServer(t, &stubToolRegistry{result: "ok"})
+
+		response, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list"}`))
+		require.NoError(t, err)
+		assert.Len(t, existing.Content, 1)
+		assert.NotContains(t, response.(map[string]interface{}), "context")
+	})
+
+	t.Run("failed executions are not appended", func(t *testing.T) {
+		server, conn, existing := newToolContextTestServer(t, &stubToolRegistry{err: errors.New("connection refused")})
+
+		_, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list", "context_id": "ctx-1"}`))
+		require.Error(t, err)
+		assert.Len(t, existing.Content, 1)
+		assert.Equal(t, 12, existing.CurrentTokens)
+	})
+
+	t.Run("unknown contexts are rejected before the tool runs", func(t *testing.T) {
+		server, conn, _ := newToolContextTestServer(t, &stubToolRegistry{result: "ok"})
+		server.toolQuota = NewToolQuotaLimiter(ToolQuotaConfig{MaxExecutions: 1})
+
+		_, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list", "context_id": "ctx-missing"}`))
+		var wsErr *ws.Error
+		require.ErrorAs(t, err, &wsErr)
+		assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+
+		// The quota wasn't spent on the rejected call
+		_, err = server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list"}`))
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,119 @@
+package websocket
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// Tool error categories returned as error_category so agents can tell a failure
+// the tool reported from one they should retry or escalate
+const (
+	ToolErrorCategoryTool      = "tool_error"  // The tool rejected the request, e.g. "PR already merged"
+	ToolErrorCategoryAuth      = "auth_error"  // Credentials are missing, invalid or lack permission
+	ToolErrorCategoryRateLimit = "rate_limit"  // The tool or its provider is throttling requests
+	ToolErrorCategoryInfra     = "infra_error" // The tool couldn't be reached or failed internally
+)
+
+var toolErrorStatusPattern = regexp.MustCompile(`HTTP (\d{3})`)
+
+var (
+	rateLimitErrorHints = []string{"rate limit", "ratelimit", "too many requests", "quota exceeded", "throttl"}
+	authErrorHints      = []string{"unauthorized", "unauthenticated", "forbidden", "bad credentials", "invalid token", "token expired", "permission denied", "access denied", "authentication"}
+	infraErrorHints     = []string{"timeout", "timed out", "deadline exceeded", "connection refused", "connection reset", "no such host", "eof", "circuit breaker", "unavailable", "bad gateway", "request failed"}
+)
+
+// classifyToolResult categorizes a tool execution that completed with a failure
+func classifyToolResult(result *models.ToolExecutionResponse) string {
+	if isRateLimitedResult(result) {
+		return ToolErrorCategoryRateLimit
+	}
+	if result.StatusCode != 0 {
+		return classifyToolStatus(result.StatusCode, result.Error)
+	}
+	if category := classifyToolMessage(result.Error); category != "" {
+		return category
+	}
+	return ToolErrorCategoryTool
+}
+
+// classifyToolError categorizes an error returned instead of a tool result. Errors
+// carrying an HTTP status are classified by it; others didn't reach the tool.
+func classifyToolError(err error) string {
+	message := err.Error()
+	if match := toolErrorStatusPattern.FindStringSubmatch(message); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		return classifyToolStatus(status, message)
+	}
+	if category := classifyToolMessage(message); category != "" {
+		return category
+	}
+	return ToolErrorCategoryInfra
+}
+
+// classifyToolStatus categorizes a failed tool response by its HTTP status
+func classifyToolStatus(status int, message string) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ToolErrorCategoryRateLimit
+	case status == http.StatusForbidden && containsAny(message, rateLimitErrorHints):
+		// Some providers, e.g. GitHub, report exhausted rate limits as 403
+		return ToolErrorCategoryRateLimit
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status == http.StatusProxyAuthRequired:
+		return ToolErrorCategoryAuth
+	case status == http.StatusRequestTimeout, status >= 500:
+		return ToolErrorCategoryInfra
+	default:
+		return ToolErrorCategoryTool
+	}
+}
+
+// classifyToolMessage categorizes a failure without a status by its message, or
+// returns an empty string when the message doesn't indicate a category
+func classifyToolMessage(message string) string {
+	switch {
+	case containsAny(message, rateLimitErrorHints):
+		return ToolErrorCategoryRateLimit
+	case containsAny(message, authErrorHints):
+		return ToolErrorCategoryAuth
+	case containsAny(message, infraErrorHints):
+		return ToolErrorCategoryInfra
+	default:
+		return ""
+	}
+}
+
+// isRateLimitedResult reports whether the tool's response headers show an exhausted rate limit
+func isRateLimitedResult(result *models.ToolExecutionResponse) bool {
+	return http.Header(result.Headers).Get("X-RateLimit-Remaining") == "0"
+}
+
+func containsAny(message string, hints []string) bool {
+	message = strings.ToLower(message)
+	for _, hint := range hints {
+		if strings.Contains(message, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// toolExecutionError builds the protocol error for a failed tool execution,
+// carrying its category
+func toolExecutionError(toolID, message, category string) *ws.Error {
+	code := ws.ErrCodeServerError
+	switch category {
+	case ToolErrorCategoryAuth:
+		code = ws.ErrCodeAuthFailed
+	case ToolErrorCategoryRateLimit:
+		code = ws.ErrCodeRateLimited
+	}
+	return ws.NewError(code, message, map[string]interface{}{
+		"tool":           toolID,
+		"error_category": category,
+	})
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// failingToolCatalog fails every tool execution with a fixed result or error
+type failingToolCatalog struct {
+	stubToolCatalog
+	result *models.ToolExecutionResponse
+	err    error
+}
+
+func (c *failingToolCatalog) ExecuteTool(ctx context.Context, tenantID, toolID, action string, params map[string]interface{}) (*models.ToolExecutionResponse, error) {
+	return c.result, c.err
+}
+
+func executeFailingTool(t *testing.T, result *models.ToolExecutionResponse, execErr error) (interface{}, error) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetRESTClient(&failingToolCatalog{result: result, err: execErr})
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+
+	params, err := json.Marshal(map[string]interface{}{
+		"tool_id": "11111111-1111-1111-1111-111111111111",
+		"action":  "merge_pull_request",
+	})
+	require.NoError(t, err)
+	return server.handleToolExecute(context.Background(), conn, params)
+}
+
+func TestToolResultErrorCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   *models.ToolExecutionResponse
+		category string
+	}{
+		{
+			name:     "business error reported by the tool",
+			result:   &models.ToolExecutionResponse{StatusCode: 405, Error: `HTTP 405: {"message":"Pull Request is not mergeable"}`},
+			category: ToolErrorCategoryTool,
+		},
+		{
+			name:     "validation failure",
+			result:   &models.ToolExecutionResponse{StatusCode: 422, Error: `HTTP 422: {"message":"Validation Failed"}`},
+			category: ToolErrorCategoryTool,
+		},
+		{
+			name:     "invalid credentials",
+			result:   &models.ToolExecutionResponse{StatusCode: 401, Error: `HTTP 401: {"message":"Bad credentials"}`},
+			category: ToolErrorCategoryAuth,
+		},
+		{
+			name:     "missing permission",
+			result:   &models.ToolExecutionResponse{StatusCode: 403, Error: `HTTP 403: {"message":"Resource not accessible by integration"}`},
+			category: ToolErrorCategoryAuth,
+		},
+		{
+			name:     "too many requests",
+			result:   &models.ToolExecutionResponse{StatusCode: 429, Error: "HTTP 429: slow down"},
+			category: ToolErrorCategoryRateLimit,
+		},
+		{
+			name: "rate limit reported as forbidden",
+			result: &models.ToolExecutionResponse{
+				StatusCode: 403,
+				Headers:    map[string][]string{"X-Ratelimit-Remaining": {"0"}},
+				Error:      `HTTP 403: {"message":"API rate limit exceeded"}`,
+			},
+			category: ToolErrorCategoryRateLimit,
+		},
+		{
+			name:     "provider outage",
+			result:   &models.ToolExecutionResponse{StatusCode: 502, Error: "HTTP 502: Bad Gateway"},
+			category: ToolErrorCategoryInfra,
+		},
+		{
+			name:     "provider unreachable",
+			result:   &models.ToolExecutionResponse{Error: `Post "https://api.github.com/repos": dial tcp: lookup api.github.com: no such host`},
+			category: ToolErrorCategoryInfra,
+		},
+		{
+			name:     "tool failure without a status",
+			result:   &models.ToolExecutionResponse{Error: "branch is protected"},
+			category: ToolErrorCategoryTool,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := executeFailingTool(t, tt.result, nil)
+			require.NoError(t, err)
+
+			result := response.(map[string]interface{})
+			assert.Equal(t, "failed", result["status"])
+			assert.Equal(t, tt.result.Error, result["error"])
+			assert.Equal(t, tt.category, result["error_category"])
+		})
+	}
+}
+
+func TestToolExecutionErrorCategory(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		code     int
+		category string
+	}{
+		{"tool not found", errors.New(`HTTP 404: {"error":"tool not found"}`), ws.ErrCodeServerError, ToolErrorCategoryTool},
+		{"rejected credentials", errors.New(`HTTP 401: {"error":"invalid API key"}`), ws.ErrCodeAuthFailed, ToolErrorCategoryAuth},
+		{"throttled", errors.New("HTTP 429: too many requests"), ws.ErrCodeRateLimited, ToolErrorCategoryRateLimit},
+		{"server error", errors.New("HTTP 503: service unavailable"), ws.ErrCodeServerError, ToolErrorCategoryInfra},
+		{"circuit breaker open", errors.New("circuit breaker is open"), ws.ErrCodeServerError, ToolErrorCategoryInfra},
+		{"network failure", errors.New("request failed: dial tcp 10.0.0.1:8081: connect: connection refused"), ws.ErrCodeServerError, ToolErrorCategoryInfra},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executeFailingTool(t, nil, tt.err)
+			require.Error(t, err)
+
+			var wsErr *ws.Error
+			require.True(t, errors.As(err, &wsErr))
+			assert.Equal(t, tt.code, wsErr.Code)
+			assert.Equal(t, tt.category, wsErr.Data.(map[string]interface{})["error_category"])
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package websocket
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+)
+
+// MaxToolMetadataValueLength caps forwarded metadata values
+const MaxToolMetadataValueLength = 1024
+
+// ToolMetadataConfig configures which request metadata is forwarded to tool executions
+type ToolMetadataConfig struct {
+	// ForwardKeys are the metadata keys forwarded as headers, matched
+	// case-insensitively. Defaults to trace context and locale.
+	ForwardKeys []string `mapstructure:"forward_keys"`
+}
+
+// DefaultToolMetadataForwardKeys returns the metadata keys forwarded when none are configured
+func DefaultToolMetadataForwardKeys() []string {
+	return []string{"traceparent", "tracestate", "baggage", "Accept-Language"}
+}
+
+// ToolMetadataFilter keeps the metadata agents attach to tool executions to an
+// allowlist, so agents can't set credentials or tenant headers on tool requests
+type ToolMetadataFilter struct {
+	allowed map[string]bool
+}
+
+// NewToolMetadataFilter creates a filter for the configured forward keys
+func NewToolMetadataFilter(config ToolMetadataConfig) *ToolMetadataFilter {
+	keys := config.ForwardKeys
+	if len(keys) == 0 {
+		keys = DefaultToolMetadataForwardKeys()
+	}
+
+	allowed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		allowed[http.CanonicalHeaderKey(strings.TrimSpace(key))] = true
+	}
+	return &ToolMetadataFilter{allowed: allowed}
+}
+
+// Filter returns the allowlisted metadata keyed by header name, and the keys
+// that were dropped. Values that could break out of a header are dropped too,
+// as is everything when there's no filter.
+func (f *ToolMetadataFilter) Filter(metadata map[string]string) (map[string]string, []string) {
+	forwarded := make(map[string]string, len(metadata))
+	var dropped []string
+	for key, value := range metadata {
+		name := http.CanonicalHeaderKey(strings.TrimSpace(key))
+		if f == nil || !f.allowed[name] || len(value) > MaxToolMetadataValueLength || strings.ContainsAny(value, "\r\n\x00") {
+			dropped = append(dropped, key)
+			continue
+		}
+		forwarded[name] = value
+	}
+	sort.Strings(dropped)
+	return forwarded, dropped
+}
+
+// SetToolMetadata sets the metadata forwarded with every tool execution on the connection
+func (c *Connection) SetToolMetadata(metadata map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == nil {
+		c.state = &ConnectionState{}
+	}
+	c.state.ToolMetadata = metadata
+}
+
+// GetToolMetadata returns the metadata forwarded with every tool execution on the connection
+func (c *Connection) GetToolMetadata() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.state == nil {
+		return nil
+	}
+	return c.state.ToolMetadata
+}
+
+// setConnectionToolMetadata stores the allowlisted part of the metadata a
+// client attached to its connection
+func (s *Server) setConnectionToolMetadata(conn *Connection, metadata map[string]string) {
+	forwarded, dropped := s.toolMetadata.Filter(metadata)
+	if len(dropped) > 0 {
+		s.logger.Warn("Dropped connection tool metadata that isn't forwardable", map[string]interface{}{
+			"connection_id": conn.ID,
+			"dropped_keys":  dropped,
+		})
+	}
+	conn.SetToolMetadata(forwarded)
+}
+
+// withToolMetadata adds the connection's and the request's allowlisted metadata
+// to the context tools are executed with. Request metadata takes precedence.
+func (s *Server) withToolMetadata(ctx context.Context, conn *Connection, requestMetadata map[string]string) context.Context {
+	forwarded, dropped := s.toolMetadata.Filter(requestMetadata)
+	if len(dropped) > 0 {
+		s.logger.Warn("Dropped tool metadata that isn't forwardable", map[string]interface{}{
+			"connection_id": conn.ID,
+			"dropped_keys":  dropped,
+		})
+	}
+
+	headers := make(map[string]string)
+	for name, value := range conn.GetToolMetadata() {
+		headers[name] = value
+	}
+	for name, value := range forwarded {
+		headers[name] = value
+	}
+	return clients.WithToolHeaders(ctx, headers)
+}
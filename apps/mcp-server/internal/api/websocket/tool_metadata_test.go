@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// headerRecordingCatalog records the headers each tool execution was asked to forward
+type headerRecordingCatalog struct {
+	stubToolCatalog
+	headers map[string]string
+}
+
+func (c *headerRecordingCatalog) ExecuteTool(ctx context.Context, tenantID, toolID, action string, params map[string]interface{}) (*models.ToolExecutionResponse, error) {
+	c.headers = clients.GetToolHeaders(ctx)
+	return &models.ToolExecutionResponse{Success: true, StatusCode: 200}, nil
+}
+
+func newToolMetadataTestServer(t *testing.T, config ToolMetadataConfig) (*Server, *headerRecordingCatalog, *Connection) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{ToolMetadata: config})
+	catalog := &headerRecordingCatalog{}
+	server.SetRESTClient(catalog)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	return server, catalog, conn
+}
+
+func executeWithMetadata(t *testing.T, server *Server, conn *Connection, metadata map[string]string) {
+	t.Helper()
+
+	params, err := json.Marshal(map[string]interface{}{
+		"tool_id":  "11111111-1111-1111-1111-111111111111",
+		"action":   "list_issues",
+		"metadata": metadata,
+	})
+	require.NoError(t, err)
+	_, err = server.handleToolExecute(context.Background(), conn, params)
+	require.NoError(t, err)
+}
+
+func TestToolMetadataForwarding(t *testing.T) {
+	t.Run("allowlisted request metadata is forwarded", func(t *testing.T) {
+		server, catalog, conn := newToolMetadataTestServer(t, ToolMetadataConfig{})
+
+		executeWithMetadata(t, server, conn, map[string]string{
+			"traceparent":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"accept-language": "fr-CA",
+			"X-API-Key":       "stolen-key",
+			"X-Tenant-ID":     "other-tenant",
+			"x-feature-flags": "beta",
+		})
+
+		assert.Equal(t, map[string]string{
+			"Traceparent":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"Accept-Language": "fr-CA",
+		}, catalog.headers)
+	})
+
+	t.Run("configured keys replace the defaults", func(t *testing.T) {
+		server, catalog, conn := newToolMetadataTestServer(t, ToolMetadataConfig{ForwardKeys: []string{"X-Feature-Flags"}})
+
+		executeWithMetadata(t, server, conn, map[string]string{
+			"x-feature-flags": "beta",
+			"traceparent":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		})
+
+		assert.Equal(t, map[string]string{"X-Feature-Flags": "beta"}, catalog.headers)
+	})
+
+	t.Run("values that could inject headers are dropped", func(t *testing.T) {
+		server, catalog, conn := newToolMetadataTestServer(t, ToolMetadataConfig{})
+
+		executeWithMetadata(t, server, conn, map[string]string{
+			"Accept-Language": "en\r\nX-API-Key: stolen-key",
+			"baggage":         "userId=alice",
+		})
+
+		assert.Equal(t, map[string]string{"Baggage": "userId=alice"}, catalog.headers)
+	})
+
+	t.Run("connection metadata is forwarded with every execution", func(t *testing.T) {
+		server, catalog, conn := newToolMetadataTestServer(t, ToolMetadataConfig{})
+
+		params, err := json.Marshal(map[string]interface{}{
+			"agentId": "agent-1",
+			"toolMetadata": map[string]string{
+				"Accept-Language": "de-DE",
+				"tracestate":      "vendor=value",
+				"Authorization":   "Bearer stolen",
+			},
+		})
+		require.NoError(t, err)
+		_, err = server.handleInitialize(context.Background(), conn, params)
+		require.NoError(t, err)
+
+		executeWithMetadata(t, server, conn, nil)
+		assert.Equal(t, map[string]string{
+			"Accept-Language": "de-DE",
+			"Tracestate":      "vendor=value",
+		}, catalog.headers)
+
+		// Request metadata overrides the connection's
+		executeWithMetadata(t, server, conn, map[string]string{"accept-language": "ja-JP"})
+		assert.Equal(t, map[string]string{
+			"Accept-Language": "ja-JP",
+			"Tracestate":      "vendor=value",
+		}, catalog.headers)
+	})
+
+	t.Run("nothing is forwarded without metadata", func(t *testing.T) {
+		server, catalog, conn := newToolMetadataTestServer(t, ToolMetadataConfig{})
+
+		executeWithMetadata(t, server, conn, nil)
+		assert.Nil(t, catalog.headers)
+	})
+}
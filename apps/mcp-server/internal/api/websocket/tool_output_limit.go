@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/common/cache"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// Tool output limit policies
+const (
+	ToolOutputPolicyTruncate = "truncate"
+	ToolOutputPolicyReject   = "reject"
+)
+
+// ToolResultsPath is the API path truncated tool results can be downloaded from
+const ToolResultsPath = "/api/v1/tool-results/"
+
+// ToolOutputLimitConfig configures tool output size caps
+type ToolOutputLimitConfig struct {
+	MaxBytes  int            `mapstructure:"max_bytes"`  // Default cap on encoded output (negative disables)
+	PerTool   map[string]int `mapstructure:"per_tool"`   // Caps by requested tool ID or name
+	Policy    string         `mapstructure:"policy"`     // truncate (default) or reject
+	ResultTTL time.Duration  `mapstructure:"result_ttl"` // How long full results of truncated output stay downloadable
+}
+
+// DefaultToolOutputLimitConfig returns default tool output limit configuration
+func DefaultToolOutputLimitConfig() ToolOutputLimitConfig {
+	return ToolOutputLimitConfig{
+		MaxBytes:  5 * 1024 * 1024, // Half the advertised max message size
+		Policy:    ToolOutputPolicyTruncate,
+		ResultTTL: 15 * time.Minute,
+	}
+}
+
+// ToolOutputLimiter enforces tool output size caps and keeps the full result of
+// truncated output available for download
+type ToolOutputLimiter struct {
+	config ToolOutputLimitConfig
+	store  cache.Cache
+}
+
+// NewToolOutputLimiter creates a new tool output limiter
+func NewToolOutputLimiter(config ToolOutputLimitConfig, store cache.Cache) *ToolOutputLimiter {
+	defaults := DefaultToolOutputLimitConfig()
+	if config.MaxBytes == 0 {
+		config.MaxBytes = defaults.MaxBytes
+	}
+	if config.Policy == "" {
+		config.Policy = defaults.Policy
+	}
+	if config.ResultTTL <= 0 {
+		config.ResultTTL = defaults.ResultTTL
+	}
+
+	return &ToolOutputLimiter{
+		config: config,
+		store:  store,
+	}
+}
+
+// SetStore replaces the store holding full results, e.g. with a shared cache
+func (l *ToolOutputLimiter) SetStore(store cache.Cache) {
+	l.store = store
+}
+
+// limitFor returns the output cap for a tool, or 0 if output is not limited
+func (l *ToolOutputLimiter) limitFor(toolID string) int {
+	if l == nil {
+		return 0
+	}
+	limit := l.config.MaxBytes
+	if perTool, ok := l.config.PerTool[toolID]; ok {
+		limit = perTool
+	}
+	if limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// Apply enforces the output cap for a tool. Results within the cap are returned
+// unchanged. Under the truncate policy, larger results are replaced with a prefix
+// of their JSON encoding and a reference to download the full result; under the
+// reject policy they produce an error.
+func (l *ToolOutputLimiter) Apply(ctx context.Context, tenantID, toolID string, result interface{}) (interface{}, map[string]interface{}, error) {
+	limit := l.limitFor(toolID)
+	if limit == 0 || result == nil {
+		return result, nil, nil
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil || len(data) <= limit {
+		return result, nil, nil
+	}
+
+	if l.config.Policy == ToolOutputPolicyReject {
+		return nil, nil, ws.NewError(ws.ErrCodeServerError, "Tool output exceeds size limit", map[string]interface{}{
+			"size":  len(data),
+			"limit": limit,
+		})
+	}
+
+	resultID := uuid.New().String()
+	if err := l.store.Set(ctx, toolResultKey(tenantID, resultID), data, l.config.ResultTTL); err != nil {
+		return nil, nil, fmt.Errorf("failed to store full tool result: %w", err)
+	}
+
+	reference := map[string]interface{}{
+		"result_id":  resultID,
+		"url":        ToolResultsPath + resultID,
+		"size":       len(data),
+		"limit":      limit,
+		"expires_at": time.Now().Add(l.config.ResultTTL).Format(time.RFC3339),
+	}
+	return truncateUTF8(data, limit), reference, nil
+}
+
+// FullResult returns the JSON encoding of a truncated tool result
+func (l *ToolOutputLimiter) FullResult(ctx context.Context, tenantID, resultID string) ([]byte, error) {
+	var data []byte
+	if err := l.store.Get(ctx, toolResultKey(tenantID, resultID), &data); err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, cache.ErrNotFound
+	}
+	return data, nil
+}
+
+func toolResultKey(tenantID, resultID string) string {
+	return fmt.Sprintf("tool_result:%s:%s", tenantID, resultID)
+}
+
+// truncateUTF8 returns at most limit bytes of data without splitting a UTF-8 sequence
+func truncateUTF8(data []byte, limit int) string {
+	if len(data) <= limit {
+		return string(data)
+	}
+	end := limit
+	for end > 0 && !utf8.RuneStart(data[end]) {
+		end--
+	}
+	return string(data[:end])
+}
+
+// limitToolOutput sets a tool's result on a tool.execute response, enforcing the output cap
+func (s *Server) limitToolOutput(ctx context.Context, conn *Connection, toolID string, response map[string]interface{}, result interface{}) error {
+	limited, reference, err := s.toolOutputLimit.Apply(ctx, conn.TenantID, toolID, result)
+	if err != nil {
+		return err
+	}
+
+	response["result"] = limited
+	if reference != nil {
+		response["truncated"] = true
+		response["full_result"] = reference
+		s.logger.Warn("Truncated tool output", map[string]interface{}{
+			"tenant_id": conn.TenantID,
+			"agent_id":  conn.AgentID,
+			"tool_id":   toolID,
+			"size":      reference["size"],
+			"limit":     reference["limit"],
+		})
+	}
+	return nil
+}
+
+// ToolResultEndpoints provides HTTP endpoints for downloading truncated tool results
+type ToolResultEndpoints struct {
+	server *Server
+}
+
+// NewToolResultEndpoints creates new tool result endpoints
+func NewToolResultEndpoints(server *Server) *ToolResultEndpoints {
+	return &ToolResultEndpoints{
+		server: server,
+	}
+}
+
+// RegisterRoutes registers tool result routes with gin
+func (e *ToolResultEndpoints) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/tool-results/:id", e.handleGetToolResult)
+}
+
+// handleGetToolResult returns the full result of a truncated tool execution
+func (e *ToolResultEndpoints) handleGetToolResult(c *gin.Context) {
+	tenantID := ""
+	if value, ok := c.Get("tenant_id"); ok {
+		tenantID = fmt.Sprint(value)
+	}
+	if tenantID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "tenant ID required"})
+		return
+	}
+
+	data, err := e.server.toolOutputLimit.FullResult(c.Request.Context(), tenantID, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tool result not found or expired"})
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", data)
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+func newToolOutputTestServer(t *testing.T, config ToolOutputLimitConfig, result interface{}) (*Server, *Connection) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{ToolOutputLimit: config})
+	server.SetToolRegistry(&stubToolRegistry{result: result})
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	return server, conn
+}
+
+func TestToolOutputTruncatedWithFullResultReference(t *testing.T) {
+	body := map[string]interface{}{"log": strings.Repeat("é", 200)}
+	server, conn := newToolOutputTestServer(t, ToolOutputLimitConfig{MaxBytes: 100}, body)
+
+	response, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "logs"}`))
+	require.NoError(t, err)
+
+	result := response.(map[string]interface{})
+	assert.Equal(t, true, result["truncated"])
+
+	preview, ok := result["result"].(string)
+	require.True(t, ok)
+	assert.LessOrEqual(t, len(preview), 100)
+	assert.True(t, strings.HasPrefix(preview, `{"log":"é`))
+
+	reference := result["full_result"].(map[string]interface{})
+	resultID := reference["result_id"].(string)
+	assert.Equal(t, ToolResultsPath+resultID, reference["url"])
+	assert.Equal(t, 100, reference["limit"])
+
+	// The full result can be downloaded by the same tenant only
+	gin.SetMode(gin.TestMode)
+	download := func(tenantID string) *httptest.ResponseRecorder {
+		router := gin.New()
+		group := router.Group("/api/v1", func(c *gin.Context) { c.Set("tenant_id", tenantID) })
+		NewToolResultEndpoints(server).RegisterRoutes(group)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, reference["url"].(string), nil))
+		return w
+	}
+
+	w := download("tenant-1")
+	require.Equal(t, http.StatusOK, w.Code)
+	var full map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &full))
+	assert.Equal(t, body, full)
+
+	assert.Equal(t, http.StatusNotFound, download("tenant-2").Code)
+}
+
+func TestToolOutputWithinLimitUnchanged(t *testing.T) {
+	server, conn := newToolOutputTestServer(t, ToolOutputLimitConfig{MaxBytes: 1024}, "small result")
+
+	response, err := server.handleToolExecute(context.Background(), conn, json.RawMessage(`{"tool_id": "github", "action": "list"}`))
+	require.NoError(t, err)
+
+	result := response.(map[string]interface{})
+	assert.Equal(t, "small result", result["result"])
+	assert.NotContains(t, result, "truncated")
+	assert.NotContains(t, result, "full_result")
+}
+
+func TestToolOutputRejectPolicyAndPerToolLimits(t *testing.T) {
+	server, conn := newToolOutputTestServer(t, ToolOutputLimitConfig{
+		MaxBytes: 10,
+		PerTool:  map[string]int{"logs": 1024, "unlimited": -1},
+		Policy:   ToolOutputPolicyReject,
+	}, strings.Repeat("x", 100))
+	ctx := context.Background()
+
+	_, err := server.handleToolExecute(ctx, conn, json.RawMessage(`{"tool_id": "github", "action": "list"}`))
+	require.Error(t, err)
+	wsErr, ok := err.(*ws.Error)
+	require.True(t, ok)
+	assert.Equal(t, ws.ErrCodeServerError, wsErr.Code)
+	assert.Equal(t, 10, wsErr.Data.(map[string]interface{})["limit"])
+
+	for _, toolID := range []string{"logs", "unlimited"} {
+		response, err := server.handleToolExecute(ctx, conn, json.RawMessage(`{"tool_id": "`+toolID+`", "action": "list"}`))
+		require.NoError(t, err, toolID)
+		assert.Equal(t, strings.Repeat("x", 100), response.(map[string]interface{})["result"])
+	}
+}
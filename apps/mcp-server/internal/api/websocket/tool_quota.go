@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"sync"
+	"time"
+)
+
+// ToolQuotaConfig configures per-agent tool execution quotas
+type ToolQuotaConfig struct {
+	MaxExecutions int           `mapstructure:"max_executions"` // Executions allowed per window; 0 disables quotas
+	Window        time.Duration `mapstructure:"window"`
+}
+
+// DefaultToolQuotaConfig returns default tool quota configuration. Quotas are
+// opt-in, so MaxExecutions is 0.
+func DefaultToolQuotaConfig() ToolQuotaConfig {
+	return ToolQuotaConfig{
+		Window: time.Minute,
+	}
+}
+
+// ToolQuota reports an agent's tool execution quota for the current window
+type ToolQuota struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// toMap renders the quota for inclusion in a response
+func (q ToolQuota) toMap() map[string]interface{} {
+	resetIn := time.Until(q.ResetAt)
+	if resetIn < 0 {
+		resetIn = 0
+	}
+	return map[string]interface{}{
+		"limit":       q.Limit,
+		"remaining":   q.Remaining,
+		"reset_at":    q.ResetAt.Format(time.RFC3339),
+		"reset_in_ms": resetIn.Milliseconds(),
+	}
+}
+
+type toolQuotaWindow struct {
+	start time.Time
+	used  int
+}
+
+// ToolQuotaLimiter tracks tool executions per tenant and agent in fixed windows
+type ToolQuotaLimiter struct {
+	config    ToolQuotaConfig
+	mu        sync.Mutex
+	windows   map[string]*toolQuotaWindow
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewToolQuotaLimiter creates a new tool quota limiter
+func NewToolQuotaLimiter(config ToolQuotaConfig) *ToolQuotaLimiter {
+	if config.Window <= 0 {
+		config.Window = DefaultToolQuotaConfig().Window
+	}
+
+	return &ToolQuotaLimiter{
+		config:  config,
+		windows: make(map[string]*toolQuotaWindow),
+		now:     time.Now,
+	}
+}
+
+// Enabled reports whether quotas are enforced
+func (l *ToolQuotaLimiter) Enabled() bool {
+	return l != nil && l.config.MaxExecutions > 0
+}
+
+// Consume records an execution and returns the resulting quota. It returns false
+// without consuming anything when the quota for the window is exhausted.
+func (l *ToolQuotaLimiter) Consume(tenantID, agentID string) (ToolQuota, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	window := l.windowLocked(tenantID + ":" + agentID)
+	if window.used >= l.config.MaxExecutions {
+		return l.quotaLocked(window), false
+	}
+	window.used++
+	return l.quotaLocked(window), true
+}
+
+// Status returns the current quota without consuming an execution
+func (l *ToolQuotaLimiter) Status(tenantID, agentID string) ToolQuota {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	window, ok := l.windows[tenantID+":"+agentID]
+	if !ok || now.Sub(window.start) >= l.config.Window {
+		// Nothing consumed yet, so don't track the agent until it executes
+		window = &toolQuotaWindow{start: now}
+	}
+	return l.quotaLocked(window)
+}
+
+// windowLocked returns the active window for a key, starting a new one if the previous expired
+func (l *ToolQuotaLimiter) windowLocked(key string) *toolQuotaWindow {
+	now := l.now()
+	l.pruneLocked(now)
+
+	window, ok := l.windows[key]
+	if !ok || now.Sub(window.start) >= l.config.Window {
+		window = &toolQuotaWindow{start: now}
+		l.windows[key] = window
+	}
+	return window
+}
+
+// pruneLocked drops expired windows, at most once a window, so agents that
+// stopped executing tools aren't tracked forever
+func (l *ToolQuotaLimiter) pruneLocked(now time.Time) {
+	if now.Sub(l.lastPrune) < l.config.Window {
+		return
+	}
+	l.lastPrune = now
+	for key, window := range l.windows {
+		if now.Sub(window.start) >= l.config.Window {
+			delete(l.windows, key)
+		}
+	}
+}
+
+func (l *ToolQuotaLimiter) quotaLocked(window *toolQuotaWindow) ToolQuota {
+	return ToolQuota{
+		Limit:     l.config.MaxExecutions,
+		Remaining: l.config.MaxExecutions - window.used,
+		ResetAt:   window.start.Add(l.config.Window),
+	}
+}
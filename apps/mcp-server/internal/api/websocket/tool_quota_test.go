@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+func TestToolQuotaLimiterResetsAfterWindow(t *testing.T) {
+	now := time.Now()
+	limiter := NewToolQuotaLimiter(ToolQuotaConfig{MaxExecutions: 2, Window: time.Minute})
+	limiter.now = func() time.Time { return now }
+
+	q, ok := limiter.Consume("tenant-1", "agent-1")
+	require.True(t, ok)
+	assert.Equal(t, 1, q.Remaining)
+
+	_, ok = limiter.Consume("tenant-1", "agent-1")
+	require.True(t, ok)
+
+	q, ok = limiter.Consume("tenant-1", "agent-1")
+	assert.False(t, ok)
+	assert.Equal(t, 0, q.Remaining)
+	assert.Equal(t, now.Add(time.Minute), q.ResetAt)
+
+	// Other agents have their own quota
+	assert.Equal(t, 2, limiter.Status("tenant-1", "agent-2").Remaining)
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 2, limiter.Status("tenant-1", "agent-1").Remaining)
+
+	// Expired windows are dropped once another agent executes
+	_, ok = limiter.Consume("tenant-1", "agent-3")
+	require.True(t, ok)
+	assert.Len(t, limiter.windows, 1)
+	assert.Contains(t, limiter.windows, "tenant-1:agent-3")
+}
+
+func TestToolQuotaLimiterIsOptIn(t *testing.T) {
+	assert.False(t, NewToolQuotaLimiter(DefaultToolQuotaConfig()).Enabled())
+	assert.False(t, NewToolQuotaLimiter(ToolQuotaConfig{}).Enabled())
+	assert.True(t, NewToolQuotaLimiter(ToolQuotaConfig{MaxExecutions: 1}).Enabled())
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{})
+	server.SetToolRegistry(&stubToolRegistry{result: "ok"})
+	conn := NewConnection("conn-1", nil, server)
+	list, err := server.handleToolList(context.Background(), conn, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, list.(map[string]interface{}), "quota")
+}
+
+func TestToolQuotaReportedInResponses(t *testing.T) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ToolQuota: ToolQuotaConfig{MaxExecutions: 2, Window: time.Minute},
+	})
+	server.SetToolRegistry(&stubToolRegistry{result: "ok"})
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	ctx := context.Background()
+	params := json.RawMessage(`{"tool_id": "github", "action": "list"}`)
+
+	quotaOf := func(result interface{}) map[string]interface{} {
+		return result.(map[string]interface{})["quota"].(map[string]interface{})
+	}
+
+	list, err := server.handleToolList(ctx, conn, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, quotaOf(list)["remaining"])
+
+	result, err := server.handleToolExecute(ctx, conn, params)
+	require.NoError(t, err)
+	assert.Equal(t, 1, quotaOf(result)["remaining"])
+	assert.Equal(t, 2, quotaOf(result)["limit"])
+
+	list, err = server.handleToolList(ctx, conn, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, quotaOf(list)["remaining"])
+
+	_, err = server.handleToolExecute(ctx, conn, params)
+	require.NoError(t, err)
+
+	_, err = server.handleToolExecute(ctx, conn, params)
+	require.Error(t, err)
+	wsErr, ok := err.(*ws.Error)
+	require.True(t, ok)
+	assert.Equal(t, ws.ErrCodeRateLimited, wsErr.Code)
+	assert.Contains(t, wsErr.Data.(map[string]interface{}), "retry_after_ms")
+
+	// Quota resets once the window elapses
+	server.toolQuota.now = func() time.Time { return time.Now().Add(time.Minute) }
+	list, err = server.handleToolList(ctx, conn, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, quotaOf(list)["remaining"])
+}
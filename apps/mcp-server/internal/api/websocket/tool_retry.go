@@ -0,0 +1,155 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// ToolRetryConfig configures retries of tool executions that fail with a
+// retryable error, for tools whose providers don't retry themselves. Retries
+// are disabled unless MaxRetries is positive.
+type ToolRetryConfig struct {
+	MaxRetries     int           `mapstructure:"max_retries"`     // Default retries per request, and the most a request may ask for
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"` // Backoff before the first retry, doubled for each one after
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// DefaultToolRetryConfig returns default tool retry configuration
+func DefaultToolRetryConfig() ToolRetryConfig {
+	return ToolRetryConfig{
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+	}
+}
+
+// retryableToolErrorCategories are failures that may succeed when repeated.
+// Tool and auth errors fail the same way every time.
+var retryableToolErrorCategories = map[string]bool{
+	ToolErrorCategoryRateLimit: true,
+	ToolErrorCategoryInfra:     true,
+}
+
+// ToolRetryPolicy retries failed tool executions with jittered exponential backoff
+type ToolRetryPolicy struct {
+	config ToolRetryConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// NewToolRetryPolicy creates a new tool retry policy
+func NewToolRetryPolicy(config ToolRetryConfig) *ToolRetryPolicy {
+	defaults := DefaultToolRetryConfig()
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = defaults.InitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+
+	return &ToolRetryPolicy{
+		config: config,
+		sleep:  sleepContext,
+	}
+}
+
+// Enabled reports whether failed executions are retried
+func (p *ToolRetryPolicy) Enabled() bool {
+	return p != nil && p.config.MaxRetries > 0
+}
+
+// MaxRetries returns the retries allowed for a request, which may ask for
+// fewer than the configured maximum but not more
+func (p *ToolRetryPolicy) MaxRetries(requested *int) int {
+	if !p.Enabled() {
+		return 0
+	}
+	if requested == nil || *requested > p.config.MaxRetries {
+		return p.config.MaxRetries
+	}
+	if *requested < 0 {
+		return 0
+	}
+	return *requested
+}
+
+// Backoff returns the jittered delay before a retry, counting from 1. The
+// delay is drawn from the upper half of the exponential backoff so retries
+// from many agents spread out without collapsing to zero.
+func (p *ToolRetryPolicy) Backoff(retry int) time.Duration {
+	backoff := p.config.InitialBackoff
+	for i := 1; i < retry && backoff < p.config.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > p.config.MaxBackoff {
+		backoff = p.config.MaxBackoff
+	}
+
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryToolExecution runs execute until it succeeds, fails with an error that
+// isn't retryable, or maxRetries retries are used. execute returns the failure's
+// error category, or an empty string on success. The connection receives a
+// tool.progress notification before each retry. It returns the retries made.
+func (s *Server) retryToolExecution(ctx context.Context, conn *Connection, toolID string, maxRetries int, execute func() string) int {
+	retries := 0
+	for {
+		category := execute()
+		if category == "" || !retryableToolErrorCategories[category] || retries >= maxRetries || ctx.Err() != nil {
+			return retries
+		}
+		retries++
+
+		backoff := s.toolRetry.Backoff(retries)
+		if err := conn.SendNotification("tool.progress", map[string]interface{}{
+			"tool":              toolID,
+			"current_operation": "retry",
+			"message":           fmt.Sprintf("Retrying after %s (retry %d of %d)", category, retries, maxRetries),
+			"retry":             retries,
+			"max_retries":       maxRetries,
+			"error_category":    category,
+			"retry_in_ms":       backoff.Milliseconds(),
+			"timestamp":         time.Now().Format(time.RFC3339),
+		}); err != nil {
+			s.logger.Debug("Failed to send tool retry progress", map[string]interface{}{
+				"connection_id": conn.ID,
+				"tool_id":       toolID,
+				"error":         err.Error(),
+			})
+		}
+
+		if err := s.toolRetry.sleep(ctx, backoff); err != nil {
+			return retries - 1
+		}
+	}
+}
+
+// withToolRetries records the retries made before a tool execution failed
+func withToolRetries(toolErr *ws.Error, retries int) *ws.Error {
+	if retries > 0 {
+		if data, ok := toolErr.Data.(map[string]interface{}); ok {
+			data["retries"] = retries
+		}
+	}
+	return toolErr
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
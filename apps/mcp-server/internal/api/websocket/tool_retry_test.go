@@ -0,0 +1,238 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+// flakyToolCatalog fails the first executions with the given failures, then succeeds
+type flakyToolCatalog struct {
+	stubToolCatalog
+	mu       sync.Mutex
+	failures []func() (*models.ToolExecutionResponse, error)
+	calls    int
+}
+
+func (c *flakyToolCatalog) ExecuteTool(ctx context.Context, tenantID, toolID, action string, params map[string]interface{}) (*models.ToolExecutionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.calls <= len(c.failures) {
+		return c.failures[c.calls-1]()
+	}
+	return &models.ToolExecutionResponse{Success: true, StatusCode: 200, Body: map[string]interface{}{"merged": true}}, nil
+}
+
+func transientError() (*models.ToolExecutionResponse, error) {
+	return nil, errors.New("HTTP 503: service unavailable")
+}
+
+func throttledResult() (*models.ToolExecutionResponse, error) {
+	return &models.ToolExecutionResponse{StatusCode: 429, Error: "HTTP 429: slow down"}, nil
+}
+
+func toolBusinessError() (*models.ToolExecutionResponse, error) {
+	return &models.ToolExecutionResponse{StatusCode: 422, Error: "HTTP 422: Validation Failed"}, nil
+}
+
+// newToolRetryTestServer returns a server retrying up to maxRetries times without sleeping
+func newToolRetryTestServer(maxRetries int, catalog *flakyToolCatalog) (*Server, *Connection, *[]time.Duration) {
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		ToolRetry: ToolRetryConfig{MaxRetries: maxRetries, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+	})
+	var slept []time.Duration
+	server.toolRetry.sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return ctx.Err()
+	}
+	server.SetRESTClient(catalog)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	return server, conn, &slept
+}
+
+func executeRetriedTool(t *testing.T, ctx context.Context, server *Server, conn *Connection, extra map[string]interface{}) (interface{}, error) {
+	t.Helper()
+	params := map[string]interface{}{
+		"tool_id": "11111111-1111-1111-1111-111111111111",
+		"action":  "merge_pull_request",
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+	return server.handleToolExecute(ctx, conn, data)
+}
+
+// drainToolProgress returns the tool.progress notifications sent on a connection
+func drainToolProgress(t *testing.T, conn *Connection) []map[string]interface{} {
+	t.Helper()
+	var progress []map[string]interface{}
+	for {
+		select {
+		case data := <-conn.send:
+			var msg ws.Message
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Method == "tool.progress" {
+				progress = append(progress, msg.Params.(map[string]interface{}))
+			}
+		default:
+			return progress
+		}
+	}
+}
+
+func TestToolExecuteRetriesTransientFailures(t *testing.T) {
+	catalog := &flakyToolCatalog{failures: []func() (*models.ToolExecutionResponse, error){transientError, throttledResult}}
+	server, conn, slept := newToolRetryTestServer(3, catalog)
+
+	result, err := executeRetriedTool(t, context.Background(), server, conn, nil)
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, "completed", response["status"])
+	assert.Equal(t, 2, response["retries"])
+	assert.Equal(t, 3, catalog.calls)
+
+	require.Len(t, *slept, 2)
+	assert.GreaterOrEqual(t, (*slept)[0], 50*time.Millisecond)
+	assert.LessOrEqual(t, (*slept)[0], 100*time.Millisecond)
+	assert.GreaterOrEqual(t, (*slept)[1], 100*time.Millisecond, "backoff grows between retries")
+	assert.LessOrEqual(t, (*slept)[1], 200*time.Millisecond)
+
+	progress := drainToolProgress(t, conn)
+	require.Len(t, progress, 2, "each retry is reported")
+	assert.Equal(t, ToolErrorCategoryInfra, progress[0]["error_category"])
+	assert.Equal(t, ToolErrorCategoryRateLimit, progress[1]["error_category"])
+	assert.Equal(t, 2.0, progress[1]["retry"])
+	assert.Equal(t, 3.0, progress[1]["max_retries"])
+}
+
+func TestToolExecuteRetryLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   int
+		failures     []func() (*models.ToolExecutionResponse, error)
+		params       map[string]interface{}
+		wantCalls    int
+		wantStatus   string
+		wantErr      bool
+		wantRetries  interface{}
+		wantProgress int
+	}{
+		{
+			name:         "gives up at the cap",
+			maxRetries:   2,
+			failures:     []func() (*models.ToolExecutionResponse, error){transientError, transientError, transientError, transientError},
+			wantCalls:    3,
+			wantErr:      true,
+			wantRetries:  2,
+			wantProgress: 2,
+		},
+		{
+			name:         "request lowers the cap",
+			maxRetries:   5,
+			failures:     []func() (*models.ToolExecutionResponse, error){throttledResult, throttledResult, throttledResult},
+			params:       map[string]interface{}{"max_retries": 1},
+			wantCalls:    2,
+			wantStatus:   "failed",
+			wantRetries:  1,
+			wantProgress: 1,
+		},
+		{
+			name:         "request can't raise the cap",
+			maxRetries:   1,
+			failures:     []func() (*models.ToolExecutionResponse, error){transientError, transientError},
+			params:       map[string]interface{}{"max_retries": 10},
+			wantCalls:    2,
+			wantErr:      true,
+			wantRetries:  1,
+			wantProgress: 1,
+		},
+		{
+			name:       "tool errors aren't retried",
+			maxRetries: 3,
+			failures:   []func() (*models.ToolExecutionResponse, error){toolBusinessError},
+			wantCalls:  1,
+			wantStatus: "failed",
+		},
+		{
+			name:       "retries disabled",
+			maxRetries: 0,
+			failures:   []func() (*models.ToolExecutionResponse, error){transientError},
+			wantCalls:  1,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalog := &flakyToolCatalog{failures: tt.failures}
+			server, conn, _ := newToolRetryTestServer(tt.maxRetries, catalog)
+
+			result, err := executeRetriedTool(t, context.Background(), server, conn, tt.params)
+			assert.Equal(t, tt.wantCalls, catalog.calls)
+			assert.Len(t, drainToolProgress(t, conn), tt.wantProgress)
+
+			if tt.wantErr {
+				var wsErr *ws.Error
+				require.True(t, errors.As(err, &wsErr))
+				data := wsErr.Data.(map[string]interface{})
+				assert.Equal(t, tt.wantRetries, data["retries"])
+				return
+			}
+			require.NoError(t, err)
+			response := result.(map[string]interface{})
+			assert.Equal(t, tt.wantStatus, response["status"])
+			assert.Equal(t, tt.wantRetries, response["retries"])
+		})
+	}
+}
+
+func TestToolExecuteRetryStopsWhenCanceled(t *testing.T) {
+	catalog := &flakyToolCatalog{failures: []func() (*models.ToolExecutionResponse, error){transientError, transientError}}
+	server, conn, _ := newToolRetryTestServer(3, catalog)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := executeRetriedTool(t, ctx, server, conn, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1, catalog.calls, "no retry once the request is canceled")
+}
+
+func TestToolRetryPolicyBackoff(t *testing.T) {
+	policy := NewToolRetryPolicy(ToolRetryConfig{MaxRetries: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 500 * time.Millisecond})
+
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 3, max: 400 * time.Millisecond},
+		{retry: 4, max: 500 * time.Millisecond},
+		{retry: 50, max: 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			backoff := policy.Backoff(tt.retry)
+			assert.GreaterOrEqual(t, backoff, tt.max/2)
+			assert.LessOrEqual(t, backoff, tt.max)
+		}
+	}
+}
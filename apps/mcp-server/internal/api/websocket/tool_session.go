@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"context"
+
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+const (
+	// ToolSessionContextArg is the tool argument session-bound executions receive
+	// the session context in. Tools that don't read it ignore it.
+	ToolSessionContextArg = "_session"
+
+	// toolSessionMessageLimit is how many of the session's most recent messages
+	// are passed to the tool
+	toolSessionMessageLimit = 10
+)
+
+// toolSessionContext builds the session context passed to a session-bound tool
+// execution. An empty session ID binds the connection's active session.
+func (s *Server) toolSessionContext(ctx context.Context, conn *Connection, sessionID string) (map[string]interface{}, error) {
+	if sessionID == "" {
+		sessionID = conn.GetActiveSession()
+		if sessionID == "" {
+			return nil, ws.NewError(ws.ErrCodeInvalidParams, "No active session to bind", nil)
+		}
+	}
+
+	if s.conversationManager == nil {
+		return nil, ws.NewError(ws.ErrCodeServerError, "Sessions are not available", nil)
+	}
+
+	session, err := s.conversationManager.GetSession(ctx, sessionID)
+	// Sessions of other tenants are reported as missing
+	if err != nil || session.TenantID != conn.TenantID {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Session not found", map[string]interface{}{
+			"session_id": sessionID,
+		})
+	}
+	if session.IsExpired() {
+		return nil, ws.NewError(ws.ErrCodeInvalidParams, "Session has expired", map[string]interface{}{
+			"session_id": sessionID,
+		})
+	}
+
+	offset := len(session.Messages) - toolSessionMessageLimit
+	if offset < 0 {
+		offset = 0
+	}
+
+	return map[string]interface{}{
+		"session_id":      session.ID,
+		"name":            session.Name,
+		"agent_id":        session.AgentID,
+		"state":           session.State,
+		"recent_messages": session.GetMessages(toolSessionMessageLimit, offset),
+		"message_count":   len(session.Messages),
+	}, nil
+}
@@ -0,0 +1,172 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// recordingToolRegistry records the arguments tools are executed with
+type recordingToolRegistry struct {
+	stubToolRegistry
+	args map[string]interface{}
+}
+
+func (r *recordingToolRegistry) ExecuteTool(ctx context.Context, agentID, toolID string, args map[string]interface{}) (interface{}, error) {
+	r.args = args
+	return map[string]interface{}{"ok": true}, nil
+}
+
+func newToolSessionTestServer(t *testing.T) (*Server, *recordingToolRegistry, *Connection) {
+	t.Helper()
+
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	registry := &recordingToolRegistry{}
+	server.SetToolRegistry(registry)
+
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+
+	return server, registry, conn
+}
+
+func createToolSession(t *testing.T, server *Server, id, tenantID string, messages int) *Session {
+	t.Helper()
+
+	session, err := server.conversationManager.CreateSession(context.Background(), &SessionConfig{
+		ID:       id,
+		Name:     "triage",
+		AgentID:  "agent-1",
+		TenantID: tenantID,
+		State:    map[string]interface{}{"repository": "developer-mesh", "issue": 42},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < messages; i++ {
+		_, err := server.conversationManager.AddMessage(context.Background(), id, map[string]interface{}{
+			"role":    "user",
+			"content": fmt.Sprintf("message %d", i),
+		})
+		require.NoError(t, err)
+	}
+	return session
+}
+
+func executeTool(server *Server, conn *Connection, params map[string]interface{}) (interface{}, error) {
+	raw, _ := json.Marshal(params)
+	return server.handleToolExecute(context.Background(), conn, raw)
+}
+
+func TestToolExecuteSessionBinding(t *testing.T) {
+	t.Run("bound execution receives the session context", func(t *testing.T) {
+		server, registry, conn := newToolSessionTestServer(t)
+		createToolSession(t, server, "session-1", "tenant-1", 15)
+
+		result, err := executeTool(server, conn, map[string]interface{}{
+			"tool_id":    "github",
+			"action":     "comment",
+			"parameters": map[string]interface{}{"body": "looking into it"},
+			"session_id": "session-1",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "session-1", result.(map[string]interface{})["session_id"])
+
+		assert.Equal(t, "looking into it", registry.args["body"])
+		sessionContext, ok := registry.args[ToolSessionContextArg].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "session-1", sessionContext["session_id"])
+		assert.Equal(t, map[string]interface{}{"repository": "developer-mesh", "issue": 42}, sessionContext["state"])
+		assert.Equal(t, 15, sessionContext["message_count"])
+
+		// Only the most recent messages are passed
+		recent := sessionContext["recent_messages"].([]SessionMessage)
+		require.Len(t, recent, toolSessionMessageLimit)
+		assert.Equal(t, "message 5", recent[0].Content)
+		assert.Equal(t, "message 14", recent[len(recent)-1].Content)
+	})
+
+	t.Run("bind_session binds the active session", func(t *testing.T) {
+		server, registry, conn := newToolSessionTestServer(t)
+		createToolSession(t, server, "session-1", "tenant-1", 1)
+		conn.SetActiveSession("session-1")
+
+		_, err := executeTool(server, conn, map[string]interface{}{
+			"tool_id":      "github",
+			"action":       "comment",
+			"bind_session": true,
+		})
+		require.NoError(t, err)
+
+		sessionContext, ok := registry.args[ToolSessionContextArg].(map[string]interface{})
+		require.True(t, ok)
+		assert.Equal(t, "session-1", sessionContext["session_id"])
+	})
+
+	t.Run("unbound execution receives no session context", func(t *testing.T) {
+		server, registry, conn := newToolSessionTestServer(t)
+		createToolSession(t, server, "session-1", "tenant-1", 1)
+		conn.SetActiveSession("session-1")
+
+		result, err := executeTool(server, conn, map[string]interface{}{
+			"tool_id":    "github",
+			"action":     "comment",
+			"parameters": map[string]interface{}{"body": "looking into it"},
+		})
+		require.NoError(t, err)
+
+		assert.NotContains(t, result, "session_id")
+		assert.Equal(t, map[string]interface{}{"body": "looking into it"}, registry.args)
+	})
+
+	t.Run("session context is not audited", func(t *testing.T) {
+		server, _, conn := newToolSessionTestServer(t)
+		store := auth.NewInMemoryToolAuditStore(0, nil)
+		server.SetToolAuditStore(store)
+		createToolSession(t, server, "session-1", "tenant-1", 1)
+
+		_, err := executeTool(server, conn, map[string]interface{}{
+			"tool_id":    "github",
+			"action":     "comment",
+			"session_id": "session-1",
+		})
+		require.NoError(t, err)
+
+		records, err := store.Query(context.Background(), auth.ToolAuditFilter{TenantID: "tenant-1"})
+		require.NoError(t, err)
+		require.Len(t, records, 1)
+		assert.NotContains(t, records[0].Arguments, ToolSessionContextArg)
+	})
+
+	t.Run("unusable sessions are rejected", func(t *testing.T) {
+		server, registry, conn := newToolSessionTestServer(t)
+		createToolSession(t, server, "other-tenant", "tenant-2", 0)
+		expired := createToolSession(t, server, "expired", "tenant-1", 0)
+		expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+		for name, params := range map[string]map[string]interface{}{
+			"no active session": {"bind_session": true},
+			"unknown session":   {"session_id": "missing"},
+			"other tenant":      {"session_id": "other-tenant"},
+			"expired session":   {"session_id": "expired"},
+		} {
+			params["tool_id"] = "github"
+			params["action"] = "comment"
+
+			_, err := executeTool(server, conn, params)
+			var wsErr *ws.Error
+			require.ErrorAs(t, err, &wsErr, name)
+			assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code, name)
+		}
+		assert.Nil(t, registry.args)
+	})
+}
@@ -21,6 +21,7 @@ type WorkflowEngine struct {
 	notificationManager *NotificationManager
 	workflowService     services.WorkflowService
 	taskService         services.TaskService
+	limits              WorkflowLimitsConfig
 }
 
 // NewWorkflowEngine creates a new workflow engine
@@ -43,6 +44,11 @@ func (we *WorkflowEngine) SetNotificationManager(nm *NotificationManager) {
 	we.notificationManager = nm
 }
 
+// SetLimits sets the limits workflows are checked against when created and executed
+func (we *WorkflowEngine) SetLimits(limits WorkflowLimitsConfig) {
+	we.limits = limits
+}
+
 // WorkflowDefinition defines a multi-step workflow
 type WorkflowDefinition struct {
 	ID        string                   `json:"id"`
@@ -81,6 +87,9 @@ func (we *WorkflowEngine) CreateWorkflow(ctx context.Context, def *WorkflowDefin
 	if len(def.Steps) == 0 {
 		return nil, fmt.Errorf("workflow must have at least one step")
 	}
+	if err := we.limits.Check(def.Steps); err != nil {
+		return nil, err
+	}
 
 	// Store workflow
 	we.workflows.Store(def.ID, def)
@@ -113,6 +122,11 @@ func (we *WorkflowEngine) ExecuteWorkflow(ctx context.Context, workflowID string
 	}
 	workflow := val.(*WorkflowDefinition)
 
+	// Limits may have been lowered since the workflow was created
+	if err := we.limits.Check(workflow.Steps); err != nil {
+		return nil, err
+	}
+
 	// Create execution
 	execution := &WorkflowExecution{
 		ID:          uuid.New().String(),
@@ -135,6 +149,27 @@ func (we *WorkflowEngine) ExecuteWorkflow(ctx context.Context, workflowID string
 	return execution, nil
 }
 
+// stepCompletion summarizes a step result for the step completed notification
+func stepCompletion(stepID string, index int, result map[string]interface{}, startedAt time.Time) WorkflowStepCompletion {
+	completion := WorkflowStepCompletion{
+		StepID:      stepID,
+		StepIndex:   index,
+		Result:      result,
+		StartedAt:   startedAt,
+		CompletedAt: time.Now(),
+	}
+	completion.Status, _ = result["status"].(string)
+	completion.Error, _ = result["error"].(string)
+
+	// Tools report either free-form output or a structured result
+	if output, ok := result["output"]; ok {
+		completion.Output = output
+	} else {
+		completion.Output = result["result"]
+	}
+	return completion
+}
+
 // GetExecutionStatus retrieves workflow execution status
 func (we *WorkflowEngine) GetExecutionStatus(ctx context.Context, executionID string) (*WorkflowExecution, error) {
 	val, ok := we.executions.Load(executionID)
@@ -339,6 +374,7 @@ func (we *WorkflowEngine) runWorkflow(ctx context.Context, workflow *WorkflowDef
 			})
 			we.notificationManager.NotifyWorkflowStepStarted(ctx, workflow.ID, execution.ID, stepID)
 		}
+		stepStartedAt := time.Now()
 
 		// Execute step using actual services
 		var stepResult map[string]interface{}
@@ -439,7 +475,7 @@ func (we *WorkflowEngine) runWorkflow(ctx context.Context, workflow *WorkflowDef
 			"result":       stepResult,
 		})
 		if we.notificationManager != nil {
-			we.notificationManager.NotifyWorkflowStepCompleted(ctx, workflow.ID, execution.ID, stepID, stepResult)
+			we.notificationManager.NotifyWorkflowStepCompleted(ctx, workflow.ID, execution.ID, stepCompletion(stepID, i, stepResult, stepStartedAt))
 		}
 
 		// Mark step as executed
@@ -534,6 +570,10 @@ func (we *WorkflowEngine) CompleteWorkflowTask(ctx context.Context, executionID,
 
 // CreateCollaborativeWorkflow creates a workflow that involves multiple agents
 func (we *WorkflowEngine) CreateCollaborativeWorkflow(ctx context.Context, def *CollaborativeWorkflowDefinition) (*WorkflowDefinition, error) {
+	if err := we.limits.Check(def.Steps); err != nil {
+		return nil, err
+	}
+
 	workflow := &WorkflowDefinition{
 		ID:        uuid.New().String(),
 		Name:      def.Name,
@@ -576,6 +616,17 @@ func (we *WorkflowEngine) ExecuteCollaborativeWorkflow(ctx context.Context, work
 
 	workflow := val.(*WorkflowDefinition)
 
+	// The collaborative metadata step isn't subject to the limits
+	steps := make([]map[string]interface{}, 0, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		if stepType, _ := step["_type"].(string); stepType != "collaborative" {
+			steps = append(steps, step)
+		}
+	}
+	if err := we.limits.Check(steps); err != nil {
+		return nil, err
+	}
+
 	execution := &CollaborativeExecution{
 		ID:         uuid.New().String(),
 		WorkflowID: workflowID,
@@ -0,0 +1,190 @@
+package websocket
+
+import (
+	"fmt"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+)
+
+const (
+	// DefaultWorkflowMaxSteps is the default limit on a workflow's steps, nested steps included
+	DefaultWorkflowMaxSteps = 500
+	// DefaultWorkflowMaxDepth is the default limit on a workflow's chain of dependent or nested steps
+	DefaultWorkflowMaxDepth = 50
+)
+
+// workflowNestedStepKeys are the step fields holding nested steps, e.g. branches
+var workflowNestedStepKeys = []string{"steps", "branches", "parallel"}
+
+// WorkflowLimitsConfig bounds the size of workflows, so oversized definitions
+// can't exhaust resources when they are created or executed
+type WorkflowLimitsConfig struct {
+	MaxSteps int `mapstructure:"max_steps"` // Total steps, nested steps included; defaults to 500
+	MaxDepth int `mapstructure:"max_depth"` // Longest chain of dependent or nested steps; defaults to 50
+}
+
+// withDefaults fills in unset limits
+func (c WorkflowLimitsConfig) withDefaults() WorkflowLimitsConfig {
+	if c.MaxSteps <= 0 {
+		c.MaxSteps = DefaultWorkflowMaxSteps
+	}
+	if c.MaxDepth <= 0 {
+		c.MaxDepth = DefaultWorkflowMaxDepth
+	}
+	return c
+}
+
+// Check rejects workflows with more steps or deeper nesting than allowed
+func (c WorkflowLimitsConfig) Check(steps []map[string]interface{}) error {
+	limits := c.withDefaults()
+
+	if count := countWorkflowSteps(steps); count > limits.MaxSteps {
+		return ws.NewError(ws.ErrCodeInvalidParams,
+			fmt.Sprintf("workflow has %d steps, exceeding the limit of %d", count, limits.MaxSteps),
+			map[string]interface{}{"steps": count, "max_steps": limits.MaxSteps})
+	}
+
+	// Stop measuring once the limit is exceeded, so pathological nesting costs no more
+	if depth := workflowDepth(steps, limits.MaxDepth+1); depth > limits.MaxDepth {
+		return ws.NewError(ws.ErrCodeInvalidParams,
+			fmt.Sprintf("workflow nesting depth exceeds the limit of %d", limits.MaxDepth),
+			map[string]interface{}{"max_depth": limits.MaxDepth})
+	}
+	return nil
+}
+
+// CheckModelSteps applies the limits to stored workflow steps
+func (c WorkflowLimitsConfig) CheckModelSteps(steps models.WorkflowSteps) error {
+	converted := make([]map[string]interface{}, len(steps))
+	for i, step := range steps {
+		converted[i] = map[string]interface{}{
+			"id":         step.ID,
+			"depends_on": step.Dependencies,
+		}
+	}
+	return c.Check(converted)
+}
+
+// countWorkflowSteps counts steps, nested steps included
+func countWorkflowSteps(steps []map[string]interface{}) int {
+	count := len(steps)
+	for _, step := range steps {
+		for _, nested := range nestedWorkflowSteps(step) {
+			count += countWorkflowSteps(nested)
+		}
+	}
+	return count
+}
+
+// workflowDepth returns the longest chain of dependent or nested steps. A step
+// counts once plus the depth of its nested steps, and a chain adds up the steps
+// along its dependencies. Measurement stops at limit.
+func workflowDepth(steps []map[string]interface{}, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+
+	byID := make(map[string]map[string]interface{}, len(steps))
+	for _, step := range steps {
+		if id, ok := step["id"].(string); ok {
+			byID[id] = step
+		}
+	}
+
+	depths := make(map[string]int, len(steps))
+	visiting := make(map[string]bool)
+
+	var chainDepth func(step map[string]interface{}) int
+	chainDepth = func(step map[string]interface{}) int {
+		id, _ := step["id"].(string)
+		if id != "" {
+			if depth, ok := depths[id]; ok {
+				return depth
+			}
+			if visiting[id] {
+				// Dependency cycles don't add depth
+				return 0
+			}
+			visiting[id] = true
+			defer delete(visiting, id)
+		}
+
+		own := 1
+		for _, nested := range nestedWorkflowSteps(step) {
+			if depth := 1 + workflowDepth(nested, limit-1); depth > own {
+				own = depth
+			}
+		}
+
+		deepest := 0
+		for _, dep := range workflowStepDependencies(step) {
+			if depStep, ok := byID[dep]; ok {
+				if depth := chainDepth(depStep); depth > deepest {
+					deepest = depth
+				}
+			}
+			if own+deepest >= limit {
+				break
+			}
+		}
+
+		depth := own + deepest
+		if depth > limit {
+			depth = limit
+		}
+		if id != "" {
+			depths[id] = depth
+		}
+		return depth
+	}
+
+	deepest := 0
+	for _, step := range steps {
+		if depth := chainDepth(step); depth > deepest {
+			deepest = depth
+			if deepest >= limit {
+				break
+			}
+		}
+	}
+	return deepest
+}
+
+// nestedWorkflowSteps returns the step lists nested in a step
+func nestedWorkflowSteps(step map[string]interface{}) [][]map[string]interface{} {
+	var lists [][]map[string]interface{}
+	for _, key := range workflowNestedStepKeys {
+		switch nested := step[key].(type) {
+		case []map[string]interface{}:
+			lists = append(lists, nested)
+		case []interface{}:
+			list := make([]map[string]interface{}, 0, len(nested))
+			for _, item := range nested {
+				if nestedStep, ok := item.(map[string]interface{}); ok {
+					list = append(list, nestedStep)
+				}
+			}
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
+
+// workflowStepDependencies returns the IDs of the steps a step depends on
+func workflowStepDependencies(step map[string]interface{}) []string {
+	switch deps := step["depends_on"].(type) {
+	case []string:
+		return deps
+	case []interface{}:
+		ids := make([]string, 0, len(deps))
+		for _, dep := range deps {
+			if id, ok := dep.(string); ok {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func newWorkflowLimitsTestServer(limits WorkflowLimitsConfig) (*Server, *Connection) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{WorkflowLimits: limits})
+	conn := NewConnection("conn-1", nil, server)
+	conn.TenantID = "tenant-1"
+	conn.AgentID = "agent-1"
+	return server, conn
+}
+
+func createLimitsTestWorkflow(server *Server, conn *Connection, steps []map[string]interface{}) (interface{}, error) {
+	params, err := json.Marshal(map[string]interface{}{"name": "limits", "steps": steps})
+	if err != nil {
+		return nil, err
+	}
+	return server.handleWorkflowCreate(context.Background(), conn, params)
+}
+
+// sequentialSteps returns steps each depending on the previous one
+func sequentialSteps(n int) []map[string]interface{} {
+	steps := make([]map[string]interface{}, n)
+	for i := range steps {
+		steps[i] = map[string]interface{}{"id": fmt.Sprintf("step-%d", i), "type": "tool"}
+		if i > 0 {
+			steps[i]["depends_on"] = []string{fmt.Sprintf("step-%d", i-1)}
+		}
+	}
+	return steps
+}
+
+// independentSteps returns steps with no dependencies between them
+func independentSteps(n int) []map[string]interface{} {
+	steps := make([]map[string]interface{}, n)
+	for i := range steps {
+		steps[i] = map[string]interface{}{"id": fmt.Sprintf("step-%d", i), "type": "tool"}
+	}
+	return steps
+}
+
+// nestedSteps returns a step whose branches nest depth levels deep
+func nestedSteps(depth int) []map[string]interface{} {
+	step := map[string]interface{}{"id": "leaf", "type": "tool"}
+	for i := 1; i < depth; i++ {
+		step = map[string]interface{}{
+			"id":       fmt.Sprintf("branch-%d", i),
+			"type":     "parallel",
+			"branches": []interface{}{step},
+		}
+	}
+	return []map[string]interface{}{step}
+}
+
+func assertWorkflowLimitError(t *testing.T, err error, message string) {
+	t.Helper()
+
+	require.Error(t, err)
+	var wsErr *ws.Error
+	require.True(t, errors.As(err, &wsErr))
+	assert.Equal(t, ws.ErrCodeInvalidParams, wsErr.Code)
+	assert.Contains(t, wsErr.Message, message)
+}
+
+func TestWorkflowCreateLimits(t *testing.T) {
+	server, conn := newWorkflowLimitsTestServer(WorkflowLimitsConfig{MaxSteps: 10, MaxDepth: 4})
+
+	t.Run("too many steps", func(t *testing.T) {
+		_, err := createLimitsTestWorkflow(server, conn, independentSteps(11))
+		assertWorkflowLimitError(t, err, "exceeding the limit of 10")
+	})
+
+	t.Run("nested steps count toward the limit", func(t *testing.T) {
+		steps := independentSteps(8)
+		steps[0]["steps"] = independentSteps(3)
+		_, err := createLimitsTestWorkflow(server, conn, steps)
+		assertWorkflowLimitError(t, err, "exceeding the limit of 10")
+	})
+
+	t.Run("dependency chain too deep", func(t *testing.T) {
+		_, err := createLimitsTestWorkflow(server, conn, sequentialSteps(5))
+		assertWorkflowLimitError(t, err, "depth exceeds the limit of 4")
+	})
+
+	t.Run("nesting too deep", func(t *testing.T) {
+		_, err := createLimitsTestWorkflow(server, conn, nestedSteps(5))
+		assertWorkflowLimitError(t, err, "depth exceeds the limit of 4")
+	})
+
+	t.Run("workflows within the limits are created", func(t *testing.T) {
+		_, err := createLimitsTestWorkflow(server, conn, sequentialSteps(4))
+		require.NoError(t, err)
+		_, err = createLimitsTestWorkflow(server, conn, nestedSteps(4))
+		require.NoError(t, err)
+		_, err = createLimitsTestWorkflow(server, conn, independentSteps(10))
+		require.NoError(t, err)
+	})
+
+	t.Run("dependency cycles terminate", func(t *testing.T) {
+		steps := sequentialSteps(3)
+		steps[0]["depends_on"] = []string{"step-2"}
+		_, err := createLimitsTestWorkflow(server, conn, steps)
+		require.NoError(t, err)
+	})
+}
+
+func TestWorkflowExecuteLimits(t *testing.T) {
+	server, conn := newWorkflowLimitsTestServer(WorkflowLimitsConfig{})
+	result, err := createLimitsTestWorkflow(server, conn, independentSteps(20))
+	require.NoError(t, err)
+	workflowID := result.(map[string]interface{})["workflow_id"].(string)
+
+	// Limits lowered after the workflow was created are enforced when it runs
+	server.workflowEngine.SetLimits(WorkflowLimitsConfig{MaxSteps: 10})
+	params, err := json.Marshal(map[string]interface{}{"workflow_id": workflowID})
+	require.NoError(t, err)
+	_, err = server.handleWorkflowExecute(context.Background(), conn, params)
+	assertWorkflowLimitError(t, err, "exceeding the limit of 10")
+}
@@ -0,0 +1,338 @@
+package websocket
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// WorkflowExportFormatVersion is the current portable workflow format version
+const WorkflowExportFormatVersion = "1.0"
+
+// WorkflowPortabilityConfig configures workflow export and import
+type WorkflowPortabilityConfig struct {
+	// SigningKey is the shared HMAC key used to sign and verify exported workflows.
+	// Environments exchanging workflows must share the same key.
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+// PortableWorkflow is a signed, tenant-independent workflow definition
+type PortableWorkflow struct {
+	FormatVersion string                     `json:"format_version"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	Workflow      PortableWorkflowDefinition `json:"workflow"`
+	Signature     string                     `json:"signature,omitempty"`
+}
+
+// PortableWorkflowDefinition holds the portable parts of a workflow
+type PortableWorkflowDefinition struct {
+	Name           string                           `json:"name"`
+	Description    string                           `json:"description,omitempty"`
+	Type           models.WorkflowType              `json:"type"`
+	Config         map[string]interface{}           `json:"config,omitempty"`
+	Tags           []string                         `json:"tags,omitempty"`
+	Steps          []models.WorkflowStep            `json:"steps"`
+	ToolReferences map[string]PortableToolReference `json:"tool_references,omitempty"` // step ID -> tool
+	Metadata       map[string]interface{}           `json:"metadata,omitempty"`
+}
+
+// PortableToolReference identifies a tool used by a step so it can be resolved in another tenant
+type PortableToolReference struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// signWorkflowExport computes the HMAC signature of an export, excluding the signature field
+func signWorkflowExport(key []byte, export *PortableWorkflow) (string, error) {
+	unsigned := *export
+	unsigned.Signature = ""
+
+	payload, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode workflow export: %w", err)
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// stepToolReference returns the tool a step references, if any
+func stepToolReference(step models.WorkflowStep) string {
+	for _, source := range []map[string]interface{}{step.Config, step.Input} {
+		for _, field := range []string{"tool_id", "tool"} {
+			if ref, ok := source[field].(string); ok && ref != "" {
+				return ref
+			}
+		}
+	}
+	return ""
+}
+
+// setStepToolReference points a step at a resolved tool ID
+func setStepToolReference(step *models.WorkflowStep, toolID string) {
+	if step.Config == nil {
+		step.Config = make(map[string]interface{})
+	}
+	step.Config["tool_id"] = toolID
+	delete(step.Config, "tool")
+	if step.Input != nil {
+		delete(step.Input, "tool_id")
+		delete(step.Input, "tool")
+	}
+}
+
+// listTenantTools returns the tools available to a tenant, keyed by ID and by name
+func (s *Server) listTenantTools(ctx context.Context, tenantID string) (map[string]*models.DynamicTool, map[string]*models.DynamicTool, error) {
+	byID := make(map[string]*models.DynamicTool)
+	byName := make(map[string]*models.DynamicTool)
+	if s.restAPIClient == nil {
+		return byID, byName, fmt.Errorf("tool catalog not available")
+	}
+
+	tools, err := s.restAPIClient.ListTools(ctx, tenantID)
+	if err != nil {
+		return byID, byName, err
+	}
+	for _, tool := range tools {
+		byID[tool.ID] = tool
+		byName[tool.ToolName] = tool
+	}
+	return byID, byName, nil
+}
+
+// handleWorkflowExport serializes a workflow definition to a signed portable format
+func (s *Server) handleWorkflowExport(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	ctx = auth.WithUserID(ctx, conn.AgentID)
+	ctx = auth.WithTenantID(ctx, conn.GetTenantUUID())
+
+	var exportParams struct {
+		WorkflowID string `json:"workflow_id"`
+	}
+
+	if err := json.Unmarshal(params, &exportParams); err != nil {
+		return nil, err
+	}
+
+	workflowID, err := uuid.Parse(exportParams.WorkflowID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workflow ID: %w", err)
+	}
+
+	if s.workflowService == nil {
+		return nil, fmt.Errorf("workflow service not initialized")
+	}
+	if s.config.WorkflowPortability.SigningKey == "" {
+		return nil, fmt.Errorf("workflow signing key not configured")
+	}
+
+	workflow, err := s.workflowService.GetWorkflow(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workflow: %w", err)
+	}
+	if workflow.TenantID != uuid.Nil && workflow.TenantID != conn.GetTenantUUID() {
+		return nil, fmt.Errorf("workflow not found")
+	}
+
+	// Record tool names so references can be resolved in the importing tenant
+	toolsByID, toolsByName, _ := s.listTenantTools(ctx, conn.TenantID)
+	toolRefs := make(map[string]PortableToolReference)
+	for _, step := range workflow.Steps {
+		ref := stepToolReference(step)
+		if ref == "" {
+			continue
+		}
+		portable := PortableToolReference{ID: ref}
+		if tool, ok := toolsByID[ref]; ok {
+			portable.Name = tool.ToolName
+		} else if tool, ok := toolsByName[ref]; ok {
+			portable = PortableToolReference{ID: tool.ID, Name: tool.ToolName}
+		}
+		toolRefs[step.ID] = portable
+	}
+
+	export := &PortableWorkflow{
+		FormatVersion: WorkflowExportFormatVersion,
+		ExportedAt:    time.Now().UTC(),
+		Workflow: PortableWorkflowDefinition{
+			Name:           workflow.Name,
+			Description:    workflow.Description,
+			Type:           workflow.Type,
+			Config:         workflow.Config,
+			Tags:           workflow.Tags,
+			Steps:          workflow.Steps,
+			ToolReferences: toolRefs,
+			Metadata: map[string]interface{}{
+				"source_workflow_id": workflow.ID.String(),
+				"source_version":     workflow.Version,
+				"exported_by":        conn.AgentID,
+			},
+		},
+	}
+
+	signature, err := signWorkflowExport([]byte(s.config.WorkflowPortability.SigningKey), export)
+	if err != nil {
+		return nil, err
+	}
+	export.Signature = signature
+
+	return map[string]interface{}{
+		"workflow_id": workflow.ID.String(),
+		"export":      export,
+	}, nil
+}
+
+// handleWorkflowImport validates a signed workflow export and re-creates it under the
+// importing tenant with new IDs. Tool references are resolved against the tenant's tools;
+// unresolved references are returned as warnings.
+func (s *Server) handleWorkflowImport(ctx context.Context, conn *Connection, params json.RawMessage) (interface{}, error) {
+	ctx = auth.WithUserID(ctx, conn.AgentID)
+	ctx = auth.WithTenantID(ctx, conn.GetTenantUUID())
+
+	var importParams struct {
+		Export PortableWorkflow `json:"export"`
+		Name   string           `json:"name"` // Optional name override
+	}
+
+	if err := json.Unmarshal(params, &importParams); err != nil {
+		return nil, err
+	}
+	export := importParams.Export
+
+	if s.workflowService == nil {
+		return nil, fmt.Errorf("workflow service not initialized")
+	}
+	if s.config.WorkflowPortability.SigningKey == "" {
+		return nil, fmt.Errorf("workflow signing key not configured")
+	}
+
+	if export.FormatVersion != WorkflowExportFormatVersion {
+		return nil, fmt.Errorf("unsupported workflow export format version: %q", export.FormatVersion)
+	}
+
+	expected, err := signWorkflowExport([]byte(s.config.WorkflowPortability.SigningKey), &export)
+	if err != nil {
+		return nil, err
+	}
+	if export.Signature == "" || !hmac.Equal([]byte(expected), []byte(export.Signature)) {
+		return nil, fmt.Errorf("invalid workflow export signature")
+	}
+
+	definition := export.Workflow
+	if len(definition.Steps) == 0 {
+		return nil, fmt.Errorf("workflow export contains no steps")
+	}
+	if err := s.config.WorkflowLimits.CheckModelSteps(definition.Steps); err != nil {
+		return nil, err
+	}
+
+	// Assign new step IDs and rewrite dependencies
+	stepIDs := make(map[string]string, len(definition.Steps))
+	for _, step := range definition.Steps {
+		stepIDs[step.ID] = uuid.New().String()
+	}
+
+	toolsByID, toolsByName, toolErr := s.listTenantTools(ctx, conn.TenantID)
+	warnings := make([]string, 0)
+	missingTools := make([]string, 0)
+
+	steps := make(models.WorkflowSteps, 0, len(definition.Steps))
+	for _, step := range definition.Steps {
+		imported := step
+		imported.ID = stepIDs[step.ID]
+		imported.AgentID = "" // Agents are tenant-specific
+
+		dependencies := make([]string, 0, len(step.Dependencies))
+		for _, dep := range step.Dependencies {
+			if newID, ok := stepIDs[dep]; ok {
+				dependencies = append(dependencies, newID)
+			}
+		}
+		imported.Dependencies = dependencies
+
+		if ref := stepToolReference(step); ref != "" {
+			portable, ok := definition.ToolReferences[step.ID]
+			if !ok {
+				portable = PortableToolReference{ID: ref}
+			}
+
+			var resolved *models.DynamicTool
+			if portable.Name != "" {
+				resolved = toolsByName[portable.Name]
+			}
+			if resolved == nil {
+				if tool, ok := toolsByID[portable.ID]; ok {
+					resolved = tool
+				} else {
+					resolved = toolsByName[ref]
+				}
+			}
+
+			if resolved != nil {
+				setStepToolReference(&imported, resolved.ID)
+			} else {
+				missing := portable.Name
+				if missing == "" {
+					missing = portable.ID
+				}
+				missingTools = append(missingTools, missing)
+				warnings = append(warnings, fmt.Sprintf("step %q references tool %q which is not available in this tenant", step.Name, missing))
+			}
+		}
+
+		steps = append(steps, imported)
+	}
+	if toolErr != nil && len(missingTools) > 0 {
+		warnings = append(warnings, fmt.Sprintf("tool references could not be verified: %v", toolErr))
+	}
+
+	name := definition.Name
+	if importParams.Name != "" {
+		name = importParams.Name
+	}
+
+	config := models.JSONMap{}
+	for k, v := range definition.Config {
+		config[k] = v
+	}
+	config["imported_from"] = definition.Metadata
+
+	now := time.Now()
+	workflow := &models.Workflow{
+		ID:          uuid.New(),
+		TenantID:    conn.GetTenantUUID(),
+		Name:        name,
+		Description: definition.Description,
+		Type:        definition.Type,
+		IsActive:    true,
+		Agents:      models.JSONMap{}, // Agents are tenant-specific and assigned after import
+		Config:      config,
+		Tags:        definition.Tags,
+		Steps:       steps,
+		CreatedBy:   conn.AgentID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.workflowService.CreateWorkflow(ctx, workflow); err != nil {
+		return nil, fmt.Errorf("failed to import workflow: %w", err)
+	}
+
+	return map[string]interface{}{
+		"workflow_id":   workflow.ID.String(),
+		"name":          workflow.Name,
+		"step_count":    len(workflow.Steps),
+		"missing_tools": missingTools,
+		"warnings":      warnings,
+		"imported_at":   now.Format(time.RFC3339),
+	}, nil
+}
@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/clients"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/developer-mesh/developer-mesh/pkg/services"
+)
+
+// stubWorkflowService stores workflows in memory
+type stubWorkflowService struct {
+	services.WorkflowService
+	workflows map[uuid.UUID]*models.Workflow
+}
+
+func (s *stubWorkflowService) GetWorkflow(ctx context.Context, id uuid.UUID) (*models.Workflow, error) {
+	workflow, ok := s.workflows[id]
+	if !ok {
+		return nil, fmt.Errorf("workflow not found")
+	}
+	return workflow, nil
+}
+
+func (s *stubWorkflowService) CreateWorkflow(ctx context.Context, workflow *models.Workflow) error {
+	s.workflows[workflow.ID] = workflow
+	return nil
+}
+
+// stubToolCatalog returns a fixed set of tools per tenant
+type stubToolCatalog struct {
+	clients.RESTAPIClient
+	tools map[string][]*models.DynamicTool
+}
+
+func (c *stubToolCatalog) ListTools(ctx context.Context, tenantID string) ([]*models.DynamicTool, error) {
+	return c.tools[tenantID], nil
+}
+
+func newPortabilityTestServer(t *testing.T) (*Server, *stubWorkflowService, *Connection, *Connection) {
+	t.Helper()
+
+	sourceTenant := uuid.New().String()
+	targetTenant := uuid.New().String()
+
+	server := NewServer(&auth.Service{}, nil, NewTestLogger(), Config{
+		WorkflowPortability: WorkflowPortabilityConfig{SigningKey: "test-signing-key"},
+	})
+	workflows := &stubWorkflowService{workflows: make(map[uuid.UUID]*models.Workflow)}
+	server.workflowService = workflows
+	server.SetRESTClient(&stubToolCatalog{tools: map[string][]*models.DynamicTool{
+		sourceTenant: {
+			{ID: "src-github", ToolName: "github"},
+			{ID: "src-jira", ToolName: "jira"},
+		},
+		targetTenant: {
+			{ID: "dst-github", ToolName: "github"},
+		},
+	}})
+
+	source := NewConnection("conn-source", nil, server)
+	source.AgentID = "agent-source"
+	source.TenantID = sourceTenant
+
+	target := NewConnection("conn-target", nil, server)
+	target.AgentID = "agent-target"
+	target.TenantID = targetTenant
+
+	return server, workflows, source, target
+}
+
+func exportTestWorkflow(t *testing.T, server *Server, workflows *stubWorkflowService, conn *Connection) (*models.Workflow, PortableWorkflow) {
+	t.Helper()
+
+	workflow := &models.Workflow{
+		ID:       uuid.New(),
+		TenantID: conn.GetTenantUUID(),
+		Name:     "release",
+		Type:     models.WorkflowTypeSequential,
+		Version:  3,
+		Steps: models.WorkflowSteps{
+			{ID: "build", Name: "build", Action: "execute", Config: map[string]interface{}{"tool_id": "src-github"}},
+			{ID: "ticket", Name: "ticket", Action: "execute", Config: map[string]interface{}{"tool_id": "src-jira"}, Dependencies: []string{"build"}},
+		},
+		Tags: []string{"release"},
+	}
+	workflows.workflows[workflow.ID] = workflow
+
+	params, err := json.Marshal(map[string]interface{}{"workflow_id": workflow.ID.String()})
+	require.NoError(t, err)
+
+	result, err := server.handleWorkflowExport(context.Background(), conn, params)
+	require.NoError(t, err)
+
+	export := result.(map[string]interface{})["export"].(*PortableWorkflow)
+	return workflow, *export
+}
+
+func importTestWorkflow(server *Server, conn *Connection, export PortableWorkflow) (interface{}, error) {
+	params, err := json.Marshal(map[string]interface{}{"export": export})
+	if err != nil {
+		return nil, err
+	}
+	return server.handleWorkflowImport(context.Background(), conn, params)
+}
+
+func TestWorkflowExportImportRoundTrip(t *testing.T) {
+	server, workflows, source, target := newPortabilityTestServer(t)
+	original, export := exportTestWorkflow(t, server, workflows, source)
+
+	assert.Equal(t, WorkflowExportFormatVersion, export.FormatVersion)
+	assert.NotEmpty(t, export.Signature)
+	assert.Equal(t, "jira", export.Workflow.ToolReferences["ticket"].Name)
+
+	result, err := importTestWorkflow(server, target, export)
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, []string{"jira"}, response["missing_tools"])
+	assert.Len(t, response["warnings"], 1)
+
+	importedID, err := uuid.Parse(response["workflow_id"].(string))
+	require.NoError(t, err)
+	assert.NotEqual(t, original.ID, importedID)
+
+	imported := workflows.workflows[importedID]
+	require.NotNil(t, imported)
+	assert.Equal(t, target.GetTenantUUID(), imported.TenantID)
+	assert.Equal(t, "agent-target", imported.CreatedBy)
+	require.Len(t, imported.Steps, 2)
+
+	build, ticket := imported.Steps[0], imported.Steps[1]
+	assert.NotEqual(t, "build", build.ID)
+	assert.Equal(t, []string{build.ID}, ticket.Dependencies)
+	assert.Equal(t, "dst-github", build.Config["tool_id"], "tool resolved by name in the importing tenant")
+	assert.Equal(t, "src-jira", ticket.Config["tool_id"], "unresolved reference left as exported")
+}
+
+func TestWorkflowImportRejectsTamperedExport(t *testing.T) {
+	server, workflows, source, target := newPortabilityTestServer(t)
+	_, export := exportTestWorkflow(t, server, workflows, source)
+
+	export.Workflow.Name = "tampered"
+	_, err := importTestWorkflow(server, target, export)
+	assert.ErrorContains(t, err, "invalid workflow export signature")
+}
+
+func TestWorkflowImportRejectsUnknownFormatVersion(t *testing.T) {
+	server, workflows, source, target := newPortabilityTestServer(t)
+	_, export := exportTestWorkflow(t, server, workflows, source)
+
+	export.FormatVersion = "2.0"
+	_, err := importTestWorkflow(server, target, export)
+	assert.ErrorContains(t, err, "unsupported workflow export format version")
+}
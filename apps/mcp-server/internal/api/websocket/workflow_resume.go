@@ -0,0 +1,99 @@
+package websocket
+
+// WorkflowResubscribeConfig configures the re-subscription of a connection to an
+// execution's notifications when it resumes the execution
+type WorkflowResubscribeConfig struct {
+	Disabled bool `mapstructure:"disabled"` // Leave subscribing to the client
+}
+
+// workflowResubscription reports the subscriptions made when resuming an execution
+type workflowResubscription struct {
+	Subscriptions map[string]string // topic -> subscription ID
+	Replayed      int
+	EventsLost    bool
+}
+
+// workflowExecutionTopics returns the topics an execution's notifications are sent on
+func workflowExecutionTopics(workflowID, executionID string) []string {
+	return []string{"workflow:" + workflowID, "execution:" + executionID}
+}
+
+// subscriptionResource returns the resource of a subscription, detached or not
+func (sm *SubscriptionManager) subscriptionResource(subscriptionID string) (string, bool) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sub, ok := sm.subscriptions[subscriptionID]
+	if !ok {
+		return "", false
+	}
+	return sub.Resource, true
+}
+
+// resubscribeWorkflow subscribes a connection to the topics of an execution it
+// resumes. Subscriptions the client held before, e.g. on a dropped connection,
+// are taken over and replay the events recorded after the cursor. Topics the
+// connection isn't subscribed to otherwise get a new subscription.
+func (s *Server) resubscribeWorkflow(conn *Connection, topics []string, previous []string, cursor uint64) *workflowResubscription {
+	result := &workflowResubscription{Subscriptions: make(map[string]string, len(topics))}
+
+	for _, sub := range s.subscriptionManager.GetConnectionSubscriptions(conn.ID) {
+		result.Subscriptions[sub.Resource] = sub.ID
+	}
+
+	wanted := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		wanted[topic] = true
+	}
+
+	for _, subID := range previous {
+		resource, ok := s.subscriptionManager.subscriptionResource(subID)
+		if !ok || !wanted[resource] || result.Subscriptions[resource] != "" {
+			continue
+		}
+
+		replayed, gap, err := s.subscriptionManager.Replay(conn.ID, subID, cursor, func(event SubscriptionEvent) error {
+			return conn.SendMessage(subscriptionEventMessage(event))
+		})
+		result.Replayed += replayed
+		if err != nil {
+			s.logger.Warn("Failed to replay workflow events on resume", map[string]interface{}{
+				"connection_id":   conn.ID,
+				"subscription_id": subID,
+				"error":           err.Error(),
+			})
+			result.EventsLost = true
+			continue
+		}
+		result.EventsLost = result.EventsLost || gap
+		result.Subscriptions[resource] = subID
+	}
+
+	for _, topic := range topics {
+		if result.Subscriptions[topic] != "" {
+			continue
+		}
+		// Events missed on a topic whose previous subscription expired can't be replayed
+		if len(previous) > 0 {
+			result.EventsLost = true
+		}
+		subID, err := s.subscriptionManager.Subscribe(conn.ID, topic, nil)
+		if err != nil {
+			s.logger.Warn("Failed to subscribe to workflow notifications on resume", map[string]interface{}{
+				"connection_id": conn.ID,
+				"topic":         topic,
+				"error":         err.Error(),
+			})
+			continue
+		}
+		result.Subscriptions[topic] = subID
+	}
+
+	// Only report the execution's topics
+	for resource := range result.Subscriptions {
+		if !wanted[resource] {
+			delete(result.Subscriptions, resource)
+		}
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/developer-mesh/developer-mesh/pkg/services"
+)
+
+// resumableWorkflowService holds a single paused execution
+type resumableWorkflowService struct {
+	services.WorkflowService
+	execution *models.WorkflowExecution
+	onResume  func()
+}
+
+func (s *resumableWorkflowService) GetExecution(ctx context.Context, executionID uuid.UUID) (*models.WorkflowExecution, error) {
+	return s.execution, nil
+}
+
+func (s *resumableWorkflowService) ResumeExecution(ctx context.Context, executionID uuid.UUID) error {
+	s.execution.Status = models.WorkflowStatusRunning
+	if s.onResume != nil {
+		s.onResume()
+	}
+	return nil
+}
+
+func newResumeTestServer(config WorkflowResubscribeConfig) (*Server, *resumableWorkflowService) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{WorkflowResubscribe: config})
+	workflows := &resumableWorkflowService{execution: &models.WorkflowExecution{
+		ID:         uuid.New(),
+		WorkflowID: uuid.New(),
+		Status:     models.WorkflowStatusPaused,
+	}}
+	server.workflowService = workflows
+	return server, workflows
+}
+
+func resumeTestWorkflow(t *testing.T, server *Server, conn *Connection, params map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(params)
+	require.NoError(t, err)
+	result, err := server.handleWorkflowResume(context.Background(), conn, data)
+	require.NoError(t, err)
+	return result.(map[string]interface{})
+}
+
+// receivedNotifications drains the notifications queued for a connection
+func receivedNotifications(t *testing.T, conn *Connection) []ws.Message {
+	t.Helper()
+
+	var messages []ws.Message
+	for {
+		select {
+		case data := <-conn.send:
+			var msg ws.Message
+			require.NoError(t, json.Unmarshal(data, &msg))
+			messages = append(messages, msg)
+		default:
+			return messages
+		}
+	}
+}
+
+func notifyStepCompleted(server *Server, execution *models.WorkflowExecution, stepID string) {
+	now := time.Now()
+	server.notificationManager.NotifyWorkflowStepCompleted(context.Background(), execution.WorkflowID.String(), execution.ID.String(),
+		WorkflowStepCompletion{StepID: stepID, Status: "completed", StartedAt: now, CompletedAt: now})
+}
+
+func TestWorkflowResumeResubscribes(t *testing.T) {
+	server, workflows := newResumeTestServer(WorkflowResubscribeConfig{})
+	execution := workflows.execution
+	conn := connectReplayTestClient(server, "conn-1")
+
+	// A step started as soon as execution resumes still reaches the client
+	workflows.onResume = func() {
+		server.notificationManager.NotifyWorkflowStepStarted(context.Background(), execution.WorkflowID.String(), execution.ID.String(), "deploy")
+	}
+
+	result := resumeTestWorkflow(t, server, conn, map[string]interface{}{"execution_id": execution.ID.String()})
+	assert.Equal(t, models.WorkflowStatusRunning, result["status"])
+	subscriptions := result["subscriptions"].(map[string]string)
+	assert.Len(t, subscriptions, 2)
+	assert.NotEmpty(t, subscriptions["workflow:"+execution.WorkflowID.String()])
+	assert.NotEmpty(t, subscriptions["execution:"+execution.ID.String()])
+	assert.Equal(t, false, result["events_lost"])
+
+	notifyStepCompleted(server, execution, "deploy")
+
+	messages := receivedNotifications(t, conn)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "workflow.step_started", messages[0].Method)
+	assert.Equal(t, "workflow.step_completed", messages[1].Method)
+	assert.Equal(t, "deploy", messages[1].Params.(map[string]interface{})["step_id"])
+
+	// Resuming again doesn't duplicate the subscriptions
+	workflows.onResume = nil
+	again := resumeTestWorkflow(t, server, conn, map[string]interface{}{"execution_id": execution.ID.String()})
+	assert.Equal(t, subscriptions, again["subscriptions"])
+	notifyStepCompleted(server, execution, "verify")
+	assert.Len(t, receivedNotifications(t, conn), 1)
+}
+
+func TestWorkflowResumeReplaysMissedEvents(t *testing.T) {
+	server, workflows := newResumeTestServer(WorkflowResubscribeConfig{})
+	execution := workflows.execution
+
+	first := connectReplayTestClient(server, "conn-1")
+	result := resumeTestWorkflow(t, server, first, map[string]interface{}{"execution_id": execution.ID.String()})
+	subscriptions := result["subscriptions"].(map[string]string)
+
+	notifyStepCompleted(server, execution, "build")
+	seen := receivedNotifications(t, first)
+	require.Len(t, seen, 1)
+	cursor, err := strconv.ParseUint(seen[0].ID, 10, 64)
+	require.NoError(t, err)
+
+	// Steps complete while the client is disconnected
+	disconnectReplayTestClient(server, first)
+	notifyStepCompleted(server, execution, "test")
+	notifyStepCompleted(server, execution, "package")
+
+	previous := make([]string, 0, len(subscriptions))
+	for _, subID := range subscriptions {
+		previous = append(previous, subID)
+	}
+	second := connectReplayTestClient(server, "conn-2")
+	result = resumeTestWorkflow(t, server, second, map[string]interface{}{
+		"execution_id":     execution.ID.String(),
+		"subscription_ids": previous,
+		"cursor":           cursor,
+	})
+	assert.Equal(t, 2, result["replayed"])
+	assert.Equal(t, false, result["events_lost"])
+	assert.Equal(t, subscriptions, result["subscriptions"])
+
+	notifyStepCompleted(server, execution, "deploy")
+
+	var steps []interface{}
+	for _, msg := range receivedNotifications(t, second) {
+		steps = append(steps, msg.Params.(map[string]interface{})["step_id"])
+	}
+	assert.Equal(t, []interface{}{"test", "package", "deploy"}, steps)
+}
+
+func TestWorkflowResumeResubscribeDisabled(t *testing.T) {
+	server, workflows := newResumeTestServer(WorkflowResubscribeConfig{Disabled: true})
+	conn := connectReplayTestClient(server, "conn-1")
+
+	result := resumeTestWorkflow(t, server, conn, map[string]interface{}{"execution_id": workflows.execution.ID.String()})
+	assert.NotContains(t, result, "subscriptions")
+
+	notifyStepCompleted(server, workflows.execution, "deploy")
+	assert.Empty(t, receivedNotifications(t, conn))
+}
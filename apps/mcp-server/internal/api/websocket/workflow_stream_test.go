@@ -0,0 +1,83 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// awaitStepCompletions collects step completed notifications until count arrive
+func awaitStepCompletions(t *testing.T, conn *Connection, count int) []map[string]interface{} {
+	t.Helper()
+
+	var completions []map[string]interface{}
+	timeout := time.After(5 * time.Second)
+	for len(completions) < count {
+		select {
+		case data := <-conn.send:
+			var msg ws.Message
+			require.NoError(t, json.Unmarshal(data, &msg))
+			if msg.Method != "workflow.step_completed" {
+				continue
+			}
+			completions = append(completions, msg.Params.(map[string]interface{}))
+		case <-timeout:
+			t.Fatalf("received %d of %d step completed notifications", len(completions), count)
+		}
+	}
+	return completions
+}
+
+func TestWorkflowExecuteStreamsStepCompletions(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	conn := NewConnection("conn-1", nil, server)
+	server.notificationManager.RegisterConnection(conn)
+	ctx := context.Background()
+
+	workflow, err := server.workflowEngine.CreateWorkflow(ctx, &WorkflowDefinition{
+		Name: "release",
+		Steps: []map[string]interface{}{
+			{"id": "build"},
+			{"id": "test", "depends_on": []string{"build"}},
+			{"id": "deploy", "depends_on": []string{"test"}},
+		},
+	})
+	require.NoError(t, err)
+
+	params, err := json.Marshal(map[string]interface{}{"workflow_id": workflow.ID, "stream": true})
+	require.NoError(t, err)
+	result, err := server.handleWorkflowExecute(ctx, conn, params)
+	require.NoError(t, err)
+	executionID := result.(map[string]interface{})["execution_id"]
+
+	// Each step is reported as it finishes, in execution order
+	completions := awaitStepCompletions(t, conn, 3)
+	for i, stepID := range []string{"build", "test", "deploy"} {
+		completion := completions[i]
+		assert.Equal(t, stepID, completion["step_id"])
+		assert.Equal(t, float64(i), completion["step_index"])
+		assert.Equal(t, executionID, completion["execution_id"])
+		assert.Equal(t, "completed", completion["status"])
+		assert.Equal(t, "Simulated result for step "+stepID, completion["output"])
+
+		startedAt, err := time.Parse(time.RFC3339Nano, completion["started_at"].(string))
+		require.NoError(t, err)
+		completedAt, err := time.Parse(time.RFC3339Nano, completion["completed_at"].(string))
+		require.NoError(t, err)
+		assert.False(t, completedAt.Before(startedAt))
+		assert.GreaterOrEqual(t, completion["duration_ms"], float64(0))
+	}
+
+	// A later step starts after the previous one completes
+	previousCompletedAt, _ := time.Parse(time.RFC3339Nano, completions[0]["completed_at"].(string))
+	nextStartedAt, _ := time.Parse(time.RFC3339Nano, completions[1]["started_at"].(string))
+	assert.False(t, nextStartedAt.Before(previousCompletedAt))
+}
@@ -0,0 +1,157 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	redisclient "github.com/redis/go-redis/v9"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// DefaultMaxBroadcastsPerMinute is the default per-sender broadcast limit for a workspace
+const DefaultMaxBroadcastsPerMinute = 60
+
+// BroadcastRateLimitConfig configures per-(workspace, agent) broadcast rate limiting
+type BroadcastRateLimitConfig struct {
+	MaxBroadcastsPerMinute int            `mapstructure:"max_broadcasts_per_minute"`
+	PerWorkspaceType       map[string]int `mapstructure:"per_workspace_type"` // Overrides keyed by workspace type (private, team, public)
+}
+
+// BroadcastRateLimiter limits how often a single agent may broadcast to a workspace
+// using a sliding-window counter. The auth package's Redis limiter is used when
+// configured so limits are shared across server instances; otherwise counts are
+// kept in memory.
+type BroadcastRateLimiter struct {
+	config  BroadcastRateLimitConfig
+	window  time.Duration
+	redis   *auth.RedisRateLimiter
+	logger  observability.Logger
+	metrics observability.MetricsClient
+
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+// NewBroadcastRateLimiter creates a new broadcast rate limiter
+func NewBroadcastRateLimiter(config BroadcastRateLimitConfig, logger observability.Logger, metrics observability.MetricsClient) *BroadcastRateLimiter {
+	if config.MaxBroadcastsPerMinute <= 0 {
+		config.MaxBroadcastsPerMinute = DefaultMaxBroadcastsPerMinute
+	}
+
+	return &BroadcastRateLimiter{
+		config:  config,
+		window:  time.Minute,
+		logger:  logger,
+		metrics: metrics,
+		events:  make(map[string][]time.Time),
+		now:     time.Now,
+	}
+}
+
+// SetRedisClient switches the limiter to a Redis-backed sliding window
+func (l *BroadcastRateLimiter) SetRedisClient(client redisclient.UniversalClient) {
+	l.redis = auth.NewRedisRateLimiter(client)
+}
+
+// LimitFor returns the per-minute broadcast limit for a workspace type
+func (l *BroadcastRateLimiter) LimitFor(workspaceType string) int {
+	if limit, ok := l.config.PerWorkspaceType[workspaceType]; ok && limit > 0 {
+		return limit
+	}
+	return l.config.MaxBroadcastsPerMinute
+}
+
+// Allow records a broadcast attempt and reports whether it is within the limit.
+// When denied, the returned duration is how long until the next broadcast is allowed.
+func (l *BroadcastRateLimiter) Allow(ctx context.Context, workspaceID, workspaceType, agentID string) (bool, time.Duration, error) {
+	limit := l.LimitFor(workspaceType)
+	key := fmt.Sprintf("workspace:broadcast:ratelimit:%s:%s", workspaceID, agentID)
+
+	var (
+		allowed    bool
+		retryAfter time.Duration
+	)
+
+	if l.redis != nil {
+		var resetAt time.Time
+		var err error
+		allowed, _, resetAt, err = l.redis.Check(ctx, key, limit, l.window)
+		if err != nil {
+			// Fall back to the in-memory window if Redis is unavailable
+			if l.logger != nil {
+				l.logger.Warn("Redis broadcast rate limit check failed, using local window", map[string]interface{}{
+					"workspace_id": workspaceID,
+					"error":        err.Error(),
+				})
+			}
+			allowed, retryAfter = l.allowLocal(key, limit)
+		} else if !allowed {
+			retryAfter = time.Until(resetAt)
+		}
+	} else {
+		allowed, retryAfter = l.allowLocal(key, limit)
+	}
+
+	if !allowed && l.metrics != nil {
+		l.metrics.IncrementCounterWithLabels("workspace.broadcast.rate_limited", 1, map[string]string{
+			"workspace_type": workspaceType,
+		})
+	}
+
+	return allowed, retryAfter, nil
+}
+
+// allowLocal applies the sliding window in memory
+func (l *BroadcastRateLimiter) allowLocal(key string, limit int) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	cutoff := now.Add(-l.window)
+	l.pruneLocked(now, cutoff)
+
+	kept := l.trimLocked(key, cutoff)
+	if len(kept) >= limit {
+		return false, kept[0].Add(l.window).Sub(now)
+	}
+
+	l.events[key] = append(kept, now)
+	return true, 0
+}
+
+// trimLocked drops a key's events from before cutoff, and the key itself once
+// none are left. l.mu must be held.
+func (l *BroadcastRateLimiter) trimLocked(key string, cutoff time.Time) []time.Time {
+	events := l.events[key]
+	kept := events[:0]
+	for _, ts := range events {
+		if ts.After(cutoff) {
+			kept = append(kept, ts)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(l.events, key)
+		return nil
+	}
+	l.events[key] = kept
+	return kept
+}
+
+// pruneLocked trims every key at most once a window, so senders that stopped
+// broadcasting don't keep their entries. l.mu must be held.
+func (l *BroadcastRateLimiter) pruneLocked(now, cutoff time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	l.lastPrune = now
+	for key := range l.events {
+		l.trimLocked(key, cutoff)
+	}
+}
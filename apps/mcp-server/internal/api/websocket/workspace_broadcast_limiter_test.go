@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	ws "github.com/developer-mesh/developer-mesh/pkg/models/websocket"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestBroadcastRateLimiterPerWorkspaceType(t *testing.T) {
+	limiter := NewBroadcastRateLimiter(BroadcastRateLimitConfig{
+		MaxBroadcastsPerMinute: 3,
+		PerWorkspaceType:       map[string]int{"public": 1},
+	}, NewTestLogger(), nil)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(ctx, "ws-1", "private", "agent-1")
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, retryAfter, err := limiter.Allow(ctx, "ws-1", "private", "agent-1")
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Greater(t, retryAfter.Milliseconds(), int64(0))
+
+	// Limits are tracked per agent
+	allowed, _, err = limiter.Allow(ctx, "ws-1", "private", "agent-2")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// Public workspaces use their own limit
+	allowed, _, _ = limiter.Allow(ctx, "ws-2", "public", "agent-1")
+	assert.True(t, allowed)
+	allowed, _, _ = limiter.Allow(ctx, "ws-2", "public", "agent-1")
+	assert.False(t, allowed)
+}
+
+func TestBroadcastRateLimiterDropsIdleSenders(t *testing.T) {
+	limiter := NewBroadcastRateLimiter(BroadcastRateLimitConfig{MaxBroadcastsPerMinute: 1}, NewTestLogger(), nil)
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for _, agentID := range []string{"agent-1", "agent-2"} {
+		allowed, _, err := limiter.Allow(ctx, "ws-1", "team", agentID)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	assert.Len(t, limiter.events, 2)
+
+	// Once the window passes, the next broadcast drops the other sender's entry
+	now = now.Add(time.Minute)
+	allowed, _, err := limiter.Allow(ctx, "ws-1", "team", "agent-1")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Len(t, limiter.events, 1)
+	assert.Contains(t, limiter.events, "workspace:broadcast:ratelimit:ws-1:agent-1")
+}
+
+func TestHandleWorkspaceBroadcastRateLimited(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{
+		BroadcastRateLimit: BroadcastRateLimitConfig{MaxBroadcastsPerMinute: 1},
+	})
+	conn := NewConnection("conn-1", nil, server)
+	conn.AgentID = "agent-1"
+
+	workspace, err := server.workspaceManager.CreateWorkspace(context.Background(), &WorkspaceConfig{
+		Name:    "test",
+		Type:    "team",
+		OwnerID: "agent-1",
+	})
+	require.NoError(t, err)
+
+	params, err := json.Marshal(map[string]interface{}{"workspace_id": workspace.ID, "event": "ping"})
+	require.NoError(t, err)
+
+	_, err = server.handleWorkspaceBroadcast(context.Background(), conn, params)
+	require.NoError(t, err)
+
+	_, err = server.handleWorkspaceBroadcast(context.Background(), conn, params)
+	require.Error(t, err)
+
+	wsErr, ok := err.(*ws.Error)
+	require.True(t, ok)
+	assert.Equal(t, ws.ErrCodeRateLimited, wsErr.Code)
+	assert.Contains(t, wsErr.Data.(map[string]interface{}), "retry_after_ms")
+}
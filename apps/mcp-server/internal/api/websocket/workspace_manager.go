@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -217,8 +218,23 @@ func (wm *WorkspaceManager) LeaveWorkspace(ctx context.Context, workspaceID, age
 	return nil
 }
 
-// BroadcastToWorkspace sends a message to all workspace members
-func (wm *WorkspaceManager) BroadcastToWorkspace(ctx context.Context, workspaceID, senderID, event string, data map[string]interface{}) ([]string, error) {
+// BroadcastResult reports the delivery outcome of a workspace broadcast
+type BroadcastResult struct {
+	Recipients []string           `json:"recipients"`
+	Successful []string           `json:"successful"`
+	Failed     []BroadcastFailure `json:"failed"`
+}
+
+// BroadcastFailure is a recipient a broadcast couldn't be delivered to
+type BroadcastFailure struct {
+	AgentID string `json:"agent_id"`
+	Error   string `json:"error"`
+}
+
+// BroadcastToWorkspace sends a message to all workspace members. Delivery never
+// blocks on a slow member; members whose connections can't accept the message
+// are reported as failed.
+func (wm *WorkspaceManager) BroadcastToWorkspace(ctx context.Context, workspaceID, senderID, event string, data map[string]interface{}) (*BroadcastResult, error) {
 	val, ok := wm.workspaces.Load(workspaceID)
 	if !ok {
 		return nil, fmt.Errorf("workspace not found: %s", workspaceID)
@@ -242,16 +258,40 @@ func (wm *WorkspaceManager) BroadcastToWorkspace(ctx context.Context, workspaceI
 	}
 
 	// Send to all members except sender
-	var recipients []string
+	result := &BroadcastResult{
+		Recipients: []string{},
+		Successful: []string{},
+		Failed:     []BroadcastFailure{},
+	}
 	for agentID := range workspace.Members {
-		if agentID != senderID {
-			recipients = append(recipients, agentID)
-			wm.server.SendToAgent(agentID, msgBytes)
+		if agentID == senderID {
+			continue
+		}
+		result.Recipients = append(result.Recipients, agentID)
+		if err := wm.server.SendToAgent(agentID, msgBytes); err != nil {
+			result.Failed = append(result.Failed, BroadcastFailure{AgentID: agentID, Error: err.Error()})
+		} else {
+			result.Successful = append(result.Successful, agentID)
 		}
 	}
+	sort.Strings(result.Recipients)
+	sort.Strings(result.Successful)
+	sort.Slice(result.Failed, func(i, j int) bool {
+		return result.Failed[i].AgentID < result.Failed[j].AgentID
+	})
+
+	if len(result.Failed) > 0 {
+		wm.logger.Warn("Workspace broadcast partially failed", map[string]interface{}{
+			"workspace_id": workspaceID,
+			"event":        event,
+			"failed":       len(result.Failed),
+			"recipients":   len(result.Recipients),
+		})
+		wm.metrics.IncrementCounter("workspace_broadcast_failures", float64(len(result.Failed)))
+	}
 
 	wm.metrics.IncrementCounter("workspace_broadcasts", 1)
-	return recipients, nil
+	return result, nil
 }
 
 // ListMembers lists all members of a workspace
@@ -287,6 +327,15 @@ func (wm *WorkspaceManager) IsMember(ctx context.Context, workspaceID, agentID s
 	return exists, nil
 }
 
+// GetWorkspaceType returns the type of a workspace, or an empty string if it does not exist
+func (wm *WorkspaceManager) GetWorkspaceType(workspaceID string) string {
+	val, ok := wm.workspaces.Load(workspaceID)
+	if !ok {
+		return ""
+	}
+	return val.(*Workspace).Type
+}
+
 // GetAgentWorkspaces returns all workspaces for an agent
 func (wm *WorkspaceManager) GetAgentWorkspaces(agentID string) []string {
 	val, ok := wm.members.Load(agentID)
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestWorkspaceBroadcastReportsFailedDeliveries(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	ctx := context.Background()
+
+	workspace, err := server.workspaceManager.CreateWorkspace(ctx, &WorkspaceConfig{
+		Name:    "team",
+		Type:    "team",
+		OwnerID: "sender",
+		Members: []string{"agent-a", "agent-b", "stalled", "offline"},
+	})
+	require.NoError(t, err)
+
+	connect := func(agentID string) *Connection {
+		conn := NewConnection("conn-"+agentID, nil, server)
+		conn.AgentID = agentID
+		server.addConnection(conn)
+		return conn
+	}
+	sender := connect("sender")
+	agentA := connect("agent-a")
+	agentB := connect("agent-b")
+	// Nothing drains this connection's unbuffered send channel
+	stalled := connect("stalled")
+	stalled.send = make(chan []byte)
+
+	params, err := json.Marshal(map[string]interface{}{
+		"workspace_id": workspace.ID,
+		"event":        "build_finished",
+		"data":         map[string]interface{}{"status": "green"},
+	})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	var result interface{}
+	go func() {
+		defer close(done)
+		result, err = server.handleWorkspaceBroadcast(ctx, sender, params)
+	}()
+
+	// The stalled member doesn't block delivery to the others
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("broadcast blocked on a stalled member")
+	}
+	require.NoError(t, err)
+
+	response := result.(map[string]interface{})
+	assert.Equal(t, []string{"agent-a", "agent-b", "offline", "stalled"}, response["recipients"])
+	assert.Equal(t, []string{"agent-a", "agent-b"}, response["successful"])
+	assert.Equal(t, []BroadcastFailure{
+		{AgentID: "offline", Error: ErrAgentNotConnected.Error()},
+		{AgentID: "stalled", Error: ErrChannelFull.Error()},
+	}, response["failed"])
+
+	assert.Len(t, agentA.send, 1)
+	assert.Len(t, agentB.send, 1)
+	assert.Len(t, sender.send, 0)
+}
+
+func TestWorkspaceBroadcastAllDelivered(t *testing.T) {
+	server := NewServer(&auth.Service{}, observability.NewNoOpMetricsClient(), NewTestLogger(), Config{})
+	ctx := context.Background()
+
+	workspace, err := server.workspaceManager.CreateWorkspace(ctx, &WorkspaceConfig{
+		Name:    "pair",
+		Type:    "private",
+		OwnerID: "sender",
+		Members: []string{"agent-a"},
+	})
+	require.NoError(t, err)
+
+	conn := NewConnection("conn-a", nil, server)
+	conn.AgentID = "agent-a"
+	server.addConnection(conn)
+
+	result, err := server.workspaceManager.BroadcastToWorkspace(ctx, workspace.ID, "sender", "ping", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"agent-a"}, result.Successful)
+	assert.Empty(t, result.Failed)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
 	"github.com/developer-mesh/developer-mesh/pkg/repository"
 	"github.com/developer-mesh/developer-mesh/pkg/services"
+	"github.com/developer-mesh/developer-mesh/pkg/tools/adapters"
 
 	// Import PostgreSQL driver
 	_ "github.com/lib/pq"
@@ -316,6 +317,9 @@ func main() {
 		},
 		Performance: api.DefaultConfig().Performance,
 	}
+	if cfg.API.ProviderLogging != nil {
+		apiConfig.ProviderLogging = parseProviderLoggingConfig(cfg.API.ProviderLogging)
+	}
 
 	// Create a MetricsClient instance
 	obsMetricsClient := observability.NewMetricsClient()
@@ -440,6 +444,30 @@ func getStringFromConfig(m map[string]any, key string) string {
 }
 
 // parseRateLimitConfig parses rate limit configuration from either a map or integer
+// parseProviderLoggingConfig converts provider exchange logging configuration
+func parseProviderLoggingConfig(input *config.ProviderLoggingConfig) adapters.ExchangeLoggingConfig {
+	toExchangeLogConfig := func(c config.ProviderExchangeLogConfig) adapters.ExchangeLogConfig {
+		return adapters.ExchangeLogConfig{
+			Enabled:      c.Enabled,
+			Level:        c.Level,
+			LogBodies:    c.LogBodies,
+			MaxBodyBytes: c.MaxBodyBytes,
+		}
+	}
+
+	result := adapters.ExchangeLoggingConfig{
+		Default:         toExchangeLogConfig(input.Default),
+		SensitiveFields: input.SensitiveFields,
+	}
+	if len(input.Providers) > 0 {
+		result.Providers = make(map[string]adapters.ExchangeLogConfig, len(input.Providers))
+		for provider, providerConfig := range input.Providers {
+			result.Providers[provider] = toExchangeLogConfig(providerConfig)
+		}
+	}
+	return result
+}
+
 func parseRateLimitConfig(input any) api.RateLimitConfig {
 	config := api.RateLimitConfig{
 		Enabled:     false,
@@ -482,6 +510,11 @@ func parseRateLimitConfig(input any) api.RateLimitConfig {
 		} else if burstFactor, ok := v["burst_factor"].(float64); ok {
 			config.BurstFactor = int(burstFactor)
 		}
+		// Search endpoints get their own, stricter limit
+		if search, ok := v["search"].(map[string]any); ok {
+			searchConfig := parseRateLimitConfig(search)
+			config.Search = &searchConfig
+		}
 		return config
 	default:
 		// Log warning and return default config
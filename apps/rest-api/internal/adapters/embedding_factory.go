@@ -89,13 +89,39 @@ func CreateEmbeddingService(cfg *config.Config, db database.Database, cache cach
 	// Create embedding cache adapter
 	embeddingCache := NewEmbeddingCacheAdapter(cache)
 
+	// A/B test a candidate model on a fraction of traffic when configured
+	var experiment *embedding.ModelExperiment
+	experimentConfig := embedding.ModelExperimentConfig{
+		Name:            cfg.Embedding.Experiment.Name,
+		CandidateModel:  cfg.Embedding.Experiment.CandidateModel,
+		TrafficFraction: cfg.Embedding.Experiment.TrafficFraction,
+	}
+	if experimentConfig.Enabled() {
+		var err error
+		experiment, err = embedding.NewModelExperiment(experimentConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create ServiceV2 - this is our ONLY embedding service
 	return embedding.NewServiceV2(embedding.ServiceV2Config{
-		Providers:    providerMap,
-		AgentService: agentService,
-		Repository:   embeddingRepo,
-		MetricsRepo:  metricsRepo,
-		Cache:        embeddingCache,
+		Providers:     providerMap,
+		AgentService:  agentService,
+		Repository:    embeddingRepo,
+		MetricsRepo:   metricsRepo,
+		Cache:         embeddingCache,
+		FallbackChain: cfg.Embedding.FallbackChain,
+		Normalization: embedding.NormalizationConfig{
+			Enabled: cfg.Embedding.Normalization.Enabled,
+			Metric:  embedding.DistanceMetric(cfg.Embedding.Normalization.Metric),
+		},
+		Deduplication: embedding.DeduplicationConfig{
+			Enabled:   cfg.Embedding.Deduplication.Enabled,
+			Threshold: cfg.Embedding.Deduplication.Threshold,
+			Policy:    embedding.DuplicatePolicy(cfg.Embedding.Deduplication.Policy),
+		},
+		Experiment: experiment,
 	})
 }
 
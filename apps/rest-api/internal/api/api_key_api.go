@@ -0,0 +1,197 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// DefaultAPIKeyRotationOverlap is how long a rotated key keeps working when
+// the rotation request doesn't say
+const DefaultAPIKeyRotationOverlap = 24 * time.Hour
+
+// APIKeyAPI handles API key management endpoints for tenant admins
+type APIKeyAPI struct {
+	authService *auth.Service
+	logger      observability.Logger
+}
+
+// NewAPIKeyAPI creates a new API key management handler
+func NewAPIKeyAPI(authService *auth.Service, logger observability.Logger) *APIKeyAPI {
+	return &APIKeyAPI{
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+// RegisterRoutes registers the API key routes, which require the admin scope
+func (api *APIKeyAPI) RegisterRoutes(router *gin.RouterGroup) {
+	apiKeys := router.Group("/api-keys")
+	apiKeys.Use(api.authService.RequireScopes("admin"))
+	{
+		apiKeys.GET("", api.ListAPIKeys)
+		apiKeys.POST("/:id/rotate", api.RotateAPIKey)
+	}
+}
+
+// RotateAPIKeyRequest is a request to rotate an API key
+type RotateAPIKeyRequest struct {
+	Overlap string `json:"overlap,omitempty"` // How long the old key keeps working, e.g. "1h"; defaults to 24h
+}
+
+// APIKeyResponse describes an API key without revealing it
+type APIKeyResponse struct {
+	ID            string     `json:"id"`
+	KeyPrefix     string     `json:"key_prefix"`
+	Name          string     `json:"name"`
+	KeyType       string     `json:"key_type"`
+	Scopes        []string   `json:"scopes"`
+	AllowedCIDRs  []string   `json:"allowed_cidrs,omitempty"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	ParentKeyID   *string    `json:"parent_key_id,omitempty"`
+	RotatingUntil *time.Time `json:"rotating_until,omitempty"`
+	RotatedTo     *string    `json:"rotated_to,omitempty"`
+	RotatedFrom   *string    `json:"rotated_from,omitempty"`
+}
+
+func newAPIKeyResponse(key *auth.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:            key.ID,
+		KeyPrefix:     key.KeyPrefix,
+		Name:          key.Name,
+		KeyType:       string(key.KeyType),
+		Scopes:        key.Scopes,
+		AllowedCIDRs:  key.AllowedCIDRs,
+		Active:        key.Active,
+		CreatedAt:     key.CreatedAt,
+		ExpiresAt:     key.ExpiresAt,
+		LastUsedAt:    key.LastUsed,
+		ParentKeyID:   key.ParentKeyID,
+		RotatingUntil: key.RotatingUntil,
+		RotatedTo:     key.RotatedTo,
+		RotatedFrom:   key.RotatedFrom,
+	}
+}
+
+// ListAPIKeys lists the API keys of the caller's tenant
+// @Summary List API keys
+// @Description Lists the tenant's API keys by prefix, including rotated and inactive keys
+// @Tags API Keys
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/api-keys [get]
+func (api *APIKeyAPI) ListAPIKeys(c *gin.Context) {
+	user, ok := auth.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	keys, err := api.authService.ListAPIKeys(c.Request.Context(), user.TenantID)
+	if err != nil {
+		api.logger.Error("Failed to list API keys", map[string]interface{}{
+			"error":     err.Error(),
+			"tenant_id": user.TenantID,
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, newAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"api_keys": response,
+		"count":    len(response),
+	})
+}
+
+// RotateAPIKey replaces an API key of the caller's tenant by its ID, as
+// ListAPIKeys reports it. The old key keeps working for the overlap period,
+// with a Deprecation header, then is deactivated.
+// @Summary Rotate an API key
+// @Description Issues a replacement key; the old key stays valid for the overlap period
+// @Tags API Keys
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param request body RotateAPIKeyRequest false "Key rotation request"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]interface{}
+// @Failure 401 {object} map[string]interface{}
+// @Failure 403 {object} map[string]interface{}
+// @Failure 404 {object} map[string]interface{}
+// @Failure 409 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/api-keys/{id}/rotate [post]
+func (api *APIKeyAPI) RotateAPIKey(c *gin.Context) {
+	user, ok := auth.GetUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// The body is optional
+	var req RotateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	overlap := DefaultAPIKeyRotationOverlap
+	if req.Overlap != "" {
+		var err error
+		overlap, err = time.ParseDuration(req.Overlap)
+		if err != nil || overlap <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Overlap must be a positive duration, e.g. 1h"})
+			return
+		}
+	}
+
+	// Admins may only rotate their own tenant's keys
+	newKey, err := api.authService.RotateAPIKeyByID(c.Request.Context(), user.TenantID, c.Param("id"), overlap)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAPIKeyRotating):
+			c.JSON(http.StatusConflict, gin.H{"error": "API key is already being rotated"})
+		case errors.Is(err, auth.ErrInvalidAPIKey):
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		default:
+			api.logger.Error("Failed to rotate API key", map[string]interface{}{
+				"error":     err.Error(),
+				"tenant_id": user.TenantID,
+			})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate API key"})
+		}
+		return
+	}
+
+	api.logger.Info("API key rotated via management API", map[string]interface{}{
+		"key_id":     newKey.ID,
+		"rotated_by": user.ID,
+		"tenant_id":  user.TenantID,
+		"overlap":    overlap.String(),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key":         newKey.Key, // Only returned once
+		"key":             newAPIKeyResponse(newKey),
+		"old_key_expires": time.Now().Add(overlap).UTC(),
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestAPIKeyAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	config := auth.DefaultConfig()
+	config.CacheEnabled = false
+	authService := auth.NewService(config, nil, nil, observability.NewNoopLogger())
+
+	tenantID := uuid.New().String()
+	createKey := func(name, tenant string, keyType auth.KeyType, scopes ...string) *auth.APIKey {
+		key, err := authService.CreateAPIKeyWithType(ctx, auth.CreateAPIKeyRequest{
+			Name:     name,
+			TenantID: tenant,
+			KeyType:  keyType,
+			Scopes:   scopes,
+		})
+		require.NoError(t, err)
+		return key
+	}
+	admin := createKey("admin", tenantID, auth.KeyTypeAdmin, "admin")
+	reader := createKey("reader", tenantID, auth.KeyTypeUser, "read")
+	deployer := createKey("deployer", tenantID, auth.KeyTypeAgent, "read", "write")
+	foreign := createKey("foreign", uuid.New().String(), auth.KeyTypeAgent, "read")
+
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.Use(authService.GinMiddleware())
+	NewAPIKeyAPI(authService, observability.NewNoopLogger()).RegisterRoutes(v1)
+
+	request := func(method, path, apiKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires the admin scope", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/api-keys", reader.Key, "")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("lists the tenant's keys without revealing them", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/api-keys", admin.Key, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), deployer.Key)
+
+		var response struct {
+			APIKeys []APIKeyResponse `json:"api_keys"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		prefixes := make([]string, 0, len(response.APIKeys))
+		for _, key := range response.APIKeys {
+			prefixes = append(prefixes, key.KeyPrefix)
+		}
+		assert.ElementsMatch(t, []string{admin.KeyPrefix, reader.KeyPrefix, deployer.KeyPrefix}, prefixes)
+	})
+
+	t.Run("rotates a key with an overlap period", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/api-keys/"+deployer.ID+"/rotate", admin.Key, `{"overlap": "1h"}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var response struct {
+			APIKey string `json:"api_key"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		_, err := authService.ValidateAPIKey(ctx, response.APIKey)
+		require.NoError(t, err)
+
+		// The old key still works, flagged as deprecated
+		w = request(http.MethodGet, "/api/v1/api-keys", deployer.Key, "")
+		assert.Equal(t, "true", w.Header().Get("Deprecation"))
+		assert.NotEmpty(t, w.Header().Get("Sunset"))
+
+		w = request(http.MethodPost, "/api/v1/api-keys/"+deployer.ID+"/rotate", admin.Key, "")
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("rotates keys bound to an allowlist without using them", func(t *testing.T) {
+		bound, err := authService.CreateAPIKeyWithType(ctx, auth.CreateAPIKeyRequest{
+			Name:         "egress-bound",
+			TenantID:     tenantID,
+			KeyType:      auth.KeyTypeAgent,
+			AllowedCIDRs: []string{"192.0.2.0/24"},
+		})
+		require.NoError(t, err)
+
+		w := request(http.MethodPost, "/api/v1/api-keys/"+bound.ID+"/rotate", admin.Key, "")
+		assert.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("rejects other tenants' keys and invalid overlaps", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/api-keys/"+foreign.ID+"/rotate", admin.Key, "")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = request(http.MethodPost, "/api/v1/api-keys/"+reader.ID+"/rotate", admin.Key, `{"overlap": "-1h"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
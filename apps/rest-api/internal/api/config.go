@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/developer-mesh/developer-mesh/pkg/interfaces"
+	"github.com/developer-mesh/developer-mesh/pkg/tools/adapters"
 )
 
 // Config holds configuration for the API server
@@ -21,6 +22,9 @@ type Config struct {
 	Versioning    VersioningConfig         `mapstructure:"versioning"`
 	Performance   PerformanceConfig        `mapstructure:"performance"`
 	Webhook       interfaces.WebhookConfig `mapstructure:"webhook"`
+
+	// ProviderLogging logs redacted provider HTTP exchanges for debugging
+	ProviderLogging adapters.ExchangeLoggingConfig `mapstructure:"provider_logging"`
 }
 
 // VersioningConfig holds API versioning configuration
@@ -69,6 +73,9 @@ type RateLimitConfig struct {
 	Limit       int           `mapstructure:"limit"`
 	Period      time.Duration `mapstructure:"period"`
 	BurstFactor int           `mapstructure:"burst_factor"`
+	// Search is the stricter limit applied to search endpoints in addition to
+	// the general limit
+	Search *RateLimitConfig `mapstructure:"search"`
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -89,6 +96,12 @@ func DefaultConfig() Config {
 			Limit:       100,
 			Period:      time.Minute,
 			BurstFactor: 3,
+			Search: &RateLimitConfig{
+				Enabled:     true,
+				Limit:       10,
+				Period:      time.Minute,
+				BurstFactor: 2,
+			},
 		},
 		Versioning: VersioningConfig{
 			Enabled:           true,
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -116,6 +117,7 @@ func (api *EmbeddingAPI) generateEmbedding(c *gin.Context) {
 // @Produce json
 // @Param requests body []embedding.GenerateEmbeddingRequest true "Array of embedding requests"
 // @Success 200 {object} map[string]interface{} "Batch generation results"
+// @Success 207 {object} map[string]interface{} "Results of the embedded requests and the failures of the rest"
 // @Failure 400 {object} ErrorResponse "Invalid request"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -143,6 +145,22 @@ func (api *EmbeddingAPI) batchGenerateEmbeddings(c *gin.Context) {
 	}
 
 	resps, err := api.embeddingService.BatchGenerateEmbeddings(c.Request.Context(), reqs)
+
+	// Report which requests failed, and whether to retry them, alongside the
+	// embeddings of the rest
+	var partial *embedding.PartialBatchError
+	if errors.As(err, &partial) && len(partial.Failures) < len(reqs) {
+		api.logger.Warn("Some batch embedding requests failed", map[string]any{
+			"failed": len(partial.Failures),
+			"count":  len(reqs),
+		})
+		c.JSON(http.StatusMultiStatus, gin.H{
+			"embeddings": resps,
+			"count":      len(reqs) - len(partial.Failures),
+			"failures":   partial.Failures,
+		})
+		return
+	}
 	if err != nil {
 		api.logger.Error("Failed to batch generate embeddings", map[string]any{
 			"error": err.Error(),
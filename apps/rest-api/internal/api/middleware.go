@@ -184,22 +184,8 @@ func RateLimiter(config RateLimiterConfig) gin.HandlerFunc {
 	})
 
 	return func(c *gin.Context) {
-		var clientID string
-
-		// Get client identifier - prefer authenticated user ID if available
-		if userID, exists := c.Get("user_id"); exists && userID != nil {
-			// Use authenticated user ID if available
-			clientID = fmt.Sprintf("user:%v", userID)
-		} else {
-			// Fallback to IP address with proper forwarded header handling
-			// Note: X-Forwarded-For can be spoofed, so in production use a secure
-			// proxy configuration that sets X-Real-IP or similar
-			clientIP := c.ClientIP()
-			clientID = fmt.Sprintf("ip:%s", clientIP)
-		}
-
 		// Get limiter for this client
-		limiter := storage.GetLimiter(clientID)
+		limiter := storage.GetLimiter(rateLimitClientID(c))
 
 		// Check if request allowed
 		if !limiter.Allow() {
@@ -215,6 +201,58 @@ func RateLimiter(config RateLimiterConfig) gin.HandlerFunc {
 	}
 }
 
+// SearchRateLimiter applies a separate, stricter rate limit to search endpoints.
+// Search is CPU and database heavy, so it gets its own budget per client on top
+// of the general limit, and exhausting it leaves other endpoints unaffected.
+func SearchRateLimiter(config RateLimiterConfig) gin.HandlerFunc {
+	storage := NewRateLimiterStorage(config)
+
+	shutdownHooks = append(shutdownHooks, func() {
+		storage.Close()
+	})
+
+	return func(c *gin.Context) {
+		if !isSearchRoute(c.FullPath()) {
+			c.Next()
+			return
+		}
+
+		if !storage.GetLimiter(rateLimitClientID(c)).Allow() {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Search rate limit exceeded",
+				"retry_after": "60",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// isSearchRoute reports whether a route pattern is a search endpoint, such as
+// /api/v1/embeddings/search or /api/v1/embeddings/search/cross-model
+func isSearchRoute(route string) bool {
+	for _, segment := range strings.Split(route, "/") {
+		if segment == "search" {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimitClientID identifies the client a request is rate limited as
+func rateLimitClientID(c *gin.Context) string {
+	// Prefer authenticated user ID if available
+	if userID, exists := c.Get("user_id"); exists && userID != nil {
+		return fmt.Sprintf("user:%v", userID)
+	}
+
+	// Fallback to IP address with proper forwarded header handling
+	// Note: X-Forwarded-For can be spoofed, so in production use a secure
+	// proxy configuration that sets X-Real-IP or similar
+	return fmt.Sprintf("ip:%s", c.ClientIP())
+}
+
 // Avoid duplicate declaration - shutdownHooks is already defined in server.go
 
 // CompressionMiddleware compresses HTTP responses
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -121,3 +122,70 @@ func TestBasicRouting(t *testing.T) {
 	// This isn't testing auth, just that the route works
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestSearchRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(general, search RateLimiterConfig) *gin.Engine {
+		router := gin.New()
+		router.Use(RateLimiter(general))
+		router.Use(SearchRateLimiter(search))
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+		router.POST("/api/v1/embeddings/search", ok)
+		router.POST("/api/v1/embeddings/search/cross-model", ok)
+		router.GET("/api/v1/contexts/:contextID", ok)
+		return router
+	}
+	do := func(router *gin.Engine, method, path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w.Code
+	}
+
+	t.Run("search is throttled by its own limit", func(t *testing.T) {
+		router := newRouter(
+			RateLimiterConfig{Limit: 1000, Burst: 1000, Expiration: time.Hour},
+			RateLimiterConfig{Limit: 0.001, Burst: 2, Expiration: time.Hour},
+		)
+
+		assert.Equal(t, http.StatusOK, do(router, http.MethodPost, "/api/v1/embeddings/search"))
+		assert.Equal(t, http.StatusOK, do(router, http.MethodPost, "/api/v1/embeddings/search/cross-model"))
+		assert.Equal(t, http.StatusTooManyRequests, do(router, http.MethodPost, "/api/v1/embeddings/search"))
+
+		// Other endpoints still have general budget left
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, http.StatusOK, do(router, http.MethodGet, "/api/v1/contexts/ctx-1"))
+		}
+	})
+
+	t.Run("cheap calls don't use search budget", func(t *testing.T) {
+		router := newRouter(
+			RateLimiterConfig{Limit: 1000, Burst: 1000, Expiration: time.Hour},
+			RateLimiterConfig{Limit: 0.001, Burst: 1, Expiration: time.Hour},
+		)
+
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, http.StatusOK, do(router, http.MethodGet, "/api/v1/contexts/ctx-1"))
+		}
+		assert.Equal(t, http.StatusOK, do(router, http.MethodPost, "/api/v1/embeddings/search"))
+	})
+
+	t.Run("general limit still applies to search", func(t *testing.T) {
+		router := newRouter(
+			RateLimiterConfig{Limit: 0.001, Burst: 1, Expiration: time.Hour},
+			RateLimiterConfig{Limit: 1000, Burst: 1000, Expiration: time.Hour},
+		)
+
+		assert.Equal(t, http.StatusOK, do(router, http.MethodGet, "/api/v1/contexts/ctx-1"))
+		assert.Equal(t, http.StatusTooManyRequests, do(router, http.MethodPost, "/api/v1/embeddings/search"))
+	})
+}
+
+func TestIsSearchRoute(t *testing.T) {
+	assert.True(t, isSearchRoute("/api/v1/embeddings/search"))
+	assert.True(t, isSearchRoute("/api/v1/embeddings/search/cross-model"))
+	assert.True(t, isSearchRoute("/api/v1/contexts/:contextID/search"))
+	assert.False(t, isSearchRoute("/api/v1/contexts/:contextID"))
+	assert.False(t, isSearchRoute("/api/v1/searches"))
+	assert.False(t, isSearchRoute(""))
+}
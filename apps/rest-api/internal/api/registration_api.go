@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/developer-mesh/developer-mesh/apps/rest-api/internal/services"
@@ -299,8 +300,10 @@ func (api *RegistrationAPI) AuthenticateEdgeMCP(c *gin.Context) {
 		return
 	}
 
-	// Validate the API key and get the user/tenant information
-	user, err := api.authService.ValidateAPIKey(c.Request.Context(), req.APIKey)
+	// Validate the API key and get the user/tenant information, from the
+	// Edge MCP's address for keys bound to an allowlist
+	ctx := context.WithValue(c.Request.Context(), auth.ContextKeyIPAddress, c.ClientIP())
+	user, err := api.authService.ValidateAPIKey(ctx, req.APIKey)
 	if err != nil {
 		api.logger.Warn("Edge MCP authentication failed", map[string]interface{}{
 			"edge_mcp_id": req.EdgeMCPID,
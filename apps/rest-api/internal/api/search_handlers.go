@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -46,12 +47,20 @@ type SearchRequest struct {
 	UseReranking bool `json:"use_reranking,omitempty"`
 	// RerankModel specifies which reranking model to use
 	RerankModel string `json:"rerank_model,omitempty"`
+	// RerankMode is "cross-encoder" (the default) or "mmr" to diversify results
+	RerankMode string `json:"rerank_mode,omitempty"`
+	// MMRLambda weighs relevance against diversity in MMR mode (0-1)
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
 	// UseQueryExpansion enables query expansion
 	UseQueryExpansion bool `json:"use_query_expansion,omitempty"`
 	// QueryExpansionTypes specifies which expansion types to use
 	QueryExpansionTypes []string `json:"query_expansion_types,omitempty"`
 	// MaxExpansions limits the number of query expansions
 	MaxExpansions int `json:"max_expansions,omitempty"`
+	// Facets are fields to count results by, such as content_type or repository
+	Facets []string `json:"facets,omitempty"`
+	// ModelID embeds the query with a specific model instead of the default one
+	ModelID string `json:"model_id,omitempty"`
 }
 
 // SearchByVectorRequest represents a vector search request with a pre-computed vector
@@ -78,6 +87,12 @@ type SearchByVectorRequest struct {
 	RerankModel string `json:"rerank_model,omitempty"`
 	// RerankQuery allows overriding the query used for reranking (for vector search)
 	RerankQuery string `json:"rerank_query,omitempty"`
+	// RerankMode is "cross-encoder" (the default) or "mmr" to diversify results
+	RerankMode string `json:"rerank_mode,omitempty"`
+	// MMRLambda weighs relevance against diversity in MMR mode (0-1)
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
+	// Facets are fields to count results by, such as content_type or repository
+	Facets []string `json:"facets,omitempty"`
 }
 
 // SearchResponse represents the API response for search endpoints
@@ -88,6 +103,8 @@ type SearchResponse struct {
 	Total int `json:"total"`
 	// HasMore indicates if there are more results available
 	HasMore bool `json:"has_more"`
+	// Facets are the result counts per value of each requested facet field
+	Facets map[string][]embedding.FacetCount `json:"facets,omitempty"`
 	// Query information for debugging and auditing
 	Query struct {
 		// Text or ContentID that was searched for
@@ -149,6 +166,12 @@ func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
+		if facets := q.Get("facets"); facets != "" {
+			searchReq.Facets = strings.Split(facets, ",")
+		}
+
+		searchReq.ModelID = q.Get("model_id")
+
 		// Note: Complex parameters like filters and weight factors
 		// are not supported in GET requests for simplicity
 	}
@@ -170,13 +193,21 @@ func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 		WeightFactors:       searchReq.WeightFactors,
 		UseReranking:        searchReq.UseReranking,
 		RerankModel:         searchReq.RerankModel,
+		RerankMode:          searchReq.RerankMode,
+		MMRLambda:           searchReq.MMRLambda,
 		UseQueryExpansion:   searchReq.UseQueryExpansion,
 		QueryExpansionTypes: searchReq.QueryExpansionTypes,
 		MaxExpansions:       searchReq.MaxExpansions,
+		Facets:              searchReq.Facets,
+		ModelID:             searchReq.ModelID,
 	}
 
 	// Perform the search
 	results, err := h.searchService.Search(r.Context(), searchReq.Query, options)
+	if errors.Is(err, embedding.ErrUnknownSearchModel) || errors.Is(err, embedding.ErrInvalidRerankOptions) {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Search error: %v", err), http.StatusInternalServerError)
 		return
@@ -187,6 +218,7 @@ func (h *SearchHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
 		Results: results.Results,
 		Total:   results.Total,
 		HasMore: results.HasMore,
+		Facets:  results.Facets,
 	}
 	response.Query.Input = searchReq.Query
 	response.Query.Options = options
@@ -230,10 +262,17 @@ func (h *SearchHandler) HandleSearchByVector(w http.ResponseWriter, r *http.Requ
 		UseReranking:  searchReq.UseReranking,
 		RerankModel:   searchReq.RerankModel,
 		RerankQuery:   searchReq.RerankQuery, // For vector search, we need the query text for reranking
+		RerankMode:    searchReq.RerankMode,
+		MMRLambda:     searchReq.MMRLambda,
+		Facets:        searchReq.Facets,
 	}
 
 	// Perform the search
 	results, err := h.searchService.SearchByVector(r.Context(), searchReq.Vector, options)
+	if errors.Is(err, embedding.ErrInvalidRerankOptions) {
+		http.Error(w, fmt.Sprintf("Invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Search error: %v", err), http.StatusInternalServerError)
 		return
@@ -244,6 +283,7 @@ func (h *SearchHandler) HandleSearchByVector(w http.ResponseWriter, r *http.Requ
 		Results: results.Results,
 		Total:   results.Total,
 		HasMore: results.HasMore,
+		Facets:  results.Facets,
 	}
 	response.Query.Input = fmt.Sprintf("vector[%d]", len(searchReq.Vector))
 	response.Query.Options = options
@@ -264,6 +304,7 @@ func (h *SearchHandler) HandleSearchByVector(w http.ResponseWriter, r *http.Requ
 // @Param limit query integer false "Maximum number of results"
 // @Param offset query integer false "Pagination offset"
 // @Param min_similarity query number false "Minimum similarity threshold (0.0-1.0)"
+// @Param facets query string false "Comma-separated fields to count results by"
 // @Success 200 {object} SearchResponse "Similar content results"
 // @Failure 400 {object} map[string]interface{} "Invalid request"
 // @Failure 401 {object} map[string]interface{} "Unauthorized"
@@ -325,6 +366,10 @@ func (h *SearchHandler) HandleSearchSimilar(w http.ResponseWriter, r *http.Reque
 				options.MinSimilarity = float32(ms)
 			}
 		}
+
+		if facets := q.Get("facets"); facets != "" {
+			options.Facets = strings.Split(facets, ",")
+		}
 	}
 
 	// Validate the request
@@ -345,6 +390,7 @@ func (h *SearchHandler) HandleSearchSimilar(w http.ResponseWriter, r *http.Reque
 		Results: results.Results,
 		Total:   results.Total,
 		HasMore: results.HasMore,
+		Facets:  results.Facets,
 	}
 	response.Query.Input = contentID
 	response.Query.Options = &options
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -163,6 +164,74 @@ func TestHandleSearch(t *testing.T) {
 		// Verify the mock was called with correct parameters
 		mockService.AssertExpectations(t)
 	})
+
+	t.Run("facets", func(t *testing.T) {
+		mockResults := &embedding.SearchResults{
+			Results: []*embedding.SearchResult{},
+			Facets: map[string][]embedding.FacetCount{
+				"repository": {{Value: "mesh", Count: 2}, {Value: "helm-charts", Count: 1}},
+			},
+		}
+
+		mockService.ExpectedCalls = nil
+		mockService.On("Search", mock.Anything, "rollback", mock.MatchedBy(func(options *embedding.SearchOptions) bool {
+			return assert.ObjectsAreEqual([]string{"repository", "content_type"}, options.Facets)
+		})).Return(mockResults, nil)
+
+		resp, err := http.Get(server.URL + "/api/v1/search?query=rollback&facets=repository,content_type")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var searchResp SearchResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&searchResp))
+		assert.Equal(t, mockResults.Facets, searchResp.Facets)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("model override", func(t *testing.T) {
+		mockService.ExpectedCalls = nil
+		mockService.On("Search", mock.Anything, "rollback", mock.MatchedBy(func(options *embedding.SearchOptions) bool {
+			return options.ModelID == "voyage-code-2"
+		})).Return(&embedding.SearchResults{Results: []*embedding.SearchResult{}}, nil)
+		mockService.On("Search", mock.Anything, "rollback", mock.MatchedBy(func(options *embedding.SearchOptions) bool {
+			return options.ModelID == "unknown-model"
+		})).Return(nil, fmt.Errorf("%w: unknown-model", embedding.ErrUnknownSearchModel))
+
+		resp, err := http.Get(server.URL + "/api/v1/search?query=rollback&model_id=voyage-code-2")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, err = http.Get(server.URL + "/api/v1/search?query=rollback&model_id=unknown-model")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("MMR rerank mode", func(t *testing.T) {
+		mockService.ExpectedCalls = nil
+		mockService.On("Search", mock.Anything, "rollback", mock.MatchedBy(func(options *embedding.SearchOptions) bool {
+			return options.RerankMode == "mmr" && options.MMRLambda != nil && *options.MMRLambda == 0.3
+		})).Return(&embedding.SearchResults{Results: []*embedding.SearchResult{}}, nil)
+		mockService.On("Search", mock.Anything, "rollback", mock.MatchedBy(func(options *embedding.SearchOptions) bool {
+			return options.RerankMode == "listwise"
+		})).Return(nil, fmt.Errorf("%w: unknown rerank mode", embedding.ErrInvalidRerankOptions))
+
+		body := `{"query": "rollback", "use_reranking": true, "rerank_mode": "mmr", "mmr_lambda": 0.3}`
+		resp, err := http.Post(server.URL+"/api/v1/search", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		body = `{"query": "rollback", "use_reranking": true, "rerank_mode": "listwise"}`
+		resp, err = http.Post(server.URL+"/api/v1/search", "application/json", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = resp.Body.Close() })
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		mockService.AssertExpectations(t)
+	})
 }
 
 func TestHandleSearchByVector(t *testing.T) {
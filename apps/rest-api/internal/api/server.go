@@ -108,6 +108,9 @@ func NewServer(engine *core.Engine, cfg Config, db *sqlx.DB, metrics observabili
 		limiterConfig := NewRateLimiterConfigFromConfig(cfg.RateLimit)
 		router.Use(RateLimiter(limiterConfig))
 	}
+	if cfg.RateLimit.Search != nil && cfg.RateLimit.Search.Enabled {
+		router.Use(SearchRateLimiter(NewRateLimiterConfigFromConfig(*cfg.RateLimit.Search)))
+	}
 
 	// Enable CORS if configured
 	if cfg.EnableCORS {
@@ -358,6 +361,9 @@ func (s *Server) setupRoutes(ctx context.Context) {
 		return
 	}
 
+	// Deactivate rotated API keys once their overlap period ends
+	go authService.RunAPIKeyRotationSweeper(ctx, time.Minute)
+
 	// Create organization and user services
 	s.logger.Info("Creating organization and user services", nil)
 	orgService := services.NewOrganizationService(s.db, authService, emailService, s.logger)
@@ -414,6 +420,9 @@ func (s *Server) setupRoutes(ctx context.Context) {
 	v1.PUT("/profile", registrationAPI.UpdateProfile)
 	v1.POST("/profile/password", registrationAPI.ChangePassword)
 
+	// API key management (admin scope)
+	NewAPIKeyAPI(authService, s.logger).RegisterRoutes(v1)
+
 	// Root endpoint to provide API entry points (HATEOAS)
 	v1.GET("/", func(c *gin.Context) {
 		// Check for authentication result set by AuthMiddleware
@@ -495,6 +504,7 @@ func (s *Server) setupRoutes(ctx context.Context) {
 		encryptionService,
 		patternRepo,
 		cacheService,
+		s.config.ProviderLogging,
 	)
 
 	// Dynamic webhook routes for dynamic tools
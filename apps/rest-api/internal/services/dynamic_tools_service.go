@@ -73,6 +73,7 @@ type DynamicToolsService struct {
 	multiAPIDiscoveryService *adapters.MultiAPIDiscoveryService
 	dynamicToolRepo          pkgrepository.DynamicToolRepository
 	cacheService             *pkgcache.Service // Execution result cache
+	exchangeLogging          adapters.ExchangeLoggingConfig
 }
 
 // NewDynamicToolsService creates a new dynamic tools service
@@ -83,6 +84,7 @@ func NewDynamicToolsService(
 	encryptionSvc *security.EncryptionService,
 	patternRepo *storage.DiscoveryPatternRepository,
 	cacheService *pkgcache.Service,
+	exchangeLogging adapters.ExchangeLoggingConfig,
 ) DynamicToolsServiceInterface {
 	// Create discovery service with pattern repository
 	discoveryService := NewEnhancedDiscoveryService(
@@ -115,6 +117,7 @@ func NewDynamicToolsService(
 		multiAPIDiscoveryService: multiAPIDiscoveryService,
 		dynamicToolRepo:          dynamicToolRepo,
 		cacheService:             cacheService,
+		exchangeLogging:          exchangeLogging,
 	}
 }
 
@@ -692,6 +695,7 @@ func (s *DynamicToolsService) ListToolActions(ctx context.Context, tenantID, too
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tool adapter: %w", err)
 	}
+	adapter.SetExchangeLogging(s.exchangeLogging)
 
 	// List actions from the adapter
 	actions, err := adapter.ListActions(ctx)
@@ -801,6 +805,7 @@ func (s *DynamicToolsService) executeToolActionInternal(ctx context.Context, ten
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tool adapter: %w", err)
 	}
+	adapter.SetExchangeLogging(s.exchangeLogging)
 
 	// Execute the action
 	result, err := adapter.ExecuteAction(ctx, action, params)
@@ -899,6 +904,7 @@ func (s *DynamicToolsService) ExecuteToolActionWithPassthrough(
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tool adapter: %w", err)
 	}
+	adapter.SetExchangeLogging(s.exchangeLogging)
 
 	// Log parameters being passed
 	s.logger.Info("Executing tool action with passthrough", map[string]interface{}{
@@ -0,0 +1,6 @@
+-- Rollback Service Accounts
+BEGIN;
+
+DROP TABLE IF EXISTS mcp.service_accounts CASCADE;
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Service Accounts
+-- Non-human identities for machine-to-machine authentication
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mcp.service_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(50) NOT NULL DEFAULT 'service_account' CHECK (type IN ('service_account')),
+
+    -- Dedicated API key (removing the key removes the account)
+    api_key_id UUID NOT NULL REFERENCES mcp.api_keys(id) ON DELETE CASCADE,
+    scopes TEXT[] DEFAULT '{}',
+
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT uk_service_accounts_tenant_name UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_accounts_api_key ON mcp.service_accounts(api_key_id);
+CREATE INDEX IF NOT EXISTS idx_service_accounts_tenant ON mcp.service_accounts(tenant_id) WHERE is_active = true;
+
+COMMIT;
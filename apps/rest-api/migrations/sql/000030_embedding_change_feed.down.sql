@@ -0,0 +1,9 @@
+-- Rollback Embedding Change Feed
+BEGIN;
+
+DROP TRIGGER IF EXISTS record_context_item_change ON mcp.context_items;
+DROP FUNCTION IF EXISTS mcp.record_context_item_change();
+DROP TABLE IF EXISTS mcp.embedding_change_cursors;
+DROP TABLE IF EXISTS mcp.embedding_changes;
+
+COMMIT;
@@ -0,0 +1,67 @@
+-- Embedding Change Feed
+-- Records context item content changes so the embedding index can be updated incrementally
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mcp.embedding_changes (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id UUID NOT NULL,
+    context_id UUID NOT NULL,
+    content_index INTEGER NOT NULL,
+    operation VARCHAR(10) NOT NULL CHECK (operation IN ('insert', 'update', 'delete')),
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_changes_changed_at ON mcp.embedding_changes(changed_at);
+
+-- Last change applied by each change feed consumer
+CREATE TABLE IF NOT EXISTS mcp.embedding_change_cursors (
+    consumer VARCHAR(255) PRIMARY KEY,
+    last_change_id BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION mcp.record_context_item_change() RETURNS TRIGGER AS $$
+DECLARE
+    v_tenant_id UUID;
+    v_change_id BIGINT;
+BEGIN
+    -- Only content changes affect embeddings
+    IF TG_OP = 'UPDATE' AND NEW.content IS NOT DISTINCT FROM OLD.content
+        AND NEW.sequence_number = OLD.sequence_number THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        SELECT tenant_id INTO v_tenant_id FROM mcp.contexts WHERE id = OLD.context_id;
+    ELSE
+        SELECT tenant_id INTO v_tenant_id FROM mcp.contexts WHERE id = NEW.context_id;
+    END IF;
+
+    -- Deleting a context cascades to its embeddings
+    IF v_tenant_id IS NULL THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'DELETE' OR (TG_OP = 'UPDATE' AND NEW.sequence_number != OLD.sequence_number) THEN
+        INSERT INTO mcp.embedding_changes (tenant_id, context_id, content_index, operation)
+        VALUES (v_tenant_id, OLD.context_id, OLD.sequence_number, 'delete')
+        RETURNING id INTO v_change_id;
+    END IF;
+
+    IF TG_OP != 'DELETE' THEN
+        INSERT INTO mcp.embedding_changes (tenant_id, context_id, content_index, operation)
+        VALUES (v_tenant_id, NEW.context_id, NEW.sequence_number, lower(TG_OP))
+        RETURNING id INTO v_change_id;
+    END IF;
+
+    PERFORM pg_notify('embedding_changes', v_change_id::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS record_context_item_change ON mcp.context_items;
+CREATE TRIGGER record_context_item_change
+    AFTER INSERT OR UPDATE OR DELETE ON mcp.context_items
+    FOR EACH ROW EXECUTE FUNCTION mcp.record_context_item_change();
+
+COMMIT;
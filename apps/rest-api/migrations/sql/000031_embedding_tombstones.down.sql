@@ -0,0 +1,69 @@
+-- Rollback Embedding Tombstones
+BEGIN;
+
+DROP TRIGGER IF EXISTS replace_embedding_tombstone ON mcp.embeddings;
+DROP FUNCTION IF EXISTS mcp.replace_embedding_tombstone();
+
+-- Tombstoned embeddings would reappear in search
+DELETE FROM mcp.embeddings WHERE deleted_at IS NOT NULL;
+
+-- Function for similarity search with proper dimension handling
+CREATE OR REPLACE FUNCTION mcp.search_embeddings(
+    p_query_embedding vector,
+    p_model_name TEXT,
+    p_tenant_id UUID,
+    p_context_id UUID DEFAULT NULL,
+    p_limit INTEGER DEFAULT 10,
+    p_threshold FLOAT DEFAULT 0.0,
+    p_metadata_filter JSONB DEFAULT NULL
+) RETURNS TABLE (
+    id UUID,
+    context_id UUID,
+    content TEXT,
+    similarity FLOAT,
+    metadata JSONB,
+    model_provider VARCHAR(50)
+) AS $$
+DECLARE
+    v_dimensions INTEGER;
+    v_provider VARCHAR(50);
+BEGIN
+    -- Get dimensions and provider for the model
+    SELECT dimensions, provider 
+    INTO v_dimensions, v_provider
+    FROM mcp.embedding_models
+    WHERE model_name = p_model_name
+    AND is_active = true
+    LIMIT 1;
+    
+    IF v_dimensions IS NULL THEN
+        RAISE EXCEPTION 'Model % not found or inactive', p_model_name;
+    END IF;
+    
+    -- Dynamic query with proper casting
+    RETURN QUERY EXECUTE format(
+        'SELECT 
+            e.id,
+            e.context_id,
+            e.content,
+            1 - (e.embedding::vector(%1$s) <=> $1::vector(%1$s)) AS similarity,
+            e.metadata,
+            e.model_provider
+        FROM mcp.embeddings e
+        WHERE e.tenant_id = $2
+            AND e.model_name = $3
+            AND e.model_dimensions = %1$s
+            AND ($4::UUID IS NULL OR e.context_id = $4)
+            AND ($7::JSONB IS NULL OR e.metadata @> $7)
+            AND 1 - (e.embedding::vector(%1$s) <=> $1::vector(%1$s)) >= $5
+        ORDER BY e.embedding::vector(%1$s) <=> $1::vector(%1$s)
+        LIMIT $6',
+        v_dimensions
+    ) USING p_query_embedding, p_tenant_id, p_model_name, p_context_id, p_threshold, p_limit, p_metadata_filter;
+END;
+$$ LANGUAGE plpgsql STABLE PARALLEL SAFE;
+
+DROP INDEX IF EXISTS mcp.idx_embeddings_deleted_at;
+ALTER TABLE mcp.embeddings DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
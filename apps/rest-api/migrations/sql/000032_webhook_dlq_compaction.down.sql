@@ -0,0 +1,10 @@
+-- Rollback Webhook DLQ Compaction
+BEGIN;
+
+DROP INDEX IF EXISTS mcp.idx_webhook_dlq_failure_signature;
+
+ALTER TABLE mcp.webhook_dlq DROP CONSTRAINT IF EXISTS chk_dlq_occurrences;
+ALTER TABLE mcp.webhook_dlq DROP COLUMN IF EXISTS occurrences;
+ALTER TABLE mcp.webhook_dlq DROP COLUMN IF EXISTS failure_signature;
+
+COMMIT;
@@ -0,0 +1,18 @@
+-- Webhook DLQ Compaction
+-- Compaction collapses repeated failures into a bounded sample per failure
+-- signature; the newest sample counts the entries it replaced
+BEGIN;
+
+ALTER TABLE mcp.webhook_dlq ADD COLUMN IF NOT EXISTS failure_signature VARCHAR(64);
+ALTER TABLE mcp.webhook_dlq ADD COLUMN IF NOT EXISTS occurrences INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE mcp.webhook_dlq ADD CONSTRAINT chk_dlq_occurrences CHECK (occurrences >= 1);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_dlq_failure_signature
+    ON mcp.webhook_dlq(failure_signature)
+    WHERE failure_signature IS NOT NULL;
+
+COMMENT ON COLUMN mcp.webhook_dlq.failure_signature IS 'Hash of the event type and normalized error message, set when the entry is compacted';
+COMMENT ON COLUMN mcp.webhook_dlq.occurrences IS 'Number of failures the entry stands for, including entries removed by compaction';
+
+COMMIT;
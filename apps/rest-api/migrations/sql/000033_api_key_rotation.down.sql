@@ -0,0 +1,9 @@
+-- Rollback API Key Rotation
+BEGIN;
+
+DROP INDEX IF EXISTS mcp.idx_api_keys_rotating_until;
+
+ALTER TABLE mcp.api_keys DROP COLUMN IF EXISTS rotated_to;
+ALTER TABLE mcp.api_keys DROP COLUMN IF EXISTS rotating_until;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- API Key Rotation
+-- A rotated key stays valid until the end of its grace period so clients can
+-- switch to its replacement, then a sweep deactivates it
+BEGIN;
+
+ALTER TABLE mcp.api_keys ADD COLUMN IF NOT EXISTS rotating_until TIMESTAMP;
+ALTER TABLE mcp.api_keys ADD COLUMN IF NOT EXISTS rotated_to UUID REFERENCES mcp.api_keys(id);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_rotating_until
+    ON mcp.api_keys(rotating_until)
+    WHERE rotating_until IS NOT NULL AND is_active = true;
+
+COMMENT ON COLUMN mcp.api_keys.rotating_until IS 'End of the grace period of a rotated key, after which it is deactivated';
+COMMENT ON COLUMN mcp.api_keys.rotated_to IS 'Key that replaced this key when it was rotated';
+
+COMMIT;
@@ -0,0 +1,7 @@
+-- Rollback Refresh Tokens
+BEGIN;
+
+DROP TABLE IF EXISTS mcp.user_token_revocations;
+DROP TABLE IF EXISTS mcp.refresh_tokens;
+
+COMMIT;
@@ -0,0 +1,30 @@
+-- Refresh Tokens
+-- Long-lived opaque tokens exchanged for new access JWTs, stored hashed, and
+-- per-user revocation times that invalidate every token issued before them
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS mcp.refresh_tokens (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    token_hash VARCHAR(255) UNIQUE NOT NULL,
+    user_id UUID NOT NULL,
+    tenant_id UUID NOT NULL,
+    email VARCHAR(255),
+    scopes TEXT[],
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON mcp.refresh_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at ON mcp.refresh_tokens(expires_at);
+
+CREATE TABLE IF NOT EXISTS mcp.user_token_revocations (
+    user_id UUID PRIMARY KEY,
+    revoked_before TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE mcp.refresh_tokens IS 'Refresh tokens exchanged for new access JWTs; only the SHA-256 hash of each token is stored';
+COMMENT ON TABLE mcp.user_token_revocations IS 'Access and refresh tokens issued to a user before revoked_before are rejected';
+
+COMMIT;
@@ -0,0 +1,6 @@
+-- Rollback API Key IP Allowlists
+BEGIN;
+
+ALTER TABLE mcp.api_keys DROP COLUMN IF EXISTS allowed_cidrs;
+
+COMMIT;
@@ -0,0 +1,9 @@
+-- API Key IP Allowlists
+-- A key with allowed CIDRs only validates for callers within one of them
+BEGIN;
+
+ALTER TABLE mcp.api_keys ADD COLUMN IF NOT EXISTS allowed_cidrs TEXT[];
+
+COMMENT ON COLUMN mcp.api_keys.allowed_cidrs IS 'CIDRs the key may be used from; empty means no restriction';
+
+COMMIT;
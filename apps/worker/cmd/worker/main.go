@@ -261,6 +261,34 @@ func runWorker(ctx context.Context) error {
 		return eventProcessor.ProcessEvent(ctx, event)
 	}
 
+	// Batch size adapts between these bounds; unset bounds keep it fixed
+	batchSizing := pkgworker.BatchSizingConfig{}
+	for env, target := range map[string]*int32{
+		"WORKER_MIN_BATCH_SIZE": &batchSizing.MinBatchSize,
+		"WORKER_MAX_BATCH_SIZE": &batchSizing.MaxBatchSize,
+	} {
+		if value := os.Getenv(env); value != "" {
+			if _, err := fmt.Sscanf(value, "%d", target); err != nil {
+				logger.Error("Invalid batch size", map[string]interface{}{
+					"variable": env,
+					"value":    value,
+					"error":    err.Error(),
+				})
+				*target = 0 // Use default
+			}
+		}
+	}
+	if value := os.Getenv("WORKER_BATCH_TARGET_LATENCY"); value != "" {
+		latency, err := time.ParseDuration(value)
+		if err != nil {
+			logger.Error("Invalid batch target latency", map[string]interface{}{
+				"value": value,
+				"error": err.Error(),
+			})
+		}
+		batchSizing.TargetLatency = latency
+	}
+
 	// Create Redis worker
 	redisWorker, err := pkgworker.NewRedisWorker(&pkgworker.Config{
 		QueueClient:    queueClient,
@@ -269,6 +297,7 @@ func runWorker(ctx context.Context) error {
 		Logger:         logger,
 		ConsumerName:   fmt.Sprintf("worker-%s", os.Getenv("HOSTNAME")),
 		IdempotencyTTL: 24 * time.Hour,
+		BatchSizing:    batchSizing,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create worker: %w", err)
@@ -278,6 +307,31 @@ func runWorker(ctx context.Context) error {
 	dlqHandler := worker.NewDLQHandler(db.GetDB(), logger, nil, queueClient)
 	dlqWorker := worker.NewDLQWorker(dlqHandler, logger, 5*time.Minute)
 
+	// Collapse repeated DLQ failures into bounded samples per failure signature
+	if os.Getenv("DLQ_COMPACTION_ENABLED") == "true" {
+		compaction := worker.DefaultDLQCompactionConfig()
+		if value := os.Getenv("DLQ_COMPACTION_SAMPLES"); value != "" {
+			if _, err := fmt.Sscanf(value, "%d", &compaction.SamplesPerSignature); err != nil {
+				logger.Error("Invalid DLQ compaction samples", map[string]interface{}{
+					"value": value,
+					"error": err.Error(),
+				})
+				compaction.SamplesPerSignature = 0 // Use default
+			}
+		}
+		if value := os.Getenv("DLQ_COMPACTION_MAX_AGE"); value != "" {
+			maxAge, err := time.ParseDuration(value)
+			if err != nil {
+				logger.Error("Invalid DLQ compaction max age", map[string]interface{}{
+					"value": value,
+					"error": err.Error(),
+				})
+			}
+			compaction.MaxAge = maxAge
+		}
+		dlqWorker.SetCompactor(worker.NewDLQCompactor(db.GetDB(), logger, nil, compaction))
+	}
+
 	// Create metrics collector and performance monitor
 	tracer := observability.GetTracer()
 	metricsClient := observability.NewMetricsClient()
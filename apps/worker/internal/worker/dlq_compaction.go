@@ -0,0 +1,212 @@
+package worker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// DLQCompactionConfig configures DLQ compaction
+type DLQCompactionConfig struct {
+	// SamplesPerSignature is how many entries are kept per failure signature
+	SamplesPerSignature int
+	// MaxAge removes entries older than this regardless of signature
+	MaxAge time.Duration
+	// BatchSize caps the entries compacted per run
+	BatchSize int
+}
+
+// DefaultDLQCompactionConfig returns the default DLQ compaction configuration
+func DefaultDLQCompactionConfig() DLQCompactionConfig {
+	return DLQCompactionConfig{
+		SamplesPerSignature: 5,
+		MaxAge:              7 * 24 * time.Hour,
+		BatchSize:           1000,
+	}
+}
+
+// DLQCompactionResult summarizes a compaction run
+type DLQCompactionResult struct {
+	AgedOut    int64 // Entries removed for exceeding MaxAge
+	Signatures int   // Distinct failure signatures among the compacted entries
+	Compacted  int   // Duplicate entries folded into their signature's sample
+}
+
+// dlqCompactionEntry is the part of a DLQ entry compaction needs
+type dlqCompactionEntry struct {
+	ID           string    `db:"id"`
+	EventType    string    `db:"event_type"`
+	ErrorMessage string    `db:"error_message"`
+	Occurrences  int       `db:"occurrences"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+// DLQCompactor collapses repeated DLQ failures so the queue shows failure
+// patterns rather than every occurrence. Entries are grouped by failure
+// signature, only the newest samples of each group are kept, and the newest
+// sample carries the number of failures the group stands for. Entries still
+// eligible for automatic retry are left alone.
+type DLQCompactor struct {
+	db      *sqlx.DB
+	logger  observability.Logger
+	metrics observability.MetricsClient
+	config  DLQCompactionConfig
+}
+
+// NewDLQCompactor creates a new DLQ compactor. Unset config values use the defaults.
+func NewDLQCompactor(db *sqlx.DB, logger observability.Logger, metrics observability.MetricsClient, config DLQCompactionConfig) *DLQCompactor {
+	defaults := DefaultDLQCompactionConfig()
+	if config.SamplesPerSignature <= 0 {
+		config.SamplesPerSignature = defaults.SamplesPerSignature
+	}
+	if config.MaxAge <= 0 {
+		config.MaxAge = defaults.MaxAge
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	return &DLQCompactor{
+		db:      db,
+		logger:  logger,
+		metrics: metrics,
+		config:  config,
+	}
+}
+
+// Compact ages out old entries and collapses repeated failures
+func (c *DLQCompactor) Compact(ctx context.Context) (*DLQCompactionResult, error) {
+	result := &DLQCompactionResult{}
+
+	ageOutQuery := `
+		DELETE FROM mcp.webhook_dlq
+		WHERE created_at < $1
+		  AND status <> 'retrying'
+	`
+	res, err := c.db.ExecContext(ctx, ageOutQuery, time.Now().Add(-c.config.MaxAge))
+	if err != nil {
+		return nil, fmt.Errorf("failed to age out DLQ entries: %w", err)
+	}
+	if result.AgedOut, err = res.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to count aged out DLQ entries: %w", err)
+	}
+
+	// Entries the DLQ worker won't retry any more
+	query := `
+		SELECT id, event_type, error_message, occurrences, created_at
+		FROM mcp.webhook_dlq
+		WHERE status = 'failed'
+		   OR (status = 'pending' AND retry_count >= 3)
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+	var entries []dlqCompactionEntry
+	if err := c.db.SelectContext(ctx, &entries, query, c.config.BatchSize); err != nil {
+		return nil, fmt.Errorf("failed to fetch DLQ entries for compaction: %w", err)
+	}
+
+	groups := make(map[string][]dlqCompactionEntry)
+	for _, entry := range entries {
+		signature := DLQFailureSignature(entry.EventType, entry.ErrorMessage)
+		groups[signature] = append(groups[signature], entry)
+	}
+	result.Signatures = len(groups)
+
+	signatures := make([]string, 0, len(groups))
+	for signature := range groups {
+		signatures = append(signatures, signature)
+	}
+	sort.Strings(signatures)
+
+	for _, signature := range signatures {
+		group := groups[signature]
+		if len(group) <= c.config.SamplesPerSignature {
+			continue
+		}
+		removed, err := c.compactGroup(ctx, signature, group)
+		if err != nil {
+			return nil, err
+		}
+		result.Compacted += removed
+	}
+
+	if c.metrics != nil {
+		c.metrics.IncrementCounterWithLabels("webhook_dlq_compacted_total", float64(result.Compacted), nil)
+		c.metrics.IncrementCounterWithLabels("webhook_dlq_aged_out_total", float64(result.AgedOut), nil)
+	}
+
+	c.logger.Info("DLQ compaction completed", map[string]interface{}{
+		"aged_out":   result.AgedOut,
+		"signatures": result.Signatures,
+		"compacted":  result.Compacted,
+	})
+
+	return result, nil
+}
+
+// compactGroup keeps the newest samples of a signature's entries, newest first,
+// and folds the occurrences of the rest into the newest sample
+func (c *DLQCompactor) compactGroup(ctx context.Context, signature string, group []dlqCompactionEntry) (int, error) {
+	samples := group[:c.config.SamplesPerSignature]
+	removed := group[c.config.SamplesPerSignature:]
+
+	removedIDs := make([]string, len(removed))
+	folded := 0
+	for i, entry := range removed {
+		removedIDs[i] = entry.ID
+		folded += entry.Occurrences
+	}
+
+	tx, err := c.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin DLQ compaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM mcp.webhook_dlq WHERE id = ANY($1)`, pq.Array(removedIDs)); err != nil {
+		return 0, fmt.Errorf("failed to remove compacted DLQ entries: %w", err)
+	}
+
+	updateQuery := `
+		UPDATE mcp.webhook_dlq
+		SET occurrences = occurrences + $2,
+		    failure_signature = $3
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, updateQuery, samples[0].ID, folded, signature); err != nil {
+		return 0, fmt.Errorf("failed to update DLQ sample: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit DLQ compaction: %w", err)
+	}
+	return len(removed), nil
+}
+
+var (
+	dlqUUIDPattern   = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	dlqHexPattern    = regexp.MustCompile(`\b[0-9a-f]{8,}\b`)
+	dlqNumberPattern = regexp.MustCompile(`\d+`)
+)
+
+// DLQFailureSignature identifies a kind of failure: the event type and the
+// error message with IDs, hashes and numbers masked, so occurrences of the same
+// failure for different events share a signature
+func DLQFailureSignature(eventType, errorMessage string) string {
+	normalized := strings.ToLower(errorMessage)
+	normalized = dlqUUIDPattern.ReplaceAllString(normalized, "<id>")
+	normalized = dlqHexPattern.ReplaceAllString(normalized, "<hex>")
+	normalized = dlqNumberPattern.ReplaceAllString(normalized, "<n>")
+	normalized = strings.Join(strings.Fields(normalized), " ")
+
+	sum := sha256.Sum256([]byte(eventType + "\n" + normalized))
+	return hex.EncodeToString(sum[:])
+}
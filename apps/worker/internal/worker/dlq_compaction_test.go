@@ -0,0 +1,113 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDLQFailureSignature(t *testing.T) {
+	timeout := DLQFailureSignature("push", "delivery to tool 3f2b9c1e-8a4d-4b6e-9c0f-1a2b3c4d5e6f timed out after 30s")
+	assert.Equal(t, timeout, DLQFailureSignature("push", "Delivery to tool 9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b timed out after 45s"))
+	assert.NotEqual(t, timeout, DLQFailureSignature("pull_request", "delivery to tool 3f2b9c1e-8a4d-4b6e-9c0f-1a2b3c4d5e6f timed out after 30s"))
+	assert.NotEqual(t, timeout, DLQFailureSignature("push", "invalid payload: missing repository"))
+
+	assert.Equal(t,
+		DLQFailureSignature("push", "commit deadbeef1234 not found"),
+		DLQFailureSignature("push", "commit 0123abcd5678 not found"),
+	)
+}
+
+func TestDLQCompactor_Compact(t *testing.T) {
+	ctx := context.Background()
+
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+	db := sqlx.NewDb(mockDB, "postgres")
+
+	compactor := NewDLQCompactor(db, observability.NewNoopLogger(), nil, DLQCompactionConfig{SamplesPerSignature: 3})
+
+	// 12 timeouts, newest first, one of them already standing for 4 failures
+	// from an earlier compaction, plus 2 entries of a different failure
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "event_type", "error_message", "occurrences", "created_at"})
+	var removedIDs []string
+	for i := 0; i < 12; i++ {
+		occurrences := 1
+		if i == 7 {
+			occurrences = 4
+		}
+		id := fmt.Sprintf("timeout-%d", i)
+		rows.AddRow(id, "push", fmt.Sprintf("event evt-%d timed out after %ds", i, 30+i), occurrences, now.Add(-time.Duration(i)*time.Minute))
+		if i >= 3 {
+			removedIDs = append(removedIDs, id)
+		}
+	}
+	rows.AddRow("invalid-0", "push", "invalid payload", 1, now)
+	rows.AddRow("invalid-1", "push", "invalid payload", 1, now.Add(-time.Minute))
+
+	sqlMock.ExpectExec(`DELETE FROM mcp\.webhook_dlq\s+WHERE created_at < \$1`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	sqlMock.ExpectQuery(`SELECT id, event_type, error_message, occurrences, created_at\s+FROM mcp\.webhook_dlq`).
+		WithArgs(1000).
+		WillReturnRows(rows)
+
+	// The 9 oldest timeouts fold into the newest sample: 8 single failures plus
+	// the earlier sample standing for 4. The 3 kept samples then still account
+	// for all 15 failures, 13 on the newest and 1 on each of the others.
+	removed, err := pq.Array(removedIDs).Value()
+	require.NoError(t, err)
+	sqlMock.ExpectBegin()
+	sqlMock.ExpectExec(`DELETE FROM mcp\.webhook_dlq WHERE id = ANY\(\$1\)`).
+		WithArgs(removed).
+		WillReturnResult(sqlmock.NewResult(0, 9))
+	sqlMock.ExpectExec(`UPDATE mcp\.webhook_dlq\s+SET occurrences = occurrences \+ \$2`).
+		WithArgs("timeout-0", 12, DLQFailureSignature("push", "event evt-0 timed out after 30s")).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	sqlMock.ExpectCommit()
+
+	result, err := compactor.Compact(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.AgedOut)
+	assert.Equal(t, 2, result.Signatures)
+	assert.Equal(t, 9, result.Compacted)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
+
+func TestDLQCompactor_CompactWithinBound(t *testing.T) {
+	mockDB, sqlMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+	db := sqlx.NewDb(mockDB, "postgres")
+
+	compactor := NewDLQCompactor(db, observability.NewNoopLogger(), nil, DLQCompactionConfig{SamplesPerSignature: 3})
+
+	rows := sqlmock.NewRows([]string{"id", "event_type", "error_message", "occurrences", "created_at"})
+	for i := 0; i < 3; i++ {
+		rows.AddRow(fmt.Sprintf("timeout-%d", i), "push", fmt.Sprintf("timed out after %ds", 30+i), 1, time.Now())
+	}
+
+	sqlMock.ExpectExec(`DELETE FROM mcp\.webhook_dlq`).
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	sqlMock.ExpectQuery(`SELECT id, event_type, error_message, occurrences, created_at`).
+		WithArgs(1000).
+		WillReturnRows(rows)
+
+	// Nothing to fold, so no transaction runs
+	result, err := compactor.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Signatures)
+	assert.Equal(t, 0, result.Compacted)
+	assert.NoError(t, sqlMock.ExpectationsWereMet())
+}
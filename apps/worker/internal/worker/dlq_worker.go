@@ -10,6 +10,7 @@ import (
 // DLQWorker periodically processes the dead letter queue
 type DLQWorker struct {
 	dlqHandler DLQHandler
+	compactor  *DLQCompactor
 	logger     observability.Logger
 	interval   time.Duration
 }
@@ -26,6 +27,11 @@ func NewDLQWorker(dlqHandler DLQHandler, logger observability.Logger, interval t
 	}
 }
 
+// SetCompactor enables compaction of the DLQ after each processing run
+func (w *DLQWorker) SetCompactor(compactor *DLQCompactor) {
+	w.compactor = compactor
+}
+
 // Run starts the DLQ worker
 func (w *DLQWorker) Run(ctx context.Context) error {
 	w.logger.Info("Starting DLQ worker", map[string]interface{}{
@@ -79,5 +85,14 @@ func (w *DLQWorker) processDLQ(ctx context.Context) error {
 		"duration_ms": duration.Milliseconds(),
 	})
 
+	if w.compactor != nil {
+		if _, err := w.compactor.Compact(ctx); err != nil {
+			w.logger.Error("DLQ compaction failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return err
+		}
+	}
+
 	return nil
 }
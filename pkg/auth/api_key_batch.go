@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyValidation is the outcome of validating one API key
+type apiKeyValidation struct {
+	user *User
+	err  error
+}
+
+// ValidateAPIKeys validates many API keys at once, for gateways that fan a
+// request out across tenants. Cached keys are served from the cache and the
+// rest are looked up with a single database query. Each key is then rate
+// limited and audited as ValidateAPIKey does.
+//
+// The users of valid keys and the errors of the others are returned keyed by
+// API key. errors.Is tells ErrInvalidAPIKeyFormat, ErrAPIKeyNotFound and
+// ErrAPIKeyExpired apart.
+func (s *Service) ValidateAPIKeys(ctx context.Context, keys []string) (map[string]*User, map[string]error) {
+	results := make(map[string]apiKeyValidation, len(keys))
+	var order, pending []string
+	for _, apiKey := range keys {
+		if _, seen := results[apiKey]; seen {
+			continue
+		}
+		order = append(order, apiKey)
+
+		// Keys the database isn't consulted for are validated one by one
+		if s.db == nil || apiKey == "" || !isValidAPIKeyFormat(apiKey) || s.hasAPIKeyInMemory(apiKey) {
+			user, err := s.validateAPIKey(ctx, apiKey)
+			results[apiKey] = apiKeyValidation{user: user, err: err}
+			continue
+		}
+
+		if user, ok := s.cachedAPIKeyUser(ctx, apiKey); ok {
+			results[apiKey] = apiKeyValidation{user: user}
+			continue
+		}
+		results[apiKey] = apiKeyValidation{}
+		pending = append(pending, apiKey)
+	}
+
+	for apiKey, result := range s.validateAPIKeysFromDB(ctx, pending) {
+		results[apiKey] = result
+	}
+
+	users := make(map[string]*User, len(order))
+	errs := make(map[string]error)
+	for _, apiKey := range order {
+		result := results[apiKey]
+		user, err := s.completeAPIKeyValidation(ctx, apiKey, result.user, result.err)
+		if err != nil {
+			errs[apiKey] = err
+			continue
+		}
+		users[apiKey] = user
+	}
+	return users, errs
+}
+
+// hasAPIKeyInMemory reports whether an API key is held in memory
+func (s *Service) hasAPIKeyInMemory(apiKey string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, exists := s.apiKeys[apiKey]
+	return exists
+}
+
+// validateAPIKeysFromDB validates API keys stored in the database with one
+// query. With bcrypt hashing, finding each key's hash still takes a query per
+// key prefix.
+func (s *Service) validateAPIKeysFromDB(ctx context.Context, apiKeys []string) map[string]apiKeyValidation {
+	results := make(map[string]apiKeyValidation, len(apiKeys))
+	if len(apiKeys) == 0 {
+		return results
+	}
+
+	// Find the hash each API key is stored under
+	keysByHash := make(map[string]string, len(apiKeys))
+	hashes := make([]string, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		keyHash, err := s.lookupKeyHash(ctx, apiKey)
+		if err != nil {
+			results[apiKey] = apiKeyValidation{err: err}
+			continue
+		}
+		keysByHash[keyHash] = apiKey
+		hashes = append(hashes, keyHash)
+	}
+	if len(hashes) == 0 {
+		return results
+	}
+
+	var rows []apiKeyRow
+	query := apiKeyValidationQuery + `WHERE k.key_hash = ANY($1) AND k.is_active = true`
+	if err := s.db.SelectContext(ctx, &rows, query, pq.Array(hashes)); err != nil {
+		s.logError("Failed to query API keys from database", map[string]interface{}{
+			"error": err.Error(),
+			"keys":  len(hashes),
+		})
+		err = fmt.Errorf("database error: %w", err)
+		for _, apiKey := range keysByHash {
+			results[apiKey] = apiKeyValidation{err: err}
+		}
+		return results
+	}
+
+	for i := range rows {
+		apiKey, ok := keysByHash[rows[i].KeyHash]
+		if !ok {
+			continue
+		}
+		delete(keysByHash, rows[i].KeyHash)
+		user, err := s.apiKeyUserFromRow(ctx, apiKey, rows[i].KeyHash, &rows[i])
+		results[apiKey] = apiKeyValidation{user: user, err: err}
+	}
+
+	// Keys without a row aren't stored or aren't active
+	for _, apiKey := range keysByHash {
+		s.logInfo("API key not found in database", map[string]interface{}{
+			"key_prefix": getKeyPrefix(apiKey),
+		})
+		results[apiKey] = apiKeyValidation{err: ErrAPIKeyNotFound}
+	}
+	return results
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestValidateAPIKeys(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	redisCache, mr := newMiniRedisCache(t)
+	config := DefaultConfig()
+	config.CacheTTL = 5 * time.Minute
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), redisCache, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	validKey := "agt_batchvalid0123"
+	rotatingKey := "agt_batchrotating0123"
+	expiredKey := "agt_batchexpired0123"
+	unknownKey := "agt_batchunknown0123"
+
+	columns := []string{
+		"key_hash", "tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+		"expires_at", "rate_limit", "allowed_services", "rotating_until", "rotated_to_prefix",
+	}
+	rotatingUntil := time.Now().Add(time.Minute)
+	rows := sqlmock.NewRows(columns).
+		AddRow(service.hashAPIKey(validKey), serviceAccountTenant, nil, "gateway", "agent", "{read}", true, nil, nil, "{}", nil, nil).
+		AddRow(service.hashAPIKey(rotatingKey), serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, nil, "{}", rotatingUntil, "agt_newk").
+		AddRow(service.hashAPIKey(expiredKey), serviceAccountTenant, nil, "old", "agent", "{read}", true, time.Now().Add(-time.Hour), nil, "{}", nil, nil)
+
+	t.Run("looks up uncached keys with one query", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE k.key_hash = ANY\(\$1\) AND k.is_active = true`).
+			WillReturnRows(rows)
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		users, errs := service.ValidateAPIKeys(ctx, []string{validKey, rotatingKey, expiredKey, unknownKey, "bad key!", validKey})
+
+		require.Len(t, users, 2)
+		assert.Equal(t, "gateway", users[validKey].Metadata["key_name"])
+		assert.Equal(t, "rotating", users[rotatingKey].Metadata["key_status"])
+
+		require.Len(t, errs, 3)
+		assert.ErrorIs(t, errs[expiredKey], ErrAPIKeyExpired)
+		assert.ErrorIs(t, errs[unknownKey], ErrAPIKeyNotFound)
+		assert.ErrorIs(t, errs["bad key!"], ErrInvalidAPIKeyFormat)
+		for _, err := range errs {
+			assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		}
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+
+		// Validations are cached no longer than a rotating key's grace period
+		assert.Equal(t, config.CacheTTL, mr.TTL("auth:apikey:"+validKey))
+		assert.LessOrEqual(t, mr.TTL("auth:apikey:"+rotatingKey), time.Minute)
+	})
+
+	t.Run("serves cached keys without querying", func(t *testing.T) {
+		users, errs := service.ValidateAPIKeys(ctx, []string{validKey, rotatingKey})
+		assert.Empty(t, errs)
+		assert.Len(t, users, 2)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database errors fail the uncached keys", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE k.key_hash = ANY\(\$1\)`).WillReturnError(errors.New("connection refused"))
+
+		users, errs := service.ValidateAPIKeys(ctx, []string{validKey, unknownKey})
+		assert.Contains(t, users, validKey)
+		require.Error(t, errs[unknownKey])
+		assert.NotErrorIs(t, errs[unknownKey], ErrInvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestInvalidAPIKeyReasons(t *testing.T) {
+	for _, err := range []error{ErrInvalidAPIKeyFormat, ErrAPIKeyNotFound, ErrAPIKeyExpired} {
+		assert.ErrorIs(t, fmt.Errorf("validate: %w", err), ErrInvalidAPIKey)
+		assert.Equal(t, ErrInvalidAPIKey.Error(), err.Error())
+	}
+	assert.NotErrorIs(t, ErrAPIKeyExpired, ErrAPIKeyNotFound)
+}
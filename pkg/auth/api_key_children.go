@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrExceedsParentKey is returned when a child key asks for more than its
+// parent key was granted
+var ErrExceedsParentKey = errors.New("child key exceeds its parent key's grants")
+
+// CreateChildKey creates a key delegating part of a parent key's grants, such
+// as a key for a single service or a short-lived task. The child gets the
+// requested scopes and services, or the parent's when none are given, and
+// expires with the parent at the latest. It belongs to the parent's tenant and
+// user, and keeps its type, allowlist and rate limit. RevokeAPIKey on the
+// parent revokes the child too.
+//
+// Scopes must be covered by the parent's under the tenant's scope hierarchy
+// and services must be among the parent's allowed services, when it has any.
+// Otherwise ErrExceedsParentKey is returned.
+func (s *Service) CreateChildKey(ctx context.Context, parentKey string, scopes []string, allowedServices []string, expiresAt *time.Time) (*APIKey, error) {
+	if !isValidAPIKeyFormat(parentKey) {
+		return nil, ErrInvalidAPIKeyFormat
+	}
+
+	parent, _, err := s.loadActiveAPIKey(ctx, parentKey)
+	if err != nil {
+		return nil, err
+	}
+	// A child would outlive a parent deactivated by the rotation sweep
+	if parent.RotatingUntil != nil {
+		return nil, ErrAPIKeyRotating
+	}
+
+	req, err := s.childKeyRequest(parent, scopes, allowedServices, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	child, err := s.CreateAPIKeyWithType(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create child API key: %w", err)
+	}
+
+	s.logInfo("Child API key created", map[string]interface{}{
+		"key_prefix":        child.KeyPrefix,
+		"parent_key_prefix": getKeyPrefix(parentKey),
+		"tenant_id":         parent.TenantID,
+	})
+
+	return child, nil
+}
+
+// childKeyRequest describes a child of a key, checking the requested grants
+// are within the parent's
+func (s *Service) childKeyRequest(parent *APIKey, scopes, allowedServices []string, expiresAt *time.Time) (CreateAPIKeyRequest, error) {
+	if len(scopes) == 0 {
+		scopes = parent.Scopes
+	}
+	hierarchy := s.scopeHierarchy(parent.TenantID)
+	for _, scope := range scopes {
+		if !scopeWithin(hierarchy, parent.Scopes, scope) {
+			return CreateAPIKeyRequest{}, fmt.Errorf("%w: scope %s", ErrExceedsParentKey, scope)
+		}
+	}
+
+	if len(allowedServices) == 0 {
+		allowedServices = parent.AllowedServices
+	}
+	if len(parent.AllowedServices) > 0 {
+		for _, service := range allowedServices {
+			if !slices.Contains(parent.AllowedServices, service) {
+				return CreateAPIKeyRequest{}, fmt.Errorf("%w: service %s", ErrExceedsParentKey, service)
+			}
+		}
+	}
+
+	if parent.ExpiresAt != nil {
+		if expiresAt == nil {
+			expiresAt = parent.ExpiresAt
+		} else if expiresAt.After(*parent.ExpiresAt) {
+			return CreateAPIKeyRequest{}, fmt.Errorf("%w: expires after %s", ErrExceedsParentKey, parent.ExpiresAt.Format(time.RFC3339))
+		}
+	}
+
+	// Everything else is carried over from the parent
+	req := copyKeyRequest(parent)
+	req.Name = parent.Name + " (child)"
+	req.ParentKeyID = &parent.ID
+	req.Scopes = scopes
+	req.AllowedServices = allowedServices
+	req.ExpiresAt = expiresAt
+	return req, nil
+}
+
+// revokeInMemory removes an in-memory key and its descendants, returning the
+// removed keys. Callers hold s.mu.
+func (s *Service) revokeInMemory(apiKey string) []string {
+	key, ok := s.apiKeys[apiKey]
+	if !ok {
+		return nil
+	}
+	delete(s.apiKeys, apiKey)
+	revoked := []string{apiKey}
+	if key.ID == "" {
+		return revoked
+	}
+
+	for childKey, child := range s.apiKeys {
+		if child.ParentKeyID == nil || *child.ParentKeyID != key.ID {
+			continue
+		}
+		revoked = append(revoked, s.revokeInMemory(childKey)...)
+	}
+	return revoked
+}
+
+// revokeAPIKeyInDB deactivates a stored key and its descendants, returning how
+// many were deactivated
+func (s *Service) revokeAPIKeyInDB(ctx context.Context, keyHash string) (int64, error) {
+	query := `
+		WITH RECURSIVE revoked AS (
+			SELECT id FROM mcp.api_keys WHERE key_hash = $1
+			UNION
+			SELECT c.id
+			FROM mcp.api_keys c
+			JOIN revoked p ON c.parent_key_id = p.id
+		)
+		UPDATE mcp.api_keys
+		SET is_active = false, updated_at = $2
+		WHERE id IN (SELECT id FROM revoked) AND is_active = true
+	`
+	result, err := s.db.ExecContext(ctx, query, keyHash, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
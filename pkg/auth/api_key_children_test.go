@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestCreateChildKey(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	parentExpiry := time.Now().Add(24 * time.Hour)
+	parent, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:            "gateway",
+		TenantID:        serviceAccountTenant,
+		KeyType:         KeyTypeGateway,
+		Scopes:          []string{"tools:*", "contexts:write"},
+		AllowedServices: []string{"github", "jira"},
+		AllowedCIDRs:    []string{"10.0.0.0/8"},
+		ExpiresAt:       &parentExpiry,
+	})
+	require.NoError(t, err)
+
+	t.Run("child gets a subset of the parent's grants", func(t *testing.T) {
+		child, err := service.CreateChildKey(ctx, parent.Key, []string{"tools:github:read", "contexts:read"}, []string{"github"}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, parent.ID, *child.ParentKeyID)
+		assert.Equal(t, parent.TenantID, child.TenantID)
+		assert.Equal(t, KeyTypeGateway, child.KeyType)
+		assert.Equal(t, []string{"tools:github:read", "contexts:read"}, child.Scopes)
+		assert.Equal(t, []string{"github"}, child.AllowedServices)
+		assert.Equal(t, []string{"10.0.0.0/8"}, child.AllowedCIDRs)
+		assert.Equal(t, parentExpiry, *child.ExpiresAt)
+
+		user, err := service.ValidateAPIKey(context.WithValue(ctx, ContextKeyIPAddress, "10.1.2.3"), child.Key)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tools:github:read", "contexts:read"}, user.Scopes)
+	})
+
+	t.Run("child without grants inherits the parent's", func(t *testing.T) {
+		child, err := service.CreateChildKey(ctx, parent.Key, nil, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, parent.Scopes, child.Scopes)
+		assert.Equal(t, parent.AllowedServices, child.AllowedServices)
+	})
+
+	t.Run("grants beyond the parent's are rejected", func(t *testing.T) {
+		later := parentExpiry.Add(time.Hour)
+		tests := []struct {
+			name      string
+			scopes    []string
+			services  []string
+			expiresAt *time.Time
+		}{
+			{name: "scope", scopes: []string{"tools:read", "admin"}},
+			{name: "implied scope", scopes: []string{"contexts:admin"}},
+			{name: "service", services: []string{"github", "slack"}},
+			{name: "expiry", expiresAt: &later},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := service.CreateChildKey(ctx, parent.Key, tt.scopes, tt.services, tt.expiresAt)
+				assert.ErrorIs(t, err, ErrExceedsParentKey)
+			})
+		}
+	})
+
+	t.Run("parent must be valid", func(t *testing.T) {
+		_, err := service.CreateChildKey(ctx, "gw_unknownparent0123", nil, nil, nil)
+		assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+		_, err = service.CreateChildKey(ctx, "not a key", nil, nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidAPIKeyFormat)
+	})
+}
+
+func TestRevokeAPIKeyCascades(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	parent, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     "gateway",
+		TenantID: serviceAccountTenant,
+		KeyType:  KeyTypeGateway,
+		Scopes:   []string{"tools:*"},
+	})
+	require.NoError(t, err)
+	child, err := service.CreateChildKey(ctx, parent.Key, []string{"tools:read"}, nil, nil)
+	require.NoError(t, err)
+	grandchild, err := service.CreateChildKey(ctx, child.Key, nil, nil, nil)
+	require.NoError(t, err)
+	sibling, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     "other",
+		TenantID: serviceAccountTenant,
+		KeyType:  KeyTypeGateway,
+	})
+	require.NoError(t, err)
+
+	// The parent's replacement isn't its child
+	replacement, err := service.RotateAPIKey(ctx, parent.Key, time.Hour)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeAPIKey(ctx, parent.Key))
+
+	for _, key := range []string{parent.Key, child.Key, grandchild.Key} {
+		_, err := service.ValidateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	}
+	for _, key := range []string{replacement.Key, sibling.Key} {
+		_, err := service.ValidateAPIKey(ctx, key)
+		assert.NoError(t, err)
+	}
+}
+
+func TestRevokeAPIKeyCascadesInDB(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+
+	key := "gw_storedparent0123"
+	mock.ExpectExec(`WITH RECURSIVE revoked AS .+JOIN revoked p ON c.parent_key_id = p.id.+SET is_active = false`).
+		WithArgs(service.hashAPIKey(key), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, service.RevokeAPIKey(context.Background(), key))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChildKeyValidationsArentCached(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("in memory", func(t *testing.T) {
+		service := NewService(DefaultConfig(), nil, NewTestCache(), observability.NewNoopLogger())
+		parent, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "gateway",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeGateway,
+		})
+		require.NoError(t, err)
+		child, err := service.CreateChildKey(ctx, parent.Key, nil, nil, nil)
+		require.NoError(t, err)
+
+		for _, key := range []string{parent.Key, child.Key} {
+			_, err := service.ValidateAPIKey(ctx, key)
+			require.NoError(t, err)
+		}
+		_, cached := service.cachedAPIKeyUser(ctx, parent.Key)
+		assert.True(t, cached)
+		_, cached = service.cachedAPIKeyUser(ctx, child.Key)
+		assert.False(t, cached)
+	})
+
+	t.Run("stored child revoked with its parent", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = mockDB.Close() }()
+		service := NewService(DefaultConfig(), sqlx.NewDb(mockDB, "sqlmock"), NewTestCache(), observability.NewNoopLogger())
+
+		const child = "gw_storedchild01234"
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WithArgs(service.hashAPIKey(child)).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+				"expires_at", "rate_limit", "allowed_services", "parent_key_id", "rotating_until", "rotated_to_prefix",
+			}).AddRow(serviceAccountTenant, nil, "gateway (child)", "gateway", "{read}", true, nil, nil, "{}", "parent-id", nil, nil))
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err = service.ValidateAPIKey(ctx, child)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+
+		// Once an ancestor is revoked, the child is looked up again and not found
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WithArgs(service.hashAPIKey(child)).
+			WillReturnRows(sqlmock.NewRows([]string{"tenant_id"}))
+
+		_, err = service.ValidateAPIKey(ctx, child)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
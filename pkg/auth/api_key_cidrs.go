@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// ErrIPNotAllowed is returned for a valid API key used from an address
+// outside its allowed CIDRs
+var ErrIPNotAllowed = errors.New("API key not allowed from this IP address")
+
+// parseAllowedCIDRs parses an API key's allowlist. Bare addresses are
+// allowed as single-address ranges.
+func parseAllowedCIDRs(cidrs []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowed CIDR %q: %w", cidr, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %q: %w", cidr, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// allowedCIDRs returns the allowlist a validated key's user carries. Users
+// read back from the validation cache carry it as []interface{}.
+func allowedCIDRs(user *User) []string {
+	switch cidrs := user.Metadata["allowed_cidrs"].(type) {
+	case []string:
+		return cidrs
+	case []interface{}:
+		result := make([]string, 0, len(cidrs))
+		for _, cidr := range cidrs {
+			if s, ok := cidr.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// callerAddr is the caller's address from the request context, which may
+// carry a port or be bracketed
+func callerAddr(ctx context.Context) (netip.Addr, bool) {
+	ip, _ := ctx.Value(ContextKeyIPAddress).(string)
+	if ip == "" {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(strings.Trim(ip, "[]"))
+	if err != nil {
+		addrPort, err := netip.ParseAddrPort(ip)
+		if err != nil {
+			return netip.Addr{}, false
+		}
+		addr = addrPort.Addr()
+	}
+	// IPv4 callers on dual-stack listeners show up as ::ffff:a.b.c.d
+	return addr.WithZone("").Unmap(), true
+}
+
+// enforceAPIKeyAllowlist rejects a validated key used from outside its
+// allowed CIDRs. Keys without an allowlist aren't restricted; keys with one
+// are rejected when the caller's address is unknown.
+func (s *Service) enforceAPIKeyAllowlist(ctx context.Context, user *User) error {
+	cidrs := allowedCIDRs(user)
+	if len(cidrs) == 0 {
+		return nil
+	}
+
+	prefixes, err := s.allowlist(cidrs)
+	if err != nil {
+		// A stored allowlist that doesn't parse allows nothing
+		s.logError("Invalid API key allowlist", map[string]interface{}{
+			"key_prefix": user.Metadata["key_prefix"],
+			"error":      err.Error(),
+		})
+		return ErrIPNotAllowed
+	}
+
+	addr, ok := callerAddr(ctx)
+	if ok {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+	}
+
+	s.logInfo("API key used from outside its allowlist", map[string]interface{}{
+		"key_prefix": user.Metadata["key_prefix"],
+		"ip":         ctx.Value(ContextKeyIPAddress),
+	})
+	return ErrIPNotAllowed
+}
+
+// allowlist parses an allowlist once. Parsed allowlists are cached by their
+// contents, so a key's allowlist is reparsed only when it changes.
+func (s *Service) allowlist(cidrs []string) ([]netip.Prefix, error) {
+	cacheKey := strings.Join(cidrs, ",")
+	if cached, ok := s.allowlists.Load(cacheKey); ok {
+		return cached.([]netip.Prefix), nil
+	}
+
+	prefixes, err := parseAllowedCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	s.allowlists.Store(cacheKey, prefixes)
+	return prefixes, nil
+}
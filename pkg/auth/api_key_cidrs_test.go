@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestAPIKeyAllowedCIDRs(t *testing.T) {
+	config := DefaultConfig()
+	service := NewService(config, nil, NewTestCache(), observability.NewNoopLogger())
+	ctx := context.Background()
+
+	createKey := func(cidrs ...string) string {
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:         "egress-bound",
+			TenantID:     serviceAccountTenant,
+			KeyType:      KeyTypeAgent,
+			AllowedCIDRs: cidrs,
+		})
+		require.NoError(t, err)
+		return key.Key
+	}
+	from := func(ip string) context.Context {
+		return context.WithValue(ctx, ContextKeyIPAddress, ip)
+	}
+
+	t.Run("mixed IPv4 and IPv6 ranges", func(t *testing.T) {
+		key := createKey("10.0.0.0/8", "2001:db8::/32", "192.0.2.7")
+
+		allowed := []string{
+			"10.1.2.3",
+			"10.1.2.3:54321",
+			"::ffff:10.1.2.3",
+			"2001:db8:abcd::1",
+			"[2001:db8::1]:443",
+			"192.0.2.7",
+		}
+		for _, ip := range allowed {
+			_, err := service.ValidateAPIKey(from(ip), key)
+			assert.NoError(t, err, ip)
+		}
+
+		denied := []string{"11.0.0.1", "192.0.2.8", "2001:db9::1", "fe80::1%eth0", "::1", "not-an-ip"}
+		for _, ip := range denied {
+			_, err := service.ValidateAPIKey(from(ip), key)
+			assert.ErrorIs(t, err, ErrIPNotAllowed, ip)
+		}
+	})
+
+	t.Run("IPv6-only allowlist", func(t *testing.T) {
+		key := createKey("2001:db8:1::/48")
+
+		_, err := service.ValidateAPIKey(from("2001:db8:1:ffff::1"), key)
+		assert.NoError(t, err)
+		_, err = service.ValidateAPIKey(from("2001:db8:2::1"), key)
+		assert.ErrorIs(t, err, ErrIPNotAllowed)
+		_, err = service.ValidateAPIKey(from("10.0.0.1"), key)
+		assert.ErrorIs(t, err, ErrIPNotAllowed)
+	})
+
+	t.Run("cached validations are still restricted", func(t *testing.T) {
+		key := createKey("10.0.0.0/8")
+		_, err := service.ValidateAPIKey(from("10.0.0.1"), key)
+		require.NoError(t, err)
+
+		// Only the cache can validate the key now
+		service.mu.Lock()
+		delete(service.apiKeys, key)
+		service.mu.Unlock()
+
+		_, err = service.ValidateAPIKey(from("10.0.0.2"), key)
+		assert.NoError(t, err)
+		_, err = service.ValidateAPIKey(from("172.16.0.1"), key)
+		assert.ErrorIs(t, err, ErrIPNotAllowed)
+	})
+
+	t.Run("an empty allowlist allows any address", func(t *testing.T) {
+		key := createKey()
+		_, err := service.ValidateAPIKey(from("203.0.113.9"), key)
+		assert.NoError(t, err)
+		_, err = service.ValidateAPIKey(from("2001:db8::9"), key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("keys with an allowlist need a caller address", func(t *testing.T) {
+		key := createKey("10.0.0.0/8")
+		_, err := service.ValidateAPIKey(ctx, key)
+		assert.ErrorIs(t, err, ErrIPNotAllowed)
+		_, err = service.ValidateAPIKey(from(""), key)
+		assert.ErrorIs(t, err, ErrIPNotAllowed)
+
+		// Keys without one don't
+		_, err = service.ValidateAPIKey(ctx, createKey())
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid CIDRs are rejected at creation", func(t *testing.T) {
+		_, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:         "bad",
+			TenantID:     serviceAccountTenant,
+			KeyType:      KeyTypeAgent,
+			AllowedCIDRs: []string{"10.0.0.0/33"},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("allowlists are parsed once", func(t *testing.T) {
+		key := createKey("198.51.100.0/24")
+		for i := 0; i < 3; i++ {
+			_, err := service.ValidateAPIKey(from("198.51.100.1"), key)
+			require.NoError(t, err)
+		}
+		cached, ok := service.allowlists.Load("198.51.100.0/24")
+		require.True(t, ok)
+		assert.Len(t, cached, 1)
+	})
+
+	t.Run("middleware rejects callers outside the allowlist", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		key := createKey("192.0.2.0/24")
+
+		router := gin.New()
+		router.Use(service.GinMiddleware())
+		router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		request := func(remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("Authorization", "Bearer "+key)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, request("192.0.2.10:4000"))
+		assert.Equal(t, http.StatusForbidden, request("198.51.100.10:4000"))
+	})
+
+	t.Run("every middleware supplies the caller address", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		key := createKey("192.0.2.0/24")
+		ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+		passthrough := gin.New()
+		passthrough.Use(service.GinMiddlewareWithPassthrough())
+		passthrough.GET("/ping", ok)
+
+		standard := service.StandardMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		request := func(handler http.Handler, remoteAddr string) int {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			req.RemoteAddr = remoteAddr
+			req.Header.Set("Authorization", "Bearer "+key)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, request(passthrough, "192.0.2.10:4000"))
+		assert.NotEqual(t, http.StatusOK, request(passthrough, "198.51.100.10:4000"))
+		assert.Equal(t, http.StatusOK, request(standard, "192.0.2.10:4000"))
+		assert.Equal(t, http.StatusForbidden, request(standard, "198.51.100.10:4000"))
+	})
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// KeyHashAlgorithm is how API keys are hashed for storage in the database
+type KeyHashAlgorithm string
+
+const (
+	// KeyHashSHA256 stores a SHA-256 hash of the key, which is looked up directly
+	KeyHashSHA256 KeyHashAlgorithm = "sha256"
+
+	// KeyHashBcrypt stores a bcrypt hash of the key, which resists offline
+	// brute force if the database leaks. As bcrypt hashes are salted, keys are
+	// looked up by their key_prefix and compared with each candidate.
+	KeyHashBcrypt KeyHashAlgorithm = "bcrypt"
+)
+
+// bcryptHashPattern matches the stored bcrypt hashes ($2a$, $2b$ or $2y$)
+const bcryptHashPattern = "$2_$%"
+
+// keyHashAlgorithm returns the configured algorithm, defaulting to SHA-256
+func (s *Service) keyHashAlgorithm() KeyHashAlgorithm {
+	if s.config == nil || s.config.HashAlgorithm == "" {
+		return KeyHashSHA256
+	}
+	return s.config.HashAlgorithm
+}
+
+// storedKeyHash hashes an API key for storage with the configured algorithm
+func (s *Service) storedKeyHash(apiKey string) (string, error) {
+	if s.keyHashAlgorithm() != KeyHashBcrypt {
+		return s.hashAPIKey(apiKey), nil
+	}
+	cost := bcrypt.DefaultCost
+	if s.config.BcryptCost > 0 {
+		cost = s.config.BcryptCost
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(apiKey), cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash API key: %w", err)
+	}
+	return string(hash), nil
+}
+
+// isBcryptHash reports whether a stored key hash is a bcrypt hash
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2")
+}
+
+// keyHashMatches reports whether a stored key hash, of either algorithm, is
+// the hash of an API key
+func (s *Service) keyHashMatches(stored, apiKey string) bool {
+	if isBcryptHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(apiKey)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(s.hashAPIKey(apiKey))) == 1
+}
+
+// lookupKeyHash returns the key_hash an API key is stored under. With SHA-256
+// that is the key's hash. With bcrypt, the keys sharing its prefix are
+// compared with it, and keys still stored as SHA-256 are found too.
+func (s *Service) lookupKeyHash(ctx context.Context, apiKey string) (string, error) {
+	keyHash := s.hashAPIKey(apiKey)
+	if s.keyHashAlgorithm() != KeyHashBcrypt || s.db == nil {
+		return keyHash, nil
+	}
+
+	// Only bcrypt hashes need comparing, as a SHA-256 hash must be this one
+	query := `
+		SELECT key_hash FROM mcp.api_keys
+		WHERE key_prefix = $1 AND (key_hash = $2 OR key_hash LIKE $3)
+	`
+	var candidates []string
+	if err := s.db.SelectContext(ctx, &candidates, query, getKeyPrefix(apiKey), keyHash, bcryptHashPattern); err != nil {
+		return "", fmt.Errorf("database error: %w", err)
+	}
+	for _, candidate := range candidates {
+		if s.keyHashMatches(candidate, apiKey) {
+			return candidate, nil
+		}
+	}
+	return "", ErrAPIKeyNotFound
+}
+
+// rehashAPIKey moves a key stored with another algorithm to the configured
+// one, once it has validated, and returns the key_hash it is now stored under.
+// Keys only move to bcrypt, never back.
+func (s *Service) rehashAPIKey(ctx context.Context, stored, apiKey string) string {
+	if s.keyHashAlgorithm() != KeyHashBcrypt || isBcryptHash(stored) || s.db == nil {
+		return stored
+	}
+
+	rehashed, err := s.storedKeyHash(apiKey)
+	if err == nil {
+		var result sql.Result
+		query := `UPDATE mcp.api_keys SET key_hash = $1, updated_at = CURRENT_TIMESTAMP WHERE key_hash = $2`
+		result, err = s.db.ExecContext(ctx, query, rehashed, stored)
+		if err == nil {
+			if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows != 1 {
+				err = fmt.Errorf("API key not found")
+			}
+		}
+	}
+	if err != nil {
+		// The key still validates with its old hash, so try again next time
+		s.logWarn("Failed to rehash API key", map[string]interface{}{
+			"key_prefix": getKeyPrefix(apiKey),
+			"error":      err.Error(),
+		})
+		return stored
+	}
+
+	s.logInfo("Rehashed API key with bcrypt", map[string]interface{}{
+		"key_prefix": getKeyPrefix(apiKey),
+	})
+	return rehashed
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// bcryptOf matches a query argument that is a bcrypt hash of a key
+type bcryptOf string
+
+func (key bcryptOf) Match(v driver.Value) bool {
+	hash, ok := v.(string)
+	return ok && bcrypt.CompareHashAndPassword([]byte(hash), []byte(key)) == nil
+}
+
+// capturedArg matches any query argument and records it
+type capturedArg struct {
+	value driver.Value
+}
+
+func (a *capturedArg) Match(v driver.Value) bool {
+	a.value = v
+	return true
+}
+
+func TestKeyHashMatches(t *testing.T) {
+	service := &Service{}
+	key := "usr_0123456789abcdef"
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	assert.True(t, service.keyHashMatches(service.hashAPIKey(key), key))
+	assert.True(t, service.keyHashMatches(string(bcryptHash), key))
+	assert.False(t, service.keyHashMatches(service.hashAPIKey(key), key+"x"))
+	assert.False(t, service.keyHashMatches(string(bcryptHash), key+"x"))
+}
+
+func TestBcryptAPIKeys(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	config.HashAlgorithm = KeyHashBcrypt
+	config.BcryptCost = bcrypt.MinCost
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	validationColumns := []string{
+		"tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+		"expires_at", "rate_limit", "allowed_services", "rotating_until", "rotated_to_prefix",
+	}
+	validationRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows(validationColumns).
+			AddRow(serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", nil, nil)
+	}
+
+	t.Run("new keys are stored with bcrypt", func(t *testing.T) {
+		var stored capturedArg
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WithArgs(&stored, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("key-id", time.Now()))
+
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "deployer",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeAgent,
+		})
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+		assert.True(t, bcryptOf(key.Key).Match(stored.value))
+	})
+
+	t.Run("bcrypt key validates by prefix", func(t *testing.T) {
+		key := "agt_bcryptkey0123456789"
+		other, err := bcrypt.GenerateFromPassword([]byte("agt_bcryptother"), bcrypt.MinCost)
+		require.NoError(t, err)
+		stored, err := bcrypt.GenerateFromPassword([]byte(key), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`SELECT key_hash FROM mcp.api_keys\s+WHERE key_prefix = \$1`).
+			WithArgs("agt_bcry", service.hashAPIKey(key), bcryptHashPattern).
+			WillReturnRows(sqlmock.NewRows([]string{"key_hash"}).AddRow(string(other)).AddRow(string(stored)))
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WithArgs(string(stored)).
+			WillReturnRows(validationRow())
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).
+			WithArgs(sqlmock.AnyArg(), string(stored)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		user, err := service.ValidateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, "deployer", user.Metadata["key_name"])
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("SHA-256 key is rehashed on validation", func(t *testing.T) {
+		key := "agt_legacykey0123456789"
+		legacyHash := service.hashAPIKey(key)
+
+		mock.ExpectQuery(`SELECT key_hash FROM mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"key_hash"}).AddRow(legacyHash))
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WithArgs(legacyHash).
+			WillReturnRows(validationRow())
+		mock.ExpectExec(`UPDATE mcp.api_keys SET key_hash = \$1`).
+			WithArgs(bcryptOf(key), legacyHash).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).
+			WithArgs(sqlmock.AnyArg(), bcryptOf(key)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := service.ValidateAPIKey(ctx, key)
+		require.NoError(t, err)
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		other, err := bcrypt.GenerateFromPassword([]byte("agt_unknownother"), bcrypt.MinCost)
+		require.NoError(t, err)
+		mock.ExpectQuery(`SELECT key_hash FROM mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"key_hash"}).AddRow(string(other)))
+
+		_, err = service.ValidateAPIKey(ctx, "agt_unknownkey0123")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
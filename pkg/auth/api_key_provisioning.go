@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// ProvisionAPIKeys adds a batch of API keys atomically. Every key is validated before
+// anything is stored; keys are then persisted in a single database transaction and
+// swapped into memory together once it commits. If any key is invalid or any write
+// fails, none of the batch is stored.
+func (s *Service) ProvisionAPIKeys(ctx context.Context, keys map[string]APIKeySettings) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// Process keys in a stable order so failures are reproducible
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	// Validate the whole batch first
+	batch := make([]*APIKey, 0, len(names))
+	for _, key := range names {
+		apiKey, err := newAPIKeyFromSettings(key, keys[key])
+		if err != nil {
+			return fmt.Errorf("invalid API key %s: %w", lastN(key, 4), err)
+		}
+		batch = append(batch, apiKey)
+	}
+
+	if s.db != nil {
+		if err := s.persistAPIKeyBatch(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	// Swap in a new map so readers never observe a partially provisioned batch
+	s.mu.Lock()
+	apiKeys := make(map[string]*APIKey, len(s.apiKeys)+len(batch))
+	for key, apiKey := range s.apiKeys {
+		apiKeys[key] = apiKey
+	}
+	for _, apiKey := range batch {
+		apiKeys[apiKey.Key] = apiKey
+	}
+	s.apiKeys = apiKeys
+	s.mu.Unlock()
+
+	s.logInfo("Provisioned API keys", map[string]interface{}{
+		"count": len(batch),
+	})
+
+	return nil
+}
+
+// persistAPIKeyBatch upserts every key in a single transaction
+func (s *Service) persistAPIKeyBatch(ctx context.Context, batch []*APIKey) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin API key transaction: %w", err)
+	}
+
+	for _, apiKey := range batch {
+		if err := persistAPIKeyWith(ctx, tx, apiKey); err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logError("Failed to roll back API key transaction", map[string]interface{}{
+					"error": rbErr.Error(),
+				})
+			}
+			return fmt.Errorf("failed to persist API key %s: %w", lastN(apiKey.Key, 4), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit API keys: %w", err)
+	}
+
+	return nil
+}
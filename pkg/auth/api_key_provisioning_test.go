@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvisioningTestService(t *testing.T) (*Service, sqlmock.Sqlmock) {
+	t.Helper()
+
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mockDB.Close() })
+
+	return NewService(DefaultConfig(), sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger()), mock
+}
+
+func hasAPIKey(service *Service, key string) bool {
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+	_, ok := service.apiKeys[key]
+	return ok
+}
+
+func TestProvisionAPIKeys(t *testing.T) {
+	t.Run("persists batch atomically", func(t *testing.T) {
+		service, mock := newProvisioningTestService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO api_keys`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO api_keys`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := service.ProvisionAPIKeys(context.Background(), map[string]APIKeySettings{
+			"admin-key-0123456789": {Role: "admin", Scopes: []string{"read", "write", "admin"}},
+			"reader-key-012345678": {Role: "read", ExpiresIn: "24h"},
+		})
+		require.NoError(t, err)
+
+		assert.True(t, hasAPIKey(service, "admin-key-0123456789"))
+		assert.True(t, hasAPIKey(service, "reader-key-012345678"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid key persists nothing", func(t *testing.T) {
+		service, mock := newProvisioningTestService(t)
+
+		err := service.ProvisionAPIKeys(context.Background(), map[string]APIKeySettings{
+			"admin-key-0123456789": {Role: "admin"},
+			"bad-tenant-key-0123":  {Role: "read", TenantID: "not-a-uuid"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid tenant ID")
+
+		assert.False(t, hasAPIKey(service, "admin-key-0123456789"))
+		assert.False(t, hasAPIKey(service, "bad-tenant-key-0123"))
+		// Validation fails before a transaction is started
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database failure rolls back", func(t *testing.T) {
+		service, mock := newProvisioningTestService(t)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO api_keys`).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO api_keys`).WillReturnError(errors.New("connection reset"))
+		mock.ExpectRollback()
+
+		err := service.ProvisionAPIKeys(context.Background(), map[string]APIKeySettings{
+			"admin-key-0123456789": {Role: "admin"},
+			"reader-key-012345678": {Role: "read"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "connection reset")
+
+		assert.False(t, hasAPIKey(service, "admin-key-0123456789"))
+		assert.False(t, hasAPIKey(service, "reader-key-012345678"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultAPIKeyRateWindow is used when a key has a request limit but no usable window
+const DefaultAPIKeyRateWindow = time.Minute
+
+// ErrRateLimited is returned when an API key has used up its request limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError reports a rejected request and when the key may be used again.
+// It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	Limit   int
+	Window  time.Duration
+	ResetAt time.Time
+}
+
+// Error implements error
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s: %d requests per %s, retry after %s",
+		ErrRateLimited, e.Limit, e.Window, e.ResetAt.UTC().Format(time.RFC3339))
+}
+
+// Unwrap returns ErrRateLimited
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RetryAfter returns the Retry-After header value in whole seconds, at least one
+func (e *RateLimitError) RetryAfter() int {
+	seconds := int(math.Ceil(time.Until(e.ResetAt).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// RateLimiter counts requests in sliding windows, such as the request limits
+// configured on API keys. Check records a request against key and reports
+// whether it fits within limit requests per window, how many requests remain,
+// and when the oldest counted request leaves the window.
+type RateLimiter interface {
+	Check(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, resetAt time.Time, err error)
+}
+
+// slidingWindowScript atomically trims, counts and records a request in a sorted set.
+// It returns {allowed, remaining, reset_at_ms}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call('ZREMRANGEBYSCORE', key, 0, now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+local reset = now + window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+return {allowed, limit - count, reset}
+`)
+
+// RedisRateLimiter is a sliding-window RateLimiter backed by Redis,
+// so limits are shared across server instances
+type RedisRateLimiter struct {
+	client redis.UniversalClient
+	now    func() time.Time
+}
+
+// NewRedisRateLimiter creates a Redis-backed sliding-window rate limiter
+func NewRedisRateLimiter(client redis.UniversalClient) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Check implements RateLimiter
+func (l *RedisRateLimiter) Check(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	now := l.now().UnixMilli()
+	res, err := slidingWindowScript.Run(ctx, l.client, []string{key},
+		now, window.Milliseconds(), limit, fmt.Sprintf("%d-%s", now, uuid.New().String())).Int64Slice()
+	if err != nil {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit check failed: %w", err)
+	}
+	if len(res) != 3 {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit check returned %d values", len(res))
+	}
+
+	return res[0] == 1, int(res[1]), time.UnixMilli(res[2]), nil
+}
+
+// RateLimitStatus is an API key's request budget after a request is counted
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// SetAPIKeyRateLimiter sets the limiter enforcing per-key request limits in
+// ValidateAPIKey. Without one, requests are counted in the service's cache.
+func (s *Service) SetAPIKeyRateLimiter(limiter RateLimiter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.apiKeyRateLimiter = limiter
+}
+
+// rateLimiter returns the limiter for per-key request limits, falling back to
+// one backed by the service's cache
+func (s *Service) rateLimiter() RateLimiter {
+	s.mu.RLock()
+	limiter := s.apiKeyRateLimiter
+	s.mu.RUnlock()
+	if limiter != nil || s.cache == nil {
+		return limiter
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apiKeyRateLimiter == nil {
+		s.apiKeyRateLimiter = NewCacheRateLimiter(s.cache)
+	}
+	return s.apiKeyRateLimiter
+}
+
+// CheckRateLimit counts a request against an API key's limit and returns the
+// remaining budget. Once the limit is used up it returns a *RateLimitError,
+// which matches ErrRateLimited. Keys without a limit aren't counted and get a
+// nil status, as do requests allowed because the limiter's backend is down.
+func (s *Service) CheckRateLimit(ctx context.Context, key *APIKey) (*RateLimitStatus, error) {
+	keyHash := key.KeyHash
+	if keyHash == "" {
+		keyHash = s.hashAPIKey(key.Key)
+	}
+	window := time.Duration(key.RateLimitWindowSeconds) * time.Second
+	return s.checkRateLimit(ctx, key.TenantID, keyHash, key.RateLimitRequests, window)
+}
+
+// enforceAPIKeyRateLimit counts a validated request against its key's limit
+// and attaches the remaining budget to the user
+func (s *Service) enforceAPIKeyRateLimit(ctx context.Context, apiKey string, user *User) error {
+	limit := metadataInt(user.Metadata, "rate_limit")
+	window := time.Duration(metadataInt(user.Metadata, "rate_limit_window_seconds")) * time.Second
+	status, err := s.checkRateLimit(ctx, user.TenantID, s.hashAPIKey(apiKey), limit, window)
+	if err != nil {
+		return err
+	}
+	user.RateLimit = status
+	return nil
+}
+
+// checkRateLimit counts a request in the tenant's counter for a key. The limiter
+// failing open keeps authentication available when its backend isn't.
+func (s *Service) checkRateLimit(ctx context.Context, tenantID uuid.UUID, keyHash string, limit int, window time.Duration) (*RateLimitStatus, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	limiter := s.rateLimiter()
+	if limiter == nil {
+		return nil, nil
+	}
+	if window <= 0 {
+		window = DefaultAPIKeyRateWindow
+	}
+
+	key := fmt.Sprintf("auth:ratelimit:apikey:%s:%s", tenantID, keyHash)
+	allowed, remaining, resetAt, err := limiter.Check(ctx, key, limit, window)
+	if err != nil {
+		s.logWarn("API key rate limit check failed, allowing request", map[string]interface{}{
+			"tenant_id": tenantID,
+			"error":     err.Error(),
+		})
+		return nil, nil
+	}
+	if !allowed {
+		s.logInfo("API key rate limit exceeded", map[string]interface{}{
+			"tenant_id": tenantID,
+			"limit":     limit,
+			"reset_at":  resetAt.UTC().Format(time.RFC3339),
+		})
+		return nil, &RateLimitError{Limit: limit, Window: window, ResetAt: resetAt}
+	}
+
+	return &RateLimitStatus{Limit: limit, Remaining: remaining, ResetAt: resetAt}, nil
+}
+
+// rateLimitMetadata describes a key's request limit for the validated user's metadata
+func rateLimitMetadata(metadata map[string]interface{}, limit, windowSeconds int) {
+	if limit <= 0 {
+		return
+	}
+	metadata["rate_limit"] = limit
+	metadata["rate_limit_window_seconds"] = windowSeconds
+}
+
+// parseRateWindow converts a stored rate window, either a duration such as "1h"
+// or a number of seconds, to seconds
+func parseRateWindow(window string) int {
+	window = strings.TrimSpace(window)
+	if seconds, err := strconv.Atoi(window); err == nil {
+		return seconds
+	}
+	if d, err := time.ParseDuration(window); err == nil {
+		return int(d.Seconds())
+	}
+	return 0
+}
+
+// metadataInt reads an integer from user metadata, which holds float64 values
+// once it has been through the validation cache
+func metadataInt(metadata map[string]interface{}, key string) int {
+	switch v := metadata[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/common/cache"
+)
+
+// slidingWindowCounter is the cached state of a CacheRateLimiter key:
+// request counts for the current fixed window and the one before it
+type slidingWindowCounter struct {
+	WindowStart int64 `json:"window_start"` // Unix milliseconds
+	Current     int   `json:"current"`
+	Previous    int   `json:"previous"`
+}
+
+// CacheRateLimiter is a RateLimiter backed by any cache.Cache. It
+// approximates a sliding window by weighting the previous fixed window's count
+// by how much of it still overlaps the sliding window. Updates are serialized
+// within a process but not across instances sharing the cache; use
+// RedisRateLimiter when limits must be exact across instances.
+type CacheRateLimiter struct {
+	cache cache.Cache
+	mu    sync.Mutex
+	now   func() time.Time
+}
+
+// NewCacheRateLimiter creates an API key rate limiter backed by a cache
+func NewCacheRateLimiter(c cache.Cache) *CacheRateLimiter {
+	return &CacheRateLimiter{
+		cache: c,
+		now:   time.Now,
+	}
+}
+
+// Check implements RateLimiter
+func (l *CacheRateLimiter) Check(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	windowMS := window.Milliseconds()
+	if windowMS <= 0 {
+		return false, 0, time.Time{}, fmt.Errorf("invalid rate limit window %s", window)
+	}
+	nowMS := l.now().UnixMilli()
+	start := nowMS - nowMS%windowMS
+
+	var counter slidingWindowCounter
+	if err := l.cache.Get(ctx, key, &counter); err != nil && !errors.Is(err, cache.ErrNotFound) {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit counter unavailable: %w", err)
+	}
+	switch counter.WindowStart {
+	case start:
+	case start - windowMS:
+		counter = slidingWindowCounter{WindowStart: start, Previous: counter.Current}
+	default:
+		counter = slidingWindowCounter{WindowStart: start}
+	}
+
+	// Share of the previous window still inside the sliding window
+	overlap := 1 - float64(nowMS-start)/float64(windowMS)
+	used := float64(counter.Previous)*overlap + float64(counter.Current)
+
+	if used+1 > float64(limit) {
+		return false, 0, time.UnixMilli(counter.resetAt(limit, windowMS)), nil
+	}
+
+	counter.Current++
+	if err := l.cache.Set(ctx, key, counter, 2*window); err != nil {
+		return false, 0, time.Time{}, fmt.Errorf("rate limit counter unavailable: %w", err)
+	}
+
+	remaining := limit - int(math.Ceil(used+1))
+	if remaining < 0 {
+		remaining = 0
+	}
+	// The previous window has fully slid out once the current one ends
+	return true, remaining, time.UnixMilli(start + windowMS), nil
+}
+
+// resetAt returns when, in Unix milliseconds, enough of the previous window has
+// slid out for another request to fit
+func (c slidingWindowCounter) resetAt(limit int, windowMS int64) int64 {
+	end := c.WindowStart + windowMS
+	free := float64(limit - 1 - c.Current)
+	if c.Previous == 0 || free < 0 {
+		// Only the current window's requests remain, and they count until it ends
+		return end
+	}
+	// Solve Previous*(1-elapsed) + Current + 1 <= limit for elapsed
+	elapsed := 1 - free/float64(c.Previous)
+	if elapsed <= 0 {
+		return c.WindowStart
+	}
+	return c.WindowStart + int64(math.Ceil(elapsed*float64(windowMS)))
+}
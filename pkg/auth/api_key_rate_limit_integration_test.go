@@ -0,0 +1,66 @@
+//go:build integration
+// +build integration
+
+package auth
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRedisAPIKeyRateLimiterWindowReset checks the sliding window against a real Redis
+func TestRedisAPIKeyRateLimiterWindowReset(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer func() { _ = client.Close() }()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		t.Skipf("Redis not available at %s: %v", addr, err)
+	}
+
+	limiter := NewRedisRateLimiter(client)
+	key := "auth:ratelimit:apikey:test:" + uuid.New().String()
+	defer client.Del(ctx, key)
+
+	const limit = 3
+	window := time.Second
+
+	var resetAt time.Time
+	for i := 0; i < limit; i++ {
+		allowed, remaining, reset, err := limiter.Check(ctx, key, limit, window)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, limit-i-1, remaining)
+		resetAt = reset
+	}
+
+	allowed, _, reset, err := limiter.Check(ctx, key, limit, window)
+	require.NoError(t, err)
+	assert.False(t, allowed, "requests over the limit are rejected")
+	assert.WithinDuration(t, resetAt, reset, window)
+
+	time.Sleep(time.Until(reset) + 50*time.Millisecond)
+
+	allowed, _, _, err = limiter.Check(ctx, key, limit, window)
+	require.NoError(t, err)
+	assert.True(t, allowed, "requests are allowed once the oldest leaves the window")
+
+	ttl, err := client.PTTL(ctx, key).Result()
+	require.NoError(t, err)
+	assert.LessOrEqual(t, ttl, window, "counters expire with the window")
+}
@@ -0,0 +1,311 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/common/cache"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// newMiniRedisRateLimiter returns a limiter on a fake Redis with a controllable clock
+func newMiniRedisRateLimiter(t *testing.T) (*RedisRateLimiter, *time.Time) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	now := time.Now()
+	limiter := NewRedisRateLimiter(client)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+// failingRateLimiter is a RateLimiter whose backend is down
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Check(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	return false, 0, time.Time{}, errors.New("connection refused")
+}
+
+func TestRedisAPIKeyRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter, now := newMiniRedisRateLimiter(t)
+	start := *now
+
+	tests := []struct {
+		name          string
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantResetAt   time.Time
+	}{
+		{name: "first request", wantAllowed: true, wantRemaining: 2, wantResetAt: start.Add(time.Minute)},
+		{name: "second request", advance: 10 * time.Second, wantAllowed: true, wantRemaining: 1, wantResetAt: start.Add(time.Minute)},
+		{name: "last request in the window", advance: 10 * time.Second, wantAllowed: true, wantRemaining: 0, wantResetAt: start.Add(time.Minute)},
+		{name: "over the limit", advance: 10 * time.Second, wantAllowed: false, wantRemaining: 0, wantResetAt: start.Add(time.Minute)},
+		{name: "oldest request slides out", advance: 30 * time.Second, wantAllowed: true, wantRemaining: 0, wantResetAt: start.Add(70 * time.Second)},
+		{name: "window is full again", wantAllowed: false, wantRemaining: 0, wantResetAt: start.Add(70 * time.Second)},
+		{name: "whole window elapsed", advance: 2 * time.Minute, wantAllowed: true, wantRemaining: 2, wantResetAt: start.Add(4 * time.Minute)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*now = now.Add(tt.advance)
+			allowed, remaining, resetAt, err := limiter.Check(ctx, "key", 3, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllowed, allowed)
+			assert.Equal(t, tt.wantRemaining, remaining)
+			assert.Equal(t, tt.wantResetAt.UnixMilli(), resetAt.UnixMilli())
+		})
+	}
+
+	t.Run("keys are counted separately", func(t *testing.T) {
+		allowed, remaining, _, err := limiter.Check(ctx, "other-key", 3, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, 2, remaining)
+	})
+}
+
+func TestValidateAPIKeyRateLimit(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	newService := func(t *testing.T, limit int) (*Service, string) {
+		service := NewService(DefaultConfig(), nil, nil, observability.NewNoopLogger())
+		apiKey := "rate-limit-test-key-" + uuid.New().String()
+		service.apiKeys[apiKey] = &APIKey{
+			TenantID:               tenantID,
+			UserID:                 uuid.New(),
+			KeyType:                KeyTypeUser,
+			Scopes:                 []string{"read"},
+			Active:                 true,
+			RateLimitRequests:      limit,
+			RateLimitWindowSeconds: 60,
+		}
+		return service, apiKey
+	}
+
+	t.Run("requests over the key's limit are rejected", func(t *testing.T) {
+		service, apiKey := newService(t, 2)
+		limiter, now := newMiniRedisRateLimiter(t)
+		service.SetAPIKeyRateLimiter(limiter)
+
+		for i := 0; i < 2; i++ {
+			_, err := service.ValidateAPIKey(ctx, apiKey)
+			require.NoError(t, err)
+		}
+
+		_, err := service.ValidateAPIKey(ctx, apiKey)
+		require.ErrorIs(t, err, ErrRateLimited)
+		var rateLimitErr *RateLimitError
+		require.True(t, errors.As(err, &rateLimitErr))
+		assert.Equal(t, 2, rateLimitErr.Limit)
+		assert.Equal(t, now.Add(time.Minute).UnixMilli(), rateLimitErr.ResetAt.UnixMilli())
+		assert.GreaterOrEqual(t, rateLimitErr.RetryAfter(), 1)
+
+		*now = now.Add(time.Minute)
+		_, err = service.ValidateAPIKey(ctx, apiKey)
+		assert.NoError(t, err, "the key is usable again once the window resets")
+	})
+
+	tests := []struct {
+		name    string
+		limit   int
+		limiter RateLimiter
+	}{
+		{name: "no limiter configured", limit: 1},
+		{name: "key without a limit", limit: 0, limiter: &RedisRateLimiter{}},
+		{name: "limiter unavailable fails open", limit: 1, limiter: failingRateLimiter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, apiKey := newService(t, tt.limit)
+			if tt.limiter != nil {
+				service.SetAPIKeyRateLimiter(tt.limiter)
+			}
+
+			for i := 0; i < 3; i++ {
+				_, err := service.ValidateAPIKey(ctx, apiKey)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseRateWindow(t *testing.T) {
+	tests := []struct {
+		window string
+		want   int
+	}{
+		{window: "1h", want: 3600},
+		{window: "60", want: 60},
+		{window: "90s", want: 90},
+		{window: "", want: 0},
+		{window: "hourly", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.window, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseRateWindow(tt.window))
+		})
+	}
+}
+
+// newMiniRedisCache returns a cache.Cache on a fake Redis
+func newMiniRedisCache(t *testing.T) (*cache.RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	redisCache, err := cache.NewRedisCache(cache.RedisConfig{Address: mr.Addr(), DialTimeout: time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = redisCache.Close() })
+	return redisCache, mr
+}
+
+func TestCacheAPIKeyRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	redisCache, _ := newMiniRedisCache(t)
+	limiter := NewCacheRateLimiter(redisCache)
+
+	// Start on a window boundary so the weighting is predictable
+	start := time.UnixMilli(time.Now().UnixMilli() / 60000 * 60000)
+	now := start
+	limiter.now = func() time.Time { return now }
+
+	tests := []struct {
+		name          string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantResetAt   time.Duration
+	}{
+		{name: "first request", wantAllowed: true, wantRemaining: 3, wantResetAt: time.Minute},
+		{name: "second request", at: 10 * time.Second, wantAllowed: true, wantRemaining: 2, wantResetAt: time.Minute},
+		{name: "third request", at: 20 * time.Second, wantAllowed: true, wantRemaining: 1, wantResetAt: time.Minute},
+		{name: "last request in the window", at: 30 * time.Second, wantAllowed: true, wantRemaining: 0, wantResetAt: time.Minute},
+		{name: "over the limit until the window ends", at: 40 * time.Second, wantAllowed: false, wantResetAt: time.Minute},
+		// Halfway through the next window half of the previous 4 still count
+		{name: "previous window partly slid out", at: 90 * time.Second, wantAllowed: true, wantRemaining: 1, wantResetAt: 2 * time.Minute},
+		{name: "budget used again", at: 90 * time.Second, wantAllowed: true, wantRemaining: 0, wantResetAt: 2 * time.Minute},
+		// 4*(1-elapsed) + 2 + 1 <= 4 once three quarters of the window has passed
+		{name: "over the limit until more slides out", at: 90 * time.Second, wantAllowed: false, wantResetAt: 105 * time.Second},
+		{name: "allowed again at the reset", at: 105 * time.Second, wantAllowed: true, wantRemaining: 0, wantResetAt: 2 * time.Minute},
+		{name: "idle for two windows", at: 5 * time.Minute, wantAllowed: true, wantRemaining: 3, wantResetAt: 6 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = start.Add(tt.at)
+			allowed, remaining, resetAt, err := limiter.Check(ctx, "auth:ratelimit:apikey:test", 4, time.Minute)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAllowed, allowed)
+			assert.Equal(t, tt.wantRemaining, remaining)
+			assert.Equal(t, start.Add(tt.wantResetAt).UnixMilli(), resetAt.UnixMilli())
+		})
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	ctx := context.Background()
+	redisCache, mr := newMiniRedisCache(t)
+	service := NewService(DefaultConfig(), nil, redisCache, observability.NewNoopLogger())
+
+	key := &APIKey{
+		Key:                    "check-rate-limit-key",
+		TenantID:               uuid.New(),
+		RateLimitRequests:      2,
+		RateLimitWindowSeconds: 60,
+	}
+
+	status, err := service.CheckRateLimit(ctx, key)
+	require.NoError(t, err)
+	require.NotNil(t, status, "the service's cache counts requests by default")
+	assert.Equal(t, 2, status.Limit)
+	assert.Equal(t, 1, status.Remaining)
+
+	status, err = service.CheckRateLimit(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Remaining)
+
+	_, err = service.CheckRateLimit(ctx, key)
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	t.Run("counters are per tenant", func(t *testing.T) {
+		other := *key
+		other.TenantID = uuid.New()
+		status, err := service.CheckRateLimit(ctx, &other)
+		require.NoError(t, err)
+		assert.Equal(t, 1, status.Remaining)
+	})
+
+	t.Run("keys without a limit aren't counted", func(t *testing.T) {
+		unlimited := *key
+		unlimited.RateLimitRequests = 0
+		status, err := service.CheckRateLimit(ctx, &unlimited)
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+	})
+
+	t.Run("unavailable cache allows requests", func(t *testing.T) {
+		mr.Close()
+		status, err := service.CheckRateLimit(ctx, key)
+		assert.NoError(t, err)
+		assert.Nil(t, status)
+	})
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	redisCache, _ := newMiniRedisCache(t)
+	service := NewService(DefaultConfig(), nil, redisCache, observability.NewNoopLogger())
+
+	rateLimit := 2
+	key, err := service.CreateAPIKeyWithType(context.Background(), CreateAPIKeyRequest{
+		Name:      "headers",
+		TenantID:  uuid.New().String(),
+		KeyType:   KeyTypeUser,
+		RateLimit: &rateLimit,
+	})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(service.GinMiddleware(TypeAPIKey))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("Authorization", "Bearer "+key.Key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := request()
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, remaining, rec.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, rec.Header().Get("X-RateLimit-Reset"))
+	}
+
+	rec := request()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	retryAfter, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Positive(t, retryAfter)
+}
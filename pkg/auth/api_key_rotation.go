@@ -0,0 +1,416 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyRotating is returned when rotating a key that is already being rotated
+var ErrAPIKeyRotating = errors.New("API key is already being rotated")
+
+// RotateAPIKey replaces an API key with a new one carrying the same tenant,
+// scopes and limits. The new key links to the old one through RotatedFrom. The
+// old key stays valid, flagged as rotating, until the grace period ends so
+// in-flight clients can switch over; SweepRotatedAPIKeys then deactivates it.
+func (s *Service) RotateAPIKey(ctx context.Context, oldKey string, gracePeriod time.Duration) (*APIKey, error) {
+	if gracePeriod <= 0 {
+		return nil, fmt.Errorf("grace period must be positive")
+	}
+	if !isValidAPIKeyFormat(oldKey) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	old, inMemory, err := s.loadActiveAPIKey(ctx, oldKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.rotateAPIKey(ctx, old, oldKey, inMemory, gracePeriod)
+}
+
+// RotateAPIKeyByID rotates a tenant's key by the ID ListAPIKeys reports, so
+// admins needn't hold the key itself. Unlike validating the key, looking it up
+// doesn't count against its rate limit or touch its usage. Keys of other
+// tenants aren't found.
+func (s *Service) RotateAPIKeyByID(ctx context.Context, tenantID uuid.UUID, keyID string, gracePeriod time.Duration) (*APIKey, error) {
+	if gracePeriod <= 0 {
+		return nil, fmt.Errorf("grace period must be positive")
+	}
+
+	old, oldKey, err := s.loadTenantAPIKey(ctx, tenantID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return s.rotateAPIKey(ctx, old, oldKey, oldKey != "", gracePeriod)
+}
+
+// rotateAPIKey replaces a loaded key. oldKey is the key itself, when known;
+// without it, validations cached before the rotation carry the rotation hint
+// only once they expire from the cache.
+func (s *Service) rotateAPIKey(ctx context.Context, old *APIKey, oldKey string, inMemory bool, gracePeriod time.Duration) (*APIKey, error) {
+	if old.RotatingUntil != nil {
+		return nil, ErrAPIKeyRotating
+	}
+
+	req := copyKeyRequest(old)
+	req.RotatedFrom = &old.ID
+	newKey, err := s.CreateAPIKeyWithType(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create replacement API key: %w", err)
+	}
+
+	rotatingUntil := time.Now().Add(gracePeriod)
+	if inMemory {
+		err = s.markRotatingInMemory(oldKey, newKey.ID, rotatingUntil)
+	} else {
+		err = s.markRotatingInDB(ctx, old.ID, newKey.ID, rotatingUntil)
+	}
+	if err != nil {
+		s.discardAPIKey(ctx, newKey)
+		return nil, err
+	}
+
+	// Validations cached before the rotation don't carry the rotation hint
+	if oldKey != "" && s.config.CacheEnabled && s.cache != nil {
+		if err := s.cache.Delete(ctx, fmt.Sprintf("auth:apikey:%s", oldKey)); err != nil {
+			s.logWarn("Failed to delete API key from cache", map[string]interface{}{"error": err})
+		}
+	}
+
+	s.logInfo("API key rotated", map[string]interface{}{
+		"key_id":         old.ID,
+		"new_key_prefix": newKey.KeyPrefix,
+		"tenant_id":      old.TenantID,
+		"rotating_until": rotatingUntil.Format(time.RFC3339),
+	})
+
+	return newKey, nil
+}
+
+// copyKeyRequest describes a key with the tenant, user, type, grants and
+// limits of another
+func copyKeyRequest(old *APIKey) CreateAPIKeyRequest {
+	keyType := old.KeyType
+	if !keyType.Valid() {
+		keyType = KeyTypeUser
+	}
+	userID := ""
+	if old.UserID != SystemUserID && old.UserID != uuid.Nil {
+		userID = old.UserID.String()
+	}
+	req := CreateAPIKeyRequest{
+		Name:            old.Name,
+		TenantID:        old.TenantID.String(),
+		UserID:          userID,
+		KeyType:         keyType,
+		Scopes:          old.Scopes,
+		ExpiresAt:       old.ExpiresAt,
+		AllowedServices: old.AllowedServices,
+		AllowedCIDRs:    old.AllowedCIDRs,
+	}
+	if old.RateLimitRequests > 0 {
+		rateLimit := old.RateLimitRequests
+		req.RateLimit = &rateLimit
+	}
+	return req
+}
+
+// loadActiveAPIKey loads an active, unexpired key from memory or the
+// database, reporting whether it's held in memory. In-memory keys without an
+// ID are given one so other keys can link to them.
+func (s *Service) loadActiveAPIKey(ctx context.Context, apiKey string) (*APIKey, bool, error) {
+	s.mu.Lock()
+	memKey, inMemory := s.apiKeys[apiKey]
+	var key APIKey
+	if inMemory {
+		if memKey.ID == "" {
+			memKey.ID = uuid.New().String()
+		}
+		key = *memKey
+	}
+	s.mu.Unlock()
+
+	if !inMemory {
+		if s.db == nil {
+			return nil, false, ErrAPIKeyNotFound
+		}
+		keyHash, err := s.lookupKeyHash(ctx, apiKey)
+		if err != nil {
+			return nil, false, err
+		}
+		dbKey, err := s.getActiveAPIKey(ctx, keyHash)
+		if err != nil {
+			return nil, false, err
+		}
+		key = *dbKey
+	}
+
+	if err := checkUsable(&key); err != nil {
+		return nil, inMemory, err
+	}
+	return &key, inMemory, nil
+}
+
+// loadTenantAPIKey loads a tenant's active, unexpired key by ID from memory or
+// the database. Keys held in memory are returned with the key itself.
+func (s *Service) loadTenantAPIKey(ctx context.Context, tenantID uuid.UUID, keyID string) (*APIKey, string, error) {
+	var key *APIKey
+	var apiKey string
+	s.mu.RLock()
+	for k, memKey := range s.apiKeys {
+		if memKey.ID == keyID && memKey.TenantID == tenantID {
+			found := *memKey
+			key, apiKey = &found, k
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if key == nil {
+		// Database keys are identified by UUID
+		if s.db == nil || uuid.Validate(keyID) != nil {
+			return nil, "", ErrAPIKeyNotFound
+		}
+		dbKey, err := s.queryActiveAPIKey(ctx, "id = $1 AND tenant_id = $2", keyID, tenantID)
+		if err != nil {
+			return nil, "", err
+		}
+		key = dbKey
+	}
+
+	if err := checkUsable(key); err != nil {
+		return nil, "", err
+	}
+	return key, apiKey, nil
+}
+
+// checkUsable rejects inactive and expired keys
+func checkUsable(key *APIKey) error {
+	if !key.Active {
+		return ErrAPIKeyNotFound
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return ErrAPIKeyExpired
+	}
+	return nil
+}
+
+// getActiveAPIKey loads an active key from the database
+func (s *Service) getActiveAPIKey(ctx context.Context, keyHash string) (*APIKey, error) {
+	return s.queryActiveAPIKey(ctx, "key_hash = $1", keyHash)
+}
+
+// queryActiveAPIKey loads the active key matching a condition from the database
+func (s *Service) queryActiveAPIKey(ctx context.Context, condition string, args ...interface{}) (*APIKey, error) {
+	query := `
+		SELECT id, tenant_id, user_id, name, key_type, scopes, is_active,
+		       expires_at, rate_limit, allowed_services, allowed_cidrs, rotating_until
+		FROM mcp.api_keys
+		WHERE ` + condition + ` AND is_active = true`
+	var row struct {
+		ID              string         `db:"id"`
+		TenantID        uuid.UUID      `db:"tenant_id"`
+		UserID          sql.NullString `db:"user_id"`
+		Name            string         `db:"name"`
+		KeyType         string         `db:"key_type"`
+		Scopes          pq.StringArray `db:"scopes"`
+		Active          bool           `db:"is_active"`
+		ExpiresAt       *time.Time     `db:"expires_at"`
+		RateLimit       *int           `db:"rate_limit"`
+		AllowedServices pq.StringArray `db:"allowed_services"`
+		AllowedCIDRs    pq.StringArray `db:"allowed_cidrs"`
+		RotatingUntil   *time.Time     `db:"rotating_until"`
+	}
+	if err := s.db.GetContext(ctx, &row, query, args...); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	key := &APIKey{
+		ID:              row.ID,
+		TenantID:        row.TenantID,
+		UserID:          SystemUserID,
+		Name:            row.Name,
+		KeyType:         KeyType(row.KeyType),
+		Scopes:          []string(row.Scopes),
+		Active:          row.Active,
+		ExpiresAt:       row.ExpiresAt,
+		AllowedServices: []string(row.AllowedServices),
+		AllowedCIDRs:    []string(row.AllowedCIDRs),
+		RotatingUntil:   row.RotatingUntil,
+	}
+	if row.UserID.Valid {
+		if userID, err := uuid.Parse(row.UserID.String); err == nil {
+			key.UserID = userID
+		}
+	}
+	if row.RateLimit != nil {
+		key.RateLimitRequests = *row.RateLimit
+	}
+	return key, nil
+}
+
+// markRotatingInMemory starts the grace period of an in-memory key, unless a
+// concurrent rotation got there first
+func (s *Service) markRotatingInMemory(oldKey, newKeyID string, rotatingUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[oldKey]
+	if !ok || !key.Active {
+		return ErrInvalidAPIKey
+	}
+	if key.RotatingUntil != nil {
+		return ErrAPIKeyRotating
+	}
+	key.RotatingUntil = &rotatingUntil
+	key.RotatedTo = &newKeyID
+	return nil
+}
+
+// markRotatingInDB persists the grace period of a key, unless a concurrent
+// rotation got there first
+func (s *Service) markRotatingInDB(ctx context.Context, oldKeyID, newKeyID string, rotatingUntil time.Time) error {
+	query := `
+		UPDATE mcp.api_keys
+		SET rotating_until = $2, rotated_to = $3, rotated_at = $4
+		WHERE id = $1 AND is_active = true AND rotating_until IS NULL
+	`
+	result, err := s.db.ExecContext(ctx, query, oldKeyID, rotatingUntil, newKeyID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark API key as rotating: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to mark API key as rotating: %w", err)
+	}
+	if rows != 1 {
+		return ErrAPIKeyRotating
+	}
+	return nil
+}
+
+// discardAPIKey removes a replacement key whose rotation failed
+func (s *Service) discardAPIKey(ctx context.Context, key *APIKey) {
+	s.mu.Lock()
+	delete(s.apiKeys, key.Key)
+	s.mu.Unlock()
+
+	if s.db == nil {
+		return
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM mcp.api_keys WHERE id = $1`, key.ID); err != nil {
+		s.logError("Failed to remove replacement API key", map[string]interface{}{
+			"key_prefix": key.KeyPrefix,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// rotatedToPrefix returns the prefix of the in-memory key that replaced a key
+func (s *Service) rotatedToPrefix(keyID string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.apiKeys {
+		if key.ID == keyID {
+			return key.KeyPrefix
+		}
+	}
+	return ""
+}
+
+// applyRotationState rejects a rotated key whose grace period is over. During
+// the grace period it flags the user's key as rotating and names the prefix of
+// the replacement key, so clients can switch to it.
+func applyRotationState(user *User, rotatingUntil *time.Time, rotatedToPrefix string) error {
+	if rotatingUntil == nil {
+		return nil
+	}
+	if !time.Now().Before(*rotatingUntil) {
+		return ErrInvalidAPIKey
+	}
+
+	user.Metadata["key_status"] = "rotating"
+	user.Metadata["rotated_to"] = rotatedToPrefix
+	user.Metadata["rotation_ends_at"] = rotatingUntil.Format(time.RFC3339)
+	return nil
+}
+
+// validationCacheTTL keeps a rotating key's validation from being cached past
+// its grace period
+func (s *Service) validationCacheTTL(rotatingUntil *time.Time) time.Duration {
+	ttl := s.config.CacheTTL
+	if rotatingUntil != nil {
+		if remaining := time.Until(*rotatingUntil); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	return ttl
+}
+
+// SweepRotatedAPIKeys deactivates rotated keys whose grace period has ended and
+// returns how many were deactivated
+func (s *Service) SweepRotatedAPIKeys(ctx context.Context) (int, error) {
+	now := time.Now()
+	swept := 0
+
+	s.mu.Lock()
+	for _, key := range s.apiKeys {
+		if key.Active && key.RotatingUntil != nil && !now.Before(*key.RotatingUntil) {
+			key.Active = false
+			swept++
+		}
+	}
+	s.mu.Unlock()
+
+	if s.db != nil {
+		query := `
+			UPDATE mcp.api_keys
+			SET is_active = false
+			WHERE rotating_until IS NOT NULL AND rotating_until <= $1 AND is_active = true
+		`
+		result, err := s.db.ExecContext(ctx, query, now)
+		if err != nil {
+			return swept, fmt.Errorf("failed to deactivate rotated API keys: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return swept, fmt.Errorf("failed to deactivate rotated API keys: %w", err)
+		}
+		swept += int(rows)
+	}
+
+	if swept > 0 {
+		s.logInfo("Deactivated rotated API keys", map[string]interface{}{
+			"count": swept,
+		})
+	}
+	return swept, nil
+}
+
+// RunAPIKeyRotationSweeper deactivates rotated keys past their grace period
+// every interval until the context is done
+func (s *Service) RunAPIKeyRotationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SweepRotatedAPIKeys(ctx); err != nil {
+				s.logError("Failed to sweep rotated API keys", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	}
+}
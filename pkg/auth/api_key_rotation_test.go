@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestRotateAPIKeyInMemory(t *testing.T) {
+	service := NewService(DefaultConfig(), nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	old, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     "deployer",
+		TenantID: serviceAccountTenant,
+		KeyType:  KeyTypeAgent,
+		Scopes:   []string{"read", "write"},
+	})
+	require.NoError(t, err)
+
+	replacement, err := service.RotateAPIKey(ctx, old.Key, time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Key, replacement.Key)
+	assert.Nil(t, replacement.ParentKeyID, "a replacement isn't a child key")
+	require.NotNil(t, replacement.RotatedFrom)
+	assert.Equal(t, old.ID, *replacement.RotatedFrom)
+	assert.Equal(t, KeyTypeAgent, replacement.KeyType)
+	assert.Equal(t, []string{"read", "write"}, replacement.Scopes)
+
+	t.Run("both keys are valid during the grace period", func(t *testing.T) {
+		user, err := service.ValidateAPIKey(ctx, old.Key)
+		require.NoError(t, err)
+		assert.Equal(t, "rotating", user.Metadata["key_status"])
+		assert.Equal(t, replacement.KeyPrefix, user.Metadata["rotated_to"])
+
+		user, err = service.ValidateAPIKey(ctx, replacement.Key)
+		require.NoError(t, err)
+		assert.NotContains(t, user.Metadata, "rotated_to")
+	})
+
+	t.Run("a rotating key can't be rotated again", func(t *testing.T) {
+		_, err := service.RotateAPIKey(ctx, old.Key, time.Hour)
+		assert.ErrorIs(t, err, ErrAPIKeyRotating)
+	})
+
+	t.Run("old key is rejected and swept once the grace period ends", func(t *testing.T) {
+		ended := time.Now().Add(-time.Second)
+		service.mu.Lock()
+		service.apiKeys[old.Key].RotatingUntil = &ended
+		service.mu.Unlock()
+
+		_, err := service.ValidateAPIKey(ctx, old.Key)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+		swept, err := service.SweepRotatedAPIKeys(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, swept)
+		assert.False(t, service.apiKeys[old.Key].Active)
+
+		_, err = service.ValidateAPIKey(ctx, replacement.Key)
+		assert.NoError(t, err)
+	})
+
+	t.Run("unknown keys and invalid grace periods are rejected", func(t *testing.T) {
+		_, err := service.RotateAPIKey(ctx, "unknown-key-1234567890", time.Hour)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		_, err = service.RotateAPIKey(ctx, replacement.Key, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestRotateAPIKeyByID(t *testing.T) {
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, nil, NewTestCache(), observability.NewNoopLogger())
+	ctx := context.Background()
+	tenantID := uuid.MustParse(serviceAccountTenant)
+
+	old, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:      "deployer",
+		TenantID:  serviceAccountTenant,
+		KeyType:   KeyTypeAgent,
+		Scopes:    []string{"read"},
+		RateLimit: intPtr(1),
+	})
+	require.NoError(t, err)
+
+	t.Run("keys of other tenants aren't found", func(t *testing.T) {
+		_, err := service.RotateAPIKeyByID(ctx, uuid.New(), old.ID, time.Hour)
+		assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+		_, err = service.RotateAPIKeyByID(ctx, tenantID, "not-a-key-id", time.Hour)
+		assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+	})
+
+	t.Run("rotation doesn't use the key", func(t *testing.T) {
+		replacement, err := service.RotateAPIKeyByID(ctx, tenantID, old.ID, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, old.ID, *replacement.RotatedFrom)
+
+		// The key's single request is still available
+		user, err := service.ValidateAPIKey(ctx, old.Key)
+		require.NoError(t, err)
+		assert.Equal(t, replacement.KeyPrefix, user.Metadata["rotated_to"])
+		_, err = service.ValidateAPIKey(ctx, old.Key)
+		assert.ErrorIs(t, err, ErrRateLimited)
+
+		_, err = service.RotateAPIKeyByID(ctx, tenantID, old.ID, time.Hour)
+		assert.ErrorIs(t, err, ErrAPIKeyRotating)
+	})
+
+	t.Run("keys loaded without an ID get one when listed", func(t *testing.T) {
+		service.mu.Lock()
+		service.apiKeys["configured-key-1234567890"] = &APIKey{
+			Key:      "configured-key-1234567890",
+			TenantID: tenantID,
+			Name:     "configured",
+			KeyType:  KeyTypeAdmin,
+			Active:   true,
+		}
+		service.mu.Unlock()
+
+		keys, err := service.ListAPIKeys(ctx, tenantID)
+		require.NoError(t, err)
+		var keyID string
+		for _, key := range keys {
+			if key.Name == "configured" {
+				keyID = key.ID
+			}
+		}
+		require.NotEmpty(t, keyID)
+
+		_, err = service.RotateAPIKeyByID(ctx, tenantID, keyID, time.Hour)
+		assert.NoError(t, err)
+	})
+}
+
+func TestRotateAPIKeyWithDatabase(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	const oldKey = "agt_oldkeyoldkeyoldkeyoldkey"
+	oldID := uuid.New().String()
+	newID := uuid.New().String()
+	keyColumns := []string{
+		"id", "tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+		"expires_at", "rate_limit", "allowed_services", "rotating_until",
+	}
+
+	t.Run("rotation is persisted", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, tenant_id, user_id, name, key_type, scopes, is_active`).
+			WithArgs(service.hashAPIKey(oldKey)).
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(oldID, serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", nil))
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WithArgs(
+				sqlmock.AnyArg(), sqlmock.AnyArg(), serviceAccountTenant, sqlmock.AnyArg(), "deployer", KeyTypeAgent,
+				sqlmock.AnyArg(), true, sqlmock.AnyArg(), 500, 60, nil, &oldID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(newID, time.Now()))
+		mock.ExpectExec(`UPDATE mcp.api_keys\s+SET rotating_until = \$2, rotated_to = \$3`).
+			WithArgs(oldID, sqlmock.AnyArg(), newID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		replacement, err := service.RotateAPIKey(ctx, oldKey, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, newID, replacement.ID)
+		assert.Equal(t, oldID, *replacement.RotatedFrom)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("concurrent rotation discards the replacement", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, tenant_id, user_id, name, key_type, scopes, is_active`).
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(oldID, serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", nil))
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(newID, time.Now()))
+		mock.ExpectExec(`UPDATE mcp.api_keys\s+SET rotating_until`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM mcp.api_keys WHERE id = \$1`).
+			WithArgs(newID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := service.RotateAPIKey(ctx, oldKey, time.Hour)
+		assert.ErrorIs(t, err, ErrAPIKeyRotating)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rotation by ID is scoped to the tenant", func(t *testing.T) {
+		otherID := uuid.New().String()
+		mock.ExpectQuery(`SELECT id, tenant_id, user_id, name, key_type, scopes, is_active.+WHERE id = \$1 AND tenant_id = \$2`).
+			WithArgs(otherID, uuid.MustParse(serviceAccountTenant)).
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(otherID, serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", nil))
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(newID, time.Now()))
+		mock.ExpectExec(`UPDATE mcp.api_keys\s+SET rotating_until = \$2, rotated_to = \$3`).
+			WithArgs(otherID, sqlmock.AnyArg(), newID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		replacement, err := service.RotateAPIKeyByID(ctx, uuid.MustParse(serviceAccountTenant), otherID, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, otherID, *replacement.RotatedFrom)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	validationColumns := []string{
+		"tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+		"expires_at", "rate_limit", "allowed_services", "rotating_until", "rotated_to_prefix",
+	}
+
+	t.Run("rotating key validates with a hint", func(t *testing.T) {
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WillReturnRows(sqlmock.NewRows(validationColumns).
+				AddRow(serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", time.Now().Add(time.Hour), "agt_newk"))
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		user, err := service.ValidateAPIKey(ctx, oldKey)
+		require.NoError(t, err)
+		assert.Equal(t, "rotating", user.Metadata["key_status"])
+		assert.Equal(t, "agt_newk", user.Metadata["rotated_to"])
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("rotated key past its grace period is rejected", func(t *testing.T) {
+		mock.ExpectQuery(`LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to`).
+			WillReturnRows(sqlmock.NewRows(validationColumns).
+				AddRow(serviceAccountTenant, nil, "deployer", "agent", "{read}", true, nil, 500, "{}", time.Now().Add(-time.Minute), "agt_newk"))
+
+		_, err := service.ValidateAPIKey(ctx, oldKey)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sweep deactivates keys past their grace period", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE mcp.api_keys\s+SET is_active = false\s+WHERE rotating_until IS NOT NULL`).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		swept, err := service.SweepRotatedAPIKeys(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, swept)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestListAPIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("in memory", func(t *testing.T) {
+		service := NewService(DefaultConfig(), nil, nil, observability.NewNoopLogger())
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "deployer",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeAgent,
+		})
+		require.NoError(t, err)
+		_, err = service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "other tenant",
+			TenantID: uuid.New().String(),
+			KeyType:  KeyTypeAgent,
+		})
+		require.NoError(t, err)
+
+		keys, err := service.ListAPIKeys(ctx, uuid.MustParse(serviceAccountTenant))
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, key.ID, keys[0].ID)
+		assert.Equal(t, key.KeyPrefix, keys[0].KeyPrefix)
+		assert.Empty(t, keys[0].Key)
+		assert.Empty(t, keys[0].KeyHash)
+		assert.NotEmpty(t, key.Key, "listing doesn't clear the stored key")
+	})
+
+	t.Run("with database", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = mockDB.Close() }()
+		service := NewService(DefaultConfig(), sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+
+		rotatingUntil := time.Now().Add(time.Hour)
+		oldID := uuid.New().String()
+		newID := uuid.New().String()
+		mock.ExpectQuery(`SELECT id, key_prefix, tenant_id.+FROM mcp.api_keys\s+WHERE tenant_id = \$1`).
+			WithArgs(uuid.MustParse(serviceAccountTenant)).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "key_prefix", "tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+				"expires_at", "created_at", "last_used_at", "rate_limit", "parent_key_id",
+				"allowed_services", "rotating_until", "rotated_to", "rotated_from",
+			}).
+				AddRow(newID, "agt_newk", serviceAccountTenant, nil, "deployer", "agent", "{read}", true,
+					nil, time.Now(), nil, 500, nil, "{}", nil, nil, &oldID).
+				AddRow(oldID, "agt_oldk", serviceAccountTenant, nil, "deployer", "agent", "{read}", true,
+					nil, time.Now().Add(-time.Hour), nil, 500, nil, "{}", rotatingUntil, newID, nil))
+
+		keys, err := service.ListAPIKeys(ctx, uuid.MustParse(serviceAccountTenant))
+		require.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, "agt_newk", keys[0].KeyPrefix)
+		assert.Equal(t, 500, keys[0].RateLimitRequests)
+		require.NotNil(t, keys[0].RotatedFrom)
+		assert.Equal(t, oldID, *keys[0].RotatedFrom)
+		require.NotNil(t, keys[1].RotatedTo)
+		assert.Equal(t, newID, *keys[1].RotatedTo)
+		assert.WithinDuration(t, rotatingUntil, *keys[1].RotatingUntil, time.Second)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRotationHeaders(t *testing.T) {
+	service := NewService(DefaultConfig(), nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	old, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     "deployer",
+		TenantID: serviceAccountTenant,
+		KeyType:  KeyTypeAgent,
+	})
+	require.NoError(t, err)
+	replacement, err := service.RotateAPIKey(ctx, old.Key, time.Hour)
+	require.NoError(t, err)
+
+	handler := service.StandardMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	request := func(apiKey string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Header()
+	}
+
+	headers := request(old.Key)
+	assert.Equal(t, "true", headers.Get("Deprecation"))
+	assert.Equal(t, replacement.KeyPrefix, headers.Get("X-API-Key-Rotated-To"))
+	sunset, err := http.ParseTime(headers.Get("Sunset"))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), sunset, 2*time.Second)
+
+	headers = request(replacement.Key)
+	assert.Empty(t, headers.Get("Deprecation"))
+	assert.Empty(t, headers.Get("Sunset"))
+}
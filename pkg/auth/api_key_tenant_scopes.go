@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ErrScopeExceedsTenantMax is returned when an API key is created with a scope
+// its tenant's keys may not receive
+var ErrScopeExceedsTenantMax = errors.New("scope exceeds the tenant's maximum")
+
+// TenantKeyScopes configures the scopes of a tenant's new API keys
+type TenantKeyScopes struct {
+	// Default is given to keys created without scopes, in place of the key
+	// type's defaults
+	Default []string
+	// Max bounds the scopes a key may receive, with any scope allowed when
+	// empty. A scope is within it when a Max scope covers it under the
+	// tenant's scope hierarchy, so "tools:*" allows "tools:read".
+	Max []string
+}
+
+// newKeyScopes returns the scopes of a new key for a tenant: those requested,
+// else the tenant's defaults, else the key type's. They must be within the
+// tenant's maximum.
+func (s *Service) newKeyScopes(tenantID uuid.UUID, requested []string, keyType KeyType) ([]string, error) {
+	var tenant TenantKeyScopes
+	if s.config != nil {
+		tenant = s.config.TenantKeyScopes[tenantID]
+	}
+
+	scopes := requested
+	if len(scopes) == 0 {
+		scopes = tenant.Default
+	}
+	if len(scopes) == 0 && keyType != "" {
+		scopes = keyType.GetScopes()
+	}
+
+	if len(tenant.Max) > 0 {
+		hierarchy := s.scopeHierarchy(tenantID)
+		for _, scope := range scopes {
+			if !scopeWithin(hierarchy, tenant.Max, scope) {
+				return nil, fmt.Errorf("%w: %s", ErrScopeExceedsTenantMax, scope)
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// scopeWithin reports whether any of the maximum scopes covers a scope
+func scopeWithin(hierarchy ScopeHierarchy, maxScopes []string, scope string) bool {
+	for _, allowed := range maxScopes {
+		if hierarchy.satisfies(allowed, scope) {
+			return true
+		}
+	}
+	return false
+}
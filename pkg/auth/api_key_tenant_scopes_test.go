@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestTenantKeyScopes(t *testing.T) {
+	tenantID := uuid.MustParse(serviceAccountTenant)
+	otherTenant := uuid.New()
+
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	config.TenantKeyScopes = map[uuid.UUID]TenantKeyScopes{
+		tenantID: {
+			Default: []string{"tools:read", "contexts:read"},
+			Max:     []string{"tools:*", "contexts:write"},
+		},
+	}
+	service := NewService(config, nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	t.Run("keys without scopes get the tenant defaults", func(t *testing.T) {
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "ci",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeAgent,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tools:read", "contexts:read"}, key.Scopes)
+
+		user, err := service.ValidateAPIKey(ctx, key.Key)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tools:read", "contexts:read"}, user.Scopes)
+
+		key, err = service.CreateAPIKey(ctx, tenantID, SystemUserID, "legacy", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tools:read", "contexts:read"}, key.Scopes)
+	})
+
+	t.Run("scopes within the tenant max are allowed", func(t *testing.T) {
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "deployer",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeAgent,
+			Scopes:   []string{"tools:github:execute", "contexts:read"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"tools:github:execute", "contexts:read"}, key.Scopes)
+	})
+
+	t.Run("scopes beyond the tenant max are rejected", func(t *testing.T) {
+		_, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "escalated",
+			TenantID: serviceAccountTenant,
+			KeyType:  KeyTypeAgent,
+			Scopes:   []string{"tools:read", "admin"},
+		})
+		assert.ErrorIs(t, err, ErrScopeExceedsTenantMax)
+		assert.Contains(t, err.Error(), "admin")
+
+		_, err = service.CreateAPIKey(ctx, tenantID, SystemUserID, "escalated", []string{"contexts:admin"}, nil)
+		assert.ErrorIs(t, err, ErrScopeExceedsTenantMax)
+	})
+
+	t.Run("other tenants keep the key type defaults", func(t *testing.T) {
+		key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+			Name:     "admin",
+			TenantID: otherTenant.String(),
+			KeyType:  KeyTypeAdmin,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, KeyTypeAdmin.GetScopes(), key.Scopes)
+	})
+}
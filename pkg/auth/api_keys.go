@@ -8,6 +8,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,6 +28,12 @@ type CreateAPIKeyRequest struct {
 	AllowedServices []string `json:"allowed_services,omitempty"`
 	ParentKeyID     *string  `json:"parent_key_id,omitempty"`
 
+	// IP allowlist, as CIDRs or single addresses; empty allows any address
+	AllowedCIDRs []string `json:"allowed_cidrs,omitempty"`
+
+	// RotatedFrom is the ID of the key being replaced, set by rotation
+	RotatedFrom *string `json:"-"`
+
 	// Rate limiting
 	RateLimit *int `json:"rate_limit,omitempty"`
 }
@@ -61,8 +68,8 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 		return nil, fmt.Errorf("failed to generate random key: %w", err)
 	}
 
-	// Create key string: prefix + base64(random)
-	keyString := fmt.Sprintf("%s_%s", generatePrefix(req.KeyType), base64.URLEncoding.EncodeToString(keyBytes))
+	// Create key string: prefix + base64(random), unpadded so it passes key format validation
+	keyString := fmt.Sprintf("%s_%s", generatePrefix(req.KeyType), base64.RawURLEncoding.EncodeToString(keyBytes))
 	keyHash := s.hashAPIKey(keyString)
 	keyPrefix := keyString[:8]
 
@@ -72,21 +79,31 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 		rateLimit = *req.RateLimit
 	}
 
-	// Set default scopes if not provided
-	if len(req.Scopes) == 0 {
-		req.Scopes = req.KeyType.GetScopes()
+	if _, err := parseAllowedCIDRs(req.AllowedCIDRs); err != nil {
+		return nil, err
+	}
+
+	// Set default scopes if not provided, within the tenant's maximum
+	req.Scopes, err = s.newKeyScopes(tenantUUID, req.Scopes, req.KeyType)
+	if err != nil {
+		return nil, err
 	}
 
 	// Insert into database if available
 	if s.db != nil {
+		storedHash, err := s.storedKeyHash(keyString)
+		if err != nil {
+			return nil, err
+		}
+
 		query := `
 			INSERT INTO mcp.api_keys (
 				id, key_hash, key_prefix, tenant_id, user_id, name, key_type,
 				scopes, is_active, expires_at, rate_limit,
-				rate_window, parent_key_id, allowed_services,
+				rate_window, parent_key_id, rotated_from, allowed_services, allowed_cidrs,
 				created_at, updated_at
 			) VALUES (
-				uuid_generate_v4(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $14
+				uuid_generate_v4(), $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $16
 			) RETURNING id, created_at
 		`
 
@@ -99,10 +116,10 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 			userID = sql.NullString{String: req.UserID, Valid: true}
 		}
 
-		err := s.db.QueryRowContext(ctx, query,
-			keyHash, keyPrefix, req.TenantID, userID, req.Name, req.KeyType,
+		err = s.db.QueryRowContext(ctx, query,
+			storedHash, keyPrefix, req.TenantID, userID, req.Name, req.KeyType,
 			pq.Array(req.Scopes), true, req.ExpiresAt, rateLimit, 60,
-			req.ParentKeyID, pq.Array(req.AllowedServices), time.Now(),
+			req.ParentKeyID, req.RotatedFrom, pq.Array(req.AllowedServices), pq.Array(req.AllowedCIDRs), time.Now(),
 		).Scan(&id, &createdAt)
 
 		if err != nil {
@@ -118,6 +135,7 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 		})
 
 		return &APIKey{
+			ID:                     id,
 			Key:                    keyString, // Only returned once
 			KeyPrefix:              keyPrefix,
 			TenantID:               tenantUUID,
@@ -129,7 +147,9 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 			CreatedAt:              createdAt,
 			ExpiresAt:              req.ExpiresAt,
 			AllowedServices:        req.AllowedServices,
+			AllowedCIDRs:           req.AllowedCIDRs,
 			ParentKeyID:            req.ParentKeyID,
+			RotatedFrom:            req.RotatedFrom,
 			RateLimitRequests:      rateLimit,
 			RateLimitWindowSeconds: 60,
 		}, nil
@@ -137,6 +157,7 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 
 	// If no database, store in memory
 	apiKey := &APIKey{
+		ID:                     uuid.New().String(),
 		Key:                    keyString,
 		KeyHash:                keyHash,
 		KeyPrefix:              keyPrefix,
@@ -149,7 +170,9 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 		CreatedAt:              time.Now(),
 		Active:                 true,
 		AllowedServices:        req.AllowedServices,
+		AllowedCIDRs:           req.AllowedCIDRs,
 		ParentKeyID:            req.ParentKeyID,
+		RotatedFrom:            req.RotatedFrom,
 		RateLimitRequests:      rateLimit,
 		RateLimitWindowSeconds: 60,
 	}
@@ -168,7 +191,107 @@ func (s *Service) CreateAPIKeyWithType(ctx context.Context, req CreateAPIKeyRequ
 	return apiKey, nil
 }
 
-// hashAPIKey generates a SHA256 hash of the API key
+// ListAPIKeys returns a tenant's API keys, active or not, newest first. Only
+// key prefixes are returned, never the keys or their hashes.
+func (s *Service) ListAPIKeys(ctx context.Context, tenantID uuid.UUID) ([]*APIKey, error) {
+	var keys []*APIKey
+	listed := make(map[string]bool)
+
+	if s.db != nil {
+		query := `
+			SELECT id, key_prefix, tenant_id, user_id, name, key_type, scopes, is_active,
+			       expires_at, created_at, last_used_at, rate_limit, parent_key_id,
+			       allowed_services, allowed_cidrs, rotating_until, rotated_to, rotated_from
+			FROM mcp.api_keys
+			WHERE tenant_id = $1
+			ORDER BY created_at DESC
+		`
+		var rows []struct {
+			ID              string         `db:"id"`
+			KeyPrefix       string         `db:"key_prefix"`
+			TenantID        uuid.UUID      `db:"tenant_id"`
+			UserID          sql.NullString `db:"user_id"`
+			Name            string         `db:"name"`
+			KeyType         string         `db:"key_type"`
+			Scopes          pq.StringArray `db:"scopes"`
+			Active          bool           `db:"is_active"`
+			ExpiresAt       *time.Time     `db:"expires_at"`
+			CreatedAt       time.Time      `db:"created_at"`
+			LastUsed        *time.Time     `db:"last_used_at"`
+			RateLimit       *int           `db:"rate_limit"`
+			ParentKeyID     *string        `db:"parent_key_id"`
+			AllowedServices pq.StringArray `db:"allowed_services"`
+			AllowedCIDRs    pq.StringArray `db:"allowed_cidrs"`
+			RotatingUntil   *time.Time     `db:"rotating_until"`
+			RotatedTo       *string        `db:"rotated_to"`
+			RotatedFrom     *string        `db:"rotated_from"`
+		}
+		if err := s.db.SelectContext(ctx, &rows, query, tenantID); err != nil {
+			return nil, fmt.Errorf("failed to list API keys: %w", err)
+		}
+
+		for _, row := range rows {
+			key := &APIKey{
+				ID:              row.ID,
+				KeyPrefix:       row.KeyPrefix,
+				TenantID:        row.TenantID,
+				UserID:          SystemUserID,
+				Name:            row.Name,
+				KeyType:         KeyType(row.KeyType),
+				Scopes:          []string(row.Scopes),
+				Active:          row.Active,
+				ExpiresAt:       row.ExpiresAt,
+				CreatedAt:       row.CreatedAt,
+				LastUsed:        row.LastUsed,
+				ParentKeyID:     row.ParentKeyID,
+				AllowedServices: []string(row.AllowedServices),
+				AllowedCIDRs:    []string(row.AllowedCIDRs),
+				RotatingUntil:   row.RotatingUntil,
+				RotatedTo:       row.RotatedTo,
+				RotatedFrom:     row.RotatedFrom,
+			}
+			if row.UserID.Valid {
+				if userID, err := uuid.Parse(row.UserID.String); err == nil {
+					key.UserID = userID
+				}
+			}
+			if row.RateLimit != nil {
+				key.RateLimitRequests = *row.RateLimit
+			}
+			keys = append(keys, key)
+			listed[key.ID] = true
+		}
+	}
+
+	// Keys created in memory or loaded from configuration. Those without an
+	// ID are given one so they can be rotated by ID.
+	var memKeys []*APIKey
+	s.mu.Lock()
+	for apiKey, key := range s.apiKeys {
+		if key.TenantID != tenantID || (key.ID != "" && listed[key.ID]) {
+			continue
+		}
+		if key.ID == "" {
+			key.ID = uuid.New().String()
+		}
+		listedKey := *key
+		listedKey.Key = ""
+		listedKey.KeyHash = ""
+		if listedKey.KeyPrefix == "" {
+			listedKey.KeyPrefix = getKeyPrefix(apiKey)
+		}
+		memKeys = append(memKeys, &listedKey)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(memKeys, func(i, j int) bool {
+		return memKeys[i].CreatedAt.After(memKeys[j].CreatedAt)
+	})
+	return append(keys, memKeys...), nil
+}
+
+// hashAPIKey generates a SHA256 hash of the API key. Keys are stored with
+// storedKeyHash, which may use bcrypt instead.
 func (s *Service) hashAPIKey(apiKey string) string {
 	hasher := sha256.New()
 	hasher.Write([]byte(apiKey))
@@ -201,6 +324,8 @@ func generatePrefix(keyType KeyType) string {
 		return "gw"
 	case KeyTypeAgent:
 		return "agt"
+	case KeyTypeService:
+		return "svc"
 	default:
 		return "usr"
 	}
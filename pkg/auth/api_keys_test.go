@@ -43,7 +43,9 @@ func TestCreateAPIKeyWithType(t *testing.T) {
 						10000,            // rate_limit_requests
 						60,               // rate_limit_window_seconds
 						nil,              // parent_key_id
+						nil,              // rotated_from
 						sqlmock.AnyArg(), // allowed_services
+						sqlmock.AnyArg(), // allowed_cidrs
 						sqlmock.AnyArg(), // created_at/updated_at
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
@@ -82,7 +84,9 @@ func TestCreateAPIKeyWithType(t *testing.T) {
 						5000,             // rate_limit_requests
 						60,               // rate_limit_window_seconds
 						nil,              // parent_key_id
+						nil,              // rotated_from
 						sqlmock.AnyArg(), // allowed_services
+						sqlmock.AnyArg(), // allowed_cidrs
 						sqlmock.AnyArg(), // created_at/updated_at
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
@@ -120,7 +124,9 @@ func TestCreateAPIKeyWithType(t *testing.T) {
 						2000,             // rate_limit_requests (custom)
 						60,               // rate_limit_window_seconds
 						nil,              // parent_key_id
+						nil,              // rotated_from
 						sqlmock.AnyArg(), // allowed_services
+						sqlmock.AnyArg(), // allowed_cidrs
 						sqlmock.AnyArg(), // created_at/updated_at
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
@@ -157,7 +163,9 @@ func TestCreateAPIKeyWithType(t *testing.T) {
 						100,              // rate_limit_requests
 						60,               // rate_limit_window_seconds
 						nil,              // parent_key_id
+						nil,              // rotated_from
 						sqlmock.AnyArg(), // allowed_services
+						sqlmock.AnyArg(), // allowed_cidrs
 						sqlmock.AnyArg(), // created_at/updated_at
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).
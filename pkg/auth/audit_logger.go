@@ -10,16 +10,20 @@ import (
 
 // AuditEvent represents an authentication audit event
 type AuditEvent struct {
-	Timestamp time.Time              `json:"timestamp"`
-	EventType string                 `json:"event_type"`
-	UserID    string                 `json:"user_id,omitempty"`
-	TenantID  string                 `json:"tenant_id,omitempty"`
-	AuthType  string                 `json:"auth_type"`
-	Success   bool                   `json:"success"`
-	IPAddress string                 `json:"ip_address,omitempty"`
-	UserAgent string                 `json:"user_agent,omitempty"`
-	Error     string                 `json:"error,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp     time.Time              `json:"timestamp"`
+	EventType     string                 `json:"event_type"`
+	UserID        string                 `json:"user_id,omitempty"`
+	TenantID      string                 `json:"tenant_id,omitempty"`
+	AuthType      string                 `json:"auth_type"`
+	KeyPrefix     string                 `json:"key_prefix,omitempty"` // Never the raw key
+	Scopes        []string               `json:"scopes,omitempty"`     // Scopes checked by an authorization
+	Success       bool                   `json:"success"`
+	Outcome       string                 `json:"outcome,omitempty"` // AuditOutcomeAllow or AuditOutcomeDeny
+	IPAddress     string                 `json:"ip_address,omitempty"`
+	UserAgent     string                 `json:"user_agent,omitempty"`
+	CorrelationID string                 `json:"correlation_id,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
 // AuditLogger handles authentication audit logging
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// Audited auth decisions, as AuditEvent.EventType
+const (
+	AuditEventAPIKeyValidation   = "api_key_validation"
+	AuditEventJWTValidation      = "jwt_validation"
+	AuditEventScopeAuthorization = "scope_authorization"
+)
+
+// Auth decision outcomes, as AuditEvent.Outcome
+const (
+	AuditOutcomeAllow = "allow"
+	AuditOutcomeDeny  = "deny"
+)
+
+// DefaultAuditStream is the Redis stream auth decisions are recorded to
+const DefaultAuditStream = "auth:audit"
+
+// AuditSink records the outcome of every authentication and authorization
+// decision. Record is called on the request path, so it should be fast and
+// handle its own failures.
+type AuditSink interface {
+	Record(ctx context.Context, event AuditEvent)
+}
+
+// NoopAuditSink discards audit events. It is the default sink.
+type NoopAuditSink struct{}
+
+// Record implements AuditSink
+func (NoopAuditSink) Record(context.Context, AuditEvent) {}
+
+// RedisStreamAuditSink appends audit events to a Redis stream, as JSON in the
+// entry's "event" field, trimming the stream to about maxLen entries
+type RedisStreamAuditSink struct {
+	client redis.UniversalClient
+	stream string
+	maxLen int64
+	logger observability.Logger
+}
+
+// NewRedisStreamAuditSink creates a sink appending to stream, or to
+// DefaultAuditStream when stream is empty. A maxLen of zero keeps every event.
+func NewRedisStreamAuditSink(client redis.UniversalClient, stream string, maxLen int64, logger observability.Logger) *RedisStreamAuditSink {
+	if stream == "" {
+		stream = DefaultAuditStream
+	}
+	return &RedisStreamAuditSink{
+		client: client,
+		stream: stream,
+		maxLen: maxLen,
+		logger: logger,
+	}
+}
+
+// Record implements AuditSink
+func (r *RedisStreamAuditSink) Record(ctx context.Context, event AuditEvent) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = r.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: r.stream,
+			MaxLen: r.maxLen,
+			Approx: r.maxLen > 0,
+			Values: map[string]interface{}{"event": data},
+		}).Err()
+	}
+	if err != nil && r.logger != nil {
+		r.logger.Error("Failed to record auth audit event", map[string]interface{}{
+			"event_type": event.EventType,
+			"outcome":    event.Outcome,
+			"tenant_id":  event.TenantID,
+			"error":      err.Error(),
+		})
+	}
+}
+
+// SetAuditSink sets the sink auth decisions are recorded to
+func (s *Service) SetAuditSink(sink AuditSink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.auditSink = sink
+}
+
+// recordAudit completes an auth decision's audit event from its request
+// context and records it
+func (s *Service) recordAudit(ctx context.Context, event AuditEvent) {
+	s.mu.RLock()
+	sink := s.auditSink
+	s.mu.RUnlock()
+	if sink == nil {
+		return
+	}
+
+	event.Timestamp = time.Now()
+	event.Outcome = AuditOutcomeDeny
+	if event.Success {
+		event.Outcome = AuditOutcomeAllow
+	}
+	event.CorrelationID = observability.GetCorrelationID(ctx)
+	event.IPAddress, _ = ctx.Value(ContextKeyIPAddress).(string)
+	event.UserAgent, _ = ctx.Value(ContextKeyUserAgent).(string)
+	sink.Record(ctx, event)
+}
+
+// auditAuthentication records the outcome of validating an API key or JWT.
+// Only an API key's prefix is recorded.
+func (s *Service) auditAuthentication(ctx context.Context, eventType string, authType Type, keyPrefix string, user *User, err error) {
+	event := AuditEvent{
+		EventType: eventType,
+		AuthType:  string(authType),
+		KeyPrefix: keyPrefix,
+		Success:   err == nil && user != nil,
+	}
+	if user != nil {
+		event.UserID = user.ID.String()
+		event.TenantID = user.TenantID.String()
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	s.recordAudit(ctx, event)
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// recordingAuditSink keeps the audit events it records
+type recordingAuditSink struct {
+	mu     sync.Mutex
+	events []AuditEvent
+}
+
+func (r *recordingAuditSink) Record(_ context.Context, event AuditEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// take returns the events recorded so far and forgets them
+func (r *recordingAuditSink) take() []AuditEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestAuditSink(t *testing.T) {
+	config := DefaultConfig()
+	config.JWTSecret = "audit-test-secret"
+	service := NewService(config, nil, NewTestCache(), observability.NewNoopLogger())
+	sink := &recordingAuditSink{}
+	service.SetAuditSink(sink)
+
+	ctx := observability.WithCorrelationID(context.Background(), "corr-123")
+	ctx = context.WithValue(ctx, ContextKeyIPAddress, "10.0.0.7")
+
+	key, err := service.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     "deployer",
+		TenantID: serviceAccountTenant,
+		KeyType:  KeyTypeAgent,
+		Scopes:   []string{"read"},
+	})
+	require.NoError(t, err)
+
+	t.Run("API key validations are recorded, including cache hits", func(t *testing.T) {
+		_, err := service.ValidateAPIKey(ctx, key.Key)
+		require.NoError(t, err)
+
+		// Only the cache can validate the key now
+		service.mu.Lock()
+		delete(service.apiKeys, key.Key)
+		service.mu.Unlock()
+		user, err := service.ValidateAPIKey(ctx, key.Key)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+
+		events := sink.take()
+		require.Len(t, events, 2)
+		for _, event := range events {
+			assert.Equal(t, AuditEventAPIKeyValidation, event.EventType)
+			assert.Equal(t, AuditOutcomeAllow, event.Outcome)
+			assert.Equal(t, serviceAccountTenant, event.TenantID)
+			assert.Equal(t, key.KeyPrefix, event.KeyPrefix)
+			assert.Equal(t, "corr-123", event.CorrelationID)
+			assert.Equal(t, "10.0.0.7", event.IPAddress)
+			assert.False(t, event.Timestamp.IsZero())
+
+			data, err := json.Marshal(event)
+			require.NoError(t, err)
+			assert.NotContains(t, string(data), key.Key, "the raw key is never recorded")
+		}
+	})
+
+	t.Run("rejected API keys are recorded", func(t *testing.T) {
+		_, err := service.ValidateAPIKey(ctx, "agt_unknownkey0123")
+		require.ErrorIs(t, err, ErrInvalidAPIKey)
+
+		events := sink.take()
+		require.Len(t, events, 1)
+		assert.Equal(t, AuditOutcomeDeny, events[0].Outcome)
+		assert.Equal(t, "agt_unkn", events[0].KeyPrefix)
+		assert.Equal(t, ErrInvalidAPIKey.Error(), events[0].Error)
+	})
+
+	t.Run("JWT validations are recorded", func(t *testing.T) {
+		token, err := service.GenerateJWT(ctx, &User{ID: uuid.New(), TenantID: uuid.MustParse(serviceAccountTenant)})
+		require.NoError(t, err)
+		_, err = service.ValidateJWT(ctx, token)
+		require.NoError(t, err)
+		_, err = service.ValidateJWT(ctx, token+"x")
+		require.Error(t, err)
+
+		events := sink.take()
+		require.Len(t, events, 2)
+		assert.Equal(t, AuditEventJWTValidation, events[0].EventType)
+		assert.Equal(t, AuditOutcomeAllow, events[0].Outcome)
+		assert.Equal(t, serviceAccountTenant, events[0].TenantID)
+		assert.Equal(t, AuditOutcomeDeny, events[1].Outcome)
+	})
+
+	t.Run("scope authorizations are recorded", func(t *testing.T) {
+		user, err := service.ValidateAPIKey(ctx, key.Key)
+		require.NoError(t, err)
+		sink.take()
+
+		require.NoError(t, service.AuthorizeScopesContext(ctx, user, []string{"read"}))
+		require.ErrorIs(t, service.AuthorizeScopesContext(ctx, user, []string{"admin"}), ErrInsufficientScope)
+
+		events := sink.take()
+		require.Len(t, events, 2)
+		assert.Equal(t, AuditEventScopeAuthorization, events[0].EventType)
+		assert.Equal(t, AuditOutcomeAllow, events[0].Outcome)
+		assert.Equal(t, []string{"read"}, events[0].Scopes)
+		assert.Equal(t, key.KeyPrefix, events[0].KeyPrefix)
+		assert.Equal(t, AuditOutcomeDeny, events[1].Outcome)
+		assert.Equal(t, []string{"admin"}, events[1].Scopes)
+		assert.Equal(t, "corr-123", events[1].CorrelationID)
+	})
+}
+
+func TestRedisStreamAuditSink(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	ctx := context.Background()
+
+	sink := NewRedisStreamAuditSink(client, "", 100, observability.NewNoopLogger())
+	sink.Record(ctx, AuditEvent{
+		EventType: AuditEventScopeAuthorization,
+		TenantID:  serviceAccountTenant,
+		Scopes:    []string{"admin"},
+		Outcome:   AuditOutcomeDeny,
+	})
+
+	entries, err := client.XRange(ctx, DefaultAuditStream, "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	var event AuditEvent
+	require.NoError(t, json.Unmarshal([]byte(entries[0].Values["event"].(string)), &event))
+	assert.Equal(t, AuditEventScopeAuthorization, event.EventType)
+	assert.Equal(t, AuditOutcomeDeny, event.Outcome)
+	assert.Equal(t, []string{"admin"}, event.Scopes)
+}
@@ -3,9 +3,8 @@ package auth
 
 import (
 	"context"
-	"crypto/sha256"
+	"crypto"
 	"database/sql"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"regexp"
@@ -33,12 +32,33 @@ var (
 	ErrInsufficientScope  = errors.New("insufficient scope")
 )
 
+// Reasons an API key is invalid. Each matches ErrInvalidAPIKey with errors.Is
+// and has its message, so clients still can't tell whether a key exists.
+var (
+	ErrInvalidAPIKeyFormat error = &invalidAPIKeyError{reason: "invalid format"}
+	ErrAPIKeyNotFound      error = &invalidAPIKeyError{reason: "not found"}
+	ErrAPIKeyExpired       error = &invalidAPIKeyError{reason: "expired"}
+)
+
+// invalidAPIKeyError is a reason for ErrInvalidAPIKey
+type invalidAPIKeyError struct {
+	reason string
+}
+
+func (e *invalidAPIKeyError) Error() string { return ErrInvalidAPIKey.Error() }
+
+func (e *invalidAPIKeyError) Unwrap() error { return ErrInvalidAPIKey }
+
+// Reason describes why the key is invalid, for logs
+func (e *invalidAPIKeyError) Reason() string { return e.reason }
+
 // Type represents the type of authentication
 type Type string
 
 const (
 	TypeAPIKey Type = "api_key"
 	TypeJWT    Type = "jwt"
+	TypeOAuth2 Type = "oauth2" // Signed in through an OAuth2 identity provider
 	TypeNone   Type = "none"
 )
 
@@ -55,10 +75,14 @@ type Claims struct {
 	TenantID string   `json:"tenant_id"` // Keep as string for JWT compatibility
 	Scopes   []string `json:"scopes,omitempty"`
 	Email    string   `json:"email,omitempty"`
+	// KeyPrefix identifies the API key the claims were derived from, as API
+	// key users can share a user ID. It's never read from a token.
+	KeyPrefix string `json:"-"`
 }
 
 // APIKey represents an API key
 type APIKey struct {
+	ID        string     `db:"id"`
 	Key       string     `db:"key"`
 	KeyHash   string     `db:"key_hash"`
 	KeyPrefix string     `db:"key_prefix"`
@@ -76,9 +100,17 @@ type APIKey struct {
 	ParentKeyID     *string  `db:"parent_key_id"`    // NEW
 	AllowedServices []string `db:"allowed_services"` // NEW
 
+	// AllowedCIDRs are the ranges the key may be used from; empty allows any
+	AllowedCIDRs []string `db:"allowed_cidrs"`
+
 	// Rate limiting
 	RateLimitRequests      int `db:"rate_limit"`
 	RateLimitWindowSeconds int `db:"rate_limit_window_seconds"`
+
+	// Rotation
+	RotatingUntil *time.Time `db:"rotating_until"` // End of the grace period once the key is rotated
+	RotatedTo     *string    `db:"rotated_to"`     // ID of the key that replaced this one
+	RotatedFrom   *string    `db:"rotated_from"`   // ID of the key this one replaced
 }
 
 // User represents an authenticated user
@@ -89,6 +121,9 @@ type User struct {
 	Scopes   []string               `json:"scopes,omitempty"`
 	AuthType Type                   `json:"auth_type"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// RateLimit is the API key's remaining request budget, when it has a limit
+	RateLimit *RateLimitStatus `json:"-"`
 }
 
 // ServiceConfig represents auth configuration
@@ -102,6 +137,37 @@ type ServiceConfig struct {
 	CacheTTL          time.Duration
 	MaxFailedAttempts int
 	LockoutDuration   time.Duration
+	RefreshTokenTTL   time.Duration
+
+	// JWTPublicKeys verifies asymmetrically signed tokens, keyed by their kid
+	// header. RSA keys verify RS256 tokens and Ed25519 keys verify EdDSA tokens.
+	// Tokens without a kid are verified with JWTSecret.
+	JWTPublicKeys map[string]crypto.PublicKey
+
+	// JWTSigningKey makes GenerateJWT sign with RS256 or EdDSA instead of
+	// JWTSecret, stamping JWTSigningKeyID as the kid header
+	JWTSigningKey   crypto.Signer
+	JWTSigningKeyID string
+
+	// AutoProvisionTenants runs tenant provisioning hooks when a tenant first
+	// authenticates with an API key
+	AutoProvisionTenants bool
+
+	// HashAlgorithm is how API keys are hashed in the database, defaulting to
+	// SHA-256. With bcrypt, keys still stored as SHA-256 are rehashed when they
+	// next validate.
+	HashAlgorithm KeyHashAlgorithm
+	BcryptCost    int // Defaults to bcrypt.DefaultCost
+
+	// ScopeHierarchy replaces the default scope implications, where admin
+	// implies write, which implies read. TenantScopeHierarchies add a tenant's
+	// own implications to it.
+	ScopeHierarchy         ScopeHierarchy
+	TenantScopeHierarchies map[uuid.UUID]ScopeHierarchy
+
+	// TenantKeyScopes sets the default and maximum scopes of each tenant's
+	// new API keys
+	TenantKeyScopes map[uuid.UUID]TenantKeyScopes
 }
 
 // DefaultConfig returns the default configuration
@@ -115,6 +181,7 @@ func DefaultConfig() *ServiceConfig {
 		CacheTTL:          5 * time.Minute,
 		MaxFailedAttempts: 5,
 		LockoutDuration:   15 * time.Minute,
+		RefreshTokenTTL:   DefaultRefreshTokenTTL,
 	}
 }
 
@@ -126,8 +193,27 @@ type Service struct {
 	logger observability.Logger
 
 	// In-memory storage for development/testing
-	apiKeys map[string]*APIKey
-	mu      sync.RWMutex
+	apiKeys         map[string]*APIKey
+	serviceAccounts map[string]*ServiceAccount // Keyed by API key hash
+	mu              sync.RWMutex
+
+	// Refresh tokens keyed by token hash, and per-user revocation times
+	refreshTokens    map[string]*refreshToken
+	tokenRevocations map[uuid.UUID]time.Time
+
+	// Enforces per-key request limits, defaulting to one backed by the cache
+	apiKeyRateLimiter RateLimiter
+
+	// Records every authentication and authorization decision
+	auditSink AuditSink
+
+	// Parsed API key allowlists, keyed by their CIDRs
+	allowlists sync.Map
+
+	// Tenant auto-provisioning
+	provisioningHooks  []TenantProvisioningHook
+	provisionedTenants map[uuid.UUID]bool
+	provisionMu        sync.Mutex
 }
 
 // NewService creates a new auth service
@@ -137,11 +223,13 @@ func NewService(config *ServiceConfig, db *sqlx.DB, cache cache.Cache, logger ob
 	}
 
 	return &Service{
-		config:  config,
-		db:      db,
-		cache:   cache,
-		logger:  logger,
-		apiKeys: make(map[string]*APIKey),
+		config:          config,
+		db:              db,
+		cache:           cache,
+		logger:          logger,
+		apiKeys:         make(map[string]*APIKey),
+		serviceAccounts: make(map[string]*ServiceAccount),
+		auditSink:       NoopAuditSink{},
 	}
 }
 
@@ -173,8 +261,34 @@ func (s *Service) logError(msg string, fields map[string]interface{}) {
 	}
 }
 
-// ValidateAPIKey validates an API key and returns the associated user
+// ValidateAPIKey validates an API key and returns the associated user. The
+// outcome is recorded to the audit sink, including validations served from
+// the cache.
 func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, error) {
+	user, err := s.validateAPIKey(ctx, apiKey)
+	return s.completeAPIKeyValidation(ctx, apiKey, user, err)
+}
+
+// completeAPIKeyValidation enforces a validated key's allowlist and rate
+// limit, audits the outcome and provisions the key's tenant
+func (s *Service) completeAPIKeyValidation(ctx context.Context, apiKey string, user *User, err error) (*User, error) {
+	if err == nil {
+		err = s.enforceAPIKeyAllowlist(ctx, user)
+	}
+	if err == nil {
+		err = s.enforceAPIKeyRateLimit(ctx, apiKey, user)
+	}
+	s.auditAuthentication(ctx, AuditEventAPIKeyValidation, TypeAPIKey, getKeyPrefix(apiKey), user, err)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ensureTenantProvisioned(ctx, user.TenantID)
+	return user, nil
+}
+
+// validateAPIKey looks up an API key in the cache, memory, and database
+func (s *Service) validateAPIKey(ctx context.Context, apiKey string) (*User, error) {
 	if apiKey == "" {
 		return nil, ErrNoAPIKey
 	}
@@ -182,7 +296,7 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, err
 	// Validate API key format - only allow alphanumeric, dash, and underscore
 	// This prevents any potential injection attacks even though we use parameterized queries
 	if !isValidAPIKeyFormat(apiKey) {
-		return nil, ErrInvalidAPIKey
+		return nil, ErrInvalidAPIKeyFormat
 	}
 
 	s.logDebug("ValidateAPIKey called", map[string]interface{}{
@@ -194,19 +308,18 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, err
 	})
 
 	// Check cache first if enabled
-	if s.config != nil && s.config.CacheEnabled && s.cache != nil {
-		cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
-		var cachedUser User
-		if err := s.cache.Get(ctx, cacheKey, &cachedUser); err == nil {
-			// Return the properly deserialized user from cache
-			cachedUser.AuthType = TypeAPIKey // Ensure auth type is set
-			return &cachedUser, nil
-		}
+	if user, ok := s.cachedAPIKeyUser(ctx, apiKey); ok {
+		return user, nil
 	}
 
 	// Check in-memory storage (for development)
 	s.mu.RLock()
 	key, exists := s.apiKeys[apiKey]
+	if exists {
+		// Rotation and sweeps update keys in place
+		snapshot := *key
+		key = &snapshot
+	}
 	// Always log for debugging auth issues
 	if s.logger != nil {
 		s.logInfo("Checking API key", map[string]interface{}{
@@ -223,103 +336,44 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, err
 			"key_prefix": getKeyPrefix(apiKey),
 		})
 
-		// Hash the API key to match stored hash
-		keyHash := s.hashAPIKey(apiKey)
-
-		// Query database for the API key
-		query := `
-			SELECT tenant_id, user_id, name, key_type, scopes, is_active, 
-			       expires_at, rate_limit, allowed_services
-			FROM mcp.api_keys 
-			WHERE key_hash = $1 AND is_active = true
-		`
-
-		var dbKey struct {
-			TenantID        string         `db:"tenant_id"`
-			UserID          sql.NullString `db:"user_id"`
-			Name            string         `db:"name"`
-			KeyType         string         `db:"key_type"`
-			Scopes          pq.StringArray `db:"scopes"`
-			Active          bool           `db:"is_active"`
-			ExpiresAt       *time.Time     `db:"expires_at"`
-			RateLimit       *int           `db:"rate_limit"`
-			AllowedServices pq.StringArray `db:"allowed_services"`
-		}
-
-		err := s.db.Get(&dbKey, query, keyHash)
+		// Find the hash the API key is stored under
+		keyHash, err := s.lookupKeyHash(ctx, apiKey)
 		if err != nil {
-			if err == sql.ErrNoRows {
+			if errors.Is(err, ErrInvalidAPIKey) {
 				s.logInfo("API key not found in database", map[string]interface{}{
 					"key_prefix": getKeyPrefix(apiKey),
 				})
-				return nil, ErrInvalidAPIKey
+				return nil, err
 			}
 			s.logError("Failed to query API key from database", map[string]interface{}{
 				"error": err.Error(),
 			})
-			return nil, fmt.Errorf("database error: %w", err)
-		}
-
-		// Check expiration
-		if dbKey.ExpiresAt != nil && time.Now().After(*dbKey.ExpiresAt) {
-			s.logInfo("API key expired", map[string]interface{}{
-				"key_prefix": getKeyPrefix(apiKey),
-				"expired_at": dbKey.ExpiresAt.Format(time.RFC3339),
-			})
-			return nil, ErrInvalidAPIKey
+			return nil, err
 		}
 
-		// Build user object
-		var userUUID uuid.UUID
-		if dbKey.UserID.Valid && dbKey.UserID.String != "" {
-			userUUID, _ = uuid.Parse(dbKey.UserID.String)
-		}
-
-		tenantUUID, err := uuid.Parse(dbKey.TenantID)
+		// Query database for the API key
+		var dbKey apiKeyRow
+		err = s.db.Get(&dbKey, apiKeyValidationQuery+`WHERE k.key_hash = $1 AND k.is_active = true`, keyHash)
 		if err != nil {
-			s.logError("Invalid tenant ID in database", map[string]interface{}{
-				"tenant_id": dbKey.TenantID,
-				"error":     err.Error(),
-			})
-			return nil, ErrInvalidAPIKey
-		}
-
-		user := &User{
-			ID:       userUUID,
-			TenantID: tenantUUID,
-			Scopes:   []string(dbKey.Scopes),
-			AuthType: TypeAPIKey,
-			Metadata: map[string]interface{}{
-				"key_type":         dbKey.KeyType,
-				"key_name":         dbKey.Name,
-				"allowed_services": []string(dbKey.AllowedServices),
-			},
-		}
-
-		// Update last used timestamp asynchronously
-		go s.updateLastUsed(ctx, keyHash)
-
-		// Cache the result
-		if s.config.CacheEnabled && s.cache != nil {
-			cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
-			if err := s.cache.Set(ctx, cacheKey, user, s.config.CacheTTL); err != nil {
-				s.logWarn("Failed to cache API key validation", map[string]interface{}{"error": err})
+			if err == sql.ErrNoRows {
+				s.logInfo("API key not found in database", map[string]interface{}{
+					"key_prefix": getKeyPrefix(apiKey),
+				})
+				return nil, ErrAPIKeyNotFound
 			}
+			s.logError("Failed to query API key from database", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, fmt.Errorf("database error: %w", err)
 		}
 
-		s.logInfo("API key validated from database", map[string]interface{}{
-			"key_prefix": getKeyPrefix(apiKey),
-			"tenant_id":  user.TenantID,
-			"key_type":   dbKey.KeyType,
-		})
-
-		return user, nil
+		return s.apiKeyUserFromRow(ctx, apiKey, keyHash, &dbKey)
 	}
 
 	if exists && key.Active {
 		// Check expiration
 		if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
-			return nil, ErrInvalidAPIKey
+			return nil, ErrAPIKeyExpired
 		}
 
 		user := &User{
@@ -330,9 +384,20 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, err
 			Metadata: map[string]interface{}{
 				"key_type":         string(key.KeyType),
 				"key_name":         key.Name,
+				"key_prefix":       getKeyPrefix(apiKey),
 				"allowed_services": key.AllowedServices,
+				"allowed_cidrs":    key.AllowedCIDRs,
 			},
 		}
+		rateLimitMetadata(user.Metadata, key.RateLimitRequests, key.RateLimitWindowSeconds)
+		if key.KeyType == KeyTypeService {
+			s.attachServiceAccount(ctx, user, s.hashAPIKey(apiKey))
+		}
+		if key.RotatingUntil != nil {
+			if err := applyRotationState(user, key.RotatingUntil, s.rotatedToPrefix(*key.RotatedTo)); err != nil {
+				return nil, err
+			}
+		}
 
 		// Update last used timestamp asynchronously
 		go func() {
@@ -345,155 +410,176 @@ func (s *Service) ValidateAPIKey(ctx context.Context, apiKey string) (*User, err
 		}()
 
 		// Cache the result
-		if s.config.CacheEnabled && s.cache != nil {
-			cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
-			// Cache the entire user object for proper retrieval
-			if err := s.cache.Set(ctx, cacheKey, user, s.config.CacheTTL); err != nil {
-				s.logWarn("Failed to cache API key validation", map[string]interface{}{"error": err})
-			}
-		}
+		s.cacheAPIKeyUser(ctx, apiKey, user, s.validationCacheTTL(key.RotatingUntil), key.ParentKeyID)
 
 		return user, nil
 	}
 
-	// Check database if available
-	if s.db != nil {
-		// Hash the API key for database lookup
-		keyHash := s.hashAPIKey(apiKey)
-
-		// Extract key prefix for additional validation
-		keyPrefix := getKeyPrefix(apiKey)
-
-		s.logDebug("Querying database for API key", map[string]interface{}{
-			"key_prefix":   keyPrefix,
-			"key_hash_len": len(keyHash),
-			"db_connected": s.db != nil,
-			"db_type":      fmt.Sprintf("%T", s.db),
-			"key_hash":     keyHash,
-		})
+	// Keys revoked in memory aren't looked up again in the database
+	s.logWarn("API key validation failed", map[string]interface{}{
+		"key_prefix": getKeyPrefix(apiKey),
+		"in_memory":  exists,
+		"has_db":     s.db != nil,
+	})
 
-		var dbKey struct {
-			ID              string         `db:"id"`
-			KeyPrefix       string         `db:"key_prefix"`
-			TenantID        string         `db:"tenant_id"`
-			UserID          *string        `db:"user_id"`
-			Name            string         `db:"name"`
-			KeyType         KeyType        `db:"key_type"`
-			Scopes          pq.StringArray `db:"scopes"`
-			ExpiresAt       *time.Time     `db:"expires_at"`
-			IsActive        bool           `db:"is_active"`
-			ParentKeyID     *string        `db:"parent_key_id"`
-			AllowedServices pq.StringArray `db:"allowed_services"`
-		}
+	return nil, ErrAPIKeyNotFound
+}
 
-		query := `
-			SELECT id, key_prefix, tenant_id, user_id, name, key_type, scopes, 
-			       expires_at, is_active, parent_key_id, allowed_services
-			FROM mcp.api_keys
-			WHERE key_hash = $1 AND key_prefix = $2 AND is_active = true
-		`
-		err := s.db.GetContext(ctx, &dbKey, query, keyHash, keyPrefix)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				s.logDebug("API key not found in database", map[string]interface{}{
-					"key_prefix": keyPrefix,
-					"error":      "no rows",
-				})
-				return nil, ErrInvalidAPIKey
-			}
-			s.logError("Database query error", map[string]interface{}{
-				"error": err.Error(),
-				"query": query,
-			})
-			return nil, fmt.Errorf("database error: %w", err)
-		}
+// apiKeyValidationQuery selects the columns of active API keys that validation
+// needs, along with the prefix of the key each was rotated to. Callers append
+// the WHERE clause.
+const apiKeyValidationQuery = `
+	SELECT k.key_hash, k.tenant_id, k.user_id, k.name, k.key_type, k.scopes, k.is_active,
+	       k.expires_at, k.rate_limit, k.rate_window, k.allowed_services,
+	       k.allowed_cidrs, k.parent_key_id, k.rotating_until, r.key_prefix AS rotated_to_prefix
+	FROM mcp.api_keys k
+	LEFT JOIN mcp.api_keys r ON r.id = k.rotated_to
+`
+
+// apiKeyRow is an API key as selected by apiKeyValidationQuery
+type apiKeyRow struct {
+	KeyHash         string         `db:"key_hash"`
+	TenantID        string         `db:"tenant_id"`
+	UserID          sql.NullString `db:"user_id"`
+	Name            string         `db:"name"`
+	KeyType         string         `db:"key_type"`
+	Scopes          pq.StringArray `db:"scopes"`
+	Active          bool           `db:"is_active"`
+	ExpiresAt       *time.Time     `db:"expires_at"`
+	RateLimit       *int           `db:"rate_limit"`
+	RateWindow      sql.NullString `db:"rate_window"`
+	AllowedServices pq.StringArray `db:"allowed_services"`
+	AllowedCIDRs    pq.StringArray `db:"allowed_cidrs"`
+	ParentKeyID     *string        `db:"parent_key_id"`
+	RotatingUntil   *time.Time     `db:"rotating_until"`
+	RotatedToPrefix sql.NullString `db:"rotated_to_prefix"`
+}
 
-		s.logDebug("API key found in database", map[string]interface{}{
-			"key_prefix": keyPrefix,
-			"tenant_id":  dbKey.TenantID,
-			"is_active":  dbKey.IsActive,
+// apiKeyUserFromRow returns the user of an API key found in the database
+// under keyHash, rehashing the key, recording its use and caching the result
+func (s *Service) apiKeyUserFromRow(ctx context.Context, apiKey, keyHash string, dbKey *apiKeyRow) (*User, error) {
+	// Check expiration
+	if dbKey.ExpiresAt != nil && time.Now().After(*dbKey.ExpiresAt) {
+		s.logInfo("API key expired", map[string]interface{}{
+			"key_prefix": getKeyPrefix(apiKey),
+			"expired_at": dbKey.ExpiresAt.Format(time.RFC3339),
 		})
+		return nil, ErrAPIKeyExpired
+	}
 
-		// Check expiration
-		if dbKey.ExpiresAt != nil && time.Now().After(*dbKey.ExpiresAt) {
-			return nil, ErrInvalidAPIKey
-		}
-
-		// Default user ID if not set - use a system user UUID
-		var userUUID = SystemUserID
-		if dbKey.UserID != nil && *dbKey.UserID != "" {
-			var err error
-			userUUID, err = uuid.Parse(*dbKey.UserID)
-			if err != nil {
-				userUUID = SystemUserID
-			}
-		}
+	// Build user object
+	var userUUID uuid.UUID
+	if dbKey.UserID.Valid && dbKey.UserID.String != "" {
+		userUUID, _ = uuid.Parse(dbKey.UserID.String)
+	}
 
-		tenantUUID, err := uuid.Parse(dbKey.TenantID)
-		if err != nil {
-			s.logError("Invalid tenant ID in database", map[string]interface{}{
-				"tenant_id": dbKey.TenantID,
-				"error":     err.Error(),
-			})
-			return nil, ErrInvalidAPIKey
-		}
+	tenantUUID, err := uuid.Parse(dbKey.TenantID)
+	if err != nil {
+		s.logError("Invalid tenant ID in database", map[string]interface{}{
+			"tenant_id": dbKey.TenantID,
+			"error":     err.Error(),
+		})
+		return nil, ErrInvalidAPIKey
+	}
 
-		user := &User{
-			ID:       userUUID,
-			TenantID: tenantUUID,
-			Scopes:   dbKey.Scopes,
-			AuthType: TypeAPIKey,
-			Metadata: map[string]interface{}{
-				"key_type":         dbKey.KeyType,
-				"key_name":         dbKey.Name,
-				"allowed_services": dbKey.AllowedServices,
-			},
-		}
+	user := &User{
+		ID:       userUUID,
+		TenantID: tenantUUID,
+		Scopes:   []string(dbKey.Scopes),
+		AuthType: TypeAPIKey,
+		Metadata: map[string]interface{}{
+			"key_type":         dbKey.KeyType,
+			"key_name":         dbKey.Name,
+			"key_prefix":       getKeyPrefix(apiKey),
+			"allowed_services": []string(dbKey.AllowedServices),
+			"allowed_cidrs":    []string(dbKey.AllowedCIDRs),
+		},
+	}
+	if dbKey.RateLimit != nil {
+		rateLimitMetadata(user.Metadata, *dbKey.RateLimit, parseRateWindow(dbKey.RateWindow.String))
+	}
+	if KeyType(dbKey.KeyType) == KeyTypeService {
+		s.attachServiceAccount(ctx, user, keyHash)
+	}
+	if err := applyRotationState(user, dbKey.RotatingUntil, dbKey.RotatedToPrefix.String); err != nil {
+		s.logInfo("Rotated API key past its grace period", map[string]interface{}{
+			"key_prefix": getKeyPrefix(apiKey),
+		})
+		return nil, err
+	}
 
-		// Update last used timestamp asynchronously
-		go s.updateLastUsed(context.Background(), keyHash)
+	// Move keys still stored with SHA-256 to bcrypt when configured
+	keyHash = s.rehashAPIKey(ctx, keyHash, apiKey)
 
-		// Cache the result
-		if s.config.CacheEnabled && s.cache != nil {
-			cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
-			// Cache the entire user object for proper retrieval
-			if err := s.cache.Set(ctx, cacheKey, user, s.config.CacheTTL); err != nil {
-				s.logWarn("Failed to cache API key from database", map[string]interface{}{"error": err})
-			}
-		}
+	// Update last used timestamp asynchronously
+	go s.updateLastUsed(ctx, keyHash)
 
-		return user, nil
-	}
+	// Cache the result
+	s.cacheAPIKeyUser(ctx, apiKey, user, s.validationCacheTTL(dbKey.RotatingUntil), dbKey.ParentKeyID)
 
-	s.logWarn("API key validation failed - no database connection", map[string]interface{}{
+	s.logInfo("API key validated from database", map[string]interface{}{
 		"key_prefix": getKeyPrefix(apiKey),
-		"has_db":     s.db != nil,
+		"tenant_id":  user.TenantID,
+		"key_type":   dbKey.KeyType,
 	})
 
-	return nil, ErrInvalidAPIKey
+	return user, nil
+}
+
+// cachedAPIKeyUser returns the cached validation of an API key, if any
+func (s *Service) cachedAPIKeyUser(ctx context.Context, apiKey string) (*User, bool) {
+	if s.config == nil || !s.config.CacheEnabled || s.cache == nil {
+		return nil, false
+	}
+	cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
+	var cachedUser User
+	if err := s.cache.Get(ctx, cacheKey, &cachedUser); err != nil {
+		return nil, false
+	}
+	// Return the properly deserialized user from cache
+	cachedUser.AuthType = TypeAPIKey // Ensure auth type is set
+	return &cachedUser, true
+}
+
+// cacheAPIKeyUser caches the validation of an API key. Child keys' validations
+// aren't cached: revoking an ancestor deactivates them by ID, without the raw
+// keys their cache entries are stored under, so they'd stay valid until the
+// entries expired.
+func (s *Service) cacheAPIKeyUser(ctx context.Context, apiKey string, user *User, ttl time.Duration, parentKeyID *string) {
+	if !s.config.CacheEnabled || s.cache == nil || parentKeyID != nil {
+		return
+	}
+	cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
+	// Cache the entire user object for proper retrieval
+	if err := s.cache.Set(ctx, cacheKey, user, ttl); err != nil {
+		s.logWarn("Failed to cache API key validation", map[string]interface{}{"error": err})
+	}
 }
 
 // storeAPIKeyInDB stores an API key in the database
 func (s *Service) storeAPIKeyInDB(rawKey string, apiKey *APIKey) error {
-	// Hash the API key
-	hasher := sha256.New()
-	hasher.Write([]byte(rawKey))
-	keyHash := hex.EncodeToString(hasher.Sum(nil))
+	ctx := context.Background()
 
 	// Extract key prefix
-	keyPrefix := rawKey
-	if len(keyPrefix) > 8 {
-		keyPrefix = keyPrefix[:8]
-	}
+	keyPrefix := getKeyPrefix(rawKey)
 
 	// Check if key already exists
 	var exists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM mcp.api_keys WHERE key_hash = $1)`
-	if err := s.db.Get(&exists, checkQuery, keyHash); err != nil {
+	keyHash, err := s.lookupKeyHash(ctx, rawKey)
+	switch {
+	case errors.Is(err, ErrInvalidAPIKey):
+	case err != nil:
 		return fmt.Errorf("failed to check existing key: %w", err)
+	default:
+		checkQuery := `SELECT EXISTS(SELECT 1 FROM mcp.api_keys WHERE key_hash = $1)`
+		if err := s.db.Get(&exists, checkQuery, keyHash); err != nil {
+			return fmt.Errorf("failed to check existing key: %w", err)
+		}
 	}
 
 	if exists {
+		keyHash = s.rehashAPIKey(ctx, keyHash, rawKey)
+
 		// Update the existing key
 		updateQuery := `
 			UPDATE mcp.api_keys 
@@ -520,30 +606,35 @@ func (s *Service) storeAPIKeyInDB(rawKey string, apiKey *APIKey) error {
 		userID = sql.NullString{String: apiKey.UserID.String(), Valid: true}
 	}
 
-	_, err := s.db.Exec(insertQuery,
+	keyHash, err = s.storedKeyHash(rawKey)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(insertQuery,
 		keyHash, keyPrefix, apiKey.TenantID, userID,
 		apiKey.Name, apiKey.KeyType, pq.Array(apiKey.Scopes), apiKey.Active, time.Now())
 
 	return err
 }
 
-// ValidateJWT validates a JWT token and returns the associated user
+// ValidateJWT validates a JWT token and returns the associated user. The
+// outcome is recorded to the audit sink.
 func (s *Service) ValidateJWT(ctx context.Context, tokenString string) (*User, error) {
-	if tokenString == "" || s.config == nil || s.config.JWTSecret == "" {
+	user, err := s.validateJWT(ctx, tokenString)
+	s.auditAuthentication(ctx, AuditEventJWTValidation, TypeJWT, "", user, err)
+	return user, err
+}
+
+// validateJWT verifies a JWT and builds its user from the claims
+func (s *Service) validateJWT(ctx context.Context, tokenString string) (*User, error) {
+	if tokenString == "" || s.config == nil {
 		return nil, ErrInvalidToken
 	}
 
-	// Parse the token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify the signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWTSecret), nil
-	})
-
+	// Parse the token, verifying it with the key named by its kid header
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, s.jwtVerificationKey)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
 
 	// Validate claims
@@ -563,6 +654,15 @@ func (s *Service) ValidateJWT(ctx context.Context, tokenString string) (*User, e
 		return nil, fmt.Errorf("invalid user ID in JWT: %w", err)
 	}
 
+	// Reject tokens issued before the user's tokens were revoked
+	revokedBefore, err := s.tokensRevokedBefore(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !revokedBefore.IsZero() && (claims.IssuedAt == nil || claims.IssuedAt.Before(revokedBefore)) {
+		return nil, ErrTokenRevoked
+	}
+
 	tenantID, err := uuid.Parse(claims.TenantID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid tenant ID in JWT: %w", err)
@@ -582,7 +682,7 @@ func (s *Service) ValidateJWT(ctx context.Context, tokenString string) (*User, e
 
 // GenerateJWT generates a new JWT token for a user
 func (s *Service) GenerateJWT(ctx context.Context, user *User) (string, error) {
-	if s.config.JWTSecret == "" {
+	if s.config.JWTSecret == "" && s.config.JWTSigningKey == nil {
 		return "", errors.New("JWT secret not configured")
 	}
 
@@ -600,12 +700,31 @@ func (s *Service) GenerateJWT(ctx context.Context, user *User) (string, error) {
 		Scopes:   user.Scopes,
 	}
 
+	if s.config.JWTSigningKey != nil {
+		if s.config.JWTSigningKeyID == "" {
+			return "", errors.New("JWT signing key ID not configured")
+		}
+		method, err := jwtSigningMethod(s.config.JWTSigningKey)
+		if err != nil {
+			return "", fmt.Errorf("JWT signing key: %w", err)
+		}
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = s.config.JWTSigningKeyID
+		return token.SignedString(s.config.JWTSigningKey)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.config.JWTSecret))
 }
 
 // CreateAPIKey creates a new API key
 func (s *Service) CreateAPIKey(ctx context.Context, tenantID, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*APIKey, error) {
+	// Use the tenant's default scopes if none are given
+	scopes, err := s.newKeyScopes(tenantID, scopes, "")
+	if err != nil {
+		return nil, err
+	}
+
 	// Generate a secure random key
 	keyStr := generateAPIKey() // You would implement this
 
@@ -643,46 +762,96 @@ func (s *Service) CreateAPIKey(ctx context.Context, tenantID, userID uuid.UUID,
 	return apiKey, nil
 }
 
-// RevokeAPIKey revokes an API key
+// RevokeAPIKey revokes an API key along with the child keys created from it
+// with CreateChildKey, and their children. A key that replaced it in a
+// rotation stays valid.
 func (s *Service) RevokeAPIKey(ctx context.Context, apiKey string) error {
 	// Remove from memory
 	s.mu.Lock()
-	delete(s.apiKeys, apiKey)
+	revoked := s.revokeInMemory(apiKey)
 	s.mu.Unlock()
 
 	// Update in database if available
 	if s.db != nil {
-		query := `UPDATE api_keys SET active = false WHERE key = $1`
-		_, err := s.db.ExecContext(ctx, query, apiKey)
-		if err != nil {
+		keyHash, err := s.lookupKeyHash(ctx, apiKey)
+		switch {
+		case errors.Is(err, ErrInvalidAPIKey):
+		case err != nil:
 			return fmt.Errorf("failed to revoke API key: %w", err)
+		default:
+			count, err := s.revokeAPIKeyInDB(ctx, keyHash)
+			if err != nil {
+				return fmt.Errorf("failed to revoke API key: %w", err)
+			}
+			s.logInfo("API key revoked", map[string]interface{}{
+				"key_prefix": getKeyPrefix(apiKey),
+				"revoked":    count,
+			})
 		}
 	}
 
-	// Remove from cache
+	// Remove from cache. Child keys' validations aren't cached, so revoked
+	// descendants known only by ID don't need removing.
 	if s.config.CacheEnabled && s.cache != nil {
-		cacheKey := fmt.Sprintf("auth:apikey:%s", apiKey)
-		if err := s.cache.Delete(ctx, cacheKey); err != nil {
-			s.logWarn("Failed to delete API key from cache", map[string]interface{}{"error": err})
+		if len(revoked) == 0 {
+			revoked = []string{apiKey}
+		}
+		for _, key := range revoked {
+			cacheKey := fmt.Sprintf("auth:apikey:%s", key)
+			if err := s.cache.Delete(ctx, cacheKey); err != nil {
+				s.logWarn("Failed to delete API key from cache", map[string]interface{}{"error": err})
+			}
 		}
 	}
 
 	return nil
 }
 
-// AuthorizeScopes checks if a user has the required scopes
+// AuthorizeScopes checks if a user has the required scopes. Granted scopes
+// are expanded through the tenant's scope implications, then cover required
+// ones through the scope hierarchy and wildcards; see ScopeHierarchy and ScopeSet.
 func (s *Service) AuthorizeScopes(user *User, requiredScopes []string) error {
+	return s.AuthorizeScopesContext(context.Background(), user, requiredScopes)
+}
+
+// AuthorizeScopesContext is AuthorizeScopes for a request, whose context
+// identifies the request in the audited decision
+func (s *Service) AuthorizeScopesContext(ctx context.Context, user *User, requiredScopes []string) error {
 	if len(requiredScopes) == 0 {
 		return nil // No scopes required
 	}
 
-	userScopeMap := make(map[string]bool)
-	for _, scope := range user.Scopes {
-		userScopeMap[scope] = true
+	err := s.authorizeScopes(user, requiredScopes)
+	event := AuditEvent{
+		EventType: AuditEventScopeAuthorization,
+		UserID:    user.ID.String(),
+		TenantID:  user.TenantID.String(),
+		AuthType:  string(user.AuthType),
+		Scopes:    requiredScopes,
+		Success:   err == nil,
+	}
+	if prefix, ok := user.Metadata["key_prefix"].(string); ok {
+		event.KeyPrefix = prefix
+	}
+	if err != nil {
+		event.Error = err.Error()
 	}
+	s.recordAudit(ctx, event)
+	return err
+}
 
+// authorizeScopes checks the granted scopes of a user cover the required ones
+func (s *Service) authorizeScopes(user *User, requiredScopes []string) error {
+	hierarchy := s.scopeHierarchy(user.TenantID)
 	for _, required := range requiredScopes {
-		if !userScopeMap[required] {
+		satisfied := false
+		for _, granted := range user.Scopes {
+			if hierarchy.satisfies(granted, required) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
 			return ErrInsufficientScope
 		}
 	}
@@ -869,17 +1038,48 @@ func (s *Service) InitializeAPIKeysWithConfig(keysConfig map[string]interface{})
 
 // AddAPIKey adds an API key to the service at runtime (thread-safe)
 func (s *Service) AddAPIKey(key string, settings APIKeySettings) error {
+	apiKey, err := newAPIKeyFromSettings(key, settings)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Store in memory
+	s.apiKeys[key] = apiKey
+
+	// Persist to database if available
+	if s.db != nil {
+		if err := s.persistAPIKey(context.Background(), apiKey); err != nil {
+			// Log but don't fail - memory storage sufficient for operation
+			s.logWarn("Failed to persist API key", map[string]interface{}{
+				"key_suffix": lastN(key, 4),
+				"error":      err.Error(),
+			})
+		}
+	}
+
+	s.logInfo("API key added", map[string]interface{}{
+		"key_suffix": lastN(key, 4),
+		"role":       settings.Role,
+		"scopes":     settings.Scopes,
+		"tenant_id":  apiKey.TenantID,
+	})
+
+	return nil
+}
+
+// newAPIKeyFromSettings validates settings and builds the API key they describe
+func newAPIKeyFromSettings(key string, settings APIKeySettings) (*APIKey, error) {
 	// Validation
 	if key == "" {
-		return fmt.Errorf("API key cannot be empty")
+		return nil, fmt.Errorf("API key cannot be empty")
 	}
 	if len(key) < 16 {
-		return fmt.Errorf("API key too short (minimum 16 characters)")
+		return nil, fmt.Errorf("API key too short (minimum 16 characters)")
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	// Parse tenant ID
 	var tenantUUID uuid.UUID
 	if settings.TenantID == "" {
@@ -888,7 +1088,7 @@ func (s *Service) AddAPIKey(key string, settings APIKeySettings) error {
 		var err error
 		tenantUUID, err = uuid.Parse(settings.TenantID)
 		if err != nil {
-			return fmt.Errorf("invalid tenant ID: %w", err)
+			return nil, fmt.Errorf("invalid tenant ID: %w", err)
 		}
 	}
 
@@ -910,41 +1110,25 @@ func (s *Service) AddAPIKey(key string, settings APIKeySettings) error {
 	if settings.ExpiresIn != "" {
 		duration, err := time.ParseDuration(settings.ExpiresIn)
 		if err != nil {
-			return fmt.Errorf("invalid expiration duration %q: %w", settings.ExpiresIn, err)
+			return nil, fmt.Errorf("invalid expiration duration %q: %w", settings.ExpiresIn, err)
 		}
 		if duration < 0 {
-			return fmt.Errorf("expiration duration cannot be negative")
+			return nil, fmt.Errorf("expiration duration cannot be negative")
 		}
 		expiresAt := time.Now().Add(duration)
 		apiKey.ExpiresAt = &expiresAt
 	}
 
-	// Store in memory
-	s.apiKeys[key] = apiKey
-
-	// Persist to database if available
-	if s.db != nil {
-		if err := s.persistAPIKey(context.Background(), apiKey); err != nil {
-			// Log but don't fail - memory storage sufficient for operation
-			s.logWarn("Failed to persist API key", map[string]interface{}{
-				"key_suffix": lastN(key, 4),
-				"error":      err.Error(),
-			})
-		}
-	}
-
-	s.logInfo("API key added", map[string]interface{}{
-		"key_suffix": lastN(key, 4),
-		"role":       settings.Role,
-		"scopes":     settings.Scopes,
-		"tenant_id":  apiKey.TenantID,
-	})
-
-	return nil
+	return apiKey, nil
 }
 
 // persistAPIKey saves to database with upsert semantics
 func (s *Service) persistAPIKey(ctx context.Context, apiKey *APIKey) error {
+	return persistAPIKeyWith(ctx, s.db, apiKey)
+}
+
+// persistAPIKeyWith upserts an API key using db, which may be a transaction
+func persistAPIKeyWith(ctx context.Context, db sqlx.ExecerContext, apiKey *APIKey) error {
 	query := `
         INSERT INTO api_keys (
             key, tenant_id, user_id, name, scopes, 
@@ -957,12 +1141,12 @@ func (s *Service) persistAPIKey(ctx context.Context, apiKey *APIKey) error {
             updated_at = NOW()
     `
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		apiKey.Key,
 		apiKey.TenantID,
 		apiKey.UserID,
 		apiKey.Name,
-		apiKey.Scopes,
+		pq.Array(apiKey.Scopes),
 		apiKey.ExpiresAt,
 		apiKey.CreatedAt,
 		apiKey.Active,
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -20,13 +21,13 @@ const (
 // AuthMiddleware wraps the auth service with production features
 type AuthMiddleware struct {
 	service     *Service
-	rateLimiter *RateLimiter
+	rateLimiter *AttemptRateLimiter
 	metrics     *MetricsCollector
 	audit       *AuditLogger
 }
 
 // NewAuthMiddleware creates middleware with rate limiting and metrics
-func NewAuthMiddleware(service *Service, rateLimiter *RateLimiter, metrics *MetricsCollector, audit *AuditLogger) *AuthMiddleware {
+func NewAuthMiddleware(service *Service, rateLimiter *AttemptRateLimiter, metrics *MetricsCollector, audit *AuditLogger) *AuthMiddleware {
 	return &AuthMiddleware{
 		service:     service,
 		rateLimiter: rateLimiter,
@@ -68,8 +69,11 @@ func (m *AuthMiddleware) ValidateAPIKeyWithMetrics(ctx context.Context, apiKey s
 	success := err == nil
 	m.metrics.RecordAuthAttempt(ctx, "api_key", success, duration)
 
-	// Record attempt for rate limiting
-	m.rateLimiter.RecordAttempt(ctx, identifier, success)
+	// Record attempt for rate limiting. A key over its request limit is
+	// valid, so it doesn't count toward a lockout.
+	if !errors.Is(err, ErrRateLimited) {
+		m.rateLimiter.RecordAttempt(ctx, identifier, success)
+	}
 
 	// Audit log
 	auditEvent := AuditEvent{
@@ -162,14 +166,18 @@ func (m *AuthMiddleware) GinMiddleware() gin.HandlerFunc {
 		var user *User
 		var err error
 
+		// Add context with enhanced values
+		ctx := context.WithValue(c.Request.Context(), ContextKeyIPAddress, c.ClientIP())
+		ctx = context.WithValue(ctx, ContextKeyUserAgent, c.Request.UserAgent())
+
 		// Try API key first
 		authHeader := c.GetHeader("Authorization")
 		if authHeader != "" {
 			if strings.HasPrefix(authHeader, "Bearer ") {
 				apiKey := strings.TrimPrefix(authHeader, "Bearer ")
-				user, err = m.service.ValidateAPIKey(c.Request.Context(), apiKey)
+				user, err = m.service.ValidateAPIKey(ctx, apiKey)
 			} else {
-				user, err = m.service.ValidateAPIKey(c.Request.Context(), authHeader)
+				user, err = m.service.ValidateAPIKey(ctx, authHeader)
 			}
 		}
 
@@ -177,17 +185,29 @@ func (m *AuthMiddleware) GinMiddleware() gin.HandlerFunc {
 		if user == nil && m.service.config.APIKeyHeader != "" {
 			apiKey := c.GetHeader(m.service.config.APIKeyHeader)
 			if apiKey != "" {
-				// Add context with enhanced values
-				ctx := context.WithValue(c.Request.Context(), ContextKeyIPAddress, c.ClientIP())
-				ctx = context.WithValue(ctx, ContextKeyUserAgent, c.Request.UserAgent())
 				user, err = m.ValidateAPIKeyWithMetrics(ctx, apiKey)
 			}
 		}
 
+		// A valid key over its request limit or outside its allowlist isn't
+		// retried as a JWT
+		var rateLimitErr *RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			writeRateLimitExceededHeaders(c.Writer.Header(), rateLimitErr)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+			c.Abort()
+			return
+		}
+		if errors.Is(err, ErrIPNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API key not allowed from this IP address"})
+			c.Abort()
+			return
+		}
+
 		// Try JWT if API key failed
 		if user == nil && authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 			token := strings.TrimPrefix(authHeader, "Bearer ")
-			user, err = m.service.ValidateJWT(c.Request.Context(), token)
+			user, err = m.service.ValidateJWT(ctx, token)
 		}
 
 		// Handle authentication failure
@@ -211,6 +231,9 @@ func (m *AuthMiddleware) GinMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		writeRateLimitHeaders(c.Writer.Header(), user.RateLimit)
+		writeRotationHeaders(c.Writer.Header(), user)
+
 		// Store user in context
 		c.Set(string(UserContextKey), user)
 
@@ -332,7 +332,7 @@ func TestAuthenticationIntegration(t *testing.T) {
 	baseService := auth.NewService(config, nil, testCache, logger)
 
 	// Create rate limiter
-	rateLimiter := auth.NewRateLimiter(testCache, logger, &auth.RateLimiterConfig{
+	rateLimiter := auth.NewAttemptRateLimiter(testCache, logger, &auth.RateLimiterConfig{
 		Enabled:       true,
 		MaxAttempts:   3,
 		WindowSize:    1 * time.Minute,
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwtVerificationKey selects the key that verifies a token. Tokens with a kid
+// header are verified with the matching public key, using RS256 for RSA keys and
+// EdDSA for Ed25519 keys. Tokens without one fall back to the shared secret.
+func (s *Service) jwtVerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, hasKid := token.Header["kid"]
+	if !hasKid {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v for a token without a key ID", token.Header["alg"])
+		}
+		if s.config.JWTSecret == "" {
+			return nil, errors.New("JWT secret not configured")
+		}
+		return []byte(s.config.JWTSecret), nil
+	}
+
+	keyID, ok := kid.(string)
+	if !ok || keyID == "" {
+		return nil, fmt.Errorf("malformed key ID %v", kid)
+	}
+	key, ok := s.jwtPublicKey(keyID)
+	if !ok {
+		return nil, fmt.Errorf("unknown key ID %q", keyID)
+	}
+
+	method, err := jwtSigningMethod(key)
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", keyID, err)
+	}
+	if token.Method.Alg() != method.Alg() {
+		return nil, fmt.Errorf("signing method %v doesn't match key %q (%s)", token.Header["alg"], keyID, method.Alg())
+	}
+	return key, nil
+}
+
+// jwtPublicKey looks up a verification key by ID. The public half of the
+// configured signing key is included so the service can verify its own tokens.
+func (s *Service) jwtPublicKey(keyID string) (crypto.PublicKey, bool) {
+	if key, ok := s.config.JWTPublicKeys[keyID]; ok {
+		return key, true
+	}
+	if s.config.JWTSigningKey != nil && s.config.JWTSigningKeyID == keyID {
+		return s.config.JWTSigningKey.Public(), true
+	}
+	return nil, false
+}
+
+// jwtSigningMethod returns the signing method for an asymmetric key, private or public
+func jwtSigningMethod(key interface{}) (jwt.SigningMethod, error) {
+	switch key.(type) {
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
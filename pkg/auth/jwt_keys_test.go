@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+const jwtKeysTestSecret = "jwt-keys-test-secret"
+
+func newJWTKeysTestService(publicKeys map[string]crypto.PublicKey) *Service {
+	config := DefaultConfig()
+	config.JWTSecret = jwtKeysTestSecret
+	config.JWTPublicKeys = publicKeys
+	return NewService(config, nil, nil, observability.NewNoopLogger())
+}
+
+// signTestJWT signs claims for user with the given method, key and optional kid
+func signTestJWT(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, user *User) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(method, &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		UserID:   user.ID.String(),
+		TenantID: user.TenantID.String(),
+		Scopes:   user.Scopes,
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestValidateJWTWithPublicKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherRSAKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	service := newJWTKeysTestService(map[string]crypto.PublicKey{
+		"idp-rsa": &rsaKey.PublicKey,
+		"idp-ed":  edPublic,
+	})
+	user := refreshTokenTestUser()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantErr    bool
+		wantReason string
+	}{
+		{name: "RS256 with known kid", token: signTestJWT(t, jwt.SigningMethodRS256, rsaKey, "idp-rsa", user)},
+		{name: "EdDSA with known kid", token: signTestJWT(t, jwt.SigningMethodEdDSA, edPrivate, "idp-ed", user)},
+		{name: "HS256 without kid uses the shared secret", token: signTestJWT(t, jwt.SigningMethodHS256, []byte(jwtKeysTestSecret), "", user)},
+		{
+			name:       "unknown kid",
+			token:      signTestJWT(t, jwt.SigningMethodRS256, rsaKey, "rotated-away", user),
+			wantErr:    true,
+			wantReason: `unknown key ID "rotated-away"`,
+		},
+		{
+			name:       "signature from a different key",
+			token:      signTestJWT(t, jwt.SigningMethodRS256, otherRSAKey, "idp-rsa", user),
+			wantErr:    true,
+			wantReason: "verification error",
+		},
+		{
+			name:       "algorithm doesn't match the key",
+			token:      signTestJWT(t, jwt.SigningMethodEdDSA, edPrivate, "idp-rsa", user),
+			wantErr:    true,
+			wantReason: "doesn't match key",
+		},
+		{
+			name:       "HMAC token naming a public key",
+			token:      signTestJWT(t, jwt.SigningMethodHS256, []byte(jwtKeysTestSecret), "idp-rsa", user),
+			wantErr:    true,
+			wantReason: "doesn't match key",
+		},
+		{
+			name:       "asymmetric token without kid",
+			token:      signTestJWT(t, jwt.SigningMethodRS256, rsaKey, "", user),
+			wantErr:    true,
+			wantReason: "without a key ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validated, err := service.ValidateJWT(context.Background(), tt.token)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidToken)
+				assert.Contains(t, err.Error(), tt.wantReason)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, validated.ID)
+			assert.Equal(t, user.TenantID, validated.TenantID)
+			assert.Equal(t, user.Scopes, validated.Scopes)
+		})
+	}
+}
+
+func TestGenerateJWTWithSigningKey(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     crypto.Signer
+		keyID   string
+		wantAlg string
+		wantErr bool
+	}{
+		{name: "RSA key signs RS256", key: rsaKey, keyID: "mesh-rsa", wantAlg: "RS256"},
+		{name: "Ed25519 key signs EdDSA", key: edPrivate, keyID: "mesh-ed", wantAlg: "EdDSA"},
+		{name: "no signing key falls back to HS256", wantAlg: "HS256"},
+		{name: "signing key without an ID", key: edPrivate, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newJWTKeysTestService(nil)
+			service.config.JWTSigningKey = tt.key
+			service.config.JWTSigningKeyID = tt.keyID
+			user := refreshTokenTestUser()
+
+			signed, err := service.GenerateJWT(context.Background(), user)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantAlg, token.Header["alg"])
+			if tt.keyID != "" {
+				assert.Equal(t, tt.keyID, token.Header["kid"])
+			} else {
+				assert.NotContains(t, token.Header, "kid")
+			}
+
+			validated, err := service.ValidateJWT(context.Background(), signed)
+			require.NoError(t, err, "the service verifies its own tokens")
+			assert.Equal(t, user.ID, validated.ID)
+		})
+	}
+}
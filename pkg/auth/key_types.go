@@ -13,12 +13,13 @@ const (
 	KeyTypeAdmin   KeyType = "admin"   // Full system access
 	KeyTypeAgent   KeyType = "agent"   // AI agents
 	KeyTypeGateway KeyType = "gateway" // Local MCP instances
+	KeyTypeService KeyType = "service" // Service accounts (machine-to-machine)
 )
 
 // Valid returns true if the key type is valid
 func (kt KeyType) Valid() bool {
 	switch kt {
-	case KeyTypeUser, KeyTypeAdmin, KeyTypeAgent, KeyTypeGateway:
+	case KeyTypeUser, KeyTypeAdmin, KeyTypeAgent, KeyTypeGateway, KeyTypeService:
 		return true
 	default:
 		return false
@@ -79,7 +80,7 @@ func (kt KeyType) GetRateLimit() int {
 		return 10000
 	case KeyTypeGateway:
 		return 5000
-	case KeyTypeAgent:
+	case KeyTypeAgent, KeyTypeService:
 		return 1000
 	default:
 		return 100
@@ -2,8 +2,11 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -28,6 +31,10 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 		var user *User
 		var err error
 
+		// API keys bound to an allowlist are checked against the caller's address
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ContextKeyIPAddress, c.ClientIP()))
+		ctx := c.Request.Context()
+
 		// Try each auth type in order
 		for _, authType := range authTypes {
 			switch authType {
@@ -38,10 +45,10 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 					// Handle "Bearer <token>" format
 					if strings.HasPrefix(authHeader, "Bearer ") {
 						apiKey := strings.TrimPrefix(authHeader, "Bearer ")
-						user, err = s.ValidateAPIKey(c.Request.Context(), apiKey)
+						user, err = s.ValidateAPIKey(ctx, apiKey)
 					} else {
 						// Direct API key
-						user, err = s.ValidateAPIKey(c.Request.Context(), authHeader)
+						user, err = s.ValidateAPIKey(ctx, authHeader)
 					}
 				}
 
@@ -49,7 +56,7 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 				if user == nil && s.config.APIKeyHeader != "" {
 					apiKey := c.GetHeader(s.config.APIKeyHeader)
 					if apiKey != "" {
-						user, err = s.ValidateAPIKey(c.Request.Context(), apiKey)
+						user, err = s.ValidateAPIKey(ctx, apiKey)
 					}
 				}
 
@@ -57,7 +64,7 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 				authHeader := c.GetHeader("Authorization")
 				if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 					token := strings.TrimPrefix(authHeader, "Bearer ")
-					user, err = s.ValidateJWT(c.Request.Context(), token)
+					user, err = s.ValidateJWT(ctx, token)
 				}
 
 			case TypeNone:
@@ -66,14 +73,29 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 				return
 			}
 
-			// If we found a valid user, break out of the loop
-			if user != nil {
+			// If we found a valid user, break out of the loop. A valid key
+			// over its request limit or outside its allowlist isn't retried
+			// with other auth types.
+			if user != nil || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrIPNotAllowed) {
 				break
 			}
 		}
 
 		// If no valid authentication found
 		if user == nil {
+			var rateLimitErr *RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				writeRateLimitExceededHeaders(c.Writer.Header(), rateLimitErr)
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+				c.Abort()
+				return
+			}
+			if errors.Is(err, ErrIPNotAllowed) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "API key not allowed from this IP address"})
+				c.Abort()
+				return
+			}
+
 			s.logger.Warn("Authentication failed", map[string]interface{}{
 				"error": err,
 				"ip":    c.ClientIP(),
@@ -86,6 +108,8 @@ func (s *Service) GinMiddleware(authTypes ...Type) gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		writeRateLimitHeaders(c.Writer.Header(), user.RateLimit)
+		writeRotationHeaders(c.Writer.Header(), user)
 
 		// Store user in context
 		c.Set(string(UserContextKey), user)
@@ -138,7 +162,7 @@ func (s *Service) RequireScopes(scopes ...string) gin.HandlerFunc {
 		}
 
 		// Check scopes
-		if err := s.AuthorizeScopes(user, scopes); err != nil {
+		if err := s.AuthorizeScopesContext(c.Request.Context(), user, scopes); err != nil {
 			s.logger.Warn("Authorization failed", map[string]interface{}{
 				"user_id":         user.ID,
 				"required_scopes": scopes,
@@ -205,6 +229,12 @@ func (s *Service) StandardMiddleware(authTypes ...Type) func(http.Handler) http.
 			var user *User
 			var err error
 
+			// API keys bound to an allowlist are checked against the caller's
+			// address, unless HTTPAuthMiddleware has already resolved it
+			if ip, _ := r.Context().Value(ContextKeyIPAddress).(string); ip == "" {
+				r = r.WithContext(context.WithValue(r.Context(), ContextKeyIPAddress, r.RemoteAddr))
+			}
+
 			// Try each auth type in order
 			for _, authType := range authTypes {
 				switch authType {
@@ -243,14 +273,27 @@ func (s *Service) StandardMiddleware(authTypes ...Type) func(http.Handler) http.
 					return
 				}
 
-				// If we found a valid user, break
-				if user != nil {
+				// If we found a valid user, break. A valid key over its
+				// request limit or outside its allowlist isn't retried with
+				// other auth types.
+				if user != nil || errors.Is(err, ErrRateLimited) || errors.Is(err, ErrIPNotAllowed) {
 					break
 				}
 			}
 
 			// If no valid authentication found
 			if user == nil {
+				var rateLimitErr *RateLimitError
+				if errors.As(err, &rateLimitErr) {
+					writeRateLimitExceededHeaders(w.Header(), rateLimitErr)
+					http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+					return
+				}
+				if errors.Is(err, ErrIPNotAllowed) {
+					http.Error(w, "API key not allowed from this IP address", http.StatusForbidden)
+					return
+				}
+
 				s.logger.Warn("Authentication failed", map[string]interface{}{
 					"error": err,
 					"ip":    r.RemoteAddr,
@@ -260,6 +303,8 @@ func (s *Service) StandardMiddleware(authTypes ...Type) func(http.Handler) http.
 				http.Error(w, "Authentication required", http.StatusUnauthorized)
 				return
 			}
+			writeRateLimitHeaders(w.Header(), user.RateLimit)
+			writeRotationHeaders(w.Header(), user)
 
 			// Store user in request context
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
@@ -275,3 +320,38 @@ func GetUserFromRequest(r *http.Request) (*User, bool) {
 	user, ok := r.Context().Value(UserContextKey).(*User)
 	return user, ok
 }
+
+// writeRateLimitHeaders reports an API key's remaining request budget
+func writeRateLimitHeaders(h http.Header, status *RateLimitStatus) {
+	if status == nil {
+		return
+	}
+	h.Set("X-RateLimit-Limit", strconv.Itoa(status.Limit))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+}
+
+// writeRateLimitExceededHeaders tells a client over its API key's limit when to retry
+func writeRateLimitExceededHeaders(h http.Header, err *RateLimitError) {
+	h.Set("X-RateLimit-Limit", strconv.Itoa(err.Limit))
+	h.Set("X-RateLimit-Remaining", "0")
+	h.Set("X-RateLimit-Reset", strconv.FormatInt(err.ResetAt.Unix(), 10))
+	h.Set("Retry-After", strconv.Itoa(err.RetryAfter()))
+}
+
+// writeRotationHeaders tells a client authenticating with a rotated API key
+// that the key is deprecated, when it stops working and which key replaced it
+func writeRotationHeaders(h http.Header, user *User) {
+	if user.Metadata["key_status"] != "rotating" {
+		return
+	}
+	h.Set("Deprecation", "true")
+	if endsAt, ok := user.Metadata["rotation_ends_at"].(string); ok {
+		if sunset, err := time.Parse(time.RFC3339, endsAt); err == nil {
+			h.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+	}
+	if rotatedTo, ok := user.Metadata["rotated_to"].(string); ok && rotatedTo != "" {
+		h.Set("X-API-Key-Rotated-To", rotatedTo)
+	}
+}
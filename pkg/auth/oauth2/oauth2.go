@@ -0,0 +1,268 @@
+// Package oauth2 signs users in through a corporate identity provider, such as
+// Okta or Azure AD, using the OAuth2 authorization code flow with PKCE and
+// OpenID Connect ID tokens.
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultScopes are requested when a provider configures none
+var DefaultScopes = []string{"openid", "email", "profile"}
+
+// ProviderConfig describes an OpenID Connect identity provider and this
+// server's client registration with it
+type ProviderConfig struct {
+	Issuer                string `mapstructure:"issuer"`
+	AuthorizationEndpoint string `mapstructure:"authorization_endpoint"`
+	TokenEndpoint         string `mapstructure:"token_endpoint"`
+	JWKSURL               string `mapstructure:"jwks_url"`
+
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"` // Empty for public clients, which rely on PKCE alone
+	RedirectURI  string   `mapstructure:"redirect_uri"`
+	Scopes       []string `mapstructure:"scopes"`
+
+	// Mapping of ID token claims to users
+	TenantClaim     string   `mapstructure:"tenant_claim"`      // Claim holding the tenant ID, default "tenant_id"
+	DefaultTenantID string   `mapstructure:"default_tenant_id"` // Tenant for tokens without the tenant claim
+	ScopesClaim     string   `mapstructure:"scopes_claim"`      // Claim holding granted scopes, default "scp"
+	DefaultScopes   []string `mapstructure:"default_scopes"`    // Scopes for tokens without the scopes claim
+
+	JWKSCacheTTL time.Duration `mapstructure:"jwks_cache_ttl"` // How long signing keys are cached, default 1 hour
+	HTTPTimeout  time.Duration `mapstructure:"http_timeout"`   // Timeout of requests to the provider, default 10 seconds
+}
+
+// Validate checks that the provider's endpoints and client are configured
+func (c ProviderConfig) Validate() error {
+	required := []struct{ name, value string }{
+		{"issuer", c.Issuer},
+		{"authorization_endpoint", c.AuthorizationEndpoint},
+		{"token_endpoint", c.TokenEndpoint},
+		{"jwks_url", c.JWKSURL},
+		{"client_id", c.ClientID},
+		{"redirect_uri", c.RedirectURI},
+	}
+	for _, field := range required {
+		if field.value == "" {
+			return fmt.Errorf("oauth2 provider %s is required", field.name)
+		}
+	}
+	return nil
+}
+
+// Tokens are the tokens issued by the provider's token endpoint
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	IDToken      string
+	TokenType    string
+	ExpiresAt    time.Time
+
+	// Claims are the validated ID token claims, when the provider returned an ID token
+	Claims *IDTokenClaims
+}
+
+// TokenError is an error response from the provider's token endpoint
+type TokenError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+// Error implements error
+func (e *TokenError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("token request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Description)
+	}
+	return fmt.Sprintf("token request failed with status %d: %s", e.StatusCode, e.Code)
+}
+
+// OAuth2Handler runs the authorization code flow against one provider
+type OAuth2Handler struct {
+	config ProviderConfig
+	client *http.Client
+	keys   *keySet
+	now    func() time.Time
+}
+
+// NewOAuth2Handler creates an OAuth2 handler. A nil client uses a default
+// client with the configured timeout.
+func NewOAuth2Handler(config ProviderConfig, client *http.Client) (*OAuth2Handler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultScopes
+	}
+	if config.TenantClaim == "" {
+		config.TenantClaim = "tenant_id"
+	}
+	if config.ScopesClaim == "" {
+		config.ScopesClaim = "scp"
+	}
+	if config.JWKSCacheTTL <= 0 {
+		config.JWKSCacheTTL = time.Hour
+	}
+	if config.HTTPTimeout <= 0 {
+		config.HTTPTimeout = 10 * time.Second
+	}
+	if client == nil {
+		client = &http.Client{Timeout: config.HTTPTimeout}
+	}
+
+	return &OAuth2Handler{
+		config: config,
+		client: client,
+		keys:   newKeySet(config.JWKSURL, client, config.JWKSCacheTTL),
+		now:    time.Now,
+	}, nil
+}
+
+// Config returns the provider configuration with defaults applied
+func (h *OAuth2Handler) Config() ProviderConfig {
+	return h.config
+}
+
+// AuthorizeURL returns the provider URL the user is redirected to in order to
+// sign in. codeChallenge is the S256 challenge of the PKCE code verifier later
+// passed to ExchangeCode.
+func (h *OAuth2Handler) AuthorizeURL(clientID, redirectURI, state, codeChallenge string) string {
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", clientID)
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(h.config.Scopes, " "))
+	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(h.config.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return h.config.AuthorizationEndpoint + separator + params.Encode()
+}
+
+// ExchangeCode exchanges an authorization code and its PKCE code verifier for
+// tokens, validating the ID token the provider returns
+func (h *OAuth2Handler) ExchangeCode(ctx context.Context, code, codeVerifier string) (*Tokens, error) {
+	if code == "" || codeVerifier == "" {
+		return nil, errors.New("authorization code and code verifier are required")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", h.config.RedirectURI)
+	form.Set("code_verifier", codeVerifier)
+
+	tokens, err := h.tokenRequest(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no ID token; is the openid scope requested?")
+	}
+	return tokens, nil
+}
+
+// RefreshOAuthToken exchanges a refresh token for new tokens. Providers may
+// omit the ID token or keep the old refresh token on refresh; the old refresh
+// token is returned when no new one is issued.
+func (h *OAuth2Handler) RefreshOAuthToken(ctx context.Context, refreshToken string) (*Tokens, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh token is required")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	tokens, err := h.tokenRequest(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+	return tokens, nil
+}
+
+// tokenResponse is a successful or failed token endpoint response
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// tokenRequest posts a grant to the token endpoint and validates any ID token returned
+func (h *OAuth2Handler) tokenRequest(ctx context.Context, form url.Values) (*Tokens, error) {
+	form.Set("client_id", h.config.ClientID)
+	if h.config.ClientSecret != "" {
+		form.Set("client_secret", h.config.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(body, &tr); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &TokenError{StatusCode: resp.StatusCode, Code: http.StatusText(resp.StatusCode)}
+		}
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		return nil, &TokenError{StatusCode: resp.StatusCode, Code: tr.Error, Description: tr.ErrorDescription}
+	}
+	if tr.AccessToken == "" {
+		return nil, errors.New("token response has no access token")
+	}
+
+	tokens := &Tokens{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		IDToken:      tr.IDToken,
+		TokenType:    tr.TokenType,
+	}
+	if tr.ExpiresIn > 0 {
+		tokens.ExpiresAt = h.now().Add(time.Duration(tr.ExpiresIn) * time.Second)
+	}
+	if tr.IDToken != "" {
+		claims, err := h.ValidateIDToken(ctx, tr.IDToken)
+		if err != nil {
+			return nil, err
+		}
+		tokens.Claims = claims
+	}
+	return tokens, nil
+}
@@ -0,0 +1,407 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+)
+
+const (
+	testClientID    = "mesh-client"
+	testRedirectURI = "https://mesh.example.com/oauth2/callback"
+)
+
+// fakeProvider is an OpenID Connect provider serving a token endpoint and JWKS
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu          sync.Mutex
+	challenges  map[string]string // Authorization code to PKCE challenge
+	claims      jwt.MapClaims     // Extra or overridden ID token claims
+	jwksFetches int
+	tokenForms  []url.Values
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &fakeProvider{t: t, key: key, kid: "key-1", challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jwks", p.handleJWKS)
+	mux.HandleFunc("/token", p.handleToken)
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+func (p *fakeProvider) config() ProviderConfig {
+	return ProviderConfig{
+		Issuer:                p.server.URL,
+		AuthorizationEndpoint: p.server.URL + "/authorize",
+		TokenEndpoint:         p.server.URL + "/token",
+		JWKSURL:               p.server.URL + "/jwks",
+		ClientID:              testClientID,
+		RedirectURI:           testRedirectURI,
+		DefaultTenantID:       "00000000-0000-0000-0000-000000000001",
+		DefaultScopes:         []string{"read"},
+	}
+}
+
+// authorize plays the user signing in, issuing a code bound to the URL's PKCE challenge
+func (p *fakeProvider) authorize(authorizeURL string) string {
+	u, err := url.Parse(authorizeURL)
+	require.NoError(p.t, err)
+	require.Equal(p.t, "S256", u.Query().Get("code_challenge_method"))
+
+	code := uuid.New().String()
+	p.mu.Lock()
+	p.challenges[code] = u.Query().Get("code_challenge")
+	p.mu.Unlock()
+	return code
+}
+
+func (p *fakeProvider) idToken(overrides jwt.MapClaims) string {
+	claims := jwt.MapClaims{
+		"iss":   p.server.URL,
+		"sub":   "00u1abcd",
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"email": "dev@example.com",
+		"name":  "Dev Example",
+	}
+	for k, v := range overrides {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	signed, err := token.SignedString(p.key)
+	require.NoError(p.t, err)
+	return signed
+}
+
+func (p *fakeProvider) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.jwksFetches++
+	kid := p.kid
+	p.mu.Unlock()
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "oct", "kid": "ignored", "k": "c2VjcmV0"},
+			{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			},
+		},
+	})
+}
+
+func (p *fakeProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	require.NoError(p.t, r.ParseForm())
+	p.mu.Lock()
+	p.tokenForms = append(p.tokenForms, r.PostForm)
+	overrides := p.claims
+	p.mu.Unlock()
+
+	writeError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": code, "error_description": "rejected by test provider"})
+	}
+
+	response := map[string]interface{}{
+		"access_token": "access-" + uuid.New().String(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	}
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		p.mu.Lock()
+		challenge, ok := p.challenges[r.PostForm.Get("code")]
+		delete(p.challenges, r.PostForm.Get("code"))
+		p.mu.Unlock()
+		if !ok || CodeChallenge(r.PostForm.Get("code_verifier")) != challenge {
+			writeError("invalid_grant")
+			return
+		}
+		response["refresh_token"] = "refresh-1"
+		response["id_token"] = p.idToken(overrides)
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != "refresh-1" {
+			writeError("invalid_grant")
+			return
+		}
+	default:
+		writeError("unsupported_grant_type")
+		return
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	provider := newFakeProvider(t)
+	handler, err := NewOAuth2Handler(provider.config(), nil)
+	require.NoError(t, err)
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+
+	u, err := url.Parse(handler.AuthorizeURL(testClientID, testRedirectURI, "state-1", CodeChallenge(verifier)))
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", u.Path)
+
+	query := u.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientID, query.Get("client_id"))
+	assert.Equal(t, testRedirectURI, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, CodeChallenge(verifier), query.Get("code_challenge"))
+	assert.NotEqual(t, verifier, query.Get("code_challenge"))
+}
+
+func TestExchangeCode(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeProvider(t)
+	handler, err := NewOAuth2Handler(provider.config(), nil)
+	require.NoError(t, err)
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+	code := provider.authorize(handler.AuthorizeURL(testClientID, testRedirectURI, "state", CodeChallenge(verifier)))
+
+	tokens, err := handler.ExchangeCode(ctx, code, verifier)
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Equal(t, "refresh-1", tokens.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), tokens.ExpiresAt, time.Minute)
+	require.NotNil(t, tokens.Claims)
+	assert.Equal(t, "00u1abcd", tokens.Claims.Subject)
+	assert.Equal(t, "dev@example.com", tokens.Claims.Email)
+
+	form := provider.tokenForms[0]
+	assert.Equal(t, testClientID, form.Get("client_id"))
+	assert.Equal(t, testRedirectURI, form.Get("redirect_uri"))
+	assert.Empty(t, form.Get("client_secret"), "public clients don't send a secret")
+
+	t.Run("wrong code verifier", func(t *testing.T) {
+		code := provider.authorize(handler.AuthorizeURL(testClientID, testRedirectURI, "state", CodeChallenge(verifier)))
+		_, err := handler.ExchangeCode(ctx, code, "not-the-verifier")
+		var tokenErr *TokenError
+		require.True(t, errors.As(err, &tokenErr))
+		assert.Equal(t, http.StatusBadRequest, tokenErr.StatusCode)
+		assert.Equal(t, "invalid_grant", tokenErr.Code)
+	})
+
+	t.Run("code is single use", func(t *testing.T) {
+		_, err := handler.ExchangeCode(ctx, code, verifier)
+		assert.Error(t, err)
+	})
+}
+
+func TestRefreshOAuthToken(t *testing.T) {
+	provider := newFakeProvider(t)
+	handler, err := NewOAuth2Handler(provider.config(), nil)
+	require.NoError(t, err)
+
+	tokens, err := handler.RefreshOAuthToken(context.Background(), "refresh-1")
+	require.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Equal(t, "refresh-1", tokens.RefreshToken, "the refresh token is kept when none is issued")
+	assert.Nil(t, tokens.Claims)
+
+	_, err = handler.RefreshOAuthToken(context.Background(), "revoked")
+	var tokenErr *TokenError
+	assert.True(t, errors.As(err, &tokenErr))
+}
+
+func TestValidateIDToken(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeProvider(t)
+	handler, err := NewOAuth2Handler(provider.config(), nil)
+	require.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss": provider.server.URL, "sub": "attacker", "aud": testClientID, "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = provider.kid
+	forgedToken, err := forged.SignedString(otherKey)
+	require.NoError(t, err)
+
+	hmacToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": provider.server.URL, "sub": "attacker", "aud": testClientID, "exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{name: "valid", token: provider.idToken(nil)},
+		{name: "audience list including the client", token: provider.idToken(jwt.MapClaims{"aud": []string{"other", testClientID}})},
+		{name: "expired", token: provider.idToken(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}), wantErr: true},
+		{name: "missing exp", token: provider.idToken(jwt.MapClaims{"exp": nil}), wantErr: true},
+		{name: "other issuer", token: provider.idToken(jwt.MapClaims{"iss": "https://evil.example.com"}), wantErr: true},
+		{name: "other audience", token: provider.idToken(jwt.MapClaims{"aud": "other-client"}), wantErr: true},
+		{name: "missing subject", token: provider.idToken(jwt.MapClaims{"sub": ""}), wantErr: true},
+		{name: "signed by another key", token: forgedToken, wantErr: true},
+		{name: "HMAC signed", token: hmacToken, wantErr: true},
+		{name: "malformed", token: "not.a.jwt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := handler.ValidateIDToken(ctx, tt.token)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidIDToken)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "00u1abcd", claims.Subject)
+		})
+	}
+}
+
+func TestValidateIDTokenKeyRotation(t *testing.T) {
+	provider := newFakeProvider(t)
+	handler, err := NewOAuth2Handler(provider.config(), nil)
+	require.NoError(t, err)
+
+	now := time.Now()
+	handler.keys.now = func() time.Time { return now }
+
+	_, err = handler.ValidateIDToken(context.Background(), provider.idToken(nil))
+	require.NoError(t, err)
+	_, err = handler.ValidateIDToken(context.Background(), provider.idToken(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 1, provider.jwksFetches, "keys are cached")
+
+	// The provider rotates in a new key ID
+	provider.mu.Lock()
+	provider.kid = "key-2"
+	provider.mu.Unlock()
+
+	_, err = handler.ValidateIDToken(context.Background(), provider.idToken(nil))
+	assert.Error(t, err, "unknown keys aren't refetched right after a fetch")
+
+	now = now.Add(2 * minJWKSRefresh)
+	_, err = handler.ValidateIDToken(context.Background(), provider.idToken(nil))
+	require.NoError(t, err)
+	assert.Equal(t, 2, provider.jwksFetches)
+}
+
+func TestJWKPublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		key     jwk
+		wantErr bool
+	}{
+		{name: "EC P-256", key: jwk{Kty: "EC", Crv: "P-256",
+			X: base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()), Y: base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes())}},
+		{name: "Ed25519", key: jwk{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(make([]byte, 32))}},
+		{name: "unsupported curve", key: jwk{Kty: "EC", Crv: "secp256k1", X: "AQ", Y: "AQ"}, wantErr: true},
+		{name: "short Ed25519 key", key: jwk{Kty: "OKP", Crv: "Ed25519", X: "AQ"}, wantErr: true},
+		{name: "missing RSA modulus", key: jwk{Kty: "RSA", E: "AQAB"}, wantErr: true},
+		{name: "symmetric key", key: jwk{Kty: "oct"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.key.publicKey()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUser(t *testing.T) {
+	provider := newFakeProvider(t)
+	config := provider.config()
+	config.TenantClaim = "mesh_tenant"
+	config.ScopesClaim = "scope"
+	handler, err := NewOAuth2Handler(config, nil)
+	require.NoError(t, err)
+
+	tenantID := uuid.New()
+	claims, err := handler.ValidateIDToken(context.Background(), provider.idToken(jwt.MapClaims{
+		"mesh_tenant": tenantID.String(),
+		"scope":       "read write",
+	}))
+	require.NoError(t, err)
+
+	user, err := handler.User(claims)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, user.TenantID)
+	assert.Equal(t, []string{"read", "write"}, user.Scopes)
+	assert.Equal(t, "dev@example.com", user.Email)
+	assert.Equal(t, auth.TypeOAuth2, user.AuthType)
+
+	again, err := handler.User(claims)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, again.ID, "user IDs are stable across sign-ins")
+
+	t.Run("defaults", func(t *testing.T) {
+		claims, err := handler.ValidateIDToken(context.Background(), provider.idToken(nil))
+		require.NoError(t, err)
+		user, err := handler.User(claims)
+		require.NoError(t, err)
+		assert.Equal(t, config.DefaultTenantID, user.TenantID.String())
+		assert.Equal(t, []string{"read"}, user.Scopes)
+	})
+
+	t.Run("scopes array", func(t *testing.T) {
+		claims, err := handler.ValidateIDToken(context.Background(), provider.idToken(jwt.MapClaims{"scope": []string{"admin"}}))
+		require.NoError(t, err)
+		user, err := handler.User(claims)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, user.Scopes)
+	})
+
+	t.Run("invalid tenant", func(t *testing.T) {
+		claims, err := handler.ValidateIDToken(context.Background(), provider.idToken(jwt.MapClaims{"mesh_tenant": "acme"}))
+		require.NoError(t, err)
+		_, err = handler.User(claims)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewOAuth2HandlerValidatesConfig(t *testing.T) {
+	_, err := NewOAuth2Handler(ProviderConfig{Issuer: "https://idp.example.com"}, nil)
+	assert.EqualError(t, err, "oauth2 provider authorization_endpoint is required")
+}
@@ -0,0 +1,32 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	return randomToken(32)
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of a code verifier
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// NewState returns a random state value binding a callback to the sign-in that started it
+func NewState() (string, error) {
+	return randomToken(16)
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
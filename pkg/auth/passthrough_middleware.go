@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -19,6 +20,9 @@ func (s *Service) GinMiddlewareWithPassthrough(authTypes ...Type) gin.HandlerFun
 		var user *User
 		var err error
 
+		// API keys bound to an allowlist are checked against the caller's address
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ContextKeyIPAddress, c.ClientIP()))
+
 		// Try each auth type in order (copied from base middleware)
 		for _, authType := range authTypes {
 			switch authType {
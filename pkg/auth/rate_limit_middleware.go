@@ -7,7 +7,7 @@ import (
 )
 
 // RateLimitMiddleware creates HTTP middleware for rate limiting
-func RateLimitMiddleware(rateLimiter *RateLimiter, logger observability.Logger) func(http.Handler) http.Handler {
+func RateLimitMiddleware(rateLimiter *AttemptRateLimiter, logger observability.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip rate limiting for non-auth endpoints
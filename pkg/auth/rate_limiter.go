@@ -10,8 +10,8 @@ import (
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
 )
 
-// RateLimiter provides rate limiting for authentication endpoints
-type RateLimiter struct {
+// AttemptRateLimiter provides rate limiting for authentication endpoints
+type AttemptRateLimiter struct {
 	cache       cache.Cache
 	logger      observability.Logger
 	localLimits sync.Map // fallback for when cache is unavailable
@@ -41,13 +41,13 @@ func DefaultRateLimiterConfig() *RateLimiterConfig {
 	}
 }
 
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(cache cache.Cache, logger observability.Logger, config *RateLimiterConfig) *RateLimiter {
+// NewAttemptRateLimiter creates a new authentication attempt rate limiter
+func NewAttemptRateLimiter(cache cache.Cache, logger observability.Logger, config *RateLimiterConfig) *AttemptRateLimiter {
 	if config == nil {
 		config = DefaultRateLimiterConfig()
 	}
 
-	return &RateLimiter{
+	return &AttemptRateLimiter{
 		cache:         cache,
 		logger:        logger,
 		enabled:       config.Enabled,
@@ -58,7 +58,7 @@ func NewRateLimiter(cache cache.Cache, logger observability.Logger, config *Rate
 }
 
 // CheckLimit checks if the identifier has exceeded rate limits
-func (rl *RateLimiter) CheckLimit(ctx context.Context, identifier string) error {
+func (rl *AttemptRateLimiter) CheckLimit(ctx context.Context, identifier string) error {
 	// If rate limiting is disabled, always allow
 	if !rl.enabled {
 		return nil
@@ -76,7 +76,7 @@ func (rl *RateLimiter) CheckLimit(ctx context.Context, identifier string) error
 }
 
 // RecordAttempt records an authentication attempt
-func (rl *RateLimiter) RecordAttempt(ctx context.Context, identifier string, success bool) {
+func (rl *AttemptRateLimiter) RecordAttempt(ctx context.Context, identifier string, success bool) {
 	// If rate limiting is disabled, do nothing
 	if !rl.enabled {
 		return
@@ -98,7 +98,7 @@ func (rl *RateLimiter) RecordAttempt(ctx context.Context, identifier string, suc
 }
 
 // Implementation details...
-func (rl *RateLimiter) checkCacheLimit(ctx context.Context, key string) error {
+func (rl *AttemptRateLimiter) checkCacheLimit(ctx context.Context, key string) error {
 	// Check if locked out
 	lockoutKey := key + ":lockout"
 	var locked bool
@@ -125,7 +125,7 @@ func (rl *RateLimiter) checkCacheLimit(ctx context.Context, key string) error {
 	return nil
 }
 
-func (rl *RateLimiter) recordCacheAttempt(ctx context.Context, key string, success bool) {
+func (rl *AttemptRateLimiter) recordCacheAttempt(ctx context.Context, key string, success bool) {
 	if success {
 		// Reset on successful auth
 		_ = rl.cache.Delete(ctx, key+":count")   // Best effort cleanup
@@ -155,7 +155,7 @@ type localRateLimit struct {
 	mu        sync.Mutex
 }
 
-func (rl *RateLimiter) checkLocalLimit(identifier string) error {
+func (rl *AttemptRateLimiter) checkLocalLimit(identifier string) error {
 	now := time.Now()
 
 	val, _ := rl.localLimits.LoadOrStore(identifier, &localRateLimit{
@@ -186,7 +186,7 @@ func (rl *RateLimiter) checkLocalLimit(identifier string) error {
 	return nil
 }
 
-func (rl *RateLimiter) recordLocalAttempt(identifier string, success bool) {
+func (rl *AttemptRateLimiter) recordLocalAttempt(identifier string, success bool) {
 	now := time.Now()
 
 	val, _ := rl.localLimits.LoadOrStore(identifier, &localRateLimit{
@@ -214,6 +214,6 @@ func (rl *RateLimiter) recordLocalAttempt(identifier string, success bool) {
 }
 
 // GetLockoutPeriod returns the configured lockout period
-func (rl *RateLimiter) GetLockoutPeriod() time.Duration {
+func (rl *AttemptRateLimiter) GetLockoutPeriod() time.Duration {
 	return rl.lockoutPeriod
 }
@@ -0,0 +1,314 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DefaultRefreshTokenTTL is how long a refresh token stays valid
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshTokenPrefix marks opaque refresh tokens
+const refreshTokenPrefix = "rt_"
+
+// ErrTokenRevoked is returned for tokens invalidated by a revocation
+var ErrTokenRevoked = errors.New("token revoked")
+
+// refreshToken is a stored refresh token. Only the hash of the token is kept.
+type refreshToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	TenantID  uuid.UUID
+	Email     string
+	Scopes    []string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// refreshTokenTTL returns the configured refresh token lifetime
+func (s *Service) refreshTokenTTL() time.Duration {
+	if s.config == nil || s.config.RefreshTokenTTL <= 0 {
+		return DefaultRefreshTokenTTL
+	}
+	return s.config.RefreshTokenTTL
+}
+
+// GenerateRefreshToken issues an opaque refresh token for a user that can be
+// exchanged for new access JWTs until it expires or is revoked. The token is
+// only returned here; it's stored hashed.
+func (s *Service) GenerateRefreshToken(ctx context.Context, user *User) (string, error) {
+	if user == nil {
+		return "", errors.New("user is required")
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := refreshTokenPrefix + base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	record := &refreshToken{
+		TokenHash: s.hashAPIKey(token),
+		UserID:    user.ID,
+		TenantID:  user.TenantID,
+		Email:     user.Email,
+		Scopes:    user.Scopes,
+		ExpiresAt: now.Add(s.refreshTokenTTL()),
+		CreatedAt: now,
+	}
+
+	if s.db != nil {
+		query := `
+			INSERT INTO mcp.refresh_tokens (
+				id, token_hash, user_id, tenant_id, email, scopes, expires_at, created_at
+			) VALUES (
+				uuid_generate_v4(), $1, $2, $3, $4, $5, $6, $7
+			)
+		`
+		if _, err := s.db.ExecContext(ctx, query,
+			record.TokenHash, record.UserID, record.TenantID, record.Email,
+			pq.Array(record.Scopes), record.ExpiresAt, record.CreatedAt,
+		); err != nil {
+			return "", fmt.Errorf("failed to store refresh token: %w", err)
+		}
+	} else {
+		s.mu.Lock()
+		if s.refreshTokens == nil {
+			s.refreshTokens = make(map[string]*refreshToken)
+		}
+		s.refreshTokens[record.TokenHash] = record
+		s.mu.Unlock()
+	}
+
+	s.logDebug("Refresh token issued", map[string]interface{}{
+		"user_id":    user.ID.String(),
+		"tenant_id":  user.TenantID.String(),
+		"expires_at": record.ExpiresAt.Format(time.RFC3339),
+	})
+
+	return token, nil
+}
+
+// RefreshAccessToken exchanges a refresh token for a new access JWT
+func (s *Service) RefreshAccessToken(ctx context.Context, token string) (string, error) {
+	record, err := s.lookupRefreshToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	if record.RevokedAt != nil {
+		return "", ErrTokenRevoked
+	}
+	if !time.Now().Before(record.ExpiresAt) {
+		return "", ErrTokenExpired
+	}
+
+	revokedBefore, err := s.tokensRevokedBefore(ctx, record.UserID)
+	if err != nil {
+		return "", err
+	}
+	if record.CreatedAt.Before(revokedBefore) {
+		return "", ErrTokenRevoked
+	}
+
+	return s.GenerateJWT(ctx, &User{
+		ID:       record.UserID,
+		TenantID: record.TenantID,
+		Email:    record.Email,
+		Scopes:   record.Scopes,
+		AuthType: TypeJWT,
+	})
+}
+
+// RevokeRefreshToken invalidates a refresh token. Access JWTs already issued
+// from it stay valid until they expire; use RevokeUserTokens to invalidate them.
+func (s *Service) RevokeRefreshToken(ctx context.Context, token string) error {
+	if !isValidAPIKeyFormat(token) {
+		return ErrInvalidToken
+	}
+	tokenHash := s.hashAPIKey(token)
+	now := time.Now()
+
+	if s.db != nil {
+		query := `UPDATE mcp.refresh_tokens SET revoked_at = $2 WHERE token_hash = $1 AND revoked_at IS NULL`
+		result, err := s.db.ExecContext(ctx, query, tokenHash, now)
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to revoke refresh token: %w", err)
+		}
+		if rows == 0 {
+			return ErrInvalidToken
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.refreshTokens[tokenHash]
+	if !ok || record.RevokedAt != nil {
+		return ErrInvalidToken
+	}
+	record.RevokedAt = &now
+	return nil
+}
+
+// RevokeUserTokens invalidates every access and refresh token issued to a user
+// so far. Tokens carry their issue time in seconds, so tokens issued within the
+// second of the revocation are rejected too.
+func (s *Service) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	revokedBefore := time.Now().Truncate(time.Second).Add(time.Second)
+
+	s.mu.Lock()
+	if s.tokenRevocations == nil {
+		s.tokenRevocations = make(map[uuid.UUID]time.Time)
+	}
+	s.tokenRevocations[userID] = revokedBefore
+	s.mu.Unlock()
+
+	if s.db != nil {
+		query := `
+			INSERT INTO mcp.user_token_revocations (user_id, revoked_before, updated_at)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id) DO UPDATE SET
+				revoked_before = GREATEST(mcp.user_token_revocations.revoked_before, EXCLUDED.revoked_before),
+				updated_at = EXCLUDED.updated_at
+		`
+		if _, err := s.db.ExecContext(ctx, query, userID, revokedBefore, time.Now()); err != nil {
+			return fmt.Errorf("failed to revoke user tokens: %w", err)
+		}
+	}
+
+	// Share the revocation with services validating through the same cache.
+	// Without a database the cache is the only shared record, so it's kept
+	// until every token it revokes has expired.
+	if s.cache != nil {
+		ttl := s.revocationCacheTTL()
+		if err := s.cache.Set(ctx, tokenRevocationCacheKey(userID), revokedBefore, ttl); err != nil {
+			s.logWarn("Failed to cache token revocation", map[string]interface{}{"error": err})
+		}
+	}
+
+	s.logInfo("Revoked user tokens", map[string]interface{}{
+		"user_id":        userID.String(),
+		"revoked_before": revokedBefore.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// revocationCacheTTL is how long a user's revocation time is cached
+func (s *Service) revocationCacheTTL() time.Duration {
+	if s.db != nil && s.config != nil && s.config.CacheTTL > 0 {
+		return s.config.CacheTTL
+	}
+	ttl := s.refreshTokenTTL()
+	if s.config != nil && s.config.JWTExpiration > ttl {
+		ttl = s.config.JWTExpiration
+	}
+	return ttl
+}
+
+func tokenRevocationCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("auth:revoked_before:%s", userID)
+}
+
+// tokensRevokedBefore returns the time before which a user's tokens are
+// revoked, or the zero time when they never were
+func (s *Service) tokensRevokedBefore(ctx context.Context, userID uuid.UUID) (time.Time, error) {
+	s.mu.RLock()
+	revokedBefore := s.tokenRevocations[userID]
+	s.mu.RUnlock()
+
+	var shared time.Time
+	if s.cache != nil {
+		if err := s.cache.Get(ctx, tokenRevocationCacheKey(userID), &shared); err == nil {
+			return latest(revokedBefore, shared), nil
+		}
+	}
+	if s.db == nil {
+		return revokedBefore, nil
+	}
+
+	query := `SELECT revoked_before FROM mcp.user_token_revocations WHERE user_id = $1`
+	if err := s.db.GetContext(ctx, &shared, query, userID); err != nil && err != sql.ErrNoRows {
+		return time.Time{}, fmt.Errorf("failed to look up token revocation: %w", err)
+	}
+
+	// Users without a revocation are cached too, so validation doesn't query
+	// the database every time
+	if s.cache != nil && s.config != nil && s.config.CacheEnabled {
+		if err := s.cache.Set(ctx, tokenRevocationCacheKey(userID), shared, s.config.CacheTTL); err != nil {
+			s.logWarn("Failed to cache token revocation", map[string]interface{}{"error": err})
+		}
+	}
+	return latest(revokedBefore, shared), nil
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// lookupRefreshToken finds a stored refresh token
+func (s *Service) lookupRefreshToken(ctx context.Context, token string) (*refreshToken, error) {
+	if !isValidAPIKeyFormat(token) {
+		return nil, ErrInvalidToken
+	}
+	tokenHash := s.hashAPIKey(token)
+
+	if s.db == nil {
+		s.mu.RLock()
+		defer s.mu.RUnlock()
+		record, ok := s.refreshTokens[tokenHash]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		snapshot := *record
+		return &snapshot, nil
+	}
+
+	query := `
+		SELECT user_id, tenant_id, email, scopes, expires_at, created_at, revoked_at
+		FROM mcp.refresh_tokens
+		WHERE token_hash = $1
+	`
+	var row struct {
+		UserID    uuid.UUID      `db:"user_id"`
+		TenantID  uuid.UUID      `db:"tenant_id"`
+		Email     sql.NullString `db:"email"`
+		Scopes    pq.StringArray `db:"scopes"`
+		ExpiresAt time.Time      `db:"expires_at"`
+		CreatedAt time.Time      `db:"created_at"`
+		RevokedAt *time.Time     `db:"revoked_at"`
+	}
+	if err := s.db.GetContext(ctx, &row, query, tokenHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &refreshToken{
+		TokenHash: tokenHash,
+		UserID:    row.UserID,
+		TenantID:  row.TenantID,
+		Email:     row.Email.String,
+		Scopes:    []string(row.Scopes),
+		ExpiresAt: row.ExpiresAt,
+		CreatedAt: row.CreatedAt,
+		RevokedAt: row.RevokedAt,
+	}, nil
+}
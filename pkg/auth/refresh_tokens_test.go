@@ -0,0 +1,351 @@
+package auth
+
+import (
+	"context"
+	"database/sql/driver"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func newRefreshTokenTestService() *Service {
+	config := DefaultConfig()
+	config.JWTSecret = "refresh-token-test-secret"
+	return NewService(config, nil, nil, observability.NewNoopLogger())
+}
+
+func refreshTokenTestUser() *User {
+	return &User{
+		ID:       uuid.New(),
+		TenantID: uuid.MustParse(serviceAccountTenant),
+		Email:    "dev@example.com",
+		Scopes:   []string{"read", "write"},
+	}
+}
+
+// waitForRevocation waits until tokens issued now are past the user's
+// revocation time, which is rounded up to the next second
+func waitForRevocation(t *testing.T, service *Service, userID uuid.UUID) {
+	t.Helper()
+	revokedBefore, err := service.tokensRevokedBefore(context.Background(), userID)
+	require.NoError(t, err)
+	time.Sleep(time.Until(revokedBefore))
+}
+
+func TestRefreshAccessToken(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, service *Service, user *User, token string)
+		wantErr error
+	}{
+		{
+			name:    "valid refresh token issues an access token",
+			prepare: func(t *testing.T, service *Service, user *User, token string) {},
+		},
+		{
+			name: "expired refresh token",
+			prepare: func(t *testing.T, service *Service, user *User, token string) {
+				service.mu.Lock()
+				service.refreshTokens[service.hashAPIKey(token)].ExpiresAt = time.Now().Add(-time.Second)
+				service.mu.Unlock()
+			},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name: "revoked refresh token",
+			prepare: func(t *testing.T, service *Service, user *User, token string) {
+				require.NoError(t, service.RevokeRefreshToken(ctx, token))
+			},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name: "refresh token issued before the user's tokens were revoked",
+			prepare: func(t *testing.T, service *Service, user *User, token string) {
+				require.NoError(t, service.RevokeUserTokens(ctx, user.ID))
+			},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name: "unknown refresh token",
+			prepare: func(t *testing.T, service *Service, user *User, token string) {
+				service.mu.Lock()
+				delete(service.refreshTokens, service.hashAPIKey(token))
+				service.mu.Unlock()
+			},
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newRefreshTokenTestService()
+			user := refreshTokenTestUser()
+
+			token, err := service.GenerateRefreshToken(ctx, user)
+			require.NoError(t, err)
+			assert.NotContains(t, service.refreshTokens, token, "refresh tokens are stored hashed")
+			tt.prepare(t, service, user, token)
+
+			accessToken, err := service.RefreshAccessToken(ctx, token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			validated, err := service.ValidateJWT(ctx, accessToken)
+			require.NoError(t, err)
+			assert.Equal(t, user.ID, validated.ID)
+			assert.Equal(t, user.TenantID, validated.TenantID)
+			assert.Equal(t, user.Scopes, validated.Scopes)
+		})
+	}
+}
+
+func TestRefreshTokenTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want time.Duration
+	}{
+		{name: "default", ttl: 0, want: 30 * 24 * time.Hour},
+		{name: "configured", ttl: time.Hour, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newRefreshTokenTestService()
+			service.config.RefreshTokenTTL = tt.ttl
+
+			_, err := service.GenerateRefreshToken(context.Background(), refreshTokenTestUser())
+			require.NoError(t, err)
+			for _, record := range service.refreshTokens {
+				assert.WithinDuration(t, time.Now().Add(tt.want), record.ExpiresAt, time.Second)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	ctx := context.Background()
+	service := newRefreshTokenTestService()
+
+	token, err := service.GenerateRefreshToken(ctx, refreshTokenTestUser())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "first revocation", token: token},
+		{name: "already revoked", token: token, wantErr: ErrInvalidToken},
+		{name: "unknown token", token: "rt_unknown", wantErr: ErrInvalidToken},
+		{name: "malformed token", token: "not a token!", wantErr: ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.RevokeRefreshToken(ctx, tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	ctx := context.Background()
+	service := newRefreshTokenTestService()
+	user := refreshTokenTestUser()
+	other := refreshTokenTestUser()
+
+	accessToken, err := service.GenerateJWT(ctx, user)
+	require.NoError(t, err)
+	otherAccessToken, err := service.GenerateJWT(ctx, other)
+	require.NoError(t, err)
+
+	require.NoError(t, service.RevokeUserTokens(ctx, user.ID))
+	waitForRevocation(t, service, user.ID)
+
+	freshAccessToken, err := service.GenerateJWT(ctx, user)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "token issued before the revocation", token: accessToken, wantErr: ErrTokenRevoked},
+		{name: "token issued after the revocation", token: freshAccessToken},
+		{name: "another user's token", token: otherAccessToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ValidateJWT(ctx, tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestRefreshTokenRaces(t *testing.T) {
+	ctx := context.Background()
+	const workers = 20
+
+	t.Run("concurrent refreshes of the same token all succeed", func(t *testing.T) {
+		service := newRefreshTokenTestService()
+		token, err := service.GenerateRefreshToken(ctx, refreshTokenTestUser())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				accessToken, err := service.RefreshAccessToken(ctx, token)
+				if err == nil {
+					_, err = service.ValidateJWT(ctx, accessToken)
+				}
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("refreshes racing a revocation fail once it returns", func(t *testing.T) {
+		service := newRefreshTokenTestService()
+		token, err := service.GenerateRefreshToken(ctx, refreshTokenTestUser())
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := service.RefreshAccessToken(ctx, token)
+				if err != nil {
+					assert.ErrorIs(t, err, ErrTokenRevoked)
+				}
+			}()
+		}
+		require.NoError(t, service.RevokeRefreshToken(ctx, token))
+		wg.Wait()
+
+		_, err = service.RefreshAccessToken(ctx, token)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+}
+
+func TestRefreshTokensWithDatabase(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	config := DefaultConfig()
+	config.JWTSecret = "refresh-token-test-secret"
+	config.CacheEnabled = false
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+	ctx := context.Background()
+	user := refreshTokenTestUser()
+
+	tokenColumns := []string{"user_id", "tenant_id", "email", "scopes", "expires_at", "created_at", "revoked_at"}
+
+	mock.ExpectExec(`INSERT INTO mcp.refresh_tokens`).
+		WithArgs(sqlmock.AnyArg(), user.ID, user.TenantID, user.Email, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	token, err := service.GenerateRefreshToken(ctx, user)
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	tests := []struct {
+		name    string
+		row     []driver.Value
+		revoked driver.Value
+		wantErr error
+	}{
+		{
+			name: "valid refresh token",
+			row:  []driver.Value{user.ID, user.TenantID, user.Email, "{read,write}", time.Now().Add(time.Hour), time.Now().Add(-time.Minute), nil},
+		},
+		{
+			name:    "expired refresh token",
+			row:     []driver.Value{user.ID, user.TenantID, user.Email, "{read}", time.Now().Add(-time.Minute), time.Now().Add(-time.Hour), nil},
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:    "revoked refresh token",
+			row:     []driver.Value{user.ID, user.TenantID, user.Email, "{read}", time.Now().Add(time.Hour), time.Now().Add(-time.Hour), time.Now()},
+			wantErr: ErrTokenRevoked,
+		},
+		{
+			name:    "refresh token issued before the user's tokens were revoked",
+			row:     []driver.Value{user.ID, user.TenantID, user.Email, "{read}", time.Now().Add(time.Hour), time.Now().Add(-time.Hour), nil},
+			revoked: time.Now(),
+			wantErr: ErrTokenRevoked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectQuery(`FROM mcp.refresh_tokens`).
+				WithArgs(service.hashAPIKey(token)).
+				WillReturnRows(sqlmock.NewRows(tokenColumns).AddRow(tt.row...))
+			if tt.wantErr == nil || tt.revoked != nil {
+				rows := sqlmock.NewRows([]string{"revoked_before"})
+				if tt.revoked != nil {
+					rows.AddRow(tt.revoked)
+				}
+				mock.ExpectQuery(`SELECT revoked_before FROM mcp.user_token_revocations`).
+					WithArgs(user.ID).
+					WillReturnRows(rows)
+			}
+
+			_, err := service.RefreshAccessToken(ctx, token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	t.Run("revocation is persisted", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE mcp.refresh_tokens SET revoked_at`).
+			WithArgs(service.hashAPIKey(token), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE mcp.refresh_tokens SET revoked_at`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO mcp.user_token_revocations`).
+			WithArgs(user.ID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, service.RevokeRefreshToken(ctx, token))
+		assert.ErrorIs(t, service.RevokeRefreshToken(ctx, token), ErrInvalidToken)
+		assert.NoError(t, service.RevokeUserTokens(ctx, user.ID))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
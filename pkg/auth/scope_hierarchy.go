@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ScopeHierarchy maps a scope to the scopes it implies. Implications are
+// transitive, and a single-segment implication also holds for that action on
+// any resource, so with "write" implying "read", "tools:write" implies
+// "tools:read".
+//
+// Implications apply before matching: a granted scope is expanded to every
+// scope it implies, and the expanded scopes then cover required ones by the
+// ScopeSet rules. So with the default hierarchy, "admin" expands to "*" and
+// covers "tools:execute", and "tools:write" expands to "tools:read" and covers
+// "tools:github:read".
+type ScopeHierarchy map[string][]string
+
+// defaultScopeHierarchy has admin imply write, which implies read. Admin also
+// implies every other scope, in its namespace when it is scoped to one.
+var defaultScopeHierarchy = ScopeHierarchy{
+	"admin": {"write", ScopeWildcard},
+	"write": {"read"},
+}
+
+// DefaultScopeHierarchy returns the hierarchy used when ServiceConfig doesn't
+// set one
+func DefaultScopeHierarchy() ScopeHierarchy {
+	return defaultScopeHierarchy.merge(nil)
+}
+
+// Expand returns the scopes followed by every scope they transitively imply,
+// without duplicates
+func (h ScopeHierarchy) Expand(scopes ...string) []string {
+	seen := make(map[string]bool)
+	expanded := make([]string, 0, len(scopes))
+	queue := append([]string(nil), scopes...)
+	for len(queue) > 0 {
+		scope := strings.TrimSpace(queue[0])
+		queue = queue[1:]
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		expanded = append(expanded, scope)
+
+		queue = append(queue, h[scope]...)
+		if i := strings.LastIndex(scope, ScopeSeparator); i >= 0 {
+			resource, action := scope[:i], scope[i+1:]
+			for _, implied := range h[action] {
+				if !strings.Contains(implied, ScopeSeparator) {
+					queue = append(queue, resource+ScopeSeparator+implied)
+				}
+			}
+		}
+	}
+	return expanded
+}
+
+// satisfies reports whether a granted scope, or any scope it implies, covers
+// the required scope
+func (h ScopeHierarchy) satisfies(granted, required string) bool {
+	return NewScopeSet(h.Expand(granted)...).Matches(required)
+}
+
+// merge returns a copy of the hierarchy with another's implications added
+func (h ScopeHierarchy) merge(other ScopeHierarchy) ScopeHierarchy {
+	merged := make(ScopeHierarchy, len(h)+len(other))
+	for scope, implied := range h {
+		merged[scope] = append([]string(nil), implied...)
+	}
+	for scope, implied := range other {
+		merged[scope] = append(merged[scope], implied...)
+	}
+	return merged
+}
+
+// scopeSatisfies reports whether a granted scope covers a required one under
+// the default hierarchy
+func scopeSatisfies(granted, required string) bool {
+	return defaultScopeHierarchy.satisfies(granted, required)
+}
+
+// scopeHierarchy returns the hierarchy for a tenant: the configured one, or
+// the default, with the tenant's own implications added
+func (s *Service) scopeHierarchy(tenantID uuid.UUID) ScopeHierarchy {
+	if s.config == nil {
+		return defaultScopeHierarchy
+	}
+
+	hierarchy := defaultScopeHierarchy
+	if s.config.ScopeHierarchy != nil {
+		hierarchy = s.config.ScopeHierarchy
+	}
+	if tenant := s.config.TenantScopeHierarchies[tenantID]; len(tenant) > 0 {
+		hierarchy = hierarchy.merge(tenant)
+	}
+	return hierarchy
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeSatisfies(t *testing.T) {
+	tests := []struct {
+		granted  string
+		required string
+		want     bool
+	}{
+		{granted: "read", required: "read", want: true},
+		{granted: "write", required: "read", want: true},
+		{granted: "admin", required: "write", want: true},
+		{granted: "admin", required: "read", want: true},
+		{granted: "admin", required: "tools:execute", want: true},
+		{granted: "read", required: "write"},
+		{granted: "write", required: "admin"},
+		{granted: "tools:*", required: "tools:execute", want: true},
+		{granted: "tools:*", required: "tools:list", want: true},
+		{granted: "tools:*", required: "contexts:read"},
+		{granted: "tools:write", required: "tools:read", want: true},
+		{granted: "tools:write", required: "tools:github:read", want: true},
+		{granted: "tools:write", required: "contexts:read"},
+		{granted: "tools:admin", required: "tools:execute", want: true},
+		{granted: "tools:admin", required: "contexts:execute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granted+" "+tt.required, func(t *testing.T) {
+			assert.Equal(t, tt.want, scopeSatisfies(tt.granted, tt.required))
+		})
+	}
+}
+
+func TestScopeHierarchyExpand(t *testing.T) {
+	hierarchy := ScopeHierarchy{
+		"deployer": {"tools:execute", "contexts:write"},
+		"write":    {"read"},
+		"a":        {"b"},
+		"b":        {"a"},
+	}
+
+	assert.Equal(t, []string{"deployer", "tools:execute", "contexts:write", "contexts:read"}, hierarchy.Expand("deployer"))
+	assert.Equal(t, []string{"a", "b"}, hierarchy.Expand("a"), "cycles terminate")
+	assert.Equal(t, []string{"read"}, hierarchy.Expand("read", " read "))
+}
+
+func TestAuthorizeScopesHierarchy(t *testing.T) {
+	tenantID := uuid.New()
+	otherTenantID := uuid.New()
+
+	t.Run("default", func(t *testing.T) {
+		service := &Service{}
+		admin := &User{Scopes: []string{"admin"}}
+		assert.NoError(t, service.AuthorizeScopes(admin, []string{"tools:execute", "write", "read"}))
+
+		reader := &User{Scopes: []string{"read"}}
+		assert.ErrorIs(t, service.AuthorizeScopes(reader, []string{"write"}), ErrInsufficientScope)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		config := DefaultConfig()
+		config.ScopeHierarchy = ScopeHierarchy{"write": {"read"}}
+		config.TenantScopeHierarchies = map[uuid.UUID]ScopeHierarchy{
+			tenantID: {"deployer": {"tools:execute"}},
+		}
+		service := &Service{config: config}
+
+		// The configured hierarchy replaces the default, so admin no longer implies everything
+		admin := &User{TenantID: tenantID, Scopes: []string{"admin"}}
+		assert.ErrorIs(t, service.AuthorizeScopes(admin, []string{"tools:execute"}), ErrInsufficientScope)
+
+		deployer := &User{TenantID: tenantID, Scopes: []string{"deployer", "write"}}
+		assert.NoError(t, service.AuthorizeScopes(deployer, []string{"tools:execute", "read"}))
+
+		// Tenant implications don't apply to other tenants
+		deployer.TenantID = otherTenantID
+		assert.ErrorIs(t, service.AuthorizeScopes(deployer, []string{"tools:execute"}), ErrInsufficientScope)
+		assert.NoError(t, service.AuthorizeScopes(deployer, []string{"read"}))
+	})
+}
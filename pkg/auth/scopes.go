@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ScopeSeparator separates the segments of a hierarchical scope such as "tools:github:read"
+const ScopeSeparator = ":"
+
+// ScopeWildcard is a scope segment matching any segment in its place, or any
+// number of segments when it is the last one
+const ScopeWildcard = "*"
+
+// Scope is a parsed scope, one element per colon-delimited segment
+type Scope []string
+
+// ParseScope splits a scope into its segments. Segments must be non-empty
+// without whitespace, and a wildcard must be a whole segment.
+func ParseScope(scope string) (Scope, error) {
+	scope = strings.TrimSpace(scope)
+	if scope == "" {
+		return nil, fmt.Errorf("empty scope")
+	}
+
+	segments := strings.Split(scope, ScopeSeparator)
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("scope %q has an empty segment", scope)
+		}
+		if strings.IndexFunc(segment, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("scope %q contains whitespace", scope)
+		}
+		if segment != ScopeWildcard && strings.Contains(segment, ScopeWildcard) {
+			return nil, fmt.Errorf("scope %q has a partial wildcard segment", scope)
+		}
+	}
+	return Scope(segments), nil
+}
+
+// String returns the scope in its colon-delimited form
+func (s Scope) String() string {
+	return strings.Join(s, ScopeSeparator)
+}
+
+// scopeNode is a node of the prefix tree of granted scopes
+type scopeNode struct {
+	children map[string]*scopeNode
+	granted  bool // A granted scope ends at this node
+}
+
+func (n *scopeNode) child(segment string) *scopeNode {
+	if n.children == nil {
+		n.children = make(map[string]*scopeNode)
+	}
+	c, ok := n.children[segment]
+	if !ok {
+		c = &scopeNode{}
+		n.children[segment] = c
+	}
+	return c
+}
+
+// ScopeSet is a set of granted scopes that checks required scopes against the
+// scope hierarchy. A granted scope matches a required scope when:
+//   - they are equal;
+//   - it names the same action for a broader resource, so "tools:read" matches
+//     "tools:github:read";
+//   - its wildcards cover it: a "*" segment matches any one segment, and a
+//     trailing "*" matches the rest, so "tools:*" matches "tools:github:read".
+//
+// A bare namespace doesn't cover the scopes under it: "tools" matches only
+// "tools", and the scopes under it need an explicit "tools:*".
+//
+// The zero value is an empty set.
+type ScopeSet struct {
+	root   scopeNode
+	scopes map[string]bool
+}
+
+// NewScopeSet creates a scope set from granted scopes, skipping any that don't parse
+func NewScopeSet(scopes ...string) *ScopeSet {
+	set := &ScopeSet{}
+	for _, scope := range scopes {
+		_ = set.Add(scope)
+	}
+	return set
+}
+
+// Add grants a scope
+func (s *ScopeSet) Add(scope string) error {
+	parsed, err := ParseScope(scope)
+	if err != nil {
+		return err
+	}
+
+	node := &s.root
+	for _, segment := range parsed {
+		node = node.child(segment)
+	}
+	node.granted = true
+
+	if s.scopes == nil {
+		s.scopes = make(map[string]bool)
+	}
+	s.scopes[parsed.String()] = true
+	return nil
+}
+
+// Matches reports whether any granted scope covers the required scope. A
+// required scope that doesn't parse never matches.
+func (s *ScopeSet) Matches(required string) bool {
+	if s == nil {
+		return false
+	}
+	parsed, err := ParseScope(required)
+	if err != nil {
+		return false
+	}
+	return s.root.matches(parsed, 0)
+}
+
+// matches walks the required segments from a node at the given depth
+func (n *scopeNode) matches(required Scope, depth int) bool {
+	if len(required) == 0 {
+		return n.granted
+	}
+
+	if c, ok := n.children[required[0]]; ok && c.matches(required[1:], depth+1) {
+		return true
+	}
+	// A wildcard takes one segment, and a trailing one covers the rest too
+	if c, ok := n.children[ScopeWildcard]; ok && (c.granted || c.matches(required[1:], depth+1)) {
+		return true
+	}
+
+	// A granted scope ending in the required action covers the resources
+	// between, so "tools:read" covers "tools:github:read"
+	if depth > 0 && len(required) > 1 {
+		if c, ok := n.children[required[len(required)-1]]; ok && c.granted {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the granted scopes, sorted and space-delimited
+func (s *ScopeSet) String() string {
+	if s == nil {
+		return ""
+	}
+	scopes := make([]string, 0, len(s.scopes))
+	for scope := range s.scopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return strings.Join(scopes, " ")
+}
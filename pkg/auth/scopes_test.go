@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScope(t *testing.T) {
+	tests := []struct {
+		scope   string
+		want    Scope
+		wantErr bool
+	}{
+		{scope: "read", want: Scope{"read"}},
+		{scope: "tools:github:read", want: Scope{"tools", "github", "read"}},
+		{scope: " tools:* ", want: Scope{"tools", "*"}},
+		{scope: "", wantErr: true},
+		{scope: "tools::read", wantErr: true},
+		{scope: "tools:", wantErr: true},
+		{scope: "tools:git*", wantErr: true},
+		{scope: "tools: :read", wantErr: true},
+		{scope: "tools read", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			got, err := ParseScope(tt.scope)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestScopeSetMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{name: "exact", granted: []string{"read"}, required: "read", want: true},
+		{name: "different flat scope", granted: []string{"read"}, required: "write"},
+		{name: "bare namespace", granted: []string{"tools"}, required: "tools:github:read"},
+		{name: "bare namespace doesn't grant its admin scope", granted: []string{"tools"}, required: "tools:admin"},
+		{name: "bare namespace covers itself", granted: []string{"tools"}, required: "tools", want: true},
+		{name: "bare resource", granted: []string{"tools:github"}, required: "tools:github:write"},
+		{name: "trailing wildcard under a resource", granted: []string{"tools:github:*"}, required: "tools:github:write", want: true},
+		{name: "narrower grant", granted: []string{"tools:github:read"}, required: "tools"},
+		{name: "action on a broader resource", granted: []string{"tools:read"}, required: "tools:github:read", want: true},
+		{name: "different action on a broader resource", granted: []string{"tools:read"}, required: "tools:github:write"},
+		{name: "flat action doesn't cover namespaces", granted: []string{"read"}, required: "tools:github:read"},
+		{name: "trailing wildcard", granted: []string{"tools:*"}, required: "tools:github:read", want: true},
+		{name: "trailing wildcard needs a segment", granted: []string{"tools:*"}, required: "tools"},
+		{name: "trailing wildcard in another namespace", granted: []string{"tools:*"}, required: "admin:read"},
+		{name: "inner wildcard", granted: []string{"tools:*:read"}, required: "tools:github:read", want: true},
+		{name: "inner wildcard with another action", granted: []string{"tools:*:read"}, required: "tools:github:write"},
+		{name: "wildcard covers everything", granted: []string{"*"}, required: "admin", want: true},
+		{name: "any granted scope", granted: []string{"read", "tools:github:write"}, required: "tools:github:write", want: true},
+		{name: "invalid required scope", granted: []string{"*"}, required: "tools::read"},
+		{name: "invalid grants are skipped", granted: []string{"tools::read", ""}, required: "tools:read"},
+		{name: "no grants", required: "read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewScopeSet(tt.granted...).Matches(tt.required))
+		})
+	}
+}
+
+func TestScopeSetAddAndString(t *testing.T) {
+	var set ScopeSet
+	assert.False(t, set.Matches("read"), "the zero value is empty")
+	assert.Equal(t, "", set.String())
+
+	require.NoError(t, set.Add("write"))
+	require.NoError(t, set.Add(" tools:* "))
+	require.NoError(t, set.Add("write"))
+	assert.Error(t, set.Add("tools:"))
+
+	assert.True(t, set.Matches("tools:github:read"))
+	assert.Equal(t, "tools:* write", set.String())
+}
+
+func TestAuthorizeScopes(t *testing.T) {
+	service := &Service{}
+	user := &User{Scopes: []string{"read", "tools:*", "contexts:write"}}
+
+	assert.NoError(t, service.AuthorizeScopes(user, nil))
+	assert.NoError(t, service.AuthorizeScopes(user, []string{"read", "tools:github:execute", "contexts:shared:write"}))
+	assert.ErrorIs(t, service.AuthorizeScopes(user, []string{"read", "admin"}), ErrInsufficientScope)
+	assert.ErrorIs(t, service.AuthorizeScopes(user, []string{"contexts:admin"}), ErrInsufficientScope)
+}
+
+func FuzzScopeSetWildcard(f *testing.F) {
+	f.Add("tools:github:read", 1)
+	f.Add("read", 0)
+	f.Add("a:b:c:d", 3)
+	f.Add("tools::read", 0)
+	f.Add("*", 0)
+
+	f.Fuzz(func(t *testing.T, scope string, position int) {
+		parsed, err := ParseScope(scope)
+		if err != nil {
+			// Invalid scopes are never granted or matched
+			set := NewScopeSet(scope)
+			assert.Equal(t, "", set.String())
+			assert.False(t, NewScopeSet(ScopeWildcard).Matches(scope))
+			return
+		}
+
+		assert.True(t, NewScopeSet(scope).Matches(scope), "a scope covers itself")
+		assert.True(t, NewScopeSet(ScopeWildcard).Matches(scope), "the wildcard covers every scope")
+
+		position = int(uint(position) % uint(len(parsed)))
+
+		// Replacing any segment with a wildcard still covers the scope
+		wildcarded := append(Scope{}, parsed...)
+		wildcarded[position] = ScopeWildcard
+		assert.True(t, NewScopeSet(wildcarded.String()).Matches(scope), "%s covers %s", wildcarded, parsed)
+
+		// A trailing wildcard covers anything nested under its prefix
+		prefix := parsed[:position+1].String()
+		assert.True(t, NewScopeSet(prefix+ScopeSeparator+ScopeWildcard).Matches(parsed.String()+ScopeSeparator+"nested"))
+
+		// A wildcard under another namespace never covers the scope
+		if parsed[0] != ScopeWildcard {
+			other := "not-" + parsed[0]
+			assert.False(t, NewScopeSet(other+ScopeSeparator+ScopeWildcard).Matches(scope))
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ServiceAccountType is the account type recorded for service accounts
+const ServiceAccountType = "service_account"
+
+// ServiceAccount is a non-human identity used for machine-to-machine authentication
+type ServiceAccount struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	Name      string    `json:"name" db:"name"`
+	Type      string    `json:"type" db:"type"`
+	Scopes    []string  `json:"scopes" db:"scopes"`
+	Active    bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// APIKey is the account's dedicated key, only returned on creation
+	APIKey string `json:"api_key,omitempty" db:"-"`
+}
+
+// CreateServiceAccount creates a service account with its own dedicated API key
+func (s *Service) CreateServiceAccount(ctx context.Context, name, tenantID string, scopes []string) (*ServiceAccount, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("service account name is required")
+	}
+
+	tenantUUID, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant ID: %w", err)
+	}
+
+	if s.db == nil && s.hasServiceAccount(tenantUUID, name) {
+		return nil, fmt.Errorf("service account %q already exists", name)
+	}
+
+	key, err := s.CreateAPIKeyWithType(ctx, CreateAPIKeyRequest{
+		Name:     name,
+		TenantID: tenantID,
+		KeyType:  KeyTypeService,
+		Scopes:   scopes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service account API key: %w", err)
+	}
+
+	account := &ServiceAccount{
+		ID:        uuid.New(),
+		TenantID:  tenantUUID,
+		Name:      name,
+		Type:      ServiceAccountType,
+		Scopes:    key.Scopes,
+		Active:    true,
+		CreatedAt: time.Now(),
+		APIKey:    key.Key,
+	}
+	keyHash := s.hashAPIKey(key.Key)
+
+	if s.db != nil {
+		query := `
+			INSERT INTO mcp.service_accounts (
+				id, tenant_id, name, type, api_key_id, scopes, is_active, created_at, updated_at
+			)
+			SELECT $1, $2, $3, $4, id, $5, true, $6, $6
+			FROM mcp.api_keys
+			WHERE id = $7
+		`
+		result, err := s.db.ExecContext(ctx, query,
+			account.ID, tenantID, name, account.Type, pq.Array(account.Scopes), account.CreatedAt, key.ID)
+		if err == nil {
+			if rows, rowsErr := result.RowsAffected(); rowsErr == nil && rows != 1 {
+				err = fmt.Errorf("API key not found")
+			}
+		}
+		if err != nil {
+			// Don't leave an orphaned key behind
+			if _, delErr := s.db.ExecContext(ctx, `DELETE FROM mcp.api_keys WHERE id = $1`, key.ID); delErr != nil {
+				s.logError("Failed to remove service account API key", map[string]interface{}{
+					"key_prefix": key.KeyPrefix,
+					"error":      delErr.Error(),
+				})
+			}
+			return nil, fmt.Errorf("failed to create service account: %w", err)
+		}
+	} else {
+		stored := *account
+		stored.APIKey = ""
+		s.mu.Lock()
+		if s.serviceAccounts == nil {
+			s.serviceAccounts = make(map[string]*ServiceAccount)
+		}
+		s.serviceAccounts[keyHash] = &stored
+		s.mu.Unlock()
+	}
+
+	s.logInfo("Service account created", map[string]interface{}{
+		"service_account_id": account.ID.String(),
+		"name":               name,
+		"tenant_id":          tenantID,
+		"key_prefix":         key.KeyPrefix,
+	})
+
+	return account, nil
+}
+
+// hasServiceAccount reports whether an in-memory service account exists for a tenant
+func (s *Service) hasServiceAccount(tenantID uuid.UUID, name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, account := range s.serviceAccounts {
+		if account.TenantID == tenantID && account.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupServiceAccount returns the active service account owning an API key hash, if any
+func (s *Service) lookupServiceAccount(ctx context.Context, keyHash string) (*ServiceAccount, error) {
+	s.mu.RLock()
+	account, ok := s.serviceAccounts[keyHash]
+	s.mu.RUnlock()
+	if ok {
+		if !account.Active {
+			return nil, nil
+		}
+		return account, nil
+	}
+
+	if s.db == nil {
+		return nil, nil
+	}
+
+	query := `
+		SELECT sa.id, sa.tenant_id, sa.name, sa.type, sa.scopes, sa.is_active, sa.created_at
+		FROM mcp.service_accounts sa
+		JOIN mcp.api_keys k ON k.id = sa.api_key_id
+		WHERE k.key_hash = $1 AND sa.is_active = true
+	`
+	var row struct {
+		ID        uuid.UUID      `db:"id"`
+		TenantID  uuid.UUID      `db:"tenant_id"`
+		Name      string         `db:"name"`
+		Type      string         `db:"type"`
+		Scopes    pq.StringArray `db:"scopes"`
+		Active    bool           `db:"is_active"`
+		CreatedAt time.Time      `db:"created_at"`
+	}
+	if err := s.db.GetContext(ctx, &row, query, keyHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &ServiceAccount{
+		ID:        row.ID,
+		TenantID:  row.TenantID,
+		Name:      row.Name,
+		Type:      row.Type,
+		Scopes:    []string(row.Scopes),
+		Active:    row.Active,
+		CreatedAt: row.CreatedAt,
+	}, nil
+}
+
+// attachServiceAccount identifies a user authenticated with a service account key
+// as that service account
+func (s *Service) attachServiceAccount(ctx context.Context, user *User, keyHash string) {
+	account, err := s.lookupServiceAccount(ctx, keyHash)
+	if err != nil {
+		s.logWarn("Failed to look up service account", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if account == nil {
+		return
+	}
+
+	user.ID = account.ID
+	user.Metadata["account_type"] = account.Type
+	user.Metadata["service_account_id"] = account.ID.String()
+	user.Metadata["service_account_name"] = account.Name
+}
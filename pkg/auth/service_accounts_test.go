@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+const serviceAccountTenant = "00000000-0000-0000-0000-000000000001"
+
+func TestCreateServiceAccountInMemory(t *testing.T) {
+	service := NewService(DefaultConfig(), nil, nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	account, err := service.CreateServiceAccount(ctx, "ci-pipeline", serviceAccountTenant, []string{"read", "write"})
+	require.NoError(t, err)
+	assert.Equal(t, ServiceAccountType, account.Type)
+	assert.NotEqual(t, uuid.Nil, account.ID)
+	assert.Contains(t, account.APIKey, "svc_")
+
+	user, err := service.ValidateAPIKey(ctx, account.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, account.ID, user.ID)
+	assert.Equal(t, []string{"read", "write"}, user.Scopes)
+	assert.Equal(t, "ci-pipeline", user.Metadata["service_account_name"])
+	assert.Equal(t, ServiceAccountType, user.Metadata["account_type"])
+
+	_, err = service.CreateServiceAccount(ctx, "ci-pipeline", serviceAccountTenant, nil)
+	assert.Error(t, err, "names are unique per tenant")
+
+	_, err = service.CreateServiceAccount(ctx, " ", serviceAccountTenant, nil)
+	assert.Error(t, err)
+	_, err = service.CreateServiceAccount(ctx, "deployer", "not-a-uuid", nil)
+	assert.Error(t, err)
+}
+
+func TestCreateServiceAccountWithDatabase(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	service := NewService(config, sqlx.NewDb(mockDB, "sqlmock"), nil, observability.NewNoopLogger())
+	ctx := context.Background()
+
+	t.Run("creates account and key", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New().String(), time.Now()))
+		mock.ExpectExec(`INSERT INTO mcp.service_accounts`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		account, err := service.CreateServiceAccount(ctx, "ci-pipeline", serviceAccountTenant, nil)
+		require.NoError(t, err)
+		assert.Equal(t, KeyTypeService.GetScopes(), account.Scopes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		mock.ExpectQuery(`FROM mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"tenant_id", "user_id", "name", "key_type", "scopes", "is_active",
+				"expires_at", "rate_limit", "allowed_services",
+			}).AddRow(serviceAccountTenant, nil, "ci-pipeline", "service", "{read}", true, nil, 1000, "{}"))
+		mock.ExpectQuery(`FROM mcp.service_accounts`).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "tenant_id", "name", "type", "scopes", "is_active", "created_at",
+			}).AddRow(account.ID.String(), serviceAccountTenant, "ci-pipeline", ServiceAccountType, "{read}", true, time.Now()))
+		mock.ExpectExec(`UPDATE mcp.api_keys SET last_used_at`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		user, err := service.ValidateAPIKey(ctx, account.APIKey)
+		require.NoError(t, err)
+		assert.Equal(t, account.ID, user.ID)
+		assert.Equal(t, "ci-pipeline", user.Metadata["service_account_name"])
+
+		// last_used_at is updated in the background
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("removes key when account insert fails", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO mcp.api_keys`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(uuid.New().String(), time.Now()))
+		mock.ExpectExec(`INSERT INTO mcp.service_accounts`).WillReturnError(errors.New("duplicate key value"))
+		mock.ExpectExec(`DELETE FROM mcp.api_keys`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		_, err := service.CreateServiceAccount(ctx, "ci-pipeline", serviceAccountTenant, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "duplicate key value")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
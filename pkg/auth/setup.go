@@ -27,7 +27,7 @@ func SetupAuthentication(db *sqlx.DB, cache cache.Cache, logger observability.Lo
 	baseService := NewService(config, db, cache, logger)
 
 	// Create rate limiter
-	rateLimiter := NewRateLimiter(cache, logger, nil)
+	rateLimiter := NewAttemptRateLimiter(cache, logger, nil)
 
 	// Create metrics and audit
 	metricsCollector := NewMetricsCollector(metrics)
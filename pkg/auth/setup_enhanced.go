@@ -62,7 +62,7 @@ func SetupAuthenticationWithConfig(
 	}
 
 	// Create components with injected config
-	rateLimiter := NewRateLimiter(cache, logger, config.RateLimiter)
+	rateLimiter := NewAttemptRateLimiter(cache, logger, config.RateLimiter)
 	metricsCollector := NewMetricsCollector(metrics)
 	auditLogger := NewAuditLogger(logger)
 
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// TenantProvisioningHook creates default resources for a tenant. Hooks run when
+// a tenant first authenticates and must be idempotent: they run again after a
+// restart and are retried if any hook for the tenant fails.
+type TenantProvisioningHook func(ctx context.Context, tenantID uuid.UUID) error
+
+// AddTenantProvisioningHook registers a hook run on a tenant's first successful
+// API key authentication when AutoProvisionTenants is enabled
+func (s *Service) AddTenantProvisioningHook(hook TenantProvisioningHook) {
+	s.provisionMu.Lock()
+	defer s.provisionMu.Unlock()
+	s.provisioningHooks = append(s.provisioningHooks, hook)
+}
+
+// ensureTenantProvisioned runs the provisioning hooks once for a tenant not yet
+// provisioned by this service. Provisioning failures are logged and retried on
+// the next authentication; they don't fail authentication.
+func (s *Service) ensureTenantProvisioned(ctx context.Context, tenantID uuid.UUID) {
+	if s.config == nil || !s.config.AutoProvisionTenants || tenantID == uuid.Nil {
+		return
+	}
+
+	s.provisionMu.Lock()
+	defer s.provisionMu.Unlock()
+
+	if s.provisionedTenants[tenantID] || len(s.provisioningHooks) == 0 {
+		return
+	}
+
+	for i, hook := range s.provisioningHooks {
+		if err := hook(ctx, tenantID); err != nil {
+			s.logError("Tenant provisioning failed", map[string]interface{}{
+				"tenant_id": tenantID.String(),
+				"hook":      i,
+				"error":     err.Error(),
+			})
+			return
+		}
+	}
+
+	if s.provisionedTenants == nil {
+		s.provisionedTenants = make(map[uuid.UUID]bool)
+	}
+	s.provisionedTenants[tenantID] = true
+
+	s.logInfo("Tenant provisioned", map[string]interface{}{
+		"tenant_id": tenantID.String(),
+		"hooks":     len(s.provisioningHooks),
+	})
+}
+
+// DefaultTenantResourcesHook creates a tenant's configuration record, which holds
+// its quotas and limits, and its default workspace if they don't exist
+func DefaultTenantResourcesHook(db *sqlx.DB) TenantProvisioningHook {
+	return func(ctx context.Context, tenantID uuid.UUID) error {
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO mcp.tenant_config (tenant_id, name)
+			VALUES ($1, $2)
+			ON CONFLICT (tenant_id) DO NOTHING
+		`, tenantID, tenantID.String()); err != nil {
+			return fmt.Errorf("failed to create tenant config: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO mcp.workspaces (tenant_id, name, description)
+			VALUES ($1, 'default', 'Default workspace')
+			ON CONFLICT (tenant_id, name) DO NOTHING
+		`, tenantID); err != nil {
+			return fmt.Errorf("failed to create default workspace: %w", err)
+		}
+
+		return tx.Commit()
+	}
+}
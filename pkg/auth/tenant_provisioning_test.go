@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func newProvisioningAuthService(autoProvision bool) *Service {
+	config := DefaultConfig()
+	config.CacheEnabled = false
+	config.AutoProvisionTenants = autoProvision
+	return NewService(config, nil, nil, observability.NewNoopLogger())
+}
+
+func TestTenantAutoProvisioning(t *testing.T) {
+	ctx := context.Background()
+	tenantA := uuid.New()
+	tenantB := uuid.New()
+
+	t.Run("provisions each new tenant once", func(t *testing.T) {
+		service := newProvisioningAuthService(true)
+		service.apiKeys["tenant-a-key-0123456789"] = &APIKey{Key: "tenant-a-key-0123456789", TenantID: tenantA, Active: true}
+		service.apiKeys["tenant-a-key-9876543210"] = &APIKey{Key: "tenant-a-key-9876543210", TenantID: tenantA, Active: true}
+		service.apiKeys["tenant-b-key-0123456789"] = &APIKey{Key: "tenant-b-key-0123456789", TenantID: tenantB, Active: true}
+
+		provisioned := map[uuid.UUID]int{}
+		service.AddTenantProvisioningHook(func(ctx context.Context, tenantID uuid.UUID) error {
+			provisioned[tenantID]++
+			return nil
+		})
+
+		for _, key := range []string{"tenant-a-key-0123456789", "tenant-a-key-0123456789", "tenant-a-key-9876543210", "tenant-b-key-0123456789"} {
+			_, err := service.ValidateAPIKey(ctx, key)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, map[uuid.UUID]int{tenantA: 1, tenantB: 1}, provisioned)
+	})
+
+	t.Run("retries after a failed hook", func(t *testing.T) {
+		service := newProvisioningAuthService(true)
+		service.apiKeys["tenant-a-key-0123456789"] = &APIKey{Key: "tenant-a-key-0123456789", TenantID: tenantA, Active: true}
+
+		calls := 0
+		service.AddTenantProvisioningHook(func(ctx context.Context, tenantID uuid.UUID) error {
+			calls++
+			if calls == 1 {
+				return errors.New("database unavailable")
+			}
+			return nil
+		})
+
+		for i := 0; i < 3; i++ {
+			// Provisioning failures don't fail authentication
+			_, err := service.ValidateAPIKey(ctx, "tenant-a-key-0123456789")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		service := newProvisioningAuthService(false)
+		service.apiKeys["tenant-a-key-0123456789"] = &APIKey{Key: "tenant-a-key-0123456789", TenantID: tenantA, Active: true}
+
+		calls := 0
+		service.AddTenantProvisioningHook(func(ctx context.Context, tenantID uuid.UUID) error {
+			calls++
+			return nil
+		})
+
+		_, err := service.ValidateAPIKey(ctx, "tenant-a-key-0123456789")
+		require.NoError(t, err)
+		assert.Zero(t, calls)
+		assert.False(t, DefaultConfig().AutoProvisionTenants)
+	})
+
+	t.Run("no provisioning on failed auth", func(t *testing.T) {
+		service := newProvisioningAuthService(true)
+		calls := 0
+		service.AddTenantProvisioningHook(func(ctx context.Context, tenantID uuid.UUID) error {
+			calls++
+			return nil
+		})
+
+		_, err := service.ValidateAPIKey(ctx, "unknown-key-0123456789")
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.Zero(t, calls)
+	})
+}
+
+func TestDefaultTenantResourcesHook(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = mockDB.Close() }()
+
+	tenantID := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO mcp.tenant_config .* ON CONFLICT \(tenant_id\) DO NOTHING`).
+		WithArgs(tenantID, tenantID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO mcp.workspaces .* ON CONFLICT \(tenant_id, name\) DO NOTHING`).
+		WithArgs(tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	hook := DefaultTenantResourcesHook(sqlx.NewDb(mockDB, "sqlmock"))
+	require.NoError(t, hook(context.Background(), tenantID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/security"
+)
+
+// Tool execution audit statuses
+const (
+	ToolAuditStatusSuccess = "success"
+	ToolAuditStatusFailed  = "failed"
+)
+
+// ToolExecutionAuditRecord captures a single tool execution for compliance review
+type ToolExecutionAuditRecord struct {
+	ID            string                 `json:"id"`
+	Timestamp     time.Time              `json:"timestamp"`
+	TenantID      string                 `json:"tenant_id"`
+	AgentID       string                 `json:"agent_id,omitempty"`
+	ToolID        string                 `json:"tool_id"`
+	Action        string                 `json:"action,omitempty"`
+	Source        string                 `json:"source,omitempty"` // e.g. "websocket", "mcp"
+	Arguments     map[string]interface{} `json:"arguments,omitempty"`
+	Status        string                 `json:"status"`
+	Error         string                 `json:"error,omitempty"`
+	DurationMS    int64                  `json:"duration_ms"`
+	ResultSummary string                 `json:"result_summary,omitempty"`
+}
+
+// ToolAuditFilter narrows tool audit queries. Empty fields match everything.
+type ToolAuditFilter struct {
+	TenantID string    `json:"tenant_id,omitempty"`
+	AgentID  string    `json:"agent_id,omitempty"`
+	ToolID   string    `json:"tool_id,omitempty"`
+	Action   string    `json:"action,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	Until    time.Time `json:"until,omitempty"`
+	Limit    int       `json:"limit,omitempty"`
+}
+
+// ToolAuditStore persists and queries tool execution audit records
+type ToolAuditStore interface {
+	Record(ctx context.Context, record *ToolExecutionAuditRecord) error
+	Query(ctx context.Context, filter ToolAuditFilter) ([]*ToolExecutionAuditRecord, error)
+}
+
+// InMemoryToolAuditStore is a bounded in-memory ToolAuditStore that redacts
+// sensitive arguments before a record is stored
+type InMemoryToolAuditStore struct {
+	mu              sync.RWMutex
+	records         []*ToolExecutionAuditRecord
+	maxRecords      int
+	sensitiveFields []string
+	maxSummaryLen   int
+	sequence        int64
+}
+
+// NewInMemoryToolAuditStore creates a new in-memory tool audit store.
+// If sensitiveFields is empty, security.SensitiveFields is used.
+func NewInMemoryToolAuditStore(maxRecords int, sensitiveFields []string) *InMemoryToolAuditStore {
+	if maxRecords <= 0 {
+		maxRecords = 10000
+	}
+	if len(sensitiveFields) == 0 {
+		sensitiveFields = security.SensitiveFields
+	}
+
+	return &InMemoryToolAuditStore{
+		records:         make([]*ToolExecutionAuditRecord, 0),
+		maxRecords:      maxRecords,
+		sensitiveFields: sensitiveFields,
+		maxSummaryLen:   256,
+	}
+}
+
+// Record stores an audit record, redacting its arguments and truncating its result summary
+func (s *InMemoryToolAuditStore) Record(ctx context.Context, record *ToolExecutionAuditRecord) error {
+	if record == nil {
+		return fmt.Errorf("audit record is required")
+	}
+
+	stored := *record
+	stored.Arguments = security.RedactMap(record.Arguments, s.sensitiveFields)
+	if len(stored.ResultSummary) > s.maxSummaryLen {
+		stored.ResultSummary = stored.ResultSummary[:s.maxSummaryLen] + "..."
+	}
+	if stored.Timestamp.IsZero() {
+		stored.Timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	if stored.ID == "" {
+		stored.ID = fmt.Sprintf("audit-%d-%d", stored.Timestamp.UnixNano(), s.sequence)
+	}
+
+	s.records = append(s.records, &stored)
+	if len(s.records) > s.maxRecords {
+		s.records = s.records[len(s.records)-s.maxRecords:]
+	}
+
+	return nil
+}
+
+// Query returns records matching the filter, newest first
+func (s *InMemoryToolAuditStore) Query(ctx context.Context, filter ToolAuditFilter) ([]*ToolExecutionAuditRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := make([]*ToolExecutionAuditRecord, 0)
+	for _, record := range s.records {
+		if !filter.matches(record) {
+			continue
+		}
+		copied := *record
+		results = append(results, &copied)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+
+	if filter.Limit > 0 && len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+
+	return results, nil
+}
+
+func (f ToolAuditFilter) matches(record *ToolExecutionAuditRecord) bool {
+	if f.TenantID != "" && record.TenantID != f.TenantID {
+		return false
+	}
+	if f.AgentID != "" && record.AgentID != f.AgentID {
+		return false
+	}
+	if f.ToolID != "" && record.ToolID != f.ToolID {
+		return false
+	}
+	if f.Action != "" && record.Action != f.Action {
+		return false
+	}
+	if f.Status != "" && record.Status != f.Status {
+		return false
+	}
+	if !f.Since.IsZero() && record.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// SummarizeToolResult renders a tool result as a short string for audit records
+func SummarizeToolResult(result interface{}) string {
+	if result == nil {
+		return ""
+	}
+	if s, ok := result.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Sprintf("%v", result)
+	}
+	return string(data)
+}
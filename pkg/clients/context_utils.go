@@ -19,6 +19,8 @@ const (
 	ContextKeyTenantID ContextKey = "tenant_id"
 	// ContextKeyAgentID is the key for agent ID in context
 	ContextKeyAgentID ContextKey = "agent_id"
+	// ContextKeyToolHeaders is the key for headers forwarded to tool executions
+	ContextKeyToolHeaders ContextKey = "tool_headers"
 )
 
 // TimeoutConfig defines timeout settings for different operations
@@ -79,6 +81,25 @@ func GetOperation(ctx context.Context) string {
 	return "unknown"
 }
 
+// WithToolHeaders adds headers to forward with tool executions to the context.
+// Callers are responsible for only passing headers that are safe to forward.
+func WithToolHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ContextKeyToolHeaders, headers)
+}
+
+// GetToolHeaders retrieves the headers to forward with tool executions from context
+func GetToolHeaders(ctx context.Context) map[string]string {
+	if val := ctx.Value(ContextKeyToolHeaders); val != nil {
+		if headers, ok := val.(map[string]string); ok {
+			return headers
+		}
+	}
+	return nil
+}
+
 // WithTimeout creates a context with timeout based on operation type
 func WithTimeout(ctx context.Context, operation string, config TimeoutConfig) (context.Context, context.CancelFunc) {
 	var timeout time.Duration
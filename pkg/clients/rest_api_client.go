@@ -367,6 +367,10 @@ func (c *restAPIClient) ExecuteTool(ctx context.Context, tenantID, toolID, actio
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	// Forwarded headers can't override the client's own
+	for name, value := range GetToolHeaders(ctx) {
+		req.Header.Set(name, value)
+	}
 	c.setHeaders(req, tenantID)
 	req.Header.Set("Content-Type", "application/json")
 
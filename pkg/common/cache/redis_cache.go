@@ -172,4 +172,9 @@ func (c *RedisCache) Size() int {
 	return 0
 }
 
+// GetClient returns the underlying Redis client
+func (c *RedisCache) GetClient() *redis.Client {
+	return c.client
+}
+
 // Note: RedisClusterCache and related types are defined in redis_cluster.go
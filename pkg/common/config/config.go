@@ -28,6 +28,41 @@ type APIConfig struct {
 	EnableSwagger  bool           `mapstructure:"enable_swagger"`
 	Auth           map[string]any `mapstructure:"auth"`
 	Webhook        map[string]any `mapstructure:"webhook"`
+
+	ClientCertAuth *ClientCertAuthConfig `mapstructure:"client_cert_auth"`
+	MCPTelemetry   *MCPTelemetryConfig   `mapstructure:"mcp_telemetry"`
+
+	ProviderLogging *ProviderLoggingConfig `mapstructure:"provider_logging"`
+}
+
+// ClientCertAuthConfig holds mutual TLS client certificate authentication configuration
+type ClientCertAuthConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	ClientCAFile string `mapstructure:"client_ca_file"`
+	Mode         string `mapstructure:"mode"`
+	URIScheme    string `mapstructure:"uri_scheme"`
+}
+
+// MCPTelemetryConfig holds MCP method latency retention configuration
+type MCPTelemetryConfig struct {
+	RotationInterval time.Duration `mapstructure:"rotation_interval"`
+	Retention        time.Duration `mapstructure:"retention"`
+	Compression      float64       `mapstructure:"compression"`
+}
+
+// ProviderLoggingConfig holds opt-in provider HTTP exchange logging configuration
+type ProviderLoggingConfig struct {
+	Default         ProviderExchangeLogConfig            `mapstructure:"default"`
+	Providers       map[string]ProviderExchangeLogConfig `mapstructure:"providers"`
+	SensitiveFields []string                             `mapstructure:"sensitive_fields"`
+}
+
+// ProviderExchangeLogConfig holds exchange logging configuration for one provider
+type ProviderExchangeLogConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	Level        string `mapstructure:"level"`
+	LogBodies    bool   `mapstructure:"log_bodies"`
+	MaxBodyBytes int    `mapstructure:"max_body_bytes"`
 }
 
 // CoreConfig defines the engine core configuration
@@ -78,6 +113,31 @@ type WebSocketConfig struct {
 	MaxMessageSize  int64                     `mapstructure:"max_message_size"`
 	Security        *WebSocketSecurityConfig  `mapstructure:"security"`
 	RateLimit       *WebSocketRateLimitConfig `mapstructure:"rate_limit"`
+
+	ContextCheckpoint  *WebSocketContextCheckpointConfig  `mapstructure:"context_checkpoint"`
+	BroadcastRateLimit *WebSocketBroadcastRateLimitConfig `mapstructure:"broadcast_rate_limit"`
+	ToolQuota          *WebSocketToolQuotaConfig          `mapstructure:"tool_quota"`
+	ToolOutputLimit    *WebSocketToolOutputLimitConfig    `mapstructure:"tool_output_limit"`
+	ToolRetry          *WebSocketToolRetryConfig          `mapstructure:"tool_retry"`
+	ContextTokenBudget *WebSocketContextTokenBudgetConfig `mapstructure:"context_token_budget"`
+	ToolMetadata       *WebSocketToolMetadataConfig       `mapstructure:"tool_metadata"`
+
+	WorkflowPortability   *WebSocketWorkflowPortabilityConfig   `mapstructure:"workflow_portability"`
+	CompressionDictionary *WebSocketCompressionDictionaryConfig `mapstructure:"compression_dictionary"`
+	RequestDedup          *WebSocketRequestDedupConfig          `mapstructure:"request_dedup"`
+	Keepalive             *WebSocketKeepaliveConfig             `mapstructure:"keepalive"`
+	SharedToolCatalog     *WebSocketSharedToolCatalogConfig     `mapstructure:"shared_tool_catalog"`
+	SubscriptionCleanup   *WebSocketSubscriptionCleanupConfig   `mapstructure:"subscription_cleanup"`
+	ConnectionWarmup      *WebSocketConnectionWarmupConfig      `mapstructure:"connection_warmup"`
+	WorkflowLimits        *WebSocketWorkflowLimitsConfig        `mapstructure:"workflow_limits"`
+	WorkflowResubscribe   *WebSocketWorkflowResubscribeConfig   `mapstructure:"workflow_resubscribe"`
+	ScopeElevation        *WebSocketScopeElevationConfig        `mapstructure:"scope_elevation"`
+
+	// JSON Schemas context metadata must satisfy, by tenant ID
+	ContextMetadataSchemas map[string]interface{} `mapstructure:"context_metadata_schemas"`
+
+	// Input schemas for method params by method, added to or replacing the built-in ones
+	MethodSchemas map[string]interface{} `mapstructure:"method_schemas"`
 }
 
 // WebSocketSecurityConfig holds WebSocket security configuration
@@ -95,6 +155,113 @@ type WebSocketRateLimitConfig struct {
 	PerUser bool    `mapstructure:"per_user"`
 }
 
+// WebSocketContextCheckpointConfig holds automatic context checkpointing configuration
+type WebSocketContextCheckpointConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`
+	AppendInterval int           `mapstructure:"append_interval"`
+	TimeInterval   time.Duration `mapstructure:"time_interval"`
+	TTL            time.Duration `mapstructure:"ttl"`
+}
+
+// WebSocketBroadcastRateLimitConfig holds per-agent workspace broadcast rate limiting configuration
+type WebSocketBroadcastRateLimitConfig struct {
+	MaxBroadcastsPerMinute int            `mapstructure:"max_broadcasts_per_minute"`
+	PerWorkspaceType       map[string]int `mapstructure:"per_workspace_type"`
+}
+
+// WebSocketToolQuotaConfig holds per-agent tool execution quota configuration
+type WebSocketToolQuotaConfig struct {
+	MaxExecutions int           `mapstructure:"max_executions"`
+	Window        time.Duration `mapstructure:"window"`
+}
+
+// WebSocketToolRetryConfig holds configuration for retrying tool executions that fail with a retryable error
+type WebSocketToolRetryConfig struct {
+	MaxRetries     int           `mapstructure:"max_retries"`
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+}
+
+// WebSocketToolOutputLimitConfig holds tool output size cap configuration
+type WebSocketToolOutputLimitConfig struct {
+	MaxBytes  int            `mapstructure:"max_bytes"`
+	PerTool   map[string]int `mapstructure:"per_tool"`
+	Policy    string         `mapstructure:"policy"`
+	ResultTTL time.Duration  `mapstructure:"result_ttl"`
+}
+
+// WebSocketContextTokenBudgetConfig holds context.append max token enforcement configuration
+type WebSocketContextTokenBudgetConfig struct {
+	Strategy  string `mapstructure:"strategy"`
+	MaxTokens int    `mapstructure:"max_tokens"`
+}
+
+// WebSocketToolMetadataConfig holds configuration for metadata forwarded to tool executions
+type WebSocketToolMetadataConfig struct {
+	ForwardKeys []string `mapstructure:"forward_keys"`
+}
+
+// WebSocketWorkflowPortabilityConfig holds workflow export/import configuration
+type WebSocketWorkflowPortabilityConfig struct {
+	SigningKey string `mapstructure:"signing_key"`
+}
+
+// WebSocketCompressionDictionaryConfig holds binary protocol compression dictionary configuration
+type WebSocketCompressionDictionaryConfig struct {
+	Disabled bool   `mapstructure:"disabled"`
+	Version  uint32 `mapstructure:"version"`
+	Path     string `mapstructure:"path"`
+}
+
+// WebSocketRequestDedupConfig holds read-only request deduplication configuration
+type WebSocketRequestDedupConfig struct {
+	Disabled bool          `mapstructure:"disabled"`
+	Window   time.Duration `mapstructure:"window"`
+}
+
+// WebSocketKeepaliveConfig holds connection keepalive and stale detection configuration
+type WebSocketKeepaliveConfig struct {
+	MaxMissedPongs int           `mapstructure:"max_missed_pongs"`
+	StaleAfter     time.Duration `mapstructure:"stale_after"`
+}
+
+// WebSocketSharedToolCatalogConfig holds configuration for tools shared across tenants
+type WebSocketSharedToolCatalogConfig struct {
+	TenantID string `mapstructure:"tenant_id"`
+}
+
+// WebSocketSubscriptionCleanupConfig holds orphaned subscription cleanup configuration
+type WebSocketSubscriptionCleanupConfig struct {
+	Disabled        bool          `mapstructure:"disabled"`
+	Interval        time.Duration `mapstructure:"interval"`
+	ReplayRetention time.Duration `mapstructure:"replay_retention"`
+}
+
+// WebSocketConnectionWarmupConfig holds connection warm-up configuration
+type WebSocketConnectionWarmupConfig struct {
+	Disabled bool          `mapstructure:"disabled"`
+	TTL      time.Duration `mapstructure:"ttl"`
+}
+
+// WebSocketWorkflowLimitsConfig holds workflow step count and depth limits
+type WebSocketWorkflowLimitsConfig struct {
+	MaxSteps int `mapstructure:"max_steps"`
+	MaxDepth int `mapstructure:"max_depth"`
+}
+
+// WebSocketWorkflowResubscribeConfig holds workflow resume re-subscription configuration
+type WebSocketWorkflowResubscribeConfig struct {
+	Disabled bool `mapstructure:"disabled"`
+}
+
+// WebSocketScopeElevationConfig holds temporary scope elevation configuration
+type WebSocketScopeElevationConfig struct {
+	AllowedScopes     []string      `mapstructure:"allowed_scopes"`
+	AutoApproveScopes []string      `mapstructure:"auto_approve_scopes"`
+	MaxDuration       time.Duration `mapstructure:"max_duration"`
+	RequestTTL        time.Duration `mapstructure:"request_ttl"`
+}
+
 // AWSConfig holds configuration for AWS services
 type AWSConfig struct {
 	RDS         aws.RDSConfig         `mapstructure:"rds"`
@@ -104,7 +271,31 @@ type AWSConfig struct {
 
 // EmbeddingConfig contains configuration for the embedding system
 type EmbeddingConfig struct {
-	Providers ProvidersConfig `mapstructure:"providers"`
+	Providers     ProvidersConfig     `mapstructure:"providers"`
+	FallbackChain []string            `mapstructure:"fallback_chain"` // "provider:model" entries tried when generation fails
+	Normalization NormalizationConfig `mapstructure:"normalization"`
+	Deduplication DeduplicationConfig `mapstructure:"deduplication"`
+	Experiment    ExperimentConfig    `mapstructure:"experiment"`
+}
+
+// ExperimentConfig contains configuration for A/B testing a candidate embedding model
+type ExperimentConfig struct {
+	Name            string  `mapstructure:"name"`
+	CandidateModel  string  `mapstructure:"candidate_model"`  // "provider:model"
+	TrafficFraction float64 `mapstructure:"traffic_fraction"` // Fraction of traffic using the candidate, 0-1
+}
+
+// DeduplicationConfig contains configuration for near-duplicate detection at index time
+type DeduplicationConfig struct {
+	Enabled   bool    `mapstructure:"enabled"`
+	Threshold float64 `mapstructure:"threshold"` // Similarity at or above which content is a near-duplicate
+	Policy    string  `mapstructure:"policy"`    // skip, merge or link
+}
+
+// NormalizationConfig contains configuration for L2 normalization of embeddings
+type NormalizationConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Metric  string `mapstructure:"metric"` // cosine, inner_product or euclidean
 }
 
 // ProvidersConfig contains configuration for embedding providers
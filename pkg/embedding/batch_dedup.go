@@ -0,0 +1,21 @@
+package embedding
+
+// dedupeTexts returns the distinct texts of a batch in first-seen order, and
+// for each text its position among them, so each distinct text is embedded
+// once and the result is mapped back to every occurrence
+func dedupeTexts(texts []string) ([]string, []int) {
+	unique := make([]string, 0, len(texts))
+	positions := make([]int, len(texts))
+	seen := make(map[string]int, len(texts))
+
+	for i, text := range texts {
+		pos, ok := seen[text]
+		if !ok {
+			pos = len(unique)
+			seen[text] = pos
+			unique = append(unique, text)
+		}
+		positions[i] = pos
+	}
+	return unique, positions
+}
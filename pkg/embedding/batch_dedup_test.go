@@ -0,0 +1,121 @@
+package embedding
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/agents"
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+// capturedArg matches any argument and records its value
+type capturedArg struct {
+	value driver.Value
+}
+
+func (c *capturedArg) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+func TestDedupeTexts(t *testing.T) {
+	unique, positions := dedupeTexts([]string{"a", "b", "a", "c", "b"})
+	assert.Equal(t, []string{"a", "b", "c"}, unique)
+	assert.Equal(t, []int{0, 1, 0, 2, 1}, positions)
+
+	unique, positions = dedupeTexts(nil)
+	assert.Empty(t, unique)
+	assert.Empty(t, positions)
+}
+
+func TestGenerateBatchDeduplicatesTexts(t *testing.T) {
+	mockProvider := providers.NewMockProvider("openai")
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers:    map[string]providers.Provider{"openai": mockProvider},
+		AgentService: &MockAgentService{},
+		Repository:   NewRepository(nil),
+	})
+	require.NoError(t, err)
+
+	texts := []string{"alpha", "beta", "alpha", "gamma", "beta"}
+	embeddings, err := service.GenerateBatch(context.Background(), texts, "mock-model-small")
+	require.NoError(t, err)
+	require.Len(t, embeddings, len(texts))
+
+	// The provider embeds each distinct text once
+	calls := mockProvider.GetBatchGenerateCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"alpha", "beta", "gamma"}, calls[0].Texts)
+
+	// Every occurrence gets its text's vector
+	assert.Equal(t, embeddings[0], embeddings[2])
+	assert.Equal(t, embeddings[1], embeddings[4])
+	assert.NotEqual(t, embeddings[0], embeddings[1])
+	assert.NotEqual(t, embeddings[0], embeddings[3])
+	assert.NotEqual(t, embeddings[1], embeddings[3])
+}
+
+func TestBatchGenerateEmbeddingsDeduplicatesTexts(t *testing.T) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	texts := []string{"alpha", "beta", "alpha", "alpha"}
+	contents := make([]*capturedArg, len(texts))
+	vectors := make([]*capturedArg, len(texts))
+	for i := range texts {
+		contents[i], vectors[i] = &capturedArg{}, &capturedArg{}
+		dbMock.ExpectQuery("SELECT mcp.insert_embedding").
+			WithArgs(
+				sqlmock.AnyArg(), contents[i], vectors[i], sqlmock.AnyArg(), sqlmock.AnyArg(),
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	}
+
+	agentService := &MockAgentService{}
+	agentService.On("GetConfig", mock.Anything, "test-agent").Return(&agents.AgentConfig{AgentID: "test-agent"}, nil)
+	agentService.On("GetModelsForAgent", mock.Anything, "test-agent", agents.TaskTypeGeneralQA).
+		Return([]string{"mock-model-small"}, []string{}, nil)
+
+	mockProvider := providers.NewMockProvider("openai")
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers:    map[string]providers.Provider{"openai": mockProvider},
+		AgentService: agentService,
+		Repository:   NewRepository(db),
+	})
+	require.NoError(t, err)
+
+	tenantID := uuid.New()
+	reqs := make([]GenerateEmbeddingRequest, len(texts))
+	for i, text := range texts {
+		reqs[i] = GenerateEmbeddingRequest{AgentID: "test-agent", Text: text, TenantID: tenantID}
+	}
+
+	resps, err := service.BatchGenerateEmbeddings(context.Background(), reqs)
+	require.NoError(t, err)
+	require.Len(t, resps, len(texts))
+	for _, resp := range resps {
+		require.NotNil(t, resp)
+	}
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	calls := mockProvider.GetBatchGenerateCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, []string{"alpha", "beta"}, calls[0].Texts)
+
+	// Each request is stored with its own content and its text's vector
+	for i, text := range texts {
+		assert.Equal(t, text, contents[i].value)
+	}
+	assert.Equal(t, vectors[0].value, vectors[2].value)
+	assert.Equal(t, vectors[0].value, vectors[3].value)
+	assert.NotEqual(t, vectors[0].value, vectors[1].value)
+}
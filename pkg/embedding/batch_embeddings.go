@@ -0,0 +1,155 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultEmbeddingBatchSize is the texts per request for providers without a known limit
+	DefaultEmbeddingBatchSize = 100
+	// DefaultBatchConcurrency is how many provider requests a batch generation has in flight
+	DefaultBatchConcurrency = 4
+)
+
+// providerMaxBatchSizes is the most texts each provider accepts in one request
+var providerMaxBatchSizes = map[string]int{
+	"openai": 2048,
+	"voyage": 128,
+}
+
+// BatchEmbeddingOptions configures GenerateEmbeddingsWithOptions
+type BatchEmbeddingOptions struct {
+	// MaxBatchSize lowers the texts per provider request below the provider's limit
+	MaxBatchSize int
+	// MaxConcurrency caps the provider requests in flight, DefaultBatchConcurrency when zero
+	MaxConcurrency int
+	// ProgressCallback is called with the number of texts embedded so far as
+	// each batch completes. Calls are never concurrent.
+	ProgressCallback func(completed, total int)
+	// Results receives each batch's embeddings as soon as the batch completes,
+	// for callers that stream them. It is closed when generation returns.
+	Results chan<- BatchEmbeddingResult
+}
+
+// BatchEmbeddingResult is one completed batch of a batch generation
+type BatchEmbeddingResult struct {
+	// Offset is the index of the batch's first text in the input
+	Offset  int
+	Vectors []*EmbeddingVector
+}
+
+// GenerateEmbeddings embeds many texts with the given model, or the first
+// active model when none is given. The texts are split into batches of the
+// provider's maximum size, which are requested in parallel, and the
+// embeddings are returned in input order.
+func (s *ServiceV2) GenerateEmbeddings(ctx context.Context, texts []string, contentType, model string) ([]*EmbeddingVector, error) {
+	return s.GenerateEmbeddingsWithOptions(ctx, texts, contentType, model, BatchEmbeddingOptions{})
+}
+
+// GenerateEmbeddingsWithOptions is GenerateEmbeddings with control over batch
+// sizes and concurrency, progress reporting and streaming of partial results.
+// Batches that completed before an error are still sent to opts.Results.
+func (s *ServiceV2) GenerateEmbeddingsWithOptions(ctx context.Context, texts []string, contentType, model string, opts BatchEmbeddingOptions) ([]*EmbeddingVector, error) {
+	if opts.Results != nil {
+		defer close(opts.Results)
+	}
+
+	if len(texts) == 0 {
+		return []*EmbeddingVector{}, nil
+	}
+
+	provider, providerName, model, err := s.batchProvider(model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Embed the text extracted from each content
+	inputs := texts
+	if contentType != "" {
+		inputs = make([]string, len(texts))
+		for i, text := range texts {
+			extracted, err := s.extractors.Extract(ctx, contentType, text)
+			if err != nil {
+				return nil, fmt.Errorf("text %d: invalid request: %w", i, err)
+			}
+			inputs[i] = extracted
+		}
+	}
+
+	batchSize := batchSizeFor(providerName, opts.MaxBatchSize)
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	vectors := make([]*EmbeddingVector, len(texts))
+	var (
+		mu        sync.Mutex
+		completed int
+	)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for offset := 0; offset < len(inputs); offset += batchSize {
+		end := min(offset+batchSize, len(inputs))
+
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+
+			embeddings, err := s.embedBatch(gctx, provider, providerName, model, inputs[offset:end])
+			if err != nil {
+				return fmt.Errorf("batch of texts %d-%d failed: %w", offset, end-1, err)
+			}
+
+			batch := vectors[offset:end]
+			for i, embedding := range embeddings {
+				batch[i] = &EmbeddingVector{
+					Vector:      embedding,
+					Dimensions:  len(embedding),
+					ModelID:     model,
+					ContentType: contentType,
+				}
+			}
+
+			if opts.ProgressCallback != nil {
+				mu.Lock()
+				completed += len(batch)
+				opts.ProgressCallback(completed, len(texts))
+				mu.Unlock()
+			}
+
+			if opts.Results != nil {
+				select {
+				case opts.Results <- BatchEmbeddingResult{Offset: offset, Vectors: batch}:
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return vectors, nil
+}
+
+// batchSizeFor returns the texts per request for a provider, capped by the
+// caller's maximum when one is set
+func batchSizeFor(providerName string, maxBatchSize int) int {
+	size, ok := providerMaxBatchSizes[providerName]
+	if !ok {
+		size = DefaultEmbeddingBatchSize
+	}
+	if maxBatchSize > 0 && maxBatchSize < size {
+		size = maxBatchSize
+	}
+	return size
+}
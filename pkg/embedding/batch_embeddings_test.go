@@ -0,0 +1,183 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+// trackingProvider records how many batch requests are in flight at once and
+// fails, without retries, any batch containing failOn
+type trackingProvider struct {
+	*providers.MockProvider
+	failOn string
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (p *trackingProvider) BatchGenerateEmbeddings(ctx context.Context, req providers.BatchGenerateEmbeddingRequest) (*providers.BatchEmbeddingResponse, error) {
+	p.mu.Lock()
+	p.inFlight++
+	p.maxInFlight = max(p.maxInFlight, p.inFlight)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	for _, text := range req.Texts {
+		if p.failOn != "" && text == p.failOn {
+			return nil, &providers.ProviderError{Provider: p.Name(), Code: "INVALID_INPUT", Message: "rejected input"}
+		}
+	}
+	return p.MockProvider.BatchGenerateEmbeddings(ctx, req)
+}
+
+func newBatchTestService(t *testing.T, provider providers.Provider) *ServiceV2 {
+	t.Helper()
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers:    map[string]providers.Provider{provider.Name(): provider},
+		AgentService: &MockAgentService{},
+		Repository:   NewRepository(nil),
+	})
+	require.NoError(t, err)
+	return service
+}
+
+func batchTestTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("func handler%d(w http.ResponseWriter, r *http.Request)", i)
+	}
+	return texts
+}
+
+func TestGenerateEmbeddingsSplitsByProviderLimit(t *testing.T) {
+	tests := []struct {
+		provider     string
+		texts        int
+		maxBatchSize int
+		batches      []int
+	}{
+		{provider: "voyage", texts: 300, batches: []int{128, 128, 44}},
+		{provider: "openai", texts: 2500, batches: []int{2048, 452}},
+		{provider: "openai", texts: 250, maxBatchSize: 100, batches: []int{100, 100, 50}},
+		{provider: "bedrock", texts: 150, batches: []int{100, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %d texts", tt.provider, tt.texts), func(t *testing.T) {
+			provider := providers.NewMockProvider(tt.provider, providers.WithLatency(0))
+			service := newBatchTestService(t, provider)
+			texts := batchTestTexts(tt.texts)
+
+			vectors, err := service.GenerateEmbeddingsWithOptions(context.Background(), texts, "", "mock-model-small", BatchEmbeddingOptions{
+				MaxBatchSize: tt.maxBatchSize,
+			})
+			require.NoError(t, err)
+			require.Len(t, vectors, len(texts))
+
+			var sizes []int
+			for _, call := range provider.GetBatchGenerateCalls() {
+				sizes = append(sizes, len(call.Texts))
+			}
+			sort.Sort(sort.Reverse(sort.IntSlice(sizes)))
+			assert.Equal(t, tt.batches, sizes)
+
+			// Results are merged back in input order
+			single, err := service.GenerateEmbeddings(context.Background(), texts[len(texts)-1:], "", "mock-model-small")
+			require.NoError(t, err)
+			assert.Equal(t, single[0].Vector, vectors[len(vectors)-1].Vector)
+			assert.Equal(t, "mock-model-small", vectors[0].ModelID)
+			assert.Equal(t, len(vectors[0].Vector), vectors[0].Dimensions)
+		})
+	}
+}
+
+func TestGenerateEmbeddingsWithOptions(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("limits concurrent requests", func(t *testing.T) {
+		provider := &trackingProvider{MockProvider: providers.NewMockProvider("voyage", providers.WithLatency(0))}
+		service := newBatchTestService(t, provider)
+
+		_, err := service.GenerateEmbeddingsWithOptions(ctx, batchTestTexts(1000), "", "mock-model-small", BatchEmbeddingOptions{
+			MaxConcurrency: 3,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, provider.maxInFlight)
+	})
+
+	t.Run("reports progress and streams each batch", func(t *testing.T) {
+		service := newBatchTestService(t, providers.NewMockProvider("voyage", providers.WithLatency(0)))
+		texts := batchTestTexts(300)
+
+		var progress []int
+		results := make(chan BatchEmbeddingResult, 3)
+		vectors, err := service.GenerateEmbeddingsWithOptions(ctx, texts, "", "mock-model-small", BatchEmbeddingOptions{
+			ProgressCallback: func(completed, total int) {
+				assert.Equal(t, len(texts), total)
+				progress = append(progress, completed)
+			},
+			Results: results,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{len(texts)}, progress[len(progress)-1:])
+		assert.IsIncreasing(t, progress)
+
+		streamed := make([]*EmbeddingVector, len(texts))
+		for result := range results {
+			copy(streamed[result.Offset:], result.Vectors)
+		}
+		assert.Equal(t, vectors, streamed)
+	})
+
+	t.Run("streams completed batches before failing", func(t *testing.T) {
+		texts := batchTestTexts(300)
+		provider := &trackingProvider{MockProvider: providers.NewMockProvider("voyage", providers.WithLatency(0)), failOn: texts[299]}
+		service := newBatchTestService(t, provider)
+
+		results := make(chan BatchEmbeddingResult, 3)
+		_, err := service.GenerateEmbeddingsWithOptions(ctx, texts, "", "mock-model-small", BatchEmbeddingOptions{
+			MaxConcurrency: 1,
+			Results:        results,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "texts 256-299")
+
+		var offsets []int
+		for result := range results {
+			offsets = append(offsets, result.Offset)
+		}
+		assert.Equal(t, []int{0, 128}, offsets)
+	})
+
+	t.Run("embeds extracted content", func(t *testing.T) {
+		provider := providers.NewMockProvider("openai", providers.WithLatency(0))
+		service := newBatchTestService(t, provider)
+
+		vectors, err := service.GenerateEmbeddings(ctx, []string{"<p>Retry with <b>backoff</b></p>"}, "text/html", "mock-model-small")
+		require.NoError(t, err)
+		require.Len(t, vectors, 1)
+		assert.Equal(t, "text/html", vectors[0].ContentType)
+		assert.NotContains(t, provider.GetBatchGenerateCalls()[0].Texts[0], "<b>")
+	})
+
+	t.Run("rejects unknown models", func(t *testing.T) {
+		service := newBatchTestService(t, providers.NewMockProvider("openai", providers.WithLatency(0)))
+		_, err := service.GenerateEmbeddings(ctx, []string{"text"}, "", "no-such-model")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+// BatchItemFailure reports a request of a batch that couldn't be embedded
+type BatchItemFailure struct {
+	Index     int    `json:"index"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error"`
+	// Retryable is whether resubmitting the request may succeed, as opposed
+	// to it being rejected for its content or configuration
+	Retryable bool `json:"retryable"`
+}
+
+// PartialBatchError is returned by BatchGenerateEmbeddings when some of its
+// requests failed. The responses returned alongside it hold the embedded and
+// stored requests, with nil at the failed requests' indices.
+type PartialBatchError struct {
+	Total    int
+	Failures []BatchItemFailure
+}
+
+func (e *PartialBatchError) Error() string {
+	return fmt.Sprintf("batch processing failed for %d of %d requests: request %d: %s",
+		len(e.Failures), e.Total, e.Failures[0].Index, e.Failures[0].Error)
+}
+
+// batchFailures collects the failures of a batch's concurrently processed requests
+type batchFailures struct {
+	mu       sync.Mutex
+	failures []BatchItemFailure
+}
+
+func (f *batchFailures) add(index int, req GenerateEmbeddingRequest, err error, retryable bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, BatchItemFailure{
+		Index:     index,
+		RequestID: req.RequestID,
+		Error:     err.Error(),
+		Retryable: retryable,
+	})
+}
+
+// err returns the failures ordered by index, or nil when there were none
+func (f *batchFailures) err(total int) error {
+	if len(f.failures) == 0 {
+		return nil
+	}
+	sort.Slice(f.failures, func(i, j int) bool {
+		return f.failures[i].Index < f.failures[j].Index
+	})
+	return &PartialBatchError{Total: total, Failures: f.failures}
+}
+
+// isRetryableEmbeddingError reports whether a request that failed with err
+// may succeed if resubmitted. Provider errors say so themselves, a cancelled
+// request won't be resubmitted, and anything else is assumed transient.
+func isRetryableEmbeddingError(err error) bool {
+	var providerErr *providers.ProviderError
+	switch {
+	case errors.As(err, &providerErr):
+		return providerErr.IsRetryable
+	case errors.Is(err, context.Canceled):
+		return false
+	default:
+		return true
+	}
+}
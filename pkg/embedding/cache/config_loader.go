@@ -56,6 +56,27 @@ func LoadConfigFromViper() (*Config, error) {
 	}
 
 	// Load eviction strategy configuration
+	if strategy := viper.GetString("cache.semantic.eviction.strategy"); strategy != "" {
+		if err := ValidateEvictionPolicy(strategy); err != nil {
+			return nil, err
+		}
+		config.EvictionPolicy = strategy
+	}
+	if interval := viper.GetDuration("cache.semantic.eviction.min_interval"); interval > 0 {
+		config.EvictionInterval = interval
+	}
+
+	// Load local tier configuration
+	if viper.IsSet("cache.semantic.local.max_entries") {
+		config.LocalCacheSize = viper.GetInt("cache.semantic.local.max_entries")
+	}
+	if promotionHits := viper.GetInt("cache.semantic.local.promotion_hits"); promotionHits > 0 {
+		config.LocalPromotionHits = promotionHits
+	}
+	if ttl := viper.GetDuration("cache.semantic.local.ttl"); ttl > 0 {
+		config.LocalCacheTTL = ttl
+	}
+
 	if maxCandidates := viper.GetInt("cache.semantic.redis.max_candidates"); maxCandidates > 0 {
 		config.MaxCandidates = maxCandidates
 	}
@@ -80,6 +101,7 @@ type SemanticCacheConfig struct {
 	Warmup         WarmupConfig         `mapstructure:"warmup"`
 	Monitoring     MonitoringConfig     `mapstructure:"monitoring"`
 	Eviction       EvictionConfig       `mapstructure:"eviction"`
+	Local          LocalTierConfig      `mapstructure:"local"`
 }
 
 // RedisCacheConfig represents Redis-specific cache configuration
@@ -140,6 +162,14 @@ type EvictionConfig struct {
 	Strategy      string        `mapstructure:"strategy"`
 	CheckInterval time.Duration `mapstructure:"check_interval"`
 	BatchSize     int           `mapstructure:"batch_size"`
+	MinInterval   time.Duration `mapstructure:"min_interval"` // Between tiered eviction passes
+}
+
+// LocalTierConfig represents the in-process tier of hot entries in front of Redis
+type LocalTierConfig struct {
+	MaxEntries    int           `mapstructure:"max_entries"` // 0 disables the local tier
+	PromotionHits int           `mapstructure:"promotion_hits"`
+	TTL           time.Duration `mapstructure:"ttl"`
 }
 
 // LoadSemanticCacheConfig loads the complete semantic cache configuration
@@ -185,5 +215,9 @@ func validateSemanticCacheConfig(config *SemanticCacheConfig) error {
 		return fmt.Errorf("max_query_length must be positive")
 	}
 
+	if err := ValidateEvictionPolicy(config.Eviction.Strategy); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -37,6 +37,10 @@ const (
 	DefaultMaxCacheEntries = 10000
 	DefaultMaxCacheBytes   = 100 * 1024 * 1024 // 100MB
 	DefaultMaxCacheSize    = 1000              // Default entries
+
+	// Local tier
+	DefaultLocalCacheSize     = 1000 // Hot entries kept in process memory
+	DefaultLocalPromotionHits = 3    // Hits before an entry is promoted
 )
 
 // Time constants
@@ -55,10 +59,11 @@ const (
 	DefaultCheckPeriod      = 5 * time.Second
 
 	// TTLs
-	DefaultCacheTTL    = 24 * time.Hour
-	DefaultConfigTTL   = 5 * time.Minute
-	DefaultLockTTL     = 30 * time.Second
-	DefaultFallbackTTL = 15 * time.Minute
+	DefaultCacheTTL      = 24 * time.Hour
+	DefaultConfigTTL     = 5 * time.Minute
+	DefaultLocalCacheTTL = 1 * time.Minute
+	DefaultLockTTL       = 30 * time.Second
+	DefaultFallbackTTL   = 15 * time.Minute
 )
 
 // Validation constants
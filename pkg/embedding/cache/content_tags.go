@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+)
+
+// Cached search results are tagged with the content they include: the ID of each
+// result and the context it belongs to. When that content is mutated the tag is
+// invalidated, purging every cached result it contributed to.
+
+func (c *SemanticCache) contentTagKey(contentID string) string {
+	return fmt.Sprintf("%s:tag:content:%s", c.config.Prefix, SanitizeRedisKey(contentID))
+}
+
+// resultContentIDs returns the IDs of the content cached results were built from
+func resultContentIDs(results []CachedSearchResult) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, result := range results {
+		add(result.ID)
+		if contextID, ok := result.Metadata["context_id"].(string); ok {
+			add(contextID)
+		}
+	}
+	return ids
+}
+
+// tagContent records a cache entry under the tags of the content it was built from
+func (c *SemanticCache) tagContent(ctx context.Context, key string, results []CachedSearchResult) error {
+	contentIDs := resultContentIDs(results)
+	if c.config.DisableContentTags || len(contentIDs) == 0 {
+		return nil
+	}
+
+	_, err := c.redis.Execute(ctx, func() (interface{}, error) {
+		pipe := c.redis.GetClient().TxPipeline()
+		for _, contentID := range contentIDs {
+			tagKey := c.contentTagKey(contentID)
+			pipe.SAdd(ctx, tagKey, key)
+			// Tags expire with the entries they point to
+			pipe.Expire(ctx, tagKey, c.config.TTL)
+		}
+		return pipe.Exec(ctx)
+	})
+	return err
+}
+
+// InvalidateContent purges every cached search result that includes any of the
+// given content. It's called when content is created, updated or deleted.
+func (c *SemanticCache) InvalidateContent(ctx context.Context, contentIDs ...string) error {
+	if c.config.DisableContentTags || len(contentIDs) == 0 {
+		return nil
+	}
+
+	tagKeys := make([]string, 0, len(contentIDs))
+	for _, contentID := range contentIDs {
+		tagKeys = append(tagKeys, c.contentTagKey(contentID))
+	}
+
+	result, err := c.redis.Execute(ctx, func() (interface{}, error) {
+		return c.redis.GetClient().SUnion(ctx, tagKeys...).Result()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read content tags: %w", err)
+	}
+	keys := result.([]string)
+
+	if err := c.redis.Del(ctx, append(keys, tagKeys...)...); err != nil {
+		return fmt.Errorf("failed to invalidate content: %w", err)
+	}
+	c.invalidateLocal(ctx, keys...)
+
+	// Drop the invalidated entries from the similarity index too
+	if c.vectorStore != nil {
+		if tenantID := auth.GetTenantID(ctx); tenantID != uuid.Nil {
+			for _, key := range keys {
+				if err := c.vectorStore.DeleteCacheEntry(ctx, tenantID, key); err != nil {
+					c.logger.Warn("Failed to delete embedding", map[string]interface{}{
+						"error": err.Error(),
+						"key":   key,
+					})
+				}
+			}
+		}
+	}
+
+	if c.metrics != nil && len(keys) > 0 {
+		c.metrics.IncrementCounter("semantic_cache.content_invalidations", float64(len(keys)))
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemanticCache_InvalidateContent(t *testing.T) {
+	ctx := context.Background()
+
+	redisResults := []CachedSearchResult{
+		{ID: "emb-1", Content: "Redis implementation guide", Score: 0.9, Metadata: map[string]interface{}{"context_id": "ctx-1"}},
+		{ID: "emb-2", Content: "Cache patterns", Score: 0.8},
+	}
+	postgresResults := []CachedSearchResult{
+		{ID: "emb-3", Content: "Postgres indexing", Score: 0.9, Metadata: map[string]interface{}{"context_id": "ctx-2"}},
+	}
+
+	t.Run("invalidating a result purges the entries including it", func(t *testing.T) {
+		cache, _, cleanup := setupTestCache(t)
+		defer cleanup()
+
+		require.NoError(t, cache.Set(ctx, "redis caching", nil, redisResults))
+		require.NoError(t, cache.Set(ctx, "cache patterns", nil, redisResults[1:]))
+		require.NoError(t, cache.Set(ctx, "postgres indexes", nil, postgresResults))
+
+		require.NoError(t, cache.InvalidateContent(ctx, "emb-2"))
+
+		for _, query := range []string{"redis caching", "cache patterns"} {
+			entry, err := cache.Get(ctx, query, nil)
+			require.NoError(t, err)
+			assert.Nil(t, entry, query)
+		}
+		entry, err := cache.Get(ctx, "postgres indexes", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("invalidating a context purges entries with its results", func(t *testing.T) {
+		cache, _, cleanup := setupTestCache(t)
+		defer cleanup()
+
+		require.NoError(t, cache.Set(ctx, "redis caching", nil, redisResults))
+		require.NoError(t, cache.Set(ctx, "postgres indexes", nil, postgresResults))
+
+		require.NoError(t, cache.InvalidateContent(ctx, "ctx-2"))
+
+		entry, err := cache.Get(ctx, "postgres indexes", nil)
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+		entry, err = cache.Get(ctx, "redis caching", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("tags are removed with the entries", func(t *testing.T) {
+		cache, mr, cleanup := setupTestCache(t)
+		defer cleanup()
+
+		require.NoError(t, cache.Set(ctx, "redis caching", nil, redisResults))
+		assert.True(t, mr.Exists(cache.contentTagKey("emb-1")))
+		assert.True(t, mr.Exists(cache.contentTagKey("ctx-1")))
+
+		require.NoError(t, cache.InvalidateContent(ctx, "emb-1"))
+		assert.False(t, mr.Exists(cache.contentTagKey("emb-1")))
+
+		// Unknown content is a no-op
+		require.NoError(t, cache.InvalidateContent(ctx, "missing"))
+	})
+
+	t.Run("content tags can be disabled", func(t *testing.T) {
+		cache, mr, cleanup := setupTestCache(t)
+		defer cleanup()
+		cache.config.DisableContentTags = true
+
+		require.NoError(t, cache.Set(ctx, "redis caching", nil, redisResults))
+		assert.False(t, mr.Exists(cache.contentTagKey("emb-1")))
+
+		require.NoError(t, cache.InvalidateContent(ctx, "emb-1"))
+		entry, err := cache.Get(ctx, "redis caching", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, entry)
+	})
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Eviction policies for entries above MaxCacheSize
+const (
+	// EvictionPolicyLRU evicts the least recently accessed entries
+	EvictionPolicyLRU = "lru"
+	// EvictionPolicyLFU evicts the least frequently hit entries, least recently accessed first on ties
+	EvictionPolicyLFU = "lfu"
+	// EvictionPolicyTTLWeighted evicts the entries closest to expiring
+	EvictionPolicyTTLWeighted = "ttl_weighted"
+	// EvictionPolicyHitWeighted evicts the entries with the fewest hits per hour cached,
+	// so new entries aren't evicted for not having built up hits yet
+	EvictionPolicyHitWeighted = "hit_weighted"
+	// EvictionPolicyTiered evicts the entries ranked lowest by hits, recency and
+	// embedding quality, at least TieredEvictionFraction of them per pass, from a
+	// rate limited background TieredEvictor
+	EvictionPolicyTiered = "tiered"
+)
+
+// ValidateEvictionPolicy returns an error for unknown eviction policies. An empty
+// policy leaves eviction to the tenant LRU manager.
+func ValidateEvictionPolicy(policy string) error {
+	switch policy {
+	case "", EvictionPolicyLRU, EvictionPolicyLFU, EvictionPolicyTTLWeighted, EvictionPolicyHitWeighted, EvictionPolicyTiered:
+		return nil
+	default:
+		return fmt.Errorf("unknown eviction policy: %s", policy)
+	}
+}
+
+// evictionCandidate is a cached entry considered for eviction
+type evictionCandidate struct {
+	key   string
+	entry *CacheEntry
+	score float64
+}
+
+// evictionScore scores an entry under a policy; lower scores are evicted first
+func evictionScore(policy string, entry *CacheEntry, now time.Time) float64 {
+	switch policy {
+	case EvictionPolicyLFU:
+		return float64(entry.HitCount)
+	case EvictionPolicyTTLWeighted:
+		if entry.TTL <= 0 {
+			return math.MaxFloat64 // Entries without a TTL never expire
+		}
+		return float64(entry.CachedAt.Add(entry.TTL).Sub(now))
+	case EvictionPolicyHitWeighted:
+		hoursCached := now.Sub(entry.CachedAt).Hours()
+		if hoursCached < 0 {
+			hoursCached = 0
+		}
+		return float64(entry.HitCount+1) / (hoursCached + 1)
+	case EvictionPolicyTiered:
+		return tieredScore(entry, now)
+	default:
+		return float64(entry.LastAccessedAt.UnixNano())
+	}
+}
+
+// selectEvictions returns the keys of the n lowest scored entries
+func selectEvictions(scorer Scorer, candidates []evictionCandidate, n int) []string {
+	for i := range candidates {
+		candidates[i].score = scorer.Score(candidates[i].entry)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		return candidates[i].entry.LastAccessedAt.Before(candidates[j].entry.LastAccessedAt)
+	})
+
+	if n > len(candidates) {
+		n = len(candidates)
+	}
+	keys := make([]string, 0, n)
+	for _, candidate := range candidates[:n] {
+		keys = append(keys, candidate.key)
+	}
+	return keys
+}
+
+// evictEntries removes count entries chosen by the configured eviction policy
+func (c *SemanticCache) evictEntries(ctx context.Context, count int) (int, error) {
+	pattern := fmt.Sprintf("%s:query:*", c.config.Prefix)
+
+	var candidates []evictionCandidate
+	iter := c.redis.Scan(ctx, 0, pattern, 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		entry, err := c.getCacheEntry(ctx, key)
+		if err != nil || entry == nil {
+			continue
+		}
+		candidates = append(candidates, evictionCandidate{key: key, entry: entry})
+	}
+	if err := iter.Err(); err != nil {
+		return 0, fmt.Errorf("failed to scan cache entries: %w", err)
+	}
+
+	keys := selectEvictions(c.evictionScorer(), candidates, count)
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	if err := c.redis.Del(ctx, keys...); err != nil {
+		return 0, fmt.Errorf("failed to delete evicted entries: %w", err)
+	}
+	c.invalidateLocal(ctx, keys...)
+
+	if c.metrics != nil {
+		c.metrics.IncrementCounterWithLabels("semantic_cache.evictions", float64(len(keys)), map[string]string{
+			"policy": c.config.EvictionPolicy,
+		})
+	}
+	return len(keys), nil
+}
+
+// evictionScorer returns the configured scorer, or the one ranking entries
+// under the eviction policy
+func (c *SemanticCache) evictionScorer() Scorer {
+	if c.config.EvictionScorer != nil {
+		return c.config.EvictionScorer
+	}
+	if c.evictor != nil {
+		return c.evictor
+	}
+	return policyScorer{policy: c.config.EvictionPolicy, now: time.Now()}
+}
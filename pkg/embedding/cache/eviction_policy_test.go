@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// seedEvictionEntries stores entries with distinct access patterns directly in Redis
+func seedEvictionEntries(t *testing.T, mr *miniredis.Miniredis, prefix string) []string {
+	now := time.Now()
+	entries := map[string]*CacheEntry{
+		// Hit often long ago
+		"old": {CachedAt: now.Add(-10 * time.Hour), LastAccessedAt: now.Add(-5 * time.Hour), HitCount: 10, TTL: 24 * time.Hour},
+		// Hit a few times, then abandoned
+		"stale":  {CachedAt: now.Add(-3 * time.Hour), LastAccessedAt: now.Add(-3 * time.Hour), HitCount: 2, TTL: 24 * time.Hour},
+		"recent": {CachedAt: now.Add(-1 * time.Hour), LastAccessedAt: now.Add(-time.Minute), HitCount: 1, TTL: 24 * time.Hour},
+		// Popular but about to expire
+		"expiring": {CachedAt: now.Add(-23 * time.Hour), LastAccessedAt: now.Add(-10 * time.Minute), HitCount: 30, TTL: 24 * time.Hour},
+		// Just cached, no hits yet
+		"fresh": {CachedAt: now.Add(-time.Minute), LastAccessedAt: now.Add(-time.Minute), HitCount: 0, TTL: 24 * time.Hour},
+	}
+
+	names := make([]string, 0, len(entries))
+	for name, entry := range entries {
+		entry.Query = name
+		entry.NormalizedQuery = name
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, mr.Set(prefix+":query:"+name, string(data)))
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestSemanticCacheEvictionPolicies(t *testing.T) {
+	tests := []struct {
+		policy  string
+		evicted []string
+	}{
+		{EvictionPolicyLRU, []string{"old", "stale"}},
+		{EvictionPolicyLFU, []string{"fresh", "recent"}},
+		{EvictionPolicyTTLWeighted, []string{"expiring", "old"}},
+		{EvictionPolicyHitWeighted, []string{"stale", "fresh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			cache, mr, cleanup := setupTestCache(t)
+			defer cleanup()
+
+			names := seedEvictionEntries(t, mr, cache.config.Prefix)
+			cache.config.MaxCacheSize = 3
+			cache.config.EvictionPolicy = tt.policy
+
+			cache.evictIfNecessary(context.Background())
+
+			for _, name := range names {
+				exists := mr.Exists(cache.config.Prefix + ":query:" + name)
+				if slices.Contains(tt.evicted, name) {
+					assert.False(t, exists, "%s should be evicted", name)
+				} else {
+					assert.True(t, exists, "%s should be kept", name)
+				}
+			}
+		})
+	}
+
+	t.Run("no policy leaves eviction to the LRU manager", func(t *testing.T) {
+		cache, mr, cleanup := setupTestCache(t)
+		defer cleanup()
+
+		names := seedEvictionEntries(t, mr, cache.config.Prefix)
+		cache.config.MaxCacheSize = 3
+
+		cache.evictIfNecessary(context.Background())
+
+		for _, name := range names {
+			assert.True(t, mr.Exists(cache.config.Prefix+":query:"+name))
+		}
+	})
+
+	t.Run("under the size limit", func(t *testing.T) {
+		cache, mr, cleanup := setupTestCache(t)
+		defer cleanup()
+
+		names := seedEvictionEntries(t, mr, cache.config.Prefix)
+		cache.config.MaxCacheSize = len(names)
+		cache.config.EvictionPolicy = EvictionPolicyLRU
+
+		cache.evictIfNecessary(context.Background())
+
+		for _, name := range names {
+			assert.True(t, mr.Exists(cache.config.Prefix+":query:"+name))
+		}
+	})
+}
+
+// scoreByQuery ranks entries by a fixed score per query
+type scoreByQuery map[string]float64
+
+func (s scoreByQuery) Score(entry *CacheEntry) float64 {
+	return s[entry.Query]
+}
+
+func TestSemanticCacheEvictionScorer(t *testing.T) {
+	// Keeps the entries every policy would evict first
+	scorer := scoreByQuery{"old": 5, "stale": 4, "recent": 3, "expiring": 2, "fresh": 1}
+
+	for _, policy := range []string{"", EvictionPolicyLRU, EvictionPolicyTiered} {
+		t.Run("policy "+policy, func(t *testing.T) {
+			cache, mr, cleanup := setupTestCache(t)
+			defer cleanup()
+
+			names := seedEvictionEntries(t, mr, cache.config.Prefix)
+			cache.config.MaxCacheSize = 4
+			cache.config.EvictionPolicy = policy
+			cache.config.EvictionScorer = scorer
+
+			cache.evictIfNecessary(context.Background())
+
+			for _, name := range names {
+				exists := mr.Exists(cache.config.Prefix + ":query:" + name)
+				assert.Equal(t, name != "fresh", exists, name)
+			}
+		})
+	}
+}
+
+func TestSemanticCacheInvalidEvictionPolicy(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	_, err = NewSemanticCache(client, &Config{
+		SimilarityThreshold: 0.95,
+		EvictionPolicy:      "random",
+	}, observability.NewNoopLogger())
+	assert.ErrorContains(t, err, "unknown eviction policy")
+}
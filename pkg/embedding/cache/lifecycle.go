@@ -144,7 +144,7 @@ func (l *Lifecycle) evictionMonitor(ctx context.Context) {
 		select {
 		case <-ticker.C:
 			// Trigger eviction check
-			go l.cache.evictIfNecessary(context.Background())
+			l.cache.requestEviction()
 		case <-ctx.Done():
 			return
 		}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// The local tier keeps the hottest cache entries in process memory, in front
+// of Redis, so they are served without a network round trip. An entry is
+// promoted once its hit count reaches LocalPromotionHits. Every instance drops
+// an entry from its local tier when any instance invalidates it, through a
+// Redis pub/sub channel, and entries expire after LocalCacheTTL regardless, to
+// bound staleness when an invalidation message is missed.
+
+// localInvalidateAll is published to drop every entry from the local tiers
+const localInvalidateAll = "*"
+
+// localEntry is a promoted cache entry and when it expires from the local tier
+type localEntry struct {
+	entry     *CacheEntry
+	expiresAt time.Time
+}
+
+// localTier is an LRU of promoted cache entries, keyed by cache key
+type localTier struct {
+	mu           sync.Mutex // Keeps hit counting from re-adding removed entries
+	entries      *lru.Cache[string, localEntry]
+	promoteAfter int
+	ttl          time.Duration
+}
+
+// newLocalTier creates a local tier, or returns nil when it is disabled
+func newLocalTier(config *Config) (*localTier, error) {
+	if config.LocalCacheSize <= 0 {
+		return nil, nil
+	}
+
+	entries, err := lru.New[string, localEntry](config.LocalCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create local cache tier: %w", err)
+	}
+
+	tier := &localTier{
+		entries:      entries,
+		promoteAfter: config.LocalPromotionHits,
+		ttl:          config.LocalCacheTTL,
+	}
+	if tier.promoteAfter <= 0 {
+		tier.promoteAfter = DefaultLocalPromotionHits
+	}
+	if tier.ttl <= 0 {
+		tier.ttl = DefaultLocalCacheTTL
+	}
+	return tier, nil
+}
+
+// get returns a promoted entry, counting the hit, or nil if it isn't promoted
+func (t *localTier) get(key string) *CacheEntry {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cached, ok := t.entries.Get(key)
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if now.After(cached.expiresAt) {
+		t.entries.Remove(key)
+		return nil
+	}
+
+	// Entries are shared with callers, so hits are counted on a copy
+	entry := *cached.entry
+	entry.HitCount++
+	entry.LastAccessedAt = now
+	t.entries.Add(key, localEntry{entry: &entry, expiresAt: cached.expiresAt})
+	return &entry
+}
+
+// promote keeps an entry in the local tier once it is hot enough
+func (t *localTier) promote(key string, entry *CacheEntry) {
+	if t == nil || entry == nil || entry.Precomputed || entry.HitCount < t.promoteAfter {
+		return
+	}
+
+	expiresAt := time.Now().Add(t.ttl)
+	if entry.TTL > 0 && entry.CachedAt.Add(entry.TTL).Before(expiresAt) {
+		expiresAt = entry.CachedAt.Add(entry.TTL)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries.Add(key, localEntry{entry: entry, expiresAt: expiresAt})
+}
+
+// remove drops entries from the local tier
+func (t *localTier) remove(keys ...string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, key := range keys {
+		if key == localInvalidateAll {
+			t.entries.Purge()
+			return
+		}
+		t.entries.Remove(key)
+	}
+}
+
+// invalidationChannel is the pub/sub channel invalidated cache keys are published on
+func (c *SemanticCache) invalidationChannel() string {
+	return fmt.Sprintf("%s:invalidations", c.config.Prefix)
+}
+
+// invalidateLocal drops entries from this instance's local tier and publishes
+// their keys so every other instance drops them too
+func (c *SemanticCache) invalidateLocal(ctx context.Context, keys ...string) {
+	if c.local == nil || len(keys) == 0 {
+		return
+	}
+	c.local.remove(keys...)
+
+	payload, err := json.Marshal(keys)
+	if err != nil {
+		return
+	}
+	_, err = c.redis.Execute(ctx, func() (interface{}, error) {
+		return nil, c.redis.GetClient().Publish(ctx, c.invalidationChannel(), payload).Err()
+	})
+	if err != nil {
+		c.logger.Warn("Failed to publish cache invalidation", map[string]interface{}{
+			"error": err.Error(),
+			"keys":  len(keys),
+		})
+	}
+}
+
+// subscribeInvalidations drops entries from the local tier as any instance
+// invalidates them, until the subscription is closed on shutdown
+func (c *SemanticCache) subscribeInvalidations() {
+	c.invalidations = c.redis.GetClient().Subscribe(context.Background(), c.invalidationChannel())
+	messages := c.invalidations.Channel()
+
+	go func() {
+		for message := range messages {
+			c.handleInvalidation(message)
+		}
+	}()
+}
+
+func (c *SemanticCache) handleInvalidation(message *redis.Message) {
+	var keys []string
+	if err := json.Unmarshal([]byte(message.Payload), &keys); err != nil {
+		c.logger.Warn("Ignoring malformed cache invalidation", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.local.remove(keys...)
+}
@@ -0,0 +1,153 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// newLocalTierTestCache creates a cache promoting entries to the local tier
+// on their second hit, sharing Redis with any other cache created on mr
+func newLocalTierTestCache(t *testing.T, mr *miniredis.Miniredis) *SemanticCache {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	channel := "local_test:invalidations"
+	subscribers := mr.PubSubNumSub(channel)[channel]
+
+	cache, err := NewSemanticCache(client, &Config{
+		SimilarityThreshold: 0.95,
+		TTL:                 time.Hour,
+		MaxCandidates:       10,
+		Prefix:              "local_test",
+		LocalCacheSize:      10,
+		LocalPromotionHits:  2,
+	}, observability.NewNoopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cache.Shutdown(context.Background()) })
+
+	// Wait for the invalidation subscription
+	require.Equal(t, channel, cache.invalidationChannel())
+	require.Eventually(t, func() bool {
+		return mr.PubSubNumSub(channel)[channel] > subscribers
+	}, time.Second, 10*time.Millisecond)
+	return cache
+}
+
+func TestLocalTierPromotion(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := newLocalTierTestCache(t, mr)
+	ctx := context.Background()
+
+	query := "how to roll back a helm release"
+	results := []CachedSearchResult{{ID: "doc-1", Content: "helm rollback", Score: 0.9}}
+	require.NoError(t, cache.Set(ctx, query, nil, results))
+
+	// The first hit is served from Redis, and the second promotes the entry
+	for i := 0; i < 2; i++ {
+		entry, err := cache.Get(ctx, query, nil)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+	}
+
+	commands := mr.CommandCount()
+	entry, err := cache.Get(ctx, query, nil)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, results, entry.Results)
+	assert.Equal(t, 3, entry.HitCount)
+	assert.Equal(t, commands, mr.CommandCount(), "a promoted entry is served without a Redis call")
+}
+
+func TestLocalTierInvalidation(t *testing.T) {
+	ctx := context.Background()
+	query := "kubernetes pod restart loop"
+	results := []CachedSearchResult{{ID: "doc-2", Content: "crashloopbackoff", Score: 0.8}}
+
+	promoted := func(t *testing.T, cache *SemanticCache) {
+		t.Helper()
+		require.NoError(t, cache.Set(ctx, query, nil, results))
+		for i := 0; i < 2; i++ {
+			_, err := cache.Get(ctx, query, nil)
+			require.NoError(t, err)
+		}
+		require.NotNil(t, cache.local.get(cache.getCacheKey(cache.normalizer.Normalize(query))))
+	}
+
+	t.Run("delete on the same instance", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newLocalTierTestCache(t, mr)
+		promoted(t, cache)
+
+		require.NoError(t, cache.Delete(ctx, query))
+		entry, err := cache.Get(ctx, query, nil)
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("delete on another instance", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newLocalTierTestCache(t, mr)
+		other := newLocalTierTestCache(t, mr)
+		promoted(t, cache)
+
+		require.NoError(t, other.Delete(ctx, query))
+		key := cache.getCacheKey(cache.normalizer.Normalize(query))
+		assert.Eventually(t, func() bool { return cache.local.get(key) == nil }, time.Second, 10*time.Millisecond)
+
+		entry, err := cache.Get(ctx, query, nil)
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+
+	t.Run("overwrite on another instance", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newLocalTierTestCache(t, mr)
+		other := newLocalTierTestCache(t, mr)
+		promoted(t, cache)
+
+		updated := []CachedSearchResult{{ID: "doc-3", Content: "liveness probe", Score: 0.7}}
+		require.NoError(t, other.Set(ctx, query, nil, updated))
+		assert.Eventually(t, func() bool {
+			entry, err := cache.Get(ctx, query, nil)
+			return err == nil && entry != nil && entry.Results[0].ID == "doc-3"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("clear on another instance", func(t *testing.T) {
+		mr := miniredis.RunT(t)
+		cache := newLocalTierTestCache(t, mr)
+		other := newLocalTierTestCache(t, mr)
+		promoted(t, cache)
+
+		require.NoError(t, other.Clear(ctx))
+		key := cache.getCacheKey(cache.normalizer.Normalize(query))
+		assert.Eventually(t, func() bool { return cache.local.get(key) == nil }, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestLocalTierExpiry(t *testing.T) {
+	tier, err := newLocalTier(&Config{LocalCacheSize: 2, LocalPromotionHits: 1, LocalCacheTTL: time.Minute})
+	require.NoError(t, err)
+
+	tier.promote("cold", &CacheEntry{CachedAt: time.Now(), TTL: time.Hour})
+	assert.Nil(t, tier.get("cold"), "entries below the promotion threshold stay in Redis")
+
+	tier.promote("hot", &CacheEntry{HitCount: 1, CachedAt: time.Now(), TTL: time.Hour})
+	assert.NotNil(t, tier.get("hot"))
+
+	// An entry never outlives its Redis TTL
+	tier.promote("expiring", &CacheEntry{HitCount: 1, CachedAt: time.Now().Add(-time.Hour), TTL: time.Hour})
+	assert.Nil(t, tier.get("expiring"))
+
+	disabled, err := newLocalTier(&Config{})
+	require.NoError(t, err)
+	assert.Nil(t, disabled)
+	assert.Nil(t, disabled.get("hot"))
+}
@@ -0,0 +1,227 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// EmbeddingFunc generates an embedding for a piece of text
+type EmbeddingFunc func(text string) ([]float32, error)
+
+// Precompute generates and stores embeddings for known high-frequency topics,
+// typically during off-peak hours. Entries are stored without search results
+// and marked as precomputed; Get treats them as misses until GetOrCompute
+// promotes them to full entries.
+//
+// Topics that are already cached or fail validation are skipped. Embedding and
+// storage failures for individual topics are logged and do not stop the run.
+//
+// Returns the number of topics precomputed.
+func (c *SemanticCache) Precompute(ctx context.Context, topics []string, embeddingFn EmbeddingFunc) (int, error) {
+	if c.IsShuttingDown() {
+		return 0, fmt.Errorf("cache is shutting down")
+	}
+	if embeddingFn == nil {
+		return 0, fmt.Errorf("embedding function is required")
+	}
+
+	stored := 0
+	for _, topic := range topics {
+		if err := ctx.Err(); err != nil {
+			return stored, err
+		}
+
+		if err := c.validator.Validate(topic); err != nil {
+			continue
+		}
+		topic = c.validator.Sanitize(topic)
+
+		normalized := c.normalizer.Normalize(topic)
+		if normalized == "" {
+			continue
+		}
+
+		// Don't overwrite real (or already precomputed) entries
+		if existing, err := c.getExactMatch(ctx, normalized); err == nil && existing != nil {
+			continue
+		}
+
+		embedding, err := embeddingFn(topic)
+		if err != nil {
+			c.safeLogger.Warn("Failed to precompute embedding", map[string]interface{}{
+				"error": err.Error(),
+				"query": topic,
+			})
+			continue
+		}
+
+		now := time.Now()
+		entry := &CacheEntry{
+			Query:           topic,
+			NormalizedQuery: normalized,
+			Embedding:       embedding,
+			CachedAt:        now,
+			LastAccessedAt:  now,
+			TTL:             c.config.TTL,
+			Precomputed:     true,
+			Metadata: map[string]interface{}{
+				"precomputed":   true,
+				"has_embedding": len(embedding) > 0,
+			},
+		}
+
+		key := c.getCacheKey(normalized)
+		if err := c.storeEntry(ctx, key, entry); err != nil {
+			c.safeLogger.Warn("Failed to store precomputed entry", map[string]interface{}{
+				"error": err.Error(),
+				"query": topic,
+			})
+			continue
+		}
+
+		if len(embedding) > 0 {
+			if err := c.storeCacheEmbedding(ctx, normalized, embedding, key); err != nil {
+				c.safeLogger.Warn("Failed to store embedding for similarity search", map[string]interface{}{
+					"error": err.Error(),
+					"query": topic,
+				})
+			}
+		}
+
+		stored++
+	}
+
+	if c.metrics != nil {
+		c.metrics.IncrementCounter("semantic_cache.precomputed", float64(stored))
+	}
+
+	return stored, nil
+}
+
+// GetOrCompute returns cached results for a query, executing the search on a miss.
+// When the query matches a precomputed entry, the search is executed and the
+// entry is promoted to a full cache entry holding the results. On a complete
+// miss the results are stored with Set.
+func (c *SemanticCache) GetOrCompute(ctx context.Context, query string, queryEmbedding []float32, executor SearchExecutor) (*CacheEntry, error) {
+	if executor == nil {
+		return nil, fmt.Errorf("search executor is required")
+	}
+
+	entry, err := c.Get(ctx, query, queryEmbedding)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		return entry, nil
+	}
+
+	key, precomputed := c.findPrecomputed(ctx, query, queryEmbedding)
+
+	results, err := executor(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if precomputed == nil {
+		if err := c.Set(ctx, query, queryEmbedding, results); err != nil {
+			c.logger.Warn("Failed to cache computed results", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return &CacheEntry{
+			Query:     query,
+			Embedding: queryEmbedding,
+			Results:   results,
+			CachedAt:  time.Now(),
+			TTL:       c.config.TTL,
+		}, nil
+	}
+
+	// Promote the precomputed entry now that real results are available
+	now := time.Now()
+	promoted := &CacheEntry{
+		Query:           precomputed.Query,
+		NormalizedQuery: precomputed.NormalizedQuery,
+		Embedding:       precomputed.Embedding,
+		Results:         results,
+		CachedAt:        now,
+		LastAccessedAt:  now,
+		HitCount:        1,
+		TTL:             c.config.TTL,
+		Metadata: map[string]interface{}{
+			"result_count":  len(results),
+			"has_embedding": len(precomputed.Embedding) > 0,
+			"promoted_from": "precomputed",
+		},
+	}
+
+	if err := c.storeEntry(ctx, key, promoted); err != nil {
+		c.logger.Warn("Failed to promote precomputed entry", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return promoted, nil
+	}
+	c.entries.Store(key, promoted)
+
+	if c.metrics != nil {
+		c.metrics.IncrementCounter("semantic_cache.promoted", 1)
+	}
+
+	return promoted, nil
+}
+
+// findPrecomputed looks for a precomputed entry matching the query exactly or by similarity
+func (c *SemanticCache) findPrecomputed(ctx context.Context, query string, queryEmbedding []float32) (string, *CacheEntry) {
+	if err := c.validator.Validate(query); err != nil {
+		return "", nil
+	}
+	normalized := c.normalizer.Normalize(c.validator.Sanitize(query))
+	if normalized == "" {
+		return "", nil
+	}
+
+	if entry, err := c.getExactMatch(ctx, normalized); err == nil && entry != nil && entry.Precomputed {
+		return c.getCacheKey(normalized), entry
+	}
+
+	if len(queryEmbedding) == 0 {
+		return "", nil
+	}
+
+	candidates, err := c.searchSimilarQueries(ctx, queryEmbedding, c.config.MaxCandidates)
+	if err != nil {
+		return "", nil
+	}
+	for _, candidate := range candidates {
+		if candidate.Similarity < c.config.SimilarityThreshold {
+			continue
+		}
+		if entry, err := c.getCacheEntry(ctx, candidate.CacheKey); err == nil && entry != nil && entry.Precomputed {
+			return candidate.CacheKey, entry
+		}
+	}
+
+	return "", nil
+}
+
+// storeEntry marshals, optionally compresses and writes an entry to Redis
+func (c *SemanticCache) storeEntry(ctx context.Context, key string, entry *CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	if c.config.EnableCompression && len(data) > 1024 {
+		if compressed, err := c.compress(data); err == nil {
+			data = compressed
+		}
+	}
+
+	if err := c.redis.Set(ctx, key, data, entry.TTL); err != nil {
+		c.enterDegradedMode("Redis SET failed", err)
+		return fmt.Errorf("failed to store in Redis: %w", err)
+	}
+	return nil
+}
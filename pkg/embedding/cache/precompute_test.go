@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemanticCache_Precompute(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	embed := func(text string) ([]float32, error) {
+		if text == "broken topic" {
+			return nil, errors.New("embedding failed")
+		}
+		return []float32{0.1, 0.2, 0.3}, nil
+	}
+
+	stored, err := cache.Precompute(ctx, []string{"kubernetes deployment", "broken topic", ""}, embed)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored)
+
+	// Precomputed entries have no results and are not served by Get
+	entry, err := cache.Get(ctx, "kubernetes deployment", nil)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	raw, err := cache.getExactMatch(ctx, cache.normalizer.Normalize("kubernetes deployment"))
+	require.NoError(t, err)
+	require.NotNil(t, raw)
+	assert.True(t, raw.Precomputed)
+	assert.Equal(t, true, raw.Metadata["precomputed"])
+	assert.Empty(t, raw.Results)
+
+	// Precomputing again does not overwrite the existing entry
+	stored, err = cache.Precompute(ctx, []string{"kubernetes deployment"}, embed)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored)
+}
+
+func TestSemanticCache_GetOrComputePromotesPrecomputed(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	_, err := cache.Precompute(ctx, []string{"kubernetes deployment"}, func(string) ([]float32, error) {
+		return []float32{0.1, 0.2, 0.3}, nil
+	})
+	require.NoError(t, err)
+
+	calls := 0
+	executor := func(ctx context.Context, query string) ([]CachedSearchResult, error) {
+		calls++
+		return []CachedSearchResult{{ID: "doc-1", Content: "deploying to k8s", Score: 0.9}}, nil
+	}
+
+	entry, err := cache.GetOrCompute(ctx, "kubernetes deployment", nil, executor)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.False(t, entry.Precomputed)
+	assert.Len(t, entry.Results, 1)
+	assert.Equal(t, []float32{0.1, 0.2, 0.3}, entry.Embedding, "promotion keeps the precomputed embedding")
+	assert.Equal(t, 1, calls)
+
+	// Promoted entry is now a normal cache hit
+	entry, err = cache.Get(ctx, "kubernetes deployment", nil)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, "doc-1", entry.Results[0].ID)
+
+	entry, err = cache.GetOrCompute(ctx, "kubernetes deployment", nil, executor)
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	assert.Equal(t, 1, calls, "served from cache without re-running the search")
+}
@@ -35,6 +35,7 @@ type SemanticCache struct {
 	mu           sync.RWMutex
 	shutdownOnce sync.Once
 	shuttingDown bool
+	evictMu      sync.Mutex // Serializes eviction runs
 
 	// Use sync.Map for concurrent access (project pattern)
 	entries sync.Map // map[string]*CacheEntry
@@ -55,6 +56,14 @@ type SemanticCache struct {
 
 	// Audit logging
 	auditLogger *audit.Logger
+
+	// Hot entries served from process memory, and the subscription that keeps
+	// them consistent with invalidations on other instances
+	local         *localTier
+	invalidations *redis.PubSub
+
+	// Runs rate limited eviction passes under the tiered policy
+	evictor *TieredEvictor
 }
 
 // NewSemanticCache creates a new semantic cache instance with default configuration.
@@ -104,6 +113,9 @@ func NewSemanticCacheWithOptions(
 	if config.Prefix == "" {
 		config.Prefix = "semantic_cache"
 	}
+	if err := ValidateEvictionPolicy(config.EvictionPolicy); err != nil {
+		return nil, err
+	}
 
 	if logger == nil {
 		logger = observability.NewLogger("embedding.cache")
@@ -131,6 +143,11 @@ func NewSemanticCacheWithOptions(
 
 	resilientClient := NewResilientRedisClientWithConfig(redisClient, config.PerformanceConfig, logger, metrics)
 
+	local, err := newLocalTier(config)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create compression service if encryption key provided
 	var compressionService *CompressionService
 	if encryptionKey != "" {
@@ -147,6 +164,16 @@ func NewSemanticCacheWithOptions(
 		compressionService: compressionService,
 		vectorStore:        vectorStore,
 		recoveryStop:       make(chan struct{}),
+		local:              local,
+	}
+
+	if cache.local != nil {
+		cache.subscribeInvalidations()
+	}
+
+	if config.EvictionPolicy == EvictionPolicyTiered && config.MaxCacheSize > 0 {
+		cache.evictor = NewTieredEvictor(cache, config.EvictionInterval)
+		cache.evictor.Start()
 	}
 
 	if config.EnableMetrics {
@@ -245,10 +272,16 @@ func (c *SemanticCache) Get(ctx context.Context, query string, queryEmbedding []
 		return nil, nil
 	}
 
-	// Try exact match first
+	// Hot entries are served from process memory without a Redis round trip
 	key := c.getCacheKey(normalized)
+	if entry := c.local.get(key); entry != nil {
+		c.recordHit(ctx, "local")
+		return entry, nil
+	}
+
+	// Try exact match first
 	entry, err := c.getExactMatch(ctx, normalized)
-	if err == nil && entry != nil {
+	if err == nil && entry != nil && !entry.Precomputed {
 		c.recordHit(ctx, "exact")
 		updatedEntry, updateErr := c.updateAccessStats(ctx, key, entry)
 		if updateErr != nil {
@@ -280,8 +313,13 @@ func (c *SemanticCache) Get(ctx context.Context, query string, queryEmbedding []
 	// Find best match above threshold
 	for _, candidate := range candidates {
 		if candidate.Similarity >= c.config.SimilarityThreshold {
+			if entry := c.local.get(candidate.CacheKey); entry != nil {
+				c.recordHit(ctx, "local")
+				return entry, nil
+			}
+
 			entry, err := c.getCacheEntry(ctx, candidate.CacheKey)
-			if err == nil && entry != nil {
+			if err == nil && entry != nil && !entry.Precomputed {
 				c.recordHit(ctx, "similarity")
 				updatedEntry, updateErr := c.updateAccessStats(ctx, candidate.CacheKey, entry)
 				if updateErr != nil {
@@ -410,6 +448,17 @@ func (c *SemanticCache) Set(ctx context.Context, query string, queryEmbedding []
 		return fmt.Errorf("failed to store in Redis: %w", err)
 	}
 
+	// Drop any promoted copy of the entry this one replaces
+	c.invalidateLocal(ctx, key)
+
+	// Tag the entry so mutating its content invalidates it
+	if err := c.tagContent(ctx, key, results); err != nil {
+		c.logger.Warn("Failed to tag cache entry with its content", map[string]interface{}{
+			"error": err.Error(),
+			"key":   key,
+		})
+	}
+
 	// Store embedding for similarity search (if provided)
 	if len(queryEmbedding) > 0 {
 		err = c.storeCacheEmbedding(ctx, normalized, queryEmbedding, key)
@@ -423,7 +472,7 @@ func (c *SemanticCache) Set(ctx context.Context, query string, queryEmbedding []
 	}
 
 	// Check cache size and evict if necessary
-	go c.evictIfNecessary(context.Background())
+	c.requestEviction()
 
 	return nil
 }
@@ -458,6 +507,7 @@ func (c *SemanticCache) Delete(ctx context.Context, query string) error {
 		auditErr = err
 		return fmt.Errorf("failed to delete from Redis: %w", err)
 	}
+	c.invalidateLocal(ctx, key)
 
 	// Delete from similarity index
 	err = c.deleteCacheEmbedding(ctx, normalized)
@@ -530,6 +580,7 @@ func (c *SemanticCache) Clear(ctx context.Context) error {
 	if err := iter.Err(); err != nil {
 		return fmt.Errorf("scan error: %w", err)
 	}
+	c.invalidateLocal(ctx, localInvalidateAll)
 
 	// Clear similarity index
 	if err := c.clearSimilarityIndex(ctx); err != nil {
@@ -629,6 +680,7 @@ func (c *SemanticCache) updateAccessStats(ctx context.Context, key string, entry
 		HitCount:        entry.HitCount + 1,
 		TTL:             entry.TTL,
 		Metadata:        entry.Metadata,
+		Precomputed:     entry.Precomputed,
 	}
 
 	// Update in Redis atomically
@@ -653,8 +705,9 @@ func (c *SemanticCache) updateAccessStats(ctx context.Context, key string, entry
 		return nil, fmt.Errorf("failed to update access stats: %w", err)
 	}
 
-	// Update local cache
+	// Update local cache, promoting the entry to the local tier once it's hot
 	c.entries.Store(key, updatedEntry)
+	c.local.promote(key, updatedEntry)
 
 	return updatedEntry, nil
 }
@@ -787,6 +840,9 @@ func (c *SemanticCache) evictIfNecessary(ctx context.Context) {
 			return
 		}
 
+		c.evictMu.Lock()
+		defer c.evictMu.Unlock()
+
 		// Count entries
 		pattern := fmt.Sprintf("%s:query:*", c.config.Prefix)
 		countInterface, err := c.redis.Eval(ctx, `
@@ -823,15 +879,48 @@ func (c *SemanticCache) evictIfNecessary(ctx context.Context) {
 			return
 		}
 
-		// LRU eviction is now handled by the LRU manager in tenant_cache.go
-		// The eviction runs asynchronously via StartLRUEviction()
-		c.logger.Warn("Cache size exceeded, eviction handled by LRU manager", map[string]interface{}{
+		if c.config.EvictionPolicy == "" && c.config.EvictionScorer == nil {
+			// LRU eviction is handled by the LRU manager in tenant_cache.go
+			// The eviction runs asynchronously via StartLRUEviction()
+			c.logger.Warn("Cache size exceeded, eviction handled by LRU manager", map[string]interface{}{
+				"current_size": count,
+				"max_size":     c.config.MaxCacheSize,
+			})
+			return
+		}
+
+		toEvict := count - c.config.MaxCacheSize
+		if c.config.EvictionPolicy == EvictionPolicyTiered {
+			toEvict = tieredEvictionCount(count, c.config.MaxCacheSize)
+		}
+		evicted, err := c.evictEntries(ctx, toEvict)
+		if err != nil {
+			c.logger.Error("Failed to evict cache entries", map[string]interface{}{
+				"error":  err.Error(),
+				"policy": c.config.EvictionPolicy,
+			})
+			return
+		}
+
+		c.logger.Info("Evicted cache entries", map[string]interface{}{
+			"evicted":      evicted,
 			"current_size": count,
 			"max_size":     c.config.MaxCacheSize,
+			"policy":       c.config.EvictionPolicy,
 		})
 	})
 }
 
+// requestEviction checks the cache size in the background, through the
+// tiered evictor's rate limit when there is one
+func (c *SemanticCache) requestEviction() {
+	if c.evictor != nil {
+		c.evictor.Trigger()
+		return
+	}
+	go c.evictIfNecessary(context.Background())
+}
+
 // Shutdown gracefully shuts down the cache.
 // It stops background operations, flushes metrics, and closes the Redis connection.
 // This method should be called when the cache is no longer needed.
@@ -871,6 +960,16 @@ func (c *SemanticCache) Shutdown(ctx context.Context) error {
 			c.logger.Info("Flushing metrics", map[string]interface{}{})
 		}
 
+		// Stop eviction passes
+		if c.evictor != nil {
+			c.evictor.Stop()
+		}
+
+		// Stop following invalidations
+		if c.invalidations != nil {
+			_ = c.invalidations.Close()
+		}
+
 		// Close Redis connection
 		if c.redis != nil {
 			if closeErr := c.redis.Close(); closeErr != nil {
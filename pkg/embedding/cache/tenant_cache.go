@@ -303,6 +303,7 @@ func (tc *TenantAwareCache) ClearTenant(ctx context.Context, tenantID uuid.UUID)
 			if err := tc.baseCache.redis.Del(ctx, keys...); err != nil {
 				return fmt.Errorf("failed to delete batch: %w", err)
 			}
+			tc.baseCache.invalidateLocal(ctx, keys...)
 			keys = keys[:0]
 		}
 	}
@@ -312,6 +313,7 @@ func (tc *TenantAwareCache) ClearTenant(ctx context.Context, tenantID uuid.UUID)
 		if err := tc.baseCache.redis.Del(ctx, keys...); err != nil {
 			return fmt.Errorf("failed to delete final batch: %w", err)
 		}
+		tc.baseCache.invalidateLocal(ctx, keys...)
 	}
 
 	if err := iter.Err(); err != nil {
@@ -395,6 +397,11 @@ func (tc *TenantAwareCache) getTenantConfig(ctx context.Context, tenantID uuid.U
 }
 
 func (tc *TenantAwareCache) getWithTenantKey(ctx context.Context, key, query string, embedding []float32) (*CacheEntry, error) {
+	// Hot entries are served from process memory
+	if entry := tc.baseCache.local.get(key); entry != nil {
+		return entry, nil
+	}
+
 	// Use the base cache's get logic but with tenant-specific key
 	data, err := tc.baseCache.redis.Get(ctx, key)
 	if err != nil {
@@ -451,7 +458,11 @@ func (tc *TenantAwareCache) setWithEncryption(ctx context.Context, key, query st
 		return err
 	}
 
-	return tc.baseCache.redis.Set(ctx, key, data, entry.TTL)
+	if err := tc.baseCache.redis.Set(ctx, key, data, entry.TTL); err != nil {
+		return err
+	}
+	tc.baseCache.invalidateLocal(ctx, key)
+	return nil
 }
 
 func (tc *TenantAwareCache) extractSensitiveData(results []CachedSearchResult) interface{} {
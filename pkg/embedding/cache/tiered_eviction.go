@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultEvictionInterval is the least time between tiered eviction passes
+	DefaultEvictionInterval = 30 * time.Second
+	// TieredEvictionFraction is the share of entries a tiered eviction pass
+	// removes, so a full cache isn't scanned again on its next write
+	TieredEvictionFraction = 0.1
+
+	// Weights of the components of a tiered score, which sum to one
+	tieredFrequencyWeight = 0.5
+	tieredRecencyWeight   = 0.3
+	tieredQualityWeight   = 0.2
+
+	// tieredHitSaturation is the hit count at which an entry gets half the
+	// frequency score
+	tieredHitSaturation = 10
+	// tieredRecencyHalfLife is how long after its last access an entry's
+	// recency score halves
+	tieredRecencyHalfLife = time.Hour
+	// tieredMaxDimensions is the embedding size given the full quality score,
+	// that of the largest embedding models in use
+	tieredMaxDimensions = 3072
+)
+
+// Scorer ranks cache entries for eviction; lower scores are evicted first
+type Scorer interface {
+	Score(entry *CacheEntry) float64
+}
+
+// policyScorer scores entries under one of the eviction policies
+type policyScorer struct {
+	policy string
+	now    time.Time
+}
+
+func (s policyScorer) Score(entry *CacheEntry) float64 {
+	return evictionScore(s.policy, entry, s.now)
+}
+
+// TieredEvictor evicts a SemanticCache's lowest ranked entries in the
+// background once it exceeds MaxCacheSize. An entry's rank combines how often
+// it's hit (LFU), how recently it was accessed (LRU) and the quality of its
+// embedding, with higher dimensional embeddings worth more to keep.
+//
+// Writes request an eviction pass with Trigger. Requests made while a pass
+// is pending are coalesced, and passes are rate limited to one per interval,
+// so a burst of writes doesn't have every instance scanning Redis at once.
+type TieredEvictor struct {
+	evict   func(ctx context.Context)
+	limiter *rate.Limiter
+	trigger chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+	now     func() time.Time
+}
+
+// NewTieredEvictor creates an evictor for a cache running at most one pass per
+// interval, DefaultEvictionInterval when zero. Start runs it.
+func NewTieredEvictor(cache *SemanticCache, interval time.Duration) *TieredEvictor {
+	if interval <= 0 {
+		interval = DefaultEvictionInterval
+	}
+	return &TieredEvictor{
+		evict:   cache.evictIfNecessary,
+		limiter: rate.NewLimiter(rate.Every(interval), 1),
+		trigger: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		now:     time.Now,
+	}
+}
+
+// Score ranks an entry between 0 and 1 by its hits, the time since its last
+// access and its embedding's dimensions
+func (e *TieredEvictor) Score(entry *CacheEntry) float64 {
+	return tieredScore(entry, e.now())
+}
+
+// Start runs eviction passes in the background until Stop is called
+func (e *TieredEvictor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	go func() {
+		defer close(e.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-e.trigger:
+			}
+
+			if err := e.limiter.Wait(ctx); err != nil {
+				return
+			}
+			e.evict(ctx)
+		}
+	}()
+}
+
+// Trigger requests an eviction pass without blocking
+func (e *TieredEvictor) Trigger() {
+	select {
+	case e.trigger <- struct{}{}:
+	default:
+		// A pass is already pending
+	}
+}
+
+// Stop ends the background passes, waiting for one in progress to finish
+func (e *TieredEvictor) Stop() {
+	if e.cancel == nil {
+		return
+	}
+	e.cancel()
+	<-e.done
+}
+
+// tieredScore combines an entry's hit frequency, recency and embedding
+// quality, each between 0 and 1, into a weighted score
+func tieredScore(entry *CacheEntry, now time.Time) float64 {
+	hits := float64(max(entry.HitCount, 0))
+	frequency := hits / (hits + tieredHitSaturation)
+
+	idle := max(now.Sub(entry.LastAccessedAt), 0)
+	recency := math.Exp2(-float64(idle) / float64(tieredRecencyHalfLife))
+
+	quality := math.Min(float64(len(entry.Embedding))/tieredMaxDimensions, 1)
+
+	return tieredFrequencyWeight*frequency + tieredRecencyWeight*recency + tieredQualityWeight*quality
+}
+
+// tieredEvictionCount is how many of count entries a tiered pass evicts: the
+// excess over maxSize, and at least TieredEvictionFraction of them
+func tieredEvictionCount(count, maxSize int) int {
+	return max(count-maxSize, int(math.Ceil(float64(count)*TieredEvictionFraction)))
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func TestTieredScore(t *testing.T) {
+	now := time.Now()
+	base := CacheEntry{HitCount: 5, LastAccessedAt: now.Add(-time.Hour), Embedding: make([]float32, 768)}
+
+	hot, cold := base, base
+	hot.HitCount, cold.HitCount = 50, 0
+	assert.Greater(t, tieredScore(&hot, now), tieredScore(&base, now))
+	assert.Greater(t, tieredScore(&base, now), tieredScore(&cold, now))
+
+	recent, stale := base, base
+	recent.LastAccessedAt, stale.LastAccessedAt = now, now.Add(-24*time.Hour)
+	assert.Greater(t, tieredScore(&recent, now), tieredScore(&base, now))
+	assert.Greater(t, tieredScore(&base, now), tieredScore(&stale, now))
+
+	large, small := base, base
+	large.Embedding, small.Embedding = make([]float32, 3072), make([]float32, 384)
+	assert.Greater(t, tieredScore(&large, now), tieredScore(&base, now))
+	assert.Greater(t, tieredScore(&base, now), tieredScore(&small, now))
+
+	for _, entry := range []*CacheEntry{&hot, &cold, &recent, &stale, &large, &small} {
+		assert.GreaterOrEqual(t, tieredScore(entry, now), 0.0)
+		assert.LessOrEqual(t, tieredScore(entry, now), 1.0)
+	}
+}
+
+func TestTieredEvictionKeepsHighHitEntries(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	cache, err := NewSemanticCache(client, &Config{
+		SimilarityThreshold: 0.95,
+		MaxCacheSize:        29,
+		EvictionPolicy:      EvictionPolicyTiered,
+		Prefix:              "test_cache",
+	}, observability.NewNoopLogger())
+	require.NoError(t, err)
+	defer func() { _ = cache.Shutdown(context.Background()) }()
+	require.NotNil(t, cache.evictor)
+
+	// 30 entries accessed at the same time, three of them hit often
+	now := time.Now()
+	for i := 0; i < 30; i++ {
+		entry := &CacheEntry{
+			Query:          fmt.Sprintf("query %d", i),
+			CachedAt:       now.Add(-time.Hour),
+			LastAccessedAt: now.Add(-10 * time.Minute),
+			HitCount:       i % 2,
+			Embedding:      make([]float32, 768),
+			TTL:            time.Hour,
+		}
+		if i%10 == 0 {
+			entry.HitCount = 100
+		}
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, mr.Set(fmt.Sprintf("test_cache:query:%d", i), string(data)))
+	}
+
+	cache.evictIfNecessary(context.Background())
+
+	// One entry over the limit still evicts the lowest scoring 10%
+	assert.Len(t, mr.Keys(), 27)
+	for i := 0; i < 30; i++ {
+		// Only entries without hits are evicted
+		if i%10 == 0 || i%2 == 1 {
+			assert.True(t, mr.Exists(fmt.Sprintf("test_cache:query:%d", i)), "entry %d should survive", i)
+		}
+	}
+}
+
+func TestTieredEvictorRateLimit(t *testing.T) {
+	cache, _, cleanup := setupTestCache(t)
+	defer cleanup()
+
+	var passes atomic.Int32
+	evictor := NewTieredEvictor(cache, time.Hour)
+	evictor.evict = func(ctx context.Context) { passes.Add(1) }
+	evictor.Start()
+
+	// A burst of writes gets one pass, with the next held for the interval
+	for i := 0; i < 100; i++ {
+		evictor.Trigger()
+		time.Sleep(time.Millisecond)
+	}
+	assert.Eventually(t, func() bool { return passes.Load() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), passes.Load())
+
+	// Stopping doesn't wait out the interval
+	stopped := make(chan struct{})
+	go func() {
+		evictor.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked on the rate limiter")
+	}
+
+	t.Run("passes resume after the interval", func(t *testing.T) {
+		var passes atomic.Int32
+		evictor := NewTieredEvictor(cache, 20*time.Millisecond)
+		evictor.evict = func(ctx context.Context) { passes.Add(1) }
+		evictor.Start()
+		defer evictor.Stop()
+
+		start := time.Now()
+		for time.Since(start) < 200*time.Millisecond {
+			evictor.Trigger()
+			time.Sleep(time.Millisecond)
+		}
+		// At most one pass per interval, plus the initial burst
+		assert.GreaterOrEqual(t, passes.Load(), int32(2))
+		assert.LessOrEqual(t, passes.Load(), int32(11))
+	})
+}
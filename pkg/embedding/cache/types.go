@@ -21,6 +21,8 @@ type CacheEntry struct {
 	HitCount        int                    `json:"hit_count"`
 	LastAccessedAt  time.Time              `json:"last_accessed_at"`
 	TTL             time.Duration          `json:"ttl"`
+	// Precomputed marks entries warmed with an embedding but no search results yet
+	Precomputed bool `json:"precomputed,omitempty"`
 }
 
 // CachedSearchResult represents a simplified search result for caching.
@@ -50,6 +52,16 @@ type Config struct {
 	MaxCandidates int `json:"max_candidates"`
 	// MaxCacheSize is the maximum number of entries to keep in cache
 	MaxCacheSize int `json:"max_cache_size"`
+	// EvictionPolicy selects the entries evicted above MaxCacheSize: lru, lfu,
+	// ttl_weighted, hit_weighted or tiered. When empty, the tenant LRU manager handles eviction.
+	EvictionPolicy string `json:"eviction_policy,omitempty"`
+	// EvictionInterval is the least time between tiered eviction passes,
+	// DefaultEvictionInterval when zero
+	EvictionInterval time.Duration `json:"eviction_interval,omitempty"`
+	// EvictionScorer, when set, ranks entries for eviction in place of
+	// EvictionPolicy's ranking. Without a policy, entries above MaxCacheSize
+	// are evicted by it alone.
+	EvictionScorer Scorer `json:"-"`
 	// Prefix is the Redis key prefix for cache entries
 	Prefix string `json:"prefix"`
 	// WarmupQueries are queries to pre-warm the cache with
@@ -60,6 +72,16 @@ type Config struct {
 	EnableCompression bool `json:"enable_compression"`
 	// EnableAuditLogging enables audit logging for compliance
 	EnableAuditLogging bool `json:"enable_audit_logging"`
+	// DisableContentTags stops tagging entries with the content their results
+	// include, so mutating content no longer invalidates them
+	DisableContentTags bool `json:"disable_content_tags,omitempty"`
+	// LocalCacheSize is the number of hot entries kept in process memory in
+	// front of Redis; 0 disables the local tier
+	LocalCacheSize int `json:"local_cache_size,omitempty"`
+	// LocalPromotionHits is the hit count at which an entry is promoted to the local tier
+	LocalPromotionHits int `json:"local_promotion_hits,omitempty"`
+	// LocalCacheTTL is the longest an entry stays in the local tier
+	LocalCacheTTL time.Duration `json:"local_cache_ttl,omitempty"`
 	// RedisPoolConfig contains Redis connection pool settings
 	RedisPoolConfig *RedisPoolConfig `json:"redis_pool_config,omitempty"`
 	// PerformanceConfig contains performance tuning parameters
@@ -71,6 +93,7 @@ type Config struct {
 //   - 95% similarity threshold for high-quality matches
 //   - 24-hour TTL for cached entries
 //   - Support for up to 10,000 cached queries
+//   - The 1,000 hottest entries served from process memory
 //   - Metrics enabled for observability
 //
 // Adjust these values based on your specific use case and requirements.
@@ -80,6 +103,9 @@ func DefaultConfig() *Config {
 		TTL:                 24 * time.Hour,
 		MaxCandidates:       10,
 		MaxCacheSize:        10000,
+		LocalCacheSize:      DefaultLocalCacheSize,
+		LocalPromotionHits:  DefaultLocalPromotionHits,
+		LocalCacheTTL:       DefaultLocalCacheTTL,
 		Prefix:              "semantic_cache",
 		EnableMetrics:       true,
 		EnableCompression:   false,
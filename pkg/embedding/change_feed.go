@@ -0,0 +1,388 @@
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// ChangeFeedChannel is the Postgres notification channel raised for each recorded content change
+const ChangeFeedChannel = "embedding_changes"
+
+// Change feed defaults
+const (
+	DefaultChangeFeedConsumer     = "default"
+	DefaultChangeFeedPollInterval = 5 * time.Second
+	DefaultChangeFeedBatchSize    = 100
+	DefaultChangeFeedMaxAttempts  = 3
+)
+
+// ChangeFeedConfig configures a change feed consumer
+type ChangeFeedConfig struct {
+	// Consumer names the cursor tracking applied changes. Run one consumer per name.
+	Consumer string
+	// PollInterval bounds the lag between a change and its embedding update
+	PollInterval time.Duration
+	// BatchSize is the number of changes applied per poll
+	BatchSize int
+	// MaxAttempts is how many polls a failing change is retried before it is skipped
+	MaxAttempts int
+	// Wake triggers a poll before the interval elapses, typically from database
+	// notifications (see ChangeFeedWakeups)
+	Wake <-chan struct{}
+}
+
+// ContentChange is a recorded change to embedded content
+type ContentChange struct {
+	ID           int64
+	TenantID     uuid.UUID
+	ContextID    uuid.UUID
+	ContentIndex int
+	Operation    string // insert, update or delete
+	ChangedAt    time.Time
+	// Content is the current content, read when the change is applied. Exists is
+	// false when the content has since been deleted.
+	Content string
+	Exists  bool
+}
+
+// ChangeFeedStats summarizes a poll of the change feed
+type ChangeFeedStats struct {
+	Reindexed int
+	Removed   int
+	Skipped   int
+	Failed    int
+	LastID    int64
+}
+
+// ChangeFeedConsumer keeps the embedding index in sync with content changes by
+// re-embedding changed content and removing embeddings of deleted content. It
+// polls the change log past its stored cursor, so no changes are lost while it
+// isn't running.
+type ChangeFeedConsumer struct {
+	db               *sql.DB
+	repository       *Repository
+	embeddingService EmbeddingService
+	config           ChangeFeedConfig
+	logger           observability.Logger
+	metrics          observability.MetricsClient
+
+	// Failed polls by change ID
+	attempts map[int64]int
+}
+
+// NewChangeFeedConsumer creates a new change feed consumer
+func NewChangeFeedConsumer(
+	db *sql.DB,
+	repository *Repository,
+	embeddingService EmbeddingService,
+	config ChangeFeedConfig,
+	logger observability.Logger,
+	metrics observability.MetricsClient,
+) *ChangeFeedConsumer {
+	if config.Consumer == "" {
+		config.Consumer = DefaultChangeFeedConsumer
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultChangeFeedPollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultChangeFeedBatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultChangeFeedMaxAttempts
+	}
+	if logger == nil {
+		logger = observability.NewLogger("embedding.change_feed")
+	}
+	if metrics == nil {
+		metrics = observability.NewMetricsClient()
+	}
+
+	return &ChangeFeedConsumer{
+		db:               db,
+		repository:       repository,
+		embeddingService: embeddingService,
+		config:           config,
+		logger:           logger,
+		metrics:          metrics,
+		attempts:         make(map[int64]int),
+	}
+}
+
+// Run applies changes until ctx is cancelled, polling every PollInterval or when woken
+func (c *ChangeFeedConsumer) Run(ctx context.Context) error {
+	c.logger.Info("Starting embedding change feed consumer", map[string]interface{}{
+		"consumer":      c.config.Consumer,
+		"poll_interval": c.config.PollInterval.String(),
+		"batch_size":    c.config.BatchSize,
+	})
+
+	ticker := time.NewTicker(c.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep polling while batches are full to catch up on a backlog
+		for {
+			stats, err := c.ProcessPending(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				c.logger.Error("Failed to process embedding changes", map[string]interface{}{
+					"consumer": c.config.Consumer,
+					"error":    err.Error(),
+				})
+				break
+			}
+			if stats.Reindexed+stats.Removed+stats.Skipped+stats.Failed < c.config.BatchSize || stats.Failed > 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-c.config.Wake:
+		}
+	}
+}
+
+// ProcessPending applies the next batch of changes past the consumer's cursor.
+// The cursor stops before a failed change so it is retried on the next poll,
+// until it has failed MaxAttempts times and is skipped.
+func (c *ChangeFeedConsumer) ProcessPending(ctx context.Context) (*ChangeFeedStats, error) {
+	cursor, err := c.loadCursor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := c.loadChanges(ctx, cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ChangeFeedStats{LastID: cursor}
+	if len(changes) == 0 {
+		return stats, nil
+	}
+
+	// Changes are applied against current content, so only the latest change to
+	// each item in the batch needs applying
+	latest := make(map[string]int64, len(changes))
+	for _, change := range changes {
+		latest[changeTarget(change)] = change.ID
+	}
+
+	for _, change := range changes {
+		if latest[changeTarget(change)] != change.ID {
+			stats.Skipped++
+			stats.LastID = change.ID
+			continue
+		}
+
+		if err := c.applyChange(ctx, change, stats); err != nil {
+			c.attempts[change.ID]++
+			c.logger.Warn("Failed to apply embedding change", map[string]interface{}{
+				"change_id":  change.ID,
+				"context_id": change.ContextID.String(),
+				"attempt":    c.attempts[change.ID],
+				"error":      err.Error(),
+			})
+			if c.attempts[change.ID] < c.config.MaxAttempts {
+				stats.Failed++
+				break
+			}
+			c.logger.Error("Skipping embedding change after repeated failures", map[string]interface{}{
+				"change_id":  change.ID,
+				"context_id": change.ContextID.String(),
+				"attempts":   c.attempts[change.ID],
+			})
+			stats.Skipped++
+		}
+
+		delete(c.attempts, change.ID)
+		stats.LastID = change.ID
+		c.metrics.RecordHistogram("embedding.change_feed.lag", time.Since(change.ChangedAt).Seconds(), nil)
+	}
+
+	if stats.LastID > cursor {
+		if err := c.saveCursor(ctx, stats.LastID); err != nil {
+			return nil, err
+		}
+	}
+
+	c.metrics.IncrementCounter("embedding.change_feed.reindexed", float64(stats.Reindexed))
+	c.metrics.IncrementCounter("embedding.change_feed.removed", float64(stats.Removed))
+	c.metrics.IncrementCounter("embedding.change_feed.failed", float64(stats.Failed))
+
+	return stats, nil
+}
+
+func changeTarget(change ContentChange) string {
+	return fmt.Sprintf("%s:%d", change.ContextID, change.ContentIndex)
+}
+
+// applyChange re-embeds changed content or removes the embeddings of deleted content
+func (c *ChangeFeedConsumer) applyChange(ctx context.Context, change ContentChange, stats *ChangeFeedStats) error {
+	if !change.Exists || change.Content == "" {
+		if err := c.removeEmbeddings(ctx, change); err != nil {
+			return err
+		}
+		stats.Removed++
+		return nil
+	}
+
+	// Generate first so a failure leaves the existing embeddings in place
+	contentID := changeTarget(change)
+	vector, err := c.embeddingService.GenerateEmbedding(ctx, change.Content, "text", contentID)
+	if err != nil {
+		return fmt.Errorf("failed to generate embedding: %w", err)
+	}
+	if vector == nil || len(vector.Vector) == 0 {
+		return errors.New("embedding service returned an empty embedding")
+	}
+
+	if err := c.removeEmbeddings(ctx, change); err != nil {
+		return err
+	}
+
+	metadata, err := json.Marshal(map[string]interface{}{
+		"source":    "change_feed",
+		"change_id": change.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	contextID := change.ContextID
+	if _, err := c.repository.InsertEmbedding(ctx, InsertRequest{
+		ContextID:    &contextID,
+		Content:      change.Content,
+		Embedding:    vector.Vector,
+		ModelName:    c.embeddingService.GetModelConfig().Name,
+		TenantID:     change.TenantID,
+		Metadata:     metadata,
+		ContentIndex: change.ContentIndex,
+	}); err != nil {
+		return err
+	}
+
+	stats.Reindexed++
+	return nil
+}
+
+func (c *ChangeFeedConsumer) removeEmbeddings(ctx context.Context, change ContentChange) error {
+	_, err := c.db.ExecContext(ctx, `
+		DELETE FROM mcp.embeddings
+		WHERE tenant_id = $1 AND context_id = $2 AND content_index = $3
+	`, change.TenantID, change.ContextID, change.ContentIndex)
+	if err != nil {
+		return fmt.Errorf("failed to remove embeddings: %w", err)
+	}
+	return nil
+}
+
+func (c *ChangeFeedConsumer) loadCursor(ctx context.Context) (int64, error) {
+	var cursor int64
+	err := c.db.QueryRowContext(ctx, `
+		SELECT last_change_id FROM mcp.embedding_change_cursors WHERE consumer = $1
+	`, c.config.Consumer).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to load change feed cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (c *ChangeFeedConsumer) saveCursor(ctx context.Context, cursor int64) error {
+	_, err := c.db.ExecContext(ctx, `
+		INSERT INTO mcp.embedding_change_cursors (consumer, last_change_id, updated_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (consumer) DO UPDATE
+		SET last_change_id = EXCLUDED.last_change_id, updated_at = EXCLUDED.updated_at
+	`, c.config.Consumer, cursor)
+	if err != nil {
+		return fmt.Errorf("failed to save change feed cursor: %w", err)
+	}
+	return nil
+}
+
+// loadChanges returns the changes after the cursor with the current content of each changed item
+func (c *ChangeFeedConsumer) loadChanges(ctx context.Context, cursor int64) ([]ContentChange, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT ch.id, ch.tenant_id, ch.context_id, ch.content_index, ch.operation, ch.changed_at,
+		       COALESCE(ci.content, ''), ci.id IS NOT NULL
+		FROM mcp.embedding_changes ch
+		LEFT JOIN mcp.context_items ci
+			ON ci.context_id = ch.context_id AND ci.sequence_number = ch.content_index
+		WHERE ch.id > $1
+		ORDER BY ch.id
+		LIMIT $2
+	`, cursor, c.config.BatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embedding changes: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var changes []ContentChange
+	for rows.Next() {
+		var change ContentChange
+		if err := rows.Scan(
+			&change.ID,
+			&change.TenantID,
+			&change.ContextID,
+			&change.ContentIndex,
+			&change.Operation,
+			&change.ChangedAt,
+			&change.Content,
+			&change.Exists,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating embedding changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// ChangeFeedWakeups converts notifications from a pq.Listener subscribed to
+// ChangeFeedChannel into ChangeFeedConfig.Wake signals. Wakeups coalesce while
+// a poll is pending.
+func ChangeFeedWakeups(ctx context.Context, notifications <-chan *pq.Notification) <-chan struct{} {
+	wake := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-notifications:
+				if !ok {
+					return
+				}
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return wake
+}
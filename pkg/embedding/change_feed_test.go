@@ -0,0 +1,168 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+var changeFeedColumns = []string{"id", "tenant_id", "context_id", "content_index", "operation", "changed_at", "content", "exists"}
+
+// failingEmbeddingService fails to generate embeddings
+type failingEmbeddingService struct {
+	MockEmbeddingServiceForTests
+}
+
+func (s *failingEmbeddingService) GenerateEmbedding(ctx context.Context, text string, contentType string, contentID string) (*EmbeddingVector, error) {
+	return nil, errors.New("provider unavailable")
+}
+
+func newChangeFeedTestConsumer(t *testing.T, service EmbeddingService, config ChangeFeedConfig) (*ChangeFeedConsumer, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := observability.NewNoopLogger()
+	metrics := observability.NewNoOpMetricsClient()
+	repository := NewRepositoryWithObservability(db, logger, metrics)
+
+	return NewChangeFeedConsumer(db, repository, service, config, logger, metrics), mock
+}
+
+func expectReembed(mock sqlmock.Sqlmock, tenantID, contextID uuid.UUID, index int) {
+	mock.ExpectExec(`DELETE FROM mcp\.embeddings`).
+		WithArgs(tenantID, contextID, index).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`mcp\.insert_embedding`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+}
+
+func TestChangeFeedProcessPending(t *testing.T) {
+	consumer, mock := newChangeFeedTestConsumer(t, &MockEmbeddingServiceForTests{}, ChangeFeedConfig{Consumer: "search"})
+	tenantID, contextID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT last_change_id FROM mcp\.embedding_change_cursors`).
+		WithArgs("search").
+		WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}).AddRow(int64(10)))
+	mock.ExpectQuery(`FROM mcp\.embedding_changes ch`).
+		WithArgs(int64(10), DefaultChangeFeedBatchSize).
+		WillReturnRows(sqlmock.NewRows(changeFeedColumns).
+			AddRow(int64(11), tenantID, contextID, 0, "insert", now, "new message", true).
+			AddRow(int64(12), tenantID, contextID, 1, "update", now, "edited twice", true).
+			AddRow(int64(13), tenantID, contextID, 1, "update", now, "edited twice", true).
+			AddRow(int64(14), tenantID, contextID, 2, "delete", now, "", false))
+
+	// Inserted content is embedded
+	expectReembed(mock, tenantID, contextID, 0)
+	// Content updated twice is re-embedded once
+	expectReembed(mock, tenantID, contextID, 1)
+	// Deleted content is removed from the index
+	mock.ExpectExec(`DELETE FROM mcp\.embeddings`).
+		WithArgs(tenantID, contextID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO mcp\.embedding_change_cursors`).
+		WithArgs("search", int64(14)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	stats, err := consumer.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &ChangeFeedStats{Reindexed: 2, Removed: 1, Skipped: 1, LastID: 14}, stats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeFeedRetriesFailedChanges(t *testing.T) {
+	consumer, mock := newChangeFeedTestConsumer(t, &failingEmbeddingService{}, ChangeFeedConfig{MaxAttempts: 2})
+	tenantID, contextID := uuid.New(), uuid.New()
+	now := time.Now()
+
+	expectPoll := func() {
+		mock.ExpectQuery(`SELECT last_change_id FROM mcp\.embedding_change_cursors`).
+			WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}))
+		mock.ExpectQuery(`FROM mcp\.embedding_changes ch`).
+			WithArgs(int64(0), DefaultChangeFeedBatchSize).
+			WillReturnRows(sqlmock.NewRows(changeFeedColumns).
+				AddRow(int64(1), tenantID, contextID, 0, "update", now, "edited", true))
+	}
+
+	// The cursor stays before the failed change so it is retried
+	expectPoll()
+	stats, err := consumer.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Failed)
+	assert.Equal(t, int64(0), stats.LastID)
+
+	// The change is skipped once it has failed MaxAttempts times
+	expectPoll()
+	mock.ExpectExec(`INSERT INTO mcp\.embedding_change_cursors`).
+		WithArgs(DefaultChangeFeedConsumer, int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	stats, err = consumer.ProcessPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Skipped)
+	assert.Equal(t, int64(1), stats.LastID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestChangeFeedRunAppliesChangesWithinLag(t *testing.T) {
+	tenantID, contextID := uuid.New(), uuid.New()
+
+	expectChange := func(mock sqlmock.Sqlmock) {
+		mock.ExpectQuery(`FROM mcp\.embedding_changes ch`).
+			WillReturnRows(sqlmock.NewRows(changeFeedColumns).
+				AddRow(int64(1), tenantID, contextID, 0, "insert", time.Now(), "new message", true))
+		expectReembed(mock, tenantID, contextID, 0)
+		mock.ExpectExec(`INSERT INTO mcp\.embedding_change_cursors`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	t.Run("polling", func(t *testing.T) {
+		consumer, mock := newChangeFeedTestConsumer(t, &MockEmbeddingServiceForTests{}, ChangeFeedConfig{PollInterval: 20 * time.Millisecond})
+
+		mock.ExpectQuery(`SELECT last_change_id`).WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}))
+		mock.ExpectQuery(`FROM mcp\.embedding_changes ch`).WillReturnRows(sqlmock.NewRows(changeFeedColumns))
+		mock.ExpectQuery(`SELECT last_change_id`).WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}))
+		expectChange(mock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = consumer.Run(ctx) }()
+
+		assert.Eventually(t, func() bool {
+			return mock.ExpectationsWereMet() == nil
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("notifications", func(t *testing.T) {
+		wake := make(chan struct{}, 1)
+		consumer, mock := newChangeFeedTestConsumer(t, &MockEmbeddingServiceForTests{}, ChangeFeedConfig{
+			PollInterval: time.Hour,
+			Wake:         wake,
+		})
+
+		mock.ExpectQuery(`SELECT last_change_id`).WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}))
+		mock.ExpectQuery(`FROM mcp\.embedding_changes ch`).WillReturnRows(sqlmock.NewRows(changeFeedColumns))
+		mock.ExpectQuery(`SELECT last_change_id`).WillReturnRows(sqlmock.NewRows([]string{"last_change_id"}))
+		expectChange(mock)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() { _ = consumer.Run(ctx) }()
+
+		wake <- struct{}{}
+		assert.Eventually(t, func() bool {
+			return mock.ExpectationsWereMet() == nil
+		}, time.Second, 5*time.Millisecond)
+	})
+}
@@ -0,0 +1,230 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// ContentExtractor extracts embeddable text from content of the types it handles,
+// e.g. the text of a PDF or the comments of a source file
+type ContentExtractor interface {
+	// ContentTypes lists the media types the extractor handles, e.g. "text/html"
+	ContentTypes() []string
+	Extract(ctx context.Context, content string) (string, error)
+}
+
+// ContentExtractorRegistry dispatches content to extractors by content type.
+// Content of types without an extractor is embedded as-is.
+type ContentExtractorRegistry struct {
+	mu         sync.RWMutex
+	extractors map[string]ContentExtractor
+}
+
+// NewContentExtractorRegistry creates a registry with the built-in HTML and
+// markdown extractors, followed by the given ones, which replace built-ins for
+// the same content types
+func NewContentExtractorRegistry(extractors ...ContentExtractor) *ContentExtractorRegistry {
+	r := &ContentExtractorRegistry{extractors: make(map[string]ContentExtractor)}
+	r.Register(HTMLExtractor{})
+	r.Register(MarkdownExtractor{})
+	for _, extractor := range extractors {
+		r.Register(extractor)
+	}
+	return r
+}
+
+// Register adds an extractor for its content types
+func (r *ContentExtractorRegistry) Register(extractor ContentExtractor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, contentType := range extractor.ContentTypes() {
+		r.extractors[normalizeContentType(contentType)] = extractor
+	}
+}
+
+// Extract returns the embeddable text of the content
+func (r *ContentExtractorRegistry) Extract(ctx context.Context, contentType, content string) (string, error) {
+	if contentType == "" {
+		return content, nil
+	}
+
+	r.mu.RLock()
+	extractor, ok := r.extractors[normalizeContentType(contentType)]
+	r.mu.RUnlock()
+	if !ok {
+		return content, nil
+	}
+
+	text, err := extractor.Extract(ctx, content)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s content: %w", contentType, err)
+	}
+	return text, nil
+}
+
+// normalizeContentType drops parameters such as charset from a media type
+func normalizeContentType(contentType string) string {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// HTMLExtractor extracts the visible text of HTML documents
+type HTMLExtractor struct{}
+
+// ContentTypes implements ContentExtractor
+func (HTMLExtractor) ContentTypes() []string {
+	return []string{"text/html", "application/xhtml+xml", "html"}
+}
+
+// htmlSkippedElements hold content that isn't visible text
+var htmlSkippedElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "head": true, "svg": true,
+}
+
+// htmlBlockElements start a new line of text
+var htmlBlockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true,
+	"div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true,
+	"p": true, "pre": true, "section": true, "table": true, "td": true, "th": true, "tr": true,
+	"ul": true,
+}
+
+// Extract implements ContentExtractor
+func (HTMLExtractor) Extract(ctx context.Context, content string) (string, error) {
+	tokenizer := html.NewTokenizer(strings.NewReader(content))
+
+	var text strings.Builder
+	skipDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); err != io.EOF {
+				return "", err
+			}
+			return normalizeWhitespace(text.String()), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if htmlSkippedElements[tag] && tokenType == html.StartTagToken {
+				skipDepth++
+			}
+			if htmlBlockElements[tag] {
+				text.WriteString("\n")
+			}
+
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if htmlSkippedElements[tag] && skipDepth > 0 {
+				skipDepth--
+			}
+			if htmlBlockElements[tag] {
+				text.WriteString("\n")
+			}
+
+		case html.TextToken:
+			if skipDepth == 0 {
+				// Text tokens have entities decoded
+				text.Write(tokenizer.Text())
+			}
+		}
+	}
+}
+
+// MarkdownExtractor strips markdown syntax, leaving the text it formats
+type MarkdownExtractor struct{}
+
+// ContentTypes implements ContentExtractor
+func (MarkdownExtractor) ContentTypes() []string {
+	return []string{"text/markdown", "text/x-markdown", "markdown"}
+}
+
+var (
+	markdownFence         = regexp.MustCompile("^\\s*(```|~~~)")
+	markdownHeading       = regexp.MustCompile(`^\s{0,3}#{1,6}\s+`)
+	markdownHeadingClose  = regexp.MustCompile(`\s+#+\s*$`)
+	markdownSetextLine    = regexp.MustCompile(`^\s*(=+|-+)\s*$`)
+	markdownRule          = regexp.MustCompile(`^\s*([-*_]\s*){3,}$`)
+	markdownBlockquote    = regexp.MustCompile(`^\s*(>\s?)+`)
+	markdownListMarker    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(\[[ xX]\]\s+)?`)
+	markdownImage         = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink          = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	markdownReferenceLink = regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	markdownLinkDef       = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s+\S+`)
+	markdownAutolink      = regexp.MustCompile(`<((?:https?|mailto):[^>]+)>`)
+	markdownInlineCode    = regexp.MustCompile("`+([^`]+)`+")
+	markdownEmphasis      = regexp.MustCompile(`(\*\*|\*|~~)([^*~\s](?:[^*~]*[^*~\s])?)(\*\*|\*|~~)`)
+	markdownHTMLTag       = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// markdownUnderscore matches underscore emphasis, which only counts at word
+// boundaries so identifiers like snake_case are left alone
+var markdownUnderscore = regexp.MustCompile(`(^|\W)(__|_)([^_\s](?:[^_]*[^_\s])?)(__|_)(\W|$)`)
+
+// Extract implements ContentExtractor
+func (MarkdownExtractor) Extract(ctx context.Context, content string) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	inFence := false
+	for _, line := range lines {
+		// Code blocks are kept verbatim, without their fences
+		if markdownFence.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			out = append(out, line)
+			continue
+		}
+
+		if markdownLinkDef.MatchString(line) || markdownRule.MatchString(line) || markdownSetextLine.MatchString(line) {
+			continue
+		}
+
+		line = markdownHeading.ReplaceAllString(line, "")
+		line = markdownHeadingClose.ReplaceAllString(line, "")
+		line = markdownBlockquote.ReplaceAllString(line, "")
+		line = markdownListMarker.ReplaceAllString(line, "$1")
+		line = markdownImage.ReplaceAllString(line, "$1")
+		line = markdownLink.ReplaceAllString(line, "$1")
+		line = markdownReferenceLink.ReplaceAllString(line, "$1")
+		line = markdownAutolink.ReplaceAllString(line, "$1")
+		line = markdownInlineCode.ReplaceAllString(line, "$1")
+		line = markdownHTMLTag.ReplaceAllString(line, "")
+		// Emphasis can be nested, e.g. ***bold italic***
+		for i := 0; i < 3; i++ {
+			line = markdownEmphasis.ReplaceAllString(line, "$2")
+			line = markdownUnderscore.ReplaceAllString(line, "$1$3$5")
+		}
+		out = append(out, line)
+	}
+
+	return normalizeWhitespace(strings.Join(out, "\n")), nil
+}
+
+// normalizeWhitespace collapses runs of spaces within lines, trims lines and
+// drops blank lines
+func normalizeWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
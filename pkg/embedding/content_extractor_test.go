@@ -0,0 +1,147 @@
+package embedding
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTMLExtractor(t *testing.T) {
+	content := `<!DOCTYPE html>
+<html>
+<head><title>Ignored</title><style>body { color: red; }</style></head>
+<body>
+  <h1>Deploying   with Helm</h1>
+  <script>trackPageView();</script>
+  <p>Install the <b>chart</b> &amp; set <code>replicas</code>.<br>Then upgrade.</p>
+  <ul><li>One</li><li>Two</li></ul>
+</body>
+</html>`
+
+	text, err := HTMLExtractor{}.Extract(context.Background(), content)
+	require.NoError(t, err)
+	assert.Equal(t, "Deploying with Helm\nInstall the chart & set replicas.\nThen upgrade.\nOne\nTwo", text)
+}
+
+func TestMarkdownExtractor(t *testing.T) {
+	content := "# Deploying with Helm #\n" +
+		"\n" +
+		"Install the **chart** and set `replicas` in [values](https://example.com/values).\n" +
+		"Keep *snake_case_names* intact, but drop __underscored__ emphasis.\n" +
+		"\n" +
+		"> Note: ![diagram](diagram.png) shows the flow\n" +
+		"\n" +
+		"---\n" +
+		"- [x] One\n" +
+		"2. Two\n" +
+		"\n" +
+		"```yaml\n" +
+		"replicas: 3\n" +
+		"```\n" +
+		"[values]: https://example.com/values\n"
+
+	text, err := MarkdownExtractor{}.Extract(context.Background(), content)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"Deploying with Helm",
+		"Install the chart and set replicas in values.",
+		"Keep snake_case_names intact, but drop underscored emphasis.",
+		"Note: diagram shows the flow",
+		"One",
+		"Two",
+		"replicas: 3",
+	}, "\n"), text)
+}
+
+// upperExtractor is a pluggable extractor for a custom content type
+type upperExtractor struct{}
+
+func (upperExtractor) ContentTypes() []string { return []string{"text/x-shout"} }
+
+func (upperExtractor) Extract(ctx context.Context, content string) (string, error) {
+	return strings.ToUpper(content), nil
+}
+
+func TestContentExtractorRegistry(t *testing.T) {
+	ctx := context.Background()
+	registry := NewContentExtractorRegistry(upperExtractor{})
+
+	text, err := registry.Extract(ctx, "text/html; charset=utf-8", "<p>Hello</p>")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", text)
+
+	text, err = registry.Extract(ctx, "text/x-shout", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO", text)
+
+	// Unknown and missing content types are embedded as-is
+	text, err = registry.Extract(ctx, "application/pdf", "<p>Hello</p>")
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Hello</p>", text)
+	text, err = registry.Extract(ctx, "", "<p>Hello</p>")
+	require.NoError(t, err)
+	assert.Equal(t, "<p>Hello</p>", text)
+}
+
+func TestGenerateEmbeddingExtractsContent(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name        string
+		contentType string
+		text        string
+		extracted   string
+	}{
+		{
+			name:        "html is stripped to text",
+			contentType: "text/html",
+			text:        "<div><h2>Rollbacks</h2><p>Run <code>helm rollback</code> &amp; verify.</p></div>",
+			extracted:   "Rollbacks\nRun helm rollback & verify.",
+		},
+		{
+			name:        "markdown is normalized",
+			contentType: "text/markdown",
+			text:        "## Rollbacks\n\nRun `helm rollback` and **verify**.",
+			extracted:   "Rollbacks\nRun helm rollback and verify.",
+		},
+		{
+			name: "plain text is embedded as-is",
+			text: "Run <helm rollback> and **verify**.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			extracted := tt.extracted
+			if extracted == "" {
+				extracted = tt.text
+			}
+
+			service, dbMock := newDedupTestService(t, DeduplicationConfig{})
+			dbMock.ExpectQuery(`WHERE e.content_hash = \$1`).
+				WithArgs(CalculateContentHash(extracted), sqlmock.AnyArg(), tenantID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			dbMock.ExpectQuery(`SELECT mcp.insert_embedding`).
+				WithArgs(
+					sqlmock.AnyArg(), extracted, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+			_, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+				AgentID:     "test-agent",
+				Text:        tt.text,
+				ContentType: tt.contentType,
+				TenantID:    tenantID,
+			})
+			require.NoError(t, err)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ContentInvalidator purges cached results built from content, keyed by the
+// content's ID. The semantic search cache implements it.
+type ContentInvalidator interface {
+	InvalidateContent(ctx context.Context, contentIDs ...string) error
+}
+
+// AddContentInvalidator registers a cache to invalidate whenever the repository
+// creates, updates or deletes content. Register invalidators before use.
+func (r *Repository) AddContentInvalidator(invalidator ContentInvalidator) {
+	r.invalidators = append(r.invalidators, invalidator)
+}
+
+// invalidateContent notifies the registered invalidators that content changed.
+// Failures are logged rather than failing the mutation; the cached results then
+// expire with their TTL.
+func (r *Repository) invalidateContent(ctx context.Context, ids ...uuid.UUID) {
+	if len(r.invalidators) == 0 {
+		return
+	}
+
+	contentIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != uuid.Nil {
+			contentIDs = append(contentIDs, id.String())
+		}
+	}
+	if len(contentIDs) == 0 {
+		return
+	}
+
+	for _, invalidator := range r.invalidators {
+		if err := invalidator.InvalidateContent(ctx, contentIDs...); err != nil {
+			r.logger.Warn("Failed to invalidate cached results for content", map[string]interface{}{
+				"content_ids": contentIDs,
+				"error":       err.Error(),
+			})
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/cache"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// cachedSearcher serves search results from the semantic cache, computing and
+// caching them on a miss
+type cachedSearcher struct {
+	cache    *cache.SemanticCache
+	results  []cache.CachedSearchResult
+	computed int
+}
+
+func (s *cachedSearcher) search(t *testing.T, query string) []cache.CachedSearchResult {
+	t.Helper()
+
+	entry, err := s.cache.Get(context.Background(), query, nil)
+	require.NoError(t, err)
+	if entry != nil {
+		return entry.Results
+	}
+
+	s.computed++
+	require.NoError(t, s.cache.Set(context.Background(), query, nil, s.results))
+	return s.results
+}
+
+func newContentInvalidationTest(t *testing.T) (*Repository, sqlmock.Sqlmock, *cache.SemanticCache) {
+	t.Helper()
+
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	searchCache, err := cache.NewSemanticCache(client, &cache.Config{
+		SimilarityThreshold: 0.95,
+		MaxCandidates:       10,
+		Prefix:              "search_cache",
+	}, observability.NewNoopLogger())
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	repository := NewRepositoryWithObservability(db, observability.NewNoopLogger(), observability.NewNoOpMetricsClient())
+	repository.AddContentInvalidator(searchCache)
+	return repository, mock, searchCache
+}
+
+func TestContentMutationInvalidatesCachedSearches(t *testing.T) {
+	ctx := context.Background()
+	tenantID, contextID := uuid.New(), uuid.New()
+	contributing, other := uuid.New(), uuid.New()
+
+	results := []cache.CachedSearchResult{
+		{ID: contributing.String(), Content: "deploy with helm", Score: 0.9, Metadata: map[string]interface{}{"context_id": contextID.String()}},
+		{ID: other.String(), Content: "deploy with terraform", Score: 0.8},
+	}
+
+	t.Run("deleting a contributing embedding", func(t *testing.T) {
+		repository, mock, searchCache := newContentInvalidationTest(t)
+		searcher := &cachedSearcher{cache: searchCache, results: results}
+
+		searcher.search(t, "how do we deploy")
+		searcher.search(t, "how do we deploy")
+		require.Equal(t, 1, searcher.computed)
+
+		mock.ExpectExec(`SET deleted_at = CURRENT_TIMESTAMP`).
+			WithArgs(contributing, tenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repository.DeleteEmbedding(ctx, contributing, tenantID))
+
+		// The next search recomputes
+		searcher.search(t, "how do we deploy")
+		assert.Equal(t, 2, searcher.computed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("adding content to a contributing context", func(t *testing.T) {
+		repository, mock, searchCache := newContentInvalidationTest(t)
+		searcher := &cachedSearcher{cache: searchCache, results: results}
+
+		searcher.search(t, "how do we deploy")
+
+		mock.ExpectQuery("SELECT mcp.insert_embedding").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New().String()))
+		_, err := repository.InsertEmbedding(ctx, InsertRequest{
+			ContextID: &contextID,
+			Content:   "deploy with argo",
+			Embedding: []float32{0.1, 0.2},
+			ModelName: "text-embedding-3-small",
+			TenantID:  tenantID,
+		})
+		require.NoError(t, err)
+
+		searcher.search(t, "how do we deploy")
+		assert.Equal(t, 2, searcher.computed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("mutating unrelated content keeps the cached result", func(t *testing.T) {
+		repository, mock, searchCache := newContentInvalidationTest(t)
+		searcher := &cachedSearcher{cache: searchCache, results: results}
+
+		searcher.search(t, "how do we deploy")
+
+		mock.ExpectExec(`SET deleted_at = CURRENT_TIMESTAMP`).
+			WithArgs(sqlmock.AnyArg(), tenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		require.NoError(t, repository.DeleteEmbedding(ctx, uuid.New(), tenantID))
+
+		searcher.search(t, "how do we deploy")
+		assert.Equal(t, 1, searcher.computed)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// failingInvalidator fails every invalidation
+type failingInvalidator struct {
+	calls int
+}
+
+func (f *failingInvalidator) InvalidateContent(ctx context.Context, contentIDs ...string) error {
+	f.calls++
+	return errors.New("cache unavailable")
+}
+
+func TestContentInvalidationFailureDoesNotFailMutation(t *testing.T) {
+	repository, mock, _ := newContentInvalidationTest(t)
+	invalidator := &failingInvalidator{}
+	repository.AddContentInvalidator(invalidator)
+	tenantID, contextID := uuid.New(), uuid.New()
+
+	mock.ExpectExec(`WHERE context_id = \$1`).
+		WithArgs(contextID, tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	deleted, err := repository.DeleteContextEmbeddings(context.Background(), contextID, tenantID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Equal(t, 1, invalidator.calls)
+}
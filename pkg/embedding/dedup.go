@@ -0,0 +1,141 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// DuplicatePolicy decides what happens when new content is a near-duplicate of
+// content that is already indexed
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicySkip keeps the existing embedding and doesn't store the new one
+	DuplicatePolicySkip DuplicatePolicy = "skip"
+	// DuplicatePolicyMerge merges the new content's metadata into the existing embedding
+	DuplicatePolicyMerge DuplicatePolicy = "merge"
+	// DuplicatePolicyLink stores the new embedding with a reference to the existing one
+	DuplicatePolicyLink DuplicatePolicy = "link"
+)
+
+// DefaultDuplicateThreshold is the similarity above which content is considered
+// a near-duplicate when no threshold is configured
+const DefaultDuplicateThreshold = 0.97
+
+// DeduplicationConfig configures near-duplicate detection at index time. Exact
+// duplicates are always detected by content hash, before generating.
+type DeduplicationConfig struct {
+	Enabled   bool            `json:"enabled"`
+	Threshold float64         `json:"threshold"` // Similarity at or above which content is a near-duplicate
+	Policy    DuplicatePolicy `json:"policy"`    // skip, merge or link; defaults to skip
+}
+
+func (c DeduplicationConfig) threshold() float64 {
+	if c.Threshold <= 0 {
+		return DefaultDuplicateThreshold
+	}
+	return c.Threshold
+}
+
+func (c DeduplicationConfig) policy() DuplicatePolicy {
+	if c.Policy == "" {
+		return DuplicatePolicySkip
+	}
+	return c.Policy
+}
+
+// Validate checks the policy and threshold are usable
+func (c DeduplicationConfig) Validate() error {
+	switch c.policy() {
+	case DuplicatePolicySkip, DuplicatePolicyMerge, DuplicatePolicyLink:
+	default:
+		return fmt.Errorf("unknown duplicate policy: %s", c.Policy)
+	}
+	if c.Threshold > 1 {
+		return fmt.Errorf("duplicate threshold must be at most 1, got %v", c.Threshold)
+	}
+	return nil
+}
+
+// FindNearDuplicate returns the most similar embedding of the same model at or
+// above the threshold, or nil when there is none. Searches are scoped to the
+// context when one is given, and to the tenant otherwise.
+func (r *Repository) FindNearDuplicate(ctx context.Context, embedding []float32, modelName string, tenantID uuid.UUID, contextID *uuid.UUID, threshold float64) (*EmbeddingSearchResult, error) {
+	results, err := r.SearchEmbeddings(ctx, SearchRequest{
+		QueryEmbedding: embedding,
+		ModelName:      modelName,
+		TenantID:       tenantID,
+		ContextID:      contextID,
+		Limit:          1,
+		Threshold:      threshold,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || results[0].Similarity < threshold {
+		return nil, nil
+	}
+	return &results[0], nil
+}
+
+// MergeEmbeddingMetadata merges metadata into an embedding's existing metadata,
+// overwriting keys present in both
+func (r *Repository) MergeEmbeddingMetadata(ctx context.Context, id, tenantID uuid.UUID, metadata json.RawMessage) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE mcp.embeddings
+		SET metadata = COALESCE(metadata, '{}'::jsonb) || $1::jsonb, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL
+	`, metadata, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to merge embedding metadata: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return ErrEmbeddingNotFound
+	}
+
+	r.invalidateContent(ctx, id)
+	return nil
+}
+
+// handleNearDuplicate applies the deduplication policy to a generated embedding.
+// It returns the ID of the existing embedding to use instead of storing the new
+// one, or nil when the embedding should be stored, in which case metadata may
+// have been updated to link it to its duplicate.
+func (s *ServiceV2) handleNearDuplicate(ctx context.Context, req GenerateEmbeddingRequest, embedding []float32, model string, callerMetadata, metadata map[string]interface{}) (*uuid.UUID, error) {
+	duplicate, err := s.repository.FindNearDuplicate(ctx, embedding, model, req.TenantID, req.ContextID, s.deduplication.threshold())
+	if err != nil {
+		// Indexing proceeds without deduplication if the lookup fails
+		s.repository.logger.Warn("Failed to check for near-duplicate embeddings", map[string]interface{}{
+			"error":     err.Error(),
+			"tenant_id": req.TenantID,
+			"model":     model,
+		})
+		return nil, nil
+	}
+	if duplicate == nil {
+		return nil, nil
+	}
+
+	switch s.deduplication.policy() {
+	case DuplicatePolicyLink:
+		metadata["duplicate_of"] = duplicate.ID.String()
+		metadata["duplicate_similarity"] = duplicate.Similarity
+		return nil, nil
+
+	case DuplicatePolicyMerge:
+		if len(callerMetadata) > 0 {
+			if err := s.repository.MergeEmbeddingMetadata(ctx, duplicate.ID, req.TenantID, json.RawMessage(mustMarshalJSON(callerMetadata))); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	metadata["deduplicated"] = true
+	metadata["duplicate_of"] = duplicate.ID.String()
+	metadata["duplicate_similarity"] = duplicate.Similarity
+	metadata["duplicate_policy"] = string(s.deduplication.policy())
+	return &duplicate.ID, nil
+}
@@ -0,0 +1,171 @@
+package embedding
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+// metadataArg matches JSON metadata containing the given values
+type metadataArg map[string]interface{}
+
+func (m metadataArg) Match(v driver.Value) bool {
+	var data []byte
+	switch value := v.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return false
+	}
+
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return false
+	}
+	for key, expected := range m {
+		if metadata[key] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func newDedupTestService(t *testing.T, config DeduplicationConfig) (*ServiceV2, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	agentService := &MockAgentService{}
+	agentService.On("GetConfig", mock.Anything, "test-agent").Return(nil, errors.New("not found"))
+
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers:     map[string]providers.Provider{"openai": providers.NewMockProvider("openai")},
+		AgentService:  agentService,
+		Repository:    NewRepository(db),
+		FallbackChain: []string{"openai:mock-model-small"},
+		Deduplication: config,
+	})
+	require.NoError(t, err)
+	return service, dbMock
+}
+
+// expectNearDuplicate expects the exact duplicate check to miss and the near
+// duplicate search to find an embedding with the given similarity
+func expectNearDuplicate(dbMock sqlmock.Sqlmock, existingID uuid.UUID, similarity float64) {
+	dbMock.ExpectQuery(`WHERE e.content_hash = \$1`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	dbMock.ExpectQuery(`mcp.search_embeddings`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "context_id", "content", "similarity", "metadata", "model_provider"}).
+			AddRow(existingID, uuid.New(), "Deploying with Helm charts", similarity, []byte(`{}`), "openai"))
+}
+
+func TestGenerateEmbeddingDeduplication(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+	existingID := uuid.New()
+	// Generating adds to the request metadata, so each run gets its own
+	newRequest := func() GenerateEmbeddingRequest {
+		return GenerateEmbeddingRequest{
+			AgentID:  "test-agent",
+			Text:     "Deploying with Helm charts.",
+			TenantID: tenantID,
+			Metadata: map[string]interface{}{"source": "wiki"},
+		}
+	}
+
+	t.Run("skip returns the existing embedding", func(t *testing.T) {
+		service, dbMock := newDedupTestService(t, DeduplicationConfig{Enabled: true, Threshold: 0.95})
+		expectNearDuplicate(dbMock, existingID, 0.98)
+
+		resp, err := service.GenerateEmbedding(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, existingID, resp.EmbeddingID)
+		assert.Equal(t, true, resp.Metadata["deduplicated"])
+		assert.Equal(t, string(DuplicatePolicySkip), resp.Metadata["duplicate_policy"])
+		// Nothing is inserted
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("merge adds the metadata to the existing embedding", func(t *testing.T) {
+		service, dbMock := newDedupTestService(t, DeduplicationConfig{Enabled: true, Threshold: 0.95, Policy: DuplicatePolicyMerge})
+		expectNearDuplicate(dbMock, existingID, 0.98)
+		dbMock.ExpectExec(`UPDATE mcp.embeddings`).
+			WithArgs(metadataArg{"source": "wiki"}, existingID, tenantID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		resp, err := service.GenerateEmbedding(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, existingID, resp.EmbeddingID)
+		assert.Equal(t, string(DuplicatePolicyMerge), resp.Metadata["duplicate_policy"])
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("link stores the embedding referencing the existing one", func(t *testing.T) {
+		service, dbMock := newDedupTestService(t, DeduplicationConfig{Enabled: true, Threshold: 0.95, Policy: DuplicatePolicyLink})
+		expectNearDuplicate(dbMock, existingID, 0.98)
+		newID := uuid.New()
+		dbMock.ExpectQuery(`SELECT mcp.insert_embedding`).
+			WithArgs(
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				metadataArg{"duplicate_of": existingID.String(), "source": "wiki"},
+				sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newID))
+
+		resp, err := service.GenerateEmbedding(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, newID, resp.EmbeddingID)
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("content below the threshold is stored", func(t *testing.T) {
+		service, dbMock := newDedupTestService(t, DeduplicationConfig{Enabled: true, Threshold: 0.99})
+		expectNearDuplicate(dbMock, existingID, 0.98)
+		newID := uuid.New()
+		dbMock.ExpectQuery(`SELECT mcp.insert_embedding`).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newID))
+
+		resp, err := service.GenerateEmbedding(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, newID, resp.EmbeddingID)
+		assert.NotContains(t, resp.Metadata, "duplicate_of")
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+
+	t.Run("exact duplicates are deduplicated by content hash", func(t *testing.T) {
+		service, dbMock := newDedupTestService(t, DeduplicationConfig{Enabled: true, Policy: DuplicatePolicyLink})
+		dbMock.ExpectQuery(`WHERE e.content_hash = \$1`).
+			WithArgs(CalculateContentHash("Deploying with Helm charts."), sqlmock.AnyArg(), tenantID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existingID))
+
+		resp, err := service.GenerateEmbedding(ctx, newRequest())
+		require.NoError(t, err)
+		assert.Equal(t, existingID, resp.EmbeddingID)
+		assert.True(t, resp.Cached)
+		assert.Equal(t, true, resp.Metadata["deduplicated"])
+		assert.NoError(t, dbMock.ExpectationsWereMet())
+	})
+}
+
+func TestDeduplicationConfigValidate(t *testing.T) {
+	assert.NoError(t, DeduplicationConfig{}.Validate())
+	assert.NoError(t, DeduplicationConfig{Policy: DuplicatePolicyLink, Threshold: 0.9}.Validate())
+	assert.Error(t, DeduplicationConfig{Policy: "replace"}.Validate())
+	assert.Error(t, DeduplicationConfig{Threshold: 1.5}.Validate())
+
+	assert.Equal(t, DefaultDuplicateThreshold, DeduplicationConfig{}.threshold())
+	assert.Equal(t, DuplicatePolicySkip, DeduplicationConfig{}.policy())
+}
@@ -32,7 +32,15 @@ type SubQuery struct {
 	Focus string `json:"focus"`
 }
 
-// Expand decomposes the query into simpler sub-queries
+// Decomposition limits applied when ExpansionOptions leaves them unset
+const (
+	DefaultMaxDecompositionDepth = 1
+	DefaultMaxSubQueries         = 8
+)
+
+// Expand decomposes the query into simpler sub-queries. Sub-queries that are still
+// complex are decomposed further, up to opts.MaxDecompositionDepth levels and
+// opts.MaxSubQueries sub-queries in total.
 func (d *DecompositionExpander) Expand(ctx context.Context, query string, opts *ExpansionOptions) (*ExpandedQuery, error) {
 	// Start span for tracing
 	ctx, span := observability.StartSpan(ctx, "expansion.decomposition")
@@ -55,6 +63,61 @@ func (d *DecompositionExpander) Expand(ctx context.Context, query string, opts *
 		}, nil
 	}
 
+	maxDepth, maxSubQueries := DefaultMaxDecompositionDepth, DefaultMaxSubQueries
+	if opts != nil {
+		if opts.MaxDecompositionDepth > 0 {
+			maxDepth = opts.MaxDecompositionDepth
+		}
+		if opts.MaxSubQueries > 0 {
+			maxSubQueries = opts.MaxSubQueries
+		}
+	}
+
+	expansions := make([]QueryVariation, 0, maxSubQueries)
+	d.decompose(ctx, query, 1, maxDepth, maxSubQueries, &expansions)
+
+	span.SetAttribute("sub_queries_count", len(expansions))
+
+	d.logger.Info("Query decomposed", map[string]interface{}{
+		"original_query": query,
+		"sub_queries":    len(expansions),
+	})
+
+	return &ExpandedQuery{
+		Original:   query,
+		Expansions: expansions,
+	}, nil
+}
+
+// decompose appends the sub-queries of query to expansions, recursing into complex
+// sub-queries until maxDepth is reached or expansions holds maxSubQueries entries
+func (d *DecompositionExpander) decompose(ctx context.Context, query string, depth, maxDepth, maxSubQueries int, expansions *[]QueryVariation) {
+	for _, subQuery := range d.decomposeOnce(ctx, query) {
+		if len(*expansions) >= maxSubQueries {
+			d.logger.Debug("Sub-query limit reached, stopping decomposition", map[string]interface{}{
+				"query":           query,
+				"max_sub_queries": maxSubQueries,
+			})
+			return
+		}
+
+		subQuery.Metadata["depth"] = depth
+		*expansions = append(*expansions, subQuery)
+
+		if depth < maxDepth && len(*expansions) < maxSubQueries && !d.isSimpleQuery(subQuery.Text) {
+			children := make([]QueryVariation, 0)
+			d.decompose(ctx, subQuery.Text, depth+1, maxDepth, maxSubQueries-len(*expansions), &children)
+			for _, child := range children {
+				// Nested sub-queries are weighted relative to their parent
+				child.Weight *= subQuery.Weight
+				*expansions = append(*expansions, child)
+			}
+		}
+	}
+}
+
+// decomposeOnce splits a query into one level of sub-queries
+func (d *DecompositionExpander) decomposeOnce(ctx context.Context, query string) []QueryVariation {
 	prompt := fmt.Sprintf(`Decompose this search query into simpler sub-queries: "%s"
 
 Rules:
@@ -80,7 +143,7 @@ Example: [{"query": "Python error handling", "focus": "language and topic"}, {"q
 			"query": query,
 		})
 		// Fallback to simple decomposition
-		return d.simpleDecompose(query), nil
+		return d.simpleDecompose(query).Expansions
 	}
 
 	// Parse JSON response
@@ -91,7 +154,7 @@ Example: [{"query": "Python error handling", "focus": "language and topic"}, {"q
 			"response": response.Text,
 		})
 		// Fallback to simple decomposition
-		return d.simpleDecompose(query), nil
+		return d.simpleDecompose(query).Expansions
 	}
 
 	// Validate and filter decomposed queries
@@ -118,17 +181,7 @@ Example: [{"query": "Python error handling", "focus": "language and topic"}, {"q
 		})
 	}
 
-	span.SetAttribute("sub_queries_count", len(expansions))
-
-	d.logger.Info("Query decomposed", map[string]interface{}{
-		"original_query": query,
-		"sub_queries":    len(expansions),
-	})
-
-	return &ExpandedQuery{
-		Original:   query,
-		Expansions: expansions,
-	}, nil
+	return expansions
 }
 
 // isSimpleQuery checks if a query is already simple enough
@@ -271,3 +271,74 @@ func TestDecompositionExpander_Integration(t *testing.T) {
 		assert.Equal(t, ExpansionTypeDecompose, exp.Type)
 	}
 }
+
+// recursiveLLMClient always decomposes a query into complex sub-queries, so
+// decomposition would recurse forever without limits
+type recursiveLLMClient struct {
+	calls int
+}
+
+func (c *recursiveLLMClient) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	c.calls++
+	query := extractQueryFromPrompt(req.Prompt)
+	subQueries := []SubQuery{
+		{Query: "first aspect of " + query + " with details", Focus: "first"},
+		{Query: "second aspect of " + query + " with details", Focus: "second"},
+	}
+	jsonResponse, _ := json.Marshal(subQueries)
+	return &CompletionResponse{Text: string(jsonResponse)}, nil
+}
+
+func TestDecompositionExpander_RecursionLimits(t *testing.T) {
+	ctx := context.Background()
+	query := "deploy services with kubernetes and terraform"
+
+	t.Run("default depth does not recurse", func(t *testing.T) {
+		llm := &recursiveLLMClient{}
+		expander := NewDecompositionExpander(llm, nil)
+
+		result, err := expander.Expand(ctx, query, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, llm.calls)
+		assert.Len(t, result.Expansions, 2)
+	})
+
+	t.Run("depth limit", func(t *testing.T) {
+		llm := &recursiveLLMClient{}
+		expander := NewDecompositionExpander(llm, nil)
+
+		result, err := expander.Expand(ctx, query, &ExpansionOptions{
+			MaxDecompositionDepth: 2,
+			MaxSubQueries:         100,
+		})
+		require.NoError(t, err)
+
+		// One call for the query, one for each of its two sub-queries
+		assert.Equal(t, 3, llm.calls)
+		assert.Len(t, result.Expansions, 6)
+		for _, exp := range result.Expansions {
+			assert.LessOrEqual(t, exp.Metadata["depth"], 2)
+		}
+
+		// Nested sub-queries follow their parent and are weighted relative to it
+		assert.Equal(t, 1, result.Expansions[0].Metadata["depth"])
+		assert.Equal(t, 2, result.Expansions[1].Metadata["depth"])
+		assert.Equal(t, result.Expansions[0].Text, result.Expansions[1].Metadata["original_query"])
+		assert.Equal(t, result.Expansions[0].Weight*0.5, result.Expansions[1].Weight)
+	})
+
+	t.Run("total sub-query cap", func(t *testing.T) {
+		llm := &recursiveLLMClient{}
+		expander := NewDecompositionExpander(llm, nil)
+
+		result, err := expander.Expand(ctx, query, &ExpansionOptions{
+			MaxDecompositionDepth: 10,
+			MaxSubQueries:         5,
+		})
+		require.NoError(t, err)
+
+		assert.Len(t, result.Expansions, 5)
+		assert.LessOrEqual(t, llm.calls, 5)
+	})
+}
@@ -18,6 +18,12 @@ type ExpansionOptions struct {
 	ExpansionTypes  []ExpansionType
 	Language        string
 	Domain          string
+	// MaxDecompositionDepth limits how many levels complex sub-queries are
+	// decomposed further. Zero uses DefaultMaxDecompositionDepth.
+	MaxDecompositionDepth int
+	// MaxSubQueries caps the total number of sub-queries produced by decomposition
+	// across all levels. Zero uses DefaultMaxSubQueries.
+	MaxSubQueries int
 }
 
 // ExpansionType defines different expansion strategies
@@ -0,0 +1,164 @@
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// HNSW index defaults, matching pgvector's own
+const (
+	DefaultHNSWM              = 16
+	DefaultHNSWEfConstruction = 64
+	DefaultHNSWRowThreshold   = 100000
+	// MaxHNSWDimensions is the most dimensions pgvector can index with HNSW
+	MaxHNSWDimensions = 2000
+)
+
+// indexNamePattern matches the index names the HNSW helpers interpolate into DDL
+var indexNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// HNSWIndexConfig describes an HNSW index over the embeddings of one dimension.
+//
+// Embeddings are stored zero-padded to 4096 dimensions, more than HNSW can
+// index, so the index is built on the leading Dimensions of each embedding
+// and limited to embeddings of that dimension. SearchOptions.UseANNIndex
+// searches with the same expression.
+type HNSWIndexConfig struct {
+	Dimensions     int    // Dimensions of the indexed embeddings, at most MaxHNSWDimensions
+	Metric         string // "cosine" (default), "euclidean" or "dot_product", as SearchOptions ranks
+	M              int    // Connections per layer; defaults to DefaultHNSWM
+	EfConstruction int    // Candidate list size while building; defaults to DefaultHNSWEfConstruction
+	RowThreshold   int64  // Build once the table has more rows than this; defaults to DefaultHNSWRowThreshold
+}
+
+// HNSWSearchOptions tunes searches through an HNSW index
+type HNSWSearchOptions struct {
+	// EfSearch is the candidate list size, trading latency for recall. It
+	// should be at least the search limit; pgvector defaults it to 40.
+	EfSearch int `json:"ef_search,omitempty"`
+}
+
+// HNSWIndexName is the name of the HNSW index for embeddings of the given
+// dimensions and metric
+func HNSWIndexName(dimensions int, metric string) string {
+	if metric == "" {
+		metric = "cosine"
+	}
+	return fmt.Sprintf("idx_embeddings_hnsw_%d_%s", dimensions, metric)
+}
+
+// withDefaults validates the config and fills in its defaults
+func (c HNSWIndexConfig) withDefaults() (HNSWIndexConfig, error) {
+	if c.Dimensions <= 0 || c.Dimensions > MaxHNSWDimensions {
+		return c, fmt.Errorf("HNSW index dimensions must be between 1 and %d, got %d", MaxHNSWDimensions, c.Dimensions)
+	}
+	if c.Metric == "" {
+		c.Metric = "cosine"
+	}
+	if _, ok := hnswOperatorClasses[c.Metric]; !ok {
+		return c, fmt.Errorf("unsupported HNSW metric: %s", c.Metric)
+	}
+	if c.M <= 0 {
+		c.M = DefaultHNSWM
+	}
+	if c.EfConstruction <= 0 {
+		c.EfConstruction = DefaultHNSWEfConstruction
+	}
+	if c.RowThreshold <= 0 {
+		c.RowThreshold = DefaultHNSWRowThreshold
+	}
+	return c, nil
+}
+
+// hnswOperatorClasses are the pgvector operator classes of each metric
+var hnswOperatorClasses = map[string]string{
+	"cosine":      "vector_cosine_ops",
+	"euclidean":   "vector_l2_ops",
+	"dot_product": "vector_ip_ops",
+}
+
+// createHNSWIndexSQL is the statement building the index described by config
+func createHNSWIndexSQL(name string, config HNSWIndexConfig) string {
+	return fmt.Sprintf(
+		"CREATE INDEX CONCURRENTLY IF NOT EXISTS %s ON mcp.embeddings USING hnsw "+
+			"((subvector(embedding, 1, %d)::vector(%d)) %s) WITH (m = %d, ef_construction = %d) "+
+			"WHERE model_dimensions = %d",
+		name, config.Dimensions, config.Dimensions, hnswOperatorClasses[config.Metric],
+		config.M, config.EfConstruction, config.Dimensions)
+}
+
+// EnsureHNSWIndex builds the HNSW index described by config once mcp.embeddings
+// has more than config.RowThreshold rows, as estimated by the planner's
+// statistics. The index is built concurrently, so writes continue while it
+// builds. It reports whether it built the index; an existing valid index is
+// kept, and one left invalid by an interrupted build is rebuilt.
+func EnsureHNSWIndex(ctx context.Context, db *sql.DB, config HNSWIndexConfig) (bool, error) {
+	config, err := config.withDefaults()
+	if err != nil {
+		return false, err
+	}
+
+	var rows int64
+	err = db.QueryRowContext(ctx,
+		"SELECT reltuples::bigint FROM pg_class WHERE oid = 'mcp.embeddings'::regclass").Scan(&rows)
+	if err != nil {
+		return false, fmt.Errorf("failed to estimate embedding rows: %w", err)
+	}
+	if rows <= config.RowThreshold {
+		return false, nil
+	}
+
+	return buildHNSWIndex(ctx, db, HNSWIndexName(config.Dimensions, config.Metric), config)
+}
+
+// MigrateIVFFlatToHNSW replaces an ivfflat index on mcp.embeddings with the
+// HNSW index described by config, whatever the table's size. The HNSW index is
+// built and the ivfflat index dropped concurrently, so neither step locks the
+// table against reads or writes, and searches keep an index throughout.
+func MigrateIVFFlatToHNSW(ctx context.Context, db *sql.DB, ivfflatIndex string, config HNSWIndexConfig) error {
+	if !indexNamePattern.MatchString(ivfflatIndex) {
+		return fmt.Errorf("invalid index name: %q", ivfflatIndex)
+	}
+	config, err := config.withDefaults()
+	if err != nil {
+		return err
+	}
+
+	if _, err := buildHNSWIndex(ctx, db, HNSWIndexName(config.Dimensions, config.Metric), config); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS mcp.%s", ivfflatIndex)); err != nil {
+		return fmt.Errorf("failed to drop ivfflat index %s: %w", ivfflatIndex, err)
+	}
+	return nil
+}
+
+// buildHNSWIndex builds the named index unless a valid one exists. CREATE
+// INDEX CONCURRENTLY can't run in a transaction, so db is used directly.
+func buildHNSWIndex(ctx context.Context, db *sql.DB, name string, config HNSWIndexConfig) (bool, error) {
+	var valid bool
+	err := db.QueryRowContext(ctx, `
+		SELECT i.indisvalid FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'mcp' AND c.relname = $1`, name).Scan(&valid)
+	switch {
+	case err == nil && valid:
+		return false, nil
+	case err == nil:
+		// A failed concurrent build leaves an invalid index behind
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS mcp.%s", name)); err != nil {
+			return false, fmt.Errorf("failed to drop invalid HNSW index %s: %w", name, err)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return false, fmt.Errorf("failed to look up HNSW index %s: %w", name, err)
+	}
+
+	if _, err := db.ExecContext(ctx, createHNSWIndexSQL(name, config)); err != nil {
+		return false, fmt.Errorf("failed to build HNSW index %s: %w", name, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,95 @@
+//go:build integration
+// +build integration
+
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+// hnswBenchmarkRows is the corpus size the HNSW index must be 5x faster at
+const hnswBenchmarkRows = 1000000
+
+// BenchmarkHNSWSearchLatency compares the p99 latency of exact and HNSW
+// searches of 1536-dimension embeddings in the database at DATABASE_URL.
+// mcp.embeddings should already hold the corpus; with at least a million
+// rows the benchmark fails unless HNSW searches are 5x faster at p99.
+//
+//	DATABASE_URL=postgres://... go test -tags integration -run '^$' -bench HNSWSearchLatency ./embedding/
+func BenchmarkHNSWSearchLatency(b *testing.B) {
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		b.Skip("DATABASE_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	ctx := context.Background()
+	if err := db.PingContext(ctx); err != nil {
+		b.Skipf("Database not available: %v", err)
+	}
+
+	const dimensions = 1536
+	var rows int64
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM mcp.embeddings WHERE model_dimensions = $1", dimensions).Scan(&rows); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := EnsureHNSWIndex(ctx, db, HNSWIndexConfig{Dimensions: dimensions, RowThreshold: 1}); err != nil {
+		b.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "ANALYZE mcp.embeddings"); err != nil {
+		b.Fatal(err)
+	}
+
+	repo := repositorySearch.NewRepository(sqlx.NewDb(db, "postgres"))
+	random := rand.New(rand.NewSource(1))
+	search := func(options *repositorySearch.SearchOptions) time.Duration {
+		vector := make([]float32, dimensions)
+		for i := range vector {
+			vector[i] = random.Float32()*2 - 1
+		}
+		start := time.Now()
+		if _, err := repo.SearchByVector(ctx, vector, options); err != nil {
+			b.Fatal(err)
+		}
+		return time.Since(start)
+	}
+
+	var exact, ann []time.Duration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		exact = append(exact, search(&repositorySearch.SearchOptions{Limit: 10, MinSimilarity: -1}))
+		ann = append(ann, search(&repositorySearch.SearchOptions{Limit: 10, MinSimilarity: -1, UseANNIndex: true, EfSearch: 40}))
+	}
+	b.StopTimer()
+
+	exactP99, annP99 := p99(exact), p99(ann)
+	speedup := float64(exactP99) / float64(annP99)
+	b.ReportMetric(float64(exactP99.Microseconds())/1000, "exact-p99-ms")
+	b.ReportMetric(float64(annP99.Microseconds())/1000, "hnsw-p99-ms")
+	b.ReportMetric(speedup, "p99-speedup")
+	if rows >= hnswBenchmarkRows && speedup < 5 {
+		b.Errorf("HNSW p99 %s is only %.1fx faster than exact p99 %s at %d rows", annP99, speedup, exactP99, rows)
+	}
+}
+
+// p99 is the 99th percentile of latencies
+func p99(latencies []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)*99)/100]
+}
@@ -0,0 +1,156 @@
+package embedding
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+func TestEnsureHNSWIndex(t *testing.T) {
+	ctx := context.Background()
+	config := HNSWIndexConfig{Dimensions: 1536, RowThreshold: 1000}
+	estimate := regexp.QuoteMeta("SELECT reltuples::bigint FROM pg_class")
+	lookup := regexp.QuoteMeta("SELECT i.indisvalid FROM pg_index")
+	create := regexp.QuoteMeta("CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_embeddings_hnsw_1536_cosine ON mcp.embeddings USING hnsw " +
+		"((subvector(embedding, 1, 1536)::vector(1536)) vector_cosine_ops) WITH (m = 16, ef_construction = 64) " +
+		"WHERE model_dimensions = 1536")
+
+	t.Run("waits for the row threshold", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(estimate).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(1000))
+
+		created, err := EnsureHNSWIndex(ctx, db, config)
+		require.NoError(t, err)
+		assert.False(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("builds the index concurrently past the threshold", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(estimate).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(1001))
+		mock.ExpectQuery(lookup).WithArgs("idx_embeddings_hnsw_1536_cosine").WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(create).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		created, err := EnsureHNSWIndex(ctx, db, config)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keeps a valid index and rebuilds an invalid one", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() { _ = db.Close() }()
+
+		mock.ExpectQuery(estimate).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(5000))
+		mock.ExpectQuery(lookup).WillReturnRows(sqlmock.NewRows([]string{"indisvalid"}).AddRow(true))
+		created, err := EnsureHNSWIndex(ctx, db, config)
+		require.NoError(t, err)
+		assert.False(t, created)
+
+		mock.ExpectQuery(estimate).WillReturnRows(sqlmock.NewRows([]string{"reltuples"}).AddRow(5000))
+		mock.ExpectQuery(lookup).WillReturnRows(sqlmock.NewRows([]string{"indisvalid"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta("DROP INDEX CONCURRENTLY IF EXISTS mcp.idx_embeddings_hnsw_1536_cosine")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(create).WillReturnResult(sqlmock.NewResult(0, 0))
+		created, err = EnsureHNSWIndex(ctx, db, config)
+		require.NoError(t, err)
+		assert.True(t, created)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects dimensions HNSW can't index", func(t *testing.T) {
+		_, err := EnsureHNSWIndex(ctx, nil, HNSWIndexConfig{Dimensions: 4096})
+		assert.Error(t, err)
+		_, err = EnsureHNSWIndex(ctx, nil, HNSWIndexConfig{Dimensions: 1536, Metric: "manhattan"})
+		assert.Error(t, err)
+	})
+}
+
+func TestMigrateIVFFlatToHNSW(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT i.indisvalid FROM pg_index")).
+		WithArgs("idx_embeddings_hnsw_1024_euclidean").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(regexp.QuoteMeta("CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_embeddings_hnsw_1024_euclidean ON mcp.embeddings USING hnsw " +
+		"((subvector(embedding, 1, 1024)::vector(1024)) vector_l2_ops) WITH (m = 24, ef_construction = 64)")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DROP INDEX CONCURRENTLY IF EXISTS mcp.idx_embeddings_vector")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = MigrateIVFFlatToHNSW(ctx, db, "idx_embeddings_vector", HNSWIndexConfig{Dimensions: 1024, Metric: "euclidean", M: 24})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	err = MigrateIVFFlatToHNSW(ctx, db, "idx; DROP TABLE mcp.embeddings", HNSWIndexConfig{Dimensions: 1024})
+	assert.Error(t, err)
+}
+
+func TestSearchByVectorANNIndex(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	repo := repositorySearch.NewRepository(sqlx.NewDb(db, "postgres"))
+	vector := []float32{0.1, 0.2, 0.3}
+	columns := []string{"id", "content_index", "content", "metadata", "type", "similarity"}
+
+	t.Run("searches the indexed subvector with ef_search set for the transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("SET LOCAL hnsw.ef_search = 100")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE 1 - (subvector(embedding, 1, 3)::vector(3) <=> $1::vector(3)) > $2 AND model_dimensions = 3") +
+			".*" + regexp.QuoteMeta("ORDER BY subvector(embedding, 1, 3)::vector(3) <=> $1::vector(3) LIMIT 5")).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("doc-1", 0, "helm rollback", "{}", "model", 0.9))
+		mock.ExpectRollback()
+
+		results, err := repo.SearchByVector(ctx, vector, &repositorySearch.SearchOptions{
+			Limit:         5,
+			MinSimilarity: 0.5,
+			UseANNIndex:   true,
+			EfSearch:      100,
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+		assert.Equal(t, "doc-1", results.Results[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("exact searches are unchanged", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("WHERE 1 - (embedding <=> $1::vector) > $2") +
+			".*" + regexp.QuoteMeta("ORDER BY embedding <=> $1::vector LIMIT 5")).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err := repo.SearchByVector(ctx, vector, &repositorySearch.SearchOptions{Limit: 5, MinSimilarity: 0.5})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("search options carry ef_search to the repository", func(t *testing.T) {
+		service := &UnifiedSearchService{}
+		repoOptions := service.convertToRepoOptions(&SearchOptions{
+			Limit:       5,
+			UseANNIndex: true,
+			HNSW:        &HNSWSearchOptions{EfSearch: 80},
+		})
+		assert.True(t, repoOptions.UseANNIndex)
+		assert.Equal(t, 80, repoOptions.EfSearch)
+	})
+}
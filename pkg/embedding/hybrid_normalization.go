@@ -0,0 +1,93 @@
+package embedding
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScoreNormalization is how semantic and keyword scores are rescaled before
+// hybrid results are merged. The two sources score on unrelated scales (vector
+// similarity from possibly different embedding spaces, and text search rank),
+// so the hybrid weight only means what it says once both are comparable.
+type ScoreNormalization string
+
+const (
+	// ScoreNormalizationNone merges raw scores
+	ScoreNormalizationNone ScoreNormalization = "none"
+	// ScoreNormalizationMinMax rescales each source's scores to 0-1 over its results
+	ScoreNormalizationMinMax ScoreNormalization = "min_max"
+	// ScoreNormalizationZScore standardizes each source's scores over its results
+	// and maps them to 0-1 with a logistic curve, so a single outlier doesn't
+	// flatten the rest as it does with min-max
+	ScoreNormalizationZScore ScoreNormalization = "z_score"
+)
+
+// Validate checks the normalization is known. Empty means none.
+func (n ScoreNormalization) Validate() error {
+	switch n {
+	case "", ScoreNormalizationNone, ScoreNormalizationMinMax, ScoreNormalizationZScore:
+		return nil
+	}
+	return fmt.Errorf("unknown score normalization: %s", n)
+}
+
+// NormalizeScores returns scores from one source rescaled by the normalization.
+// When every score is equal min-max maps them all to 1 and z-score to 0.5.
+func NormalizeScores(scores []float32, normalization ScoreNormalization) []float32 {
+	if len(scores) == 0 {
+		return scores
+	}
+
+	switch normalization {
+	case ScoreNormalizationMinMax:
+		return minMaxNormalize(scores)
+	case ScoreNormalizationZScore:
+		return zScoreNormalize(scores)
+	default:
+		return scores
+	}
+}
+
+func minMaxNormalize(scores []float32) []float32 {
+	low, high := scores[0], scores[0]
+	for _, score := range scores[1:] {
+		low = float32(math.Min(float64(low), float64(score)))
+		high = float32(math.Max(float64(high), float64(score)))
+	}
+
+	normalized := make([]float32, len(scores))
+	for i, score := range scores {
+		if high == low {
+			normalized[i] = 1
+			continue
+		}
+		normalized[i] = (score - low) / (high - low)
+	}
+	return normalized
+}
+
+func zScoreNormalize(scores []float32) []float32 {
+	var sum float64
+	for _, score := range scores {
+		sum += float64(score)
+	}
+	mean := sum / float64(len(scores))
+
+	var variance float64
+	for _, score := range scores {
+		diff := float64(score) - mean
+		variance += diff * diff
+	}
+	stddev := math.Sqrt(variance / float64(len(scores)))
+
+	normalized := make([]float32, len(scores))
+	for i, score := range scores {
+		if stddev == 0 {
+			normalized[i] = 0.5
+			continue
+		}
+		z := (float64(score) - mean) / stddev
+		normalized[i] = float32(1 / (1 + math.Exp(-z)))
+	}
+	return normalized
+}
@@ -0,0 +1,98 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeScores(t *testing.T) {
+	scores := []float32{0.2, 0.4, 0.6}
+
+	assert.Equal(t, scores, NormalizeScores(scores, ScoreNormalizationNone))
+	assert.InDeltaSlice(t, []float32{0, 0.5, 1}, NormalizeScores(scores, ScoreNormalizationMinMax), 0.0001)
+
+	zScores := NormalizeScores(scores, ScoreNormalizationZScore)
+	assert.InDelta(t, 0.5, zScores[1], 0.0001)
+	assert.InDelta(t, 1, zScores[0]+zScores[2], 0.0001)
+	assert.Less(t, zScores[0], zScores[1])
+	assert.Less(t, zScores[1], zScores[2])
+
+	// Equal scores can't be spread
+	assert.Equal(t, []float32{1, 1}, NormalizeScores([]float32{0.3, 0.3}, ScoreNormalizationMinMax))
+	assert.Equal(t, []float32{0.5, 0.5}, NormalizeScores([]float32{0.3, 0.3}, ScoreNormalizationZScore))
+	assert.Empty(t, NormalizeScores(nil, ScoreNormalizationMinMax))
+}
+
+func TestScoreNormalizationValidate(t *testing.T) {
+	assert.NoError(t, ScoreNormalization("").Validate())
+	assert.NoError(t, ScoreNormalizationZScore.Validate())
+	assert.Error(t, ScoreNormalization("rank").Validate())
+}
+
+// mergeOrder merges hybrid results with the normalization and returns their
+// IDs' names in merged order
+func mergeOrder(normalization ScoreNormalization, semantic, keyword map[string]float32, names map[string]uuid.UUID) []string {
+	service := &UnifiedSearchService{hybridScores: normalization}
+
+	var semanticResults, keywordResults []HybridSearchResult
+	for name, score := range semantic {
+		r := HybridSearchResult{SemanticScore: score}
+		r.ID = names[name]
+		semanticResults = append(semanticResults, r)
+	}
+	for name, score := range keyword {
+		r := HybridSearchResult{KeywordScore: score}
+		r.ID = names[name]
+		keywordResults = append(keywordResults, r)
+	}
+
+	order := make([]string, 0, len(names))
+	for _, result := range service.mergeHybridResults(semanticResults, keywordResults, 0.6) {
+		for name, id := range names {
+			if id == result.ID {
+				order = append(order, name)
+			}
+		}
+	}
+	return order
+}
+
+func TestMergeHybridResultsNormalization(t *testing.T) {
+	names := map[string]uuid.UUID{"a": uuid.New(), "b": uuid.New(), "c": uuid.New(), "d": uuid.New()}
+
+	// Semantic similarities bunch near the top of their range while keyword
+	// ranks are small, so raw keyword scores barely move the merged order
+	semantic := map[string]float32{"a": 0.90, "b": 0.86, "c": 0.82}
+	keyword := map[string]float32{"b": 0.05, "c": 0.15, "d": 0.25}
+
+	t.Run("raw scores bury the best keyword match", func(t *testing.T) {
+		assert.Equal(t, []string{"c", "a", "b", "d"}, mergeOrder(ScoreNormalizationNone, semantic, keyword, names))
+	})
+
+	t.Run("min-max ranks each source's best match highly", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "d", "b", "c"}, mergeOrder(ScoreNormalizationMinMax, semantic, keyword, names))
+	})
+
+	t.Run("z-score keeps the best semantic match first", func(t *testing.T) {
+		order := mergeOrder(ScoreNormalizationZScore, semantic, keyword, names)
+		require.Len(t, order, 4)
+		assert.Equal(t, "a", order[0])
+	})
+
+	t.Run("raw scores are kept on results", func(t *testing.T) {
+		service := &UnifiedSearchService{hybridScores: ScoreNormalizationMinMax}
+		semanticResult := HybridSearchResult{SemanticScore: 0.9}
+		semanticResult.ID = names["a"]
+		keywordResult := HybridSearchResult{KeywordScore: 0.2}
+		keywordResult.ID = names["a"]
+
+		merged := service.mergeHybridResults([]HybridSearchResult{semanticResult}, []HybridSearchResult{keywordResult}, 0.6)
+		require.Len(t, merged, 1)
+		assert.Equal(t, float32(0.9), merged[0].SemanticScore)
+		assert.Equal(t, float32(0.2), merged[0].KeywordScore)
+		assert.InDelta(t, 1, merged[0].HybridScore, 0.0001)
+	})
+}
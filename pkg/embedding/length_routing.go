@@ -0,0 +1,103 @@
+package embedding
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// LengthRoute selects the embedding model for content up to a length
+type LengthRoute struct {
+	// MaxChars is the longest content the route takes, in characters; 0 takes
+	// content of any length not taken by a shorter route
+	MaxChars int `json:"max_chars" mapstructure:"max_chars"`
+	// Model embeds the content, e.g. "openai:text-embedding-3-small"
+	Model string `json:"model" mapstructure:"model"`
+}
+
+// LengthRoutingConfig selects the embedding model by content length, e.g. a
+// cheaper model for short snippets and a long-context one for documents.
+// Requests that pin a model aren't routed.
+type LengthRoutingConfig struct {
+	// Routes apply to tenants without their own routes
+	Routes []LengthRoute `json:"routes" mapstructure:"routes"`
+	// TenantRoutes replace Routes for the given tenants
+	TenantRoutes map[uuid.UUID][]LengthRoute `json:"tenant_routes" mapstructure:"tenant_routes"`
+}
+
+// Enabled reports whether any content is routed
+func (c LengthRoutingConfig) Enabled() bool {
+	return len(c.Routes) > 0 || len(c.TenantRoutes) > 0
+}
+
+// Validate checks the routes
+func (c LengthRoutingConfig) Validate() error {
+	if err := validateLengthRoutes(c.Routes); err != nil {
+		return err
+	}
+	for tenantID, routes := range c.TenantRoutes {
+		if err := validateLengthRoutes(routes); err != nil {
+			return fmt.Errorf("tenant %s: %w", tenantID, err)
+		}
+	}
+	return nil
+}
+
+func validateLengthRoutes(routes []LengthRoute) error {
+	seen := make(map[int]bool, len(routes))
+	for _, route := range routes {
+		if route.Model == "" {
+			return fmt.Errorf("length route model is required")
+		}
+		if route.MaxChars < 0 {
+			return fmt.Errorf("length route max chars must not be negative, got %d", route.MaxChars)
+		}
+		if seen[route.MaxChars] {
+			return fmt.Errorf("duplicate length route for max chars %d", route.MaxChars)
+		}
+		seen[route.MaxChars] = true
+	}
+	return nil
+}
+
+// sorted returns a copy of the config with each route list ordered from the
+// shortest content to the unbounded route
+func (c LengthRoutingConfig) sorted() LengthRoutingConfig {
+	sorted := LengthRoutingConfig{
+		Routes:       sortLengthRoutes(c.Routes),
+		TenantRoutes: make(map[uuid.UUID][]LengthRoute, len(c.TenantRoutes)),
+	}
+	for tenantID, routes := range c.TenantRoutes {
+		sorted.TenantRoutes[tenantID] = sortLengthRoutes(routes)
+	}
+	return sorted
+}
+
+func sortLengthRoutes(routes []LengthRoute) []LengthRoute {
+	sorted := append([]LengthRoute(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].MaxChars == 0 || sorted[j].MaxChars == 0 {
+			return sorted[j].MaxChars == 0 && sorted[i].MaxChars != 0
+		}
+		return sorted[i].MaxChars < sorted[j].MaxChars
+	})
+	return sorted
+}
+
+// Select returns the route for a tenant's content. Routes must be sorted.
+func (c LengthRoutingConfig) Select(tenantID uuid.UUID, text string) (LengthRoute, bool) {
+	routes, ok := c.TenantRoutes[tenantID]
+	if !ok {
+		routes = c.Routes
+	}
+
+	length := utf8.RuneCountInString(text)
+	for _, route := range routes {
+		if route.MaxChars == 0 || length <= route.MaxChars {
+			return route, true
+		}
+	}
+	return LengthRoute{}, false
+}
@@ -0,0 +1,129 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+func TestLengthRoutingSelect(t *testing.T) {
+	tenantID := uuid.New()
+	config := LengthRoutingConfig{
+		Routes: []LengthRoute{
+			{MaxChars: 0, Model: "openai:long"},
+			{MaxChars: 1000, Model: "openai:medium"},
+			{MaxChars: 100, Model: "openai:short"},
+		},
+		TenantRoutes: map[uuid.UUID][]LengthRoute{
+			tenantID: {{MaxChars: 10, Model: "openai:tenant-short"}},
+		},
+	}.sorted()
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		length   int
+		model    string
+	}{
+		{"short content", uuid.New(), 100, "openai:short"},
+		{"medium content", uuid.New(), 101, "openai:medium"},
+		{"long content", uuid.New(), 5000, "openai:long"},
+		{"tenant routes replace the defaults", tenantID, 10, "openai:tenant-short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, ok := config.Select(tt.tenantID, strings.Repeat("a", tt.length))
+			require.True(t, ok)
+			assert.Equal(t, tt.model, route.Model)
+		})
+	}
+
+	// A tenant's content longer than all of its routes isn't routed
+	_, ok := config.Select(tenantID, strings.Repeat("a", 11))
+	assert.False(t, ok)
+
+	// Length counts characters rather than bytes
+	route, ok := config.Select(uuid.New(), strings.Repeat("é", 100))
+	require.True(t, ok)
+	assert.Equal(t, "openai:short", route.Model)
+}
+
+func TestLengthRoutingConfigValidate(t *testing.T) {
+	assert.NoError(t, LengthRoutingConfig{}.Validate())
+	assert.Error(t, LengthRoutingConfig{Routes: []LengthRoute{{MaxChars: 100}}}.Validate())
+	assert.Error(t, LengthRoutingConfig{Routes: []LengthRoute{{MaxChars: -1, Model: "openai:short"}}}.Validate())
+	assert.Error(t, LengthRoutingConfig{Routes: []LengthRoute{
+		{Model: "openai:long"},
+		{Model: "openai:longer"},
+	}}.Validate())
+	assert.Error(t, LengthRoutingConfig{TenantRoutes: map[uuid.UUID][]LengthRoute{
+		uuid.New(): {{MaxChars: 100}},
+	}}.Validate())
+}
+
+func TestGenerateEmbeddingRoutesByLength(t *testing.T) {
+	ctx := context.Background()
+	tenantID := uuid.New()
+
+	tests := []struct {
+		name  string
+		text  string
+		model string
+	}{
+		{name: "short content uses the small model", text: "helm rollback", model: "mock-model-small"},
+		{name: "long content uses the large model", text: strings.Repeat("Roll back the release with helm. ", 10), model: "mock-model-large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, dbMock, err := sqlmock.New()
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = db.Close() })
+
+			agentService := &MockAgentService{}
+			agentService.On("GetConfig", mock.Anything, "test-agent").Return(nil, errors.New("not found"))
+
+			service, err := NewServiceV2(ServiceV2Config{
+				Providers:     map[string]providers.Provider{"openai": providers.NewMockProvider("openai")},
+				AgentService:  agentService,
+				Repository:    NewRepository(db),
+				FallbackChain: []string{"openai:mock-model-small"},
+				LengthRouting: LengthRoutingConfig{Routes: []LengthRoute{
+					{MaxChars: 100, Model: "openai:mock-model-small"},
+					{Model: "openai:mock-model-large"},
+				}},
+			})
+			require.NoError(t, err)
+
+			// The routed model is used for the duplicate check, generation and storage
+			dbMock.ExpectQuery(`WHERE e.content_hash = \$1`).
+				WithArgs(CalculateContentHash(tt.text), tt.model, tenantID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			dbMock.ExpectQuery(`SELECT mcp.insert_embedding`).
+				WithArgs(
+					sqlmock.AnyArg(), tt.text, sqlmock.AnyArg(), tt.model, sqlmock.AnyArg(),
+					metadataArg{"embedding_model": tt.model, "length_routed_model": "openai:" + tt.model},
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+			resp, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+				AgentID:  "test-agent",
+				Text:     tt.text,
+				TenantID: tenantID,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.model, resp.ModelUsed)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/rerank"
+)
+
+// DefaultMMRLambda weighs relevance and diversity equally
+const DefaultMMRLambda = 0.5
+
+// MMRCandidateMultiplier is how many more results than requested a search
+// retrieves for MMR reranking to choose from
+const MMRCandidateMultiplier = 3
+
+// ErrInvalidRerankOptions is returned for a search with an unknown rerank
+// mode or an MMR lambda outside 0-1
+var ErrInvalidRerankOptions = errors.New("invalid rerank options")
+
+// MMRReranker reorders results by Maximal Marginal Relevance, so a handful of
+// near-duplicates don't crowd out everything else. It repeatedly picks the
+// result maximising λ·relevance − (1−λ)·similarity to the closest result
+// already picked, where relevance is the result's search score.
+//
+// Unlike rerank.MMRReranker it makes no embedding calls. Results are compared
+// by the []float32 "embedding" in their metadata when every result has one,
+// and otherwise by the terms of their content.
+type MMRReranker struct {
+	lambda float64
+}
+
+// NewMMRReranker creates an MMR reranker with the given default lambda,
+// using DefaultMMRLambda when it isn't between 0 and 1
+func NewMMRReranker(lambda float64) *MMRReranker {
+	if lambda < 0 || lambda > 1 {
+		lambda = DefaultMMRLambda
+	}
+	return &MMRReranker{lambda: lambda}
+}
+
+// Rerank implements rerank.Reranker. It selects opts.TopK results, or all of
+// them, in O(k·n) similarity comparisons.
+func (m *MMRReranker) Rerank(_ context.Context, _ string, results []rerank.SearchResult, opts *rerank.RerankOptions) ([]rerank.SearchResult, error) {
+	lambda := m.lambda
+	k := len(results)
+	if opts != nil {
+		if opts.MMRLambda != nil {
+			if *opts.MMRLambda < 0 || *opts.MMRLambda > 1 {
+				return nil, fmt.Errorf("%w: MMR lambda must be between 0 and 1, got %v", ErrInvalidRerankOptions, *opts.MMRLambda)
+			}
+			lambda = *opts.MMRLambda
+		}
+		if opts.TopK > 0 && opts.TopK < k {
+			k = opts.TopK
+		}
+	}
+	if len(results) <= 1 {
+		return results, nil
+	}
+
+	similarity := mmrSimilarity(results)
+
+	// maxSimilarity[i] is result i's similarity to the closest selected result,
+	// updated as each result is selected so no pair is compared twice
+	maxSimilarity := make([]float64, len(results))
+	selected := make([]bool, len(results))
+	reranked := make([]rerank.SearchResult, 0, k)
+
+	for len(reranked) < k {
+		best, bestScore := -1, math.Inf(-1)
+		for i, result := range results {
+			if selected[i] {
+				continue
+			}
+			score := lambda*float64(result.Score) - (1-lambda)*maxSimilarity[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		selected[best] = true
+		result := results[best]
+		metadata := make(map[string]interface{}, len(result.Metadata)+1)
+		for key, value := range result.Metadata {
+			metadata[key] = value
+		}
+		metadata["mmr_score"] = bestScore
+		result.Metadata = metadata
+		reranked = append(reranked, result)
+
+		for i := range results {
+			if !selected[i] {
+				maxSimilarity[i] = math.Max(maxSimilarity[i], similarity(i, best))
+			}
+		}
+	}
+
+	return reranked, nil
+}
+
+// GetName implements rerank.Reranker
+func (m *MMRReranker) GetName() string {
+	return fmt.Sprintf("mmr_lambda%.2f", m.lambda)
+}
+
+// Close implements rerank.Reranker
+func (m *MMRReranker) Close() error {
+	return nil
+}
+
+// mmrSimilarity returns the cosine similarity of two results, comparing
+// their embeddings when all of them carry one and their content otherwise.
+// Each result is normalized once, up front.
+func mmrSimilarity(results []rerank.SearchResult) func(i, j int) float64 {
+	vectors := make([][]float64, len(results))
+	for i, result := range results {
+		embedding, ok := result.Metadata["embedding"].([]float32)
+		if !ok || len(embedding) == 0 || (i > 0 && len(embedding) != len(vectors[0])) {
+			vectors = nil
+			break
+		}
+		vectors[i] = unitVector(embedding)
+	}
+	if vectors != nil {
+		return func(i, j int) float64 {
+			var dot float64
+			for d := range vectors[i] {
+				dot += vectors[i][d] * vectors[j][d]
+			}
+			return dot
+		}
+	}
+
+	terms := make([]map[string]float64, len(results))
+	for i, result := range results {
+		terms[i] = termVector(result.Content)
+	}
+	return func(i, j int) float64 {
+		a, b := terms[i], terms[j]
+		if len(b) < len(a) {
+			a, b = b, a
+		}
+		var dot float64
+		for term, weight := range a {
+			dot += weight * b[term]
+		}
+		return dot
+	}
+}
+
+// unitVector scales an embedding to unit length
+func unitVector(embedding []float32) []float64 {
+	var norm float64
+	for _, v := range embedding {
+		norm += float64(v) * float64(v)
+	}
+	norm = math.Sqrt(norm)
+
+	vector := make([]float64, len(embedding))
+	if norm == 0 {
+		return vector
+	}
+	for i, v := range embedding {
+		vector[i] = float64(v) / norm
+	}
+	return vector
+}
+
+// termVector is the unit-length term frequency vector of a text
+func termVector(text string) map[string]float64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	vector := make(map[string]float64, len(words))
+	for _, word := range words {
+		vector[word]++
+	}
+	var norm float64
+	for _, count := range vector {
+		norm += count * count
+	}
+	norm = math.Sqrt(norm)
+	for word := range vector {
+		vector[word] /= norm
+	}
+	return vector
+}
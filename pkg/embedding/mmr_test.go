@@ -0,0 +1,153 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/rerank"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+func TestMMRReranker(t *testing.T) {
+	ctx := context.Background()
+	result := func(id string, score float32, embedding ...float32) rerank.SearchResult {
+		return rerank.SearchResult{ID: id, Score: score, Metadata: map[string]interface{}{"embedding": embedding}}
+	}
+	// Three near-duplicates outscore two distinct results
+	results := []rerank.SearchResult{
+		result("dup-1", 0.95, 1, 0, 0),
+		result("dup-2", 0.94, 0.99, 0.01, 0),
+		result("dup-3", 0.93, 0.98, 0.02, 0),
+		result("other-1", 0.80, 0, 1, 0),
+		result("other-2", 0.70, 0, 0, 1),
+	}
+	similarity := mmrSimilarity(results)
+	index := make(map[string]int, len(results))
+	for i, r := range results {
+		index[r.ID] = i
+	}
+
+	// diversity is the mean pairwise dissimilarity of the selected results
+	diversity := func(selected []rerank.SearchResult) float64 {
+		var total float64
+		var pairs int
+		for i := range selected {
+			for j := i + 1; j < len(selected); j++ {
+				total += 1 - similarity(index[selected[i].ID], index[selected[j].ID])
+				pairs++
+			}
+		}
+		return total / float64(pairs)
+	}
+
+	// Ordered from most to least weight on relevance
+	tests := []struct {
+		lambda   float64
+		expected []string
+	}{
+		{lambda: 1, expected: []string{"dup-1", "dup-2", "dup-3"}},
+		{lambda: 0.85, expected: []string{"dup-1", "other-1", "dup-2"}},
+		{lambda: 0.5, expected: []string{"dup-1", "other-1", "other-2"}},
+		{lambda: 0, expected: []string{"dup-1", "other-1", "other-2"}},
+	}
+
+	reranker := NewMMRReranker(DefaultMMRLambda)
+	previous := -1.0
+	for _, tt := range tests {
+		lambda := tt.lambda
+		reranked, err := reranker.Rerank(ctx, "", results, &rerank.RerankOptions{TopK: 3, MMRLambda: &lambda})
+		require.NoError(t, err)
+
+		ids := make([]string, 0, len(reranked))
+		for _, r := range reranked {
+			ids = append(ids, r.ID)
+		}
+		assert.Equal(t, tt.expected, ids, "lambda %v", lambda)
+
+		d := diversity(reranked)
+		assert.GreaterOrEqual(t, d, previous, "diversity shouldn't drop as lambda decreases to %v", lambda)
+		previous = d
+	}
+	assert.Greater(t, previous, 0.9, "the most diverse selection has no near-duplicates")
+
+	t.Run("compares content when results have no embeddings", func(t *testing.T) {
+		textResults := []rerank.SearchResult{
+			{ID: "retry-1", Score: 0.9, Content: "Retry failed requests with exponential backoff"},
+			{ID: "retry-2", Score: 0.89, Content: "retry failed requests with exponential backoff."},
+			{ID: "timeouts", Score: 0.7, Content: "Configure client timeouts per request"},
+		}
+		lambda := 0.5
+		reranked, err := reranker.Rerank(ctx, "", textResults, &rerank.RerankOptions{TopK: 2, MMRLambda: &lambda})
+		require.NoError(t, err)
+		require.Len(t, reranked, 2)
+		assert.Equal(t, "retry-1", reranked[0].ID)
+		assert.Equal(t, "timeouts", reranked[1].ID)
+	})
+
+	t.Run("leaves the input untouched", func(t *testing.T) {
+		reranked, err := reranker.Rerank(ctx, "", results, nil)
+		require.NoError(t, err)
+		assert.Len(t, reranked, len(results))
+		assert.Contains(t, reranked[0].Metadata, "mmr_score")
+		assert.NotContains(t, results[0].Metadata, "mmr_score")
+	})
+
+	t.Run("rejects lambdas outside 0-1", func(t *testing.T) {
+		lambda := 1.5
+		_, err := reranker.Rerank(ctx, "", results, &rerank.RerankOptions{MMRLambda: &lambda})
+		assert.ErrorIs(t, err, ErrInvalidRerankOptions)
+	})
+}
+
+func TestSearchMMRMode(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	repository := &pagingSearchRepository{results: []*repositorySearch.SearchResult{
+		{ID: "doc-0", Score: 0.95, Content: "Rolling back a helm release"},
+		{ID: "doc-1", Score: 0.94, Content: "rolling back a Helm release"},
+		{ID: "doc-2", Score: 0.93, Content: "Rolling back a helm release!"},
+		{ID: "doc-3", Score: 0.80, Content: "Pinning chart versions in CI"},
+		{ID: "doc-4", Score: 0.70, Content: "Diffing manifests before an upgrade"},
+		{ID: "doc-5", Score: 0.60, Content: "Helm hooks for database migrations"},
+	}}
+	service, err := NewUnifiedSearchService(&UnifiedSearchConfig{
+		DB:               db,
+		SearchRepository: repository,
+		EmbeddingService: &countingEmbeddingService{},
+		Logger:           observability.NewNoopLogger(),
+		Metrics:          observability.NewNoOpMetricsClient(),
+	})
+	require.NoError(t, err)
+
+	t.Run("picks the page from a wider pool without a configured reranker", func(t *testing.T) {
+		results, err := service.Search(ctx, "helm rollback", &SearchOptions{
+			Limit:        2,
+			UseReranking: true,
+			RerankMode:   rerank.ModeMMR,
+		})
+		require.NoError(t, err)
+		require.Len(t, results.Results, 2)
+		assert.Equal(t, "doc-0", results.Results[0].Content.ContentID)
+		assert.Equal(t, "doc-3", results.Results[1].Content.ContentID)
+	})
+
+	t.Run("rejects unknown modes and lambdas", func(t *testing.T) {
+		_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2, UseReranking: true, RerankMode: "listwise"})
+		assert.ErrorIs(t, err, ErrInvalidRerankOptions)
+
+		lambda := -0.1
+		_, err = service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2, UseReranking: true, RerankMode: rerank.ModeMMR, MMRLambda: &lambda})
+		assert.ErrorIs(t, err, ErrInvalidRerankOptions)
+	})
+}
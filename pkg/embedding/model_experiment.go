@@ -0,0 +1,202 @@
+package embedding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Experiment arms
+const (
+	ExperimentArmControl   = "control"
+	ExperimentArmCandidate = "candidate"
+)
+
+// Experiment operations metrics are collected for
+const (
+	ExperimentOperationIndex  = "index"
+	ExperimentOperationSearch = "search"
+)
+
+// ModelExperimentConfig configures an A/B test of a candidate embedding model
+// against the current one, without migrating to it
+type ModelExperimentConfig struct {
+	// Name identifies the experiment in result tags
+	Name string `json:"name" mapstructure:"name"`
+	// CandidateModel is the model under evaluation, e.g. "openai:text-embedding-3-large"
+	CandidateModel string `json:"candidate_model" mapstructure:"candidate_model"`
+	// TrafficFraction of index and search traffic that uses the candidate, from 0 to 1
+	TrafficFraction float64 `json:"traffic_fraction" mapstructure:"traffic_fraction"`
+}
+
+// Enabled reports whether any traffic is sent to the candidate
+func (c ModelExperimentConfig) Enabled() bool {
+	return c.CandidateModel != "" && c.TrafficFraction > 0
+}
+
+// Validate checks the experiment configuration
+func (c ModelExperimentConfig) Validate() error {
+	if c.TrafficFraction < 0 || c.TrafficFraction > 1 {
+		return fmt.Errorf("traffic fraction must be between 0 and 1, got %v", c.TrafficFraction)
+	}
+	if c.TrafficFraction > 0 && c.CandidateModel == "" {
+		return fmt.Errorf("candidate model is required")
+	}
+	return nil
+}
+
+// ExperimentArmStats summarizes one arm's traffic for an operation
+type ExperimentArmStats struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ErrorRate    float64 `json:"error_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	AvgCostUSD   float64 `json:"avg_cost_usd"`
+	// AvgResults and AvgTopScore compare search quality
+	AvgResults  float64 `json:"avg_results"`
+	AvgTopScore float64 `json:"avg_top_score"`
+}
+
+// ExperimentOutcome is the outcome of one request in an experiment arm
+type ExperimentOutcome struct {
+	Latency     time.Duration
+	CostUSD     float64
+	ResultCount int
+	TopScore    float64
+	Err         error
+}
+
+type experimentArmTotals struct {
+	requests    int64
+	errors      int64
+	latency     time.Duration
+	costUSD     float64
+	results     int64
+	topScoreSum float64
+}
+
+// ModelExperiment assigns index and search traffic to the control or
+// candidate arm and collects comparative metrics. Share one experiment
+// between the embedding and search services so their metrics are combined.
+type ModelExperiment struct {
+	config ModelExperimentConfig
+	mu     sync.Mutex
+	totals map[string]map[string]*experimentArmTotals // operation -> arm
+}
+
+// NewModelExperiment creates an experiment from its configuration
+func NewModelExperiment(config ModelExperimentConfig) (*ModelExperiment, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid model experiment config: %w", err)
+	}
+	if config.Name == "" {
+		config.Name = "embedding-model"
+	}
+	return &ModelExperiment{
+		config: config,
+		totals: make(map[string]map[string]*experimentArmTotals),
+	}, nil
+}
+
+// Name returns the experiment's name
+func (e *ModelExperiment) Name() string {
+	return e.config.Name
+}
+
+// CandidateModel returns the model under evaluation
+func (e *ModelExperiment) CandidateModel() string {
+	return e.config.CandidateModel
+}
+
+// candidateModelName returns the candidate model without its provider, as
+// stored embeddings record it
+func (e *ModelExperiment) candidateModelName() string {
+	if _, model, found := strings.Cut(e.config.CandidateModel, ":"); found {
+		return model
+	}
+	return e.config.CandidateModel
+}
+
+// Assign returns the arm for a unit of traffic. Assignment hashes the key
+// rather than drawing at random, so the same content or query always lands in
+// the same arm and re-indexing doesn't move content between models.
+func (e *ModelExperiment) Assign(key string) string {
+	if e == nil || !e.config.Enabled() {
+		return ExperimentArmControl
+	}
+
+	sum := sha256.Sum256([]byte(e.config.Name + "\x00" + key))
+	if float64(binary.BigEndian.Uint64(sum[:8])>>11)/float64(1<<53) < e.config.TrafficFraction {
+		return ExperimentArmCandidate
+	}
+	return ExperimentArmControl
+}
+
+// Record adds a request's outcome to its arm's metrics
+func (e *ModelExperiment) Record(operation, arm string, outcome ExperimentOutcome) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	arms, ok := e.totals[operation]
+	if !ok {
+		arms = make(map[string]*experimentArmTotals)
+		e.totals[operation] = arms
+	}
+	totals, ok := arms[arm]
+	if !ok {
+		totals = &experimentArmTotals{}
+		arms[arm] = totals
+	}
+
+	totals.requests++
+	totals.latency += outcome.Latency
+	if outcome.Err != nil {
+		totals.errors++
+		return
+	}
+	totals.costUSD += outcome.CostUSD
+	totals.results += int64(outcome.ResultCount)
+	totals.topScoreSum += outcome.TopScore
+}
+
+// Stats returns each arm's metrics by operation
+func (e *ModelExperiment) Stats() map[string]map[string]ExperimentArmStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stats := make(map[string]map[string]ExperimentArmStats, len(e.totals))
+	for operation, arms := range e.totals {
+		stats[operation] = make(map[string]ExperimentArmStats, len(arms))
+		for arm, totals := range arms {
+			armStats := ExperimentArmStats{
+				Requests:     totals.requests,
+				Errors:       totals.errors,
+				ErrorRate:    float64(totals.errors) / float64(totals.requests),
+				AvgLatencyMs: float64(totals.latency.Milliseconds()) / float64(totals.requests),
+			}
+			if succeeded := totals.requests - totals.errors; succeeded > 0 {
+				armStats.AvgCostUSD = totals.costUSD / float64(succeeded)
+				armStats.AvgResults = float64(totals.results) / float64(succeeded)
+				armStats.AvgTopScore = totals.topScoreSum / float64(succeeded)
+			}
+			stats[operation][arm] = armStats
+		}
+	}
+	return stats
+}
+
+// experimentTags returns the metadata results of an arm are tagged with
+func (e *ModelExperiment) experimentTags(arm, model string) map[string]interface{} {
+	return map[string]interface{}{
+		"experiment":       e.config.Name,
+		"experiment_arm":   arm,
+		"experiment_model": model,
+	}
+}
@@ -0,0 +1,239 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+func TestModelExperimentTrafficSplit(t *testing.T) {
+	for _, fraction := range []float64{0, 0.05, 0.25, 0.5, 1} {
+		t.Run(fmt.Sprintf("%.2f", fraction), func(t *testing.T) {
+			experiment, err := NewModelExperiment(ModelExperimentConfig{
+				CandidateModel:  "openai:text-embedding-3-large",
+				TrafficFraction: fraction,
+			})
+			require.NoError(t, err)
+
+			const total = 20000
+			candidates := 0
+			for i := 0; i < total; i++ {
+				key := fmt.Sprintf("tenant:%d", i)
+				arm := experiment.Assign(key)
+				if arm == ExperimentArmCandidate {
+					candidates++
+				}
+				// The same traffic always lands in the same arm
+				assert.Equal(t, arm, experiment.Assign(key))
+			}
+			assert.InDelta(t, fraction, float64(candidates)/total, 0.01)
+		})
+	}
+
+	t.Run("invalid configurations are rejected", func(t *testing.T) {
+		_, err := NewModelExperiment(ModelExperimentConfig{CandidateModel: "openai:text-embedding-3-large", TrafficFraction: 1.5})
+		assert.Error(t, err)
+		_, err = NewModelExperiment(ModelExperimentConfig{TrafficFraction: 0.1})
+		assert.Error(t, err)
+	})
+}
+
+func TestModelExperimentStats(t *testing.T) {
+	experiment, err := NewModelExperiment(ModelExperimentConfig{CandidateModel: "openai:text-embedding-3-large", TrafficFraction: 0.1})
+	require.NoError(t, err)
+
+	experiment.Record(ExperimentOperationSearch, ExperimentArmControl, ExperimentOutcome{Latency: 10 * time.Millisecond, ResultCount: 4, TopScore: 0.8})
+	experiment.Record(ExperimentOperationSearch, ExperimentArmControl, ExperimentOutcome{Latency: 30 * time.Millisecond, ResultCount: 2, TopScore: 0.6})
+	experiment.Record(ExperimentOperationSearch, ExperimentArmCandidate, ExperimentOutcome{Latency: 50 * time.Millisecond, ResultCount: 5, TopScore: 0.9})
+	experiment.Record(ExperimentOperationSearch, ExperimentArmCandidate, ExperimentOutcome{Latency: 70 * time.Millisecond, Err: errors.New("timeout")})
+
+	stats := experiment.Stats()[ExperimentOperationSearch]
+	control, candidate := stats[ExperimentArmControl], stats[ExperimentArmCandidate]
+
+	assert.Equal(t, int64(2), control.Requests)
+	assert.Equal(t, float64(20), control.AvgLatencyMs)
+	assert.Equal(t, float64(3), control.AvgResults)
+	assert.InDelta(t, 0.7, control.AvgTopScore, 1e-9)
+	assert.Zero(t, control.ErrorRate)
+
+	assert.Equal(t, int64(2), candidate.Requests)
+	assert.Equal(t, int64(1), candidate.Errors)
+	assert.Equal(t, 0.5, candidate.ErrorRate)
+	assert.Equal(t, float64(60), candidate.AvgLatencyMs)
+	// Quality is averaged over successful requests
+	assert.Equal(t, float64(5), candidate.AvgResults)
+	assert.InDelta(t, 0.9, candidate.AvgTopScore, 1e-9)
+}
+
+func newExperimentTestService(t *testing.T, experiment *ModelExperiment, inserts int) (*ServiceV2, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mockDB.MatchExpectationsInOrder(false)
+
+	for i := 0; i < inserts; i++ {
+		mockDB.ExpectQuery("SELECT mcp.insert_embedding").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	}
+
+	mockAgentService := &MockAgentService{}
+	mockAgentService.On("GetConfig", mock.Anything, "test-agent").Return(nil, errors.New("not found"))
+
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers: map[string]providers.Provider{
+			"openai": providers.NewMockProvider("openai"),
+		},
+		AgentService:  mockAgentService,
+		Repository:    NewRepository(db),
+		FallbackChain: []string{"openai:mock-model-small"},
+		Experiment:    experiment,
+	})
+	require.NoError(t, err)
+	return service, mockDB
+}
+
+func TestGenerateEmbeddingTagsExperimentArm(t *testing.T) {
+	ctx := context.Background()
+	experiment, err := NewModelExperiment(ModelExperimentConfig{
+		Name:            "large-model",
+		CandidateModel:  "openai:mock-model-large",
+		TrafficFraction: 0.5,
+	})
+	require.NoError(t, err)
+
+	const total = 30
+	service, mockDB := newExperimentTestService(t, experiment, total+1)
+	tenantID := uuid.New()
+
+	arms := map[string]int{}
+	for i := 0; i < total; i++ {
+		text := fmt.Sprintf("document %d", i)
+		resp, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+			AgentID:  "test-agent",
+			Text:     text,
+			TenantID: tenantID,
+		})
+		require.NoError(t, err)
+
+		expectedArm := experiment.Assign(tenantID.String() + ":" + calculateContentHash(text))
+		expectedModel := "mock-model-small"
+		if expectedArm == ExperimentArmCandidate {
+			expectedModel = "mock-model-large"
+		}
+
+		assert.Equal(t, "large-model", resp.Metadata["experiment"])
+		assert.Equal(t, expectedArm, resp.Metadata["experiment_arm"])
+		assert.Equal(t, "openai:"+expectedModel, resp.Metadata["experiment_model"])
+		assert.Equal(t, expectedModel, resp.ModelUsed)
+		arms[expectedArm]++
+	}
+
+	// Both arms received traffic and their metrics were collected
+	stats := experiment.Stats()[ExperimentOperationIndex]
+	assert.Positive(t, arms[ExperimentArmCandidate])
+	assert.Positive(t, arms[ExperimentArmControl])
+	assert.Equal(t, int64(arms[ExperimentArmCandidate]), stats[ExperimentArmCandidate].Requests)
+	assert.Equal(t, int64(arms[ExperimentArmControl]), stats[ExperimentArmControl].Requests)
+	assert.Zero(t, stats[ExperimentArmCandidate].ErrorRate)
+	assert.Positive(t, stats[ExperimentArmCandidate].AvgCostUSD)
+
+	// Requests that pin a model aren't part of the experiment
+	resp, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+		AgentID:  "test-agent",
+		Text:     "pinned",
+		Model:    "openai:mock-model-small",
+		TenantID: tenantID,
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, resp.Metadata, "experiment_arm")
+	require.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestGenerateEmbeddingExperimentCandidateFallback(t *testing.T) {
+	experiment, err := NewModelExperiment(ModelExperimentConfig{
+		CandidateModel:  "voyage:voyage-3",
+		TrafficFraction: 1,
+	})
+	require.NoError(t, err)
+	service, _ := newExperimentTestService(t, experiment, 1)
+
+	resp, err := service.GenerateEmbedding(context.Background(), GenerateEmbeddingRequest{
+		AgentID:  "test-agent",
+		Text:     "unavailable candidate",
+		TenantID: uuid.New(),
+	})
+	require.NoError(t, err)
+
+	// The selected model served the request, and it's tagged accordingly
+	assert.Equal(t, ExperimentArmControl, resp.Metadata["experiment_arm"])
+	assert.Equal(t, true, resp.Metadata["experiment_fallback"])
+	assert.Equal(t, "mock-model-small", resp.ModelUsed)
+
+	stats := experiment.Stats()[ExperimentOperationIndex]
+	assert.Equal(t, int64(1), stats[ExperimentArmCandidate].Errors)
+	assert.NotContains(t, stats, ExperimentArmControl)
+}
+
+func TestCrossModelSearchExperimentArms(t *testing.T) {
+	service, dbMock := newReindexTestService(t)
+	experiment, err := NewModelExperiment(ModelExperimentConfig{
+		CandidateModel:  "openai:text-embedding-3-large",
+		TrafficFraction: 0.5,
+	})
+	require.NoError(t, err)
+	service.experiment = experiment
+	service.candidateService = &MockEmbeddingServiceForTests{MockVectors: map[string]*EmbeddingVector{}}
+	tenantID := uuid.New()
+
+	// Find a query assigned to each arm
+	queries := map[string]string{}
+	for i := 0; len(queries) < 2; i++ {
+		query := fmt.Sprintf("query %d", i)
+		queries[experiment.Assign(tenantID.String()+":"+calculateContentHash(query))] = query
+	}
+
+	columns := []string{"id", "context_id", "content", "original_model", "original_dimension", "embedding", "similarity", "agent_id", "metadata", "created_at"}
+
+	// Candidate queries only search content the candidate model embedded
+	dbMock.ExpectQuery(`e\.model_name = ANY`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), nil, "match", "text-embedding-3-large", 3, "{0.1,0.2,0.3}", 0.9, "", []byte(`{}`), time.Now()))
+	results, err := service.CrossModelSearch(context.Background(), CrossModelSearchRequest{
+		Query:    queries[ExperimentArmCandidate],
+		TenantID: tenantID,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, ExperimentArmCandidate, results[0].Metadata["experiment_arm"])
+	assert.Equal(t, "text-embedding-3-large", results[0].Metadata["experiment_model"])
+
+	// Control queries exclude it
+	dbMock.ExpectQuery(`e\.model_name != ALL`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), nil, "match", "test-model", 3, "{0.1,0.2,0.3}", 0.8, "", nil, time.Now()))
+	results, err = service.CrossModelSearch(context.Background(), CrossModelSearchRequest{
+		Query:    queries[ExperimentArmControl],
+		TenantID: tenantID,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, ExperimentArmControl, results[0].Metadata["experiment_arm"])
+	assert.Equal(t, "test-model", results[0].Metadata["experiment_model"])
+
+	stats := experiment.Stats()[ExperimentOperationSearch]
+	assert.Equal(t, int64(1), stats[ExperimentArmCandidate].Requests)
+	assert.Equal(t, int64(1), stats[ExperimentArmControl].Requests)
+	assert.Equal(t, float64(1), stats[ExperimentArmCandidate].AvgResults)
+}
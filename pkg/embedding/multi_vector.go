@@ -0,0 +1,246 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// Multi-vector errors
+var (
+	ErrMultiVectorDisabled     = errors.New("multi-vector embeddings are not enabled for this content type")
+	ErrMultiVectorEmpty        = errors.New("multi-vector embedding must contain at least one vector")
+	ErrMultiVectorTooMany      = errors.New("multi-vector embedding exceeds maximum vectors per content")
+	ErrMultiVectorDimensionMix = errors.New("multi-vector embedding vectors must share the same dimensions")
+)
+
+// MultiVectorEmbedding represents content as a set of token-level vectors
+// (ColBERT-style late interaction) rather than a single pooled vector.
+type MultiVectorEmbedding struct {
+	ContentID   string                 `json:"content_id"`
+	ContentType string                 `json:"content_type"`
+	Content     string                 `json:"content,omitempty"`
+	ModelID     string                 `json:"model_id"`
+	Vectors     [][]float32            `json:"vectors"`
+	Dimensions  int                    `json:"dimensions"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// MultiVectorConfig configures multi-vector storage and scoring.
+// Multi-vector embeddings cost one vector per token, so they are only
+// stored for content types that explicitly opt in.
+type MultiVectorConfig struct {
+	// EnabledContentTypes lists the content types that store multi-vector embeddings
+	EnabledContentTypes []string `json:"enabled_content_types"`
+	// MaxVectorsPerContent caps the number of token vectors stored per content (0 = unlimited)
+	MaxVectorsPerContent int `json:"max_vectors_per_content"`
+}
+
+// DefaultMultiVectorConfig returns a configuration with multi-vector storage disabled
+func DefaultMultiVectorConfig() *MultiVectorConfig {
+	return &MultiVectorConfig{
+		EnabledContentTypes:  []string{},
+		MaxVectorsPerContent: 512,
+	}
+}
+
+// MultiVectorStore stores multi-vector embeddings and searches them with MaxSim scoring
+type MultiVectorStore struct {
+	config       *MultiVectorConfig
+	enabledTypes map[string]bool
+	embeddings   map[string]*MultiVectorEmbedding
+	mu           sync.RWMutex
+}
+
+// NewMultiVectorStore creates a new multi-vector store
+func NewMultiVectorStore(config *MultiVectorConfig) *MultiVectorStore {
+	if config == nil {
+		config = DefaultMultiVectorConfig()
+	}
+
+	enabled := make(map[string]bool, len(config.EnabledContentTypes))
+	for _, contentType := range config.EnabledContentTypes {
+		enabled[contentType] = true
+	}
+
+	return &MultiVectorStore{
+		config:       config,
+		enabledTypes: enabled,
+		embeddings:   make(map[string]*MultiVectorEmbedding),
+	}
+}
+
+// IsEnabled reports whether multi-vector embeddings are enabled for a content type
+func (s *MultiVectorStore) IsEnabled(contentType string) bool {
+	return s.enabledTypes[contentType]
+}
+
+// Store saves a multi-vector embedding, replacing any existing entry for the same content ID
+func (s *MultiVectorStore) Store(ctx context.Context, embedding *MultiVectorEmbedding) error {
+	if embedding == nil {
+		return ErrMultiVectorEmpty
+	}
+	if !s.IsEnabled(embedding.ContentType) {
+		return fmt.Errorf("%w: %s", ErrMultiVectorDisabled, embedding.ContentType)
+	}
+	if len(embedding.Vectors) == 0 {
+		return ErrMultiVectorEmpty
+	}
+	if s.config.MaxVectorsPerContent > 0 && len(embedding.Vectors) > s.config.MaxVectorsPerContent {
+		return fmt.Errorf("%w: %d > %d", ErrMultiVectorTooMany, len(embedding.Vectors), s.config.MaxVectorsPerContent)
+	}
+
+	dims := len(embedding.Vectors[0])
+	for _, vector := range embedding.Vectors {
+		if len(vector) != dims || dims == 0 {
+			return ErrMultiVectorDimensionMix
+		}
+	}
+
+	stored := *embedding
+	stored.Dimensions = dims
+
+	s.mu.Lock()
+	s.embeddings[embedding.ContentID] = &stored
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Get retrieves a multi-vector embedding by content ID
+func (s *MultiVectorStore) Get(ctx context.Context, contentID string) (*MultiVectorEmbedding, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	embedding, ok := s.embeddings[contentID]
+	return embedding, ok
+}
+
+// Delete removes a multi-vector embedding by content ID
+func (s *MultiVectorStore) Delete(ctx context.Context, contentID string) {
+	s.mu.Lock()
+	delete(s.embeddings, contentID)
+	s.mu.Unlock()
+}
+
+// Search scores every stored embedding against the query token vectors using
+// MaxSim and returns results ordered by descending score
+func (s *MultiVectorStore) Search(ctx context.Context, queryVectors [][]float32, options *SearchOptions) (*SearchResults, error) {
+	if len(queryVectors) == 0 {
+		return nil, ErrMultiVectorEmpty
+	}
+	if options == nil {
+		options = &SearchOptions{Limit: 10}
+	}
+
+	contentTypes := make(map[string]bool, len(options.ContentTypes))
+	for _, contentType := range options.ContentTypes {
+		contentTypes[contentType] = true
+	}
+
+	s.mu.RLock()
+	results := make([]*SearchResult, 0, len(s.embeddings))
+	for _, embedding := range s.embeddings {
+		if err := ctx.Err(); err != nil {
+			s.mu.RUnlock()
+			return nil, err
+		}
+		if len(contentTypes) > 0 && !contentTypes[embedding.ContentType] {
+			continue
+		}
+
+		score := MaxSimScore(queryVectors, embedding.Vectors)
+		if score < options.MinSimilarity {
+			continue
+		}
+
+		results = append(results, &SearchResult{
+			Content: &EmbeddingVector{
+				Dimensions:  embedding.Dimensions,
+				ModelID:     embedding.ModelID,
+				ContentType: embedding.ContentType,
+				ContentID:   embedding.ContentID,
+				Metadata:    embedding.Metadata,
+			},
+			Score: score,
+			Matches: map[string]interface{}{
+				"scoring":      "maxsim",
+				"query_tokens": len(queryVectors),
+				"doc_tokens":   len(embedding.Vectors),
+			},
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].Content.ContentID < results[j].Content.ContentID
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	total := len(results)
+	if options.Offset > 0 {
+		if options.Offset >= len(results) {
+			results = []*SearchResult{}
+		} else {
+			results = results[options.Offset:]
+		}
+	}
+	hasMore := false
+	if options.Limit > 0 && len(results) > options.Limit {
+		results = results[:options.Limit]
+		hasMore = true
+	}
+
+	return &SearchResults{
+		Results: results,
+		Total:   total,
+		HasMore: hasMore,
+	}, nil
+}
+
+// MaxSimScore computes the late-interaction score between query and document
+// token vectors: for each query vector take the maximum cosine similarity over
+// all document vectors, then average across query vectors
+func MaxSimScore(queryVectors, docVectors [][]float32) float32 {
+	if len(queryVectors) == 0 || len(docVectors) == 0 {
+		return 0
+	}
+
+	var total float32
+	for _, q := range queryVectors {
+		best := float32(-1)
+		for _, d := range docVectors {
+			if sim := vectorCosineSimilarity(q, d); sim > best {
+				best = sim
+			}
+		}
+		total += best
+	}
+
+	return total / float32(len(queryVectors))
+}
+
+// vectorCosineSimilarity calculates the cosine similarity between two vectors
+func vectorCosineSimilarity(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dotProduct, normA, normB float32
+	for i := range a {
+		dotProduct += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dotProduct / (float32(math.Sqrt(float64(normA))) * float32(math.Sqrt(float64(normB))))
+}
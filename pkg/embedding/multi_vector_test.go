@@ -0,0 +1,113 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxSimScore(t *testing.T) {
+	t.Run("identical token sets score one", func(t *testing.T) {
+		vectors := [][]float32{{1, 0, 0}, {0, 1, 0}}
+		assert.InDelta(t, 1.0, MaxSimScore(vectors, vectors), 0.0001)
+	})
+
+	t.Run("empty inputs score zero", func(t *testing.T) {
+		assert.Equal(t, float32(0), MaxSimScore(nil, [][]float32{{1, 0}}))
+		assert.Equal(t, float32(0), MaxSimScore([][]float32{{1, 0}}, nil))
+	})
+
+	t.Run("takes best match per query token", func(t *testing.T) {
+		query := [][]float32{{1, 0}, {0, 1}}
+		doc := [][]float32{{1, 0}, {-1, 0}}
+		// First query token matches exactly (1.0), second is orthogonal to both (0.0)
+		assert.InDelta(t, 0.5, MaxSimScore(query, doc), 0.0001)
+	})
+}
+
+func TestMultiVectorStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rejects content types that are not opted in", func(t *testing.T) {
+		store := NewMultiVectorStore(&MultiVectorConfig{EnabledContentTypes: []string{"code"}})
+		err := store.Store(ctx, &MultiVectorEmbedding{
+			ContentID:   "doc-1",
+			ContentType: "text",
+			Vectors:     [][]float32{{1, 0}},
+		})
+		assert.ErrorIs(t, err, ErrMultiVectorDisabled)
+	})
+
+	t.Run("rejects too many vectors", func(t *testing.T) {
+		store := NewMultiVectorStore(&MultiVectorConfig{EnabledContentTypes: []string{"code"}, MaxVectorsPerContent: 1})
+		err := store.Store(ctx, &MultiVectorEmbedding{
+			ContentID:   "doc-1",
+			ContentType: "code",
+			Vectors:     [][]float32{{1, 0}, {0, 1}},
+		})
+		assert.ErrorIs(t, err, ErrMultiVectorTooMany)
+	})
+
+	t.Run("rejects mixed dimensions", func(t *testing.T) {
+		store := NewMultiVectorStore(&MultiVectorConfig{EnabledContentTypes: []string{"code"}})
+		err := store.Store(ctx, &MultiVectorEmbedding{
+			ContentID:   "doc-1",
+			ContentType: "code",
+			Vectors:     [][]float32{{1, 0}, {0, 1, 0}},
+		})
+		assert.ErrorIs(t, err, ErrMultiVectorDimensionMix)
+	})
+
+	t.Run("strong token match ranks above weak overall similarity", func(t *testing.T) {
+		store := NewMultiVectorStore(&MultiVectorConfig{EnabledContentTypes: []string{"code"}})
+
+		// Document with one token that exactly matches the rare query term
+		require.NoError(t, store.Store(ctx, &MultiVectorEmbedding{
+			ContentID:   "strong-match",
+			ContentType: "code",
+			Vectors: [][]float32{
+				{0, 0, 0, 1}, // exact match for the query's distinctive token
+				{0.5, 0.5, 0, 0},
+				{0, 0, 1, 0},
+			},
+		}))
+
+		// Document whose tokens are all only loosely related to every query token
+		require.NoError(t, store.Store(ctx, &MultiVectorEmbedding{
+			ContentID:   "weak-overall",
+			ContentType: "code",
+			Vectors: [][]float32{
+				{0.5, 0.5, 0.5, 0.5},
+				{0.6, 0.4, 0.5, 0.5},
+			},
+		}))
+
+		query := [][]float32{
+			{1, 0, 0, 0},
+			{0, 0, 0, 1},
+		}
+
+		results, err := store.Search(ctx, query, &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, results.Results, 2)
+		assert.Equal(t, "strong-match", results.Results[0].Content.ContentID)
+		assert.Equal(t, "weak-overall", results.Results[1].Content.ContentID)
+		assert.Greater(t, results.Results[0].Score, results.Results[1].Score)
+	})
+
+	t.Run("filters by content type and limit", func(t *testing.T) {
+		store := NewMultiVectorStore(&MultiVectorConfig{EnabledContentTypes: []string{"code", "docs"}})
+		require.NoError(t, store.Store(ctx, &MultiVectorEmbedding{ContentID: "a", ContentType: "code", Vectors: [][]float32{{1, 0}}}))
+		require.NoError(t, store.Store(ctx, &MultiVectorEmbedding{ContentID: "b", ContentType: "code", Vectors: [][]float32{{0.9, 0.1}}}))
+		require.NoError(t, store.Store(ctx, &MultiVectorEmbedding{ContentID: "c", ContentType: "docs", Vectors: [][]float32{{1, 0}}}))
+
+		results, err := store.Search(ctx, [][]float32{{1, 0}}, &SearchOptions{ContentTypes: []string{"code"}, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, results.Results, 1)
+		assert.Equal(t, "a", results.Results[0].Content.ContentID)
+		assert.Equal(t, 2, results.Total)
+		assert.True(t, results.HasMore)
+	})
+}
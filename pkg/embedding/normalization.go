@@ -0,0 +1,56 @@
+package embedding
+
+import "math"
+
+// DistanceMetric identifies how vector similarity is measured
+type DistanceMetric string
+
+const (
+	DistanceMetricCosine       DistanceMetric = "cosine"
+	DistanceMetricInnerProduct DistanceMetric = "inner_product"
+	DistanceMetricEuclidean    DistanceMetric = "euclidean"
+)
+
+// NormalizationConfig controls L2 normalization of embeddings when they are
+// indexed and when they are used as search queries. Providers don't agree on
+// vector magnitude, so without it inner product and euclidean comparisons
+// across models are skewed towards whichever model emits the longest vectors.
+type NormalizationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Metric is the distance metric vectors are compared with. Cosine similarity
+	// ignores magnitude, so vectors are left as-is for it. An empty metric is
+	// treated as inner product.
+	Metric DistanceMetric `mapstructure:"metric"`
+}
+
+// Applies reports whether vectors are normalized under this configuration
+func (c NormalizationConfig) Applies() bool {
+	return c.Enabled && c.Metric != DistanceMetricCosine
+}
+
+// Apply returns the vector L2-normalized when the configuration calls for it
+func (c NormalizationConfig) Apply(vector []float32) []float32 {
+	if !c.Applies() {
+		return vector
+	}
+	return L2Normalize(vector)
+}
+
+// L2Normalize returns a copy of the vector scaled to unit length. Zero vectors
+// have no direction and are returned unchanged.
+func L2Normalize(vector []float32) []float32 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vector
+	}
+
+	magnitude := math.Sqrt(sum)
+	normalized := make([]float32, len(vector))
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / magnitude)
+	}
+	return normalized
+}
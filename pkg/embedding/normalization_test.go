@@ -0,0 +1,215 @@
+package embedding
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+)
+
+func l2Norm(vector []float32) float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+func dotProduct(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
+
+func euclideanDistance(a, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+func scaled(vector []float32, factor float32) []float32 {
+	out := make([]float32, len(vector))
+	for i, v := range vector {
+		out[i] = v * factor
+	}
+	return out
+}
+
+// unitVectorArg matches a pq array argument holding a unit-length vector
+type unitVectorArg struct{}
+
+func (unitVectorArg) Match(v driver.Value) bool {
+	literal, ok := v.(string)
+	if !ok {
+		return false
+	}
+	var vector []float32
+	for _, field := range strings.Split(strings.Trim(literal, "{}"), ",") {
+		f, err := strconv.ParseFloat(field, 32)
+		if err != nil {
+			return false
+		}
+		vector = append(vector, float32(f))
+	}
+	return math.Abs(l2Norm(vector)-1) < 1e-4
+}
+
+func TestL2Normalize(t *testing.T) {
+	vectors := [][]float32{
+		{3, 4},
+		{0.001, -0.002, 0.003},
+		{120, -45, 8, 0, 33},
+		scaled([]float32{0.1, 0.2, 0.3, 0.4}, 250),
+	}
+	for _, vector := range vectors {
+		original := append([]float32(nil), vector...)
+
+		normalized := L2Normalize(vector)
+		assert.InDelta(t, 1.0, l2Norm(normalized), 1e-6)
+		// Direction is kept
+		assert.InDelta(t, 1.0, vectorCosineSimilarity(original, normalized), 1e-6)
+		// and the input is left alone
+		assert.Equal(t, original, vector)
+	}
+
+	assert.Equal(t, []float32{0.6, 0.8}, L2Normalize([]float32{3, 4}))
+	assert.Equal(t, []float32{0, 0, 0}, L2Normalize([]float32{0, 0, 0}))
+	assert.Empty(t, L2Normalize(nil))
+}
+
+func TestNormalizationConfigIsMetricAware(t *testing.T) {
+	vector := []float32{3, 4}
+
+	tests := []struct {
+		name   string
+		config NormalizationConfig
+		want   []float32
+	}{
+		{"disabled", NormalizationConfig{Metric: DistanceMetricInnerProduct}, vector},
+		{"cosine ignores magnitude", NormalizationConfig{Enabled: true, Metric: DistanceMetricCosine}, vector},
+		{"inner product", NormalizationConfig{Enabled: true, Metric: DistanceMetricInnerProduct}, []float32{0.6, 0.8}},
+		{"euclidean", NormalizationConfig{Enabled: true, Metric: DistanceMetricEuclidean}, []float32{0.6, 0.8}},
+		{"default metric", NormalizationConfig{Enabled: true}, []float32{0.6, 0.8}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.config.Apply(vector))
+		})
+	}
+}
+
+func TestNormalizationImprovesCrossModelSimilarity(t *testing.T) {
+	// The same topics embedded by models that emit vectors of very different
+	// magnitudes. The query comes from a model with unit vectors, the relevant
+	// document from one with short vectors and the unrelated one from a model
+	// with long vectors.
+	query := L2Normalize([]float32{0.9, 0.3, 0.1, 0.1})
+	relevant := scaled(L2Normalize([]float32{0.85, 0.35, 0.15, 0.05}), 0.5)
+	unrelated := scaled(L2Normalize([]float32{0.3, 0.1, 0.9, 0.3}), 15)
+
+	// Raw inner product is dominated by magnitude and ranks the unrelated
+	// document first
+	assert.Greater(t, dotProduct(query, unrelated), dotProduct(query, relevant))
+	rawGap := dotProduct(query, relevant) - dotProduct(query, unrelated)
+
+	for _, metric := range []DistanceMetric{DistanceMetricInnerProduct, DistanceMetricEuclidean} {
+		config := NormalizationConfig{Enabled: true, Metric: metric}
+		q, r, u := config.Apply(query), config.Apply(relevant), config.Apply(unrelated)
+
+		// Once normalized the relevant document ranks first under both metrics
+		assert.Greater(t, dotProduct(q, r), dotProduct(q, u), metric)
+		assert.Less(t, euclideanDistance(q, r), euclideanDistance(q, u), metric)
+		assert.Greater(t, dotProduct(q, r)-dotProduct(q, u), rawGap, metric)
+
+		// and inner product agrees with cosine similarity across models
+		assert.InDelta(t, vectorCosineSimilarity(query, relevant), dotProduct(q, r), 1e-6)
+		assert.InDelta(t, vectorCosineSimilarity(query, unrelated), dotProduct(q, u), 1e-6)
+	}
+}
+
+// scaledProvider emits the mock provider's embeddings at a fixed magnitude
+type scaledProvider struct {
+	*providers.MockProvider
+	factor float32
+}
+
+func (p *scaledProvider) GenerateEmbedding(ctx context.Context, req providers.GenerateEmbeddingRequest) (*providers.EmbeddingResponse, error) {
+	resp, err := p.MockProvider.GenerateEmbedding(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Embedding = scaled(resp.Embedding, p.factor)
+	return resp, nil
+}
+
+func TestGenerateEmbeddingNormalizesBeforeStoring(t *testing.T) {
+	ctx := context.Background()
+
+	db, mockDB, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mockDB.ExpectQuery("SELECT mcp.insert_embedding").
+		WithArgs(
+			sqlmock.AnyArg(), sqlmock.AnyArg(), unitVectorArg{}, sqlmock.AnyArg(), sqlmock.AnyArg(),
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	mockAgentService := &MockAgentService{}
+	mockAgentService.On("GetConfig", mock.Anything, "test-agent").Return(nil, errors.New("not found"))
+
+	service, err := NewServiceV2(ServiceV2Config{
+		Providers: map[string]providers.Provider{
+			"openai": &scaledProvider{MockProvider: providers.NewMockProvider("openai"), factor: 7},
+		},
+		AgentService:  mockAgentService,
+		Repository:    NewRepository(db),
+		FallbackChain: []string{"openai:mock-model-small"},
+		Normalization: NormalizationConfig{Enabled: true, Metric: DistanceMetricInnerProduct},
+	})
+	require.NoError(t, err)
+
+	resp, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+		AgentID:  "test-agent",
+		Text:     "normalize me",
+		TenantID: uuid.New(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, true, resp.Metadata["l2_normalized"])
+	assert.NoError(t, mockDB.ExpectationsWereMet())
+}
+
+func TestCrossModelSearchNormalizesQuery(t *testing.T) {
+	service, dbMock := newReindexTestService(t)
+	service.normalization = NormalizationConfig{Enabled: true, Metric: DistanceMetricInnerProduct}
+	tenantID := uuid.New()
+
+	dbMock.ExpectQuery(`FROM mcp\.embeddings e`).
+		WithArgs(sqlmock.AnyArg(), unitVectorArg{}, tenantID, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err := service.CrossModelSearch(context.Background(), CrossModelSearchRequest{
+		QueryEmbedding: []float32{3, 4, 12},
+		TenantID:       tenantID,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// PreprocessingHook transforms a tenant's content before it's embedded, e.g. to
+// normalize domain terminology or keep only the relevant sections
+type PreprocessingHook interface {
+	Preprocess(ctx context.Context, content string) (string, error)
+}
+
+// PreprocessingHookFunc adapts a function to a PreprocessingHook
+type PreprocessingHookFunc func(ctx context.Context, content string) (string, error)
+
+// Preprocess implements PreprocessingHook
+func (f PreprocessingHookFunc) Preprocess(ctx context.Context, content string) (string, error) {
+	return f(ctx, content)
+}
+
+// PreprocessingRegistry holds the preprocessing hooks of each tenant. A tenant's
+// hooks run in registration order; content of other tenants passes unchanged.
+type PreprocessingRegistry struct {
+	mu    sync.RWMutex
+	hooks map[uuid.UUID][]PreprocessingHook
+}
+
+// NewPreprocessingRegistry creates a registry with the given hooks by tenant
+func NewPreprocessingRegistry(hooks map[uuid.UUID][]PreprocessingHook) *PreprocessingRegistry {
+	r := &PreprocessingRegistry{hooks: make(map[uuid.UUID][]PreprocessingHook)}
+	for tenantID, tenantHooks := range hooks {
+		for _, hook := range tenantHooks {
+			r.Register(tenantID, hook)
+		}
+	}
+	return r
+}
+
+// Register adds a hook run after the tenant's hooks already registered
+func (r *PreprocessingRegistry) Register(tenantID uuid.UUID, hook PreprocessingHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks[tenantID] = append(r.hooks[tenantID], hook)
+}
+
+// Clear removes all of a tenant's hooks
+func (r *PreprocessingRegistry) Clear(tenantID uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.hooks, tenantID)
+}
+
+// Apply runs the tenant's hooks over the content
+func (r *PreprocessingRegistry) Apply(ctx context.Context, tenantID uuid.UUID, content string) (string, error) {
+	r.mu.RLock()
+	hooks := r.hooks[tenantID]
+	r.mu.RUnlock()
+
+	for i, hook := range hooks {
+		processed, err := hook.Preprocess(ctx, content)
+		if err != nil {
+			return "", fmt.Errorf("preprocessing hook %d failed: %w", i, err)
+		}
+		content = processed
+	}
+	return content, nil
+}
@@ -0,0 +1,106 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expandAbbreviations normalizes legal shorthand to full terms
+var expandAbbreviations = PreprocessingHookFunc(func(ctx context.Context, content string) (string, error) {
+	return strings.NewReplacer("K.", "contract", "Def.", "defendant").Replace(content), nil
+})
+
+func TestPreprocessingRegistry(t *testing.T) {
+	ctx := context.Background()
+	legal := uuid.New()
+	other := uuid.New()
+
+	registry := NewPreprocessingRegistry(map[uuid.UUID][]PreprocessingHook{legal: {expandAbbreviations}})
+	registry.Register(legal, PreprocessingHookFunc(func(ctx context.Context, content string) (string, error) {
+		return strings.ToUpper(content), nil
+	}))
+
+	// Hooks run in registration order
+	text, err := registry.Apply(ctx, legal, "Def. breached the K.")
+	require.NoError(t, err)
+	assert.Equal(t, "DEFENDANT BREACHED THE CONTRACT", text)
+
+	text, err = registry.Apply(ctx, other, "Def. breached the K.")
+	require.NoError(t, err)
+	assert.Equal(t, "Def. breached the K.", text)
+
+	registry.Register(other, PreprocessingHookFunc(func(ctx context.Context, content string) (string, error) {
+		return "", errors.New("section not found")
+	}))
+	_, err = registry.Apply(ctx, other, "Def. breached the K.")
+	assert.ErrorContains(t, err, "section not found")
+
+	registry.Clear(legal)
+	text, err = registry.Apply(ctx, legal, "Def. breached the K.")
+	require.NoError(t, err)
+	assert.Equal(t, "Def. breached the K.", text)
+}
+
+func TestGenerateEmbeddingAppliesTenantPreprocessing(t *testing.T) {
+	ctx := context.Background()
+	legal := uuid.New()
+	other := uuid.New()
+	text := "Def. breached the K."
+
+	tests := []struct {
+		name     string
+		tenantID uuid.UUID
+		embedded string
+	}{
+		{name: "tenant hook transforms content", tenantID: legal, embedded: "defendant breached the contract"},
+		{name: "other tenants are unaffected", tenantID: other, embedded: text},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, dbMock := newDedupTestService(t, DeduplicationConfig{})
+			service.RegisterPreprocessingHook(legal, expandAbbreviations)
+
+			dbMock.ExpectQuery(`WHERE e.content_hash = \$1`).
+				WithArgs(CalculateContentHash(tt.embedded), sqlmock.AnyArg(), tt.tenantID).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			dbMock.ExpectQuery(`SELECT mcp.insert_embedding`).
+				WithArgs(
+					sqlmock.AnyArg(), tt.embedded, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+					sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+			_, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+				AgentID:  "test-agent",
+				Text:     text,
+				TenantID: tt.tenantID,
+			})
+			require.NoError(t, err)
+			assert.NoError(t, dbMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestGenerateEmbeddingPreprocessingFailure(t *testing.T) {
+	service, dbMock := newDedupTestService(t, DeduplicationConfig{})
+	tenantID := uuid.New()
+	service.RegisterPreprocessingHook(tenantID, PreprocessingHookFunc(func(ctx context.Context, content string) (string, error) {
+		return "", errors.New("section not found")
+	}))
+
+	_, err := service.GenerateEmbedding(context.Background(), GenerateEmbeddingRequest{
+		AgentID:  "test-agent",
+		Text:     "Def. breached the K.",
+		TenantID: tenantID,
+	})
+	assert.ErrorContains(t, err, "failed to preprocess content")
+	assert.NoError(t, dbMock.ExpectationsWereMet())
+}
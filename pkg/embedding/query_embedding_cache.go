@@ -0,0 +1,147 @@
+package embedding
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+)
+
+const (
+	// DefaultQueryEmbeddingCacheTTL is how long a query embedding is reused
+	DefaultQueryEmbeddingCacheTTL = time.Minute
+	// DefaultQueryEmbeddingCacheMaxEntries is the number of query embeddings kept
+	DefaultQueryEmbeddingCacheMaxEntries = 10000
+)
+
+// QueryEmbeddingCacheConfig configures caching of search query embeddings, so
+// repeated queries don't each call the embedding provider
+type QueryEmbeddingCacheConfig struct {
+	TTL        time.Duration // How long an embedding is reused; defaults to 1 minute
+	MaxEntries int           // Embeddings kept, evicting the oldest; defaults to 10000
+}
+
+// withDefaults fills in unset limits
+func (c QueryEmbeddingCacheConfig) withDefaults() QueryEmbeddingCacheConfig {
+	if c.TTL <= 0 {
+		c.TTL = DefaultQueryEmbeddingCacheTTL
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = DefaultQueryEmbeddingCacheMaxEntries
+	}
+	return c
+}
+
+type cachedQueryEmbedding struct {
+	embedding *EmbeddingVector
+	cachedAt  time.Time
+}
+
+// queryEmbeddingCache holds recent query embeddings by tenant, model and
+// normalized query. Identical queries arriving while one is being embedded wait
+// for that embedding instead of requesting their own.
+type queryEmbeddingCache struct {
+	config   QueryEmbeddingCacheConfig
+	mu       sync.Mutex
+	entries  map[string]*cachedQueryEmbedding
+	inflight singleflight.Group
+}
+
+func newQueryEmbeddingCache(config QueryEmbeddingCacheConfig) *queryEmbeddingCache {
+	return &queryEmbeddingCache{
+		config:  config.withDefaults(),
+		entries: make(map[string]*cachedQueryEmbedding),
+	}
+}
+
+// normalizeQuery ignores case and whitespace differences between queries
+func normalizeQuery(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// key identifies a query embedding
+func (c *queryEmbeddingCache) key(tenantID uuid.UUID, model, text string) string {
+	return tenantID.String() + "\x00" + model + "\x00" + normalizeQuery(text)
+}
+
+// getOrGenerate returns the cached embedding of a query, generating it on a miss.
+// hit reports whether no new embedding was generated for this call.
+func (c *queryEmbeddingCache) getOrGenerate(ctx context.Context, key string, generate func(ctx context.Context) (*EmbeddingVector, error)) (embedding *EmbeddingVector, hit bool, err error) {
+	if embedding, ok := c.get(key); ok {
+		return embedding, true, nil
+	}
+
+	value, err, shared := c.inflight.Do(key, func() (interface{}, error) {
+		embedding, err := generate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.put(key, embedding)
+		return embedding, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return value.(*EmbeddingVector), shared, nil
+}
+
+// get returns the embedding of a query while it's fresh
+func (c *queryEmbeddingCache) get(key string) (*EmbeddingVector, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(entry.cachedAt) > c.config.TTL {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.embedding, true
+}
+
+// put stores a query embedding, evicting the oldest beyond the entry limit
+func (c *queryEmbeddingCache) put(key string, embedding *EmbeddingVector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = &cachedQueryEmbedding{embedding: embedding, cachedAt: time.Now()}
+	for len(c.entries) > c.config.MaxEntries {
+		oldestKey := ""
+		var oldest time.Time
+		for k, entry := range c.entries {
+			if oldestKey == "" || entry.cachedAt.Before(oldest) {
+				oldestKey, oldest = k, entry.cachedAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// embedQuery embeds a search query with an embedder, reusing a recent embedding
+// of the same query by the same model when the query embedding cache is enabled
+func (s *UnifiedSearchService) embedQuery(ctx context.Context, embedder EmbeddingService, text string) (*EmbeddingVector, error) {
+	if s.queryEmbeddings == nil {
+		return embedder.GenerateEmbedding(ctx, text, "search_query", "")
+	}
+
+	key := s.queryEmbeddings.key(auth.GetTenantID(ctx), embedder.GetModelConfig().Name, text)
+	embedding, hit, err := s.queryEmbeddings.getOrGenerate(ctx, key, func(ctx context.Context) (*EmbeddingVector, error) {
+		return embedder.GenerateEmbedding(ctx, text, "search_query", "")
+	})
+	if err != nil {
+		return nil, err
+	}
+	if hit {
+		s.metrics.IncrementCounter("search.unified.query_embedding_cache.hit", 1.0)
+	} else {
+		s.metrics.IncrementCounter("search.unified.query_embedding_cache.miss", 1.0)
+	}
+	return embedding, nil
+}
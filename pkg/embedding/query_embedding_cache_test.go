@@ -0,0 +1,117 @@
+package embedding
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+// countingEmbeddingService counts the embeddings generated, optionally holding
+// each request until release is closed
+type countingEmbeddingService struct {
+	MockEmbeddingServiceForTests
+	generated atomic.Int32
+	release   chan struct{}
+}
+
+func (c *countingEmbeddingService) GenerateEmbedding(ctx context.Context, text string, contentType string, contentID string) (*EmbeddingVector, error) {
+	c.generated.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	return c.MockEmbeddingServiceForTests.GenerateEmbedding(ctx, text, contentType, contentID)
+}
+
+func newQueryEmbeddingCacheTestService(t *testing.T, config *QueryEmbeddingCacheConfig) (*UnifiedSearchService, *countingEmbeddingService) {
+	t.Helper()
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	embedder := &countingEmbeddingService{}
+	service, err := NewUnifiedSearchService(&UnifiedSearchConfig{
+		DB:               db,
+		SearchRepository: &pagingSearchRepository{results: []*repositorySearch.SearchResult{{ID: "doc-0", Score: 0.9}}},
+		EmbeddingService: embedder,
+		QueryEmbeddings:  config,
+		Logger:           observability.NewNoopLogger(),
+		Metrics:          observability.NewNoOpMetricsClient(),
+	})
+	require.NoError(t, err)
+	return service, embedder
+}
+
+func TestQueryEmbeddingCache(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+
+	t.Run("repeated query within the TTL reuses the embedding", func(t *testing.T) {
+		service, embedder := newQueryEmbeddingCacheTestService(t, &QueryEmbeddingCacheConfig{TTL: time.Minute})
+
+		for _, query := range []string{"helm rollback", "Helm  Rollback", " helm rollback\n"} {
+			_, err := service.Search(ctx, query, &SearchOptions{Limit: 10})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(1), embedder.generated.Load())
+
+		// A different query or tenant gets its own embedding
+		_, err := service.Search(ctx, "helm upgrade", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		_, err = service.Search(auth.WithTenantID(context.Background(), uuid.New()), "helm rollback", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), embedder.generated.Load())
+	})
+
+	t.Run("query is embedded again once the TTL passes", func(t *testing.T) {
+		service, embedder := newQueryEmbeddingCacheTestService(t, &QueryEmbeddingCacheConfig{TTL: 10 * time.Millisecond})
+
+		_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		time.Sleep(20 * time.Millisecond)
+		_, err = service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), embedder.generated.Load())
+	})
+
+	t.Run("simultaneous identical queries share one embedding", func(t *testing.T) {
+		service, embedder := newQueryEmbeddingCacheTestService(t, &QueryEmbeddingCacheConfig{})
+		embedder.release = make(chan struct{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10})
+				assert.NoError(t, err)
+			}()
+		}
+		require.Eventually(t, func() bool { return embedder.generated.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(embedder.release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), embedder.generated.Load())
+	})
+
+	t.Run("without the cache every query is embedded", func(t *testing.T) {
+		service, embedder := newQueryEmbeddingCacheTestService(t, nil)
+
+		for i := 0; i < 2; i++ {
+			_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10})
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), embedder.generated.Load())
+	})
+}
@@ -0,0 +1,212 @@
+package embedding
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// QueryIntent is what a search query is looking for
+type QueryIntent string
+
+const (
+	// QueryIntentLookup queries name something specific: an identifier, error
+	// code, commit, issue or quoted phrase
+	QueryIntentLookup QueryIntent = "lookup"
+	// QueryIntentNavigational queries look for a known file, package or location
+	QueryIntentNavigational QueryIntent = "navigational"
+	// QueryIntentExploratory queries ask about a concept or how something works
+	QueryIntentExploratory QueryIntent = "exploratory"
+)
+
+// SearchStrategy is how a hybrid search request is executed
+type SearchStrategy string
+
+const (
+	SearchStrategyKeyword  SearchStrategy = "keyword"
+	SearchStrategySemantic SearchStrategy = "semantic"
+	SearchStrategyHybrid   SearchStrategy = "hybrid"
+)
+
+// navigationalHybridWeight favors keyword matches for navigational queries,
+// which usually contain the name of what they're looking for
+const navigationalHybridWeight = 0.3
+
+// lookupMaxTerms is the most terms a query naming an identifier can have and
+// still be a lookup; longer queries are questions about the identifier
+const lookupMaxTerms = 4
+
+var (
+	uuidPattern       = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	commitPattern     = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	issuePattern      = regexp.MustCompile(`^#\d+$`)
+	camelCasePattern  = regexp.MustCompile(`^[A-Za-z][a-z0-9]+[A-Z][A-Za-z0-9]*$`)
+	snakeCasePattern  = regexp.MustCompile(`^[A-Za-z0-9]+(_[A-Za-z0-9]+)+$`)
+	qualifiedPattern  = regexp.MustCompile(`^[A-Za-z_]\w*((\.|::)[A-Za-z_]\w*)+(\(\))?$`)
+	errorCodePattern  = regexp.MustCompile(`^[A-Z]{1,5}-?\d{3,}$`)
+	keywordTermSplit  = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+	navigationalVerbs = []string{"go to ", "open ", "where is ", "where are ", "find file ", "show me the "}
+)
+
+// navigationalStopWords are dropped from navigational queries' keywords
+var navigationalStopWords = map[string]bool{
+	"go": true, "to": true, "open": true, "where": true, "is": true, "are": true,
+	"find": true, "file": true, "show": true, "me": true, "the": true, "a": true,
+}
+
+// fileExtensions are extensions that mark a query term as a file name
+var fileExtensions = map[string]bool{
+	".go": true, ".md": true, ".yaml": true, ".yml": true, ".json": true, ".sql": true,
+	".py": true, ".js": true, ".ts": true, ".tsx": true, ".java": true, ".rs": true,
+	".sh": true, ".toml": true, ".proto": true, ".tf": true,
+}
+
+// QueryRoute is the search strategy chosen for a query
+type QueryRoute struct {
+	Intent       QueryIntent
+	Strategy     SearchStrategy
+	Keywords     []string // Keywords for keyword search, empty for semantic search
+	HybridWeight float32  // Weight of semantic scores when merging
+}
+
+// ClassifyQueryIntent classifies a query from its terms. It is deliberately
+// cheap: queries are classified on every routed search.
+func ClassifyQueryIntent(query string) QueryIntent {
+	query = strings.TrimSpace(query)
+	if isQuoted(query) {
+		return QueryIntentLookup
+	}
+
+	terms := queryTerms(query)
+	for _, term := range terms {
+		if isFileTerm(term) {
+			return QueryIntentNavigational
+		}
+	}
+
+	if len(terms) <= lookupMaxTerms {
+		for _, term := range terms {
+			if isIdentifierTerm(term) {
+				return QueryIntentLookup
+			}
+		}
+	}
+
+	lower := strings.ToLower(query) + " "
+	for _, verb := range navigationalVerbs {
+		if strings.HasPrefix(lower, verb) {
+			return QueryIntentNavigational
+		}
+	}
+
+	return QueryIntentExploratory
+}
+
+// RouteQuery chooses how to execute a hybrid search request from its query's
+// intent. Keywords given in the request are kept; otherwise lookups and
+// navigational queries search for keywords taken from the query.
+func RouteQuery(req HybridSearchRequest) QueryRoute {
+	route := QueryRoute{Intent: ClassifyQueryIntent(req.Query)}
+
+	switch route.Intent {
+	case QueryIntentLookup:
+		route.Strategy = SearchStrategyKeyword
+		route.Keywords = req.Keywords
+		if len(route.Keywords) == 0 {
+			route.Keywords = lookupKeywords(req.Query)
+		}
+	case QueryIntentNavigational:
+		route.Strategy = SearchStrategyHybrid
+		route.HybridWeight = navigationalHybridWeight
+		route.Keywords = req.Keywords
+		if len(route.Keywords) == 0 {
+			route.Keywords = keywordTerms(req.Query, navigationalStopWords)
+		}
+	default:
+		route.Strategy = SearchStrategySemantic
+		route.HybridWeight = 1
+		if len(req.Keywords) > 0 {
+			route.Strategy = SearchStrategyHybrid
+			route.Keywords = req.Keywords
+			route.HybridWeight = req.HybridWeight
+		}
+	}
+
+	// A query without usable keywords can only be searched semantically
+	if route.Strategy != SearchStrategySemantic && len(route.Keywords) == 0 {
+		route.Strategy = SearchStrategySemantic
+		route.HybridWeight = 1
+	}
+
+	return route
+}
+
+// isQuoted reports whether the whole query is a quoted phrase
+func isQuoted(query string) bool {
+	if len(query) < 2 {
+		return false
+	}
+	first, last := query[0], query[len(query)-1]
+	return (first == '"' || first == '`' || first == '\'') && first == last
+}
+
+// queryTerms splits a query into terms without surrounding punctuation
+func queryTerms(query string) []string {
+	fields := strings.Fields(query)
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		term := strings.Trim(field, `"'`+"`"+`,;:!?`)
+		term = strings.TrimSuffix(term, ".")
+		if term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// isFileTerm reports whether a term is a file name or path
+func isFileTerm(term string) bool {
+	if strings.Contains(term, "/") && !strings.HasPrefix(term, "http") {
+		return true
+	}
+	return fileExtensions[strings.ToLower(path.Ext(term))]
+}
+
+// isIdentifierTerm reports whether a term names something specific rather
+// than being an ordinary word
+func isIdentifierTerm(term string) bool {
+	if uuidPattern.MatchString(term) || issuePattern.MatchString(term) || errorCodePattern.MatchString(term) {
+		return true
+	}
+	if commitPattern.MatchString(term) && strings.ContainsAny(term, "0123456789") && strings.ContainsAny(term, "abcdef") {
+		return true
+	}
+	return camelCasePattern.MatchString(term) || snakeCasePattern.MatchString(term) || qualifiedPattern.MatchString(term)
+}
+
+// lookupKeywords returns the identifiers in a lookup query, or all its terms
+// when it has none (a quoted phrase)
+func lookupKeywords(query string) []string {
+	var identifiers []string
+	for _, term := range queryTerms(query) {
+		if isIdentifierTerm(term) {
+			identifiers = append(identifiers, keywordTerms(term, nil)...)
+		}
+	}
+	if len(identifiers) > 0 {
+		return identifiers
+	}
+	return keywordTerms(query, nil)
+}
+
+// keywordTerms splits text into terms safe to use in a text search query
+func keywordTerms(text string, stopWords map[string]bool) []string {
+	var keywords []string
+	for _, term := range keywordTermSplit.Split(text, -1) {
+		if term == "" || stopWords[strings.ToLower(term)] {
+			continue
+		}
+		keywords = append(keywords, term)
+	}
+	return keywords
+}
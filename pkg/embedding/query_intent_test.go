@@ -0,0 +1,99 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyQueryIntent(t *testing.T) {
+	tests := []struct {
+		query  string
+		intent QueryIntent
+	}{
+		{"ErrCodeContextTooLarge", QueryIntentLookup},
+		{"handleContextAppend", QueryIntentLookup},
+		{"where is max_tokens set", QueryIntentLookup},
+		{"embedding.NewUnifiedSearchService", QueryIntentLookup},
+		{"3f9c2a1b", QueryIntentLookup},
+		{"550e8400-e29b-41d4-a716-446655440000", QueryIntentLookup},
+		{"#1234", QueryIntentLookup},
+		{`"connection refused"`, QueryIntentLookup},
+		{"pkg/embedding/search_unified.go", QueryIntentNavigational},
+		{"open the docker-compose.yml", QueryIntentNavigational},
+		{"where is the websocket server", QueryIntentNavigational},
+		{"how do agents share context across workspaces", QueryIntentExploratory},
+		{"why does the hybrid search favor semantic results", QueryIntentExploratory},
+		{"retry strategies for flaky tool executions", QueryIntentExploratory},
+		{"how does handleContextAppend decide when to truncate a context", QueryIntentExploratory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.intent, ClassifyQueryIntent(tt.query))
+		})
+	}
+}
+
+func TestRouteQuery(t *testing.T) {
+	t.Run("identifier routes to keyword search", func(t *testing.T) {
+		route := RouteQuery(HybridSearchRequest{Query: "find ErrCodeContextTooLarge", HybridWeight: 0.7})
+		assert.Equal(t, QueryIntentLookup, route.Intent)
+		assert.Equal(t, SearchStrategyKeyword, route.Strategy)
+		assert.Equal(t, []string{"ErrCodeContextTooLarge"}, route.Keywords)
+		assert.Equal(t, float32(0), route.HybridWeight)
+	})
+
+	t.Run("conceptual query routes to semantic search", func(t *testing.T) {
+		route := RouteQuery(HybridSearchRequest{Query: "how do agents coordinate long running tasks", HybridWeight: 0.7})
+		assert.Equal(t, QueryIntentExploratory, route.Intent)
+		assert.Equal(t, SearchStrategySemantic, route.Strategy)
+		assert.Empty(t, route.Keywords)
+		assert.Equal(t, float32(1), route.HybridWeight)
+	})
+
+	t.Run("conceptual query with keywords stays hybrid", func(t *testing.T) {
+		route := RouteQuery(HybridSearchRequest{
+			Query:        "how do agents coordinate long running tasks",
+			Keywords:     []string{"workflow"},
+			HybridWeight: 0.7,
+		})
+		assert.Equal(t, SearchStrategyHybrid, route.Strategy)
+		assert.Equal(t, []string{"workflow"}, route.Keywords)
+		assert.Equal(t, float32(0.7), route.HybridWeight)
+	})
+
+	t.Run("navigational query favors keywords", func(t *testing.T) {
+		route := RouteQuery(HybridSearchRequest{Query: "where is the websocket server"})
+		assert.Equal(t, QueryIntentNavigational, route.Intent)
+		assert.Equal(t, SearchStrategyHybrid, route.Strategy)
+		assert.Equal(t, []string{"websocket", "server"}, route.Keywords)
+		assert.Equal(t, float32(navigationalHybridWeight), route.HybridWeight)
+	})
+}
+
+func TestHybridSearchRoutesLookupToKeywordSearch(t *testing.T) {
+	service, mock := newReindexTestService(t)
+	tenantID, id := uuid.New(), uuid.New()
+
+	// Only the keyword query is expected; a semantic search would fail the mock
+	expectKeywordSearch(mock, tenantID, id)
+
+	results, err := service.HybridSearch(context.Background(), HybridSearchRequest{
+		Query:         "ErrCodeContextTooLarge",
+		TenantID:      tenantID,
+		Limit:         10,
+		HybridWeight:  0.7,
+		RouteByIntent: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, id, results[0].ID)
+	assert.Equal(t, QueryIntentLookup, results[0].Intent)
+	assert.Equal(t, SearchStrategyKeyword, results[0].Strategy)
+	assert.Equal(t, results[0].KeywordScore, results[0].HybridScore)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
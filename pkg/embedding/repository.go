@@ -18,6 +18,8 @@ type Repository struct {
 	db      *sql.DB
 	logger  observability.Logger
 	metrics observability.MetricsClient
+
+	invalidators []ContentInvalidator // Caches purged when content changes
 }
 
 func NewRepository(db *sql.DB) *Repository {
@@ -117,6 +119,13 @@ func (r *Repository) InsertEmbedding(ctx context.Context, req InsertRequest) (uu
 		"context_id":     req.ContextID,
 	})
 
+	// Inserting may replace an existing embedding, and changes its context either way
+	if req.ContextID != nil {
+		r.invalidateContent(ctx, id, *req.ContextID)
+	} else {
+		r.invalidateContent(ctx, id)
+	}
+
 	return id, nil
 }
 
@@ -315,6 +324,7 @@ func (r *Repository) GetExistingEmbedding(ctx context.Context, contentHash strin
 		WHERE e.content_hash = $1 
 		AND (m.model_name = $2 OR m.model_id = $2)
 		AND e.tenant_id = $3
+		AND e.deleted_at IS NULL
 		LIMIT 1
 	`
 
@@ -351,7 +361,7 @@ func (r *Repository) GetEmbeddingsByContext(ctx context.Context, contextID, tena
                processing_time_ms, embedding_created_at, magnitude, 
                tenant_id, metadata, created_at, updated_at
         FROM mcp.embeddings
-        WHERE context_id = $1 AND tenant_id = $2
+        WHERE context_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
         ORDER BY content_index, chunk_index
     `
 
@@ -0,0 +1,215 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// BudgetPolicy decides what happens when reranking every result would exceed the budget
+type BudgetPolicy string
+
+const (
+	// BudgetPolicyPartial reranks as many of the top results as fit the budget
+	// and appends the rest in their original order
+	BudgetPolicyPartial BudgetPolicy = "partial"
+	// BudgetPolicySkip returns the results in their original order
+	BudgetPolicySkip BudgetPolicy = "skip"
+)
+
+// BudgetOutcome records how much of a result set was reranked
+type BudgetOutcome string
+
+const (
+	BudgetOutcomeFull    BudgetOutcome = "full"
+	BudgetOutcomePartial BudgetOutcome = "partial"
+	BudgetOutcomeSkipped BudgetOutcome = "skipped"
+)
+
+// BudgetConfig configures the reranking latency budget
+type BudgetConfig struct {
+	Budget time.Duration // Latency reranking may add; zero disables the budget
+	Policy BudgetPolicy  // partial or skip; defaults to partial
+	// LatencyPerResult is the initial estimate of how long reranking takes per
+	// result, refined from observed reranks. Without it the first rerank is
+	// unbudgeted.
+	LatencyPerResult time.Duration
+}
+
+// BudgetResult describes what a budgeted rerank did
+type BudgetResult struct {
+	Outcome          BudgetOutcome `json:"outcome"`
+	Reranked         int           `json:"reranked"`
+	Total            int           `json:"total"`
+	EstimatedLatency time.Duration `json:"estimated_latency"`
+}
+
+// budgetEstimateWeight is how much each observed rerank moves the per-result estimate
+const budgetEstimateWeight = 0.3
+
+// BudgetedReranker keeps reranking within a latency budget by estimating the
+// per-result cost of the wrapped reranker from previous reranks
+type BudgetedReranker struct {
+	reranker Reranker
+	config   BudgetConfig
+	logger   observability.Logger
+	metrics  observability.MetricsClient
+
+	mu        sync.Mutex
+	perResult time.Duration
+}
+
+// NewBudgetedReranker wraps a reranker with a latency budget
+func NewBudgetedReranker(reranker Reranker, config BudgetConfig, logger observability.Logger, metrics observability.MetricsClient) (*BudgetedReranker, error) {
+	if reranker == nil {
+		return nil, fmt.Errorf("reranker is required")
+	}
+	if config.Budget < 0 {
+		return nil, fmt.Errorf("budget must not be negative")
+	}
+	switch config.Policy {
+	case "":
+		config.Policy = BudgetPolicyPartial
+	case BudgetPolicyPartial, BudgetPolicySkip:
+	default:
+		return nil, fmt.Errorf("unknown budget policy: %s", config.Policy)
+	}
+	if logger == nil {
+		logger = observability.NewLogger("rerank.budget")
+	}
+	if metrics == nil {
+		metrics = observability.NewMetricsClient()
+	}
+
+	return &BudgetedReranker{
+		reranker:  reranker,
+		config:    config,
+		logger:    logger,
+		metrics:   metrics,
+		perResult: config.LatencyPerResult,
+	}, nil
+}
+
+// Rerank implements Reranker
+func (b *BudgetedReranker) Rerank(ctx context.Context, query string, results []SearchResult, opts *RerankOptions) ([]SearchResult, error) {
+	reranked, _, err := b.RerankWithBudget(ctx, query, results, opts)
+	return reranked, err
+}
+
+// RerankWithBudget reranks the results within the budget, reporting whether all,
+// some or none of them were reranked. Reranks that overrun the budget anyway are
+// abandoned and the results returned in their original order.
+func (b *BudgetedReranker) RerankWithBudget(ctx context.Context, query string, results []SearchResult, opts *RerankOptions) ([]SearchResult, BudgetResult, error) {
+	outcome := BudgetResult{Outcome: BudgetOutcomeFull, Total: len(results)}
+	if len(results) == 0 {
+		return results, outcome, nil
+	}
+
+	count := len(results)
+	perResult := b.estimate()
+	if b.config.Budget > 0 && perResult > 0 {
+		if fits := int(b.config.Budget / perResult); fits < count {
+			count = fits
+			if b.config.Policy == BudgetPolicySkip {
+				count = 0
+			}
+		}
+	}
+	outcome.EstimatedLatency = perResult * time.Duration(count)
+
+	if count == 0 {
+		return b.skip(results, opts), b.skipped(outcome), nil
+	}
+
+	head := results[:count]
+	headOpts := RerankOptions{}
+	if opts != nil {
+		headOpts = *opts
+	}
+	// Results after the head are appended, so the head is reranked in full
+	headOpts.TopK = 0
+
+	rerankCtx := ctx
+	if b.config.Budget > 0 {
+		var cancel context.CancelFunc
+		rerankCtx, cancel = context.WithTimeout(ctx, b.config.Budget)
+		defer cancel()
+	}
+
+	start := time.Now()
+	reranked, err := b.reranker.Rerank(rerankCtx, query, head, &headOpts)
+	b.observe(time.Since(start), count)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			b.logger.Warn("Reranking exceeded its latency budget", map[string]interface{}{
+				"reranker": b.reranker.GetName(),
+				"budget":   b.config.Budget.String(),
+				"results":  count,
+			})
+			return b.skip(results, opts), b.skipped(outcome), nil
+		}
+		return nil, outcome, err
+	}
+
+	outcome.Reranked = count
+	if count < len(results) {
+		outcome.Outcome = BudgetOutcomePartial
+		reranked = append(reranked, results[count:]...)
+		b.metrics.IncrementCounter("rerank.budget.partial", 1.0)
+	}
+
+	if opts != nil && opts.TopK > 0 && opts.TopK < len(reranked) {
+		reranked = reranked[:opts.TopK]
+	}
+	return reranked, outcome, nil
+}
+
+// skip returns the results in their original order, limited to the top K
+func (b *BudgetedReranker) skip(results []SearchResult, opts *RerankOptions) []SearchResult {
+	if opts != nil && opts.TopK > 0 && opts.TopK < len(results) {
+		return results[:opts.TopK]
+	}
+	return results
+}
+
+// skipped records that reranking was skipped
+func (b *BudgetedReranker) skipped(outcome BudgetResult) BudgetResult {
+	b.metrics.IncrementCounter("rerank.budget.skipped", 1.0)
+	outcome.Outcome = BudgetOutcomeSkipped
+	outcome.Reranked = 0
+	return outcome
+}
+
+// estimate returns the current per-result latency estimate
+func (b *BudgetedReranker) estimate() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.perResult
+}
+
+// observe refines the per-result latency estimate from a rerank of count results
+func (b *BudgetedReranker) observe(elapsed time.Duration, count int) {
+	observed := elapsed / time.Duration(count)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.perResult == 0 {
+		b.perResult = observed
+		return
+	}
+	b.perResult = time.Duration(budgetEstimateWeight*float64(observed) + (1-budgetEstimateWeight)*float64(b.perResult))
+}
+
+// GetName returns the name of the wrapped reranker
+func (b *BudgetedReranker) GetName() string {
+	return b.reranker.GetName()
+}
+
+// Close closes the wrapped reranker
+func (b *BudgetedReranker) Close() error {
+	return b.reranker.Close()
+}
@@ -0,0 +1,156 @@
+package rerank
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowReranker takes a fixed time per result and reverses their order
+type slowReranker struct {
+	perResult time.Duration
+	calls     []int // How many results each call reranked
+}
+
+func (s *slowReranker) Rerank(ctx context.Context, query string, results []SearchResult, opts *RerankOptions) ([]SearchResult, error) {
+	s.calls = append(s.calls, len(results))
+	select {
+	case <-time.After(s.perResult * time.Duration(len(results))):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	reranked := make([]SearchResult, len(results))
+	for i, result := range results {
+		result.Score = float32(i + 1)
+		reranked[i] = result
+	}
+	sort.Slice(reranked, func(i, j int) bool { return reranked[i].Score > reranked[j].Score })
+	return reranked, nil
+}
+
+func (s *slowReranker) GetName() string { return "slow" }
+func (s *slowReranker) Close() error    { return nil }
+
+func budgetTestResults(n int) []SearchResult {
+	results := make([]SearchResult, n)
+	for i := range results {
+		results[i] = SearchResult{ID: fmt.Sprintf("doc-%d", i), Score: float32(n - i)}
+	}
+	return results
+}
+
+func resultIDs(results []SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	return ids
+}
+
+func newTestBudgetedReranker(t *testing.T, reranker Reranker, config BudgetConfig) *BudgetedReranker {
+	t.Helper()
+	budgeted, err := NewBudgetedReranker(reranker, config, observability.NewNoopLogger(), observability.NewNoOpMetricsClient())
+	require.NoError(t, err)
+	return budgeted
+}
+
+func TestBudgetedReranker(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("reranks everything that fits the budget", func(t *testing.T) {
+		slow := &slowReranker{perResult: time.Millisecond}
+		budgeted := newTestBudgetedReranker(t, slow, BudgetConfig{
+			Budget:           time.Second,
+			LatencyPerResult: time.Millisecond,
+		})
+
+		reranked, outcome, err := budgeted.RerankWithBudget(ctx, "query", budgetTestResults(4), nil)
+		require.NoError(t, err)
+		assert.Equal(t, BudgetOutcomeFull, outcome.Outcome)
+		assert.Equal(t, 4, outcome.Reranked)
+		assert.Equal(t, []string{"doc-3", "doc-2", "doc-1", "doc-0"}, resultIDs(reranked))
+	})
+
+	t.Run("reranks only the top results within the budget", func(t *testing.T) {
+		slow := &slowReranker{perResult: 10 * time.Millisecond}
+		budgeted := newTestBudgetedReranker(t, slow, BudgetConfig{
+			Budget:           45 * time.Millisecond,
+			LatencyPerResult: 10 * time.Millisecond,
+		})
+
+		start := time.Now()
+		reranked, outcome, err := budgeted.RerankWithBudget(ctx, "query", budgetTestResults(10), &RerankOptions{TopK: 6})
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 200*time.Millisecond)
+
+		assert.Equal(t, []int{4}, slow.calls)
+		assert.Equal(t, BudgetOutcomePartial, outcome.Outcome)
+		assert.Equal(t, 4, outcome.Reranked)
+		assert.Equal(t, 10, outcome.Total)
+		// The top 4 are reranked and the rest follow in their original order
+		assert.Equal(t, []string{"doc-3", "doc-2", "doc-1", "doc-0", "doc-4", "doc-5"}, resultIDs(reranked))
+	})
+
+	t.Run("skips reranking under the skip policy", func(t *testing.T) {
+		slow := &slowReranker{perResult: 10 * time.Millisecond}
+		budgeted := newTestBudgetedReranker(t, slow, BudgetConfig{
+			Budget:           45 * time.Millisecond,
+			Policy:           BudgetPolicySkip,
+			LatencyPerResult: 10 * time.Millisecond,
+		})
+
+		reranked, outcome, err := budgeted.RerankWithBudget(ctx, "query", budgetTestResults(10), &RerankOptions{TopK: 3})
+		require.NoError(t, err)
+		assert.Empty(t, slow.calls)
+		assert.Equal(t, BudgetOutcomeSkipped, outcome.Outcome)
+		assert.Equal(t, []string{"doc-0", "doc-1", "doc-2"}, resultIDs(reranked))
+	})
+
+	t.Run("learns the latency from observed reranks", func(t *testing.T) {
+		slow := &slowReranker{perResult: 10 * time.Millisecond}
+		budgeted := newTestBudgetedReranker(t, slow, BudgetConfig{Budget: 45 * time.Millisecond})
+
+		// Without an estimate the first rerank can't be budgeted
+		_, outcome, err := budgeted.RerankWithBudget(ctx, "query", budgetTestResults(3), nil)
+		require.NoError(t, err)
+		assert.Equal(t, BudgetOutcomeFull, outcome.Outcome)
+
+		_, outcome, err = budgeted.RerankWithBudget(ctx, "query", budgetTestResults(10), nil)
+		require.NoError(t, err)
+		assert.Equal(t, BudgetOutcomePartial, outcome.Outcome)
+		assert.LessOrEqual(t, outcome.Reranked, 4)
+		assert.Equal(t, []int{3, outcome.Reranked}, slow.calls)
+	})
+
+	t.Run("abandons reranks that overrun the budget", func(t *testing.T) {
+		slow := &slowReranker{perResult: 50 * time.Millisecond}
+		budgeted := newTestBudgetedReranker(t, slow, BudgetConfig{
+			Budget:           30 * time.Millisecond,
+			LatencyPerResult: time.Millisecond,
+		})
+
+		results := budgetTestResults(5)
+		reranked, outcome, err := budgeted.RerankWithBudget(ctx, "query", results, nil)
+		require.NoError(t, err)
+		assert.Equal(t, BudgetOutcomeSkipped, outcome.Outcome)
+		assert.Equal(t, resultIDs(results), resultIDs(reranked))
+	})
+}
+
+func TestNewBudgetedRerankerValidation(t *testing.T) {
+	_, err := NewBudgetedReranker(nil, BudgetConfig{}, nil, nil)
+	assert.Error(t, err)
+
+	_, err = NewBudgetedReranker(&slowReranker{}, BudgetConfig{Policy: "truncate"}, nil, nil)
+	assert.Error(t, err)
+
+	_, err = NewBudgetedReranker(&slowReranker{}, BudgetConfig{Budget: -time.Second}, nil, nil)
+	assert.Error(t, err)
+}
@@ -27,6 +27,7 @@ type CrossEncoderReranker struct {
 // CrossEncoderConfig configures the cross-encoder reranker
 type CrossEncoderConfig struct {
 	Model              string
+	ModelURL           string // Endpoint of a local cross-encoder model server (see NewLocalCrossEncoderReranker)
 	BatchSize          int
 	MaxConcurrency     int
 	TimeoutPerBatch    time.Duration
@@ -0,0 +1,139 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// LocalCrossEncoderProvider implements providers.RerankProvider against a local
+// cross-encoder model server (e.g. a sentence-transformers REST server)
+type LocalCrossEncoderProvider struct {
+	modelURL   string
+	model      string
+	httpClient *http.Client
+}
+
+// localCrossEncoderRequest is the payload sent to the model server
+type localCrossEncoderRequest struct {
+	Model string      `json:"model,omitempty"`
+	Pairs [][2]string `json:"pairs"`
+}
+
+// localCrossEncoderResponse is the payload returned by the model server
+type localCrossEncoderResponse struct {
+	Scores []float64 `json:"scores"`
+}
+
+// NewLocalCrossEncoderProvider creates a provider that scores query-document pairs via HTTP
+func NewLocalCrossEncoderProvider(modelURL, model string, timeout time.Duration) (*LocalCrossEncoderProvider, error) {
+	if modelURL == "" {
+		return nil, fmt.Errorf("model URL is required")
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &LocalCrossEncoderProvider{
+		modelURL:   modelURL,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Rerank scores each document against the query and returns the scores by index
+func (p *LocalCrossEncoderProvider) Rerank(ctx context.Context, req providers.RerankRequest) (*providers.RerankResponse, error) {
+	pairs := make([][2]string, len(req.Documents))
+	for i, doc := range req.Documents {
+		pairs[i] = [2]string{req.Query, doc}
+	}
+
+	body, err := json.Marshal(localCrossEncoderRequest{Model: req.Model, Pairs: pairs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal rerank request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.modelURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rerank request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("cross-encoder request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cross-encoder response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cross-encoder server returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var scored localCrossEncoderResponse
+	if err := json.Unmarshal(respBody, &scored); err != nil {
+		return nil, fmt.Errorf("failed to decode cross-encoder response: %w", err)
+	}
+
+	if len(scored.Scores) != len(req.Documents) {
+		return nil, fmt.Errorf("cross-encoder returned %d scores for %d documents", len(scored.Scores), len(req.Documents))
+	}
+
+	results := make([]providers.RerankResult, len(scored.Scores))
+	for i, score := range scored.Scores {
+		results[i] = providers.RerankResult{
+			Index:    i,
+			Score:    score,
+			Document: req.Documents[i],
+		}
+	}
+
+	return &providers.RerankResponse{
+		Results: results,
+		Model:   req.Model,
+	}, nil
+}
+
+// GetRerankModels returns the configured model
+func (p *LocalCrossEncoderProvider) GetRerankModels() []string {
+	return []string{p.model}
+}
+
+// SupportsReranking indicates this provider supports reranking
+func (p *LocalCrossEncoderProvider) SupportsReranking() bool {
+	return true
+}
+
+// NewLocalCrossEncoderReranker creates a CrossEncoderReranker backed by a local
+// model server at config.ModelURL. Pairs are sent in batches of config.BatchSize,
+// each bounded by config.TimeoutPerBatch and retried with exponential backoff.
+func NewLocalCrossEncoderReranker(
+	config *CrossEncoderConfig,
+	logger observability.Logger,
+	metrics observability.MetricsClient,
+) (*CrossEncoderReranker, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is required")
+	}
+	if config.Model == "" {
+		config.Model = "local-cross-encoder"
+	}
+
+	provider, err := NewLocalCrossEncoderProvider(config.ModelURL, config.Model, config.TimeoutPerBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCrossEncoderReranker(provider, config, logger, metrics)
+}
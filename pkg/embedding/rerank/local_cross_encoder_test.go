@@ -0,0 +1,85 @@
+package rerank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCrossEncoderServer returns a test model server that scores documents from a fixed table
+func newCrossEncoderServer(t *testing.T, scores map[string]float64, failures int32, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		if n <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var req localCrossEncoderRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := localCrossEncoderResponse{Scores: make([]float64, len(req.Pairs))}
+		for i, pair := range req.Pairs {
+			assert.Equal(t, "query", pair[0])
+			resp.Scores[i] = scores[pair[1]]
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestLocalCrossEncoderReranker(t *testing.T) {
+	scores := map[string]float64{"a": 0.1, "b": 0.9, "c": 0.5}
+	results := []SearchResult{
+		{ID: "1", Content: "a", Score: 0.9},
+		{ID: "2", Content: "b", Score: 0.5},
+		{ID: "3", Content: "c", Score: 0.1},
+	}
+
+	t.Run("scores in batches and sorts", func(t *testing.T) {
+		var calls int32
+		server := newCrossEncoderServer(t, scores, 0, &calls)
+		defer server.Close()
+
+		reranker, err := NewLocalCrossEncoderReranker(&CrossEncoderConfig{
+			ModelURL:  server.URL,
+			BatchSize: 2,
+		}, observability.NewLogger("test"), observability.NewMetricsClient())
+		require.NoError(t, err)
+
+		reranked, err := reranker.Rerank(context.Background(), "query", results, nil)
+		require.NoError(t, err)
+		require.Len(t, reranked, 3)
+
+		assert.Equal(t, []string{"2", "3", "1"}, []string{reranked[0].ID, reranked[1].ID, reranked[2].ID})
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "three results in batches of two")
+	})
+
+	t.Run("retries transient failures", func(t *testing.T) {
+		var calls int32
+		server := newCrossEncoderServer(t, scores, 1, &calls)
+		defer server.Close()
+
+		reranker, err := NewLocalCrossEncoderReranker(&CrossEncoderConfig{
+			ModelURL:  server.URL,
+			BatchSize: 10,
+		}, observability.NewLogger("test"), observability.NewMetricsClient())
+		require.NoError(t, err)
+
+		reranked, err := reranker.Rerank(context.Background(), "query", results, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "2", reranked[0].ID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("requires model URL", func(t *testing.T) {
+		_, err := NewLocalCrossEncoderReranker(&CrossEncoderConfig{}, nil, nil)
+		assert.Error(t, err)
+	})
+}
@@ -23,13 +23,21 @@ type Reranker interface {
 	Close() error
 }
 
+// Rerank modes, as RerankOptions.Mode
+const (
+	ModeCrossEncoder = "cross-encoder" // Re-score results against the query with a model
+	ModeMMR          = "mmr"           // Reorder results for diversity by Maximal Marginal Relevance
+)
+
 // RerankOptions configures reranking behavior
 type RerankOptions struct {
-	TopK            int     // Return top K results
-	Model           string  // Model to use for reranking
-	IncludeScores   bool    // Include scores in metadata
-	DiversityFactor float64 // For MMR diversity (0-1)
-	MaxConcurrency  int     // Max concurrent operations
+	TopK            int      // Return top K results
+	Model           string   // Model to use for reranking
+	IncludeScores   bool     // Include scores in metadata
+	DiversityFactor float64  // For MMR diversity (0-1)
+	MaxConcurrency  int      // Max concurrent operations
+	Mode            string   // ModeCrossEncoder (default) or ModeMMR
+	MMRLambda       *float64 // Relevance weight for ModeMMR (0-1); nil uses the reranker's own
 }
 
 // MultiStageReranker applies multiple rerankers in sequence
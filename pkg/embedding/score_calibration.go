@@ -0,0 +1,184 @@
+package embedding
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// RelevanceFeedback is a single relevance judgement on a search result
+type RelevanceFeedback struct {
+	Model     string    `json:"model"`
+	Score     float64   `json:"score"` // Normalized similarity the result was ranked with
+	Relevant  bool      `json:"relevant"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ScoreCalibrationConfig configures feedback-driven model quality calibration
+type ScoreCalibrationConfig struct {
+	// Interval between automatic recalibrations
+	Interval time.Duration `json:"interval"`
+	// MinSamples is the minimum feedback count before a model is recalibrated
+	MinSamples int `json:"min_samples"`
+	// MaxSamples bounds the feedback retained per model (oldest dropped first)
+	MaxSamples int `json:"max_samples"`
+	// MinMultiplier and MaxMultiplier bound the calibration multiplier
+	MinMultiplier float64 `json:"min_multiplier"`
+	MaxMultiplier float64 `json:"max_multiplier"`
+	// MaxStep bounds how far a multiplier may move in a single recalibration
+	MaxStep float64 `json:"max_step"`
+}
+
+// DefaultScoreCalibrationConfig returns default calibration configuration
+func DefaultScoreCalibrationConfig() *ScoreCalibrationConfig {
+	return &ScoreCalibrationConfig{
+		Interval:      15 * time.Minute,
+		MinSamples:    20,
+		MaxSamples:    1000,
+		MinMultiplier: 0.8,
+		MaxMultiplier: 1.2,
+		MaxStep:       0.05,
+	}
+}
+
+// ScoreCalibrator recomputes per-model quality multipliers from accumulated
+// relevance feedback. A model whose results are judged relevant more often
+// than their scores predict is adjusted upward, and vice versa.
+type ScoreCalibrator struct {
+	config      *ScoreCalibrationConfig
+	feedback    map[string][]RelevanceFeedback
+	multipliers map[string]float64
+	logger      observability.Logger
+	mu          sync.RWMutex
+}
+
+// NewScoreCalibrator creates a new score calibrator
+func NewScoreCalibrator(config *ScoreCalibrationConfig, logger observability.Logger) *ScoreCalibrator {
+	defaults := DefaultScoreCalibrationConfig()
+	if config == nil {
+		config = defaults
+	}
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.MinSamples <= 0 {
+		config.MinSamples = defaults.MinSamples
+	}
+	if config.MaxSamples <= 0 {
+		config.MaxSamples = defaults.MaxSamples
+	}
+	if config.MinMultiplier <= 0 || config.MinMultiplier > 1 {
+		config.MinMultiplier = defaults.MinMultiplier
+	}
+	if config.MaxMultiplier < 1 {
+		config.MaxMultiplier = defaults.MaxMultiplier
+	}
+	if config.MaxStep <= 0 {
+		config.MaxStep = defaults.MaxStep
+	}
+	if logger == nil {
+		logger = observability.NewLogger("embedding.calibration")
+	}
+
+	return &ScoreCalibrator{
+		config:      config,
+		feedback:    make(map[string][]RelevanceFeedback),
+		multipliers: make(map[string]float64),
+		logger:      logger,
+	}
+}
+
+// RecordFeedback adds a relevance judgement for a model
+func (c *ScoreCalibrator) RecordFeedback(feedback RelevanceFeedback) {
+	if feedback.Model == "" {
+		return
+	}
+	if feedback.Timestamp.IsZero() {
+		feedback.Timestamp = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	samples := append(c.feedback[feedback.Model], feedback)
+	if len(samples) > c.config.MaxSamples {
+		samples = samples[len(samples)-c.config.MaxSamples:]
+	}
+	c.feedback[feedback.Model] = samples
+}
+
+// Multiplier returns the current calibration multiplier for a model (1.0 when uncalibrated)
+func (c *ScoreCalibrator) Multiplier(model string) float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if m, ok := c.multipliers[model]; ok {
+		return m
+	}
+	return 1.0
+}
+
+// Recalibrate recomputes multipliers for every model with enough feedback.
+// Each multiplier moves toward observed precision / predicted score, limited
+// to MaxStep per call and clamped to [MinMultiplier, MaxMultiplier].
+func (c *ScoreCalibrator) Recalibrate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for model, samples := range c.feedback {
+		if len(samples) < c.config.MinSamples {
+			continue
+		}
+
+		var relevant, predicted float64
+		for _, sample := range samples {
+			if sample.Relevant {
+				relevant++
+			}
+			predicted += math.Min(1.0, math.Max(0.0, sample.Score))
+		}
+		observed := relevant / float64(len(samples))
+		predicted /= float64(len(samples))
+		if predicted == 0 {
+			continue
+		}
+
+		current := 1.0
+		if m, ok := c.multipliers[model]; ok {
+			current = m
+		}
+
+		target := observed / predicted
+		step := math.Max(-c.config.MaxStep, math.Min(c.config.MaxStep, target-current))
+		updated := math.Max(c.config.MinMultiplier, math.Min(c.config.MaxMultiplier, current+step))
+		c.multipliers[model] = updated
+
+		c.logger.Debug("Recalibrated model quality multiplier", map[string]interface{}{
+			"model":              model,
+			"samples":            len(samples),
+			"observed_precision": observed,
+			"predicted_score":    predicted,
+			"previous":           current,
+			"multiplier":         updated,
+		})
+	}
+}
+
+// Start recalibrates periodically until the context is cancelled
+func (c *ScoreCalibrator) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.config.Interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Recalibrate()
+			}
+		}
+	}()
+}
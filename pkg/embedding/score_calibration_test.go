@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCalibrator() *ScoreCalibrator {
+	return NewScoreCalibrator(&ScoreCalibrationConfig{
+		MinSamples:    10,
+		MinMultiplier: 0.8,
+		MaxMultiplier: 1.2,
+		MaxStep:       0.05,
+	}, nil)
+}
+
+func recordFeedback(c *ScoreCalibrator, model string, score float64, relevant, total int) {
+	for i := 0; i < total; i++ {
+		c.RecordFeedback(RelevanceFeedback{Model: model, Score: score, Relevant: i < relevant})
+	}
+}
+
+func TestScoreCalibratorOverperformingModel(t *testing.T) {
+	c := newTestCalibrator()
+	// Scored around 0.5 but nearly always relevant
+	recordFeedback(c, "voyage-code-2", 0.5, 19, 20)
+
+	c.Recalibrate()
+	assert.InDelta(t, 1.05, c.Multiplier("voyage-code-2"), 1e-9, "single step is bounded")
+
+	for i := 0; i < 20; i++ {
+		c.Recalibrate()
+	}
+	assert.InDelta(t, 1.2, c.Multiplier("voyage-code-2"), 1e-9, "clamped to max multiplier")
+}
+
+func TestScoreCalibratorUnderperformingModel(t *testing.T) {
+	c := newTestCalibrator()
+	// Scored highly but rarely relevant
+	recordFeedback(c, "text-embedding-ada-002", 0.9, 2, 20)
+
+	c.Recalibrate()
+	assert.InDelta(t, 0.95, c.Multiplier("text-embedding-ada-002"), 1e-9)
+
+	for i := 0; i < 20; i++ {
+		c.Recalibrate()
+	}
+	assert.InDelta(t, 0.8, c.Multiplier("text-embedding-ada-002"), 1e-9, "clamped to min multiplier")
+}
+
+func TestScoreCalibratorRequiresMinSamples(t *testing.T) {
+	c := newTestCalibrator()
+	recordFeedback(c, "voyage-2", 0.5, 5, 5)
+
+	c.Recalibrate()
+	assert.Equal(t, 1.0, c.Multiplier("voyage-2"))
+	assert.Equal(t, 1.0, c.Multiplier("unknown-model"))
+}
+
+func TestModelQualityScoreUsesCalibration(t *testing.T) {
+	c := newTestCalibrator()
+	recordFeedback(c, "text-embedding-ada-002", 0.9, 2, 20)
+	c.Recalibrate()
+
+	s := &UnifiedSearchService{calibrator: c}
+	assert.InDelta(t, 0.85*0.95, s.getModelQualityScore("text-embedding-ada-002"), 1e-9)
+
+	uncalibrated := &UnifiedSearchService{}
+	assert.InDelta(t, 0.85, uncalibrated.getModelQualityScore("text-embedding-ada-002"), 1e-9)
+}
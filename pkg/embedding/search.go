@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/rerank"
 )
 
 // SearchFilter defines a filter for metadata fields
@@ -47,12 +49,32 @@ type SearchOptions struct {
 	RerankModel string `json:"rerank_model,omitempty"`
 	// RerankQuery allows overriding the query used for reranking
 	RerankQuery string `json:"rerank_query,omitempty"`
+	// RerankMode is rerank.ModeCrossEncoder (the default), which uses the
+	// configured reranker, or rerank.ModeMMR, which diversifies results
+	RerankMode string `json:"rerank_mode,omitempty"`
+	// MMRLambda weighs relevance against diversity in MMR mode, from 0 (most
+	// diverse) to 1 (plain relevance order); nil uses DefaultMMRLambda
+	MMRLambda *float64 `json:"mmr_lambda,omitempty"`
 	// UseQueryExpansion enables query expansion
 	UseQueryExpansion bool `json:"use_query_expansion,omitempty"`
 	// QueryExpansionTypes specifies which expansion types to use
 	QueryExpansionTypes []string `json:"query_expansion_types,omitempty"`
 	// MaxExpansions limits the number of query expansions
 	MaxExpansions int `json:"max_expansions,omitempty"`
+	// Explain attaches a ScoreBreakdown to each result's Matches
+	Explain bool `json:"explain,omitempty"`
+	// Facets are fields to count results by, such as content_type or a metadata
+	// key like repository. Counts are returned in SearchResults.Facets.
+	Facets []string `json:"facets,omitempty"`
+	// ModelID embeds the query with this model instead of the default one, for
+	// corpora known to be embedded with it. Unknown models are rejected with
+	// ErrUnknownSearchModel.
+	ModelID string `json:"model_id,omitempty"`
+	// UseANNIndex searches through the HNSW index built by EnsureHNSWIndex,
+	// trading exact ranking for latency on large corpora
+	UseANNIndex bool `json:"use_ann_index,omitempty"`
+	// HNSW tunes ANN searches; nil keeps the server's settings
+	HNSW *HNSWSearchOptions `json:"hnsw,omitempty"`
 }
 
 // SearchResult represents a single search result
@@ -73,6 +95,13 @@ type SearchResults struct {
 	Total int `json:"total"`
 	// HasMore indicates if there are more results available
 	HasMore bool `json:"has_more"`
+	// RerankBudget reports how many results were reranked within the rerank
+	// latency budget, when one is configured
+	RerankBudget *rerank.BudgetResult `json:"rerank_budget,omitempty"`
+	// Facets are the result counts per value of each requested facet field.
+	// They count every result the search retrieved: the returned page, or the
+	// whole cached result set when result set caching is enabled.
+	Facets map[string][]FacetCount `json:"facets,omitempty"`
 }
 
 // SearchService defines the interface for vector search operations
@@ -165,6 +194,9 @@ type HybridSearchRequest struct {
 	Options *SearchOptions `json:"options,omitempty"`
 	// QueryEmbedding allows pre-computed embedding to be passed
 	QueryEmbedding []float32 `json:"query_embedding,omitempty"`
+	// RouteByIntent chooses keyword, semantic or hybrid search from the query's
+	// intent instead of always running both
+	RouteByIntent bool `json:"route_by_intent,omitempty"`
 }
 
 // HybridSearchResult represents a result from hybrid search
@@ -179,6 +211,10 @@ type HybridSearchResult struct {
 	KeywordScore float32 `json:"keyword_score"`
 	// HybridScore is the combined score
 	HybridScore float32 `json:"hybrid_score"`
+	// Intent is the query's classified intent when the search was routed by intent
+	Intent QueryIntent `json:"intent,omitempty"`
+	// Strategy is how the search was executed
+	Strategy SearchStrategy `json:"strategy,omitempty"`
 }
 
 // AdvancedSearchService extends SearchService with cross-model and hybrid search capabilities
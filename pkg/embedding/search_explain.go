@@ -0,0 +1,61 @@
+package embedding
+
+// ScoreBreakdownKey is the SearchResult.Matches key holding a *ScoreBreakdown
+// when SearchOptions.Explain is set
+const ScoreBreakdownKey = "score_breakdown"
+
+// ScoreBreakdown explains how a search result's score was computed.
+// Components are additive: FinalScore is the sum of RawSimilarity and every
+// contribution. Stages that did not run for a search contribute zero.
+type ScoreBreakdown struct {
+	// RawSimilarity is the vector similarity reported by the search repository
+	RawSimilarity float32 `json:"raw_similarity"`
+	// ModelQuality is the contribution from embedding model quality weighting
+	ModelQuality float32 `json:"model_quality"`
+	// FieldBoost is the contribution from field boosts
+	FieldBoost float32 `json:"field_boost"`
+	// QueryExpansion is the contribution from weighting and merging expanded queries
+	QueryExpansion float32 `json:"query_expansion"`
+	// RerankDelta is the change in score applied by the reranker
+	RerankDelta float32 `json:"rerank_delta"`
+	// FinalScore is the score of the result
+	FinalScore float32 `json:"final_score"`
+}
+
+// GetScoreBreakdown returns the score breakdown attached to a result, or nil if
+// the search was not run with Explain
+func (r *SearchResult) GetScoreBreakdown() *ScoreBreakdown {
+	if r == nil || r.Matches == nil {
+		return nil
+	}
+	breakdown, _ := r.Matches[ScoreBreakdownKey].(*ScoreBreakdown)
+	return breakdown
+}
+
+// attachScoreBreakdowns starts a breakdown for each result from its raw similarity
+func attachScoreBreakdowns(results *SearchResults) {
+	for _, result := range results.Results {
+		if result == nil {
+			continue
+		}
+		if result.Matches == nil {
+			result.Matches = make(map[string]interface{})
+		}
+		result.Matches[ScoreBreakdownKey] = &ScoreBreakdown{
+			RawSimilarity: result.Score,
+			FinalScore:    result.Score,
+		}
+	}
+}
+
+// recordScoreChange attributes a change to a result's score to one breakdown
+// component. It is a no-op for results without a breakdown.
+func recordScoreChange(result *SearchResult, component func(*ScoreBreakdown) *float32, newScore float32) {
+	if breakdown := result.GetScoreBreakdown(); breakdown != nil {
+		*component(breakdown) += newScore - breakdown.FinalScore
+		breakdown.FinalScore = newScore
+	}
+}
+
+func rerankComponent(b *ScoreBreakdown) *float32         { return &b.RerankDelta }
+func queryExpansionComponent(b *ScoreBreakdown) *float32 { return &b.QueryExpansion }
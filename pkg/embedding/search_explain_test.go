@@ -0,0 +1,90 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/rerank"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+// stubSearchRepository returns fixed vector search results
+type stubSearchRepository struct {
+	repositorySearch.Repository
+	results []*repositorySearch.SearchResult
+}
+
+func (r *stubSearchRepository) SearchByVector(ctx context.Context, vector []float32, options *repositorySearch.SearchOptions) (*repositorySearch.SearchResults, error) {
+	return &repositorySearch.SearchResults{Results: r.results, Total: len(r.results)}, nil
+}
+
+// boostingReranker adds a fixed amount to every score
+type boostingReranker struct {
+	boost float32
+}
+
+func (r *boostingReranker) Rerank(ctx context.Context, query string, results []rerank.SearchResult, opts *rerank.RerankOptions) ([]rerank.SearchResult, error) {
+	reranked := make([]rerank.SearchResult, len(results))
+	for i, result := range results {
+		result.Score += r.boost
+		reranked[i] = result
+	}
+	return reranked, nil
+}
+
+func (r *boostingReranker) GetName() string { return "boosting" }
+func (r *boostingReranker) Close() error    { return nil }
+
+func newExplainTestService() *UnifiedSearchService {
+	return &UnifiedSearchService{
+		searchRepository: &stubSearchRepository{results: []*repositorySearch.SearchResult{
+			{ID: "doc-1", Score: 0.72},
+			{ID: "doc-2", Score: 0.65},
+		}},
+		embeddingService: &MockEmbeddingServiceForTests{MockVectors: map[string]*EmbeddingVector{}},
+		reranker:         &boostingReranker{boost: 0.1},
+		logger:           observability.NewNoopLogger(),
+		metrics:          observability.NewNoOpMetricsClient(),
+	}
+}
+
+func sumScoreBreakdown(b *ScoreBreakdown) float32 {
+	return b.RawSimilarity + b.ModelQuality + b.FieldBoost + b.QueryExpansion + b.RerankDelta
+}
+
+func TestSearchExplainBreakdownSumsToFinalScore(t *testing.T) {
+	service := newExplainTestService()
+
+	results, err := service.Search(context.Background(), "query", &SearchOptions{
+		Limit:        10,
+		UseReranking: true,
+		Explain:      true,
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+
+	result := results.Results[0]
+	breakdown := result.GetScoreBreakdown()
+	require.NotNil(t, breakdown)
+
+	assert.InDelta(t, 0.72, breakdown.RawSimilarity, 1e-6)
+	assert.InDelta(t, 0.1, breakdown.RerankDelta, 1e-6)
+	assert.InDelta(t, result.Score, breakdown.FinalScore, 1e-6)
+	assert.InDelta(t, breakdown.FinalScore, sumScoreBreakdown(breakdown), 1e-6)
+}
+
+func TestSearchWithoutExplainOmitsBreakdown(t *testing.T) {
+	service := newExplainTestService()
+
+	results, err := service.Search(context.Background(), "query", &SearchOptions{Limit: 10, UseReranking: true})
+	require.NoError(t, err)
+
+	for _, result := range results.Results {
+		assert.Nil(t, result.GetScoreBreakdown())
+		assert.NotContains(t, result.Matches, ScoreBreakdownKey)
+	}
+}
@@ -0,0 +1,97 @@
+package embedding
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FacetCount is the number of search results with one value of a facet field
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// facetValues returns the values of a facet field for a result. content_type
+// and model_id are read from the embedding, and any other field, with or
+// without a "metadata." prefix, from its metadata. A list-valued field, such
+// as tags, has one value per element.
+func facetValues(result *SearchResult, field string) []string {
+	if result == nil || result.Content == nil {
+		return nil
+	}
+
+	switch field {
+	case "content_type":
+		return nonEmpty(result.Content.ContentType)
+	case "model_id":
+		return nonEmpty(result.Content.ModelID)
+	}
+
+	value, ok := result.Content.Metadata[strings.TrimPrefix(field, "metadata.")]
+	if !ok || value == nil {
+		return nil
+	}
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				values = append(values, fmt.Sprint(item))
+			}
+		}
+		return values
+	default:
+		return nonEmpty(fmt.Sprint(v))
+	}
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// computeFacets counts results per value of each facet field. Counts are
+// sorted by count, then value, and results without a field aren't counted
+// for it.
+func computeFacets(results []*SearchResult, fields []string) map[string][]FacetCount {
+	facets := make(map[string][]FacetCount, len(fields))
+	for _, field := range fields {
+		counts := make(map[string]int)
+		for _, result := range results {
+			// A result counts once per value even if a list repeats it
+			seen := make(map[string]bool)
+			for _, value := range facetValues(result, field) {
+				if !seen[value] {
+					seen[value] = true
+					counts[value]++
+				}
+			}
+		}
+
+		facet := make([]FacetCount, 0, len(counts))
+		for value, count := range counts {
+			facet = append(facet, FacetCount{Value: value, Count: count})
+		}
+		sort.Slice(facet, func(i, j int) bool {
+			if facet[i].Count != facet[j].Count {
+				return facet[i].Count > facet[j].Count
+			}
+			return facet[i].Value < facet[j].Value
+		})
+		facets[field] = facet
+	}
+	return facets
+}
+
+// attachFacets sets the facet counts of a search's results when requested
+func attachFacets(results *SearchResults, options *SearchOptions) {
+	if results == nil || options == nil || len(options.Facets) == 0 {
+		return
+	}
+	results.Facets = computeFacets(results.Results, options.Facets)
+}
@@ -0,0 +1,107 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+func facetTestResult(contentType string, metadata map[string]interface{}) *SearchResult {
+	return &SearchResult{Content: &EmbeddingVector{ContentType: contentType, Metadata: metadata}}
+}
+
+func TestComputeFacets(t *testing.T) {
+	results := []*SearchResult{
+		facetTestResult("code", map[string]interface{}{"repository": "mesh", "tags": []interface{}{"go", "api"}, "stars": 10}),
+		facetTestResult("code", map[string]interface{}{"repository": "mesh", "tags": []string{"go", "go"}, "stars": 10}),
+		facetTestResult("issue", map[string]interface{}{"repository": "helm-charts", "tags": []interface{}{"api"}}),
+		facetTestResult("docs", map[string]interface{}{"repository": "mesh"}),
+		facetTestResult("", nil),
+		nil,
+	}
+
+	tests := []struct {
+		field string
+		want  []FacetCount
+	}{
+		{field: "content_type", want: []FacetCount{{"code", 2}, {"docs", 1}, {"issue", 1}}},
+		{field: "repository", want: []FacetCount{{"mesh", 3}, {"helm-charts", 1}}},
+		{field: "metadata.repository", want: []FacetCount{{"mesh", 3}, {"helm-charts", 1}}},
+		{field: "tags", want: []FacetCount{{"api", 2}, {"go", 2}}},
+		{field: "stars", want: []FacetCount{{"10", 2}}},
+		{field: "missing", want: []FacetCount{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			facets := computeFacets(results, []string{tt.field})
+			assert.Equal(t, tt.want, facets[tt.field])
+		})
+	}
+}
+
+func TestSearchFacets(t *testing.T) {
+	service := newExplainTestService()
+	service.searchRepository = &stubSearchRepository{results: []*repositorySearch.SearchResult{
+		{ID: "doc-1", Type: "code", Score: 0.9, Metadata: map[string]interface{}{"repository": "mesh"}},
+		{ID: "doc-2", Type: "code", Score: 0.8, Metadata: map[string]interface{}{"repository": "helm-charts"}},
+		{ID: "doc-3", Type: "issue", Score: 0.7, Metadata: map[string]interface{}{"repository": "mesh"}},
+	}}
+
+	results, err := service.Search(context.Background(), "query", &SearchOptions{
+		Limit:        10,
+		UseReranking: true,
+		Facets:       []string{"content_type", "repository"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results.Results, 3)
+
+	assert.Equal(t, map[string][]FacetCount{
+		"content_type": {{"code", 2}, {"issue", 1}},
+		"repository":   {{"mesh", 2}, {"helm-charts", 1}},
+	}, results.Facets)
+
+	t.Run("by vector", func(t *testing.T) {
+		results, err := service.SearchByVector(context.Background(), []float32{0.1}, &SearchOptions{Limit: 10, Facets: []string{"content_type"}})
+		require.NoError(t, err)
+		assert.Equal(t, []FacetCount{{"code", 2}, {"issue", 1}}, results.Facets["content_type"])
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		results, err := service.Search(context.Background(), "query", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Nil(t, results.Facets)
+	})
+}
+
+func TestSearchFacetsCountWholeResultSet(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+	service, searchRepo, _ := newResultSetCacheTestService(t, ResultSetCacheConfig{TTL: time.Minute})
+	for i, result := range searchRepo.results {
+		repository := "mesh"
+		if i%2 == 1 {
+			repository = "helm-charts"
+		}
+		result.Metadata = map[string]interface{}{"repository": repository}
+	}
+
+	want := []FacetCount{{"mesh", 3}, {"helm-charts", 2}}
+	for _, offset := range []int{0, 2} {
+		results, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2, Offset: offset, Facets: []string{"repository"}})
+		require.NoError(t, err)
+		assert.Len(t, results.Results, 2)
+		assert.Equal(t, want, results.Facets["repository"], "facets count every cached result, not just the page")
+	}
+
+	// Requesting facets doesn't split the cached result set
+	_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), searchRepo.queries.Load())
+}
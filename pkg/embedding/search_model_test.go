@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+// namedEmbeddingService is a counting embedding service for a named model
+type namedEmbeddingService struct {
+	countingEmbeddingService
+	name string
+}
+
+func (n *namedEmbeddingService) GetModelConfig() ModelConfig {
+	config := n.countingEmbeddingService.GetModelConfig()
+	config.Name = n.name
+	return config
+}
+
+func TestSearchModelOverride(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	defaultEmbedder := &namedEmbeddingService{name: "text-embedding-3-small"}
+	voyageEmbedder := &namedEmbeddingService{name: "voyage-code-2"}
+	service, err := NewUnifiedSearchService(&UnifiedSearchConfig{
+		DB:               db,
+		SearchRepository: &pagingSearchRepository{results: []*repositorySearch.SearchResult{{ID: "doc-0", Score: 0.9}}},
+		EmbeddingService: defaultEmbedder,
+		ModelServices:    map[string]EmbeddingService{"voyage-code-2": voyageEmbedder},
+		QueryEmbeddings:  &QueryEmbeddingCacheConfig{TTL: time.Minute},
+		Logger:           observability.NewNoopLogger(),
+		Metrics:          observability.NewNoOpMetricsClient(),
+	})
+	require.NoError(t, err)
+
+	t.Run("override embeds the query with the chosen model", func(t *testing.T) {
+		_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10, ModelID: "voyage-code-2"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), voyageEmbedder.generated.Load())
+		assert.Equal(t, int32(0), defaultEmbedder.generated.Load())
+
+		// Cached query embeddings aren't shared across models
+		_, err = service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), defaultEmbedder.generated.Load())
+
+		_, err = service.Search(ctx, "helm upgrade", &SearchOptions{Limit: 10, ModelID: "text-embedding-3-small"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), defaultEmbedder.generated.Load())
+		assert.Equal(t, int32(1), voyageEmbedder.generated.Load())
+	})
+
+	t.Run("unknown model is rejected", func(t *testing.T) {
+		_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 10, ModelID: "unknown-model"})
+		assert.ErrorIs(t, err, ErrUnknownSearchModel)
+		assert.Equal(t, int32(2), defaultEmbedder.generated.Load())
+		assert.Equal(t, int32(1), voyageEmbedder.generated.Load())
+	})
+}
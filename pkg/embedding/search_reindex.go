@@ -0,0 +1,362 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DefaultReindexBatchSize is the number of embeddings re-generated per batch
+const DefaultReindexBatchSize = 100
+
+// ReindexCommand controls a running reindex
+type ReindexCommand string
+
+// Reindex control commands
+const (
+	ReindexPause  ReindexCommand = "pause"
+	ReindexResume ReindexCommand = "resume"
+)
+
+// ReindexOptions configures a reindex run
+type ReindexOptions struct {
+	// TargetModel is the model new embeddings are generated with. It must match the
+	// model of the configured embedding service.
+	TargetModel string
+	// SourceModel limits the reindex to embeddings created by this model. Empty reindexes
+	// embeddings from every model other than TargetModel.
+	SourceModel string
+	// ContentTypes limits the reindex to embeddings with these metadata content types
+	ContentTypes []string
+	// BatchSize is the number of embeddings processed per batch
+	BatchSize int
+	// TenantID scopes the reindex. Defaults to the tenant in the context.
+	TenantID uuid.UUID
+	// Control pauses and resumes the reindex between batches
+	Control <-chan ReindexCommand
+}
+
+// ReindexProgress reports the state of a reindex run
+type ReindexProgress struct {
+	Processed int       `json:"processed"`
+	Skipped   int       `json:"skipped"`
+	Failed    int       `json:"failed"`
+	Batches   int       `json:"batches"`
+	Paused    bool      `json:"paused,omitempty"`
+	Done      bool      `json:"done,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// reindexItem is an existing embedding to re-generate with the target model
+type reindexItem struct {
+	ID           uuid.UUID
+	ContextID    uuid.NullUUID
+	ContentIndex int
+	ChunkIndex   int
+	Content      string
+	ContentHash  string
+	ModelName    string
+	Metadata     map[string]interface{}
+}
+
+// Reindex re-generates existing embeddings with a new model in the background.
+// Progress is streamed on the returned channel, which is closed when the reindex
+// finishes, fails or ctx is cancelled. Source embeddings are left in place so
+// searches keep working until callers switch to the target model.
+func (s *UnifiedSearchService) Reindex(ctx context.Context, opts ReindexOptions) (<-chan ReindexProgress, error) {
+	if opts.TargetModel == "" {
+		return nil, errors.New("target model is required")
+	}
+	if opts.SourceModel == opts.TargetModel {
+		return nil, errors.New("source and target models must differ")
+	}
+	if s.repository == nil {
+		return nil, errors.New("embedding repository is required for reindexing")
+	}
+	if model := s.embeddingService.GetModelConfig().Name; model != opts.TargetModel {
+		return nil, fmt.Errorf("embedding service is configured for model %s, not %s", model, opts.TargetModel)
+	}
+
+	if opts.TenantID == uuid.Nil {
+		opts.TenantID = auth.GetTenantID(ctx)
+	}
+	if opts.TenantID == uuid.Nil {
+		return nil, errors.New("tenant ID is required for reindexing")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultReindexBatchSize
+	}
+
+	progress := make(chan ReindexProgress, 16)
+	go s.runReindex(ctx, opts, progress)
+
+	return progress, nil
+}
+
+func (s *UnifiedSearchService) runReindex(ctx context.Context, opts ReindexOptions, out chan<- ReindexProgress) {
+	defer close(out)
+
+	s.logger.Info("Starting embedding reindex", map[string]interface{}{
+		"tenant_id":     opts.TenantID.String(),
+		"target_model":  opts.TargetModel,
+		"source_model":  opts.SourceModel,
+		"content_types": opts.ContentTypes,
+		"batch_size":    opts.BatchSize,
+	})
+
+	var state ReindexProgress
+	lastID := uuid.Nil
+
+	for {
+		if !s.waitIfPaused(ctx, opts.Control, &state, out) {
+			return
+		}
+
+		items, err := s.loadReindexBatch(ctx, opts, lastID)
+		if err != nil {
+			state.Error = err.Error()
+			break
+		}
+		if len(items) == 0 {
+			break
+		}
+		lastID = items[len(items)-1].ID
+
+		s.reindexBatch(ctx, opts, items, &state)
+		state.Batches++
+
+		if !sendReindexProgress(ctx, out, state) {
+			return
+		}
+		if len(items) < opts.BatchSize {
+			break
+		}
+	}
+
+	state.Done = true
+	s.metrics.IncrementCounter("search.reindex.processed", float64(state.Processed))
+	s.metrics.IncrementCounter("search.reindex.failed", float64(state.Failed))
+	s.logger.Info("Embedding reindex finished", map[string]interface{}{
+		"tenant_id":    opts.TenantID.String(),
+		"target_model": opts.TargetModel,
+		"processed":    state.Processed,
+		"skipped":      state.Skipped,
+		"failed":       state.Failed,
+		"error":        state.Error,
+	})
+	sendReindexProgress(ctx, out, state)
+}
+
+// waitIfPaused applies pending control commands and blocks while paused.
+// It returns false if ctx is cancelled.
+func (s *UnifiedSearchService) waitIfPaused(ctx context.Context, control <-chan ReindexCommand, state *ReindexProgress, out chan<- ReindexProgress) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case cmd := <-control:
+		if cmd != ReindexPause {
+			return true
+		}
+	default:
+		return true
+	}
+
+	state.Paused = true
+	if !sendReindexProgress(ctx, out, *state) {
+		return false
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case cmd, ok := <-control:
+			if !ok || cmd == ReindexResume {
+				state.Paused = false
+				return sendReindexProgress(ctx, out, *state)
+			}
+		}
+	}
+}
+
+func sendReindexProgress(ctx context.Context, out chan<- ReindexProgress, state ReindexProgress) bool {
+	state.Timestamp = time.Now()
+	select {
+	case out <- state:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// loadReindexBatch returns the next batch of embeddings to reindex, ordered by ID
+func (s *UnifiedSearchService) loadReindexBatch(ctx context.Context, opts ReindexOptions, afterID uuid.UUID) ([]reindexItem, error) {
+	query := `
+		SELECT e.id, e.context_id, e.content_index, e.chunk_index, e.content,
+		       e.content_hash, e.model_name, e.metadata
+		FROM mcp.embeddings e
+		WHERE e.tenant_id = $1
+			AND e.deleted_at IS NULL
+			AND e.id > $2
+			AND e.model_name != $3
+	`
+	args := []interface{}{opts.TenantID, afterID, opts.TargetModel}
+
+	if opts.SourceModel != "" {
+		args = append(args, opts.SourceModel)
+		query += fmt.Sprintf(" AND e.model_name = $%d", len(args))
+	}
+	if len(opts.ContentTypes) > 0 {
+		args = append(args, pq.Array(opts.ContentTypes))
+		query += fmt.Sprintf(" AND e.metadata->>'content_type' = ANY($%d)", len(args))
+	}
+
+	args = append(args, opts.BatchSize)
+	query += fmt.Sprintf(" ORDER BY e.id LIMIT $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load embeddings for reindex: %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var items []reindexItem
+	for rows.Next() {
+		var item reindexItem
+		var metadataJSON []byte
+		if err := rows.Scan(
+			&item.ID,
+			&item.ContextID,
+			&item.ContentIndex,
+			&item.ChunkIndex,
+			&item.Content,
+			&item.ContentHash,
+			&item.ModelName,
+			&metadataJSON,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan embedding for reindex: %w", err)
+		}
+		if len(metadataJSON) > 0 {
+			if err := json.Unmarshal(metadataJSON, &item.Metadata); err != nil {
+				item.Metadata = nil
+			}
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating embeddings for reindex: %w", err)
+	}
+
+	return items, nil
+}
+
+// reindexBatch re-generates a batch of embeddings, grouped by content type
+func (s *UnifiedSearchService) reindexBatch(ctx context.Context, opts ReindexOptions, items []reindexItem, state *ReindexProgress) {
+	byContentType := make(map[string][]reindexItem)
+	var contentTypes []string
+
+	for _, item := range items {
+		if item.Content == "" {
+			state.Skipped++
+			continue
+		}
+
+		existing, err := s.repository.GetExistingEmbedding(ctx, item.ContentHash, opts.TargetModel, opts.TenantID)
+		if err != nil {
+			state.Failed++
+			continue
+		}
+		if existing != nil {
+			state.Skipped++
+			continue
+		}
+
+		contentType := "text"
+		if ct, ok := item.Metadata["content_type"].(string); ok && ct != "" {
+			contentType = ct
+		}
+		if _, ok := byContentType[contentType]; !ok {
+			contentTypes = append(contentTypes, contentType)
+		}
+		byContentType[contentType] = append(byContentType[contentType], item)
+	}
+
+	for _, contentType := range contentTypes {
+		group := byContentType[contentType]
+
+		texts := make([]string, len(group))
+		contentIDs := make([]string, len(group))
+		for i, item := range group {
+			texts[i] = item.Content
+			contentIDs[i] = item.ID.String()
+		}
+
+		vectors, err := s.embeddingService.BatchGenerateEmbeddings(ctx, texts, contentType, contentIDs)
+		if err != nil || len(vectors) != len(group) {
+			s.logger.Warn("Failed to generate embeddings during reindex", map[string]interface{}{
+				"content_type": contentType,
+				"count":        len(group),
+				"error":        fmt.Sprint(err),
+			})
+			state.Failed += len(group)
+			continue
+		}
+
+		for i, item := range group {
+			if vectors[i] == nil || len(vectors[i].Vector) == 0 {
+				state.Failed++
+				continue
+			}
+			if err := s.storeReindexedEmbedding(ctx, opts, item, vectors[i].Vector); err != nil {
+				s.logger.Warn("Failed to store reindexed embedding", map[string]interface{}{
+					"embedding_id": item.ID.String(),
+					"error":        err.Error(),
+				})
+				state.Failed++
+				continue
+			}
+			state.Processed++
+		}
+	}
+}
+
+func (s *UnifiedSearchService) storeReindexedEmbedding(ctx context.Context, opts ReindexOptions, item reindexItem, vector []float32) error {
+	metadata := make(map[string]interface{}, len(item.Metadata)+2)
+	for k, v := range item.Metadata {
+		metadata[k] = v
+	}
+	metadata["reindexed_from_model"] = item.ModelName
+	metadata["reindexed_from_id"] = item.ID.String()
+
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+
+	req := InsertRequest{
+		Content:      item.Content,
+		Embedding:    vector,
+		ModelName:    opts.TargetModel,
+		TenantID:     opts.TenantID,
+		Metadata:     metadataJSON,
+		ContentIndex: item.ContentIndex,
+		ChunkIndex:   item.ChunkIndex,
+	}
+	if item.ContextID.Valid {
+		contextID := item.ContextID.UUID
+		req.ContextID = &contextID
+	}
+
+	_, err = s.repository.InsertEmbedding(ctx, req)
+	return err
+}
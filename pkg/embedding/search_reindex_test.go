@@ -0,0 +1,128 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+func newReindexTestService(t *testing.T) (*UnifiedSearchService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	logger := observability.NewNoopLogger()
+	metrics := observability.NewNoOpMetricsClient()
+
+	return &UnifiedSearchService{
+		db:               db,
+		repository:       NewRepositoryWithObservability(db, logger, metrics),
+		embeddingService: &MockEmbeddingServiceForTests{MockVectors: map[string]*EmbeddingVector{}},
+		logger:           logger,
+		metrics:          metrics,
+	}, mock
+}
+
+func collectReindexProgress(t *testing.T, progress <-chan ReindexProgress) []ReindexProgress {
+	t.Helper()
+
+	var events []ReindexProgress
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-progress:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatal("reindex did not finish")
+		}
+	}
+}
+
+func TestReindexProcessesAndSkips(t *testing.T) {
+	service, mock := newReindexTestService(t)
+	tenantID := uuid.New()
+	existingID, newID := uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`e\.content_hash, e\.model_name, e\.metadata`).
+		WithArgs(tenantID, uuid.Nil, "test-model", "old-model", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "context_id", "content_index", "chunk_index", "content", "content_hash", "model_name", "metadata"}).
+			AddRow(existingID, nil, 0, 0, "already reindexed", "hash-1", "old-model", []byte(`{}`)).
+			AddRow(newID, nil, 1, 0, "needs reindex", "hash-2", "old-model", []byte(`{"content_type":"code"}`)).
+			AddRow(uuid.New(), nil, 2, 0, "", "hash-3", "old-model", nil))
+
+	mock.ExpectQuery(`JOIN mcp\.embedding_models`).
+		WithArgs("hash-1", "test-model", tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery(`JOIN mcp\.embedding_models`).
+		WithArgs("hash-2", "test-model", tenantID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`mcp\.insert_embedding`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+	progress, err := service.Reindex(context.Background(), ReindexOptions{
+		TargetModel: "test-model",
+		SourceModel: "old-model",
+		BatchSize:   10,
+		TenantID:    tenantID,
+	})
+	require.NoError(t, err)
+
+	events := collectReindexProgress(t, progress)
+	require.NotEmpty(t, events)
+
+	final := events[len(events)-1]
+	assert.True(t, final.Done)
+	assert.Empty(t, final.Error)
+	assert.Equal(t, 1, final.Processed)
+	assert.Equal(t, 2, final.Skipped)
+	assert.Equal(t, 0, final.Failed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReindexPauseResume(t *testing.T) {
+	service, mock := newReindexTestService(t)
+
+	mock.ExpectQuery(`e\.content_hash, e\.model_name, e\.metadata`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "context_id", "content_index", "chunk_index", "content", "content_hash", "model_name", "metadata"}))
+
+	control := make(chan ReindexCommand, 1)
+	control <- ReindexPause
+
+	progress, err := service.Reindex(context.Background(), ReindexOptions{
+		TargetModel: "test-model",
+		TenantID:    uuid.New(),
+		Control:     control,
+	})
+	require.NoError(t, err)
+
+	paused := <-progress
+	assert.True(t, paused.Paused)
+
+	control <- ReindexResume
+	events := collectReindexProgress(t, progress)
+	require.Len(t, events, 2)
+	assert.False(t, events[0].Paused)
+	assert.True(t, events[1].Done)
+}
+
+func TestReindexValidatesTargetModel(t *testing.T) {
+	service, _ := newReindexTestService(t)
+
+	_, err := service.Reindex(context.Background(), ReindexOptions{TargetModel: "other-model", TenantID: uuid.New()})
+	assert.ErrorContains(t, err, "configured for model test-model")
+
+	_, err = service.Reindex(context.Background(), ReindexOptions{TargetModel: "test-model"})
+	assert.ErrorContains(t, err, "tenant ID is required")
+}
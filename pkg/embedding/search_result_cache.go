@@ -0,0 +1,175 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/rerank"
+)
+
+const (
+	// DefaultResultSetCacheTTL is how long a cached result set serves later pages
+	DefaultResultSetCacheTTL = 5 * time.Minute
+	// DefaultResultSetCacheMaxResults is the prefix of a result set that's cached
+	DefaultResultSetCacheMaxResults = 200
+	// DefaultResultSetCacheMaxEntries is the number of result sets kept
+	DefaultResultSetCacheMaxEntries = 1000
+)
+
+// ResultSetCacheConfig configures caching of search result sets, so the pages
+// after the first are served without re-running the query
+type ResultSetCacheConfig struct {
+	TTL        time.Duration // How long a result set is reused; defaults to 5 minutes
+	MaxResults int           // Results cached per query; pages beyond them are queried directly. Defaults to 200.
+	MaxEntries int           // Result sets kept, evicting the oldest; defaults to 1000
+}
+
+// withDefaults fills in unset limits
+func (c ResultSetCacheConfig) withDefaults() ResultSetCacheConfig {
+	if c.TTL <= 0 {
+		c.TTL = DefaultResultSetCacheTTL
+	}
+	if c.MaxResults <= 0 {
+		c.MaxResults = DefaultResultSetCacheMaxResults
+	}
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = DefaultResultSetCacheMaxEntries
+	}
+	return c
+}
+
+// cachedResultSet is the prefix of a query's results. complete is set when the
+// query had no results beyond it.
+type cachedResultSet struct {
+	results      []*SearchResult
+	complete     bool
+	rerankBudget *rerank.BudgetResult
+	cachedAt     time.Time
+}
+
+// resultSetCache holds the result sets of recent searches by query. The
+// generation advances on invalidation, so a result set queried before content
+// changed isn't stored after it.
+type resultSetCache struct {
+	config     ResultSetCacheConfig
+	mu         sync.Mutex
+	entries    map[string]*cachedResultSet
+	generation uint64
+}
+
+func newResultSetCache(config ResultSetCacheConfig) *resultSetCache {
+	return &resultSetCache{
+		config:  config.withDefaults(),
+		entries: make(map[string]*cachedResultSet),
+	}
+}
+
+// cacheable reports whether the requested page falls within the cached prefix
+func (c *resultSetCache) cacheable(options *SearchOptions) bool {
+	return options != nil && options.Limit > 0 && options.Offset >= 0 &&
+		options.Offset+options.Limit <= c.config.MaxResults
+}
+
+// key identifies a query regardless of the page requested
+func (c *resultSetCache) key(tenantID uuid.UUID, text string, options *SearchOptions) string {
+	query := *options
+	query.Limit, query.Offset = 0, 0
+	query.Facets = nil // Facets are counted per page served, not cached
+	data, _ := json.Marshal(struct {
+		TenantID uuid.UUID     `json:"tenant_id"`
+		Text     string        `json:"text"`
+		Options  SearchOptions `json:"options"`
+	}{tenantID, text, query})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the result set of a query while it's fresh
+func (c *resultSetCache) get(key string) (*cachedResultSet, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	set, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Since(set.cachedAt) > c.config.TTL {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return set, true
+}
+
+// currentGeneration returns the generation to pass to put for a new query
+func (c *resultSetCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// put stores a query's result set unless content changed since the query
+// started, evicting the oldest result set beyond the entry limit
+func (c *resultSetCache) put(key string, set *cachedResultSet, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	c.entries[key] = set
+	for len(c.entries) > c.config.MaxEntries {
+		oldestKey := ""
+		var oldest time.Time
+		for k, entry := range c.entries {
+			if oldestKey == "" || entry.cachedAt.Before(oldest) {
+				oldestKey, oldest = k, entry.cachedAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+}
+
+// InvalidateContent implements ContentInvalidator. Changed content can enter
+// or leave any result set, so all of them are dropped.
+func (c *resultSetCache) InvalidateContent(ctx context.Context, contentIDs ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedResultSet)
+	c.generation++
+	return nil
+}
+
+// page returns the requested page of a result set
+func (set *cachedResultSet) page(offset, limit int) *SearchResults {
+	start := offset
+	if start > len(set.results) {
+		start = len(set.results)
+	}
+	end := start + limit
+	if end > len(set.results) {
+		end = len(set.results)
+	}
+
+	return &SearchResults{
+		Results:      append([]*SearchResult(nil), set.results[start:end]...),
+		Total:        len(set.results),
+		HasMore:      end < len(set.results) || !set.complete,
+		RerankBudget: set.rerankBudget,
+	}
+}
+
+// facetedPage returns the requested page of a result set, with facets counted
+// over the whole set
+func (set *cachedResultSet) facetedPage(options *SearchOptions) *SearchResults {
+	page := set.page(options.Offset, options.Limit)
+	if len(options.Facets) > 0 {
+		page.Facets = computeFacets(set.results, options.Facets)
+	}
+	return page
+}
@@ -0,0 +1,151 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/auth"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	repositorySearch "github.com/developer-mesh/developer-mesh/pkg/repository/search"
+)
+
+// pagingSearchRepository pages through fixed results and counts the queries
+type pagingSearchRepository struct {
+	repositorySearch.Repository
+	results []*repositorySearch.SearchResult
+	queries atomic.Int32
+}
+
+func (r *pagingSearchRepository) SearchByVector(ctx context.Context, vector []float32, options *repositorySearch.SearchOptions) (*repositorySearch.SearchResults, error) {
+	r.queries.Add(1)
+
+	start := options.Offset
+	if start > len(r.results) {
+		start = len(r.results)
+	}
+	end := start + options.Limit
+	if end > len(r.results) {
+		end = len(r.results)
+	}
+	return &repositorySearch.SearchResults{
+		Results: r.results[start:end],
+		Total:   len(r.results),
+		HasMore: end < len(r.results),
+	}, nil
+}
+
+func newResultSetCacheTestService(t *testing.T, config ResultSetCacheConfig) (*UnifiedSearchService, *pagingSearchRepository, *Repository) {
+	t.Helper()
+
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	searchRepo := &pagingSearchRepository{}
+	for i := 0; i < 5; i++ {
+		searchRepo.results = append(searchRepo.results, &repositorySearch.SearchResult{
+			ID:    fmt.Sprintf("doc-%d", i),
+			Score: 0.9 - float32(i)*0.05,
+		})
+	}
+
+	repo := NewRepository(db)
+	service, err := NewUnifiedSearchService(&UnifiedSearchConfig{
+		DB:               db,
+		Repository:       repo,
+		SearchRepository: searchRepo,
+		EmbeddingService: &MockEmbeddingServiceForTests{MockVectors: map[string]*EmbeddingVector{}},
+		ResultSetCache:   &config,
+		Logger:           observability.NewNoopLogger(),
+		Metrics:          observability.NewNoOpMetricsClient(),
+	})
+	require.NoError(t, err)
+	return service, searchRepo, repo
+}
+
+func searchPage(t *testing.T, ctx context.Context, service *UnifiedSearchService, offset int) ([]string, bool) {
+	t.Helper()
+
+	results, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2, Offset: offset})
+	require.NoError(t, err)
+	ids := make([]string, len(results.Results))
+	for i, result := range results.Results {
+		ids[i] = result.Content.ContentID
+	}
+	return ids, results.HasMore
+}
+
+func TestSearchResultSetCachePaging(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+	service, searchRepo, _ := newResultSetCacheTestService(t, ResultSetCacheConfig{TTL: time.Minute})
+
+	pages := []struct {
+		offset  int
+		ids     []string
+		hasMore bool
+	}{
+		{0, []string{"doc-0", "doc-1"}, true},
+		{2, []string{"doc-2", "doc-3"}, true},
+		{4, []string{"doc-4"}, false},
+	}
+	for _, page := range pages {
+		ids, hasMore := searchPage(t, ctx, service, page.offset)
+		assert.Equal(t, page.ids, ids)
+		assert.Equal(t, page.hasMore, hasMore)
+	}
+
+	// Only the first page queried the backend
+	assert.Equal(t, int32(1), searchRepo.queries.Load())
+
+	// Other tenants and other queries don't share the result set
+	_, _ = searchPage(t, auth.WithTenantID(context.Background(), uuid.New()), service, 2)
+	_, err := service.Search(ctx, "helm rollback", &SearchOptions{Limit: 2, Offset: 2, MinSimilarity: 0.5})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), searchRepo.queries.Load())
+}
+
+func TestSearchResultSetCacheExpires(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+	service, searchRepo, _ := newResultSetCacheTestService(t, ResultSetCacheConfig{TTL: 10 * time.Millisecond})
+
+	_, _ = searchPage(t, ctx, service, 0)
+	time.Sleep(20 * time.Millisecond)
+	ids, _ := searchPage(t, ctx, service, 2)
+	assert.Equal(t, []string{"doc-2", "doc-3"}, ids)
+	assert.Equal(t, int32(2), searchRepo.queries.Load())
+}
+
+func TestSearchResultSetCacheInvalidatedOnContentChange(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+	service, searchRepo, repo := newResultSetCacheTestService(t, ResultSetCacheConfig{TTL: time.Minute})
+
+	_, _ = searchPage(t, ctx, service, 0)
+	repo.invalidateContent(ctx, uuid.New())
+	_, _ = searchPage(t, ctx, service, 2)
+	assert.Equal(t, int32(2), searchRepo.queries.Load())
+}
+
+func TestSearchResultSetCacheBoundedPrefix(t *testing.T) {
+	ctx := auth.WithTenantID(context.Background(), uuid.New())
+	service, searchRepo, _ := newResultSetCacheTestService(t, ResultSetCacheConfig{TTL: time.Minute, MaxResults: 3})
+
+	// The cached prefix ends before the last results, so more are reported
+	ids, hasMore := searchPage(t, ctx, service, 0)
+	assert.Equal(t, []string{"doc-0", "doc-1"}, ids)
+	assert.True(t, hasMore)
+
+	// Pages beyond the prefix are queried directly
+	ids, _ = searchPage(t, ctx, service, 2)
+	assert.Equal(t, []string{"doc-2", "doc-3"}, ids)
+	ids, _ = searchPage(t, ctx, service, 2)
+	assert.Equal(t, []string{"doc-2", "doc-3"}, ids)
+	assert.Equal(t, int32(3), searchRepo.queries.Load())
+}
@@ -21,6 +21,10 @@ import (
 	"github.com/lib/pq"
 )
 
+// ErrUnknownSearchModel is returned for a search whose ModelID no embedding
+// service of the search service produces
+var ErrUnknownSearchModel = errors.New("unknown search model")
+
 // UnifiedSearchService implements the SearchService interface with advanced features
 type UnifiedSearchService struct {
 	db               *sql.DB
@@ -31,6 +35,14 @@ type UnifiedSearchService struct {
 	hybridSearch     *hybrid.HybridSearchService
 	reranker         rerank.Reranker
 	queryExpander    expansion.QueryExpander
+	calibrator       *ScoreCalibrator
+	normalization    NormalizationConfig
+	hybridScores     ScoreNormalization
+	experiment       *ModelExperiment
+	candidateService EmbeddingService
+	modelServices    map[string]EmbeddingService
+	resultSets       *resultSetCache
+	queryEmbeddings  *queryEmbeddingCache
 	logger           observability.Logger
 	metrics          observability.MetricsClient
 }
@@ -44,7 +56,16 @@ type UnifiedSearchConfig struct {
 	DimensionAdapter *DimensionAdapter
 	HybridSearch     *hybrid.HybridSearchService
 	Reranker         rerank.Reranker
+	RerankBudget     *rerank.BudgetConfig // Optional latency budget for the reranker
 	QueryExpander    expansion.QueryExpander
+	Calibrator       *ScoreCalibrator            // Optional feedback-driven model quality calibration
+	Normalization    NormalizationConfig         // Should match the normalization embeddings were stored with
+	HybridScores     ScoreNormalization          // How semantic and keyword scores are made comparable before merging
+	Experiment       *ModelExperiment            // Optional A/B test of a candidate embedding model
+	CandidateService EmbeddingService            // Embeds queries in the experiment's candidate arm
+	ModelServices    map[string]EmbeddingService // Optional query embedders for other models, by name, for SearchOptions.ModelID
+	ResultSetCache   *ResultSetCacheConfig       // Optional caching of result sets for paginated searches
+	QueryEmbeddings  *QueryEmbeddingCacheConfig  // Optional short-lived caching of query embeddings
 	Logger           observability.Logger
 	Metrics          observability.MetricsClient
 }
@@ -87,6 +108,35 @@ func NewUnifiedSearchService(config *UnifiedSearchConfig) (*UnifiedSearchService
 		}
 	}
 
+	if err := config.HybridScores.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid hybrid score normalization: %w", err)
+	}
+
+	if config.Experiment != nil && config.CandidateService == nil {
+		return nil, errors.New("candidate embedding service is required for a model experiment")
+	}
+
+	if config.Reranker != nil && config.RerankBudget != nil {
+		budgeted, err := rerank.NewBudgetedReranker(config.Reranker, *config.RerankBudget, config.Logger, config.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rerank budget: %w", err)
+		}
+		config.Reranker = budgeted
+	}
+
+	var resultSets *resultSetCache
+	if config.ResultSetCache != nil {
+		resultSets = newResultSetCache(*config.ResultSetCache)
+		if config.Repository != nil {
+			config.Repository.AddContentInvalidator(resultSets)
+		}
+	}
+
+	var queryEmbeddings *queryEmbeddingCache
+	if config.QueryEmbeddings != nil {
+		queryEmbeddings = newQueryEmbeddingCache(*config.QueryEmbeddings)
+	}
+
 	return &UnifiedSearchService{
 		db:               config.DB,
 		repository:       config.Repository,
@@ -96,6 +146,14 @@ func NewUnifiedSearchService(config *UnifiedSearchConfig) (*UnifiedSearchService
 		hybridSearch:     config.HybridSearch,
 		reranker:         config.Reranker,
 		queryExpander:    config.QueryExpander,
+		calibrator:       config.Calibrator,
+		normalization:    config.Normalization,
+		hybridScores:     config.HybridScores,
+		experiment:       config.Experiment,
+		candidateService: config.CandidateService,
+		modelServices:    config.ModelServices,
+		resultSets:       resultSets,
+		queryEmbeddings:  queryEmbeddings,
 		logger:           config.Logger,
 		metrics:          config.Metrics,
 	}, nil
@@ -141,6 +199,66 @@ func (s *UnifiedSearchService) Search(ctx context.Context, text string, options
 		return nil, err
 	}
 
+	if options != nil {
+		_, err := s.queryEmbedder(options.ModelID)
+		if err == nil {
+			err = validateRerankOptions(options)
+		}
+		if err != nil {
+			s.metrics.IncrementCounter("search.unified.error", 1.0)
+			span.RecordError(err)
+			span.SetStatus(400, "Invalid input")
+			return nil, err
+		}
+	}
+
+	if s.resultSets != nil && s.resultSets.cacheable(options) {
+		return s.searchResultSet(ctx, span, tenantID, text, options)
+	}
+	results, err := s.searchText(ctx, span, text, options)
+	if err != nil {
+		return nil, err
+	}
+	// Reranking and query expansion change the result set after the vector search
+	attachFacets(results, options)
+	return results, nil
+}
+
+// searchResultSet serves a page of a query from its cached result set. On a
+// miss, the query runs once for the whole cached prefix.
+func (s *UnifiedSearchService) searchResultSet(ctx context.Context, span observability.Span, tenantID uuid.UUID, text string, options *SearchOptions) (*SearchResults, error) {
+	key := s.resultSets.key(tenantID, text, options)
+	if set, ok := s.resultSets.get(key); ok {
+		s.metrics.IncrementCounter("search.unified.result_set_cache.hit", 1.0)
+		return set.facetedPage(options), nil
+	}
+	s.metrics.IncrementCounter("search.unified.result_set_cache.miss", 1.0)
+
+	generation := s.resultSets.currentGeneration()
+	prefix := *options
+	prefix.Offset = 0
+	prefix.Limit = s.resultSets.config.MaxResults
+
+	results, err := s.searchText(ctx, span, text, &prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &cachedResultSet{
+		results:      results.Results,
+		complete:     len(results.Results) < prefix.Limit && !results.HasMore,
+		rerankBudget: results.RerankBudget,
+		cachedAt:     time.Now(),
+	}
+	s.resultSets.put(key, set, generation)
+	return set.facetedPage(options), nil
+}
+
+// searchText embeds the query, expanding it if requested, and runs the search
+func (s *UnifiedSearchService) searchText(ctx context.Context, span observability.Span, text string, options *SearchOptions) (*SearchResults, error) {
+	tenantID := auth.GetTenantID(ctx)
+	correlationID := observability.GetCorrelationID(ctx)
+
 	// Apply query expansion if configured
 	queries := []string{text}
 	if s.queryExpander != nil && options != nil && options.UseQueryExpansion {
@@ -167,7 +285,16 @@ func (s *UnifiedSearchService) Search(ctx context.Context, text string, options
 		"correlation_id": correlationID,
 	})
 
-	embedding, err := s.embeddingService.GenerateEmbedding(ctx, text, "search_query", "")
+	modelID := ""
+	if options != nil {
+		modelID = options.ModelID
+	}
+	embedder, err := s.queryEmbedder(modelID)
+	if err != nil {
+		return nil, err
+	}
+
+	embedding, err := s.embedQuery(ctx, embedder, text)
 	if err != nil {
 		s.metrics.IncrementCounter("search.unified.error", 1.0)
 		s.logger.Error("Failed to generate embedding", map[string]interface{}{
@@ -180,14 +307,22 @@ func (s *UnifiedSearchService) Search(ctx context.Context, text string, options
 		return nil, fmt.Errorf("failed to generate embedding: %w", err)
 	}
 
-	// Search with the generated vector
-	results, err := s.SearchByVector(ctx, embedding.Vector, options)
+	// Search with the generated vector. MMR picks the page from a wider pool
+	// of candidates, so near-duplicates can give way to other results.
+	searchOptions := options
+	if options != nil && options.UseReranking && options.RerankMode == rerank.ModeMMR && options.Limit > 0 {
+		widened := *options
+		widened.Limit = options.Limit * MMRCandidateMultiplier
+		widened.RerankQuery = ""
+		searchOptions = &widened
+	}
+	results, err := s.SearchByVector(ctx, embedding.Vector, searchOptions)
 	if err != nil {
 		return nil, err
 	}
 
 	// Apply reranking if configured
-	if s.reranker != nil && options != nil && options.UseReranking {
+	if options != nil && options.UseReranking && s.rerankerFor(options.RerankMode) != nil {
 		return s.applyReranking(ctx, text, results, options)
 	}
 
@@ -232,6 +367,16 @@ func (s *UnifiedSearchService) SearchByVector(ctx context.Context, vector []floa
 		span.SetStatus(400, "Invalid input")
 		return nil, err
 	}
+	if options != nil {
+		if err := validateRerankOptions(options); err != nil {
+			s.metrics.IncrementCounter("search.unified.error", 1.0)
+			span.RecordError(err)
+			span.SetStatus(400, "Invalid input")
+			return nil, err
+		}
+	}
+
+	vector = s.normalization.Apply(vector)
 
 	// Convert SearchOptions to repository SearchOptions
 	repoOptions := s.convertToRepoOptions(options)
@@ -261,6 +406,10 @@ func (s *UnifiedSearchService) SearchByVector(ctx context.Context, vector []floa
 		}
 	}
 	searchResults := s.convertToSearchResults(results)
+	if options != nil && options.Explain {
+		attachScoreBreakdowns(searchResults)
+	}
+	attachFacets(searchResults, options)
 
 	s.logger.Debug("Vector search completed", map[string]interface{}{
 		"result_count":   len(searchResults.Results),
@@ -269,7 +418,7 @@ func (s *UnifiedSearchService) SearchByVector(ctx context.Context, vector []floa
 	})
 
 	// Apply reranking if configured for vector search
-	if s.reranker != nil && options != nil && options.UseReranking && options.RerankQuery != "" {
+	if options != nil && options.UseReranking && options.RerankQuery != "" && s.rerankerFor(options.RerankMode) != nil {
 		return s.applyReranking(ctx, options.RerankQuery, searchResults, options)
 	}
 
@@ -344,6 +493,10 @@ func (s *UnifiedSearchService) SearchByContentID(ctx context.Context, contentID
 		}
 	}
 	searchResults := s.convertToSearchResults(results)
+	if options != nil && options.Explain {
+		attachScoreBreakdowns(searchResults)
+	}
+	attachFacets(searchResults, options)
 
 	s.logger.Debug("Content search completed", map[string]interface{}{
 		"result_count":   len(searchResults.Results),
@@ -398,16 +551,21 @@ func (s *UnifiedSearchService) CrossModelSearch(ctx context.Context, req CrossMo
 		return nil, err
 	}
 
+	// Queries in an experiment arm are scoped to that arm's model
+	queryEmbedder, experimentArm := s.assignSearchExperiment(&req)
+
 	// Generate embedding if needed
 	if len(req.QueryEmbedding) == 0 && req.Query != "" {
-		embedding, err := s.embeddingService.GenerateEmbedding(ctx, req.Query, "search_query", req.SearchModel)
+		embedding, err := queryEmbedder.GenerateEmbedding(ctx, req.Query, "search_query", req.SearchModel)
 		if err != nil {
 			s.metrics.IncrementCounter("search.unified.cross_model.error", 1.0)
+			s.recordSearchExperiment(experimentArm, start, nil, err)
 			span.RecordError(err)
 			return nil, fmt.Errorf("failed to generate embedding: %w", err)
 		}
 		req.QueryEmbedding = embedding.Vector
 	}
+	req.QueryEmbedding = s.normalization.Apply(req.QueryEmbedding)
 
 	// Determine target dimension
 	targetDimension := StandardDimension
@@ -423,6 +581,7 @@ func (s *UnifiedSearchService) CrossModelSearch(ctx context.Context, req CrossMo
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		s.metrics.IncrementCounter("search.unified.cross_model.error", 1.0)
+		s.recordSearchExperiment(experimentArm, start, nil, err)
 		span.RecordError(err)
 		return nil, fmt.Errorf("failed to execute cross-model search: %w", err)
 	}
@@ -434,9 +593,11 @@ func (s *UnifiedSearchService) CrossModelSearch(ctx context.Context, req CrossMo
 	results, err := s.processCrossModelResults(rows, req, targetDimension)
 	if err != nil {
 		s.metrics.IncrementCounter("search.unified.cross_model.error", 1.0)
+		s.recordSearchExperiment(experimentArm, start, nil, err)
 		span.RecordError(err)
 		return nil, err
 	}
+	s.recordSearchExperiment(experimentArm, start, results, nil)
 
 	s.logger.Debug("Cross-model search completed", map[string]interface{}{
 		"result_count":   len(results),
@@ -480,17 +641,39 @@ func (s *UnifiedSearchService) HybridSearch(ctx context.Context, req HybridSearc
 		s.metrics.IncrementCounter("search.unified.hybrid.total", 1.0)
 	}()
 
-	// Perform semantic search
-	semanticResults, err := s.semanticSearch(ctx, req)
-	if err != nil {
-		s.metrics.IncrementCounter("search.unified.hybrid.error", 1.0)
-		span.RecordError(err)
-		return nil, fmt.Errorf("semantic search failed: %w", err)
+	// Choose the strategy from the query's intent, or search both ways
+	route := QueryRoute{Strategy: SearchStrategySemantic, Keywords: req.Keywords, HybridWeight: req.HybridWeight}
+	if len(req.Keywords) > 0 {
+		route.Strategy = SearchStrategyHybrid
+	}
+	if req.RouteByIntent {
+		route = RouteQuery(req)
+		req.Keywords = route.Keywords
+		req.HybridWeight = route.HybridWeight
+
+		span.SetAttribute("query_intent", string(route.Intent))
+		s.metrics.IncrementCounterWithLabels("search.unified.hybrid.routed", 1.0, map[string]string{
+			"intent":   string(route.Intent),
+			"strategy": string(route.Strategy),
+		})
+	}
+	span.SetAttribute("search_strategy", string(route.Strategy))
+
+	// Perform semantic search unless the query is routed to keyword search
+	var semanticResults []HybridSearchResult
+	var err error
+	if route.Strategy != SearchStrategyKeyword {
+		semanticResults, err = s.semanticSearch(ctx, req)
+		if err != nil {
+			s.metrics.IncrementCounter("search.unified.hybrid.error", 1.0)
+			span.RecordError(err)
+			return nil, fmt.Errorf("semantic search failed: %w", err)
+		}
 	}
 
 	// Perform keyword search if keywords provided
 	var keywordResults []HybridSearchResult
-	if len(req.Keywords) > 0 {
+	if route.Strategy != SearchStrategySemantic && len(req.Keywords) > 0 {
 		keywordResults, err = s.keywordSearch(ctx, req)
 		if err != nil {
 			s.metrics.IncrementCounter("search.unified.hybrid.error", 1.0)
@@ -507,7 +690,13 @@ func (s *UnifiedSearchService) HybridSearch(ctx context.Context, req HybridSearc
 		merged = merged[:req.Limit]
 	}
 
+	for i := range merged {
+		merged[i].Intent = route.Intent
+		merged[i].Strategy = route.Strategy
+	}
+
 	s.logger.Debug("Hybrid search completed", map[string]interface{}{
+		"strategy":         route.Strategy,
 		"result_count":     len(merged),
 		"semantic_results": len(semanticResults),
 		"keyword_results":  len(keywordResults),
@@ -547,7 +736,7 @@ func (s *UnifiedSearchService) convertToRepoOptions(options *SearchOptions) *rep
 	// WeightFactors is map[string]float32, so we can't store algorithm there
 	// Default to cosine for now
 
-	return &repositorySearch.SearchOptions{
+	repoOptions := &repositorySearch.SearchOptions{
 		Limit:               options.Limit,
 		Offset:              options.Offset,
 		MinSimilarity:       options.MinSimilarity,
@@ -555,7 +744,12 @@ func (s *UnifiedSearchService) convertToRepoOptions(options *SearchOptions) *rep
 		MetadataFilters:     metadataFilters,
 		RankingAlgorithm:    rankingAlgorithm,
 		MaxResults:          options.Limit,
+		UseANNIndex:         options.UseANNIndex,
 	}
+	if options.UseANNIndex && options.HNSW != nil {
+		repoOptions.EfSearch = options.HNSW.EfSearch
+	}
+	return repoOptions
 }
 
 func (s *UnifiedSearchService) convertToSearchResults(results []repositorySearch.SearchResult) *SearchResults {
@@ -589,6 +783,11 @@ func (s *UnifiedSearchService) convertToSearchResults(results []repositorySearch
 		// Add similarity to metadata
 		embedding.Metadata["similarity"] = similarity
 
+		// Rerankers compare results by their text
+		if _, ok := embedding.Metadata["content"]; !ok && result.Content != "" {
+			embedding.Metadata["content"] = result.Content
+		}
+
 		searchResults.Results[i] = &SearchResult{
 			Content: embedding,
 			Score:   similarity,
@@ -601,6 +800,69 @@ func (s *UnifiedSearchService) convertToSearchResults(results []repositorySearch
 	return searchResults
 }
 
+// queryEmbedder returns the service embedding queries with a model, or the
+// default service when no model is given
+func (s *UnifiedSearchService) queryEmbedder(modelID string) (EmbeddingService, error) {
+	if modelID == "" || modelID == s.embeddingService.GetModelConfig().Name {
+		return s.embeddingService, nil
+	}
+	if service, ok := s.modelServices[modelID]; ok {
+		return service, nil
+	}
+	if s.candidateService != nil && modelID == s.candidateService.GetModelConfig().Name {
+		return s.candidateService, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownSearchModel, modelID)
+}
+
+// assignSearchExperiment assigns a query to a model experiment arm and returns
+// the service that embeds it. Each arm only searches content its model
+// embedded, since embeddings from different models aren't comparable. Queries
+// that bring their own embedding or choose their models aren't part of the
+// experiment.
+func (s *UnifiedSearchService) assignSearchExperiment(req *CrossModelSearchRequest) (EmbeddingService, string) {
+	if s.experiment == nil || len(req.QueryEmbedding) > 0 || req.SearchModel != "" || len(req.IncludeModels) > 0 {
+		return s.embeddingService, ""
+	}
+
+	candidateModel := s.experiment.candidateModelName()
+	arm := s.experiment.Assign(req.TenantID.String() + ":" + calculateContentHash(req.Query))
+	if arm == ExperimentArmCandidate {
+		req.SearchModel = candidateModel
+		req.IncludeModels = []string{candidateModel}
+		return s.candidateService, arm
+	}
+	req.ExcludeModels = append(req.ExcludeModels, candidateModel)
+	return s.embeddingService, arm
+}
+
+// recordSearchExperiment tags results with their experiment arm and records
+// the search's outcome
+func (s *UnifiedSearchService) recordSearchExperiment(arm string, start time.Time, results []CrossModelSearchResult, err error) {
+	if arm == "" {
+		return
+	}
+
+	outcome := ExperimentOutcome{Latency: time.Since(start), ResultCount: len(results), Err: err}
+	if len(results) > 0 {
+		outcome.TopScore = float64(results[0].FinalScore)
+	}
+	s.experiment.Record(ExperimentOperationSearch, arm, outcome)
+
+	model := s.experiment.candidateModelName()
+	if arm == ExperimentArmControl {
+		model = s.embeddingService.GetModelConfig().Name
+	}
+	for i := range results {
+		if results[i].Metadata == nil {
+			results[i].Metadata = make(map[string]interface{})
+		}
+		for k, v := range s.experiment.experimentTags(arm, model) {
+			results[i].Metadata[k] = v
+		}
+	}
+}
+
 func (s *UnifiedSearchService) validateCrossModelRequest(req *CrossModelSearchRequest) error {
 	if len(req.Query) == 0 && len(req.QueryEmbedding) == 0 {
 		return fmt.Errorf("either query or query_embedding must be provided")
@@ -643,6 +905,7 @@ func (s *UnifiedSearchService) buildCrossModelQuery(req CrossModelSearchRequest,
 				END as similarity
 			FROM mcp.embeddings e
 			WHERE e.tenant_id = $3
+				AND e.deleted_at IS NULL
 	`
 
 	args := []interface{}{targetDimension, pq.Array(req.QueryEmbedding), req.TenantID}
@@ -770,7 +1033,7 @@ func (s *UnifiedSearchService) semanticSearch(ctx context.Context, req HybridSea
 	if len(req.QueryEmbedding) > 0 {
 		queryEmbedding = req.QueryEmbedding
 	} else if req.Query != "" {
-		embedding, err := s.embeddingService.GenerateEmbedding(ctx, req.Query, "search_query", "")
+		embedding, err := s.embedQuery(ctx, s.embeddingService, req.Query)
 		if err != nil {
 			return nil, err
 		}
@@ -822,6 +1085,7 @@ func (s *UnifiedSearchService) keywordSearch(ctx context.Context, req HybridSear
 		FROM mcp.embeddings e,
 			to_tsquery('english', $1) query
 		WHERE e.tenant_id = $2
+			AND e.deleted_at IS NULL
 			AND to_tsvector('english', e.content) @@ query
 		ORDER BY rank DESC
 		LIMIT $3
@@ -872,14 +1136,29 @@ func (s *UnifiedSearchService) keywordSearch(ctx context.Context, req HybridSear
 }
 
 func (s *UnifiedSearchService) mergeHybridResults(semantic, keyword []HybridSearchResult, weight float64) []HybridSearchResult {
+	// Normalize each source's scores separately so the weight applies to comparable scales
+	semanticScores := make([]float32, len(semantic))
+	for i := range semantic {
+		semanticScores[i] = semantic[i].SemanticScore
+	}
+	semanticScores = NormalizeScores(semanticScores, s.hybridScores)
+
+	keywordScores := make([]float32, len(keyword))
+	for i := range keyword {
+		keywordScores[i] = keyword[i].KeywordScore
+	}
+	keywordScores = NormalizeScores(keywordScores, s.hybridScores)
+
 	// Create map for deduplication
 	resultMap := make(map[uuid.UUID]*HybridSearchResult)
+	semanticParts := make(map[uuid.UUID]float32, len(semantic))
 
 	// Add semantic results
 	for i := range semantic {
 		r := semantic[i]
-		r.HybridScore = float32(weight) * r.SemanticScore
+		r.HybridScore = float32(weight) * semanticScores[i]
 		resultMap[r.ID] = &r
+		semanticParts[r.ID] = r.HybridScore
 	}
 
 	// Merge keyword results
@@ -888,10 +1167,10 @@ func (s *UnifiedSearchService) mergeHybridResults(semantic, keyword []HybridSear
 		if existing, ok := resultMap[k.ID]; ok {
 			// Combine scores
 			existing.KeywordScore = k.KeywordScore
-			existing.HybridScore = float32(weight)*existing.SemanticScore + float32(1-weight)*k.KeywordScore
+			existing.HybridScore = semanticParts[k.ID] + float32(1-weight)*keywordScores[i]
 		} else {
 			// Add new result
-			k.HybridScore = float32(1-weight) * k.KeywordScore
+			k.HybridScore = float32(1-weight) * keywordScores[i]
 			resultMap[k.ID] = &k
 		}
 	}
@@ -941,10 +1220,25 @@ func (s *UnifiedSearchService) getModelQualityScore(model string) float64 {
 		"cohere.embed-multilingual-v3": 0.91,
 	}
 
-	if score, ok := qualityScores[model]; ok {
-		return score
+	score, ok := qualityScores[model]
+	if !ok {
+		score = 0.80 // Default score for unknown models
+	}
+
+	// Apply feedback-driven calibration
+	if s.calibrator != nil {
+		score = math.Min(1.0, score*s.calibrator.Multiplier(model))
 	}
-	return 0.80 // Default score for unknown models
+	return score
+}
+
+// RecordFeedback records a relevance judgement used to calibrate model quality scores
+func (s *UnifiedSearchService) RecordFeedback(feedback RelevanceFeedback) {
+	if s.calibrator == nil {
+		return
+	}
+	s.calibrator.RecordFeedback(feedback)
+	s.metrics.IncrementCounter("search.feedback.total", 1.0)
 }
 
 func getModelFamily(model string) string {
@@ -1040,6 +1334,29 @@ func (s *UnifiedSearchService) buildTsQuery(keywords []string) string {
 	return query
 }
 
+// rerankerFor returns the reranker of a rerank mode, nil when no reranker
+// is configured for it
+func (s *UnifiedSearchService) rerankerFor(mode string) rerank.Reranker {
+	if mode == rerank.ModeMMR {
+		// MMR needs nothing beyond the results, so it's always available
+		return NewMMRReranker(DefaultMMRLambda)
+	}
+	return s.reranker
+}
+
+// validateRerankOptions rejects unknown rerank modes and MMR lambdas outside 0-1
+func validateRerankOptions(options *SearchOptions) error {
+	switch options.RerankMode {
+	case "", rerank.ModeCrossEncoder, rerank.ModeMMR:
+	default:
+		return fmt.Errorf("%w: unknown rerank mode %q", ErrInvalidRerankOptions, options.RerankMode)
+	}
+	if options.MMRLambda != nil && (*options.MMRLambda < 0 || *options.MMRLambda > 1) {
+		return fmt.Errorf("%w: MMR lambda must be between 0 and 1, got %v", ErrInvalidRerankOptions, *options.MMRLambda)
+	}
+	return nil
+}
+
 // applyReranking applies reranking to search results
 func (s *UnifiedSearchService) applyReranking(ctx context.Context, query string, results *SearchResults, options *SearchOptions) (*SearchResults, error) {
 	// Start span for tracing
@@ -1077,11 +1394,23 @@ func (s *UnifiedSearchService) applyReranking(ctx context.Context, query string,
 
 	// Configure reranking options
 	rerankOpts := &rerank.RerankOptions{
-		TopK: options.Limit,
+		TopK:      options.Limit,
+		Mode:      options.RerankMode,
+		MMRLambda: options.MMRLambda,
+	}
+
+	// Perform reranking, within the latency budget when there is one
+	reranker := s.rerankerFor(options.RerankMode)
+	var reranked []rerank.SearchResult
+	var budget *rerank.BudgetResult
+	var err error
+	if budgeted, ok := reranker.(*rerank.BudgetedReranker); ok {
+		var result rerank.BudgetResult
+		reranked, result, err = budgeted.RerankWithBudget(ctx, query, rerankInput, rerankOpts)
+		budget = &result
+	} else {
+		reranked, err = reranker.Rerank(ctx, query, rerankInput, rerankOpts)
 	}
-
-	// Perform reranking
-	reranked, err := s.reranker.Rerank(ctx, query, rerankInput, rerankOpts)
 	if err != nil {
 		s.logger.Error("Reranking failed", map[string]interface{}{
 			"error": err.Error(),
@@ -1094,9 +1423,10 @@ func (s *UnifiedSearchService) applyReranking(ctx context.Context, query string,
 
 	// Convert back to SearchResults
 	rerankedResults := &SearchResults{
-		Results: make([]*SearchResult, len(reranked)),
-		Total:   len(reranked),
-		HasMore: false,
+		Results:      make([]*SearchResult, len(reranked)),
+		Total:        len(reranked),
+		HasMore:      false,
+		RerankBudget: budget,
 	}
 
 	for i, r := range reranked {
@@ -1111,6 +1441,7 @@ func (s *UnifiedSearchService) applyReranking(ctx context.Context, query string,
 
 		if originalResult != nil {
 			// Update score and metadata
+			recordScoreChange(originalResult, rerankComponent, r.Score)
 			originalResult.Score = r.Score
 			if r.Metadata != nil {
 				for k, v := range r.Metadata {
@@ -1262,6 +1593,7 @@ func (s *UnifiedSearchService) multiQuerySearch(ctx context.Context, queries []s
 
 	// Convert map to slice
 	for _, r := range resultMap {
+		recordScoreChange(r, queryExpansionComponent, r.Score)
 		allResults = append(allResults, r)
 	}
 
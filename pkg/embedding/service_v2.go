@@ -9,6 +9,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/developer-mesh/developer-mesh/pkg/agents"
@@ -34,6 +35,13 @@ type ServiceV2 struct {
 	dimensionAdapter *DimensionAdapter
 	cache            EmbeddingCache
 	modelSelector    ModelSelector
+	fallbackChain    []ProviderCandidate
+	normalization    NormalizationConfig
+	deduplication    DeduplicationConfig
+	extractors       *ContentExtractorRegistry
+	preprocessing    *PreprocessingRegistry
+	lengthRouting    LengthRoutingConfig
+	experiment       *ModelExperiment
 	progressFunc     func(float64) // Progress callback for batch operations
 	mu               sync.RWMutex
 }
@@ -47,6 +55,31 @@ type ServiceV2Config struct {
 	Cache         EmbeddingCache
 	ModelSelector ModelSelector
 	RouterConfig  *RouterConfig
+
+	// FallbackChain lists models tried in order when the selected models fail,
+	// e.g. "openai:text-embedding-3-small"
+	FallbackChain []string
+
+	// Normalization L2-normalizes embeddings before they are stored
+	Normalization NormalizationConfig
+
+	// Deduplication detects near-duplicates of already indexed content
+	Deduplication DeduplicationConfig
+
+	// ContentExtractors extract embeddable text from content by content type,
+	// in addition to or replacing the built-in HTML and markdown extractors
+	ContentExtractors []ContentExtractor
+
+	// PreprocessingHooks transform each tenant's content before it's embedded,
+	// after content extraction
+	PreprocessingHooks map[uuid.UUID][]PreprocessingHook
+
+	// LengthRouting selects the embedding model by content length
+	LengthRouting LengthRoutingConfig
+
+	// Experiment embeds a fraction of content with a candidate model for A/B
+	// testing it against the current one
+	Experiment *ModelExperiment
 }
 
 // EmbeddingCache defines the interface for caching embeddings
@@ -104,6 +137,10 @@ type GenerateEmbeddingRequest struct {
 	RequestID string                 `json:"request_id"`
 	TenantID  uuid.UUID              `json:"tenant_id"`
 	ContextID *uuid.UUID             `json:"context_id,omitempty"` // Optional context reference
+
+	// ContentType of the text, e.g. "text/html", selects the extractor applied
+	// before embedding. Text without a content type is embedded as-is.
+	ContentType string `json:"content_type,omitempty"`
 }
 
 // GenerateEmbeddingResponse represents the response from generating an embedding
@@ -135,6 +172,13 @@ func NewServiceV2(config ServiceV2Config) (*ServiceV2, error) {
 		return nil, fmt.Errorf("repository is required")
 	}
 
+	if err := config.Deduplication.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid deduplication config: %w", err)
+	}
+	if err := config.LengthRouting.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid length routing config: %w", err)
+	}
+
 	s := &ServiceV2{
 		providers:     config.Providers,
 		agentService:  config.AgentService,
@@ -142,6 +186,12 @@ func NewServiceV2(config ServiceV2Config) (*ServiceV2, error) {
 		metricsRepo:   config.MetricsRepo,
 		cache:         config.Cache,
 		modelSelector: config.ModelSelector,
+		normalization: config.Normalization,
+		deduplication: config.Deduplication,
+		extractors:    NewContentExtractorRegistry(config.ContentExtractors...),
+		preprocessing: NewPreprocessingRegistry(config.PreprocessingHooks),
+		lengthRouting: config.LengthRouting.sorted(),
+		experiment:    config.Experiment,
 	}
 
 	// Use default model selector if none provided
@@ -158,9 +208,70 @@ func NewServiceV2(config ServiceV2Config) (*ServiceV2, error) {
 	// Initialize dimension adapter
 	s.dimensionAdapter = NewDimensionAdapter()
 
+	// Fallback models of unconfigured providers are skipped when generating
+	for _, entry := range config.FallbackChain {
+		provider, model := s.parseModelString(entry)
+		if model == "" {
+			continue
+		}
+		s.fallbackChain = append(s.fallbackChain, ProviderCandidate{Provider: provider, Model: model})
+	}
+
 	return s, nil
 }
 
+// withFallbackChain appends the configured fallback chain to the selected candidates
+func (s *ServiceV2) withFallbackChain(candidates []ProviderCandidate) []ProviderCandidate {
+	if len(s.fallbackChain) == 0 {
+		return candidates
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	chain := make([]ProviderCandidate, 0, len(candidates)+len(s.fallbackChain))
+	for _, candidate := range candidates {
+		seen[candidate.Provider+":"+candidate.Model] = true
+		chain = append(chain, candidate)
+	}
+	for _, candidate := range s.fallbackChain {
+		if !seen[candidate.Provider+":"+candidate.Model] {
+			chain = append(chain, candidate)
+		}
+	}
+	return chain
+}
+
+// prependCandidate puts a candidate ahead of the others, removing it from them
+func prependCandidate(first ProviderCandidate, candidates []ProviderCandidate) []ProviderCandidate {
+	chain := []ProviderCandidate{first}
+	for _, candidate := range candidates {
+		if candidate.Provider != first.Provider || candidate.Model != first.Model {
+			chain = append(chain, candidate)
+		}
+	}
+	return chain
+}
+
+// recordExperimentIndex tags an embedding with the experiment arm whose model
+// produced it and records the outcome. A candidate that failed over to the
+// selected models counts as a candidate error, and the embedding as control.
+func (s *ServiceV2) recordExperimentIndex(arm string, experimentCandidate, usedCandidate ProviderCandidate, start time.Time, metadata map[string]interface{}) {
+	outcome := ExperimentOutcome{Latency: time.Since(start)}
+	usedExperimentCandidate := usedCandidate.Provider == experimentCandidate.Provider && usedCandidate.Model == experimentCandidate.Model
+	if arm == ExperimentArmCandidate && !usedExperimentCandidate {
+		outcome.Err = fmt.Errorf("candidate model %s failed", s.experiment.CandidateModel())
+		s.experiment.Record(ExperimentOperationIndex, arm, outcome)
+		arm = ExperimentArmControl
+		metadata["experiment_fallback"] = true
+	} else {
+		outcome.CostUSD, _ = metadata["cost_usd"].(float64)
+		s.experiment.Record(ExperimentOperationIndex, arm, outcome)
+	}
+
+	for k, v := range s.experiment.experimentTags(arm, usedCandidate.Provider+":"+usedCandidate.Model) {
+		metadata[k] = v
+	}
+}
+
 // parseModelString parses a model string like "bedrock:amazon.titan-embed-text-v2:0" into provider and model
 func (s *ServiceV2) parseModelString(modelStr string) (provider, model string) {
 	if modelStr == "" {
@@ -189,6 +300,11 @@ func (s *ServiceV2) parseModelString(modelStr string) (provider, model string) {
 
 // GenerateEmbedding generates an embedding for the given request
 func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbeddingRequest) (*GenerateEmbeddingResponse, error) {
+	req, err := s.extractContent(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Input validation
 	if err := s.validateEmbeddingRequest(req); err != nil {
 		return nil, fmt.Errorf("invalid request: %w", err)
@@ -226,6 +342,29 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 		}
 	}
 
+	// Content is routed to a model by its length unless the request pins one
+	var lengthRoute *ProviderCandidate
+	if req.Model == "" {
+		if route, ok := s.lengthRouting.Select(req.TenantID, req.Text); ok {
+			provider, model := s.parseModelString(route.Model)
+			lengthRoute = &ProviderCandidate{Provider: provider, Model: model}
+			modelName = model
+		}
+	}
+
+	// Content in the experiment's candidate arm is embedded with the candidate
+	// model. Requests that pin a model aren't part of the experiment.
+	var experimentArm string
+	var experimentCandidate ProviderCandidate
+	if s.experiment != nil && req.Model == "" {
+		experimentArm = s.experiment.Assign(req.TenantID.String() + ":" + contentHash)
+		if experimentArm == ExperimentArmCandidate {
+			provider, model := s.parseModelString(s.experiment.CandidateModel())
+			experimentCandidate = ProviderCandidate{Provider: provider, Model: model}
+			modelName = model
+		}
+	}
+
 	// Check for existing embedding
 	existingEmbeddingID, checkErr := s.repository.GetExistingEmbedding(ctx, contentHash, modelName, req.TenantID)
 	if checkErr != nil {
@@ -273,7 +412,6 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 	// Select model and provider using router or use defaults from request
 	var routingDecision *RoutingDecision
 	var modelSelection *ModelSelectionResult // Store for usage tracking
-	var err error
 
 	if agentConfig != nil {
 		// Use agent config if available
@@ -347,16 +485,29 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 		}
 	}
 
-	// Generate embedding with selected provider using exponential backoff
+	// Generate embedding with selected provider using exponential backoff,
+	// falling back through the remaining candidates on failure
 	var embeddingResp *providers.EmbeddingResponse
+	var usedCandidate ProviderCandidate
+	var attempted []string
 	var lastErr error
 	retryCount := 0
 
-	for _, candidate := range routingDecision.Candidates {
+	// The selected models serve the request if the routed or candidate model fails
+	candidates := routingDecision.Candidates
+	if lengthRoute != nil {
+		candidates = prependCandidate(*lengthRoute, candidates)
+	}
+	if experimentArm == ExperimentArmCandidate {
+		candidates = prependCandidate(experimentCandidate, candidates)
+	}
+	candidates = s.withFallbackChain(candidates)
+	for _, candidate := range candidates {
 		provider := s.providers[candidate.Provider]
 		if provider == nil {
 			continue
 		}
+		attempted = append(attempted, candidate.Provider+":"+candidate.Model)
 
 		// Create exponential backoff strategy
 		b := backoff.NewExponentialBackOff()
@@ -415,6 +566,7 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 
 		if lastErr == nil {
 			// Success with this provider
+			usedCandidate = candidate
 			break
 		}
 
@@ -435,8 +587,15 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 		retryCount++
 	}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("all providers failed: %w", lastErr)
+	if lastErr != nil || embeddingResp == nil {
+		err := fmt.Errorf("no configured provider for selected models")
+		if lastErr != nil {
+			err = fmt.Errorf("all providers failed: %w", lastErr)
+		}
+		if experimentArm != "" {
+			s.experiment.Record(ExperimentOperationIndex, experimentArm, ExperimentOutcome{Latency: time.Since(start), Err: err})
+		}
+		return nil, err
 	}
 
 	// Track usage asynchronously if we have a model selector and model selection
@@ -463,8 +622,9 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 		}()
 	}
 
-	// Normalize embedding to standard dimension
+	// Normalize embedding to unit length and to standard dimension
 	normStart := time.Now()
+	embeddingResp.Embedding = s.normalization.Apply(embeddingResp.Embedding)
 	normalizedEmbedding := s.dimensionAdapter.Normalize(
 		embeddingResp.Embedding,
 		embeddingResp.Dimensions,
@@ -475,6 +635,10 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 	// Store embedding
 	// Add agent-specific metadata
 	metadata := make(map[string]interface{})
+	callerMetadata := make(map[string]interface{}, len(req.Metadata))
+	for k, v := range req.Metadata {
+		callerMetadata[k] = v
+	}
 	if req.Metadata != nil {
 		metadata = req.Metadata
 	}
@@ -483,6 +647,44 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 	metadata["normalized_embedding"] = normalizedEmbedding
 	metadata["cost_usd"] = calculateCost(embeddingResp.TokensUsed, embeddingResp.Model)
 	metadata["generation_time_ms"] = time.Since(start).Milliseconds()
+	// Record the model that actually produced the embedding, which differs from
+	// the selected model when a fallback was used
+	metadata["embedding_provider"] = usedCandidate.Provider
+	metadata["embedding_model"] = usedCandidate.Model
+	metadata["fallback_used"] = len(attempted) > 1
+	metadata["attempted_models"] = attempted
+	if s.normalization.Applies() {
+		metadata["l2_normalized"] = true
+	}
+	if lengthRoute != nil {
+		metadata["length_routed_model"] = lengthRoute.Provider + ":" + lengthRoute.Model
+		metadata["content_length"] = utf8.RuneCountInString(req.Text)
+	}
+	if experimentArm != "" {
+		s.recordExperimentIndex(experimentArm, experimentCandidate, usedCandidate, start, metadata)
+	}
+
+	// Near-duplicates of indexed content are skipped, merged or linked
+	if s.deduplication.Enabled {
+		duplicateID, err := s.handleNearDuplicate(ctx, req, embeddingResp.Embedding, embeddingResp.Model, callerMetadata, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deduplicate embedding: %w", err)
+		}
+		if duplicateID != nil {
+			return &GenerateEmbeddingResponse{
+				EmbeddingID:          *duplicateID,
+				RequestID:            requestID,
+				ModelUsed:            embeddingResp.Model,
+				Provider:             embeddingResp.ProviderInfo.Provider,
+				Dimensions:           embeddingResp.Dimensions,
+				NormalizedDimensions: StandardDimension,
+				CostUSD:              calculateCost(embeddingResp.TokensUsed, embeddingResp.Model),
+				TokensUsed:           embeddingResp.TokensUsed,
+				GenerationTimeMs:     time.Since(start).Milliseconds(),
+				Metadata:             metadata,
+			}, nil
+		}
+	}
 
 	insertReq := InsertRequest{
 		ContextID:            req.ContextID, // Now properly nullable
@@ -538,12 +740,17 @@ func (s *ServiceV2) GenerateEmbedding(ctx context.Context, req GenerateEmbedding
 	}, nil
 }
 
-// BatchGenerateEmbeddings generates embeddings for multiple texts
+// BatchGenerateEmbeddings generates embeddings for multiple texts. A request
+// that fails doesn't fail the others: they are still embedded and stored, and
+// the failures are returned as a *PartialBatchError alongside the responses.
 func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []GenerateEmbeddingRequest) ([]*GenerateEmbeddingResponse, error) {
 	if len(reqs) == 0 {
 		return []*GenerateEmbeddingResponse{}, nil
 	}
 
+	responses := make([]*GenerateEmbeddingResponse, len(reqs))
+	failures := &batchFailures{}
+
 	// Group requests by agent and task type for optimal batching
 	type batchKey struct {
 		agentID  string
@@ -551,7 +758,16 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 	}
 	batches := make(map[batchKey][]int)
 
+	extracted := make([]GenerateEmbeddingRequest, len(reqs))
 	for i, req := range reqs {
+		req, err := s.extractContent(ctx, req)
+		if err != nil {
+			// The content itself is rejected, so resubmitting it won't help
+			failures.add(i, reqs[i], err, false)
+			continue
+		}
+		extracted[i] = req
+
 		taskType := req.TaskType
 		if taskType == "" {
 			taskType = agents.TaskTypeGeneralQA
@@ -562,33 +778,38 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 		}
 		batches[key] = append(batches[key], i)
 	}
+	original, reqs := reqs, extracted
 
 	// Process each batch concurrently
-	responses := make([]*GenerateEmbeddingResponse, len(reqs))
 	var wg sync.WaitGroup
-	errCh := make(chan error, len(batches))
 
 	for key, indices := range batches {
 		wg.Add(1)
 		go func(bk batchKey, reqIndices []int) {
 			defer wg.Done()
 
+			failAll := func(err error, retryable bool) {
+				for _, idx := range reqIndices {
+					failures.add(idx, original[idx], err, retryable)
+				}
+			}
+
 			// Get agent configuration
 			agentConfig, err := s.agentService.GetConfig(ctx, bk.agentID)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get config for agent %s: %w", bk.agentID, err)
+				failAll(fmt.Errorf("failed to get config for agent %s: %w", bk.agentID, err), true)
 				return
 			}
 
 			// Get models for this agent and task type
 			primaryModels, _, err := s.agentService.GetModelsForAgent(ctx, bk.agentID, bk.taskType)
 			if err != nil {
-				errCh <- fmt.Errorf("failed to get models for agent %s: %w", bk.agentID, err)
+				failAll(fmt.Errorf("failed to get models for agent %s: %w", bk.agentID, err), true)
 				return
 			}
 
 			if len(primaryModels) == 0 {
-				errCh <- fmt.Errorf("no models configured for agent %s task %s", bk.agentID, bk.taskType)
+				failAll(fmt.Errorf("no models configured for agent %s task %s", bk.agentID, bk.taskType), false)
 				return
 			}
 
@@ -612,19 +833,20 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 			}
 
 			if provider == nil {
-				errCh <- fmt.Errorf("no provider found for model %s", modelName)
+				failAll(fmt.Errorf("no provider found for model %s", modelName), false)
 				return
 			}
 
-			// Extract texts for this batch
+			// Extract texts for this batch, embedding each distinct text once
 			texts := make([]string, len(reqIndices))
 			for i, idx := range reqIndices {
 				texts[i] = reqs[idx].Text
 			}
+			uniqueTexts, positions := dedupeTexts(texts)
 
 			// Create batch request
 			batchReq := providers.BatchGenerateEmbeddingRequest{
-				Texts:     texts,
+				Texts:     uniqueTexts,
 				Model:     modelName,
 				Metadata:  agentConfig.Metadata,
 				RequestID: uuid.New().String(),
@@ -634,12 +856,14 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 			start := time.Now()
 			batchResp, err := provider.BatchGenerateEmbeddings(ctx, batchReq)
 			if err != nil {
-				// Fall back to sequential processing
+				// Fall back to sequential processing of the original requests,
+				// which GenerateEmbedding extracts and preprocesses itself, so
+				// a request the provider rejects only fails itself
 				for _, idx := range reqIndices {
-					resp, err := s.GenerateEmbedding(ctx, reqs[idx])
+					resp, err := s.GenerateEmbedding(ctx, original[idx])
 					if err != nil {
-						errCh <- fmt.Errorf("failed to generate embedding for request %d: %w", idx, err)
-						return
+						failures.add(idx, original[idx], err, isRetryableEmbeddingError(err))
+						continue
 					}
 					responses[idx] = resp
 				}
@@ -650,16 +874,16 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 
 			// Process responses
 			for i, idx := range reqIndices {
-				if i >= len(batchResp.Embeddings) {
-					errCh <- fmt.Errorf("missing embedding for request %d", idx)
-					return
+				if positions[i] >= len(batchResp.Embeddings) {
+					failures.add(idx, original[idx], fmt.Errorf("missing embedding"), true)
+					continue
 				}
 
 				// Convert metadata to JSON
 				metadataJSON, err := json.Marshal(reqs[idx].Metadata)
 				if err != nil {
-					errCh <- fmt.Errorf("failed to marshal metadata for request %d: %w", idx, err)
-					return
+					failures.add(idx, original[idx], fmt.Errorf("failed to marshal metadata: %w", err), false)
+					continue
 				}
 
 				// Store embedding
@@ -667,7 +891,7 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 				insertReq := InsertRequest{
 					ContextID:            reqs[idx].ContextID,
 					Content:              texts[i],
-					Embedding:            batchResp.Embeddings[i],
+					Embedding:            s.normalization.Apply(batchResp.Embeddings[positions[i]]),
 					ModelName:            modelName,
 					TenantID:             reqs[idx].TenantID,
 					Metadata:             json.RawMessage(metadataJSON),
@@ -678,8 +902,8 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 
 				embeddingID, err := s.repository.InsertEmbedding(ctx, insertReq)
 				if err != nil {
-					errCh <- fmt.Errorf("failed to store embedding %d: %w", idx, err)
-					return
+					failures.add(idx, original[idx], fmt.Errorf("failed to store embedding: %w", err), isRetryableEmbeddingError(err))
+					continue
 				}
 
 				// Create response
@@ -699,21 +923,8 @@ func (s *ServiceV2) BatchGenerateEmbeddings(ctx context.Context, reqs []Generate
 	}
 
 	wg.Wait()
-	close(errCh)
 
-	// Collect errors
-	var errs []error
-	for err := range errCh {
-		if err != nil {
-			errs = append(errs, err)
-		}
-	}
-
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("batch processing failed with %d errors: %v", len(errs), errs)
-	}
-
-	return responses, nil
+	return responses, failures.err(len(reqs))
 }
 
 // GenerateBatch generates embeddings for multiple texts with progress tracking
@@ -724,6 +935,46 @@ func (s *ServiceV2) GenerateBatch(ctx context.Context, texts []string, model str
 		return [][]float32{}, nil
 	}
 
+	provider, providerName, model, err := s.batchProvider(model)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process in batches
+	var results [][]float32
+	totalBatches := (len(texts) + batchSize - 1) / batchSize
+
+	for i := 0; i < len(texts); i += batchSize {
+		end := i + batchSize
+		if end > len(texts) {
+			end = len(texts)
+		}
+
+		embeddings, err := s.embedBatch(ctx, provider, providerName, model, texts[i:end])
+		if err != nil {
+			return nil, fmt.Errorf("batch %d/%d failed: %w", (i/batchSize)+1, totalBatches, err)
+		}
+
+		results = append(results, embeddings...)
+
+		// Progress callback if available
+		if s.progressFunc != nil {
+			progress := float64(end) / float64(len(texts))
+			s.progressFunc(progress)
+		}
+
+		// Add small delay between batches to avoid rate limiting
+		if end < len(texts) {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	return results, nil
+}
+
+// batchProvider finds the provider of an active model, or of the first active
+// model when none is given, returning the provider, its name and the model
+func (s *ServiceV2) batchProvider(model string) (providers.Provider, string, string, error) {
 	// Use default model if not specified
 	if model == "" {
 		// Find first available model
@@ -735,110 +986,88 @@ func (s *ServiceV2) GenerateBatch(ctx context.Context, texts []string, model str
 			}
 		}
 		if model == "" {
-			return nil, fmt.Errorf("no active models available")
+			return nil, "", "", fmt.Errorf("no active models available")
 		}
 	}
 
 	// Find provider that supports the model
-	var provider providers.Provider
-	var providerName string
 	for name, p := range s.providers {
-		models := p.GetSupportedModels()
-		for _, m := range models {
+		for _, m := range p.GetSupportedModels() {
 			if m.Name == model && m.IsActive {
-				provider = p
-				providerName = name
-				break
+				return p, name, model, nil
 			}
 		}
-		if provider != nil {
-			break
-		}
 	}
 
-	if provider == nil {
-		return nil, fmt.Errorf("no provider found for model %s", model)
-	}
-
-	// Process in batches
-	var results [][]float32
-	totalBatches := (len(texts) + batchSize - 1) / batchSize
-
-	for i := 0; i < len(texts); i += batchSize {
-		end := i + batchSize
-		if end > len(texts) {
-			end = len(texts)
-		}
-		batch := texts[i:end]
+	return nil, "", "", fmt.Errorf("no provider found for model %s", model)
+}
 
-		// Create batch request
-		batchReq := providers.BatchGenerateEmbeddingRequest{
-			Texts:     batch,
-			Model:     model,
-			RequestID: uuid.New().String(),
-		}
+// embedBatch embeds one provider request's worth of texts with retries,
+// embedding each distinct text once, and returns their normalized embeddings
+func (s *ServiceV2) embedBatch(ctx context.Context, provider providers.Provider, providerName, model string, texts []string) ([][]float32, error) {
+	batch, positions := dedupeTexts(texts)
 
-		// Generate embeddings with retry using circuit breaker pattern
-		var embeddings [][]float32
-		err := s.generateWithRetry(ctx, func() error {
-			// Record metrics
-			start := time.Now()
-			defer func() {
-				if s.metricsRepo != nil {
-					s.recordMetric(ctx, &EmbeddingMetric{
-						ID:                uuid.New(),
-						ModelProvider:     providerName,
-						ModelName:         model,
-						TokenCount:        len(batch),
-						TotalLatencyMs:    int(time.Since(start).Milliseconds()),
-						ProviderLatencyMs: int(time.Since(start).Milliseconds()),
-						Status:            "success",
-						Timestamp:         time.Now(),
-					})
-				}
-			}()
+	// Create batch request
+	batchReq := providers.BatchGenerateEmbeddingRequest{
+		Texts:     batch,
+		Model:     model,
+		RequestID: uuid.New().String(),
+	}
 
-			// Call provider
-			resp, err := provider.BatchGenerateEmbeddings(ctx, batchReq)
-			if err != nil {
-				// Record error metric
-				if s.metricsRepo != nil {
-					s.recordMetric(ctx, &EmbeddingMetric{
-						ID:            uuid.New(),
-						ModelProvider: providerName,
-						ModelName:     model,
-						TokenCount:    len(batch),
-						Status:        "error",
-						ErrorMessage:  err.Error(),
-						Timestamp:     time.Now(),
-					})
-				}
-				return err
+	// Generate embeddings with retry using circuit breaker pattern
+	var embeddings [][]float32
+	err := s.generateWithRetry(ctx, func() error {
+		// Record metrics
+		start := time.Now()
+		defer func() {
+			if s.metricsRepo != nil {
+				s.recordMetric(ctx, &EmbeddingMetric{
+					ID:                uuid.New(),
+					ModelProvider:     providerName,
+					ModelName:         model,
+					TokenCount:        len(batch),
+					TotalLatencyMs:    int(time.Since(start).Milliseconds()),
+					ProviderLatencyMs: int(time.Since(start).Milliseconds()),
+					Status:            "success",
+					Timestamp:         time.Now(),
+				})
 			}
+		}()
 
-			embeddings = resp.Embeddings
-			return nil
-		})
-
+		// Call provider
+		resp, err := provider.BatchGenerateEmbeddings(ctx, batchReq)
 		if err != nil {
-			return nil, fmt.Errorf("batch %d/%d failed: %w", (i/batchSize)+1, totalBatches, err)
+			// Record error metric
+			if s.metricsRepo != nil {
+				s.recordMetric(ctx, &EmbeddingMetric{
+					ID:            uuid.New(),
+					ModelProvider: providerName,
+					ModelName:     model,
+					TokenCount:    len(batch),
+					Status:        "error",
+					ErrorMessage:  err.Error(),
+					Timestamp:     time.Now(),
+				})
+			}
+			return err
 		}
 
-		results = append(results, embeddings...)
-
-		// Progress callback if available
-		if s.progressFunc != nil {
-			progress := float64(end) / float64(len(texts))
-			s.progressFunc(progress)
+		if len(resp.Embeddings) < len(batch) {
+			return fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Embeddings))
 		}
 
-		// Add small delay between batches to avoid rate limiting
-		if end < len(texts) {
-			time.Sleep(100 * time.Millisecond)
+		// Map each distinct text's embedding back to all its occurrences
+		embeddings = make([][]float32, len(positions))
+		for j, pos := range positions {
+			embeddings[j] = s.normalization.Apply(resp.Embeddings[pos])
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	return embeddings, nil
 }
 
 // generateWithRetry implements retry logic with exponential backoff
@@ -877,6 +1106,44 @@ func (s *ServiceV2) generateWithRetry(ctx context.Context, fn func() error) erro
 	return fmt.Errorf("failed after %d retries: %w", maxRetries, lastErr)
 }
 
+// RegisterContentExtractor adds an extractor for its content types, replacing
+// any extractor already registered for them
+func (s *ServiceV2) RegisterContentExtractor(extractor ContentExtractor) {
+	s.extractors.Register(extractor)
+}
+
+// RegisterPreprocessingHook adds a hook run on the tenant's content before it's
+// embedded, after the tenant's hooks already registered
+func (s *ServiceV2) RegisterPreprocessingHook(tenantID uuid.UUID, hook PreprocessingHook) {
+	s.preprocessing.Register(tenantID, hook)
+}
+
+// ClearPreprocessingHooks removes the tenant's preprocessing hooks
+func (s *ServiceV2) ClearPreprocessingHooks(tenantID uuid.UUID) {
+	s.preprocessing.Clear(tenantID)
+}
+
+// extractContent replaces the request's text with its extracted, embeddable
+// text, preprocessed by the tenant's hooks. The content type is cleared so the
+// text isn't extracted twice.
+func (s *ServiceV2) extractContent(ctx context.Context, req GenerateEmbeddingRequest) (GenerateEmbeddingRequest, error) {
+	if req.ContentType != "" {
+		text, err := s.extractors.Extract(ctx, req.ContentType, req.Text)
+		if err != nil {
+			return req, fmt.Errorf("invalid request: %w", err)
+		}
+		req.Text = text
+		req.ContentType = ""
+	}
+
+	text, err := s.preprocessing.Apply(ctx, req.TenantID, req.Text)
+	if err != nil {
+		return req, fmt.Errorf("failed to preprocess content: %w", err)
+	}
+	req.Text = text
+	return req, nil
+}
+
 // SetProgressCallback sets the progress callback function
 func (s *ServiceV2) SetProgressCallback(fn func(float64)) {
 	s.mu.Lock()
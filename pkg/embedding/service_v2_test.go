@@ -434,6 +434,56 @@ func TestGenerateEmbedding(t *testing.T) {
 			t.Errorf("Database expectations not met: %v", err)
 		}
 	})
+
+	t.Run("falls back through configured chain", func(t *testing.T) {
+		mockAgentService := &MockAgentService{}
+		// The primary provider doesn't serve the default model
+		primary := providers.NewMockProvider("bedrock")
+		fallback := providers.NewMockProvider("openai")
+
+		db, mockDB, err := sqlmock.New()
+		require.NoError(t, err)
+		defer func() {
+			if err := db.Close(); err != nil {
+				t.Logf("Failed to close database: %v", err)
+			}
+		}()
+
+		mockDB.ExpectQuery("SELECT mcp.insert_embedding").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+
+		service, err := NewServiceV2(ServiceV2Config{
+			Providers: map[string]providers.Provider{
+				"bedrock": primary,
+				"openai":  fallback,
+			},
+			AgentService:  mockAgentService,
+			Repository:    NewRepository(db),
+			FallbackChain: []string{"google:text-embedding-004", "openai:mock-model-small"},
+		})
+		require.NoError(t, err)
+
+		mockAgentService.On("GetConfig", ctx, "test-agent").Return(nil, fmt.Errorf("not found"))
+
+		resp, err := service.GenerateEmbedding(ctx, GenerateEmbeddingRequest{
+			AgentID:  "test-agent",
+			Text:     "fallback text",
+			TenantID: uuid.New(),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "mock-model-small", resp.ModelUsed)
+		assert.Equal(t, "openai", resp.Provider)
+		assert.Equal(t, "openai", resp.Metadata["embedding_provider"])
+		assert.Equal(t, "mock-model-small", resp.Metadata["embedding_model"])
+		assert.Equal(t, true, resp.Metadata["fallback_used"])
+		// The unconfigured google provider is skipped
+		assert.Equal(t, []string{"bedrock:amazon.titan-embed-text-v2:0", "openai:mock-model-small"}, resp.Metadata["attempted_models"])
+
+		assert.Len(t, primary.GetGenerateCalls(), 1)
+		assert.Len(t, fallback.GetGenerateCalls(), 1)
+		assert.NoError(t, mockDB.ExpectationsWereMet())
+	})
 }
 
 func TestBatchGenerateEmbeddings(t *testing.T) {
@@ -629,10 +679,22 @@ func TestBatchGenerateEmbeddings(t *testing.T) {
 		// Execute
 		resps, err := service.BatchGenerateEmbeddings(ctx, reqs)
 
-		// Verify - batch fails if any request fails (all-or-nothing)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "batch processing failed")
-		assert.Nil(t, resps)
+		// Verify - the valid request is embedded and stored, and the failed
+		// one is reported at its index
+		var partial *PartialBatchError
+		require.ErrorAs(t, err, &partial)
+		assert.Contains(t, err.Error(), "batch processing failed for 1 of 2 requests")
+		assert.Equal(t, 2, partial.Total)
+		require.Len(t, partial.Failures, 1)
+		assert.Equal(t, 1, partial.Failures[0].Index)
+		assert.Contains(t, partial.Failures[0].Error, "failed to get config for agent invalid-agent")
+		assert.True(t, partial.Failures[0].Retryable)
+
+		require.Len(t, resps, 2)
+		require.NotNil(t, resps[0])
+		assert.NotEqual(t, uuid.Nil, resps[0].EmbeddingID)
+		assert.Nil(t, resps[1])
+		assert.NoError(t, mockDB.ExpectationsWereMet())
 
 		mockAgentService.AssertExpectations(t)
 	})
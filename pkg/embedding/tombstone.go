@@ -0,0 +1,197 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// Tombstone defaults
+const (
+	DefaultTombstoneRetention     = 7 * 24 * time.Hour
+	DefaultTombstoneSweepInterval = time.Hour
+)
+
+// ErrEmbeddingNotFound is returned when an embedding to delete or restore doesn't
+// exist, or is past its retention window
+var ErrEmbeddingNotFound = errors.New("embedding not found")
+
+// DeleteEmbedding marks an embedding deleted. Deleted embeddings are excluded from
+// search and can be restored until they are purged.
+func (r *Repository) DeleteEmbedding(ctx context.Context, id, tenantID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE mcp.embeddings
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete embedding: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete embedding: %w", err)
+	}
+	if affected == 0 {
+		return ErrEmbeddingNotFound
+	}
+
+	r.metrics.IncrementCounter("embedding.repository.delete.total", 1.0)
+	r.invalidateContent(ctx, id)
+	return nil
+}
+
+// DeleteContextEmbeddings marks all of a context's embeddings deleted and returns
+// how many were deleted
+func (r *Repository) DeleteContextEmbeddings(ctx context.Context, contextID, tenantID uuid.UUID) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE mcp.embeddings
+		SET deleted_at = CURRENT_TIMESTAMP
+		WHERE context_id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, contextID, tenantID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete context embeddings: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete context embeddings: %w", err)
+	}
+
+	r.metrics.IncrementCounter("embedding.repository.delete.total", float64(affected))
+	if affected > 0 {
+		r.invalidateContent(ctx, contextID)
+	}
+	return affected, nil
+}
+
+// RestoreEmbedding undoes the deletion of an embedding deleted within retention
+func (r *Repository) RestoreEmbedding(ctx context.Context, id, tenantID uuid.UUID, retention time.Duration) error {
+	if retention <= 0 {
+		retention = DefaultTombstoneRetention
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE mcp.embeddings
+		SET deleted_at = NULL
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL AND deleted_at > $3
+	`, id, tenantID, time.Now().Add(-retention))
+	if err != nil {
+		return fmt.Errorf("failed to restore embedding: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to restore embedding: %w", err)
+	}
+	if affected == 0 {
+		return ErrEmbeddingNotFound
+	}
+
+	r.metrics.IncrementCounter("embedding.repository.restore.total", 1.0)
+	r.invalidateContent(ctx, id)
+	return nil
+}
+
+// PurgeDeletedEmbeddings permanently removes embeddings deleted before cutoff
+// and returns how many were removed
+func (r *Repository) PurgeDeletedEmbeddings(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM mcp.embeddings
+		WHERE deleted_at IS NOT NULL AND deleted_at <= $1
+	`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted embeddings: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted embeddings: %w", err)
+	}
+
+	r.metrics.IncrementCounter("embedding.repository.purge.total", float64(affected))
+	return affected, nil
+}
+
+// TombstoneSweeperConfig configures a tombstone sweeper
+type TombstoneSweeperConfig struct {
+	// Retention is how long deleted embeddings can be restored before they're purged
+	Retention time.Duration
+	// Interval is how often deleted embeddings past retention are purged
+	Interval time.Duration
+}
+
+// TombstoneSweeper periodically purges embeddings deleted longer ago than the
+// retention window
+type TombstoneSweeper struct {
+	repository *Repository
+	config     TombstoneSweeperConfig
+	logger     observability.Logger
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// NewTombstoneSweeper creates a new tombstone sweeper
+func NewTombstoneSweeper(repository *Repository, config TombstoneSweeperConfig, logger observability.Logger) *TombstoneSweeper {
+	if config.Retention <= 0 {
+		config.Retention = DefaultTombstoneRetention
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultTombstoneSweepInterval
+	}
+	if logger == nil {
+		logger = observability.NewLogger("embedding.tombstone_sweeper")
+	}
+
+	return &TombstoneSweeper{
+		repository: repository,
+		config:     config,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Run purges expired tombstones every Interval until ctx is cancelled
+func (s *TombstoneSweeper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sweep(ctx); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			s.logger.Error("Failed to purge deleted embeddings", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep purges embeddings deleted longer ago than the retention window
+func (s *TombstoneSweeper) Sweep(ctx context.Context) (int64, error) {
+	purged, err := s.repository.PurgeDeletedEmbeddings(ctx, s.now().Add(-s.config.Retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		s.logger.Info("Purged deleted embeddings", map[string]interface{}{
+			"purged":    purged,
+			"retention": s.config.Retention.String(),
+		})
+	}
+	return purged, nil
+}
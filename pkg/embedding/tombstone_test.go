@@ -0,0 +1,132 @@
+package embedding
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var keywordSearchColumns = []string{"id", "context_id", "content", "model_name", "model_dimensions", "metadata", "created_at", "agent_id", "rank"}
+
+// timeNear matches a time argument within a second of the expected time
+type timeNear time.Time
+
+func (t timeNear) Match(v driver.Value) bool {
+	actual, ok := v.(time.Time)
+	if !ok {
+		return false
+	}
+	diff := actual.Sub(time.Time(t))
+	return diff > -time.Second && diff < time.Second
+}
+
+func expectKeywordSearch(mock sqlmock.Sqlmock, tenantID uuid.UUID, ids ...uuid.UUID) {
+	rows := sqlmock.NewRows(keywordSearchColumns)
+	for _, id := range ids {
+		rows.AddRow(id.String(), uuid.New().String(), "deploy the service", "text-embedding-3-small", 1536, []byte(`{}`), time.Now(), "", 1.0)
+	}
+	mock.ExpectQuery(`(?s)FROM mcp\.embeddings e.*WHERE e\.tenant_id = \$2\s+AND e\.deleted_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), tenantID, sqlmock.AnyArg()).
+		WillReturnRows(rows)
+}
+
+func keywordSearchIDs(t *testing.T, service *UnifiedSearchService, tenantID uuid.UUID) []uuid.UUID {
+	t.Helper()
+
+	results, err := service.keywordSearch(context.Background(), HybridSearchRequest{
+		Keywords: []string{"deploy"},
+		TenantID: tenantID,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+
+	ids := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestEmbeddingTombstones(t *testing.T) {
+	service, mock := newReindexTestService(t)
+	repository := service.repository
+	tenantID, id := uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	// Deleting marks the embedding rather than removing it
+	mock.ExpectExec(`UPDATE mcp\.embeddings\s+SET deleted_at = CURRENT_TIMESTAMP\s+WHERE id = \$1 AND tenant_id = \$2 AND deleted_at IS NULL`).
+		WithArgs(id, tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repository.DeleteEmbedding(ctx, id, tenantID))
+
+	// and excludes it from search
+	expectKeywordSearch(mock, tenantID)
+	assert.Empty(t, keywordSearchIDs(t, service, tenantID))
+
+	// Restoring it within retention returns it to search
+	mock.ExpectExec(`UPDATE mcp\.embeddings\s+SET deleted_at = NULL\s+WHERE id = \$1 AND tenant_id = \$2 AND deleted_at IS NOT NULL AND deleted_at > \$3`).
+		WithArgs(id, tenantID, timeNear(time.Now().Add(-time.Hour))).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repository.RestoreEmbedding(ctx, id, tenantID, time.Hour))
+
+	expectKeywordSearch(mock, tenantID, id)
+	assert.Equal(t, []uuid.UUID{id}, keywordSearchIDs(t, service, tenantID))
+
+	// Once retention has passed the sweeper purges it for good
+	mock.ExpectExec(`UPDATE mcp\.embeddings\s+SET deleted_at = CURRENT_TIMESTAMP`).
+		WithArgs(id, tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repository.DeleteEmbedding(ctx, id, tenantID))
+
+	sweeper := NewTombstoneSweeper(repository, TombstoneSweeperConfig{Retention: time.Hour}, service.logger)
+	later := time.Now().Add(2 * time.Hour)
+	sweeper.now = func() time.Time { return later }
+
+	mock.ExpectExec(`DELETE FROM mcp\.embeddings\s+WHERE deleted_at IS NOT NULL AND deleted_at <= \$1`).
+		WithArgs(later.Add(-time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	purged, err := sweeper.Sweep(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	// and it can no longer be restored
+	mock.ExpectExec(`SET deleted_at = NULL`).
+		WithArgs(id, tenantID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repository.RestoreEmbedding(ctx, id, tenantID, time.Hour), ErrEmbeddingNotFound)
+
+	expectKeywordSearch(mock, tenantID)
+	assert.Empty(t, keywordSearchIDs(t, service, tenantID))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteEmbeddingNotFound(t *testing.T) {
+	service, mock := newReindexTestService(t)
+	tenantID, id := uuid.New(), uuid.New()
+
+	// Missing and already deleted embeddings match no rows
+	mock.ExpectExec(`SET deleted_at = CURRENT_TIMESTAMP`).
+		WithArgs(id, tenantID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, service.repository.DeleteEmbedding(context.Background(), id, tenantID), ErrEmbeddingNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCrossModelSearchExcludesDeleted(t *testing.T) {
+	service, _ := newReindexTestService(t)
+
+	query, _ := service.buildCrossModelQuery(CrossModelSearchRequest{
+		QueryEmbedding: []float32{0.1, 0.2},
+		TenantID:       uuid.New(),
+		Limit:          10,
+	}, 1536)
+	assert.Regexp(t, `WHERE e\.tenant_id = \$3\s+AND e\.deleted_at IS NULL`, query)
+}
@@ -33,6 +33,8 @@ type SearchOptions struct {
 	Sorts               []SearchSort           // Sort criteria
 	ContentTypes        []string               // Filter by content types
 	WeightFactors       map[string]float32     // Weights for hybrid search
+	UseANNIndex         bool                   // Search through the HNSW index for the query vector's dimensions
+	EfSearch            int                    // hnsw.ef_search for ANN searches; 0 keeps the server setting
 }
 
 // SearchFilter defines a filter for search operations
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/developer-mesh/developer-mesh/pkg/common"
 	"github.com/developer-mesh/developer-mesh/pkg/observability"
 	"github.com/jmoiron/sqlx"
 )
@@ -21,6 +22,12 @@ type EmbeddingService interface {
 	GenerateBatch(ctx context.Context, texts []string, model string) ([][]float32, error)
 }
 
+// queryer runs search queries on the database or a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // SQLRepository implements the Repository interface using a SQL database
 type SQLRepository struct {
 	db               *sqlx.DB
@@ -253,6 +260,21 @@ func (r *SQLRepository) SearchByVector(ctx context.Context, vector []float32, op
 		distanceOp = "<=>" // Cosine distance
 	}
 
+	// Embeddings are stored zero-padded to 4096 dimensions, beyond what HNSW
+	// can index, so ANN searches compare the leading subvector the index is
+	// built on. The padding doesn't change any of the distances.
+	target, queryVector := "embedding", "$1::vector"
+	if options.UseANNIndex {
+		target = fmt.Sprintf("subvector(embedding, 1, %d)::vector(%d)", len(vector), len(vector))
+		queryVector = fmt.Sprintf("$1::vector(%d)", len(vector))
+	}
+	distance := fmt.Sprintf("%s %s %s", target, distanceOp, queryVector)
+	where := fmt.Sprintf("1 - (%s) > $2", distance)
+	if options.UseANNIndex {
+		// Matches the partial index of this dimension
+		where += fmt.Sprintf(" AND model_dimensions = %d", len(vector))
+	}
+
 	// Build the base query
 	query := fmt.Sprintf(`
 		SELECT 
@@ -261,11 +283,13 @@ func (r *SQLRepository) SearchByVector(ctx context.Context, vector []float32, op
 			text as content,
 			metadata,
 			model_id as type,
-			1 - (embedding %s $1::vector) as similarity
+			1 - (%s) as similarity
 		FROM mcp.embeddings
-		WHERE 1 - (embedding %s $1::vector) > $2`, distanceOp, distanceOp)
+		WHERE %s`, distance, where)
 
-	args := []interface{}{vector, options.MinSimilarity}
+	// database/sql can't bind a []float32, so the vector is sent in pgvector's text format
+	queryVectorArg := common.FormatVectorForPgVector(vector)
+	args := []interface{}{queryVectorArg, options.MinSimilarity}
 	argIndex := 3
 
 	// Add metadata filters if specified
@@ -316,7 +340,7 @@ func (r *SQLRepository) SearchByVector(ctx context.Context, vector []float32, op
 	}
 
 	// Add ordering
-	query += fmt.Sprintf(" ORDER BY embedding %s $1::vector", distanceOp)
+	query += fmt.Sprintf(" ORDER BY %s", distance)
 
 	// Add limit and offset
 	query += fmt.Sprintf(" LIMIT %d", options.Limit)
@@ -324,8 +348,23 @@ func (r *SQLRepository) SearchByVector(ctx context.Context, vector []float32, op
 		query += fmt.Sprintf(" OFFSET %d", options.Offset)
 	}
 
+	// SET LOCAL only lasts for a transaction, so tuned ANN searches run in one
+	var db queryer = r.db
+	if options.UseANNIndex && options.EfSearch > 0 {
+		tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin vector search transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL hnsw.ef_search = %d", options.EfSearch)); err != nil {
+			return nil, fmt.Errorf("failed to set hnsw.ef_search: %w", err)
+		}
+		db = tx
+	}
+
 	// Execute query
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("vector search query failed: %w", err)
 	}
@@ -383,11 +422,11 @@ func (r *SQLRepository) SearchByVector(ctx context.Context, vector []float32, op
 		// Quick check for one more result
 		checkQuery := fmt.Sprintf(`
 			SELECT 1 FROM mcp.embeddings 
-			WHERE 1 - (embedding %s $1::vector) > $2 
-			LIMIT 1 OFFSET %d`, distanceOp, options.Offset+options.Limit)
+			WHERE %s 
+			LIMIT 1 OFFSET %d`, where, options.Offset+options.Limit)
 
 		var exists int
-		err = r.db.QueryRowContext(ctx, checkQuery, vector, options.MinSimilarity).Scan(&exists)
+		err = db.QueryRowContext(ctx, checkQuery, queryVectorArg, options.MinSimilarity).Scan(&exists)
 		hasMore = err == nil
 	}
 
@@ -0,0 +1,61 @@
+package security
+
+import "strings"
+
+// Redacted replaces sensitive values
+const Redacted = "[REDACTED]"
+
+// SensitiveFields are the field, header and parameter names whose values are
+// redacted from audit records, logs and diagnostics: credentials, and PII
+// that turns up in tool arguments and provider payloads. A name is sensitive
+// when it contains any of these, ignoring case.
+var SensitiveFields = []string{
+	"password", "passwd", "token", "secret", "key", "authorization", "credential",
+	"cookie", "session", "signature",
+	"email", "phone", "ssn", "credit_card", "card_number", "cvv",
+}
+
+// IsSensitive reports whether name contains any of the sensitive fields,
+// ignoring case
+func IsSensitive(name string, sensitiveFields []string) bool {
+	name = strings.ToLower(name)
+	for _, field := range sensitiveFields {
+		if strings.Contains(name, strings.ToLower(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactMap returns a copy of data with the values of sensitive fields, at any
+// depth, replaced by Redacted
+func RedactMap(data map[string]interface{}, sensitiveFields []string) map[string]interface{} {
+	if data == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		redacted[k] = redactValue(k, v, sensitiveFields)
+	}
+	return redacted
+}
+
+func redactValue(key string, value interface{}, sensitiveFields []string) interface{} {
+	if IsSensitive(key, sensitiveFields) {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return RedactMap(v, sensitiveFields)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactValue(key, item, sensitiveFields)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
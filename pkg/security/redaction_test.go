@@ -0,0 +1,50 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactMap(t *testing.T) {
+	data := map[string]interface{}{
+		"query":        "open issues",
+		"api_key":      "sk-123",
+		"AccessToken":  "abc",
+		"issue_number": 42,
+		"headers": map[string]interface{}{
+			"Authorization": "Bearer abc",
+			"Accept":        "application/json",
+		},
+		"recipients": []interface{}{
+			map[string]interface{}{"name": "Ada", "email": "ada@example.com"},
+		},
+	}
+
+	redacted := RedactMap(data, SensitiveFields)
+	assert.Equal(t, map[string]interface{}{
+		"query":        "open issues",
+		"api_key":      Redacted,
+		"AccessToken":  Redacted,
+		"issue_number": 42,
+		"headers": map[string]interface{}{
+			"Authorization": Redacted,
+			"Accept":        "application/json",
+		},
+		"recipients": []interface{}{
+			map[string]interface{}{"name": "Ada", "email": Redacted},
+		},
+	}, redacted)
+
+	// The original is left alone
+	assert.Equal(t, "sk-123", data["api_key"])
+	assert.Nil(t, RedactMap(nil, SensitiveFields))
+}
+
+func TestIsSensitive(t *testing.T) {
+	assert.True(t, IsSensitive("X-Api-Key", SensitiveFields))
+	assert.True(t, IsSensitive("Set-Cookie", SensitiveFields))
+	assert.True(t, IsSensitive("JWTSecret", SensitiveFields))
+	assert.False(t, IsSensitive("RequireAuth", SensitiveFields))
+	assert.True(t, IsSensitive("RequireAuth", []string{"AUTH"}))
+}
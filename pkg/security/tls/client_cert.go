@@ -0,0 +1,168 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Client certificate authentication modes
+const (
+	// ClientCertModeAlternative accepts a client certificate instead of an API key or JWT.
+	// Clients without a certificate fall back to token authentication.
+	ClientCertModeAlternative = "alternative"
+	// ClientCertModeAdditional requires a client certificate in addition to an API key or JWT
+	ClientCertModeAdditional = "additional"
+)
+
+// DefaultClientCertURIScheme is the URI SAN scheme carrying a tenant/agent identity,
+// e.g. devmesh://<tenant-id>/<agent-id>
+const DefaultClientCertURIScheme = "devmesh"
+
+var (
+	// ErrNoClientCert is returned when a connection did not present a client certificate
+	ErrNoClientCert = errors.New("no client certificate presented")
+	// ErrInvalidClientCert is returned when a client certificate cannot be mapped to an identity
+	ErrInvalidClientCert = errors.New("invalid client certificate")
+)
+
+// ClientCertConfig configures mutual TLS client certificate authentication
+type ClientCertConfig struct {
+	Enabled      bool   `mapstructure:"enabled"`
+	ClientCAFile string `mapstructure:"client_ca_file"` // PEM bundle of CAs trusted to issue client certs
+	Mode         string `mapstructure:"mode"`           // alternative (default) or additional
+	URIScheme    string `mapstructure:"uri_scheme"`     // URI SAN scheme for identities, defaults to devmesh
+}
+
+// ClientCertIdentity is the tenant/agent identity carried by a client certificate
+type ClientCertIdentity struct {
+	TenantID     string
+	AgentID      string
+	Subject      string
+	SerialNumber string
+}
+
+// ClientCertAuthenticator validates client certificates during the TLS handshake
+// and maps them to tenant/agent identities.
+//
+// The identity is read from a URI SAN of the form <scheme>://<tenant-id>/<agent-id>.
+// If no such SAN is present, the subject Organization is used as the tenant and the
+// subject Common Name as the agent.
+type ClientCertAuthenticator struct {
+	config ClientCertConfig
+	pool   *x509.CertPool
+}
+
+// NewClientCertAuthenticator creates a client certificate authenticator trusting the CAs in config.ClientCAFile
+func NewClientCertAuthenticator(config ClientCertConfig) (*ClientCertAuthenticator, error) {
+	if config.ClientCAFile == "" {
+		return nil, fmt.Errorf("client CA file is required for client certificate authentication")
+	}
+
+	pemData, err := os.ReadFile(config.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", config.ClientCAFile)
+	}
+
+	return NewClientCertAuthenticatorWithPool(config, pool)
+}
+
+// NewClientCertAuthenticatorWithPool creates a client certificate authenticator trusting the given CA pool
+func NewClientCertAuthenticatorWithPool(config ClientCertConfig, pool *x509.CertPool) (*ClientCertAuthenticator, error) {
+	if pool == nil {
+		return nil, fmt.Errorf("client CA pool is required for client certificate authentication")
+	}
+
+	switch config.Mode {
+	case "":
+		config.Mode = ClientCertModeAlternative
+	case ClientCertModeAlternative, ClientCertModeAdditional:
+	default:
+		return nil, fmt.Errorf("unknown client certificate mode: %s", config.Mode)
+	}
+	if config.URIScheme == "" {
+		config.URIScheme = DefaultClientCertURIScheme
+	}
+
+	return &ClientCertAuthenticator{
+		config: config,
+		pool:   pool,
+	}, nil
+}
+
+// Mode returns the configured authentication mode
+func (a *ClientCertAuthenticator) Mode() string {
+	return a.config.Mode
+}
+
+// ConfigureTLS enables client certificate verification on a server TLS config.
+// Certificates are verified against the client CA pool, and certificates that do
+// not carry a tenant/agent identity are rejected during the handshake.
+func (a *ClientCertAuthenticator) ConfigureTLS(tlsConfig *tls.Config) {
+	tlsConfig.ClientCAs = a.pool
+	if a.config.Mode == ClientCertModeAdditional {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return nil
+		}
+		_, err := a.IdentityFromCertificate(state.PeerCertificates[0])
+		return err
+	}
+}
+
+// Authenticate returns the identity of the verified client certificate of a TLS connection.
+// It returns ErrNoClientCert when no verified certificate was presented.
+func (a *ClientCertAuthenticator) Authenticate(state *tls.ConnectionState) (*ClientCertIdentity, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, ErrNoClientCert
+	}
+
+	return a.IdentityFromCertificate(state.VerifiedChains[0][0])
+}
+
+// IdentityFromCertificate maps a client certificate to a tenant/agent identity
+func (a *ClientCertAuthenticator) IdentityFromCertificate(cert *x509.Certificate) (*ClientCertIdentity, error) {
+	if cert == nil {
+		return nil, ErrNoClientCert
+	}
+
+	identity := &ClientCertIdentity{
+		Subject:      cert.Subject.String(),
+		SerialNumber: cert.SerialNumber.String(),
+	}
+
+	for _, uri := range cert.URIs {
+		if !strings.EqualFold(uri.Scheme, a.config.URIScheme) {
+			continue
+		}
+		identity.TenantID = uri.Host
+		identity.AgentID = strings.Trim(uri.Path, "/")
+		break
+	}
+
+	if identity.TenantID == "" && len(cert.Subject.Organization) > 0 {
+		identity.TenantID = cert.Subject.Organization[0]
+	}
+	if identity.AgentID == "" {
+		identity.AgentID = cert.Subject.CommonName
+	}
+
+	if identity.TenantID == "" || identity.AgentID == "" {
+		return nil, fmt.Errorf("%w: no tenant/agent identity in subject or SAN", ErrInvalidClientCert)
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,182 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) issueClientCert(t *testing.T, subject pkix.Name, uris ...string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      subject,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		template.URIs = append(template.URIs, u)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newMTLSServer starts a TLS server that echoes the authenticated identity
+func newMTLSServer(t *testing.T, authenticator *ClientCertAuthenticator) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := authenticator.Authenticate(r.TLS)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(identity.TenantID + "/" + identity.AgentID))
+	}))
+	server.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	authenticator.ConfigureTLS(server.TLS)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// mtlsClient returns a client trusting the test server that always presents cert,
+// even when it was not issued by one of the server's acceptable CAs
+func mtlsClient(server *httptest.Server, cert *tls.Certificate) *http.Client {
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if cert == nil {
+			return &tls.Certificate{}, nil
+		}
+		return cert, nil
+	}
+	return &http.Client{Transport: transport}
+}
+
+func TestClientCertAuthenticatorHandshake(t *testing.T) {
+	trusted := newTestCA(t, "trusted-ca")
+	untrusted := newTestCA(t, "untrusted-ca")
+
+	authenticator, err := NewClientCertAuthenticatorWithPool(ClientCertConfig{Enabled: true}, trusted.pool())
+	require.NoError(t, err)
+	server := newMTLSServer(t, authenticator)
+
+	t.Run("valid certificate authenticates", func(t *testing.T) {
+		cert := trusted.issueClientCert(t, pkix.Name{CommonName: "ignored"}, "devmesh://tenant-1/agent-1")
+
+		resp, err := mtlsClient(server, &cert).Get(server.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, "tenant-1/agent-1", string(body[:n]))
+	})
+
+	t.Run("certificate from untrusted CA is rejected", func(t *testing.T) {
+		cert := untrusted.issueClientCert(t, pkix.Name{CommonName: "agent-1", Organization: []string{"tenant-1"}})
+
+		_, err := mtlsClient(server, &cert).Get(server.URL)
+		assert.Error(t, err, "handshake should fail")
+	})
+
+	t.Run("certificate without identity is rejected", func(t *testing.T) {
+		cert := trusted.issueClientCert(t, pkix.Name{CommonName: "agent-1"})
+
+		_, err := mtlsClient(server, &cert).Get(server.URL)
+		assert.Error(t, err, "handshake should fail")
+	})
+
+	t.Run("no certificate falls through in alternative mode", func(t *testing.T) {
+		resp, err := mtlsClient(server, nil).Get(server.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestClientCertAuthenticatorAdditionalModeRequiresCert(t *testing.T) {
+	trusted := newTestCA(t, "trusted-ca")
+
+	authenticator, err := NewClientCertAuthenticatorWithPool(ClientCertConfig{Enabled: true, Mode: ClientCertModeAdditional}, trusted.pool())
+	require.NoError(t, err)
+	server := newMTLSServer(t, authenticator)
+
+	_, err = mtlsClient(server, nil).Get(server.URL)
+	assert.Error(t, err, "handshake should fail without a client certificate")
+}
+
+func TestIdentityFromCertificateSubjectFallback(t *testing.T) {
+	trusted := newTestCA(t, "trusted-ca")
+	authenticator, err := NewClientCertAuthenticatorWithPool(ClientCertConfig{}, trusted.pool())
+	require.NoError(t, err)
+
+	cert := trusted.issueClientCert(t, pkix.Name{CommonName: "agent-2", Organization: []string{"tenant-2"}})
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	identity, err := authenticator.IdentityFromCertificate(parsed)
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-2", identity.TenantID)
+	assert.Equal(t, "agent-2", identity.AgentID)
+}
@@ -0,0 +1,220 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers carrying the signature of an outbound webhook. The signature covers
+// "<timestamp>.<nonce>.<body>", so neither can be changed without invalidating it.
+const (
+	WebhookTimestampHeader = "X-Webhook-Timestamp" // Unix seconds when the payload was signed
+	WebhookNonceHeader     = "X-Webhook-Nonce"     // Random value unique to the delivery
+	WebhookSignatureHeader = "X-Webhook-Signature" // Comma-separated "v1=<hex HMAC-SHA256>" per signing key
+)
+
+// webhookSignatureVersion prefixes each signature in the signature header
+const webhookSignatureVersion = "v1"
+
+// DefaultWebhookTolerance is how old a signed webhook may be before it's rejected
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// Webhook verification errors
+var (
+	ErrWebhookSignatureMissing = errors.New("webhook signature headers missing")
+	ErrWebhookSignatureInvalid = errors.New("invalid webhook signature")
+	ErrWebhookStale            = errors.New("webhook timestamp outside the tolerance window")
+	ErrWebhookReplayed         = errors.New("webhook nonce already seen")
+)
+
+// SignedWebhook holds the signature headers of a webhook payload
+type SignedWebhook struct {
+	Timestamp int64
+	Nonce     string
+	Signature string
+}
+
+// Apply sets the signature headers on an outbound request
+func (w SignedWebhook) Apply(header http.Header) {
+	header.Set(WebhookTimestampHeader, strconv.FormatInt(w.Timestamp, 10))
+	header.Set(WebhookNonceHeader, w.Nonce)
+	header.Set(WebhookSignatureHeader, w.Signature)
+}
+
+// WebhookSigner signs outbound webhook payloads. During a secret rotation it
+// signs with both the new and the previous secrets, so receivers can switch
+// secrets at their own pace.
+type WebhookSigner struct {
+	mu      sync.RWMutex
+	secrets []string
+	now     func() time.Time
+}
+
+// NewWebhookSigner creates a signer for the secret
+func NewWebhookSigner(secret string) (*WebhookSigner, error) {
+	if secret == "" {
+		return nil, errors.New("webhook signing secret is required")
+	}
+	return &WebhookSigner{secrets: []string{secret}, now: time.Now}, nil
+}
+
+// Rotate starts signing with a new secret alongside the current ones, which
+// keep signing until retired
+func (s *WebhookSigner) Rotate(secret string) error {
+	if secret == "" {
+		return errors.New("webhook signing secret is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets = append([]string{secret}, s.secrets...)
+	return nil
+}
+
+// RetirePrevious stops signing with every secret but the newest
+func (s *WebhookSigner) RetirePrevious() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secrets = s.secrets[:1]
+}
+
+// Sign signs the payload with a fresh timestamp and nonce
+func (s *WebhookSigner) Sign(payload []byte) (SignedWebhook, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return SignedWebhook{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	signed := SignedWebhook{
+		Timestamp: s.now().Unix(),
+		Nonce:     hex.EncodeToString(nonce),
+	}
+
+	s.mu.RLock()
+	signatures := make([]string, len(s.secrets))
+	for i, secret := range s.secrets {
+		signatures[i] = webhookSignatureVersion + "=" + computeWebhookSignature(secret, signed.Timestamp, signed.Nonce, payload)
+	}
+	s.mu.RUnlock()
+
+	signed.Signature = strings.Join(signatures, ",")
+	return signed, nil
+}
+
+// WebhookVerifier verifies signed webhooks on the receiving side. A webhook is
+// accepted when:
+//   - its timestamp is within the tolerance of the current time,
+//   - one of its signatures matches one of the verifier's secrets, and
+//   - its nonce wasn't seen within the tolerance window.
+//
+// Nonces are remembered only as long as their timestamp would be accepted, since
+// older deliveries are rejected as stale anyway.
+type WebhookVerifier struct {
+	secrets   []string
+	tolerance time.Duration
+	now       func() time.Time
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonce -> when it stops being accepted
+}
+
+// NewWebhookVerifier creates a verifier accepting signatures made with any of
+// the secrets, e.g. the new and previous ones during a rotation. A tolerance of
+// zero uses DefaultWebhookTolerance.
+func NewWebhookVerifier(tolerance time.Duration, secrets ...string) (*WebhookVerifier, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("at least one webhook signing secret is required")
+	}
+	for _, secret := range secrets {
+		if secret == "" {
+			return nil, errors.New("webhook signing secret is required")
+		}
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+
+	return &WebhookVerifier{
+		secrets:   secrets,
+		tolerance: tolerance,
+		now:       time.Now,
+		nonces:    make(map[string]time.Time),
+	}, nil
+}
+
+// VerifyRequest verifies the signature headers of a received webhook
+func (v *WebhookVerifier) VerifyRequest(header http.Header, payload []byte) error {
+	timestamp, err := strconv.ParseInt(header.Get(WebhookTimestampHeader), 10, 64)
+	if err != nil {
+		return ErrWebhookSignatureMissing
+	}
+	return v.Verify(SignedWebhook{
+		Timestamp: timestamp,
+		Nonce:     header.Get(WebhookNonceHeader),
+		Signature: header.Get(WebhookSignatureHeader),
+	}, payload)
+}
+
+// Verify checks a webhook's freshness, signature and nonce. The nonce is only
+// recorded once the signature is valid, so forged deliveries can't burn nonces.
+func (v *WebhookVerifier) Verify(signed SignedWebhook, payload []byte) error {
+	if signed.Nonce == "" || signed.Signature == "" {
+		return ErrWebhookSignatureMissing
+	}
+
+	now := v.now()
+	signedAt := time.Unix(signed.Timestamp, 0)
+	if age := now.Sub(signedAt); age > v.tolerance || age < -v.tolerance {
+		return ErrWebhookStale
+	}
+
+	if !v.signatureMatches(signed, payload) {
+		return ErrWebhookSignatureInvalid
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	for nonce, expiresAt := range v.nonces {
+		if now.After(expiresAt) {
+			delete(v.nonces, nonce)
+		}
+	}
+	if _, seen := v.nonces[signed.Nonce]; seen {
+		return ErrWebhookReplayed
+	}
+	v.nonces[signed.Nonce] = signedAt.Add(v.tolerance)
+	return nil
+}
+
+// signatureMatches reports whether any signature in the header was made with
+// any of the verifier's secrets
+func (v *WebhookVerifier) signatureMatches(signed SignedWebhook, payload []byte) bool {
+	for _, secret := range v.secrets {
+		expected := computeWebhookSignature(secret, signed.Timestamp, signed.Nonce, payload)
+		for _, part := range strings.Split(signed.Signature, ",") {
+			version, signature, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if ok && version == webhookSignatureVersion && hmac.Equal([]byte(signature), []byte(expected)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// computeWebhookSignature returns the hex HMAC-SHA256 of "<timestamp>.<nonce>.<payload>"
+func computeWebhookSignature(secret string, timestamp int64, nonce string, payload []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(h, "%d.%s.", timestamp, nonce)
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil))
+}
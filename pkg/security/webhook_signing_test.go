@@ -0,0 +1,133 @@
+package security
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSigning(t *testing.T) {
+	payload := []byte(`{"event":"task.completed","task_id":"task-1"}`)
+
+	newPair := func(t *testing.T) (*WebhookSigner, *WebhookVerifier) {
+		signer, err := NewWebhookSigner("secret-1")
+		require.NoError(t, err)
+		verifier, err := NewWebhookVerifier(time.Minute, "secret-1")
+		require.NoError(t, err)
+		return signer, verifier
+	}
+
+	t.Run("fresh payload verifies", func(t *testing.T) {
+		signer, verifier := newPair(t)
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		header := http.Header{}
+		signed.Apply(header)
+
+		assert.NoError(t, verifier.VerifyRequest(header, payload))
+	})
+
+	t.Run("stale payload is rejected", func(t *testing.T) {
+		signer, verifier := newPair(t)
+		signer.now = func() time.Time { return time.Now().Add(-2 * time.Minute) }
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		assert.ErrorIs(t, verifier.Verify(signed, payload), ErrWebhookStale)
+	})
+
+	t.Run("payload from the future is rejected", func(t *testing.T) {
+		signer, verifier := newPair(t)
+		signer.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		assert.ErrorIs(t, verifier.Verify(signed, payload), ErrWebhookStale)
+	})
+
+	t.Run("replayed nonce is rejected", func(t *testing.T) {
+		signer, verifier := newPair(t)
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(signed, payload))
+		assert.ErrorIs(t, verifier.Verify(signed, payload), ErrWebhookReplayed)
+	})
+
+	t.Run("nonces are forgotten once their timestamp is stale", func(t *testing.T) {
+		signer, verifier := newPair(t)
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(signed, payload))
+
+		later := func() time.Time { return time.Now().Add(2 * time.Minute) }
+		signer.now, verifier.now = later, later
+		fresh, err := signer.Sign(payload)
+		require.NoError(t, err)
+		require.NoError(t, verifier.Verify(fresh, payload))
+		assert.NotContains(t, verifier.nonces, signed.Nonce)
+	})
+
+	t.Run("tampered payload or nonce is rejected", func(t *testing.T) {
+		signer, verifier := newPair(t)
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		assert.ErrorIs(t, verifier.Verify(signed, []byte(`{"event":"task.failed"}`)), ErrWebhookSignatureInvalid)
+
+		signed.Nonce = "0123456789abcdef"
+		assert.ErrorIs(t, verifier.Verify(signed, payload), ErrWebhookSignatureInvalid)
+	})
+
+	t.Run("forged delivery doesn't burn the nonce", func(t *testing.T) {
+		signer, verifier := newPair(t)
+
+		signed, err := signer.Sign(payload)
+		require.NoError(t, err)
+		forged := signed
+		forged.Signature = "v1=" + computeWebhookSignature("wrong-secret", signed.Timestamp, signed.Nonce, payload)
+		assert.ErrorIs(t, verifier.Verify(forged, payload), ErrWebhookSignatureInvalid)
+		assert.NoError(t, verifier.Verify(signed, payload))
+	})
+
+	t.Run("missing headers are rejected", func(t *testing.T) {
+		_, verifier := newPair(t)
+		assert.ErrorIs(t, verifier.VerifyRequest(http.Header{}, payload), ErrWebhookSignatureMissing)
+	})
+}
+
+func TestWebhookSecretRotation(t *testing.T) {
+	payload := []byte(`{"event":"task.completed"}`)
+
+	signer, err := NewWebhookSigner("old-secret")
+	require.NoError(t, err)
+	require.NoError(t, signer.Rotate("new-secret"))
+
+	oldReceiver, err := NewWebhookVerifier(time.Minute, "old-secret")
+	require.NoError(t, err)
+	newReceiver, err := NewWebhookVerifier(time.Minute, "new-secret")
+	require.NoError(t, err)
+
+	// During the rotation, receivers on either secret accept the webhook
+	signed, err := signer.Sign(payload)
+	require.NoError(t, err)
+	assert.NoError(t, oldReceiver.Verify(signed, payload))
+	assert.NoError(t, newReceiver.Verify(signed, payload))
+
+	// Once the previous secret is retired, only the new one verifies
+	signer.RetirePrevious()
+	signed, err = signer.Sign(payload)
+	require.NoError(t, err)
+	assert.ErrorIs(t, oldReceiver.Verify(signed, payload), ErrWebhookSignatureInvalid)
+	assert.NoError(t, newReceiver.Verify(signed, payload))
+
+	// A receiver may also accept both secrets while it switches
+	bothReceiver, err := NewWebhookVerifier(time.Minute, "new-secret", "old-secret")
+	require.NoError(t, err)
+	assert.NoError(t, bothReceiver.Verify(signed, payload))
+}
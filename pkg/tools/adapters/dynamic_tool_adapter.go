@@ -35,6 +35,7 @@ type DynamicToolAdapter struct {
 	resourceResolver     *tools.ResourceScopeResolver
 	allowedOperations    map[string]bool      // Cache of allowed operations based on permissions
 	resourceScope        *tools.ResourceScope // Resource scope for this tool
+	specScheduler        *SpecRefreshScheduler
 }
 
 // NewDynamicToolAdapter creates a new adapter for a dynamic tool
@@ -75,6 +76,28 @@ func NewDynamicToolAdapter(
 	}, nil
 }
 
+// SetSpecRefreshScheduler serves specs the scheduler keeps refreshed before falling back to the cache
+func (a *DynamicToolAdapter) SetSpecRefreshScheduler(scheduler *SpecRefreshScheduler) {
+	a.specScheduler = scheduler
+}
+
+// SetExchangeLogging logs the tool's HTTP exchanges as configured for its provider
+func (a *DynamicToolAdapter) SetExchangeLogging(config ExchangeLoggingConfig) {
+	provider := a.tool.Provider
+	if provider == "" {
+		provider = a.tool.ToolName
+	}
+
+	// Replace rather than stack any logging already configured
+	client := a.httpClient
+	if transport, ok := client.Transport.(*exchangeLoggingTransport); ok {
+		unwrapped := *client
+		unwrapped.Transport = transport.next
+		client = &unwrapped
+	}
+	a.httpClient = config.WrapClient(client, provider, a.logger)
+}
+
 // ListActions returns available actions from the OpenAPI spec
 func (a *DynamicToolAdapter) ListActions(ctx context.Context) ([]models.ToolAction, error) {
 	// Get the OpenAPI spec
@@ -210,8 +233,9 @@ func (a *DynamicToolAdapter) ExecuteAction(ctx context.Context, actionID string,
 		return nil, fmt.Errorf("failed to build request: %w", err)
 	}
 
-	// Decrypt and apply authentication
-	if err := a.applyAuthentication(req); err != nil {
+	// Decrypt and apply authentication as the operation's security schemes expect
+	schemes := tools.SecuritySchemesForOperation(spec, operation, a.authenticator.ExtractSecuritySchemes(spec))
+	if err := a.applyAuthentication(req, schemes); err != nil {
 		return nil, fmt.Errorf("failed to apply authentication: %w", err)
 	}
 
@@ -295,7 +319,8 @@ func (a *DynamicToolAdapter) ExecuteWithPassthrough(
 	}
 
 	// Apply authentication with passthrough support
-	if err := a.applyAuthenticationWithPassthrough(req, passthroughAuth, passthroughConfig); err != nil {
+	schemes := tools.SecuritySchemesForOperation(spec, operation, a.authenticator.ExtractSecuritySchemes(spec))
+	if err := a.applyAuthenticationWithPassthrough(req, schemes, passthroughAuth, passthroughConfig); err != nil {
 		return nil, fmt.Errorf("failed to apply authentication: %w", err)
 	}
 
@@ -348,6 +373,7 @@ func (a *DynamicToolAdapter) ExecuteWithPassthrough(
 // applyAuthenticationWithPassthrough applies authentication with passthrough support
 func (a *DynamicToolAdapter) applyAuthenticationWithPassthrough(
 	req *http.Request,
+	schemes []tools.SecurityScheme,
 	passthroughAuth *models.PassthroughAuthBundle,
 	passthroughConfig *models.EnhancedPassthroughConfig,
 ) error {
@@ -384,7 +410,7 @@ func (a *DynamicToolAdapter) applyAuthenticationWithPassthrough(
 				a.logger.Warn("Passthrough auth failed, falling back to stored credentials", map[string]interface{}{
 					"error": err.Error(),
 				})
-				return a.applyAuthentication(req)
+				return a.applyAuthentication(req, schemes)
 			}
 			return err
 		}
@@ -392,7 +418,7 @@ func (a *DynamicToolAdapter) applyAuthenticationWithPassthrough(
 	}
 
 	// Use stored credentials
-	return a.applyAuthentication(req)
+	return a.applyAuthentication(req, schemes)
 }
 
 // getOpenAPISpec retrieves the OpenAPI spec from cache or fetches it
@@ -407,8 +433,15 @@ func (a *DynamicToolAdapter) getOpenAPISpec(ctx context.Context) (*openapi3.T, e
 		return nil, fmt.Errorf("no OpenAPI spec URL found in tool configuration")
 	}
 
-	// Try cache first
-	spec, err := a.specCache.Get(ctx, specURL)
+	// Prefer the proactively refreshed spec, then the cache
+	var spec *openapi3.T
+	var err error
+	if a.specScheduler != nil {
+		spec = a.specScheduler.ActiveSpec(specURL)
+	}
+	if spec == nil {
+		spec, err = a.specCache.Get(ctx, specURL)
+	}
 	if err == nil {
 		a.logger.Debug("Loaded OpenAPI spec from cache", map[string]interface{}{
 			"tool_name": a.tool.ToolName,
@@ -549,7 +582,7 @@ func (a *DynamicToolAdapter) getOpenAPISpec(ctx context.Context) (*openapi3.T, e
 		}
 
 		// Success! Cache the spec
-		if err := a.specCache.Set(ctx, specURL, spec, 24*time.Hour); err != nil {
+		if err := a.specCache.Set(ctx, specURL, spec, DefaultSpecCacheTTL); err != nil {
 			a.logger.Warn("Failed to cache OpenAPI spec", map[string]interface{}{
 				"url":   specURL,
 				"error": err.Error(),
@@ -910,8 +943,9 @@ func (a *DynamicToolAdapter) buildRequest(
 	return req, nil
 }
 
-// applyAuthentication applies authentication to the request
-func (a *DynamicToolAdapter) applyAuthentication(req *http.Request) error {
+// applyAuthentication applies the tool's stored credentials to the request in
+// the location and format the given security schemes expect
+func (a *DynamicToolAdapter) applyAuthentication(req *http.Request, schemes []tools.SecurityScheme) error {
 	// Decrypt credentials if encrypted
 	var creds *models.TokenCredential
 
@@ -926,7 +960,7 @@ func (a *DynamicToolAdapter) applyAuthentication(req *http.Request) error {
 
 	// Apply authentication
 	if creds != nil {
-		if err := a.authenticator.ApplyAuthentication(req, creds); err != nil {
+		if err := a.authenticator.ApplySecuritySchemes(req, creds, schemes); err != nil {
 			return err
 		}
 	}
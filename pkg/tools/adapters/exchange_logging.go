@@ -0,0 +1,246 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/developer-mesh/developer-mesh/pkg/security"
+)
+
+// Exchange logging defaults
+const (
+	DefaultExchangeLogMaxBodyBytes = 4096
+	exchangeLogRedacted            = security.Redacted
+)
+
+var (
+	exchangeLogEmailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	exchangeLogBearerPattern = regexp.MustCompile(`(?i)\b(bearer|basic|token)\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// ExchangeLogConfig configures logging of one provider's HTTP exchanges
+type ExchangeLogConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Level is the log level exchanges are logged at: "debug" (default) or "info"
+	Level string `json:"level" mapstructure:"level"`
+	// LogBodies includes redacted request and response bodies
+	LogBodies bool `json:"log_bodies" mapstructure:"log_bodies"`
+	// MaxBodyBytes caps how much of each body is logged
+	MaxBodyBytes int `json:"max_body_bytes" mapstructure:"max_body_bytes"`
+}
+
+// ExchangeLoggingConfig configures opt-in logging of provider HTTP exchanges
+// for debugging integrations. Credentials and PII are masked before logging.
+type ExchangeLoggingConfig struct {
+	Default ExchangeLogConfig `json:"default" mapstructure:"default"`
+	// Providers overrides Default by provider name
+	Providers map[string]ExchangeLogConfig `json:"providers" mapstructure:"providers"`
+	// SensitiveFields are the header, query parameter and body field names
+	// whose values are masked, security.SensitiveFields when empty
+	SensitiveFields []string `json:"sensitive_fields" mapstructure:"sensitive_fields"`
+}
+
+// ForProvider returns the logging configuration for a provider
+func (c ExchangeLoggingConfig) ForProvider(provider string) ExchangeLogConfig {
+	config := c.Default
+	if override, ok := c.Providers[provider]; ok {
+		config = override
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultExchangeLogMaxBodyBytes
+	}
+	return config
+}
+
+// WrapClient returns a client that logs the provider's exchanges, or the
+// client unchanged when logging is disabled for the provider
+func (c ExchangeLoggingConfig) WrapClient(client *http.Client, provider string, logger observability.Logger) *http.Client {
+	config := c.ForProvider(provider)
+	if !config.Enabled || logger == nil {
+		return client
+	}
+
+	sensitiveFields := c.SensitiveFields
+	if len(sensitiveFields) == 0 {
+		sensitiveFields = security.SensitiveFields
+	}
+
+	wrapped := *client
+	wrapped.Transport = &exchangeLoggingTransport{
+		next:     client.Transport,
+		provider: provider,
+		config:   config,
+		redactor: exchangeRedactor{sensitiveFields: sensitiveFields},
+		logger:   logger,
+	}
+	return &wrapped
+}
+
+// exchangeLoggingTransport logs the redacted exchanges made through it
+type exchangeLoggingTransport struct {
+	next     http.RoundTripper
+	provider string
+	config   ExchangeLogConfig
+	redactor exchangeRedactor
+	logger   observability.Logger
+}
+
+func (t *exchangeLoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	fields := map[string]interface{}{
+		"provider":        t.provider,
+		"method":          req.Method,
+		"url":             t.redactor.URL(req.URL),
+		"request_headers": t.redactor.Headers(req.Header),
+	}
+	// Only bodies that can be re-read are logged, so the request is sent intact
+	if t.config.LogBodies && req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, truncated := readExchangeBody(body, t.config.MaxBodyBytes)
+			_ = body.Close()
+			fields["request_body"] = t.redactor.Body(data, req.Header.Get("Content-Type"), truncated)
+		}
+	}
+
+	startTime := time.Now()
+	resp, err := next.RoundTrip(req)
+	fields["duration_ms"] = time.Since(startTime).Milliseconds()
+	if err != nil {
+		fields["error"] = err.Error()
+		t.log("Provider request failed", fields)
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	fields["response_headers"] = t.redactor.Headers(resp.Header)
+	if t.config.LogBodies && resp.Body != nil {
+		data, truncated := readExchangeBody(resp.Body, t.config.MaxBodyBytes)
+		// Put back what was read so the caller still sees the whole body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+		fields["response_body"] = t.redactor.Body(data, resp.Header.Get("Content-Type"), truncated)
+	}
+
+	t.log("Provider exchange", fields)
+	return resp, nil
+}
+
+func (t *exchangeLoggingTransport) log(msg string, fields map[string]interface{}) {
+	if strings.EqualFold(t.config.Level, "info") {
+		t.logger.Info(msg, fields)
+		return
+	}
+	t.logger.Debug(msg, fields)
+}
+
+// readExchangeBody reads up to limit bytes of a body and reports whether there was more
+func readExchangeBody(body io.Reader, limit int) ([]byte, bool) {
+	data, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	if len(data) > limit {
+		return data[:limit], true
+	}
+	return data, false
+}
+
+// exchangeRedactor masks credentials and PII in logged exchanges
+type exchangeRedactor struct {
+	sensitiveFields []string
+}
+
+func (r exchangeRedactor) sensitive(name string) bool {
+	return security.IsSensitive(name, r.sensitiveFields)
+}
+
+// Headers returns the headers with sensitive values masked
+func (r exchangeRedactor) Headers(headers http.Header) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name, values := range headers {
+		if r.sensitive(name) {
+			redacted[name] = exchangeLogRedacted
+			continue
+		}
+		redacted[name] = r.Text(strings.Join(values, ", "))
+	}
+	return redacted
+}
+
+// URL returns the URL with user info and sensitive query parameters masked
+func (r exchangeRedactor) URL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redacted := *u
+	if redacted.User != nil {
+		redacted.User = url.User(exchangeLogRedacted)
+	}
+	if redacted.RawQuery != "" {
+		redacted.RawQuery = r.values(redacted.Query()).Encode()
+	}
+	return redacted.String()
+}
+
+func (r exchangeRedactor) values(values url.Values) url.Values {
+	for name, vals := range values {
+		for i := range vals {
+			if r.sensitive(name) {
+				vals[i] = exchangeLogRedacted
+			} else {
+				vals[i] = r.Text(vals[i])
+			}
+		}
+	}
+	return values
+}
+
+// Body returns the body with sensitive fields masked. JSON and form bodies are
+// redacted by field name; anything else, and truncated JSON, by pattern.
+func (r exchangeRedactor) Body(data []byte, contentType string, truncated bool) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	body := ""
+	switch {
+	case !truncated && strings.Contains(contentType, "json"):
+		var parsed interface{}
+		if err := json.Unmarshal(data, &parsed); err == nil {
+			// Wrapping lets arrays and scalars go through the same redaction as objects
+			redacted := security.RedactMap(map[string]interface{}{"body": parsed}, r.sensitiveFields)
+			if encoded, err := json.Marshal(redacted["body"]); err == nil {
+				body = string(encoded)
+			}
+		}
+	case !truncated && strings.Contains(contentType, "application/x-www-form-urlencoded"):
+		if values, err := url.ParseQuery(string(data)); err == nil {
+			body = r.values(values).Encode()
+		}
+	}
+	if body == "" {
+		body = string(data)
+	}
+
+	body = r.Text(body)
+	if truncated {
+		body += "...[truncated]"
+	}
+	return body
+}
+
+// Text masks emails and authorization tokens in free text
+func (r exchangeRedactor) Text(text string) string {
+	text = exchangeLogEmailPattern.ReplaceAllString(text, exchangeLogRedacted)
+	return exchangeLogBearerPattern.ReplaceAllString(text, "$1 "+exchangeLogRedacted)
+}
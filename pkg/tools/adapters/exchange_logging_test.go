@@ -0,0 +1,178 @@
+package adapters
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+)
+
+// levelRecordingLogger records the level each entry was logged at
+type levelRecordingLogger struct {
+	mockLogger
+	levels []string
+}
+
+func (l *levelRecordingLogger) Debug(msg string, fields map[string]interface{}) {
+	l.levels = append(l.levels, "debug")
+	l.mockLogger.Debug(msg, fields)
+}
+
+func (l *levelRecordingLogger) Info(msg string, fields map[string]interface{}) {
+	l.levels = append(l.levels, "info")
+	l.mockLogger.Info(msg, fields)
+}
+
+func newExchangeTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":42,"owner":{"login":"octocat","email":"octo@example.com"},"access_token":"gho_secret"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func sendExchange(t *testing.T, client *http.Client, serverURL string) string {
+	t.Helper()
+
+	req, err := http.NewRequest("POST", serverURL+"/repos?per_page=10&access_token=qs-secret",
+		strings.NewReader(`{"title":"Bug","password":"hunter2","notes":"contact jane@example.com"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer ghp_live_token")
+	req.Header.Set("X-API-Key", "key-123")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExchangeLoggingRedactsCredentials(t *testing.T) {
+	server := newExchangeTestServer(t)
+	logger := &levelRecordingLogger{}
+	config := ExchangeLoggingConfig{
+		Providers: map[string]ExchangeLogConfig{
+			"github": {Enabled: true, Level: "info", LogBodies: true},
+		},
+	}
+
+	client := config.WrapClient(&http.Client{}, "github", logger)
+	body := sendExchange(t, client, server.URL)
+
+	// The caller still gets the unredacted response
+	assert.Contains(t, body, "gho_secret")
+
+	require.Len(t, logger.logs, 1)
+	assert.Equal(t, []string{"info"}, logger.levels)
+	entry := logger.logs[0]
+
+	assert.Equal(t, "github", entry["provider"])
+	assert.Equal(t, "POST", entry["method"])
+	assert.Equal(t, http.StatusCreated, entry["status"])
+	assert.Contains(t, entry, "duration_ms")
+
+	assert.Contains(t, entry["url"], "per_page=10")
+	assert.Contains(t, entry["url"], "access_token=%5BREDACTED%5D")
+	assert.NotContains(t, entry["url"], "qs-secret")
+
+	requestHeaders := entry["request_headers"].(map[string]string)
+	assert.Equal(t, "[REDACTED]", requestHeaders["Authorization"])
+	assert.Equal(t, "[REDACTED]", requestHeaders["X-Api-Key"])
+	assert.Equal(t, "application/json", requestHeaders["Accept"])
+	assert.Equal(t, "[REDACTED]", entry["response_headers"].(map[string]string)["Set-Cookie"])
+
+	requestBody := entry["request_body"].(string)
+	assert.Contains(t, requestBody, `"title":"Bug"`)
+	assert.NotContains(t, requestBody, "hunter2")
+	assert.NotContains(t, requestBody, "jane@example.com")
+
+	responseBody := entry["response_body"].(string)
+	assert.Contains(t, responseBody, `"login":"octocat"`)
+	assert.NotContains(t, responseBody, "octo@example.com")
+	assert.NotContains(t, responseBody, "gho_secret")
+}
+
+func TestExchangeLoggingConfiguration(t *testing.T) {
+	server := newExchangeTestServer(t)
+
+	t.Run("disabled by default", func(t *testing.T) {
+		logger := &levelRecordingLogger{}
+		base := &http.Client{}
+
+		client := ExchangeLoggingConfig{}.WrapClient(base, "github", logger)
+		assert.Same(t, base, client)
+
+		sendExchange(t, client, server.URL)
+		assert.Empty(t, logger.logs)
+	})
+
+	t.Run("provider overrides the default", func(t *testing.T) {
+		logger := &levelRecordingLogger{}
+		config := ExchangeLoggingConfig{
+			Default:   ExchangeLogConfig{Enabled: true},
+			Providers: map[string]ExchangeLogConfig{"jira": {Enabled: false}},
+		}
+
+		sendExchange(t, config.WrapClient(&http.Client{}, "jira", logger), server.URL)
+		assert.Empty(t, logger.logs)
+
+		sendExchange(t, config.WrapClient(&http.Client{}, "github", logger), server.URL)
+		require.Len(t, logger.logs, 1)
+		assert.Equal(t, []string{"debug"}, logger.levels)
+		// Bodies are only logged when asked for
+		assert.NotContains(t, logger.logs[0], "request_body")
+		assert.NotContains(t, logger.logs[0], "response_body")
+	})
+
+	t.Run("bodies are truncated", func(t *testing.T) {
+		logger := &levelRecordingLogger{}
+		config := ExchangeLoggingConfig{
+			Default: ExchangeLogConfig{Enabled: true, LogBodies: true, MaxBodyBytes: 16},
+		}
+
+		body := sendExchange(t, config.WrapClient(&http.Client{}, "github", logger), server.URL)
+		assert.Contains(t, body, "gho_secret")
+
+		require.Len(t, logger.logs, 1)
+		assert.Equal(t, `{"id":42,"owner"...[truncated]`, logger.logs[0]["response_body"])
+	})
+}
+
+func TestDynamicToolAdapterExchangeLogging(t *testing.T) {
+	server := newExchangeTestServer(t)
+	logger := &levelRecordingLogger{}
+
+	tool := &models.DynamicTool{ToolName: "github-repos", Provider: "github", BaseURL: server.URL}
+	adapter, err := NewDynamicToolAdapter(tool, nil, nil, logger)
+	require.NoError(t, err)
+
+	config := ExchangeLoggingConfig{
+		Providers: map[string]ExchangeLogConfig{"github": {Enabled: true}},
+	}
+	adapter.SetExchangeLogging(config)
+	// Setting it again replaces rather than stacks the logging
+	adapter.SetExchangeLogging(config)
+
+	sendExchange(t, adapter.httpClient, server.URL)
+	require.Len(t, logger.logs, 1)
+	assert.Equal(t, "github", logger.logs[0]["provider"])
+
+	adapter.SetExchangeLogging(ExchangeLoggingConfig{})
+	sendExchange(t, adapter.httpClient, server.URL)
+	assert.Len(t, logger.logs, 1)
+}
@@ -382,8 +382,8 @@ func (a *OpenAPIAdapter) sanitizePrefix(s string) string {
 
 // AuthenticateRequest adds authentication to HTTP requests based on OpenAPI security schemes
 func (a *OpenAPIAdapter) AuthenticateRequest(req *http.Request, creds *models.TokenCredential, securitySchemes map[string]tools.SecurityScheme) error {
-	// Use the dynamic authenticator to apply authentication
-	return a.auth.ApplyAuthentication(req, creds)
+	// Use the dynamic authenticator to apply credentials as the schemes expect
+	return a.auth.ApplySecuritySchemes(req, creds, tools.SecuritySchemesForOperation(nil, nil, securitySchemes))
 }
 
 // TestConnection tests the connection to the tool
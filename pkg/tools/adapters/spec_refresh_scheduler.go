@@ -0,0 +1,276 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+	"github.com/developer-mesh/developer-mesh/pkg/repository"
+)
+
+// Spec refresh defaults
+const (
+	DefaultSpecCacheTTL        = 24 * time.Hour
+	DefaultSpecRefreshInterval = 20 * time.Hour
+	DefaultSpecRefreshJitter   = 0.1
+)
+
+// SpecRefreshConfig configures proactive OpenAPI spec refreshes
+type SpecRefreshConfig struct {
+	// DefaultInterval is how often provider specs are refreshed
+	DefaultInterval time.Duration
+	// CacheTTL is how long refreshed specs stay in the spec cache. Refresh
+	// intervals must be shorter so specs are replaced before they expire.
+	CacheTTL time.Duration
+	// Jitter is the fraction of the interval each refresh is brought forward by
+	// at random, so providers don't all refresh at once
+	Jitter float64
+	// Intervals overrides DefaultInterval by provider name
+	Intervals map[string]time.Duration
+}
+
+// SpecFetcher fetches an OpenAPI spec from its source
+type SpecFetcher interface {
+	FetchSpec(ctx context.Context, specURL string) (*openapi3.T, error)
+}
+
+// HTTPSpecFetcher fetches OpenAPI specs over HTTP
+type HTTPSpecFetcher struct {
+	client *http.Client
+}
+
+// NewHTTPSpecFetcher creates a new HTTP spec fetcher
+func NewHTTPSpecFetcher(client *http.Client) *HTTPSpecFetcher {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPSpecFetcher{client: client}
+}
+
+// FetchSpec downloads and parses the spec at specURL
+func (f *HTTPSpecFetcher) FetchSpec(ctx context.Context, specURL string) (*openapi3.T, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", specURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	// Same 50MB limit as request-path fetches
+	bodyData, err := io.ReadAll(io.LimitReader(resp.Body, 50*1024*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	spec, err := loader.LoadFromData(bodyData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	return spec, nil
+}
+
+// specProvider is a provider whose spec is refreshed on a schedule
+type specProvider struct {
+	name     string
+	specURL  string
+	interval time.Duration
+	spec     atomic.Pointer[openapi3.T]
+}
+
+// SpecRefreshScheduler refreshes provider specs in the background before they
+// expire from the spec cache, so requests never wait on a spec fetch
+type SpecRefreshScheduler struct {
+	fetcher SpecFetcher
+	cache   repository.OpenAPICacheRepository
+	config  SpecRefreshConfig
+	logger  observability.Logger
+
+	mu        sync.RWMutex
+	providers map[string]*specProvider // spec URL -> provider
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+
+	// jitter returns how far to bring a refresh forward; replaced in tests
+	jitter func(interval time.Duration) time.Duration
+}
+
+// NewSpecRefreshScheduler creates a new spec refresh scheduler. cache may be nil
+// when refreshed specs only need to be served from memory.
+func NewSpecRefreshScheduler(
+	fetcher SpecFetcher,
+	cache repository.OpenAPICacheRepository,
+	config SpecRefreshConfig,
+	logger observability.Logger,
+) *SpecRefreshScheduler {
+	if config.DefaultInterval <= 0 {
+		config.DefaultInterval = DefaultSpecRefreshInterval
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultSpecCacheTTL
+	}
+	if config.Jitter < 0 || config.Jitter >= 1 {
+		config.Jitter = DefaultSpecRefreshJitter
+	}
+
+	s := &SpecRefreshScheduler{
+		fetcher:   fetcher,
+		cache:     cache,
+		config:    config,
+		logger:    logger,
+		providers: make(map[string]*specProvider),
+	}
+	s.jitter = func(interval time.Duration) time.Duration {
+		return time.Duration(rand.Float64() * s.config.Jitter * float64(interval))
+	}
+	return s
+}
+
+// Register schedules refreshes of a provider's spec. Providers registered after
+// Start begin refreshing immediately.
+func (s *SpecRefreshScheduler) Register(name, specURL string) error {
+	interval := s.config.DefaultInterval
+	if override, ok := s.config.Intervals[name]; ok && override > 0 {
+		interval = override
+	}
+	if interval >= s.config.CacheTTL {
+		return fmt.Errorf("refresh interval %s for provider %s must be shorter than the spec cache TTL %s",
+			interval, name, s.config.CacheTTL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.providers[specURL]; exists {
+		return fmt.Errorf("spec %s is already scheduled for refresh", specURL)
+	}
+
+	provider := &specProvider{name: name, specURL: specURL, interval: interval}
+	s.providers[specURL] = provider
+	if s.ctx != nil {
+		s.startProvider(provider)
+	}
+	return nil
+}
+
+// Start begins refreshing registered providers until ctx is cancelled or Stop is called
+func (s *SpecRefreshScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.logger.Info("Starting spec refresh scheduler", map[string]interface{}{
+		"providers":        len(s.providers),
+		"default_interval": s.config.DefaultInterval.String(),
+	})
+
+	for _, provider := range s.providers {
+		s.startProvider(provider)
+	}
+}
+
+// Stop stops refreshing and waits for in-progress refreshes to finish
+func (s *SpecRefreshScheduler) Stop() {
+	s.mu.Lock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// ActiveSpec returns the most recently refreshed spec for specURL, or nil if
+// it isn't scheduled or hasn't been refreshed yet
+func (s *SpecRefreshScheduler) ActiveSpec(specURL string) *openapi3.T {
+	s.mu.RLock()
+	provider, ok := s.providers[specURL]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return provider.spec.Load()
+}
+
+// startProvider must be called with s.mu held
+func (s *SpecRefreshScheduler) startProvider(provider *specProvider) {
+	ctx := s.ctx
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		// Spread the first refreshes across the jitter window
+		timer := time.NewTimer(s.jitter(provider.interval))
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			next := provider.interval - s.jitter(provider.interval)
+			if err := s.refresh(ctx, provider); err != nil {
+				// Retry well before the cached spec expires
+				next = provider.interval / 10
+			}
+			timer.Reset(next)
+		}
+	}()
+}
+
+// refresh fetches a provider's spec and swaps it in
+func (s *SpecRefreshScheduler) refresh(ctx context.Context, provider *specProvider) error {
+	start := time.Now()
+	spec, err := s.fetcher.FetchSpec(ctx, provider.specURL)
+	if err != nil {
+		s.logger.Warn("Failed to refresh OpenAPI spec", map[string]interface{}{
+			"provider": provider.name,
+			"spec_url": provider.specURL,
+			"error":    err.Error(),
+		})
+		return err
+	}
+
+	provider.spec.Store(spec)
+
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, provider.specURL, spec, s.config.CacheTTL); err != nil {
+			s.logger.Warn("Failed to cache refreshed OpenAPI spec", map[string]interface{}{
+				"provider": provider.name,
+				"spec_url": provider.specURL,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	s.logger.Debug("Refreshed OpenAPI spec", map[string]interface{}{
+		"provider": provider.name,
+		"spec_url": provider.specURL,
+		"duration": time.Since(start).String(),
+	})
+	return nil
+}
@@ -0,0 +1,213 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/developer-mesh/developer-mesh/pkg/observability"
+)
+
+// fakeSpecSource serves a new spec version on every fetch
+type fakeSpecSource struct {
+	fetches atomic.Int32
+	fail    atomic.Bool
+}
+
+func (f *fakeSpecSource) FetchSpec(ctx context.Context, specURL string) (*openapi3.T, error) {
+	if f.fail.Load() {
+		return nil, errors.New("source unavailable")
+	}
+	version := f.fetches.Add(1)
+	return &openapi3.T{
+		OpenAPI: "3.0.0",
+		Info:    &openapi3.Info{Title: specURL, Version: fmt.Sprintf("%d", version)},
+		Paths:   openapi3.NewPaths(),
+	}, nil
+}
+
+// fakeSpecCache records cached specs and when they expire
+type fakeSpecCache struct {
+	mu      sync.Mutex
+	specs   map[string]*openapi3.T
+	expires map[string]time.Time
+	gets    atomic.Int32
+}
+
+func newFakeSpecCache() *fakeSpecCache {
+	return &fakeSpecCache{specs: make(map[string]*openapi3.T), expires: make(map[string]time.Time)}
+}
+
+func (c *fakeSpecCache) Get(ctx context.Context, url string) (*openapi3.T, error) {
+	c.gets.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	spec, ok := c.specs[url]
+	if !ok || time.Now().After(c.expires[url]) {
+		return nil, errors.New("not cached")
+	}
+	return spec, nil
+}
+
+func (c *fakeSpecCache) Set(ctx context.Context, url string, spec *openapi3.T, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.specs[url] = spec
+	c.expires[url] = time.Now().Add(ttl)
+	return nil
+}
+
+func (c *fakeSpecCache) Invalidate(ctx context.Context, url string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.specs, url)
+	return nil
+}
+
+func (c *fakeSpecCache) GetByHash(ctx context.Context, url, hash string) (*openapi3.T, error) {
+	return c.Get(ctx, url)
+}
+
+func (c *fakeSpecCache) expiry(url string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.expires[url]
+}
+
+func activeVersion(s *SpecRefreshScheduler, specURL string) string {
+	if spec := s.ActiveSpec(specURL); spec != nil {
+		return spec.Info.Version
+	}
+	return ""
+}
+
+func TestSpecRefreshSchedulerRefreshesBeforeExpiry(t *testing.T) {
+	source := &fakeSpecSource{}
+	cache := newFakeSpecCache()
+	scheduler := NewSpecRefreshScheduler(source, cache, SpecRefreshConfig{
+		DefaultInterval: 40 * time.Millisecond,
+		CacheTTL:        200 * time.Millisecond,
+		Jitter:          0.5,
+	}, observability.NewNoopLogger())
+	require.NoError(t, scheduler.Register("github", "https://example.com/github.json"))
+
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+
+	require.Eventually(t, func() bool {
+		return activeVersion(scheduler, "https://example.com/github.json") == "1"
+	}, time.Second, 5*time.Millisecond)
+	firstExpiry := cache.expiry("https://example.com/github.json")
+
+	// The next version is swapped in and cached before the first one expires
+	require.Eventually(t, func() bool {
+		return activeVersion(scheduler, "https://example.com/github.json") == "2"
+	}, time.Second, 5*time.Millisecond)
+	assert.True(t, time.Now().Before(firstExpiry))
+	assert.True(t, cache.expiry("https://example.com/github.json").After(firstExpiry))
+
+	// Reading the active spec never fetches
+	fetches := source.fetches.Load()
+	scheduler.Stop()
+	for i := 0; i < 10; i++ {
+		scheduler.ActiveSpec("https://example.com/github.json")
+	}
+	assert.Equal(t, fetches, source.fetches.Load())
+}
+
+func TestSpecRefreshSchedulerKeepsSpecWhenRefreshFails(t *testing.T) {
+	source := &fakeSpecSource{}
+	scheduler := NewSpecRefreshScheduler(source, nil, SpecRefreshConfig{
+		DefaultInterval: 20 * time.Millisecond,
+		CacheTTL:        time.Second,
+	}, observability.NewNoopLogger())
+	require.NoError(t, scheduler.Register("jira", "https://example.com/jira.json"))
+
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+
+	require.Eventually(t, func() bool {
+		return scheduler.ActiveSpec("https://example.com/jira.json") != nil
+	}, time.Second, 5*time.Millisecond)
+
+	source.fail.Store(true)
+	spec := scheduler.ActiveSpec("https://example.com/jira.json")
+	time.Sleep(60 * time.Millisecond)
+	assert.Same(t, spec, scheduler.ActiveSpec("https://example.com/jira.json"))
+}
+
+func TestSpecRefreshSchedulerIntervals(t *testing.T) {
+	scheduler := NewSpecRefreshScheduler(&fakeSpecSource{}, nil, SpecRefreshConfig{
+		DefaultInterval: time.Hour,
+		CacheTTL:        2 * time.Hour,
+		Jitter:          0.2,
+		Intervals: map[string]time.Duration{
+			"github": 30 * time.Minute,
+			"legacy": 3 * time.Hour,
+		},
+	}, observability.NewNoopLogger())
+
+	require.NoError(t, scheduler.Register("github", "https://example.com/github.json"))
+	require.NoError(t, scheduler.Register("jira", "https://example.com/jira.json"))
+	assert.Equal(t, 30*time.Minute, scheduler.providers["https://example.com/github.json"].interval)
+	assert.Equal(t, time.Hour, scheduler.providers["https://example.com/jira.json"].interval)
+
+	// Intervals that would let the cached spec expire are rejected
+	assert.ErrorContains(t, scheduler.Register("legacy", "https://example.com/legacy.json"), "must be shorter")
+	assert.ErrorContains(t, scheduler.Register("jira", "https://example.com/jira.json"), "already scheduled")
+
+	// Jitter only brings refreshes forward, within the configured fraction
+	for i := 0; i < 100; i++ {
+		jitter := scheduler.jitter(time.Hour)
+		assert.GreaterOrEqual(t, jitter, time.Duration(0))
+		assert.Less(t, jitter, 12*time.Minute)
+	}
+}
+
+func TestDynamicToolAdapterUsesRefreshedSpec(t *testing.T) {
+	requests := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	specURL := server.URL + "/openapi.json"
+
+	scheduler := NewSpecRefreshScheduler(&fakeSpecSource{}, nil, SpecRefreshConfig{
+		DefaultInterval: time.Hour,
+		CacheTTL:        2 * time.Hour,
+	}, observability.NewNoopLogger())
+	scheduler.jitter = func(time.Duration) time.Duration { return 0 }
+	require.NoError(t, scheduler.Register("api", specURL))
+	scheduler.Start(context.Background())
+	defer scheduler.Stop()
+	require.Eventually(t, func() bool {
+		return scheduler.ActiveSpec(specURL) != nil
+	}, time.Second, 5*time.Millisecond)
+
+	cache := newFakeSpecCache()
+	adapter, err := NewDynamicToolAdapter(&models.DynamicTool{
+		ID:       "tool-1",
+		ToolName: "api",
+		Config:   map[string]interface{}{"spec_url": specURL},
+	}, cache, nil, observability.NewNoopLogger())
+	require.NoError(t, err)
+	adapter.SetSpecRefreshScheduler(scheduler)
+
+	spec, err := adapter.getOpenAPISpec(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, scheduler.ActiveSpec(specURL), spec)
+	assert.Equal(t, int32(0), cache.gets.Load())
+	assert.Equal(t, int32(0), requests.Load())
+}
@@ -1,11 +1,17 @@
 package tools
 
 import (
+	"context"
+	"fmt"
+	"sort"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/embedding"
+	"github.com/developer-mesh/developer-mesh/pkg/embedding/providers"
 )
 
 func TestSchemaGenerator_GenerateMCPSchema(t *testing.T) {
@@ -191,3 +197,72 @@ func TestSchemaGenerator_GenerateOperationSchemas(t *testing.T) {
 		assert.Contains(t, props, "body")
 	}
 }
+
+// batchAgentService satisfies embedding.AgentService for batch generation,
+// which doesn't consult agent configuration
+type batchAgentService struct {
+	embedding.AgentService
+}
+
+func TestSchemaGenerator_IndexSpecWithBatchEmbeddings(t *testing.T) {
+	const operations = 300
+
+	paths := make([]openapi3.NewPathsOption, 0, operations)
+	for i := 0; i < operations; i++ {
+		paths = append(paths, openapi3.WithPath(fmt.Sprintf("/repos/{owner}/resource%d", i), &openapi3.PathItem{
+			Get: &openapi3.Operation{
+				OperationID: fmt.Sprintf("getResource%d", i),
+				Summary:     fmt.Sprintf("Get resource %d of a repository", i),
+			},
+		}))
+	}
+	spec := &openapi3.T{
+		OpenAPI: "3.0.0",
+		Info:    &openapi3.Info{Title: "Large API", Version: "1.0.0"},
+		Paths:   openapi3.NewPaths(paths...),
+	}
+
+	schemas, err := NewSchemaGenerator().GenerateOperationSchemas(spec)
+	require.NoError(t, err)
+	require.Len(t, schemas, operations)
+
+	// Index every operation of the spec in one batch generation
+	operationIDs := make([]string, 0, len(schemas))
+	for operationID := range schemas {
+		operationIDs = append(operationIDs, operationID)
+	}
+	sort.Strings(operationIDs)
+	texts := make([]string, len(operationIDs))
+	for i, operationID := range operationIDs {
+		schema := schemas[operationID].(map[string]interface{})
+		texts[i] = fmt.Sprintf("%s: %v", operationID, schema["description"])
+	}
+
+	provider := providers.NewMockProvider("voyage", providers.WithLatency(0))
+	service, err := embedding.NewServiceV2(embedding.ServiceV2Config{
+		Providers:    map[string]providers.Provider{"voyage": provider},
+		AgentService: batchAgentService{},
+		Repository:   embedding.NewRepository(nil),
+	})
+	require.NoError(t, err)
+
+	var indexed int
+	vectors, err := service.GenerateEmbeddingsWithOptions(context.Background(), texts, "", "mock-model-small", embedding.BatchEmbeddingOptions{
+		MaxConcurrency:   2,
+		ProgressCallback: func(completed, total int) { indexed = completed },
+	})
+	require.NoError(t, err)
+	require.Len(t, vectors, operations)
+	assert.Equal(t, operations, indexed)
+
+	// The spec is embedded in provider-sized batches rather than per operation
+	calls := provider.GetBatchGenerateCalls()
+	assert.Len(t, calls, 3)
+	for _, call := range calls {
+		assert.LessOrEqual(t, len(call.Texts), 128)
+	}
+	assert.Empty(t, provider.GetGenerateCalls())
+	for i, vector := range vectors {
+		assert.NotEmpty(t, vector.Vector, operationIDs[i])
+	}
+}
@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SecuritySchemesForOperation returns the security schemes an operation accepts,
+// in the order of its security requirements. Operations without requirements of
+// their own use the spec's global requirements; when neither declares any, every
+// scheme is returned ordered by name.
+func SecuritySchemesForOperation(spec *openapi3.T, operation *openapi3.Operation, schemes map[string]SecurityScheme) []SecurityScheme {
+	var requirements openapi3.SecurityRequirements
+	if operation != nil && operation.Security != nil {
+		requirements = *operation.Security
+	} else if spec != nil {
+		requirements = spec.Security
+	}
+
+	var names []string
+	if len(requirements) > 0 {
+		seen := make(map[string]bool)
+		for _, requirement := range requirements {
+			// Schemes within a requirement are listed in a map, so sort them
+			requirementNames := make([]string, 0, len(requirement))
+			for name := range requirement {
+				requirementNames = append(requirementNames, name)
+			}
+			sort.Strings(requirementNames)
+
+			for _, name := range requirementNames {
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+		}
+	} else {
+		for name := range schemes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	result := make([]SecurityScheme, 0, len(names))
+	for _, name := range names {
+		if scheme, ok := schemes[name]; ok {
+			result = append(result, scheme)
+		}
+	}
+	return result
+}
+
+// ApplySecuritySchemes applies credentials where and how the first compatible
+// security scheme expects them: an apiKey scheme places the token in its header,
+// query parameter or cookie, and http and oauth2 schemes use the Authorization
+// header. Credentials with an explicit custom header, or that no scheme accepts,
+// are applied by credential type instead.
+func (a *DynamicAuthenticator) ApplySecuritySchemes(req *http.Request, creds *models.TokenCredential, schemes []SecurityScheme) error {
+	if creds == nil {
+		return fmt.Errorf("no credentials provided")
+	}
+	if creds.Type == "custom_header" {
+		return a.ApplyAuthentication(req, creds)
+	}
+
+	scheme, ok := selectSecurityScheme(creds, schemes)
+	if !ok {
+		return a.ApplyAuthentication(req, creds)
+	}
+
+	switch scheme.Type {
+	case "apiKey":
+		name := scheme.ParamName
+		if name == "" {
+			name = scheme.Name
+		}
+		switch scheme.In {
+		case "query":
+			q := req.URL.Query()
+			q.Set(name, creds.Token)
+			req.URL.RawQuery = q.Encode()
+		case "cookie":
+			req.AddCookie(&http.Cookie{Name: name, Value: creds.Token})
+		default:
+			value := creds.Token
+			if creds.HeaderPrefix != "" {
+				value = creds.HeaderPrefix + " " + value
+			}
+			req.Header.Set(name, value)
+		}
+
+	case "http":
+		if strings.EqualFold(scheme.Scheme, "basic") {
+			req.SetBasicAuth(creds.Username, creds.Password)
+		} else {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", creds.Token))
+		}
+
+	default:
+		// oauth2 and openIdConnect access tokens are bearer tokens
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", creds.Token))
+	}
+
+	return nil
+}
+
+// selectSecurityScheme picks the scheme to apply credentials with. Schemes that
+// match the credential type are preferred over ones that merely accept a token.
+func selectSecurityScheme(creds *models.TokenCredential, schemes []SecurityScheme) (SecurityScheme, bool) {
+	var fallback *SecurityScheme
+	for i, scheme := range schemes {
+		preferred, accepted := schemeAcceptsCredentials(scheme, creds)
+		if preferred {
+			return scheme, true
+		}
+		if accepted && fallback == nil {
+			fallback = &schemes[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback, true
+	}
+	return SecurityScheme{}, false
+}
+
+// schemeAcceptsCredentials reports whether a scheme can carry the credentials,
+// and whether it's the natural fit for their type
+func schemeAcceptsCredentials(scheme SecurityScheme, creds *models.TokenCredential) (preferred, accepted bool) {
+	if creds.Type == "basic" {
+		match := scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic")
+		return match, match
+	}
+	if creds.Token == "" {
+		return false, false
+	}
+
+	switch scheme.Type {
+	case "apiKey":
+		return creds.Type == "api_key", true
+	case "http":
+		bearer := strings.EqualFold(scheme.Scheme, "bearer")
+		return bearer && creds.Type != "api_key", bearer
+	case "oauth2", "openIdConnect":
+		return creds.Type == "oauth2", true
+	}
+	return false, false
+}
@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/developer-mesh/developer-mesh/pkg/models"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// securitySpec builds a spec with a single operation protected by the given schemes
+func securitySpec(t *testing.T, schemes string, security string) (*openapi3.T, *openapi3.Operation) {
+	t.Helper()
+
+	data := fmt.Sprintf(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Provider", "version": "1.0"},
+		"paths": {
+			"/items": {"get": {"operationId": "listItems", "responses": {"200": {"description": "OK"}}}}
+		},
+		"components": {"securitySchemes": %s},
+		"security": %s
+	}`, schemes, security)
+
+	spec, err := openapi3.NewLoader().LoadFromData([]byte(data))
+	require.NoError(t, err)
+	return spec, spec.Paths.Find("/items").Get
+}
+
+func applySecuritySchemes(t *testing.T, spec *openapi3.T, operation *openapi3.Operation, creds *models.TokenCredential) *http.Request {
+	t.Helper()
+
+	auth := NewDynamicAuthenticator()
+	req, err := http.NewRequest("GET", "https://api.example.com/items?page=2", nil)
+	require.NoError(t, err)
+
+	schemes := SecuritySchemesForOperation(spec, operation, auth.ExtractSecuritySchemes(spec))
+	require.NoError(t, auth.ApplySecuritySchemes(req, creds, schemes))
+	return req
+}
+
+func TestApplySecuritySchemes(t *testing.T) {
+	token := &models.TokenCredential{Type: "api_key", Token: "secret"}
+
+	t.Run("apiKey in header", func(t *testing.T) {
+		spec, operation := securitySpec(t,
+			`{"apiKeyHeader": {"type": "apiKey", "in": "header", "name": "X-Provider-Token"}}`,
+			`[{"apiKeyHeader": []}]`)
+
+		req := applySecuritySchemes(t, spec, operation, token)
+		assert.Equal(t, "secret", req.Header.Get("X-Provider-Token"))
+		assert.Empty(t, req.Header.Get("Authorization"))
+		assert.Equal(t, "page=2", req.URL.RawQuery)
+	})
+
+	t.Run("apiKey in query", func(t *testing.T) {
+		spec, operation := securitySpec(t,
+			`{"apiKeyQuery": {"type": "apiKey", "in": "query", "name": "api_token"}}`,
+			`[{"apiKeyQuery": []}]`)
+
+		req := applySecuritySchemes(t, spec, operation, token)
+		assert.Equal(t, "secret", req.URL.Query().Get("api_token"))
+		assert.Equal(t, "2", req.URL.Query().Get("page"))
+		assert.Empty(t, req.Header.Get("X-API-Key"))
+	})
+
+	t.Run("http bearer", func(t *testing.T) {
+		spec, operation := securitySpec(t,
+			`{"bearerAuth": {"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}}`,
+			`[{"bearerAuth": []}]`)
+
+		for _, credType := range []string{"bearer", "token", "api_key"} {
+			req := applySecuritySchemes(t, spec, operation, &models.TokenCredential{Type: credType, Token: "secret"})
+			assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"), credType)
+			assert.Empty(t, req.Header.Get("X-API-Key"), credType)
+		}
+	})
+
+	t.Run("credential type picks between accepted schemes", func(t *testing.T) {
+		spec, operation := securitySpec(t, `{
+			"apiKeyQuery": {"type": "apiKey", "in": "query", "name": "api_token"},
+			"bearerAuth": {"type": "http", "scheme": "bearer"}
+		}`, `[{"apiKeyQuery": []}, {"bearerAuth": []}]`)
+
+		req := applySecuritySchemes(t, spec, operation, &models.TokenCredential{Type: "bearer", Token: "secret"})
+		assert.Equal(t, "Bearer secret", req.Header.Get("Authorization"))
+		assert.Empty(t, req.URL.Query().Get("api_token"))
+
+		req = applySecuritySchemes(t, spec, operation, token)
+		assert.Equal(t, "secret", req.URL.Query().Get("api_token"))
+		assert.Empty(t, req.Header.Get("Authorization"))
+	})
+
+	t.Run("operation requirements override global ones", func(t *testing.T) {
+		spec, operation := securitySpec(t, `{
+			"apiKeyHeader": {"type": "apiKey", "in": "header", "name": "X-Provider-Token"},
+			"apiKeyQuery": {"type": "apiKey", "in": "query", "name": "api_token"}
+		}`, `[{"apiKeyHeader": []}]`)
+		operation.Security = &openapi3.SecurityRequirements{{"apiKeyQuery": []string{}}}
+
+		req := applySecuritySchemes(t, spec, operation, token)
+		assert.Equal(t, "secret", req.URL.Query().Get("api_token"))
+		assert.Empty(t, req.Header.Get("X-Provider-Token"))
+	})
+
+	t.Run("basic credentials", func(t *testing.T) {
+		spec, operation := securitySpec(t, `{
+			"apiKeyHeader": {"type": "apiKey", "in": "header", "name": "X-Provider-Token"},
+			"basicAuth": {"type": "http", "scheme": "basic"}
+		}`, `[]`)
+
+		req := applySecuritySchemes(t, spec, operation, &models.TokenCredential{Type: "basic", Username: "user", Password: "pass"})
+		username, password, ok := req.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "user", username)
+		assert.Equal(t, "pass", password)
+		assert.Empty(t, req.Header.Get("X-Provider-Token"))
+	})
+
+	t.Run("falls back to the credential type without a compatible scheme", func(t *testing.T) {
+		spec, operation := securitySpec(t,
+			`{"basicAuth": {"type": "http", "scheme": "basic"}}`,
+			`[{"basicAuth": []}]`)
+
+		req := applySecuritySchemes(t, spec, operation, token)
+		assert.Equal(t, "secret", req.Header.Get("X-API-Key"))
+
+		custom := &models.TokenCredential{Type: "custom_header", Token: "secret", HeaderName: "X-Custom", HeaderPrefix: "Token"}
+		req = applySecuritySchemes(t, spec, operation, custom)
+		assert.Equal(t, "Token secret", req.Header.Get("X-Custom"))
+	})
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// Batch sizing defaults
+const (
+	DefaultBatchSize     = 10
+	DefaultTargetLatency = 5 * time.Second
+)
+
+// BatchSizingConfig bounds the number of events fetched per receive. With equal
+// bounds the batch size is fixed.
+type BatchSizingConfig struct {
+	MinBatchSize int32
+	MaxBatchSize int32
+	// TargetLatency is the longest a batch should take to process. Fetched events
+	// wait behind the rest of their batch, so slower batches mean events are
+	// fetched faster than they can be processed.
+	TargetLatency time.Duration
+}
+
+// BatchSizer adapts the batch size to processing throughput. It grows the batch
+// while full batches are processed well within the target latency and halves it
+// when a batch takes longer than the target.
+type BatchSizer struct {
+	mu     sync.Mutex
+	size   int32
+	config BatchSizingConfig
+}
+
+// NewBatchSizer creates a new batch sizer starting at the smaller of the
+// default batch size and the maximum
+func NewBatchSizer(config BatchSizingConfig) *BatchSizer {
+	if config.MinBatchSize <= 0 && config.MaxBatchSize <= 0 {
+		config.MinBatchSize = DefaultBatchSize
+		config.MaxBatchSize = DefaultBatchSize
+	}
+	if config.MinBatchSize <= 0 {
+		config.MinBatchSize = 1
+	}
+	if config.MaxBatchSize < config.MinBatchSize {
+		config.MaxBatchSize = config.MinBatchSize
+	}
+	if config.TargetLatency <= 0 {
+		config.TargetLatency = DefaultTargetLatency
+	}
+
+	size := int32(DefaultBatchSize)
+	if size > config.MaxBatchSize {
+		size = config.MaxBatchSize
+	}
+	if size < config.MinBatchSize {
+		size = config.MinBatchSize
+	}
+
+	return &BatchSizer{size: size, config: config}
+}
+
+// Size returns the number of events to fetch next
+func (b *BatchSizer) Size() int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.size
+}
+
+// Record adjusts the batch size after a batch of received events took
+// processing to handle, and returns the new size
+func (b *BatchSizer) Record(received int, processing time.Duration) int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case processing > b.config.TargetLatency:
+		// Processing lags behind fetching
+		b.size /= 2
+		if b.size < b.config.MinBatchSize {
+			b.size = b.config.MinBatchSize
+		}
+	case int32(received) >= b.size && processing <= b.config.TargetLatency/2:
+		// A full batch means more events are waiting, and there's headroom to take them
+		step := b.size / 4
+		if step < 1 {
+			step = 1
+		}
+		b.size += step
+		if b.size > b.config.MaxBatchSize {
+			b.size = b.config.MaxBatchSize
+		}
+	}
+
+	return b.size
+}
@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/developer-mesh/developer-mesh/pkg/queue"
+)
+
+func TestBatchSizer(t *testing.T) {
+	config := BatchSizingConfig{MinBatchSize: 2, MaxBatchSize: 40, TargetLatency: 100 * time.Millisecond}
+
+	t.Run("grows while full batches keep up", func(t *testing.T) {
+		sizer := NewBatchSizer(config)
+		assert.Equal(t, int32(DefaultBatchSize), sizer.Size())
+
+		previous := sizer.Size()
+		for i := 0; i < 20; i++ {
+			size := sizer.Record(int(sizer.Size()), 10*time.Millisecond)
+			assert.GreaterOrEqual(t, size, previous)
+			assert.LessOrEqual(t, size, config.MaxBatchSize)
+			previous = size
+		}
+		assert.Equal(t, config.MaxBatchSize, sizer.Size())
+	})
+
+	t.Run("shrinks when processing lags", func(t *testing.T) {
+		sizer := NewBatchSizer(config)
+		for i := 0; i < 10; i++ {
+			size := sizer.Record(int(sizer.Size()), 300*time.Millisecond)
+			assert.GreaterOrEqual(t, size, config.MinBatchSize)
+		}
+		assert.Equal(t, config.MinBatchSize, sizer.Size())
+	})
+
+	t.Run("partial batches don't grow", func(t *testing.T) {
+		sizer := NewBatchSizer(config)
+		assert.Equal(t, int32(DefaultBatchSize), sizer.Record(3, time.Millisecond))
+	})
+
+	t.Run("close to the target holds steady", func(t *testing.T) {
+		sizer := NewBatchSizer(config)
+		assert.Equal(t, int32(DefaultBatchSize), sizer.Record(DefaultBatchSize, 80*time.Millisecond))
+	})
+
+	t.Run("unset bounds keep the default size", func(t *testing.T) {
+		sizer := NewBatchSizer(BatchSizingConfig{})
+		sizer.Record(DefaultBatchSize, time.Millisecond)
+		assert.Equal(t, int32(DefaultBatchSize), sizer.Size())
+		sizer.Record(DefaultBatchSize, time.Minute)
+		assert.Equal(t, int32(DefaultBatchSize), sizer.Size())
+	})
+}
+
+func TestRedisWorker_AdaptsBatchSize(t *testing.T) {
+	run := func(t *testing.T, processingTime time.Duration) []int32 {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+
+		var mu sync.Mutex
+		var requested []int32
+		queueClient := &mockQueueClient{
+			// The queue always has a backlog, so every fetch returns a full batch
+			receiveFunc: func(ctx context.Context, max, wait int32) ([]queue.Event, []string, error) {
+				mu.Lock()
+				requested = append(requested, max)
+				mu.Unlock()
+
+				events := make([]queue.Event, max)
+				handles := make([]string, max)
+				for i := range events {
+					events[i] = queue.Event{EventID: fmt.Sprintf("event-%d", i)}
+					handles[i] = fmt.Sprintf("handle-%d", i)
+				}
+				return events, handles, nil
+			},
+			deleteFunc: func(ctx context.Context, handle string) error { return nil },
+		}
+		redisClient := &mockRedisClient{
+			existsFunc: func(ctx context.Context, key string) (int64, error) { return 0, nil },
+			setFunc:    func(ctx context.Context, key string, value string, ttl time.Duration) error { return nil },
+		}
+
+		worker, err := NewRedisWorker(&Config{
+			QueueClient: queueClient,
+			RedisClient: redisClient,
+			Processor: func(event queue.Event) error {
+				time.Sleep(processingTime)
+				return nil
+			},
+			BatchSizing: BatchSizingConfig{MinBatchSize: 1, MaxBatchSize: 32, TargetLatency: 20 * time.Millisecond},
+		})
+		require.NoError(t, err)
+
+		_ = worker.Run(ctx)
+
+		mu.Lock()
+		defer mu.Unlock()
+		return requested
+	}
+
+	t.Run("fast processing", func(t *testing.T) {
+		requested := run(t, 0)
+		require.NotEmpty(t, requested)
+		for _, size := range requested {
+			assert.GreaterOrEqual(t, size, int32(1))
+			assert.LessOrEqual(t, size, int32(32))
+		}
+		assert.Equal(t, int32(32), requested[len(requested)-1])
+	})
+
+	t.Run("slow processing", func(t *testing.T) {
+		requested := run(t, 5*time.Millisecond)
+		require.NotEmpty(t, requested)
+		for _, size := range requested {
+			assert.GreaterOrEqual(t, size, int32(1))
+			assert.LessOrEqual(t, size, int32(32))
+		}
+		assert.Less(t, requested[len(requested)-1], requested[0])
+	})
+}
@@ -29,6 +29,7 @@ type RedisWorker struct {
 	logger         observability.Logger
 	consumerName   string
 	idempotencyTTL time.Duration
+	batchSizer     *BatchSizer
 }
 
 // Config holds configuration for the Redis worker
@@ -39,6 +40,7 @@ type Config struct {
 	Logger         observability.Logger
 	ConsumerName   string
 	IdempotencyTTL time.Duration
+	BatchSizing    BatchSizingConfig
 }
 
 // NewRedisWorker creates a new Redis worker
@@ -69,6 +71,7 @@ func NewRedisWorker(config *Config) (*RedisWorker, error) {
 		logger:         config.Logger,
 		consumerName:   config.ConsumerName,
 		idempotencyTTL: config.IdempotencyTTL,
+		batchSizer:     NewBatchSizer(config.BatchSizing),
 	}, nil
 }
 
@@ -88,7 +91,8 @@ func (w *RedisWorker) Run(ctx context.Context) error {
 		}
 
 		// Receive events from Redis
-		events, handles, err := w.queueClient.ReceiveEvents(ctx, 10, 5)
+		batchSize := w.batchSizer.Size()
+		events, handles, err := w.queueClient.ReceiveEvents(ctx, batchSize, 5)
 		if err != nil {
 			w.logger.Error("Failed to receive events", map[string]interface{}{
 				"error": err.Error(),
@@ -99,6 +103,7 @@ func (w *RedisWorker) Run(ctx context.Context) error {
 		}
 
 		// Process each event
+		start := time.Now()
 		for i, event := range events {
 			if err := w.processEvent(ctx, event, handles[i]); err != nil {
 				w.logger.Error("Failed to process event", map[string]interface{}{
@@ -107,6 +112,15 @@ func (w *RedisWorker) Run(ctx context.Context) error {
 				})
 			}
 		}
+
+		if len(events) > 0 {
+			if newSize := w.batchSizer.Record(len(events), time.Since(start)); newSize != batchSize {
+				w.logger.Debug("Adjusted batch size", map[string]interface{}{
+					"previous": batchSize,
+					"current":  newSize,
+				})
+			}
+		}
 	}
 }
 